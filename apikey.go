@@ -0,0 +1,64 @@
+package storage
+
+import (
+	"errors"
+	"time"
+)
+
+// APIKeyPrefix starts every API key so that it can be told from access token
+// and found by secret scanners
+const APIKeyPrefix = "aspat_"
+
+const (
+	ScopeAudioRead  = "audio:read"
+	ScopeAudioWrite = "audio:write"
+	ScopeShareWrite = "share:write"
+)
+
+type APIKeyInput struct {
+	Name      string     `json:"name" binding:"required,max=100"`
+	Scopes    []string   `json:"scopes" binding:"required,min=1,dive,oneof=audio:read audio:write share:write"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+func (i APIKeyInput) Validate() error {
+	if i.ExpiresAt != nil && !i.ExpiresAt.After(time.Now()) {
+		return errors.New("expires_at must be in the future")
+	}
+
+	return nil
+}
+
+// APIKey describes key without its secret, Prefix is start of the key to
+// recognize it
+type APIKey struct {
+	Id         int        `json:"id" db:"api_key_id"`
+	Name       string     `json:"name" db:"name"`
+	Prefix     string     `json:"prefix" db:"prefix"`
+	Scopes     []string   `json:"scopes" db:"-"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at" db:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at" db:"last_used_at"`
+}
+
+// NewAPIKey is returned once on creation, only hash of Key is stored
+type NewAPIKey struct {
+	APIKey
+	Key string `json:"key"`
+}
+
+// APIKeyOwner is user authenticated by API key and scopes granted to the key
+type APIKeyOwner struct {
+	UserId int
+	Scopes []string
+}
+
+func (o APIKeyOwner) HasScope(scope string) bool {
+	for _, s := range o.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+
+	return false
+}
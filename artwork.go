@@ -0,0 +1,29 @@
+package storage
+
+const (
+	ArtworkSmall  = "small"
+	ArtworkMedium = "medium"
+	ArtworkLarge  = "large"
+
+	MaxArtworkUploadSize = 5 << 20
+	// MaxArtworkPixels limits decoded image so that small files can't take a lot of memory
+	MaxArtworkPixels = 8000 * 8000
+)
+
+// ArtworkSizes are max width and height of stored artwork sizes, smaller
+// images aren't upscaled
+var ArtworkSizes = map[string]int{
+	ArtworkSmall:  150,
+	ArtworkMedium: 600,
+	ArtworkLarge:  1200,
+}
+
+// Artwork is an image of one size, Id changes every time artwork is replaced
+type Artwork struct {
+	Id   string
+	Data []byte
+}
+
+type ArtworkParam struct {
+	Size string `json:"size" form:"size" binding:"omitempty,oneof='small' 'medium' 'large'" enums:"small,medium,large"`
+}
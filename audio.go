@@ -1,31 +1,78 @@
 package storage
 
-import "errors"
+import (
+	"errors"
+	"time"
+)
 
-const FileExt = ".aac"
+const (
+	FileExt   = ".aac"
+	FormatAac = "aac"
+)
 
 type Audio struct {
-	Id       int    `json:"id"`
-	UserId   int    `json:"user_id"`
-	Title    string `json:"title"`
-	Duration int    `json:"duration"`
-	FilePath string `json:"-"`
+	Id     int `json:"id" db:"audio_id"`
+	UserId int `json:"user_id" db:"user_id"`
+	AudioMetadata
+	AudioStream
+	FilePath   string    `json:"-" db:"file_path"`
+	UploadedBy int       `json:"uploaded_by" db:"uploaded_by"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at" db:"updated_at"`
+	Version    int       `json:"version" db:"version"`
 }
 
 type DownloadAudio struct {
 	Title    string `db:"title"`
+	Artist   string `db:"artist"`
+	Album    string `db:"album"`
 	FilePath string `db:"file_path"`
 }
 
-type UpdateAudio struct {
-	Title    *string `json:"title"`
-	Duration *int    `json:"duration"`
+// AudioTags are tags embedded in audio file like ID3 or APE, Duration in
+// seconds ends the last chapter when the tag is written
+type AudioTags struct {
+	Title    string
+	Artist   string
+	Album    string
+	Cover    []byte
+	Chapters []Chapter
+	Duration int
+}
+
+// StoredFile is audio stream saved to storage without embedded tags
+type StoredFile struct {
+	Size int64
+	Tags AudioTags
 }
 
 type AudioListParam struct {
-	Limit     *int   `json:"limit" form:"limit" binding:"required"`
-	Offset    *int   `json:"offset" form:"offset" binding:"required"`
-	OrderType string `json:"order_type" form:"order_type" binding:"required,oneof='owner' 'alphabet'" enums:"owner,alphabet"`
+	Limit        *int       `json:"limit" form:"limit" binding:"required,min=1,max=1000"`
+	Offset       *int       `json:"offset" form:"offset" binding:"omitempty,min=0"`
+	Cursor       string     `json:"cursor" form:"cursor"`
+	WithCount    bool       `json:"with_count" form:"with_count"`
+	OrderType    string     `json:"order_type" form:"order_type" binding:"omitempty,oneof='owner' 'alphabet'" enums:"owner,alphabet"`
+	Sort         string     `json:"sort" form:"sort" binding:"omitempty,oneof='created_at' 'updated_at' 'duration' 'size' 'title'" enums:"created_at,updated_at,duration,size,title"`
+	Direction    string     `json:"direction" form:"direction" binding:"omitempty,oneof='asc' 'desc'" enums:"asc,desc"`
+	Tags         []string   `json:"tag" form:"tag" binding:"max=20"`
+	TagMode      string     `json:"tag_mode" form:"tag_mode" binding:"omitempty,oneof='and' 'or'" enums:"and,or"`
+	Owner        string     `json:"owner" form:"owner" binding:"omitempty,oneof='me' 'others'" enums:"me,others"`
+	OwnerId      *int       `json:"owner_id" form:"owner_id"`
+	SharedState  string     `json:"shared_state" form:"shared_state" binding:"omitempty,oneof='shared' 'private' 'received'" enums:"shared,private,received"`
+	Format       string     `json:"format" form:"format" binding:"omitempty,oneof='aac'" enums:"aac"`
+	MinDuration  *int       `json:"min_duration" form:"min_duration" binding:"omitempty,min=0"`
+	MaxDuration  *int       `json:"max_duration" form:"max_duration" binding:"omitempty,min=0"`
+	CreatedFrom  *time.Time `json:"created_from" form:"created_from"`
+	CreatedTo    *time.Time `json:"created_to" form:"created_to"`
+	UpdatedFrom  *time.Time `json:"updated_from" form:"updated_from"`
+	UpdatedTo    *time.Time `json:"updated_to" form:"updated_to"`
+	Artist       string     `json:"artist" form:"artist"`
+	Album        string     `json:"album" form:"album"`
+	Language     string     `json:"language" form:"language"`
+	Location     string     `json:"location" form:"location"`
+	RecordedFrom *time.Time `json:"recorded_from" form:"recorded_from"`
+	RecordedTo   *time.Time `json:"recorded_to" form:"recorded_to"`
+	Custom       []string   `json:"custom" form:"custom" binding:"max=10"`
 }
 
 type ShareList struct {
@@ -34,12 +81,15 @@ type ShareList struct {
 }
 
 type AudioList struct {
-	Id      int          `json:"id" db:"audio_id"`
-	Title   string       `json:"name" db:"title"`
-	IsOwner bool         `json:"is_owner" db:"is_owner"`
-	Owner   int          `json:"owner_id" db:"user_id"`
-	Name    string       `json:"owner_name" db:"name"`
-	Shares  *[]ShareList `json:"shared_to,omitempty""`
+	Id         int          `json:"id" db:"audio_id"`
+	Title      string       `json:"name" db:"title"`
+	IsOwner    bool         `json:"is_owner" db:"is_owner"`
+	Owner      int          `json:"owner_id" db:"user_id"`
+	Name       string       `json:"owner_name" db:"name"`
+	UploadedBy int          `json:"uploaded_by" db:"uploaded_by"`
+	CreatedAt  time.Time    `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time    `json:"updated_at" db:"updated_at"`
+	Shares     *[]ShareList `json:"shared_to,omitempty"`
 }
 
 type AudioListJson struct {
@@ -47,15 +97,61 @@ type AudioListJson struct {
 	Records    []AudioList `json:"records"`
 }
 
+// AudioPageJson is a page of audio list in cursor mode, total count is
+// returned only on request
+type AudioPageJson struct {
+	TotalCount *int        `json:"total_count,omitempty"`
+	Records    []AudioList `json:"records"`
+	NextCursor string      `json:"next_cursor,omitempty"`
+	PrevCursor string      `json:"prev_cursor,omitempty"`
+}
+
+// AudioSortKeys are columns audio list can be sorted by besides AudioList fields
+type AudioSortKeys struct {
+	Duration int   `db:"duration"`
+	Size     int64 `db:"size"`
+}
+
 type AudioListDb struct {
-	Count int `db:"full_count"`
-	AudioList
-	ShareList
+	Count         int `db:"full_count"`
+	AudioList     `json:"-"`
+	ShareList     `json:"-"`
+	AudioSortKeys `json:"-"`
 }
 
-func (i UpdateAudio) Validate() error {
-	if i.Title == nil && i.Duration == nil {
-		return errors.New("update structure has no values")
+func (p AudioListParam) Validate() error {
+	if p.Offset != nil && (p.Cursor != "" || p.WithCount) {
+		return errors.New("offset can't be used with cursor or with_count")
+	}
+
+	if p.OrderType != "" && p.Sort != "" {
+		return errors.New("order_type and sort can't be used together")
+	}
+
+	if p.Owner != "" && p.OwnerId != nil {
+		return errors.New("owner and owner_id can't be used together")
+	}
+
+	if p.MinDuration != nil && p.MaxDuration != nil && *p.MinDuration > *p.MaxDuration {
+		return errors.New("min_duration is greater than max_duration")
+	}
+
+	if p.CreatedFrom != nil && p.CreatedTo != nil && p.CreatedFrom.After(*p.CreatedTo) {
+		return errors.New("created_from is after created_to")
+	}
+
+	if p.UpdatedFrom != nil && p.UpdatedTo != nil && p.UpdatedFrom.After(*p.UpdatedTo) {
+		return errors.New("updated_from is after updated_to")
+	}
+
+	if p.RecordedFrom != nil && p.RecordedTo != nil && p.RecordedFrom.After(*p.RecordedTo) {
+		return errors.New("recorded_from is after recorded_to")
+	}
+
+	for _, filter := range p.Custom {
+		if _, _, ok := SplitCustomFilter(filter); !ok {
+			return errors.New("custom filter must be key:value")
+		}
 	}
 
 	return nil
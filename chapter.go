@@ -0,0 +1,128 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+const (
+	MaxChapters         = 500
+	ChapterFormatJson   = "json"
+	ChapterFormatPSC    = "podlove"
+	ChapterFormatVTT    = "webvtt"
+	MaxChapterUrlLength = 2048
+)
+
+// Chapter starts at start_ms and lasts until the next chapter or the end of audio
+type Chapter struct {
+	StartMs int    `json:"start_ms" db:"start_ms"`
+	Title   string `json:"title" db:"title"`
+	Url     string `json:"url,omitempty" db:"url"`
+	Image   string `json:"image,omitempty" db:"image"`
+}
+
+type ChaptersInput struct {
+	Chapters []Chapter `json:"chapters" binding:"required"`
+}
+
+type ChapterParam struct {
+	Format string `form:"format" binding:"omitempty,oneof=json podlove webvtt"`
+}
+
+// AudioChapters holds chapters ordered by start_ms and audio duration in seconds
+type AudioChapters struct {
+	Duration int       `json:"-" db:"duration"`
+	Chapters []Chapter `json:"chapters"`
+}
+
+// PodloveChapter is a chapter of Podlove Simple Chapters JSON
+type PodloveChapter struct {
+	Start string `json:"start"`
+	Title string `json:"title"`
+	Href  string `json:"href,omitempty"`
+	Image string `json:"image,omitempty"`
+}
+
+func (i ChaptersInput) Validate() error {
+	if len(i.Chapters) > MaxChapters {
+		return fmt.Errorf("audio can't have more than %d chapters", MaxChapters)
+	}
+
+	starts := make(map[int]bool, len(i.Chapters))
+	for _, chapter := range i.Chapters {
+		if chapter.StartMs < 0 {
+			return errors.New("start_ms can't be negative")
+		}
+		if starts[chapter.StartMs] {
+			return fmt.Errorf("more than one chapter starts at %d ms", chapter.StartMs)
+		}
+		starts[chapter.StartMs] = true
+
+		if err := ValidateTitle(chapter.Title); err != nil {
+			return err
+		}
+		for name, value := range map[string]string{"url": chapter.Url, "image": chapter.Image} {
+			if err := validateChapterUrl(name, value); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Podlove returns chapters in Podlove Simple Chapters JSON representation
+func (c AudioChapters) Podlove() []PodloveChapter {
+	chapters := make([]PodloveChapter, len(c.Chapters))
+	for i, chapter := range c.Chapters {
+		chapters[i] = PodloveChapter{
+			Start: formatTimestamp(chapter.StartMs),
+			Title: chapter.Title,
+			Href:  chapter.Url,
+			Image: chapter.Image,
+		}
+	}
+
+	return chapters
+}
+
+// WebVTT returns chapters as WebVTT chapter cues, the last one ends with audio
+func (c AudioChapters) WebVTT() string {
+	var b strings.Builder
+	b.WriteString("WEBVTT\n")
+
+	for i, chapter := range c.Chapters {
+		end := c.Duration * 1000
+		if i+1 < len(c.Chapters) {
+			end = c.Chapters[i+1].StartMs
+		}
+		if end <= chapter.StartMs {
+			end = chapter.StartMs + 1
+		}
+
+		fmt.Fprintf(&b, "\n%d\n%s --> %s\n%s\n", i+1, formatTimestamp(chapter.StartMs), formatTimestamp(end),
+			strings.ReplaceAll(chapter.Title, "-->", "->"))
+	}
+
+	return b.String()
+}
+
+// formatTimestamp formats milliseconds as hh:mm:ss.ttt used by both formats
+func formatTimestamp(ms int) string {
+	return fmt.Sprintf("%02d:%02d:%02d.%03d", ms/3600000, ms/60000%60, ms/1000%60, ms%1000)
+}
+
+func validateChapterUrl(name, value string) error {
+	if value == "" {
+		return nil
+	}
+
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || len(value) > MaxChapterUrlLength {
+		return fmt.Errorf("%s must be http or https url up to %d characters", name, MaxChapterUrlLength)
+	}
+
+	return nil
+}
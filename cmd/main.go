@@ -1,6 +1,8 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"github.com/joho/godotenv"
 	_ "github.com/lib/pq"
 	storage "github.com/mahadeva604/audio-storage"
@@ -9,7 +11,10 @@ import (
 	"github.com/mahadeva604/audio-storage/pkg/service"
 	"github.com/spf13/viper"
 	"log"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 )
 
@@ -24,7 +29,10 @@ import (
 // @in header
 // @name Authorization
 
-const saveDir = "saved/"
+const (
+	saveDir         = "saved/"
+	shutdownTimeout = 30 * time.Second
+)
 
 func main() {
 
@@ -65,14 +73,187 @@ func main() {
 		log.Fatalf("Can't parse refresh token TTL: %s", err.Error())
 	}
 
+	keys, err := loadKeySet(secretKey)
+	if err != nil {
+		log.Fatalf("Can't load signing keys: %s", err.Error())
+	}
+
+	authConfig := service.AuthConfig{
+		AccessTokenTTL:  accessTokenTTL,
+		RefreshTokenTTL: refreshTokenTTL,
+		Issuer:          viper.GetString("auth.issuer"),
+		Audience:        viper.GetString("auth.audience"),
+		Leeway:          parseDuration("auth.leeway"),
+		Keys:            keys,
+	}
+
+	notifier, err := newNotifier()
+	if err != nil {
+		log.Fatalf("Can't create notifier: %s", err.Error())
+	}
+
+	passwordConfig := service.PasswordConfig{
+		ResetTokenTTL: parseDuration("passwordReset.tokenTTL"),
+		ResetURL:      viper.GetString("passwordReset.url"),
+		Notifier:      notifier,
+		Workers:       viper.GetInt("passwordReset.workers"),
+		QueueSize:     viper.GetInt("passwordReset.queueSize"),
+	}
+
+	downloadTTL, err := time.ParseDuration(viper.GetString("feed.downloadTTL"))
+	if err != nil {
+		log.Fatalf("Can't parse feed download TTL: %s", err.Error())
+	}
+
+	feedConfig := service.FeedConfig{
+		BaseURL:     viper.GetString("feed.baseURL"),
+		DownloadTTL: downloadTTL,
+	}
+
+	transcriptionTimeout, err := time.ParseDuration(viper.GetString("transcription.timeout"))
+	if err != nil {
+		log.Fatalf("Can't parse transcription timeout: %s", err.Error())
+	}
+
+	transcriptionConfig := service.TranscriptionConfig{
+		Timeout: transcriptionTimeout,
+	}
+	if command := viper.GetString("transcription.command"); command != "" {
+		transcriptionConfig.Provider = service.NewCommandProvider(command, viper.GetStringSlice("transcription.args"))
+	}
+
+	jobConfig := service.JobConfig{
+		Workers:         viper.GetInt("jobs.workers"),
+		MaxAttempts:     viper.GetInt("jobs.maxAttempts"),
+		PollInterval:    parseDuration("jobs.pollInterval"),
+		RetryBackoff:    parseDuration("jobs.retryBackoff"),
+		MaxRetryBackoff: parseDuration("jobs.maxRetryBackoff"),
+		Lease:           parseDuration("jobs.lease"),
+		CleanupInterval: parseDuration("jobs.cleanupInterval"),
+		CleanupGrace:    parseDuration("jobs.cleanupGrace"),
+		Transcription:   transcriptionConfig,
+	}
+
 	repos := repository.NewRepository(db, saveDir)
-	services := service.NewService(repos, secretKey, accessTokenTTL, refreshTokenTTL)
+
+	if err := repos.SetSearchLanguage(viper.GetString("search.language")); err != nil {
+		log.Fatalf("Can't set search language: %s", err.Error())
+	}
+
+	services := service.NewService(repos, secretKey, authConfig, passwordConfig, feedConfig, jobConfig)
 	handlers := handler.NewHandler(services)
 
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	jobsDone := make(chan struct{})
+	go func() {
+		services.Job.Run(jobsCtx)
+		close(jobsDone)
+	}()
+
 	srv := new(storage.Server)
 
-	if err := srv.Run(viper.GetString("port"), handlers.InitRoutes()); err != nil {
-		log.Fatalf("Can't run http server: %s", err.Error())
+	go func() {
+		if err := srv.Run(viper.GetString("port"), handlers.InitRoutes()); err != nil && err != http.ErrServerClosed {
+			log.Fatalf("Can't run http server: %s", err.Error())
+		}
+	}()
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGTERM, syscall.SIGINT)
+	<-quit
+
+	log.Print("Shutting down")
+
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		log.Printf("Can't shut down http server: %s", err.Error())
+	}
+
+	// running jobs are finished, the rest stay queued for the next start
+	stopJobs()
+	<-jobsDone
+
+	// queued password reset tokens are sent before exit
+	services.Password.Close()
+
+	if err := db.Close(); err != nil {
+		log.Printf("Can't close db connection: %s", err.Error())
+	}
+}
+
+func parseDuration(key string) time.Duration {
+	duration, err := time.ParseDuration(viper.GetString(key))
+	if err != nil {
+		log.Fatalf("Can't parse %s: %s", key, err.Error())
+	}
+	return duration
+}
+
+type keyConfig struct {
+	Id   string `mapstructure:"id"`
+	Alg  string `mapstructure:"alg"`
+	File string `mapstructure:"file"`
+}
+
+// loadKeySet loads access token signing key and keys of tokens signed before
+// rotation, HS256 keys use SECRET_KEY and others are read from PEM files
+func loadKeySet(secretKey []byte) (service.KeySet, error) {
+	var signing keyConfig
+	if err := viper.UnmarshalKey("auth.signingKey", &signing); err != nil {
+		return service.KeySet{}, err
+	}
+
+	var verification []keyConfig
+	if err := viper.UnmarshalKey("auth.verificationKeys", &verification); err != nil {
+		return service.KeySet{}, err
+	}
+
+	signingKey, err := loadSigningKey(signing, secretKey)
+	if err != nil {
+		return service.KeySet{}, err
+	}
+
+	verificationKeys := make([]service.SigningKey, 0, len(verification))
+	for _, cfg := range verification {
+		key, err := loadSigningKey(cfg, secretKey)
+		if err != nil {
+			return service.KeySet{}, err
+		}
+		verificationKeys = append(verificationKeys, key)
+	}
+
+	return service.NewKeySet(signingKey, verificationKeys...)
+}
+
+func loadSigningKey(cfg keyConfig, secretKey []byte) (service.SigningKey, error) {
+	if cfg.Alg == "" || cfg.Alg == "HS256" {
+		return service.NewHMACKey(cfg.Id, secretKey), nil
+	}
+
+	data, err := os.ReadFile(cfg.File)
+	if err != nil {
+		return service.SigningKey{}, err
+	}
+
+	return service.ParseSigningKey(cfg.Id, cfg.Alg, data)
+}
+
+// newNotifier returns notifier password reset tokens are sent with
+func newNotifier() (service.Notifier, error) {
+	switch notifier := viper.GetString("passwordReset.notifier"); notifier {
+	case "log":
+		return service.LogNotifier{}, nil
+	case "smtp":
+		return service.NewSMTPNotifier(service.SMTPConfig{
+			Host:     viper.GetString("passwordReset.smtp.host"),
+			Port:     viper.GetString("passwordReset.smtp.port"),
+			Username: viper.GetString("passwordReset.smtp.username"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+			From:     viper.GetString("passwordReset.smtp.from"),
+		}), nil
+	default:
+		return nil, fmt.Errorf("unknown notifier %q", notifier)
 	}
 }
 
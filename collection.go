@@ -0,0 +1,61 @@
+package storage
+
+type CollectionInput struct {
+	Title string `json:"title" binding:"required"`
+}
+
+type CollectionListParam struct {
+	Limit     *int   `json:"limit" form:"limit" binding:"required"`
+	Offset    *int   `json:"offset" form:"offset" binding:"required"`
+	OrderType string `json:"order_type" form:"order_type" binding:"required,oneof='owner' 'alphabet'" enums:"owner,alphabet"`
+}
+
+type CollectionList struct {
+	Id         int    `json:"id" db:"collection_id"`
+	Title      string `json:"title" db:"title"`
+	IsOwner    bool   `json:"is_owner" db:"is_owner"`
+	Owner      int    `json:"owner_id" db:"user_id"`
+	Name       string `json:"owner_name" db:"name"`
+	ItemsCount int    `json:"items_count" db:"items_count"`
+}
+
+type CollectionListDb struct {
+	Count int `db:"full_count"`
+	CollectionList
+}
+
+type CollectionListJson struct {
+	TotalCount int              `json:"total_count"`
+	Records    []CollectionList `json:"records"`
+}
+
+type CollectionItemsParam struct {
+	Limit  *int `json:"limit" form:"limit" binding:"required"`
+	Offset *int `json:"offset" form:"offset" binding:"required"`
+}
+
+type CollectionItem struct {
+	Id       int    `json:"id" db:"audio_id"`
+	Title    string `json:"title" db:"title"`
+	Owner    int    `json:"owner_id" db:"user_id"`
+	Name     string `json:"owner_name" db:"name"`
+	Position int    `json:"position" db:"position"`
+}
+
+type CollectionItemDb struct {
+	Count int `db:"full_count"`
+	CollectionItem
+}
+
+type CollectionItemsJson struct {
+	TotalCount int              `json:"total_count"`
+	Records    []CollectionItem `json:"records"`
+}
+
+type CollectionItemInput struct {
+	AudioId int `json:"audio_id" binding:"required"`
+}
+
+type CollectionOrderInput struct {
+	AudioIds []int `json:"audio_ids" binding:"required"`
+}
@@ -0,0 +1,105 @@
+package storage
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+const MaxCommentLength = 4096
+
+// Comment is anchored to start_ms or start_ms to end_ms range of audio,
+// replies have no offset of their own
+type Comment struct {
+	Id        int       `json:"id" db:"comment_id"`
+	ParentId  *int      `json:"parent_id,omitempty" db:"parent_id"`
+	UserId    int       `json:"user_id" db:"user_id"`
+	Name      string    `json:"user_name" db:"name"`
+	StartMs   *int      `json:"start_ms,omitempty" db:"start_ms"`
+	EndMs     *int      `json:"end_ms,omitempty" db:"end_ms"`
+	Text      string    `json:"text" db:"text"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+	Replies   []Comment `json:"replies,omitempty" db:"-"`
+}
+
+type CommentInput struct {
+	Text     string `json:"text" binding:"required"`
+	StartMs  *int   `json:"start_ms"`
+	EndMs    *int   `json:"end_ms"`
+	ParentId *int   `json:"parent_id"`
+}
+
+// CommentUpdate changes text, offset can be changed only for top level comments
+type CommentUpdate struct {
+	Text    string `json:"text" binding:"required"`
+	StartMs *int   `json:"start_ms"`
+	EndMs   *int   `json:"end_ms"`
+}
+
+type CommentListParam struct {
+	Limit  *int `json:"limit" form:"limit" binding:"required,min=1"`
+	Offset *int `json:"offset" form:"offset" binding:"required,min=0"`
+}
+
+// CommentListJson holds a page of top level comments ordered by offset with their replies
+type CommentListJson struct {
+	TotalCount int       `json:"total_count"`
+	Records    []Comment `json:"records"`
+}
+
+type CommentDb struct {
+	Count int `db:"full_count"`
+	Comment
+}
+
+func (i CommentInput) Validate() error {
+	if err := validateCommentText(i.Text); err != nil {
+		return err
+	}
+
+	if i.ParentId != nil {
+		if i.StartMs != nil || i.EndMs != nil {
+			return CommentReplyOffset
+		}
+		return nil
+	}
+
+	if i.StartMs == nil {
+		return errors.New("start_ms is required for comment without parent_id")
+	}
+
+	return validateCommentRange(i.StartMs, i.EndMs)
+}
+
+func (i CommentUpdate) Validate() error {
+	if err := validateCommentText(i.Text); err != nil {
+		return err
+	}
+
+	if i.StartMs == nil && i.EndMs != nil {
+		return errors.New("end_ms can't be set without start_ms")
+	}
+
+	return validateCommentRange(i.StartMs, i.EndMs)
+}
+
+func validateCommentText(text string) error {
+	if strings.TrimSpace(text) == "" {
+		return errors.New("comment text can't be empty")
+	}
+
+	return validateLength("text", text, MaxCommentLength)
+}
+
+func validateCommentRange(start, end *int) error {
+	if start != nil && *start < 0 {
+		return errors.New("start_ms can't be negative")
+	}
+
+	if end != nil && *end < *start {
+		return errors.New("end_ms is before start_ms")
+	}
+
+	return nil
+}
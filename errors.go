@@ -10,3 +10,61 @@ var ShareUserNotExists = errors.New("user you share with not exists")
 var NotOwner = errors.New("you are not owner or audio not exists")
 var NotAacFile = errors.New("file is not Aac")
 var WrongRefreshToken = errors.New("token not found or expires in")
+var InvitationNotFound = errors.New("invitation not found")
+var SenderNotExists = errors.New("user not exists")
+var BlockExists = errors.New("user already blocked")
+var BlockNotFound = errors.New("user is not blocked")
+var AutoAcceptExists = errors.New("user already in auto accept list")
+var AutoAcceptNotFound = errors.New("user is not in auto accept list")
+var CollectionNotFound = errors.New("collection not found or you haven't access")
+var NotCollectionOwner = errors.New("you are not owner or collection not exists")
+var CollectionItemExists = errors.New("audio already in collection")
+var CollectionItemNotFound = errors.New("audio not in collection or you are not owner")
+var CollectionItemNotAllowed = errors.New("you are not owner of collection or haven't access to audio")
+var WrongCollectionOrder = errors.New("order must contain every audio of collection exactly once")
+var FeedNotFound = errors.New("feed not found")
+var WrongSignature = errors.New("download link is wrong or expired")
+var InvalidFeed = errors.New("feed is invalid")
+var InvalidTag = errors.New("tag must be from 1 to 64 characters")
+var TagNotFound = errors.New("tag not found")
+var InvalidCursor = errors.New("cursor is invalid or made for another sort order")
+var InvalidTitle = errors.New("title must be from 1 to 256 characters without control characters")
+var InvalidDuration = errors.New("duration can't be negative")
+var InvalidLanguage = errors.New("language must be a language tag like en or en-US")
+var VersionMismatch = errors.New("audio was changed by someone else, get it again and retry")
+var InvalidMetadataSchema = errors.New("metadata schema is not a valid JSON schema")
+var MetadataSchemaNotFound = errors.New("metadata schema not found")
+var InvalidCustomFields = errors.New("custom fields are invalid")
+var InvalidArtwork = errors.New("artwork must be PNG, JPEG or WebP image")
+var ArtworkNotFound = errors.New("artwork not found or you haven't access")
+var CommentNotFound = errors.New("comment not found or you haven't access")
+var NotCommentAuthor = errors.New("you are not author of the comment")
+var CommentReplyOffset = errors.New("replies belong to thread and can't have start_ms or end_ms")
+var InvalidTranscript = errors.New("transcript must be WebVTT or SRT file")
+var TranscriptNotFound = errors.New("transcript not found or you haven't access")
+var TranscriptionNotFound = errors.New("transcription job not found or you haven't access")
+var TranscriptionActive = errors.New("transcription of audio is already queued or running")
+var TranscriptionDisabled = errors.New("speech-to-text provider is not configured")
+var JobNotFound = errors.New("job not found")
+var JobActive = errors.New("job of the same type is already queued or running")
+var JobNotDead = errors.New("only dead jobs can be retried")
+var ChecksumMismatch = errors.New("stored file doesn't match its checksum")
+var HLSNotFound = errors.New("HLS playlist or segment not found")
+var NotAdmin = errors.New("admin rights are required")
+var TokenRevoked = errors.New("token is revoked")
+var SessionNotFound = errors.New("session not found")
+var RefreshTokenReused = errors.New("refresh token was already used, its session is revoked")
+var TokenExpired = errors.New("token is expired")
+var TokenNotValidYet = errors.New("token is not valid yet")
+var APIKeyNotFound = errors.New("api key not found")
+var InvalidAPIKey = errors.New("api key is invalid or expired")
+var InsufficientScope = errors.New("api key doesn't have required scope")
+var APIKeyNotAllowed = errors.New("api keys can't be used here")
+var WrongPassword = errors.New("password is wrong")
+var InvalidResetToken = errors.New("password reset token is invalid, used or expired")
+var SelfBlock = errors.New("can't block yourself")
+var SelfAutoAccept = errors.New("can't auto accept shares from yourself")
+var SelfShareCollection = errors.New("can't share own collection to yourself")
+var SelfUnshareCollection = errors.New("can't unshare own collection from yourself")
+var JobLeaseLost = errors.New("job lease is lost, the job was reaped or claimed again")
+var ResetQueueFull = errors.New("too many password reset requests, try again later")
@@ -0,0 +1,155 @@
+package storage
+
+import (
+	"encoding/xml"
+	"errors"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const (
+	FeedContentType  = "application/rss+xml; charset=utf-8"
+	EpisodeMimeType  = "audio/aac"
+	itunesNamespace  = "http://www.itunes.com/dtds/podcast-1.0.dtd"
+	feedDateLayout   = time.RFC1123Z
+	rssVersion       = "2.0"
+	guidPrefix       = "audio-storage-"
+	notPermaLinkGuid = "false"
+)
+
+type FeedInput struct {
+	Description string `json:"description" binding:"required"`
+	Author      string `json:"author"`
+	ImageUrl    string `json:"image_url" binding:"required,url"`
+	Language    string `json:"language" binding:"required"`
+	Category    string `json:"category" binding:"required"`
+	Explicit    bool   `json:"explicit"`
+}
+
+type FeedSettings struct {
+	Token       string `json:"-" db:"token"`
+	Url         string `json:"url" db:"-"`
+	Description string `json:"description" db:"description"`
+	Author      string `json:"author" db:"author"`
+	ImageUrl    string `json:"image_url" db:"image_url"`
+	Language    string `json:"language" db:"language"`
+	Category    string `json:"category" db:"category"`
+	Explicit    bool   `json:"explicit" db:"explicit"`
+}
+
+type FeedUrlResponse struct {
+	Url string `json:"url"`
+}
+
+type FeedChannel struct {
+	CollectionId int    `db:"collection_id"`
+	Title        string `db:"title"`
+	OwnerName    string `db:"name"`
+	FeedSettings
+}
+
+type FeedEpisode struct {
+	AudioId  int       `db:"audio_id"`
+	Title    string    `db:"title"`
+	Duration int       `db:"duration"`
+	FilePath string    `db:"file_path"`
+	Size     int64     `db:"size"`
+	AddedAt  time.Time `db:"added_at"`
+}
+
+type RssFeed struct {
+	XMLName  xml.Name   `xml:"rss"`
+	Version  string     `xml:"version,attr"`
+	ItunesNS string     `xml:"xmlns:itunes,attr"`
+	Channel  RssChannel `xml:"channel"`
+}
+
+type RssChannel struct {
+	Title          string            `xml:"title"`
+	Link           string            `xml:"link"`
+	Description    string            `xml:"description"`
+	Language       string            `xml:"language"`
+	ItunesAuthor   string            `xml:"itunes:author"`
+	ItunesImage    RssItunesImage    `xml:"itunes:image"`
+	ItunesCategory RssItunesCategory `xml:"itunes:category"`
+	ItunesExplicit string            `xml:"itunes:explicit"`
+	Items          []RssItem         `xml:"item"`
+}
+
+type RssItunesImage struct {
+	Href string `xml:"href,attr"`
+}
+
+type RssItunesCategory struct {
+	Text string `xml:"text,attr"`
+}
+
+type RssItem struct {
+	Title          string       `xml:"title"`
+	Enclosure      RssEnclosure `xml:"enclosure"`
+	Guid           RssGuid      `xml:"guid"`
+	PubDate        string       `xml:"pubDate"`
+	ItunesDuration int          `xml:"itunes:duration"`
+}
+
+type RssEnclosure struct {
+	Url    string `xml:"url,attr"`
+	Length int64  `xml:"length,attr"`
+	Type   string `xml:"type,attr"`
+}
+
+type RssGuid struct {
+	IsPermaLink string `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+func NewRssFeed(channel RssChannel) RssFeed {
+	return RssFeed{Version: rssVersion, ItunesNS: itunesNamespace, Channel: channel}
+}
+
+func NewRssItem(episode FeedEpisode, enclosureUrl string) RssItem {
+	return RssItem{
+		Title:          episode.Title,
+		Enclosure:      RssEnclosure{Url: enclosureUrl, Length: episode.Size, Type: EpisodeMimeType},
+		Guid:           RssGuid{IsPermaLink: notPermaLinkGuid, Value: guidPrefix + strconv.Itoa(episode.AudioId)},
+		PubDate:        episode.AddedAt.Format(feedDateLayout),
+		ItunesDuration: episode.Duration,
+	}
+}
+
+// Validate checks the tags Apple Podcasts requires for a show and its
+// episodes. Artwork size can't be checked without fetching the image
+func (f RssFeed) Validate() error {
+	ch := f.Channel
+	if ch.Title == "" || ch.Description == "" || ch.Language == "" || ch.ItunesCategory.Text == "" {
+		return errors.New("feed must have title, description, language and category")
+	}
+
+	if !absoluteUrl(ch.ItunesImage.Href) {
+		return errors.New("feed artwork must be an absolute url")
+	}
+
+	if ch.ItunesExplicit != "true" && ch.ItunesExplicit != "false" {
+		return errors.New("feed explicit must be true or false")
+	}
+
+	for _, item := range ch.Items {
+		if item.Title == "" {
+			return errors.New("every episode must have title")
+		}
+		if !absoluteUrl(item.Enclosure.Url) || item.Enclosure.Length <= 0 || item.Enclosure.Type == "" {
+			return errors.New("every episode must have enclosure with url, length and type")
+		}
+		if item.Guid.Value == "" {
+			return errors.New("every episode must have guid")
+		}
+	}
+
+	return nil
+}
+
+func absoluteUrl(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
@@ -0,0 +1,40 @@
+package storage
+
+import "time"
+
+const (
+	HistoryFieldTitle       = "title"
+	HistoryFieldDuration    = "duration"
+	HistoryFieldDescription = "description"
+	HistoryFieldArtist      = "artist"
+	HistoryFieldAlbum       = "album"
+	HistoryFieldRecordedAt  = "recorded_at"
+	HistoryFieldLanguage    = "language"
+	HistoryFieldLocation    = "location"
+	HistoryFieldCustom      = "custom"
+)
+
+type HistoryListParam struct {
+	Limit  *int `json:"limit" form:"limit" binding:"required,min=1"`
+	Offset *int `json:"offset" form:"offset" binding:"required,min=0"`
+}
+
+// HistoryRecord is one changed field of audio, values are kept as text
+type HistoryRecord struct {
+	Field     string    `json:"field" db:"field"`
+	OldValue  string    `json:"old_value" db:"old_value"`
+	NewValue  string    `json:"new_value" db:"new_value"`
+	UserId    int       `json:"user_id" db:"user_id"`
+	Name      string    `json:"user_name" db:"name"`
+	ChangedAt time.Time `json:"changed_at" db:"changed_at"`
+}
+
+type HistoryListJson struct {
+	TotalCount int             `json:"total_count"`
+	Records    []HistoryRecord `json:"records"`
+}
+
+type HistoryRecordDb struct {
+	Count int `db:"full_count"`
+	HistoryRecord
+}
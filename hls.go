@@ -0,0 +1,18 @@
+package storage
+
+import (
+	"regexp"
+	"time"
+)
+
+const (
+	HLSPlaylist        = "index.m3u8"
+	HLSSegmentDuration = 6 * time.Second
+)
+
+var hlsFileName = regexp.MustCompile(`^(index\.m3u8|segment\d{5}\.aac)$`)
+
+// HLSFile reports if name is the playlist or a segment of HLS package
+func HLSFile(name string) bool {
+	return hlsFileName.MatchString(name)
+}
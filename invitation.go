@@ -0,0 +1,40 @@
+package storage
+
+const (
+	InvitationAudio      = "audio"
+	InvitationCollection = "collection"
+)
+
+// Invitation is pending share of audio or collection, id of the other kind is omitted
+type Invitation struct {
+	Type         string `json:"type" db:"type"`
+	AudioId      int    `json:"audio_id,omitempty" db:"audio_id"`
+	CollectionId int    `json:"collection_id,omitempty" db:"collection_id"`
+	Title        string `json:"title" db:"title"`
+	OwnerId      int    `json:"owner_id" db:"user_id"`
+	OwnerName    string `json:"owner_name" db:"name"`
+}
+
+type InvitationListParam struct {
+	Limit  *int `json:"limit" form:"limit" binding:"required"`
+	Offset *int `json:"offset" form:"offset" binding:"required"`
+}
+
+type InvitationListDb struct {
+	Count int `db:"full_count"`
+	Invitation
+}
+
+type InvitationListJson struct {
+	TotalCount  int          `json:"total_count"`
+	Invitations []Invitation `json:"invitations"`
+}
+
+type SenderInput struct {
+	UserId int `json:"user_id" binding:"required"`
+}
+
+type Sender struct {
+	UserId int    `json:"id" db:"user_id"`
+	Name   string `json:"name" db:"name"`
+}
@@ -0,0 +1,105 @@
+package storage
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	JobProbe    = "probe"
+	JobRemux    = "remux"
+	JobHLS      = "hls"
+	JobChecksum = "checksum"
+	JobCleanup  = "cleanup"
+	// JobTranscribe replaces transcript of audio with speech-to-text result
+	JobTranscribe = "transcribe"
+
+	JobQueued  = "queued"
+	JobRunning = "running"
+	JobDone    = "done"
+	JobDead    = "dead"
+)
+
+// Job is a background task of audio, cleanup jobs have no audio. Failed jobs
+// are retried with backoff until MaxAttempts, then they are dead
+type Job struct {
+	Id          int       `json:"id" db:"job_id"`
+	Type        string    `json:"type" db:"type" enums:"probe,remux,hls,checksum,cleanup,transcribe"`
+	AudioId     *int      `json:"audio_id,omitempty" db:"audio_id"`
+	Status      string    `json:"status" db:"status" enums:"queued,running,done,dead"`
+	Attempts    int       `json:"attempts" db:"attempts"`
+	MaxAttempts int       `json:"max_attempts" db:"max_attempts"`
+	Error       string    `json:"error,omitempty" db:"error"`
+	RunAt       time.Time `json:"run_at" db:"run_at"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// JobTask is a claimed job with the file of its audio. ClaimToken changes
+// with every claim, only the worker holding it may finish the job
+type JobTask struct {
+	Job
+	ClaimToken string  `db:"claim_token"`
+	UserId     *int    `db:"user_id"`
+	FilePath   *string `db:"file_path"`
+	Checksum   *string `db:"checksum"`
+}
+
+type JobInput struct {
+	Type    string `json:"type" binding:"required,oneof=probe remux hls checksum cleanup transcribe"`
+	AudioId *int   `json:"audio_id"`
+}
+
+type JobListParam struct {
+	Status string `json:"status" form:"status" binding:"omitempty,oneof=queued running done dead"`
+	Type   string `json:"type" form:"type" binding:"omitempty,oneof=probe remux hls checksum cleanup transcribe"`
+	Limit  *int   `json:"limit" form:"limit" binding:"required,min=1"`
+	Offset *int   `json:"offset" form:"offset" binding:"required,min=0"`
+}
+
+type JobListJson struct {
+	TotalCount int   `json:"total_count"`
+	Records    []Job `json:"records"`
+}
+
+type JobDb struct {
+	Count int `db:"full_count"`
+	Job
+}
+
+// AudioStream is technical data of stored file filled by background jobs
+type AudioStream struct {
+	SampleRate *int    `json:"sample_rate,omitempty" db:"sample_rate"`
+	Channels   *int    `json:"channels,omitempty" db:"channels"`
+	Bitrate    *int    `json:"bitrate,omitempty" db:"bitrate"`
+	Checksum   *string `json:"checksum,omitempty" db:"checksum"`
+}
+
+// AudioProbe is result of probing ADTS stream, duration is in seconds and
+// bitrate in bits per second
+type AudioProbe struct {
+	Duration   int
+	SampleRate int
+	Channels   int
+	Bitrate    int
+}
+
+// OrphanFiles are stored files which may be unreferenced, names are uuids
+// and temporary files are paths
+type OrphanFiles struct {
+	Audio   []string
+	Artwork []string
+	HLS     []string
+	Temp    []string
+}
+
+func (i JobInput) Validate() error {
+	if i.Type == JobCleanup && i.AudioId != nil {
+		return errors.New("cleanup job can't have audio_id")
+	}
+	if i.Type != JobCleanup && i.AudioId == nil {
+		return errors.New("audio_id is required for " + i.Type + " job")
+	}
+
+	return nil
+}
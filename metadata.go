@@ -0,0 +1,330 @@
+package storage
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	MaxTitleLength       = 256
+	MaxDescriptionLength = 4096
+	MaxMetadataLength    = 256
+	MaxCustomFields      = 50
+	MaxCustomKeyLength   = 64
+)
+
+// languageTag matches BCP 47 like tags: en, en-US, zh-Hant-TW
+var languageTag = regexp.MustCompile(`^[A-Za-z]{2,3}(-[A-Za-z0-9]{1,8})*$`)
+
+// CustomFields are user defined key/value pairs of audio stored as JSONB
+type CustomFields map[string]interface{}
+
+func (f CustomFields) Value() (driver.Value, error) {
+	data, err := json.Marshal(f)
+	return string(data), err
+}
+
+func (f *CustomFields) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case []byte:
+		return json.Unmarshal(v, f)
+	case string:
+		return json.Unmarshal([]byte(v), f)
+	case nil:
+		*f = nil
+		return nil
+	}
+
+	return fmt.Errorf("can't scan %T into custom fields", src)
+}
+
+func (f CustomFields) MarshalJSON() ([]byte, error) {
+	if f == nil {
+		return []byte("{}"), nil
+	}
+
+	return json.Marshal(map[string]interface{}(f))
+}
+
+// AudioMetadata holds editable fields of audio
+type AudioMetadata struct {
+	Title       string       `json:"title" db:"title"`
+	Duration    int          `json:"duration" db:"duration"`
+	Description string       `json:"description" db:"description"`
+	Artist      string       `json:"artist" db:"artist"`
+	Album       string       `json:"album" db:"album"`
+	RecordedAt  *time.Time   `json:"recorded_at" db:"recorded_at"`
+	Language    string       `json:"language" db:"language"`
+	Location    string       `json:"location" db:"location"`
+	Custom      CustomFields `json:"custom" db:"custom" swaggertype:"object"`
+}
+
+// UpdateAudio is a partial update, nil fields are left untouched
+type UpdateAudio struct {
+	Title       *string      `json:"title"`
+	Duration    *int         `json:"duration"`
+	Description *string      `json:"description"`
+	Artist      *string      `json:"artist"`
+	Album       *string      `json:"album"`
+	RecordedAt  *time.Time   `json:"recorded_at"`
+	Language    *string      `json:"language"`
+	Location    *string      `json:"location"`
+	Custom      CustomFields `json:"custom" swaggertype:"object"`
+	// ClearRecordedAt and ClearCustom are set by null in merge patch
+	ClearRecordedAt bool `json:"-"`
+	ClearCustom     bool `json:"-"`
+}
+
+// ReplaceAudio is a full update, missing optional fields are cleared
+type ReplaceAudio struct {
+	Title       *string      `json:"title" binding:"required"`
+	Duration    *int         `json:"duration" binding:"required"`
+	Description string       `json:"description"`
+	Artist      string       `json:"artist"`
+	Album       string       `json:"album"`
+	RecordedAt  *time.Time   `json:"recorded_at"`
+	Language    string       `json:"language"`
+	Location    string       `json:"location"`
+	Custom      CustomFields `json:"custom" swaggertype:"object"`
+}
+
+type MetadataSchema struct {
+	Schema json.RawMessage `json:"schema" binding:"required" swaggertype:"object"`
+}
+
+// UnmarshalJSON follows JSON Merge Patch: absent members are left untouched
+// and null removes a member. Title and duration can't be removed, null in
+// custom removes a key
+func (i *UpdateAudio) UnmarshalJSON(data []byte) error {
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(data, &members); err != nil {
+		return err
+	}
+
+	texts := map[string]**string{
+		"description": &i.Description,
+		"artist":      &i.Artist,
+		"album":       &i.Album,
+		"language":    &i.Language,
+		"location":    &i.Location,
+	}
+
+	for name, value := range members {
+		null := string(value) == "null"
+		if field, ok := texts[name]; ok {
+			var s string
+			if !null {
+				if err := json.Unmarshal(value, &s); err != nil {
+					return err
+				}
+			}
+			*field = &s
+			continue
+		}
+
+		switch name {
+		case "title":
+			if null {
+				return errors.New("title can't be removed")
+			}
+			if err := json.Unmarshal(value, &i.Title); err != nil {
+				return err
+			}
+		case "duration":
+			if null {
+				return errors.New("duration can't be removed")
+			}
+			if err := json.Unmarshal(value, &i.Duration); err != nil {
+				return err
+			}
+		case "recorded_at":
+			i.ClearRecordedAt = null
+			if err := json.Unmarshal(value, &i.RecordedAt); err != nil {
+				return err
+			}
+		case "custom":
+			i.ClearCustom = null
+			if err := json.Unmarshal(value, &i.Custom); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (i UpdateAudio) Validate() error {
+	if i.Title == nil && i.Duration == nil && i.Description == nil && i.Artist == nil && i.Album == nil &&
+		i.RecordedAt == nil && !i.ClearRecordedAt && i.Language == nil && i.Location == nil && !i.TouchesCustom() {
+		return errors.New("update structure has no values")
+	}
+
+	if i.Title != nil {
+		if err := ValidateTitle(*i.Title); err != nil {
+			return err
+		}
+	}
+
+	if i.Duration != nil && *i.Duration < 0 {
+		return InvalidDuration
+	}
+
+	if i.Description != nil {
+		if err := validateLength("description", *i.Description, MaxDescriptionLength); err != nil {
+			return err
+		}
+	}
+
+	for name, value := range map[string]*string{"artist": i.Artist, "album": i.Album, "location": i.Location} {
+		if value != nil {
+			if err := validateLength(name, *value, MaxMetadataLength); err != nil {
+				return err
+			}
+		}
+	}
+
+	if i.Language != nil && *i.Language != "" && !languageTag.MatchString(*i.Language) {
+		return InvalidLanguage
+	}
+
+	for key := range i.Custom {
+		if key == "" || utf8.RuneCountInString(key) > MaxCustomKeyLength {
+			return fmt.Errorf("custom keys must be from 1 to %d characters", MaxCustomKeyLength)
+		}
+	}
+
+	return nil
+}
+
+// Apply returns metadata with update applied
+func (i UpdateAudio) Apply(m AudioMetadata) AudioMetadata {
+	if i.Title != nil {
+		m.Title = *i.Title
+	}
+	if i.Duration != nil {
+		m.Duration = *i.Duration
+	}
+	if i.Description != nil {
+		m.Description = *i.Description
+	}
+	if i.Artist != nil {
+		m.Artist = *i.Artist
+	}
+	if i.Album != nil {
+		m.Album = *i.Album
+	}
+	if i.RecordedAt != nil || i.ClearRecordedAt {
+		m.RecordedAt = i.RecordedAt
+	}
+	if i.Language != nil {
+		m.Language = *i.Language
+	}
+	if i.Location != nil {
+		m.Location = *i.Location
+	}
+
+	if i.TouchesCustom() {
+		custom := CustomFields{}
+		if !i.ClearCustom {
+			for key, value := range m.Custom {
+				custom[key] = value
+			}
+		}
+		for key, value := range i.Custom {
+			if value == nil {
+				delete(custom, key)
+			} else {
+				custom[key] = value
+			}
+		}
+		m.Custom = custom
+	}
+
+	return m
+}
+
+// TouchesCustom reports if update changes custom fields
+func (i UpdateAudio) TouchesCustom() bool {
+	return i.Custom != nil || i.ClearCustom
+}
+
+func (m AudioMetadata) Validate() error {
+	if len(m.Custom) > MaxCustomFields {
+		return fmt.Errorf("%w: audio can't have more than %d of them", InvalidCustomFields, MaxCustomFields)
+	}
+
+	return nil
+}
+
+func (i ReplaceAudio) Update() UpdateAudio {
+	return UpdateAudio{
+		Title:           i.Title,
+		Duration:        i.Duration,
+		Description:     &i.Description,
+		Artist:          &i.Artist,
+		Album:           &i.Album,
+		RecordedAt:      i.RecordedAt,
+		Language:        &i.Language,
+		Location:        &i.Location,
+		Custom:          i.Custom,
+		ClearRecordedAt: i.RecordedAt == nil,
+		ClearCustom:     true,
+	}
+}
+
+// Metadata returns tags as audio metadata, values not valid as metadata are dropped
+func (t AudioTags) Metadata() AudioMetadata {
+	var m AudioMetadata
+	if ValidateTitle(t.Title) == nil {
+		m.Title = t.Title
+	}
+	if validateLength("artist", t.Artist, MaxMetadataLength) == nil {
+		m.Artist = t.Artist
+	}
+	if validateLength("album", t.Album, MaxMetadataLength) == nil {
+		m.Album = t.Album
+	}
+
+	return m
+}
+
+// SplitCustomFilter splits key:value filter of custom fields
+func SplitCustomFilter(filter string) (string, string, bool) {
+	parts := strings.SplitN(filter, ":", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return "", "", false
+	}
+
+	return parts[0], parts[1], true
+}
+
+// ValidateTitle checks title is valid UTF-8 of 1 to MaxTitleLength characters
+// without control characters and not blank
+func ValidateTitle(title string) error {
+	if !utf8.ValidString(title) || strings.TrimSpace(title) == "" || utf8.RuneCountInString(title) > MaxTitleLength {
+		return InvalidTitle
+	}
+
+	for _, r := range title {
+		if unicode.IsControl(r) {
+			return InvalidTitle
+		}
+	}
+
+	return nil
+}
+
+func validateLength(name, value string, max int) error {
+	if !utf8.ValidString(value) || utf8.RuneCountInString(value) > max {
+		return fmt.Errorf("%s must be up to %d characters", name, max)
+	}
+
+	return nil
+}
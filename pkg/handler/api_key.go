@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	storage "github.com/mahadeva604/audio-storage"
+	"net/http"
+	"strconv"
+)
+
+// @Summary Get API keys
+// @Security ApiKeyAuth
+// @Tags api-key
+// @Description get your API keys, the keys themselves are never shown again
+// @ID get-api-keys
+// @Produce  json
+// @Success 200 {array} storage.APIKey
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /api/me/api-keys [get]
+func (h *Handler) getAPIKeys(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	keys, err := h.services.GetAPIKeys(userId)
+	if err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, keys)
+}
+
+// @Summary Create API key
+// @Security ApiKeyAuth
+// @Tags api-key
+// @Description create key for scripts, send it as bearer token. The key is shown only in this response
+// @ID create-api-key
+// @Accept  json
+// @Produce  json
+// @Param input body storage.APIKeyInput true "key name, scopes and optional expiry"
+// @Success 200 {object} storage.NewAPIKey
+// @Failure 400 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /api/me/api-keys [post]
+func (h *Handler) createAPIKey(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	var input storage.APIKeyInput
+	if err := c.BindJSON(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid input body")
+		return
+	}
+
+	if err := input.Validate(); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	key, err := h.services.CreateAPIKey(userId, input)
+	if err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, key)
+}
+
+// @Summary Delete API key
+// @Security ApiKeyAuth
+// @Tags api-key
+// @Description revoke API key, it stops working immediately
+// @ID delete-api-key
+// @Produce  json
+// @Param id path int true "api key id"
+// @Success 200 {object} statusResponse
+// @Failure 400,404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /api/me/api-keys/{id} [delete]
+func (h *Handler) deleteAPIKey(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	keyId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid api key id param")
+		return
+	}
+
+	if err := h.services.DeleteAPIKey(userId, keyId); err != nil {
+		newAPIKeyErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, statusResponse{"ok"})
+}
+
+func newAPIKeyErrorResponse(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, storage.APIKeyNotFound):
+		newErrorResponse(c, http.StatusNotFound, err.Error())
+	default:
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+	}
+}
@@ -0,0 +1,211 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	storage "github.com/mahadeva604/audio-storage"
+	"github.com/mahadeva604/audio-storage/pkg/service"
+	mock_service "github.com/mahadeva604/audio-storage/pkg/service/mocks"
+	"github.com/stretchr/testify/assert"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHandler_getAPIKeys(t *testing.T) {
+	at := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	testTable := []struct {
+		name                 string
+		mockBehavior         func(s *mock_service.MockAPIKey)
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name: "OK",
+			mockBehavior: func(s *mock_service.MockAPIKey) {
+				s.EXPECT().GetAPIKeys(1).Return([]storage.APIKey{
+					{Id: 2, Name: "backup", Prefix: "aspat_AbCdEf", Scopes: []string{"audio:read"}, CreatedAt: at},
+				}, nil)
+			},
+			expectedStatusCode: 200,
+			expectedResponseBody: `[{"id":2,"name":"backup","prefix":"aspat_AbCdEf","scopes":["audio:read"],` +
+				`"created_at":"2021-06-01T12:00:00Z","expires_at":null,"last_used_at":null}]`,
+		},
+		{
+			name: "Service error",
+			mockBehavior: func(s *mock_service.MockAPIKey) {
+				s.EXPECT().GetAPIKeys(1).Return(nil, errors.New("service error"))
+			},
+			expectedStatusCode:   500,
+			expectedResponseBody: `{"message":"service error"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			apiKey := mock_service.NewMockAPIKey(c)
+			testCase.mockBehavior(apiKey)
+
+			handler := NewHandler(&service.Service{APIKey: apiKey})
+
+			r := gin.New()
+			r.GET("/me/api-keys", func(c *gin.Context) {
+				c.Set(userCtx, 1)
+			}, handler.getAPIKeys)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/me/api-keys", nil)
+
+			r.ServeHTTP(w, req)
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedResponseBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_createAPIKey(t *testing.T) {
+	at := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	input := storage.APIKeyInput{Name: "backup", Scopes: []string{"audio:read"}}
+
+	testTable := []struct {
+		name                 string
+		inputBody            string
+		mockBehavior         func(s *mock_service.MockAPIKey)
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:      "OK",
+			inputBody: `{"name":"backup","scopes":["audio:read"]}`,
+			mockBehavior: func(s *mock_service.MockAPIKey) {
+				s.EXPECT().CreateAPIKey(1, input).Return(storage.NewAPIKey{
+					APIKey: storage.APIKey{Id: 2, Name: "backup", Prefix: "aspat_AbCdEf", Scopes: []string{"audio:read"}, CreatedAt: at},
+					Key:    "aspat_AbCdEfGh",
+				}, nil)
+			},
+			expectedStatusCode: 200,
+			expectedResponseBody: `{"id":2,"name":"backup","prefix":"aspat_AbCdEf","scopes":["audio:read"],` +
+				`"created_at":"2021-06-01T12:00:00Z","expires_at":null,"last_used_at":null,"key":"aspat_AbCdEfGh"}`,
+		},
+		{
+			name:                 "No scopes",
+			inputBody:            `{"name":"backup","scopes":[]}`,
+			mockBehavior:         func(s *mock_service.MockAPIKey) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"invalid input body"}`,
+		},
+		{
+			name:                 "Unknown scope",
+			inputBody:            `{"name":"backup","scopes":["admin"]}`,
+			mockBehavior:         func(s *mock_service.MockAPIKey) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"invalid input body"}`,
+		},
+		{
+			name:                 "Expired",
+			inputBody:            `{"name":"backup","scopes":["audio:read"],"expires_at":"2021-06-01T12:00:00Z"}`,
+			mockBehavior:         func(s *mock_service.MockAPIKey) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"expires_at must be in the future"}`,
+		},
+		{
+			name:      "Service error",
+			inputBody: `{"name":"backup","scopes":["audio:read"]}`,
+			mockBehavior: func(s *mock_service.MockAPIKey) {
+				s.EXPECT().CreateAPIKey(1, input).Return(storage.NewAPIKey{}, errors.New("service error"))
+			},
+			expectedStatusCode:   500,
+			expectedResponseBody: `{"message":"service error"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			apiKey := mock_service.NewMockAPIKey(c)
+			testCase.mockBehavior(apiKey)
+
+			handler := NewHandler(&service.Service{APIKey: apiKey})
+
+			r := gin.New()
+			r.POST("/me/api-keys", func(c *gin.Context) {
+				c.Set(userCtx, 1)
+			}, handler.createAPIKey)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/me/api-keys", bytes.NewBufferString(testCase.inputBody))
+
+			r.ServeHTTP(w, req)
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedResponseBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_deleteAPIKey(t *testing.T) {
+	testTable := []struct {
+		name                 string
+		keyId                string
+		mockBehavior         func(s *mock_service.MockAPIKey)
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:  "OK",
+			keyId: "2",
+			mockBehavior: func(s *mock_service.MockAPIKey) {
+				s.EXPECT().DeleteAPIKey(1, 2).Return(nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"status":"ok"}`,
+		},
+		{
+			name:                 "Invalid id",
+			keyId:                "key",
+			mockBehavior:         func(s *mock_service.MockAPIKey) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"invalid api key id param"}`,
+		},
+		{
+			name:  "Not found",
+			keyId: "2",
+			mockBehavior: func(s *mock_service.MockAPIKey) {
+				s.EXPECT().DeleteAPIKey(1, 2).Return(storage.APIKeyNotFound)
+			},
+			expectedStatusCode:   404,
+			expectedResponseBody: `{"message":"` + storage.APIKeyNotFound.Error() + `"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			apiKey := mock_service.NewMockAPIKey(c)
+			testCase.mockBehavior(apiKey)
+
+			handler := NewHandler(&service.Service{APIKey: apiKey})
+
+			r := gin.New()
+			r.DELETE("/me/api-keys/:id", func(c *gin.Context) {
+				c.Set(userCtx, 1)
+			}, handler.deleteAPIKey)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("DELETE", "/me/api-keys/"+testCase.keyId, nil)
+
+			r.ServeHTTP(w, req)
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedResponseBody, w.Body.String())
+		})
+	}
+}
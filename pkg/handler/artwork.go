@@ -0,0 +1,250 @@
+package handler
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	storage "github.com/mahadeva604/audio-storage"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+// @Summary Get audio artwork
+// @Security ApiKeyAuth
+// @Tags artwork
+// @Description get artwork of audio you have access to, 304 is returned if If-None-Match matches ETag
+// @ID get-audio-artwork
+// @Produce  image/jpeg,image/png
+// @Param id path int true "audio id"
+// @Param size query string false "artwork size, large by default" Enums(small,medium,large)
+// @Param If-None-Match header string false "ETag of cached artwork"
+// @Success 200 "artwork image"
+// @Header 200 {string} ETag "artwork version"
+// @Failure 400,404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /api/audio/{id}/artwork [get]
+func (h *Handler) getAudioArtwork(c *gin.Context) {
+	userId, audioId, ok := getAudioParams(c)
+	if !ok {
+		return
+	}
+
+	var input storage.ArtworkParam
+	if err := c.BindQuery(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid query")
+		return
+	}
+
+	artwork, err := h.services.GetAudioArtwork(userId, audioId, input.Size)
+	if err != nil {
+		newArtworkErrorResponse(c, err)
+		return
+	}
+
+	sendArtwork(c, artwork, input.Size)
+}
+
+// @Summary Set audio artwork
+// @Security ApiKeyAuth
+// @Tags artwork
+// @Description set artwork of own audio, it is resized to small, medium and large sizes
+// @ID set-audio-artwork
+// @Accept multipart/form-data
+// @Produce  json
+// @Param id path int true "audio id"
+// @Param file formData file true "PNG, JPEG or WebP image"
+// @Success 200 {object} statusResponse
+// @Failure 400,404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /api/audio/{id}/artwork [put]
+func (h *Handler) setAudioArtwork(c *gin.Context) {
+	userId, audioId, ok := getAudioParams(c)
+	if !ok {
+		return
+	}
+
+	image, ok := readArtwork(c)
+	if !ok {
+		return
+	}
+
+	if err := h.services.SetAudioArtwork(userId, audioId, image); err != nil {
+		newArtworkErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, statusResponse{"ok"})
+}
+
+// @Summary Delete audio artwork
+// @Security ApiKeyAuth
+// @Tags artwork
+// @Description delete artwork of own audio
+// @ID delete-audio-artwork
+// @Produce  json
+// @Param id path int true "audio id"
+// @Success 200 {object} statusResponse
+// @Failure 400,404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /api/audio/{id}/artwork [delete]
+func (h *Handler) deleteAudioArtwork(c *gin.Context) {
+	userId, audioId, ok := getAudioParams(c)
+	if !ok {
+		return
+	}
+
+	if err := h.services.DeleteAudioArtwork(userId, audioId); err != nil {
+		newArtworkErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, statusResponse{"ok"})
+}
+
+// @Summary Get collection artwork
+// @Security ApiKeyAuth
+// @Tags artwork
+// @Description get artwork of own or shared collection, 304 is returned if If-None-Match matches ETag
+// @ID get-collection-artwork
+// @Produce  image/jpeg,image/png
+// @Param id path int true "collection id"
+// @Param size query string false "artwork size, large by default" Enums(small,medium,large)
+// @Param If-None-Match header string false "ETag of cached artwork"
+// @Success 200 "artwork image"
+// @Header 200 {string} ETag "artwork version"
+// @Failure 400,404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /api/collections/{id}/artwork [get]
+func (h *Handler) getCollectionArtwork(c *gin.Context) {
+	userId, collectionId, ok := getCollectionParams(c)
+	if !ok {
+		return
+	}
+
+	var input storage.ArtworkParam
+	if err := c.BindQuery(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid query")
+		return
+	}
+
+	artwork, err := h.services.GetCollectionArtwork(userId, collectionId, input.Size)
+	if err != nil {
+		newArtworkErrorResponse(c, err)
+		return
+	}
+
+	sendArtwork(c, artwork, input.Size)
+}
+
+// @Summary Set collection artwork
+// @Security ApiKeyAuth
+// @Tags artwork
+// @Description set artwork of own collection, it is resized to small, medium and large sizes
+// @ID set-collection-artwork
+// @Accept multipart/form-data
+// @Produce  json
+// @Param id path int true "collection id"
+// @Param file formData file true "PNG, JPEG or WebP image"
+// @Success 200 {object} statusResponse
+// @Failure 400,404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /api/collections/{id}/artwork [put]
+func (h *Handler) setCollectionArtwork(c *gin.Context) {
+	userId, collectionId, ok := getCollectionParams(c)
+	if !ok {
+		return
+	}
+
+	image, ok := readArtwork(c)
+	if !ok {
+		return
+	}
+
+	if err := h.services.SetCollectionArtwork(userId, collectionId, image); err != nil {
+		newArtworkErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, statusResponse{"ok"})
+}
+
+// @Summary Delete collection artwork
+// @Security ApiKeyAuth
+// @Tags artwork
+// @Description delete artwork of own collection
+// @ID delete-collection-artwork
+// @Produce  json
+// @Param id path int true "collection id"
+// @Success 200 {object} statusResponse
+// @Failure 400,404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /api/collections/{id}/artwork [delete]
+func (h *Handler) deleteCollectionArtwork(c *gin.Context) {
+	userId, collectionId, ok := getCollectionParams(c)
+	if !ok {
+		return
+	}
+
+	if err := h.services.DeleteCollectionArtwork(userId, collectionId); err != nil {
+		newArtworkErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, statusResponse{"ok"})
+}
+
+func readArtwork(c *gin.Context) ([]byte, bool) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, storage.MaxArtworkUploadSize)
+
+	file, _, err := c.Request.FormFile("file")
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return nil, false
+	}
+	defer file.Close()
+
+	image, err := io.ReadAll(file)
+	if err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return nil, false
+	}
+
+	return image, true
+}
+
+// sendArtwork writes artwork with ETag, the same artwork id and size always
+// have the same content so clients only have to revalidate it
+func sendArtwork(c *gin.Context, artwork storage.Artwork, size string) {
+	if size == "" {
+		size = storage.ArtworkLarge
+	}
+
+	etag := strconv.Quote(artwork.Id + "-" + size)
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", "private, no-cache")
+
+	if c.GetHeader("If-None-Match") == etag {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	c.Data(http.StatusOK, http.DetectContentType(artwork.Data), artwork.Data)
+}
+
+func newArtworkErrorResponse(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, storage.ArtworkNotFound), errors.Is(err, storage.NotOwner),
+		errors.Is(err, storage.NotCollectionOwner):
+		newErrorResponse(c, http.StatusNotFound, err.Error())
+	case errors.Is(err, storage.InvalidArtwork):
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+	default:
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+	}
+}
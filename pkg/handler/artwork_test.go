@@ -0,0 +1,234 @@
+package handler
+
+import (
+	"bytes"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	storage "github.com/mahadeva604/audio-storage"
+	"github.com/mahadeva604/audio-storage/pkg/service"
+	mock_service "github.com/mahadeva604/audio-storage/pkg/service/mocks"
+	"github.com/stretchr/testify/assert"
+	"mime/multipart"
+	"net/http/httptest"
+	"testing"
+)
+
+var testPNG = []byte("\x89PNG\r\n\x1a\nimage")
+
+func TestHandler_getAudioArtwork(t *testing.T) {
+	type mockBehavior func(s *mock_service.MockArtwork)
+
+	testTable := []struct {
+		name                 string
+		target               string
+		ifNoneMatch          string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedHeaders      map[string]string
+		expectedResponseBody string
+	}{
+		{
+			name:   "OK",
+			target: "/audio/2/artwork?size=small",
+			mockBehavior: func(s *mock_service.MockArtwork) {
+				s.EXPECT().GetAudioArtwork(1, 2, storage.ArtworkSmall).Return(storage.Artwork{Id: "id", Data: testPNG}, nil)
+			},
+			expectedStatusCode: 200,
+			expectedHeaders: map[string]string{
+				"Content-Type":  "image/png",
+				"ETag":          `"id-small"`,
+				"Cache-Control": "private, no-cache",
+			},
+			expectedResponseBody: string(testPNG),
+		},
+		{
+			name:        "Not modified",
+			target:      "/audio/2/artwork",
+			ifNoneMatch: `"id-large"`,
+			mockBehavior: func(s *mock_service.MockArtwork) {
+				s.EXPECT().GetAudioArtwork(1, 2, "").Return(storage.Artwork{Id: "id", Data: testPNG}, nil)
+			},
+			expectedStatusCode: 304,
+			expectedHeaders:    map[string]string{"ETag": `"id-large"`},
+		},
+		{
+			name:                 "Invalid size",
+			target:               "/audio/2/artwork?size=huge",
+			mockBehavior:         func(s *mock_service.MockArtwork) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"invalid query"}`,
+		},
+		{
+			name:                 "Invalid audio id",
+			target:               "/audio/wrong/artwork",
+			mockBehavior:         func(s *mock_service.MockArtwork) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"invalid audio id param"}`,
+		},
+		{
+			name:   "Not found",
+			target: "/audio/2/artwork",
+			mockBehavior: func(s *mock_service.MockArtwork) {
+				s.EXPECT().GetAudioArtwork(1, 2, "").Return(storage.Artwork{}, storage.ArtworkNotFound)
+			},
+			expectedStatusCode:   404,
+			expectedResponseBody: `{"message":"artwork not found or you haven't access"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			artwork := mock_service.NewMockArtwork(c)
+			testCase.mockBehavior(artwork)
+
+			handler := NewHandler(&service.Service{Artwork: artwork})
+
+			r := gin.New()
+			r.GET("/audio/:id/artwork", func(c *gin.Context) {
+				c.Set(userCtx, 1)
+			}, handler.getAudioArtwork)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", testCase.target, nil)
+			if testCase.ifNoneMatch != "" {
+				req.Header.Set("If-None-Match", testCase.ifNoneMatch)
+			}
+
+			r.ServeHTTP(w, req)
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			for header, value := range testCase.expectedHeaders {
+				assert.Equal(t, value, w.Header().Get(header))
+			}
+			assert.Equal(t, testCase.expectedResponseBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_setCollectionArtwork(t *testing.T) {
+	type mockBehavior func(s *mock_service.MockArtwork)
+
+	testTable := []struct {
+		name                 string
+		formKey              string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:    "OK",
+			formKey: "file",
+			mockBehavior: func(s *mock_service.MockArtwork) {
+				s.EXPECT().SetCollectionArtwork(1, 3, testPNG).Return(nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"status":"ok"}`,
+		},
+		{
+			name:                 "No file",
+			formKey:              "image",
+			mockBehavior:         func(s *mock_service.MockArtwork) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"http: no such file"}`,
+		},
+		{
+			name:    "Invalid artwork",
+			formKey: "file",
+			mockBehavior: func(s *mock_service.MockArtwork) {
+				s.EXPECT().SetCollectionArtwork(1, 3, testPNG).Return(storage.InvalidArtwork)
+			},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"artwork must be PNG, JPEG or WebP image"}`,
+		},
+		{
+			name:    "Not owner",
+			formKey: "file",
+			mockBehavior: func(s *mock_service.MockArtwork) {
+				s.EXPECT().SetCollectionArtwork(1, 3, testPNG).Return(storage.NotCollectionOwner)
+			},
+			expectedStatusCode:   404,
+			expectedResponseBody: `{"message":"you are not owner or collection not exists"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			artwork := mock_service.NewMockArtwork(c)
+			testCase.mockBehavior(artwork)
+
+			handler := NewHandler(&service.Service{Artwork: artwork})
+
+			r := gin.New()
+			r.PUT("/collections/:id/artwork", func(c *gin.Context) {
+				c.Set(userCtx, 1)
+			}, handler.setCollectionArtwork)
+
+			body := &bytes.Buffer{}
+			writer := multipart.NewWriter(body)
+			part, err := writer.CreateFormFile(testCase.formKey, "cover.png")
+			if err != nil {
+				t.Fatal(err)
+			}
+			part.Write(testPNG)
+			writer.Close()
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("PUT", "/collections/3/artwork", body)
+			req.Header.Set("Content-Type", writer.FormDataContentType())
+
+			r.ServeHTTP(w, req)
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedResponseBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_deleteAudioArtwork(t *testing.T) {
+	testTable := []struct {
+		name                 string
+		err                  error
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:                 "OK",
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"status":"ok"}`,
+		},
+		{
+			name:                 "Not owner",
+			err:                  storage.NotOwner,
+			expectedStatusCode:   404,
+			expectedResponseBody: `{"message":"you are not owner or audio not exists"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			artwork := mock_service.NewMockArtwork(c)
+			artwork.EXPECT().DeleteAudioArtwork(1, 2).Return(testCase.err)
+
+			handler := NewHandler(&service.Service{Artwork: artwork})
+
+			r := gin.New()
+			r.DELETE("/audio/:id/artwork", func(c *gin.Context) {
+				c.Set(userCtx, 1)
+			}, handler.deleteAudioArtwork)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("DELETE", "/audio/2/artwork", nil)
+
+			r.ServeHTTP(w, req)
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedResponseBody, w.Body.String())
+		})
+	}
+}
@@ -1,12 +1,16 @@
 package handler
 
 import (
+	"errors"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	storage "github.com/mahadeva604/audio-storage"
+	"github.com/sirupsen/logrus"
+	"io"
 	"net/http"
 
 	"strconv"
+	"strings"
 )
 
 const (
@@ -16,14 +20,37 @@ const (
 // @Summary Get audio list
 // @Security ApiKeyAuth
 // @Tags audio
-// @Description get audio list
+// @Description get audio list. Without offset pages are navigated with next_cursor and prev_cursor and total count is returned only with with_count. Offset mode returns storage.AudioListJson
 // @ID get-all-audio
 // @Accept  json
 // @Produce  json
-// @Param offset query integer true "offset" minimum(0)
-// @Param limit query integer true "limit"  minimum(1)
-// @Param order_type query string true "order type" Enums(owner,alphabet)
-// @Success 200 {object} storage.AudioListJson
+// @Param offset query integer false "offset, switches to offset mode" minimum(0)
+// @Param limit query integer true "limit"  minimum(1) maximum(1000)
+// @Param cursor query string false "next_cursor or prev_cursor of previous page"
+// @Param with_count query boolean false "return total count in cursor mode"
+// @Param order_type query string false "legacy order type, default owner" Enums(owner,alphabet)
+// @Param sort query string false "sort field, can't be used with order_type" Enums(created_at,updated_at,duration,size,title)
+// @Param direction query string false "sort direction, default asc for title and desc for others" Enums(asc,desc)
+// @Param tag query []string false "filter by tags" collectionFormat(multi)
+// @Param tag_mode query string false "all tags must match or any of them, default and" Enums(and,or)
+// @Param owner query string false "only own audio or only audio of others" Enums(me,others)
+// @Param owner_id query integer false "only audio of user"
+// @Param shared_state query string false "own audio shared with someone, own audio not shared or audio shared with you" Enums(shared,private,received)
+// @Param format query string false "file format" Enums(aac)
+// @Param min_duration query integer false "min duration" minimum(0)
+// @Param max_duration query integer false "max duration" minimum(0)
+// @Param created_from query string false "created at or after, RFC3339" format(date-time)
+// @Param created_to query string false "created at or before, RFC3339" format(date-time)
+// @Param updated_from query string false "updated at or after, RFC3339" format(date-time)
+// @Param updated_to query string false "updated at or before, RFC3339" format(date-time)
+// @Param artist query string false "artist, case insensitive"
+// @Param album query string false "album or series, case insensitive"
+// @Param language query string false "language tag, case insensitive"
+// @Param location query string false "location, case insensitive"
+// @Param recorded_from query string false "recorded at or after, RFC3339" format(date-time)
+// @Param recorded_to query string false "recorded at or before, RFC3339" format(date-time)
+// @Param custom query []string false "custom field equals value, key:value" collectionFormat(multi)
+// @Success 200 {object} storage.AudioPageJson
 // @Failure 400 {object} errorResponse
 // @Failure 500 {object} errorResponse
 // @Failure default {object} errorResponse
@@ -41,7 +68,60 @@ func (h *Handler) getAllAudio(c *gin.Context) {
 		return
 	}
 
-	result, err := h.services.GetAudioList(userId, input)
+	if err := input.Validate(); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var result interface{}
+	if input.Offset != nil {
+		result, err = h.services.GetAudioList(userId, input)
+	} else {
+		result, err = h.services.GetAudioPage(userId, input)
+	}
+
+	if errors.Is(err, storage.InvalidTag) || errors.Is(err, storage.InvalidCursor) {
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// @Summary Search audio
+// @Security ApiKeyAuth
+// @Tags audio
+// @Description full-text search over title, description, tags, transcript and owner name of audio you have access to, most relevant first. Matched words in snippets are wrapped in <mark> tags, cue is the best matching transcript cue
+// @ID search-audio
+// @Accept  json
+// @Produce  json
+// @Param q query string true "search query, supports quotes, or and -"
+// @Param offset query integer true "offset" minimum(0)
+// @Param limit query integer true "limit"  minimum(1)
+// @Success 200 {object} storage.SearchResultJson
+// @Failure 400 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /api/audio/search [get]
+func (h *Handler) searchAudio(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	var input storage.SearchParam
+	if err := c.BindQuery(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid query")
+		return
+	}
+
+	result, err := h.services.SearchAudio(userId, input)
 	if err != nil {
 		newErrorResponse(c, http.StatusInternalServerError, err.Error())
 		return
@@ -53,7 +133,7 @@ func (h *Handler) getAllAudio(c *gin.Context) {
 // @Summary Upload AAC file
 // @Security ApiKeyAuth
 // @Tags audio
-// @Description upload aac file
+// @Description upload aac file. ID3v2, ID3v1 and APE tags are stripped, title, artist, album and artwork are taken from them, chapters are taken from ID3v2 CHAP frames if all of them are valid. The file is remuxed, probed, checksummed and packaged for HLS in background, see jobs of audio. Transcription is started if speech-to-text is configured
 // @ID upload-file
 // @Accept multipart/form-data
 // @Produce  json
@@ -72,7 +152,7 @@ func (h *Handler) uploadAudio(c *gin.Context) {
 
 	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, MaxUploadSize)
 
-	file, _, err := c.Request.FormFile("file")
+	file, header, err := c.Request.FormFile("file")
 	if err != nil {
 		newErrorResponse(c, http.StatusBadRequest, err.Error())
 		return
@@ -81,48 +161,127 @@ func (h *Handler) uploadAudio(c *gin.Context) {
 
 	fileId := uuid.New()
 
-	err = h.services.StoreFile(fileId, file)
+	stored, err := h.services.StoreFile(fileId, file, header.Size)
 
 	if err != nil {
 		newErrorResponse(c, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	audioId, err := h.services.UploadFile(userId, fileId.String())
+	audioId, err := h.services.UploadFile(userId, fileId.String(), stored.Size, stored.Tags.Metadata())
 	if err != nil {
 		newErrorResponse(c, http.StatusInternalServerError, err.Error())
 		return
 	}
 
+	// cover art of tags which isn't a valid artwork is skipped, audio is
+	// already stored so failed artwork doesn't fail the upload
+	if stored.Tags.Cover != nil {
+		err = h.services.SetAudioArtwork(userId, audioId, stored.Tags.Cover)
+		if err != nil && !errors.Is(err, storage.InvalidArtwork) {
+			logrus.Errorf("can't set artwork of audio %d: %s", audioId, err.Error())
+		}
+	}
+
+	// chapter frames are imported only if all of them are valid, audio is
+	// already stored so failed import doesn't fail the upload
+	if len(stored.Tags.Chapters) > 0 && (storage.ChaptersInput{Chapters: stored.Tags.Chapters}).Validate() == nil {
+		if err := h.services.SetChapters(userId, audioId, stored.Tags.Chapters); err != nil {
+			logrus.Errorf("can't import chapters of audio %d: %s", audioId, err.Error())
+		}
+	}
+
+	// jobs failed to queue don't fail the upload either, retrying it would
+	// store the audio again
+	if err := h.services.ProcessAudio(audioId); err != nil {
+		logrus.Errorf("can't queue processing of audio %d: %s", audioId, err.Error())
+	}
+
+	err = h.services.StartTranscription(userId, audioId)
+	if err != nil && !errors.Is(err, storage.TranscriptionDisabled) {
+		logrus.Errorf("can't start transcription of audio %d: %s", audioId, err.Error())
+	}
+
 	c.JSON(http.StatusOK, idResponse{
 		ID: audioId,
 	})
 }
 
-// @Summary Add description to AAC file
+// @Summary Replace description of AAC file
 // @Security ApiKeyAuth
 // @Tags audio
-// @Description add description
+// @Description replace metadata of audio, missing optional fields are cleared. Custom fields are validated against your metadata schema
 // @ID add-description
 // @Accept  json
 // @Produce  json
-// @Param input body storage.UpdateAudio true "aac description"
+// @Param input body storage.ReplaceAudio true "aac description"
 // @Param id path int true "audio id"
+// @Param If-Match header string true "ETags of audio metadata or *"
 // @Success 200 {object} statusResponse
-// @Failure 400 {object} errorResponse
+// @Header 200 {string} ETag "new version of audio"
+// @Failure 400,404,412,428 {object} errorResponse
 // @Failure 500 {object} errorResponse
 // @Failure default {object} errorResponse
 // @Router /api/audio/{id} [put]
+// @Router /api/audio/{id}/metadata [put]
 func (h *Handler) addDescription(c *gin.Context) {
-	userId, err := getUserId(c)
-	if err != nil {
-		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+	userId, audioId, ok := getAudioParams(c)
+	if !ok {
 		return
 	}
 
-	audioId, err := strconv.Atoi(c.Param("id"))
+	versions, ok := getIfMatchVersions(c)
+	if !ok {
+		return
+	}
+
+	var input storage.ReplaceAudio
+	if err := c.BindJSON(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid input body")
+		return
+	}
+
+	update := input.Update()
+	if err := update.Validate(); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	audio, err := h.services.AddDescription(userId, audioId, versions, update)
 	if err != nil {
-		newErrorResponse(c, http.StatusBadRequest, "invalid audio id param")
+		newAudioErrorResponse(c, err)
+		return
+	}
+
+	setETag(c, audio.Version)
+	c.JSON(http.StatusOK, statusResponse{"ok"})
+}
+
+// @Summary Update description of AAC file
+// @Security ApiKeyAuth
+// @Tags audio
+// @Description partial update as JSON Merge Patch: only supplied fields are changed, null clears optional fields and removes custom fields. Custom fields are validated against your metadata schema
+// @ID update-audio
+// @Accept  json,application/merge-patch+json
+// @Produce  json
+// @Param input body storage.UpdateAudio true "fields to change"
+// @Param id path int true "audio id"
+// @Param If-Match header string true "ETags of audio metadata or *"
+// @Success 200 {object} storage.Audio
+// @Header 200 {string} ETag "new version of audio"
+// @Failure 400,404,412,428 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /api/audio/{id} [patch]
+// @Router /api/audio/{id}/metadata [patch]
+func (h *Handler) updateAudio(c *gin.Context) {
+	userId, audioId, ok := getAudioParams(c)
+	if !ok {
+		return
+	}
+
+	versions, ok := getIfMatchVersions(c)
+	if !ok {
 		return
 	}
 
@@ -137,22 +296,91 @@ func (h *Handler) addDescription(c *gin.Context) {
 		return
 	}
 
-	if err := h.services.AddDescription(userId, audioId, input); err != nil {
-		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+	audio, err := h.services.AddDescription(userId, audioId, versions, input)
+	if err != nil {
+		newAudioErrorResponse(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, statusResponse{"ok"})
+	setETag(c, audio.Version)
+	c.JSON(http.StatusOK, audio)
+}
+
+// @Summary Get audio metadata
+// @Security ApiKeyAuth
+// @Tags audio
+// @Description get metadata of own or shared with you audio, ETag header is used as If-Match on update
+// @ID get-audio
+// @Accept  json
+// @Produce  json
+// @Param id path int true "audio id"
+// @Success 200 {object} storage.Audio
+// @Header 200 {string} ETag "version of audio"
+// @Failure 400,404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /api/audio/{id}/metadata [get]
+func (h *Handler) getAudio(c *gin.Context) {
+	userId, audioId, ok := getAudioParams(c)
+	if !ok {
+		return
+	}
+
+	audio, err := h.services.GetAudio(userId, audioId)
+	if err != nil {
+		newAudioErrorResponse(c, err)
+		return
+	}
+
+	setETag(c, audio.Version)
+	c.JSON(http.StatusOK, audio)
+}
+
+// @Summary Get audio history
+// @Security ApiKeyAuth
+// @Tags audio
+// @Description get changes of title, duration and description of own audio, newest first
+// @ID get-audio-history
+// @Accept  json
+// @Produce  json
+// @Param id path int true "audio id"
+// @Param offset query integer true "offset" minimum(0)
+// @Param limit query integer true "limit"  minimum(1)
+// @Success 200 {object} storage.HistoryListJson
+// @Failure 400,404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /api/audio/{id}/history [get]
+func (h *Handler) getAudioHistory(c *gin.Context) {
+	userId, audioId, ok := getAudioParams(c)
+	if !ok {
+		return
+	}
+
+	var input storage.HistoryListParam
+	if err := c.BindQuery(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid query")
+		return
+	}
+
+	result, err := h.services.GetAudioHistory(userId, audioId, input)
+	if err != nil {
+		newAudioErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
 }
 
 // @Summary Download AAC file
 // @Security ApiKeyAuth
 // @Tags audio
-// @Description download aac file
+// @Description download aac file, with id3 set current metadata, artwork and chapters are written to ID3v2 tag in front of the file
 // @ID download-file
 // @Accept  json
 // @Produce  application/octet-stream
 // @Param id path int true "audio id"
+// @Param id3 query bool false "write ID3v2 tag"
 // @Success 200 "Success Download"
 // @Failure 400 {object} errorResponse
 // @Failure 500 {object} errorResponse
@@ -171,6 +399,15 @@ func (h *Handler) downloadAudio(c *gin.Context) {
 		return
 	}
 
+	withTag := false
+	if value := c.Query("id3"); value != "" {
+		withTag, err = strconv.ParseBool(value)
+		if err != nil {
+			newErrorResponse(c, http.StatusBadRequest, "invalid id3 param")
+			return
+		}
+	}
+
 	audio, err := h.services.DownloadFile(userId, audioId)
 	if err != nil {
 		newErrorResponse(c, http.StatusInternalServerError, err.Error())
@@ -183,7 +420,31 @@ func (h *Handler) downloadAudio(c *gin.Context) {
 		return
 	}
 
-	file, fileSize, err := h.services.GetFile(fileId)
+	var file io.ReadCloser
+	var fileSize int64
+	if withTag {
+		tags := storage.AudioTags{Title: audio.Title, Artist: audio.Artist, Album: audio.Album}
+
+		var artwork storage.Artwork
+		artwork, err = h.services.GetAudioArtwork(userId, audioId, storage.ArtworkLarge)
+		if err != nil && !errors.Is(err, storage.ArtworkNotFound) {
+			newErrorResponse(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+		tags.Cover = artwork.Data
+
+		var chapters storage.AudioChapters
+		chapters, err = h.services.GetChapters(userId, audioId)
+		if err != nil {
+			newErrorResponse(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+		tags.Chapters, tags.Duration = chapters.Chapters, chapters.Duration
+
+		file, fileSize, err = h.services.GetTaggedFile(fileId, tags)
+	} else {
+		file, fileSize, err = h.services.GetFile(fileId)
+	}
 	if err != nil {
 		newErrorResponse(c, http.StatusInternalServerError, err.Error())
 		return
@@ -192,3 +453,110 @@ func (h *Handler) downloadAudio(c *gin.Context) {
 
 	c.DataFromReader(http.StatusOK, fileSize, "application/octet-stream", file, map[string]string{"Content-Disposition": audio.Title + storage.FileExt})
 }
+
+func setETag(c *gin.Context, version int) {
+	c.Header("ETag", strconv.Quote(strconv.Itoa(version)))
+}
+
+// getIfMatchVersions reads versions from If-Match header set to ETags of audio,
+// nil versions are returned for * which matches any version. If-Match uses
+// strong comparison, so weak tags never match and a list of only weak or
+// unknown tags fails with 412
+func getIfMatchVersions(c *gin.Context) ([]int, bool) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" {
+		newErrorResponse(c, http.StatusPreconditionRequired, "If-Match header is required")
+		return nil, false
+	}
+
+	if header == "*" {
+		return nil, true
+	}
+
+	tags, ok := parseEntityTags(header)
+	if !ok {
+		newErrorResponse(c, http.StatusBadRequest, "invalid If-Match header")
+		return nil, false
+	}
+
+	versions := make([]int, 0, len(tags))
+	for _, tag := range tags {
+		if tag.weak {
+			continue
+		}
+		if version, err := strconv.Atoi(tag.opaque); err == nil && strconv.Itoa(version) == tag.opaque {
+			versions = append(versions, version)
+		}
+	}
+
+	if len(versions) == 0 {
+		newErrorResponse(c, http.StatusPreconditionFailed, storage.VersionMismatch.Error())
+		return nil, false
+	}
+
+	return versions, true
+}
+
+// entityTag is a tag of If-Match header, opaque is the tag without quotes
+type entityTag struct {
+	weak   bool
+	opaque string
+}
+
+// parseEntityTags parses comma separated list of entity tags as defined in
+// RFC 7232, empty list elements are allowed
+func parseEntityTags(header string) ([]entityTag, bool) {
+	var tags []entityTag
+	rest := header
+	for {
+		rest = strings.TrimLeft(rest, " \t")
+		if rest == "" {
+			break
+		}
+		if rest[0] == ',' {
+			rest = rest[1:]
+			continue
+		}
+
+		var tag entityTag
+		if strings.HasPrefix(rest, "W/") {
+			tag.weak = true
+			rest = rest[2:]
+		}
+		if rest == "" || rest[0] != '"' {
+			return nil, false
+		}
+
+		end := strings.IndexByte(rest[1:], '"')
+		if end < 0 {
+			return nil, false
+		}
+		tag.opaque = rest[1 : end+1]
+		for i := 0; i < len(tag.opaque); i++ {
+			if b := tag.opaque[i]; b < 0x21 || b == 0x7f {
+				return nil, false
+			}
+		}
+		tags = append(tags, tag)
+
+		rest = strings.TrimLeft(rest[end+2:], " \t")
+		if rest != "" && rest[0] != ',' {
+			return nil, false
+		}
+	}
+
+	return tags, len(tags) > 0
+}
+
+func newAudioErrorResponse(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, storage.NotOwner), errors.Is(err, storage.FileNotFound):
+		newErrorResponse(c, http.StatusNotFound, err.Error())
+	case errors.Is(err, storage.VersionMismatch):
+		newErrorResponse(c, http.StatusPreconditionFailed, err.Error())
+	case errors.Is(err, storage.InvalidCustomFields), errors.Is(err, storage.InvalidMetadataSchema):
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+	default:
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+	}
+}
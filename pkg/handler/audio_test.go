@@ -19,18 +19,22 @@ import (
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestHandler_getAllAudio(t *testing.T) {
 	type mockBehavior func(s *mock_service.MockAudio, userId int, audioParam storage.AudioListParam)
 
 	offset, limit := 0, 10
+	minDuration := 60
+	at := time.Date(2021, 6, 1, 10, 0, 0, 0, time.UTC)
 
 	testTable := []struct {
 		name                 string
 		offset               string
 		limit                string
 		orderType            string
+		extraParams          url.Values
 		userId               int
 		audio                storage.AudioListParam
 		mockBehavior         mockBehavior
@@ -53,11 +57,14 @@ func TestHandler_getAllAudio(t *testing.T) {
 					TotalCount: 10,
 					Records: []storage.AudioList{
 						{
-							Id:      1,
-							Title:   "title 1",
-							IsOwner: true,
-							Owner:   1,
-							Name:    "user 1",
+							Id:         1,
+							Title:      "title 1",
+							IsOwner:    true,
+							Owner:      1,
+							Name:       "user 1",
+							UploadedBy: 1,
+							CreatedAt:  at,
+							UpdatedAt:  at,
 							Shares: &[]storage.ShareList{
 								{
 									UserId: 2,
@@ -66,17 +73,20 @@ func TestHandler_getAllAudio(t *testing.T) {
 							},
 						},
 						{
-							Id:      2,
-							Title:   "title 2",
-							IsOwner: true,
-							Owner:   1,
-							Name:    "user 1",
+							Id:         2,
+							Title:      "title 2",
+							IsOwner:    true,
+							Owner:      1,
+							Name:       "user 1",
+							UploadedBy: 1,
+							CreatedAt:  at,
+							UpdatedAt:  at,
 						},
 					},
 				}, nil)
 			},
 			expectedStatusCode:   200,
-			expectedResponseBody: `{"total_count":10,"records":[{"id":1,"name":"title 1","is_owner":true,"owner_id":1,"owner_name":"user 1","shared_to":[{"id":2,"name":"user 2"}]},{"id":2,"name":"title 2","is_owner":true,"owner_id":1,"owner_name":"user 1"}]}`,
+			expectedResponseBody: `{"total_count":10,"records":[{"id":1,"name":"title 1","is_owner":true,"owner_id":1,"owner_name":"user 1","uploaded_by":1,"created_at":"2021-06-01T10:00:00Z","updated_at":"2021-06-01T10:00:00Z","shared_to":[{"id":2,"name":"user 2"}]},{"id":2,"name":"title 2","is_owner":true,"owner_id":1,"owner_name":"user 1","uploaded_by":1,"created_at":"2021-06-01T10:00:00Z","updated_at":"2021-06-01T10:00:00Z"}]}`,
 		},
 		{
 			name:                 "User no found",
@@ -102,6 +112,148 @@ func TestHandler_getAllAudio(t *testing.T) {
 			expectedStatusCode:   400,
 			expectedResponseBody: `{"message":"invalid query"}`,
 		},
+		{
+			name:        "OK sort and filters",
+			offset:      strconv.Itoa(offset),
+			limit:       strconv.Itoa(limit),
+			extraParams: url.Values{"sort": {"duration"}, "direction": {"asc"}, "owner": {"me"}, "min_duration": {"60"}},
+			userId:      1,
+			audio: storage.AudioListParam{
+				Offset:      &offset,
+				Limit:       &limit,
+				Sort:        "duration",
+				Direction:   "asc",
+				Owner:       "me",
+				MinDuration: &minDuration,
+			},
+			mockBehavior: func(s *mock_service.MockAudio, userId int, audioParam storage.AudioListParam) {
+				s.EXPECT().GetAudioList(userId, audioParam).Return(storage.AudioListJson{Records: []storage.AudioList{}}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"total_count":0,"records":[]}`,
+		},
+		{
+			name:                 "Invalid sort",
+			offset:               strconv.Itoa(offset),
+			limit:                strconv.Itoa(limit),
+			extraParams:          url.Values{"sort": {"name; DROP TABLE audios"}},
+			userId:               1,
+			mockBehavior:         func(s *mock_service.MockAudio, userId int, audioParam storage.AudioListParam) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"invalid query"}`,
+		},
+		{
+			name:                 "Invalid duration range",
+			offset:               strconv.Itoa(offset),
+			limit:                strconv.Itoa(limit),
+			extraParams:          url.Values{"min_duration": {"600"}, "max_duration": {"60"}},
+			userId:               1,
+			mockBehavior:         func(s *mock_service.MockAudio, userId int, audioParam storage.AudioListParam) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"min_duration is greater than max_duration"}`,
+		},
+		{
+			name:                 "Invalid custom filter",
+			offset:               strconv.Itoa(offset),
+			limit:                strconv.Itoa(limit),
+			extraParams:          url.Values{"custom": {"episode"}},
+			userId:               1,
+			mockBehavior:         func(s *mock_service.MockAudio, userId int, audioParam storage.AudioListParam) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"custom filter must be key:value"}`,
+		},
+		{
+			name:                 "Order type with sort",
+			offset:               strconv.Itoa(offset),
+			limit:                strconv.Itoa(limit),
+			orderType:            "owner",
+			extraParams:          url.Values{"sort": {"title"}},
+			userId:               1,
+			mockBehavior:         func(s *mock_service.MockAudio, userId int, audioParam storage.AudioListParam) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"order_type and sort can't be used together"}`,
+		},
+		{
+			name:        "OK cursor mode",
+			limit:       strconv.Itoa(limit),
+			extraParams: url.Values{"cursor": {"cursor"}, "with_count": {"true"}},
+			userId:      1,
+			audio: storage.AudioListParam{
+				Limit:     &limit,
+				Cursor:    "cursor",
+				WithCount: true,
+			},
+			mockBehavior: func(s *mock_service.MockAudio, userId int, audioParam storage.AudioListParam) {
+				count := 3
+				s.EXPECT().GetAudioPage(userId, audioParam).Return(storage.AudioPageJson{
+					TotalCount: &count,
+					Records: []storage.AudioList{
+						{
+							Id:         3,
+							Title:      "title 3",
+							IsOwner:    true,
+							Owner:      1,
+							Name:       "user 1",
+							UploadedBy: 1,
+							CreatedAt:  at,
+							UpdatedAt:  at,
+						},
+					},
+					PrevCursor: "prev",
+				}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"total_count":3,"records":[{"id":3,"name":"title 3","is_owner":true,"owner_id":1,"owner_name":"user 1","uploaded_by":1,"created_at":"2021-06-01T10:00:00Z","updated_at":"2021-06-01T10:00:00Z"}],"prev_cursor":"prev"}`,
+		},
+		{
+			name:        "Invalid cursor",
+			limit:       strconv.Itoa(limit),
+			extraParams: url.Values{"cursor": {"wrong"}},
+			userId:      1,
+			audio: storage.AudioListParam{
+				Limit:  &limit,
+				Cursor: "wrong",
+			},
+			mockBehavior: func(s *mock_service.MockAudio, userId int, audioParam storage.AudioListParam) {
+				s.EXPECT().GetAudioPage(userId, audioParam).Return(storage.AudioPageJson{}, storage.InvalidCursor)
+			},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"cursor is invalid or made for another sort order"}`,
+		},
+		{
+			name:                 "Negative limit",
+			limit:                "-1",
+			userId:               1,
+			mockBehavior:         func(s *mock_service.MockAudio, userId int, audioParam storage.AudioListParam) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"invalid query"}`,
+		},
+		{
+			name:                 "Zero limit",
+			limit:                "0",
+			userId:               1,
+			mockBehavior:         func(s *mock_service.MockAudio, userId int, audioParam storage.AudioListParam) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"invalid query"}`,
+		},
+		{
+			name:                 "Too large limit",
+			limit:                "1001",
+			userId:               1,
+			mockBehavior:         func(s *mock_service.MockAudio, userId int, audioParam storage.AudioListParam) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"invalid query"}`,
+		},
+		{
+			name:                 "Offset with cursor",
+			offset:               strconv.Itoa(offset),
+			limit:                strconv.Itoa(limit),
+			extraParams:          url.Values{"cursor": {"cursor"}},
+			userId:               1,
+			mockBehavior:         func(s *mock_service.MockAudio, userId int, audioParam storage.AudioListParam) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"offset can't be used with cursor or with_count"}`,
+		},
 		{
 			name:      "Service error",
 			offset:    strconv.Itoa(offset),
@@ -143,8 +295,17 @@ func TestHandler_getAllAudio(t *testing.T) {
 			}
 
 			w := httptest.NewRecorder()
-			params := url.Values{"offset": {testCase.offset}, "limit": {testCase.limit}, "order_type": {testCase.orderType}}.Encode()
-			req := httptest.NewRequest("GET", "/?"+params, nil)
+			params := url.Values{"limit": {testCase.limit}}
+			if testCase.offset != "" {
+				params.Set("offset", testCase.offset)
+			}
+			if testCase.orderType != "" {
+				params.Set("order_type", testCase.orderType)
+			}
+			for key, values := range testCase.extraParams {
+				params[key] = values
+			}
+			req := httptest.NewRequest("GET", "/?"+params.Encode(), nil)
 			r.ServeHTTP(w, req)
 			assert.Equal(t, testCase.expectedStatusCode, w.Code)
 			assert.Equal(t, testCase.expectedResponseBody, w.Body.String())
@@ -156,6 +317,7 @@ func TestHandler_addDescription(t *testing.T) {
 
 	title := "new audio"
 	duration := 67
+	description := ""
 
 	testTable := []struct {
 		name      string
@@ -166,19 +328,28 @@ func TestHandler_addDescription(t *testing.T) {
 		audioParam           storage.UpdateAudio
 		mockBehavior         mockBehavior
 		expectedStatusCode   int
+		ifMatch              string
 		expectedResponseBody string
 	}{
 		{
 			name:      "OK",
 			inputBody: fmt.Sprintf(`{"title":"%s","duration":%d}`, title, duration),
+			ifMatch:   `"3"`,
 			userId:    1,
 			audioId:   1,
 			audioParam: storage.UpdateAudio{
-				Title:    &title,
-				Duration: &duration,
+				Title:           &title,
+				Duration:        &duration,
+				Description:     &description,
+				Artist:          &description,
+				Album:           &description,
+				Language:        &description,
+				Location:        &description,
+				ClearRecordedAt: true,
+				ClearCustom:     true,
 			},
 			mockBehavior: func(s *mock_service.MockAudio, userId, audioId int, audioParam storage.UpdateAudio) {
-				s.EXPECT().AddDescription(userId, audioId, audioParam).Return(nil)
+				s.EXPECT().AddDescription(userId, audioId, []int{3}, audioParam).Return(storage.Audio{}, nil)
 			},
 			expectedStatusCode:   200,
 			expectedResponseBody: `{"status":"ok"}`,
@@ -201,6 +372,7 @@ func TestHandler_addDescription(t *testing.T) {
 		{
 			name:                 "Invalid input",
 			inputBody:            ``,
+			ifMatch:              `"3"`,
 			userId:               1,
 			audioId:              1,
 			mockBehavior:         func(s *mock_service.MockAudio, userId, audioId int, audioParam storage.UpdateAudio) {},
@@ -208,25 +380,67 @@ func TestHandler_addDescription(t *testing.T) {
 			expectedResponseBody: `{"message":"invalid input body"}`,
 		},
 		{
-			name:                 "Empty input",
-			inputBody:            `{}`,
+			name:                 "Missing duration",
+			inputBody:            fmt.Sprintf(`{"title":"%s"}`, title),
+			ifMatch:              `"3"`,
 			userId:               1,
 			audioId:              1,
 			mockBehavior:         func(s *mock_service.MockAudio, userId, audioId int, audioParam storage.UpdateAudio) {},
 			expectedStatusCode:   400,
-			expectedResponseBody: `{"message":"update structure has no values"}`,
+			expectedResponseBody: `{"message":"invalid input body"}`,
+		},
+		{
+			name:                 "Invalid title",
+			inputBody:            fmt.Sprintf(`{"title":" ","duration":%d}`, duration),
+			ifMatch:              `"3"`,
+			userId:               1,
+			audioId:              1,
+			mockBehavior:         func(s *mock_service.MockAudio, userId, audioId int, audioParam storage.UpdateAudio) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"title must be from 1 to 256 characters without control characters"}`,
+		},
+		{
+			name:      "Not owner",
+			inputBody: fmt.Sprintf(`{"title":"%s","duration":%d}`, title, duration),
+			ifMatch:   `"3"`,
+			userId:    1,
+			audioId:   1,
+			audioParam: storage.UpdateAudio{
+				Title:           &title,
+				Duration:        &duration,
+				Description:     &description,
+				Artist:          &description,
+				Album:           &description,
+				Language:        &description,
+				Location:        &description,
+				ClearRecordedAt: true,
+				ClearCustom:     true,
+			},
+			mockBehavior: func(s *mock_service.MockAudio, userId, audioId int, audioParam storage.UpdateAudio) {
+				s.EXPECT().AddDescription(userId, audioId, []int{3}, audioParam).Return(storage.Audio{}, storage.NotOwner)
+			},
+			expectedStatusCode:   404,
+			expectedResponseBody: `{"message":"you are not owner or audio not exists"}`,
 		},
 		{
 			name:      "Service fail",
 			inputBody: fmt.Sprintf(`{"title":"%s","duration":%d}`, title, duration),
+			ifMatch:   `"3"`,
 			userId:    1,
 			audioId:   1,
 			audioParam: storage.UpdateAudio{
-				Title:    &title,
-				Duration: &duration,
+				Title:           &title,
+				Duration:        &duration,
+				Description:     &description,
+				Artist:          &description,
+				Album:           &description,
+				Language:        &description,
+				Location:        &description,
+				ClearRecordedAt: true,
+				ClearCustom:     true,
 			},
 			mockBehavior: func(s *mock_service.MockAudio, userId, audioId int, audioParam storage.UpdateAudio) {
-				s.EXPECT().AddDescription(userId, audioId, audioParam).Return(errors.New("service fail"))
+				s.EXPECT().AddDescription(userId, audioId, []int{3}, audioParam).Return(storage.Audio{}, errors.New("service fail"))
 			},
 			expectedStatusCode:   500,
 			expectedResponseBody: `{"message":"service fail"}`,
@@ -261,6 +475,467 @@ func TestHandler_addDescription(t *testing.T) {
 			}
 			req := httptest.NewRequest("POST", url, bytes.NewBufferString(testCase.inputBody))
 
+			if testCase.ifMatch != "" {
+				req.Header.Set("If-Match", testCase.ifMatch)
+			}
+
+			r.ServeHTTP(w, req)
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedResponseBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_updateAudio(t *testing.T) {
+	type mockBehavior func(s *mock_service.MockAudio, userId, audioId int, audioParam storage.UpdateAudio)
+
+	title := "new audio"
+	empty := ""
+	artist := "artist"
+	at := time.Date(2021, 6, 1, 10, 0, 0, 0, time.UTC)
+
+	testTable := []struct {
+		name                 string
+		inputBody            string
+		userId               int
+		audioId              int
+		audioParam           storage.UpdateAudio
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		ifMatch              string
+		expectedResponseBody string
+	}{
+		{
+			name:       "OK title only",
+			inputBody:  fmt.Sprintf(`{"title":"%s"}`, title),
+			ifMatch:    `"3"`,
+			userId:     1,
+			audioId:    2,
+			audioParam: storage.UpdateAudio{Title: &title},
+			mockBehavior: func(s *mock_service.MockAudio, userId, audioId int, audioParam storage.UpdateAudio) {
+				s.EXPECT().AddDescription(userId, audioId, []int{3}, audioParam).Return(storage.Audio{
+					Id:     audioId,
+					UserId: userId,
+					AudioMetadata: storage.AudioMetadata{
+						Title:       title,
+						Duration:    67,
+						Description: "description",
+						Language:    "en",
+						Custom:      storage.CustomFields{"episode": "12"},
+					},
+					UploadedBy: userId,
+					CreatedAt:  at,
+					UpdatedAt:  at,
+					Version:    4,
+				}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"id":2,"user_id":1,"title":"new audio","duration":67,"description":"description","artist":"","album":"","recorded_at":null,"language":"en","location":"","custom":{"episode":"12"},"uploaded_by":1,"created_at":"2021-06-01T10:00:00Z","updated_at":"2021-06-01T10:00:00Z","version":4}`,
+		},
+		{
+			name:       "OK null clears description",
+			inputBody:  `{"description":null}`,
+			ifMatch:    `"3"`,
+			userId:     1,
+			audioId:    2,
+			audioParam: storage.UpdateAudio{Description: &empty},
+			mockBehavior: func(s *mock_service.MockAudio, userId, audioId int, audioParam storage.UpdateAudio) {
+				s.EXPECT().AddDescription(userId, audioId, []int{3}, audioParam).Return(storage.Audio{Id: audioId, UserId: userId, UploadedBy: userId, CreatedAt: at, UpdatedAt: at, Version: 4}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"id":2,"user_id":1,"title":"","duration":0,"description":"","artist":"","album":"","recorded_at":null,"language":"","location":"","custom":{},"uploaded_by":1,"created_at":"2021-06-01T10:00:00Z","updated_at":"2021-06-01T10:00:00Z","version":4}`,
+		},
+		{
+			name:      "OK metadata merge patch",
+			inputBody: `{"artist":"artist","recorded_at":null,"custom":{"episode":"12","guest":null}}`,
+			ifMatch:   `"3"`,
+			userId:    1,
+			audioId:   2,
+			audioParam: storage.UpdateAudio{
+				Artist:          &artist,
+				ClearRecordedAt: true,
+				Custom:          storage.CustomFields{"episode": "12", "guest": nil},
+			},
+			mockBehavior: func(s *mock_service.MockAudio, userId, audioId int, audioParam storage.UpdateAudio) {
+				s.EXPECT().AddDescription(userId, audioId, []int{3}, audioParam).Return(storage.Audio{Id: audioId, UserId: userId, UploadedBy: userId, CreatedAt: at, UpdatedAt: at, Version: 4}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"id":2,"user_id":1,"title":"","duration":0,"description":"","artist":"","album":"","recorded_at":null,"language":"","location":"","custom":{},"uploaded_by":1,"created_at":"2021-06-01T10:00:00Z","updated_at":"2021-06-01T10:00:00Z","version":4}`,
+		},
+		{
+			name:                 "Invalid language",
+			inputBody:            `{"language":"english language"}`,
+			ifMatch:              `"3"`,
+			userId:               1,
+			audioId:              2,
+			mockBehavior:         func(s *mock_service.MockAudio, userId, audioId int, audioParam storage.UpdateAudio) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"language must be a language tag like en or en-US"}`,
+		},
+		{
+			name:       "Custom fields don't match schema",
+			inputBody:  `{"custom":{"episode":12}}`,
+			ifMatch:    `"3"`,
+			userId:     1,
+			audioId:    2,
+			audioParam: storage.UpdateAudio{Custom: storage.CustomFields{"episode": float64(12)}},
+			mockBehavior: func(s *mock_service.MockAudio, userId, audioId int, audioParam storage.UpdateAudio) {
+				s.EXPECT().AddDescription(userId, audioId, []int{3}, audioParam).Return(storage.Audio{}, fmt.Errorf("%w: episode: Invalid type", storage.InvalidCustomFields))
+			},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"custom fields are invalid: episode: Invalid type"}`,
+		},
+		{
+			name:                 "Null title",
+			inputBody:            `{"title":null}`,
+			ifMatch:              `"3"`,
+			userId:               1,
+			audioId:              2,
+			mockBehavior:         func(s *mock_service.MockAudio, userId, audioId int, audioParam storage.UpdateAudio) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"invalid input body"}`,
+		},
+		{
+			name:                 "Empty input",
+			inputBody:            `{}`,
+			ifMatch:              `"3"`,
+			userId:               1,
+			audioId:              2,
+			mockBehavior:         func(s *mock_service.MockAudio, userId, audioId int, audioParam storage.UpdateAudio) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"update structure has no values"}`,
+		},
+		{
+			name:                 "Title with control characters",
+			inputBody:            `{"title":"new\naudio"}`,
+			ifMatch:              `"3"`,
+			userId:               1,
+			audioId:              2,
+			mockBehavior:         func(s *mock_service.MockAudio, userId, audioId int, audioParam storage.UpdateAudio) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"title must be from 1 to 256 characters without control characters"}`,
+		},
+		{
+			name:                 "Negative duration",
+			inputBody:            `{"duration":-1}`,
+			ifMatch:              `"3"`,
+			userId:               1,
+			audioId:              2,
+			mockBehavior:         func(s *mock_service.MockAudio, userId, audioId int, audioParam storage.UpdateAudio) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"duration can't be negative"}`,
+		},
+		{
+			name:                 "Missing If-Match",
+			inputBody:            fmt.Sprintf(`{"title":"%s"}`, title),
+			userId:               1,
+			audioId:              2,
+			mockBehavior:         func(s *mock_service.MockAudio, userId, audioId int, audioParam storage.UpdateAudio) {},
+			expectedStatusCode:   428,
+			expectedResponseBody: `{"message":"If-Match header is required"}`,
+		},
+		{
+			name:                 "Invalid If-Match",
+			inputBody:            fmt.Sprintf(`{"title":"%s"}`, title),
+			userId:               1,
+			audioId:              2,
+			ifMatch:              "3",
+			mockBehavior:         func(s *mock_service.MockAudio, userId, audioId int, audioParam storage.UpdateAudio) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"invalid If-Match header"}`,
+		},
+		{
+			name:                 "Backquoted If-Match",
+			inputBody:            fmt.Sprintf(`{"title":"%s"}`, title),
+			userId:               1,
+			audioId:              2,
+			ifMatch:              "`3`",
+			mockBehavior:         func(s *mock_service.MockAudio, userId, audioId int, audioParam storage.UpdateAudio) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"invalid If-Match header"}`,
+		},
+		{
+			name:                 "If-Match without comma",
+			inputBody:            fmt.Sprintf(`{"title":"%s"}`, title),
+			userId:               1,
+			audioId:              2,
+			ifMatch:              `"3" "4"`,
+			mockBehavior:         func(s *mock_service.MockAudio, userId, audioId int, audioParam storage.UpdateAudio) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"invalid If-Match header"}`,
+		},
+		{
+			name:                 "Weak If-Match",
+			inputBody:            fmt.Sprintf(`{"title":"%s"}`, title),
+			userId:               1,
+			audioId:              2,
+			ifMatch:              `W/"3"`,
+			mockBehavior:         func(s *mock_service.MockAudio, userId, audioId int, audioParam storage.UpdateAudio) {},
+			expectedStatusCode:   412,
+			expectedResponseBody: `{"message":"audio was changed by someone else, get it again and retry"}`,
+		},
+		{
+			name:       "OK If-Match list",
+			inputBody:  fmt.Sprintf(`{"title":"%s"}`, title),
+			ifMatch:    `W/"2", "x", "5" ,"3"`,
+			userId:     1,
+			audioId:    2,
+			audioParam: storage.UpdateAudio{Title: &title},
+			mockBehavior: func(s *mock_service.MockAudio, userId, audioId int, audioParam storage.UpdateAudio) {
+				s.EXPECT().AddDescription(userId, audioId, []int{5, 3}, audioParam).Return(storage.Audio{Version: 4}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"id":0,"user_id":0,"title":"","duration":0,"description":"","artist":"","album":"","recorded_at":null,"language":"","location":"","custom":{},"uploaded_by":0,"created_at":"0001-01-01T00:00:00Z","updated_at":"0001-01-01T00:00:00Z","version":4}`,
+		},
+		{
+			name:       "OK If-Match any",
+			inputBody:  fmt.Sprintf(`{"title":"%s"}`, title),
+			ifMatch:    `*`,
+			userId:     1,
+			audioId:    2,
+			audioParam: storage.UpdateAudio{Title: &title},
+			mockBehavior: func(s *mock_service.MockAudio, userId, audioId int, audioParam storage.UpdateAudio) {
+				s.EXPECT().AddDescription(userId, audioId, nil, audioParam).Return(storage.Audio{Version: 4}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"id":0,"user_id":0,"title":"","duration":0,"description":"","artist":"","album":"","recorded_at":null,"language":"","location":"","custom":{},"uploaded_by":0,"created_at":"0001-01-01T00:00:00Z","updated_at":"0001-01-01T00:00:00Z","version":4}`,
+		},
+		{
+			name:       "Version mismatch",
+			inputBody:  fmt.Sprintf(`{"title":"%s"}`, title),
+			ifMatch:    `"3"`,
+			userId:     1,
+			audioId:    2,
+			audioParam: storage.UpdateAudio{Title: &title},
+			mockBehavior: func(s *mock_service.MockAudio, userId, audioId int, audioParam storage.UpdateAudio) {
+				s.EXPECT().AddDescription(userId, audioId, []int{3}, audioParam).Return(storage.Audio{}, storage.VersionMismatch)
+			},
+			expectedStatusCode:   412,
+			expectedResponseBody: `{"message":"audio was changed by someone else, get it again and retry"}`,
+		},
+		{
+			name:       "Not owner",
+			inputBody:  fmt.Sprintf(`{"title":"%s"}`, title),
+			ifMatch:    `"3"`,
+			userId:     1,
+			audioId:    2,
+			audioParam: storage.UpdateAudio{Title: &title},
+			mockBehavior: func(s *mock_service.MockAudio, userId, audioId int, audioParam storage.UpdateAudio) {
+				s.EXPECT().AddDescription(userId, audioId, []int{3}, audioParam).Return(storage.Audio{}, storage.NotOwner)
+			},
+			expectedStatusCode:   404,
+			expectedResponseBody: `{"message":"you are not owner or audio not exists"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			audio := mock_service.NewMockAudio(c)
+			testCase.mockBehavior(audio, testCase.userId, testCase.audioId, testCase.audioParam)
+
+			services := &service.Service{Audio: audio}
+			handler := NewHandler(services)
+
+			r := gin.New()
+			r.PATCH("/audio/:id", func(c *gin.Context) {
+				c.Set(userCtx, testCase.userId)
+			}, handler.updateAudio)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("PATCH", fmt.Sprintf("/audio/%d", testCase.audioId), bytes.NewBufferString(testCase.inputBody))
+			req.Header.Set("Content-Type", "application/merge-patch+json")
+
+			if testCase.ifMatch != "" {
+				req.Header.Set("If-Match", testCase.ifMatch)
+			}
+
+			r.ServeHTTP(w, req)
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedResponseBody, w.Body.String())
+			if w.Code == 200 {
+				assert.Equal(t, `"4"`, w.Header().Get("ETag"))
+			}
+		})
+	}
+}
+
+func TestHandler_getAudio(t *testing.T) {
+	type mockBehavior func(s *mock_service.MockAudio, userId, audioId int)
+
+	at := time.Date(2021, 6, 1, 10, 0, 0, 0, time.UTC)
+
+	testTable := []struct {
+		name                 string
+		userId               int
+		audioId              int
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedETag         string
+		expectedResponseBody string
+	}{
+		{
+			name:    "OK",
+			userId:  1,
+			audioId: 2,
+			mockBehavior: func(s *mock_service.MockAudio, userId, audioId int) {
+				s.EXPECT().GetAudio(userId, audioId).Return(storage.Audio{
+					Id:     audioId,
+					UserId: 3,
+					AudioMetadata: storage.AudioMetadata{
+						Title:      "audio 2",
+						Duration:   67,
+						Artist:     "artist",
+						RecordedAt: &at,
+					},
+					UploadedBy: 3,
+					CreatedAt:  at,
+					UpdatedAt:  at,
+					Version:    7,
+				}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedETag:         `"7"`,
+			expectedResponseBody: `{"id":2,"user_id":3,"title":"audio 2","duration":67,"description":"","artist":"artist","album":"","recorded_at":"2021-06-01T10:00:00Z","language":"","location":"","custom":{},"uploaded_by":3,"created_at":"2021-06-01T10:00:00Z","updated_at":"2021-06-01T10:00:00Z","version":7}`,
+		},
+		{
+			name:                 "Invalid audio id",
+			userId:               1,
+			mockBehavior:         func(s *mock_service.MockAudio, userId, audioId int) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"invalid audio id param"}`,
+		},
+		{
+			name:    "Not found",
+			userId:  1,
+			audioId: 2,
+			mockBehavior: func(s *mock_service.MockAudio, userId, audioId int) {
+				s.EXPECT().GetAudio(userId, audioId).Return(storage.Audio{}, storage.FileNotFound)
+			},
+			expectedStatusCode:   404,
+			expectedResponseBody: `{"message":"file not found or you haven't access"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			audio := mock_service.NewMockAudio(c)
+			testCase.mockBehavior(audio, testCase.userId, testCase.audioId)
+
+			services := &service.Service{Audio: audio}
+			handler := NewHandler(services)
+
+			r := gin.New()
+			r.GET("/audio/:id/metadata", func(c *gin.Context) {
+				c.Set(userCtx, testCase.userId)
+			}, handler.getAudio)
+
+			w := httptest.NewRecorder()
+			target := fmt.Sprintf("/audio/%d/metadata", testCase.audioId)
+			if testCase.audioId == 0 {
+				target = "/audio/wrong_id/metadata"
+			}
+			req := httptest.NewRequest("GET", target, nil)
+
+			r.ServeHTTP(w, req)
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedETag, w.Header().Get("ETag"))
+			assert.Equal(t, testCase.expectedResponseBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_getAudioHistory(t *testing.T) {
+	type mockBehavior func(s *mock_service.MockAudio, userId, audioId int, input storage.HistoryListParam)
+
+	offset, limit := 0, 10
+	at := time.Date(2021, 6, 1, 10, 0, 0, 0, time.UTC)
+
+	testTable := []struct {
+		name                 string
+		userId               int
+		audioId              int
+		input                storage.HistoryListParam
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:    "OK",
+			userId:  1,
+			audioId: 2,
+			input:   storage.HistoryListParam{Offset: &offset, Limit: &limit},
+			mockBehavior: func(s *mock_service.MockAudio, userId, audioId int, input storage.HistoryListParam) {
+				s.EXPECT().GetAudioHistory(userId, audioId, input).Return(storage.HistoryListJson{
+					TotalCount: 1,
+					Records: []storage.HistoryRecord{
+						{Field: "title", OldValue: "old", NewValue: "new", UserId: 1, Name: "user 1", ChangedAt: at},
+					},
+				}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"total_count":1,"records":[{"field":"title","old_value":"old","new_value":"new","user_id":1,"user_name":"user 1","changed_at":"2021-06-01T10:00:00Z"}]}`,
+		},
+		{
+			name:                 "Invalid audio id",
+			userId:               1,
+			mockBehavior:         func(s *mock_service.MockAudio, userId, audioId int, input storage.HistoryListParam) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"invalid audio id param"}`,
+		},
+		{
+			name:    "Not owner",
+			userId:  1,
+			audioId: 2,
+			input:   storage.HistoryListParam{Offset: &offset, Limit: &limit},
+			mockBehavior: func(s *mock_service.MockAudio, userId, audioId int, input storage.HistoryListParam) {
+				s.EXPECT().GetAudioHistory(userId, audioId, input).Return(storage.HistoryListJson{}, storage.NotOwner)
+			},
+			expectedStatusCode:   404,
+			expectedResponseBody: `{"message":"you are not owner or audio not exists"}`,
+		},
+		{
+			name:    "Service error",
+			userId:  1,
+			audioId: 2,
+			input:   storage.HistoryListParam{Offset: &offset, Limit: &limit},
+			mockBehavior: func(s *mock_service.MockAudio, userId, audioId int, input storage.HistoryListParam) {
+				s.EXPECT().GetAudioHistory(userId, audioId, input).Return(storage.HistoryListJson{}, errors.New("service error"))
+			},
+			expectedStatusCode:   500,
+			expectedResponseBody: `{"message":"service error"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			audio := mock_service.NewMockAudio(c)
+			testCase.mockBehavior(audio, testCase.userId, testCase.audioId, testCase.input)
+
+			services := &service.Service{Audio: audio}
+			handler := NewHandler(services)
+
+			r := gin.New()
+			r.GET("/audio/:id/history", func(c *gin.Context) {
+				c.Set(userCtx, testCase.userId)
+			}, handler.getAudioHistory)
+
+			w := httptest.NewRecorder()
+			target := fmt.Sprintf("/audio/%d/history", testCase.audioId)
+			if testCase.audioId == 0 {
+				target = "/audio/wrong_id/history"
+			}
+			params := url.Values{"offset": {strconv.Itoa(offset)}, "limit": {strconv.Itoa(limit)}}.Encode()
+			req := httptest.NewRequest("GET", target+"?"+params, nil)
+
 			r.ServeHTTP(w, req)
 			assert.Equal(t, testCase.expectedStatusCode, w.Code)
 			assert.Equal(t, testCase.expectedResponseBody, w.Body.String())
@@ -269,7 +944,7 @@ func TestHandler_addDescription(t *testing.T) {
 }
 
 func TestHandler_uploadAudio(t *testing.T) {
-	type mockBehavior func(s1 *mock_service.MockAudio, s2 *mock_service.MockStorage, userId int)
+	type mockBehavior func(s1 *mock_service.MockAudio, s2 *mock_service.MockStorage, s3 *mock_service.MockArtwork, s4 *mock_service.MockTranscription, s5 *mock_service.MockJob, s6 *mock_service.MockChapter, userId int)
 
 	testTable := []struct {
 		name                 string
@@ -282,34 +957,157 @@ func TestHandler_uploadAudio(t *testing.T) {
 		{
 			name:   "OK",
 			userId: 1,
-			mockBehavior: func(s1 *mock_service.MockAudio, s2 *mock_service.MockStorage, userId int) {
-				s1.EXPECT().UploadFile(userId, gomock.AssignableToTypeOf("")).Return(1, nil)
-				ioInterface := reflect.TypeOf((*io.ReadCloser)(nil)).Elem()
-				s2.EXPECT().StoreFile(gomock.AssignableToTypeOf(uuid.UUID{}), gomock.AssignableToTypeOf(ioInterface)).Return(nil)
+			mockBehavior: func(s1 *mock_service.MockAudio, s2 *mock_service.MockStorage, s3 *mock_service.MockArtwork, s4 *mock_service.MockTranscription, s5 *mock_service.MockJob, s6 *mock_service.MockChapter, userId int) {
+				s1.EXPECT().UploadFile(userId, gomock.AssignableToTypeOf(""), int64(10), storage.AudioMetadata{Title: "title", Artist: "artist"}).Return(1, nil)
+				ioInterface := reflect.TypeOf((*io.ReaderAt)(nil)).Elem()
+				s2.EXPECT().StoreFile(gomock.AssignableToTypeOf(uuid.UUID{}), gomock.AssignableToTypeOf(ioInterface), int64(len("file content"))).
+					Return(storage.StoredFile{Size: 10, Tags: storage.AudioTags{Title: "title", Artist: "artist", Album: string([]byte{0xFF})}}, nil)
+				s5.EXPECT().ProcessAudio(1).Return(nil)
+				s4.EXPECT().StartTranscription(userId, 1).Return(nil)
 			},
 			expectedStatusCode:   200,
 			expectedResponseBody: `{"id":1}`,
 		},
 		{
-			name:                 "Wrong form key",
-			userId:               1,
-			wrongFormKey:         true,
-			mockBehavior:         func(s1 *mock_service.MockAudio, s2 *mock_service.MockStorage, userId int) {},
+			name:   "OK invalid cover skipped",
+			userId: 1,
+			mockBehavior: func(s1 *mock_service.MockAudio, s2 *mock_service.MockStorage, s3 *mock_service.MockArtwork, s4 *mock_service.MockTranscription, s5 *mock_service.MockJob, s6 *mock_service.MockChapter, userId int) {
+				ioInterface := reflect.TypeOf((*io.ReaderAt)(nil)).Elem()
+				s2.EXPECT().StoreFile(gomock.AssignableToTypeOf(uuid.UUID{}), gomock.AssignableToTypeOf(ioInterface), int64(len("file content"))).
+					Return(storage.StoredFile{Size: 12, Tags: storage.AudioTags{Cover: []byte("cover")}}, nil)
+				s1.EXPECT().UploadFile(userId, gomock.AssignableToTypeOf(""), int64(12), storage.AudioMetadata{}).Return(1, nil)
+				s3.EXPECT().SetAudioArtwork(userId, 1, []byte("cover")).Return(storage.InvalidArtwork)
+				s5.EXPECT().ProcessAudio(1).Return(nil)
+				s4.EXPECT().StartTranscription(userId, 1).Return(nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"id":1}`,
+		},
+		{
+			name:   "OK chapters imported",
+			userId: 1,
+			mockBehavior: func(s1 *mock_service.MockAudio, s2 *mock_service.MockStorage, s3 *mock_service.MockArtwork, s4 *mock_service.MockTranscription, s5 *mock_service.MockJob, s6 *mock_service.MockChapter, userId int) {
+				chapters := []storage.Chapter{{StartMs: 0, Title: "intro"}, {StartMs: 5000, Title: "main", Url: "https://example.com"}}
+				ioInterface := reflect.TypeOf((*io.ReaderAt)(nil)).Elem()
+				s2.EXPECT().StoreFile(gomock.AssignableToTypeOf(uuid.UUID{}), gomock.AssignableToTypeOf(ioInterface), int64(len("file content"))).
+					Return(storage.StoredFile{Size: 12, Tags: storage.AudioTags{Chapters: chapters}}, nil)
+				s1.EXPECT().UploadFile(userId, gomock.AssignableToTypeOf(""), int64(12), storage.AudioMetadata{}).Return(1, nil)
+				s6.EXPECT().SetChapters(userId, 1, chapters).Return(nil)
+				s5.EXPECT().ProcessAudio(1).Return(nil)
+				s4.EXPECT().StartTranscription(userId, 1).Return(nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"id":1}`,
+		},
+		{
+			name:   "OK invalid chapters skipped",
+			userId: 1,
+			mockBehavior: func(s1 *mock_service.MockAudio, s2 *mock_service.MockStorage, s3 *mock_service.MockArtwork, s4 *mock_service.MockTranscription, s5 *mock_service.MockJob, s6 *mock_service.MockChapter, userId int) {
+				ioInterface := reflect.TypeOf((*io.ReaderAt)(nil)).Elem()
+				s2.EXPECT().StoreFile(gomock.AssignableToTypeOf(uuid.UUID{}), gomock.AssignableToTypeOf(ioInterface), int64(len("file content"))).
+					Return(storage.StoredFile{Size: 12, Tags: storage.AudioTags{Chapters: []storage.Chapter{{StartMs: 0, Title: "a"}, {StartMs: 0, Title: "b"}}}}, nil)
+				s1.EXPECT().UploadFile(userId, gomock.AssignableToTypeOf(""), int64(12), storage.AudioMetadata{}).Return(1, nil)
+				s5.EXPECT().ProcessAudio(1).Return(nil)
+				s4.EXPECT().StartTranscription(userId, 1).Return(nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"id":1}`,
+		},
+		{
+			name:   "OK set chapters error logged",
+			userId: 1,
+			mockBehavior: func(s1 *mock_service.MockAudio, s2 *mock_service.MockStorage, s3 *mock_service.MockArtwork, s4 *mock_service.MockTranscription, s5 *mock_service.MockJob, s6 *mock_service.MockChapter, userId int) {
+				chapters := []storage.Chapter{{StartMs: 0, Title: "intro"}}
+				ioInterface := reflect.TypeOf((*io.ReaderAt)(nil)).Elem()
+				s2.EXPECT().StoreFile(gomock.AssignableToTypeOf(uuid.UUID{}), gomock.AssignableToTypeOf(ioInterface), int64(len("file content"))).
+					Return(storage.StoredFile{Size: 12, Tags: storage.AudioTags{Chapters: chapters}}, nil)
+				s1.EXPECT().UploadFile(userId, gomock.AssignableToTypeOf(""), int64(12), storage.AudioMetadata{}).Return(1, nil)
+				s6.EXPECT().SetChapters(userId, 1, chapters).Return(errors.New("storage error"))
+				s5.EXPECT().ProcessAudio(1).Return(nil)
+				s4.EXPECT().StartTranscription(userId, 1).Return(nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"id":1}`,
+		},
+		{
+			name:   "OK transcription disabled",
+			userId: 1,
+			mockBehavior: func(s1 *mock_service.MockAudio, s2 *mock_service.MockStorage, s3 *mock_service.MockArtwork, s4 *mock_service.MockTranscription, s5 *mock_service.MockJob, s6 *mock_service.MockChapter, userId int) {
+				ioInterface := reflect.TypeOf((*io.ReaderAt)(nil)).Elem()
+				s2.EXPECT().StoreFile(gomock.AssignableToTypeOf(uuid.UUID{}), gomock.AssignableToTypeOf(ioInterface), int64(len("file content"))).
+					Return(storage.StoredFile{Size: 12}, nil)
+				s1.EXPECT().UploadFile(userId, gomock.AssignableToTypeOf(""), int64(12), storage.AudioMetadata{}).Return(1, nil)
+				s5.EXPECT().ProcessAudio(1).Return(nil)
+				s4.EXPECT().StartTranscription(userId, 1).Return(storage.TranscriptionDisabled)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"id":1}`,
+		},
+		{
+			name:   "OK start transcription error logged",
+			userId: 1,
+			mockBehavior: func(s1 *mock_service.MockAudio, s2 *mock_service.MockStorage, s3 *mock_service.MockArtwork, s4 *mock_service.MockTranscription, s5 *mock_service.MockJob, s6 *mock_service.MockChapter, userId int) {
+				ioInterface := reflect.TypeOf((*io.ReaderAt)(nil)).Elem()
+				s2.EXPECT().StoreFile(gomock.AssignableToTypeOf(uuid.UUID{}), gomock.AssignableToTypeOf(ioInterface), int64(len("file content"))).
+					Return(storage.StoredFile{Size: 12}, nil)
+				s1.EXPECT().UploadFile(userId, gomock.AssignableToTypeOf(""), int64(12), storage.AudioMetadata{}).Return(1, nil)
+				s5.EXPECT().ProcessAudio(1).Return(nil)
+				s4.EXPECT().StartTranscription(userId, 1).Return(errors.New("queue error"))
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"id":1}`,
+		},
+		{
+			name:   "OK queue jobs error logged",
+			userId: 1,
+			mockBehavior: func(s1 *mock_service.MockAudio, s2 *mock_service.MockStorage, s3 *mock_service.MockArtwork, s4 *mock_service.MockTranscription, s5 *mock_service.MockJob, s6 *mock_service.MockChapter, userId int) {
+				ioInterface := reflect.TypeOf((*io.ReaderAt)(nil)).Elem()
+				s2.EXPECT().StoreFile(gomock.AssignableToTypeOf(uuid.UUID{}), gomock.AssignableToTypeOf(ioInterface), int64(len("file content"))).
+					Return(storage.StoredFile{Size: 12}, nil)
+				s1.EXPECT().UploadFile(userId, gomock.AssignableToTypeOf(""), int64(12), storage.AudioMetadata{}).Return(1, nil)
+				s5.EXPECT().ProcessAudio(1).Return(errors.New("queue error"))
+				s4.EXPECT().StartTranscription(userId, 1).Return(nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"id":1}`,
+		},
+		{
+			name:   "OK set cover error logged",
+			userId: 1,
+			mockBehavior: func(s1 *mock_service.MockAudio, s2 *mock_service.MockStorage, s3 *mock_service.MockArtwork, s4 *mock_service.MockTranscription, s5 *mock_service.MockJob, s6 *mock_service.MockChapter, userId int) {
+				ioInterface := reflect.TypeOf((*io.ReaderAt)(nil)).Elem()
+				s2.EXPECT().StoreFile(gomock.AssignableToTypeOf(uuid.UUID{}), gomock.AssignableToTypeOf(ioInterface), int64(len("file content"))).
+					Return(storage.StoredFile{Size: 12, Tags: storage.AudioTags{Cover: []byte("cover")}}, nil)
+				s1.EXPECT().UploadFile(userId, gomock.AssignableToTypeOf(""), int64(12), storage.AudioMetadata{}).Return(1, nil)
+				s3.EXPECT().SetAudioArtwork(userId, 1, []byte("cover")).Return(errors.New("storage error"))
+				s5.EXPECT().ProcessAudio(1).Return(nil)
+				s4.EXPECT().StartTranscription(userId, 1).Return(nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"id":1}`,
+		},
+		{
+			name:         "Wrong form key",
+			userId:       1,
+			wrongFormKey: true,
+			mockBehavior: func(s1 *mock_service.MockAudio, s2 *mock_service.MockStorage, s3 *mock_service.MockArtwork, s4 *mock_service.MockTranscription, s5 *mock_service.MockJob, s6 *mock_service.MockChapter, userId int) {
+			},
 			expectedStatusCode:   400,
 			expectedResponseBody: `{"message":"http: no such file"}`,
 		},
 		{
-			name:                 "User not found",
-			mockBehavior:         func(s1 *mock_service.MockAudio, s2 *mock_service.MockStorage, userId int) {},
+			name: "User not found",
+			mockBehavior: func(s1 *mock_service.MockAudio, s2 *mock_service.MockStorage, s3 *mock_service.MockArtwork, s4 *mock_service.MockTranscription, s5 *mock_service.MockJob, s6 *mock_service.MockChapter, userId int) {
+			},
 			expectedStatusCode:   500,
 			expectedResponseBody: `{"message":"user id not found"}`,
 		},
 		{
 			name:   "Save file error",
 			userId: 1,
-			mockBehavior: func(s1 *mock_service.MockAudio, s2 *mock_service.MockStorage, userId int) {
-				ioInterface := reflect.TypeOf((*io.ReadCloser)(nil)).Elem()
-				s2.EXPECT().StoreFile(gomock.AssignableToTypeOf(uuid.UUID{}), gomock.AssignableToTypeOf(ioInterface)).Return(errors.New("save file error"))
+			mockBehavior: func(s1 *mock_service.MockAudio, s2 *mock_service.MockStorage, s3 *mock_service.MockArtwork, s4 *mock_service.MockTranscription, s5 *mock_service.MockJob, s6 *mock_service.MockChapter, userId int) {
+				ioInterface := reflect.TypeOf((*io.ReaderAt)(nil)).Elem()
+				s2.EXPECT().StoreFile(gomock.AssignableToTypeOf(uuid.UUID{}), gomock.AssignableToTypeOf(ioInterface), int64(len("file content"))).Return(storage.StoredFile{}, errors.New("save file error"))
 			},
 			expectedStatusCode:   500,
 			expectedResponseBody: `{"message":"save file error"}`,
@@ -317,10 +1115,10 @@ func TestHandler_uploadAudio(t *testing.T) {
 		{
 			name:   "Store data to DB error",
 			userId: 1,
-			mockBehavior: func(s1 *mock_service.MockAudio, s2 *mock_service.MockStorage, userId int) {
-				s1.EXPECT().UploadFile(userId, gomock.AssignableToTypeOf("")).Return(0, errors.New("store data to DB error"))
-				ioInterface := reflect.TypeOf((*io.ReadCloser)(nil)).Elem()
-				s2.EXPECT().StoreFile(gomock.AssignableToTypeOf(uuid.UUID{}), gomock.AssignableToTypeOf(ioInterface)).Return(nil)
+			mockBehavior: func(s1 *mock_service.MockAudio, s2 *mock_service.MockStorage, s3 *mock_service.MockArtwork, s4 *mock_service.MockTranscription, s5 *mock_service.MockJob, s6 *mock_service.MockChapter, userId int) {
+				s1.EXPECT().UploadFile(userId, gomock.AssignableToTypeOf(""), int64(12), storage.AudioMetadata{}).Return(0, errors.New("store data to DB error"))
+				ioInterface := reflect.TypeOf((*io.ReaderAt)(nil)).Elem()
+				s2.EXPECT().StoreFile(gomock.AssignableToTypeOf(uuid.UUID{}), gomock.AssignableToTypeOf(ioInterface), int64(len("file content"))).Return(storage.StoredFile{Size: 12}, nil)
 			},
 			expectedStatusCode:   500,
 			expectedResponseBody: `{"message":"store data to DB error"}`,
@@ -334,10 +1132,14 @@ func TestHandler_uploadAudio(t *testing.T) {
 
 			audio := mock_service.NewMockAudio(c)
 			strg := mock_service.NewMockStorage(c)
+			artwork := mock_service.NewMockArtwork(c)
+			transcription := mock_service.NewMockTranscription(c)
+			job := mock_service.NewMockJob(c)
+			chapter := mock_service.NewMockChapter(c)
 
-			testCase.mockBehavior(audio, strg, testCase.userId)
+			testCase.mockBehavior(audio, strg, artwork, transcription, job, chapter, testCase.userId)
 
-			services := &service.Service{Audio: audio, Storage: strg}
+			services := &service.Service{Audio: audio, Storage: strg, Artwork: artwork, Transcription: transcription, Job: job, Chapter: chapter}
 			handler := NewHandler(services)
 
 			r := gin.New()
@@ -376,12 +1178,13 @@ func TestHandler_uploadAudio(t *testing.T) {
 }
 
 func TestHandler_downloadAudio(t *testing.T) {
-	type mockBehavior func(s1 *mock_service.MockAudio, s2 *mock_service.MockStorage, userId, audioId int, fileId uuid.UUID, fileContent string)
+	type mockBehavior func(s1 *mock_service.MockAudio, s2 *mock_service.MockStorage, s3 *mock_service.MockArtwork, s4 *mock_service.MockChapter, userId, audioId int, fileId uuid.UUID, fileContent string)
 
 	testTable := []struct {
 		name                 string
 		userId               int
 		audioId              int
+		query                string
 		fileId               uuid.UUID
 		fileContent          string
 		mockBehavior         mockBehavior
@@ -395,7 +1198,7 @@ func TestHandler_downloadAudio(t *testing.T) {
 			audioId:     1,
 			fileId:      uuid.New(),
 			fileContent: "file content",
-			mockBehavior: func(s1 *mock_service.MockAudio, s2 *mock_service.MockStorage, userId, audioId int, fileId uuid.UUID, fileContent string) {
+			mockBehavior: func(s1 *mock_service.MockAudio, s2 *mock_service.MockStorage, s3 *mock_service.MockArtwork, s4 *mock_service.MockChapter, userId, audioId int, fileId uuid.UUID, fileContent string) {
 				s1.EXPECT().DownloadFile(userId, audioId).Return(storage.DownloadAudio{Title: "audio", FilePath: fileId.String()}, nil)
 				r := io.NopCloser(strings.NewReader(fileContent))
 				s2.EXPECT().GetFile(fileId).Return(r, int64(len(fileContent)), nil)
@@ -404,9 +1207,73 @@ func TestHandler_downloadAudio(t *testing.T) {
 			expectedLenBody:      len("file content"),
 			expectedResponseBody: "file content",
 		},
+		{
+			name:        "OK with ID3 tag",
+			userId:      1,
+			audioId:     1,
+			query:       "?id3=true",
+			fileId:      uuid.New(),
+			fileContent: "tagged content",
+			mockBehavior: func(s1 *mock_service.MockAudio, s2 *mock_service.MockStorage, s3 *mock_service.MockArtwork, s4 *mock_service.MockChapter, userId, audioId int, fileId uuid.UUID, fileContent string) {
+				s1.EXPECT().DownloadFile(userId, audioId).Return(storage.DownloadAudio{Title: "audio", Artist: "artist", Album: "album", FilePath: fileId.String()}, nil)
+				s3.EXPECT().GetAudioArtwork(userId, audioId, storage.ArtworkLarge).Return(storage.Artwork{Id: "artwork", Data: []byte("cover")}, nil)
+				chapters := []storage.Chapter{{StartMs: 0, Title: "intro"}}
+				s4.EXPECT().GetChapters(userId, audioId).Return(storage.AudioChapters{Duration: 60, Chapters: chapters}, nil)
+				r := io.NopCloser(strings.NewReader(fileContent))
+				s2.EXPECT().GetTaggedFile(fileId, storage.AudioTags{Title: "audio", Artist: "artist", Album: "album", Cover: []byte("cover"), Chapters: chapters, Duration: 60}).Return(r, int64(len(fileContent)), nil)
+			},
+			expectedStatusCode:   200,
+			expectedLenBody:      len("tagged content"),
+			expectedResponseBody: "tagged content",
+		},
+		{
+			name:        "OK with ID3 tag without artwork",
+			userId:      1,
+			audioId:     1,
+			query:       "?id3=1",
+			fileId:      uuid.New(),
+			fileContent: "tagged content",
+			mockBehavior: func(s1 *mock_service.MockAudio, s2 *mock_service.MockStorage, s3 *mock_service.MockArtwork, s4 *mock_service.MockChapter, userId, audioId int, fileId uuid.UUID, fileContent string) {
+				s1.EXPECT().DownloadFile(userId, audioId).Return(storage.DownloadAudio{Title: "audio", FilePath: fileId.String()}, nil)
+				s3.EXPECT().GetAudioArtwork(userId, audioId, storage.ArtworkLarge).Return(storage.Artwork{}, storage.ArtworkNotFound)
+				s4.EXPECT().GetChapters(userId, audioId).Return(storage.AudioChapters{Duration: 60, Chapters: []storage.Chapter{}}, nil)
+				r := io.NopCloser(strings.NewReader(fileContent))
+				s2.EXPECT().GetTaggedFile(fileId, storage.AudioTags{Title: "audio", Chapters: []storage.Chapter{}, Duration: 60}).Return(r, int64(len(fileContent)), nil)
+			},
+			expectedStatusCode:   200,
+			expectedLenBody:      len("tagged content"),
+			expectedResponseBody: "tagged content",
+		},
+		{
+			name:        "Get chapters error",
+			userId:      1,
+			audioId:     1,
+			query:       "?id3=true",
+			fileId:      uuid.New(),
+			fileContent: "tagged content",
+			mockBehavior: func(s1 *mock_service.MockAudio, s2 *mock_service.MockStorage, s3 *mock_service.MockArtwork, s4 *mock_service.MockChapter, userId, audioId int, fileId uuid.UUID, fileContent string) {
+				s1.EXPECT().DownloadFile(userId, audioId).Return(storage.DownloadAudio{Title: "audio", FilePath: fileId.String()}, nil)
+				s3.EXPECT().GetAudioArtwork(userId, audioId, storage.ArtworkLarge).Return(storage.Artwork{}, storage.ArtworkNotFound)
+				s4.EXPECT().GetChapters(userId, audioId).Return(storage.AudioChapters{}, errors.New("service not work"))
+			},
+			expectedStatusCode:   500,
+			expectedLenBody:      len(`{"message":"service not work"}`),
+			expectedResponseBody: `{"message":"service not work"}`,
+		},
+		{
+			name:    "Invalid id3 param",
+			userId:  1,
+			audioId: 1,
+			query:   "?id3=maybe",
+			mockBehavior: func(s1 *mock_service.MockAudio, s2 *mock_service.MockStorage, s3 *mock_service.MockArtwork, s4 *mock_service.MockChapter, userId, audioId int, fileId uuid.UUID, fileContent string) {
+			},
+			expectedStatusCode:   400,
+			expectedLenBody:      len(`{"message":"invalid id3 param"}`),
+			expectedResponseBody: `{"message":"invalid id3 param"}`,
+		},
 		{
 			name: "User not found",
-			mockBehavior: func(s1 *mock_service.MockAudio, s2 *mock_service.MockStorage, userId, audioId int, fileId uuid.UUID, fileContent string) {
+			mockBehavior: func(s1 *mock_service.MockAudio, s2 *mock_service.MockStorage, s3 *mock_service.MockArtwork, s4 *mock_service.MockChapter, userId, audioId int, fileId uuid.UUID, fileContent string) {
 			},
 			expectedStatusCode:   500,
 			expectedLenBody:      len(`{"message":"user id not found"}`),
@@ -416,7 +1283,7 @@ func TestHandler_downloadAudio(t *testing.T) {
 			name:    "Invalid audio id",
 			userId:  1,
 			audioId: 0,
-			mockBehavior: func(s1 *mock_service.MockAudio, s2 *mock_service.MockStorage, userId, audioId int, fileId uuid.UUID, fileContent string) {
+			mockBehavior: func(s1 *mock_service.MockAudio, s2 *mock_service.MockStorage, s3 *mock_service.MockArtwork, s4 *mock_service.MockChapter, userId, audioId int, fileId uuid.UUID, fileContent string) {
 			},
 			expectedStatusCode:   400,
 			expectedLenBody:      len(`{"message":"invalid audio id param"}`),
@@ -428,7 +1295,7 @@ func TestHandler_downloadAudio(t *testing.T) {
 			audioId:     1,
 			fileId:      uuid.New(),
 			fileContent: "file content",
-			mockBehavior: func(s1 *mock_service.MockAudio, s2 *mock_service.MockStorage, userId, audioId int, fileId uuid.UUID, fileContent string) {
+			mockBehavior: func(s1 *mock_service.MockAudio, s2 *mock_service.MockStorage, s3 *mock_service.MockArtwork, s4 *mock_service.MockChapter, userId, audioId int, fileId uuid.UUID, fileContent string) {
 				s1.EXPECT().DownloadFile(userId, audioId).Return(storage.DownloadAudio{}, errors.New("service not work"))
 			},
 			expectedStatusCode:   500,
@@ -441,7 +1308,7 @@ func TestHandler_downloadAudio(t *testing.T) {
 			audioId:     1,
 			fileId:      uuid.New(),
 			fileContent: "file content",
-			mockBehavior: func(s1 *mock_service.MockAudio, s2 *mock_service.MockStorage, userId, audioId int, fileId uuid.UUID, fileContent string) {
+			mockBehavior: func(s1 *mock_service.MockAudio, s2 *mock_service.MockStorage, s3 *mock_service.MockArtwork, s4 *mock_service.MockChapter, userId, audioId int, fileId uuid.UUID, fileContent string) {
 				s1.EXPECT().DownloadFile(userId, audioId).Return(storage.DownloadAudio{Title: "audio", FilePath: "wrong uuid"}, nil)
 			},
 			expectedStatusCode:   500,
@@ -454,7 +1321,7 @@ func TestHandler_downloadAudio(t *testing.T) {
 			audioId:     1,
 			fileId:      uuid.New(),
 			fileContent: "file content",
-			mockBehavior: func(s1 *mock_service.MockAudio, s2 *mock_service.MockStorage, userId, audioId int, fileId uuid.UUID, fileContent string) {
+			mockBehavior: func(s1 *mock_service.MockAudio, s2 *mock_service.MockStorage, s3 *mock_service.MockArtwork, s4 *mock_service.MockChapter, userId, audioId int, fileId uuid.UUID, fileContent string) {
 				s1.EXPECT().DownloadFile(userId, audioId).Return(storage.DownloadAudio{Title: "audio", FilePath: fileId.String()}, nil)
 				s2.EXPECT().GetFile(fileId).Return(nil, int64(0), errors.New("can't get file"))
 			},
@@ -471,10 +1338,12 @@ func TestHandler_downloadAudio(t *testing.T) {
 
 			audio := mock_service.NewMockAudio(c)
 			strg := mock_service.NewMockStorage(c)
+			artwork := mock_service.NewMockArtwork(c)
+			chapter := mock_service.NewMockChapter(c)
 
-			testCase.mockBehavior(audio, strg, testCase.userId, testCase.audioId, testCase.fileId, testCase.fileContent)
+			testCase.mockBehavior(audio, strg, artwork, chapter, testCase.userId, testCase.audioId, testCase.fileId, testCase.fileContent)
 
-			services := &service.Service{Audio: audio, Storage: strg}
+			services := &service.Service{Audio: audio, Storage: strg, Artwork: artwork, Chapter: chapter}
 			handler := NewHandler(services)
 
 			r := gin.New()
@@ -492,7 +1361,7 @@ func TestHandler_downloadAudio(t *testing.T) {
 			if testCase.audioId == 0 {
 				url = fmt.Sprintf("/download/%s", "wrong_id")
 			}
-			req := httptest.NewRequest("GET", url, nil)
+			req := httptest.NewRequest("GET", url+testCase.query, nil)
 
 			r.ServeHTTP(w, req)
 			assert.Equal(t, testCase.expectedStatusCode, w.Code)
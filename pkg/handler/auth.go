@@ -45,8 +45,9 @@ func (h *Handler) signUp(c *gin.Context) {
 }
 
 type signInInput struct {
-	Username string `json:"username" binding:"required"`
-	Password string `json:"password" binding:"required"`
+	Username   string `json:"username" binding:"required"`
+	Password   string `json:"password" binding:"required"`
+	DeviceName string `json:"device_name" binding:"max=100"`
 }
 
 type refreshTokensInput struct {
@@ -55,7 +56,7 @@ type refreshTokensInput struct {
 
 // @Summary SignIn
 // @Tags auth
-// @Description login
+// @Description login, every login opens new session
 // @ID login
 // @Accept  json
 // @Produce  json
@@ -79,7 +80,7 @@ func (h *Handler) signIn(c *gin.Context) {
 		return
 	}
 
-	refreshToken, err := h.services.Authorization.GenerateRefreshToken(userId)
+	refreshToken, err := h.services.Authorization.GenerateRefreshToken(userId, sessionClient(c, input.DeviceName))
 	if err != nil {
 		newErrorResponse(c, http.StatusInternalServerError, err.Error())
 		return
@@ -93,13 +94,13 @@ func (h *Handler) signIn(c *gin.Context) {
 
 // @Summary Refresh tokens
 // @Tags auth
-// @Description Generate new refresh and access tokens
+// @Description Generate new refresh and access tokens, refresh token can be used once. Reusing it revokes its session and every access token of user
 // @ID refresh
 // @Accept  json
 // @Produce  json
 // @Param input body refreshTokensInput true "refresh token"
 // @Success 200 {object} tokensResponse
-// @Failure 400,404 {object} errorResponse
+// @Failure 400,401,404 {object} errorResponse
 // @Failure 500 {object} errorResponse
 // @Failure default {object} errorResponse
 // @Router /auth/refresh [post]
@@ -110,9 +111,9 @@ func (h *Handler) refreshTokens(c *gin.Context) {
 		return
 	}
 
-	userId, newRefreshToken, err := h.services.UpdateRefreshToken(input.RefreshTooken)
+	userId, newRefreshToken, err := h.services.UpdateRefreshToken(input.RefreshTooken, sessionClient(c, ""))
 	if err != nil {
-		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		newRefreshErrorResponse(c, err)
 		return
 	}
 
@@ -127,3 +128,81 @@ func (h *Handler) refreshTokens(c *gin.Context) {
 		"refresh_token": newRefreshToken,
 	})
 }
+
+// @Summary Logout
+// @Security ApiKeyAuth
+// @Tags auth
+// @Description revoke access token and refresh token of current session
+// @ID logout
+// @Accept  json
+// @Produce  json
+// @Param input body refreshTokensInput true "refresh token"
+// @Success 200 {object} statusResponse
+// @Failure 400,401 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /auth/logout [post]
+func (h *Handler) logout(c *gin.Context) {
+	var input refreshTokensInput
+	if err := c.BindJSON(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid input body")
+		return
+	}
+
+	if err := h.services.Logout(c.GetString(tokenCtx), input.RefreshTooken); err != nil {
+		newLogoutErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, statusResponse{"ok"})
+}
+
+// @Summary Logout from every session
+// @Security ApiKeyAuth
+// @Tags auth
+// @Description revoke every access and refresh token of user
+// @ID logout-all
+// @Produce  json
+// @Success 200 {object} statusResponse
+// @Failure 401 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /auth/logout-all [post]
+func (h *Handler) logoutAll(c *gin.Context) {
+	if err := h.services.LogoutAll(c.GetString(tokenCtx)); err != nil {
+		newLogoutErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, statusResponse{"ok"})
+}
+
+func newLogoutErrorResponse(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, storage.WrongRefreshToken):
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+	default:
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+	}
+}
+
+func newRefreshErrorResponse(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, storage.WrongRefreshToken), errors.Is(err, storage.RefreshTokenReused):
+		newErrorResponse(c, http.StatusUnauthorized, err.Error())
+	default:
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+	}
+}
+
+// @Summary JWKS
+// @Tags auth
+// @Description public keys access tokens are signed with, HS256 keys are secret and not listed
+// @ID jwks
+// @Produce  json
+// @Success 200 {object} storage.JWKS
+// @Router /.well-known/jwks.json [get]
+func (h *Handler) getJWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.services.JWKS())
+}
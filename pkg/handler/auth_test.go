@@ -38,6 +38,28 @@ func TestHandler_sigUp(t *testing.T) {
 			expectedStatusCode:  200,
 			expectedRequestBody: `{"id":1}`,
 		},
+		{
+			name:      "OK with email",
+			inputBody: `{"name":"User 1","username":"user_1","password":"qwerty_1","email":"user_1@example.com"}`,
+			inputUser: storage.User{
+				Name:     "User 1",
+				Username: "user_1",
+				Password: "qwerty_1",
+				Email:    "user_1@example.com",
+			},
+			mockBehavior: func(s *mock_service.MockAuthorization, user storage.User) {
+				s.EXPECT().CreateUser(user).Return(1, nil)
+			},
+			expectedStatusCode:  200,
+			expectedRequestBody: `{"id":1}`,
+		},
+		{
+			name:                "Invalid email",
+			inputBody:           `{"name":"User 1","username":"user_1","password":"qwerty_1","email":"user_1"}`,
+			mockBehavior:        func(s *mock_service.MockAuthorization, user storage.User) {},
+			expectedStatusCode:  400,
+			expectedRequestBody: `{"message":"invalid input body"}`,
+		},
 		{
 			name:                "Empty field",
 			inputBody:           "",
@@ -114,14 +136,16 @@ func TestHandler_sigIn(t *testing.T) {
 		{
 			name:      "OK",
 			userId:    1,
-			inputBody: `{"username":"user_1","password":"qwerty_1"}`,
+			inputBody: `{"username":"user_1","password":"qwerty_1","device_name":"laptop"}`,
 			inputUser: signInInput{
-				Username: "user_1",
-				Password: "qwerty_1",
+				Username:   "user_1",
+				Password:   "qwerty_1",
+				DeviceName: "laptop",
 			},
 			mockBehavior: func(s *mock_service.MockAuthorization, user signInInput, userId int) {
 				s.EXPECT().GenerateAccessToken(user.Username, user.Password).Return(userId, "token_string", nil)
-				s.EXPECT().GenerateRefreshToken(userId).Return("refresh_token_string", nil)
+				s.EXPECT().GenerateRefreshToken(userId, storage.SessionClient{DeviceName: "laptop", IP: "192.0.2.1"}).
+					Return("refresh_token_string", nil)
 			},
 			expectedStatusCode:   200,
 			expectedResponseBody: `{"token":"token_string","refresh_token":"refresh_token_string"}`,
@@ -143,7 +167,7 @@ func TestHandler_sigIn(t *testing.T) {
 			},
 			mockBehavior: func(s *mock_service.MockAuthorization, user signInInput, userId int) {
 				s.EXPECT().GenerateAccessToken(user.Username, user.Password).Return(userId, "token_string", nil)
-				s.EXPECT().GenerateRefreshToken(userId).Return("", errors.New("service error 1"))
+				s.EXPECT().GenerateRefreshToken(userId, storage.SessionClient{IP: "192.0.2.1"}).Return("", errors.New("service error 1"))
 			},
 			expectedStatusCode:   500,
 			expectedResponseBody: `{"message":"service error 1"}`,
@@ -207,7 +231,7 @@ func TestHandler_refreshTokens(t *testing.T) {
 				RefreshTooken: "refresh_token",
 			},
 			mockBehavior: func(s *mock_service.MockAuthorization, refreshToken refreshTokensInput, userId int) {
-				s.EXPECT().UpdateRefreshToken(refreshToken.RefreshTooken).Return(userId, "new_refresh_token_string", nil)
+				s.EXPECT().UpdateRefreshToken(refreshToken.RefreshTooken, storage.SessionClient{IP: "192.0.2.1"}).Return(userId, "new_refresh_token_string", nil)
 				s.EXPECT().UpdateAccessToken(userId).Return("new_token_string", nil)
 			},
 			expectedStatusCode:   200,
@@ -228,11 +252,24 @@ func TestHandler_refreshTokens(t *testing.T) {
 				RefreshTooken: "refresh_token",
 			},
 			mockBehavior: func(s *mock_service.MockAuthorization, refreshToken refreshTokensInput, userId int) {
-				s.EXPECT().UpdateRefreshToken(refreshToken.RefreshTooken).Return(0, "", errors.New("service error 1"))
+				s.EXPECT().UpdateRefreshToken(refreshToken.RefreshTooken, storage.SessionClient{IP: "192.0.2.1"}).Return(0, "", errors.New("service error 1"))
 			},
 			expectedStatusCode:   500,
 			expectedResponseBody: `{"message":"service error 1"}`,
 		},
+		{
+			name:      "Reused token",
+			inputBody: `{"refresh_token":"refresh_token"}`,
+			refreshToken: refreshTokensInput{
+				RefreshTooken: "refresh_token",
+			},
+			mockBehavior: func(s *mock_service.MockAuthorization, refreshToken refreshTokensInput, userId int) {
+				s.EXPECT().UpdateRefreshToken(refreshToken.RefreshTooken, storage.SessionClient{IP: "192.0.2.1"}).
+					Return(0, "", storage.RefreshTokenReused)
+			},
+			expectedStatusCode:   401,
+			expectedResponseBody: `{"message":"refresh token was already used, its session is revoked"}`,
+		},
 		{
 			name:      "Error service 2",
 			userId:    1,
@@ -241,7 +278,7 @@ func TestHandler_refreshTokens(t *testing.T) {
 				RefreshTooken: "refresh_token",
 			},
 			mockBehavior: func(s *mock_service.MockAuthorization, refreshToken refreshTokensInput, userId int) {
-				s.EXPECT().UpdateRefreshToken(refreshToken.RefreshTooken).Return(userId, "new_refresh_token_string", nil)
+				s.EXPECT().UpdateRefreshToken(refreshToken.RefreshTooken, storage.SessionClient{IP: "192.0.2.1"}).Return(userId, "new_refresh_token_string", nil)
 				s.EXPECT().UpdateAccessToken(userId).Return("", errors.New("service error 2"))
 			},
 			expectedStatusCode:   500,
@@ -272,3 +309,146 @@ func TestHandler_refreshTokens(t *testing.T) {
 		})
 	}
 }
+
+func TestHandler_logout(t *testing.T) {
+	type mockBehavior func(s *mock_service.MockAuthorization)
+
+	testTable := []struct {
+		name                 string
+		inputBody            string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:      "OK",
+			inputBody: `{"refresh_token":"refresh_token"}`,
+			mockBehavior: func(s *mock_service.MockAuthorization) {
+				s.EXPECT().Logout("token_string", "refresh_token").Return(nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"status":"ok"}`,
+		},
+		{
+			name:                 "Error invalid body",
+			mockBehavior:         func(s *mock_service.MockAuthorization) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"invalid input body"}`,
+		},
+		{
+			name:      "Error wrong refresh token",
+			inputBody: `{"refresh_token":"refresh_token"}`,
+			mockBehavior: func(s *mock_service.MockAuthorization) {
+				s.EXPECT().Logout("token_string", "refresh_token").Return(storage.WrongRefreshToken)
+			},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"token not found or expires in"}`,
+		},
+		{
+			name:      "Error service",
+			inputBody: `{"refresh_token":"refresh_token"}`,
+			mockBehavior: func(s *mock_service.MockAuthorization) {
+				s.EXPECT().Logout("token_string", "refresh_token").Return(errors.New("service error"))
+			},
+			expectedStatusCode:   500,
+			expectedResponseBody: `{"message":"service error"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			auth := mock_service.NewMockAuthorization(c)
+			testCase.mockBehavior(auth)
+
+			handler := NewHandler(&service.Service{Authorization: auth})
+
+			r := gin.New()
+			r.POST("/logout", func(c *gin.Context) {
+				c.Set(userCtx, 1)
+				c.Set(tokenCtx, "token_string")
+			}, handler.logout)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/logout", bytes.NewBufferString(testCase.inputBody))
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedResponseBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_logoutAll(t *testing.T) {
+	testTable := []struct {
+		name                 string
+		err                  error
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:                 "OK",
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"status":"ok"}`,
+		},
+		{
+			name:                 "Error service",
+			err:                  errors.New("service error"),
+			expectedStatusCode:   500,
+			expectedResponseBody: `{"message":"service error"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			auth := mock_service.NewMockAuthorization(c)
+			auth.EXPECT().LogoutAll("token_string").Return(testCase.err)
+
+			handler := NewHandler(&service.Service{Authorization: auth})
+
+			r := gin.New()
+			r.POST("/logout-all", func(c *gin.Context) {
+				c.Set(userCtx, 1)
+				c.Set(tokenCtx, "token_string")
+			}, handler.logoutAll)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/logout-all", nil)
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedResponseBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_getJWKS(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	auth := mock_service.NewMockAuthorization(c)
+	auth.EXPECT().JWKS().Return(storage.JWKS{Keys: []storage.JWK{
+		{Kty: "OKP", Kid: "ed", Use: "sig", Alg: "EdDSA", Crv: "Ed25519", X: "11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"},
+	}})
+
+	handler := NewHandler(&service.Service{Authorization: auth})
+
+	r := gin.New()
+	r.GET("/.well-known/jwks.json", handler.getJWKS)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/.well-known/jwks.json", nil)
+
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, "public, max-age=300", w.Header().Get("Cache-Control"))
+	assert.Equal(t, `{"keys":[{"kty":"OKP","kid":"ed","use":"sig","alg":"EdDSA","crv":"Ed25519","x":"11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"}]}`,
+		w.Body.String())
+}
@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	storage "github.com/mahadeva604/audio-storage"
+	"net/http"
+)
+
+// @Summary Get audio chapters
+// @Security ApiKeyAuth
+// @Tags chapter
+// @Description get chapters of audio ordered by start, as json, Podlove Simple Chapters JSON or WebVTT chapters
+// @ID get-chapters
+// @Produce  json,text/vtt
+// @Param id path int true "audio id"
+// @Param format query string false "chapters format, json by default" Enums(json,podlove,webvtt)
+// @Success 200 {object} storage.AudioChapters
+// @Failure 400,404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /api/audio/{id}/chapters [get]
+func (h *Handler) getChapters(c *gin.Context) {
+	userId, audioId, ok := getAudioParams(c)
+	if !ok {
+		return
+	}
+
+	var input storage.ChapterParam
+	if err := c.BindQuery(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid query")
+		return
+	}
+
+	chapters, err := h.services.GetChapters(userId, audioId)
+	if err != nil {
+		newChapterErrorResponse(c, err)
+		return
+	}
+
+	switch input.Format {
+	case storage.ChapterFormatPSC:
+		c.JSON(http.StatusOK, chapters.Podlove())
+	case storage.ChapterFormatVTT:
+		c.Data(http.StatusOK, "text/vtt; charset=utf-8", []byte(chapters.WebVTT()))
+	default:
+		c.JSON(http.StatusOK, chapters)
+	}
+}
+
+// @Summary Set audio chapters
+// @Security ApiKeyAuth
+// @Tags chapter
+// @Description replace chapters of own audio, each chapter lasts until the next one or the end of audio
+// @ID set-chapters
+// @Accept  json
+// @Produce  json
+// @Param id path int true "audio id"
+// @Param input body storage.ChaptersInput true "chapters"
+// @Success 200 {object} statusResponse
+// @Failure 400,404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /api/audio/{id}/chapters [put]
+func (h *Handler) setChapters(c *gin.Context) {
+	userId, audioId, ok := getAudioParams(c)
+	if !ok {
+		return
+	}
+
+	var input storage.ChaptersInput
+	if err := c.BindJSON(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid input body")
+		return
+	}
+
+	if err := input.Validate(); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := h.services.SetChapters(userId, audioId, input.Chapters); err != nil {
+		newChapterErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, statusResponse{"ok"})
+}
+
+// @Summary Delete audio chapters
+// @Security ApiKeyAuth
+// @Tags chapter
+// @Description delete all chapters of own audio
+// @ID delete-chapters
+// @Produce  json
+// @Param id path int true "audio id"
+// @Success 200 {object} statusResponse
+// @Failure 400,404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /api/audio/{id}/chapters [delete]
+func (h *Handler) deleteChapters(c *gin.Context) {
+	userId, audioId, ok := getAudioParams(c)
+	if !ok {
+		return
+	}
+
+	if err := h.services.SetChapters(userId, audioId, nil); err != nil {
+		newChapterErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, statusResponse{"ok"})
+}
+
+func newChapterErrorResponse(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, storage.FileNotFound), errors.Is(err, storage.NotOwner):
+		newErrorResponse(c, http.StatusNotFound, err.Error())
+	default:
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+	}
+}
@@ -0,0 +1,197 @@
+package handler
+
+import (
+	"bytes"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	storage "github.com/mahadeva604/audio-storage"
+	"github.com/mahadeva604/audio-storage/pkg/service"
+	mock_service "github.com/mahadeva604/audio-storage/pkg/service/mocks"
+	"github.com/stretchr/testify/assert"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHandler_getChapters(t *testing.T) {
+	type mockBehavior func(s *mock_service.MockChapter)
+
+	chapters := storage.AudioChapters{
+		Duration: 4000,
+		Chapters: []storage.Chapter{
+			{StartMs: 0, Title: "Intro"},
+			{StartMs: 3723004, Title: "Q --> A", Url: "https://example.com"},
+		},
+	}
+
+	testTable := []struct {
+		name                 string
+		target               string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedContentType  string
+		expectedResponseBody string
+	}{
+		{
+			name:   "OK json",
+			target: "/audio/2/chapters",
+			mockBehavior: func(s *mock_service.MockChapter) {
+				s.EXPECT().GetChapters(1, 2).Return(chapters, nil)
+			},
+			expectedStatusCode:  200,
+			expectedContentType: "application/json; charset=utf-8",
+			expectedResponseBody: `{"chapters":[{"start_ms":0,"title":"Intro"},` +
+				`{"start_ms":3723004,"title":"Q --\u003e A","url":"https://example.com"}]}`,
+		},
+		{
+			name:   "OK podlove",
+			target: "/audio/2/chapters?format=podlove",
+			mockBehavior: func(s *mock_service.MockChapter) {
+				s.EXPECT().GetChapters(1, 2).Return(chapters, nil)
+			},
+			expectedStatusCode:  200,
+			expectedContentType: "application/json; charset=utf-8",
+			expectedResponseBody: `[{"start":"00:00:00.000","title":"Intro"},` +
+				`{"start":"01:02:03.004","title":"Q --\u003e A","href":"https://example.com"}]`,
+		},
+		{
+			name:   "OK webvtt",
+			target: "/audio/2/chapters?format=webvtt",
+			mockBehavior: func(s *mock_service.MockChapter) {
+				s.EXPECT().GetChapters(1, 2).Return(chapters, nil)
+			},
+			expectedStatusCode:  200,
+			expectedContentType: "text/vtt; charset=utf-8",
+			expectedResponseBody: "WEBVTT\n\n1\n00:00:00.000 --> 01:02:03.004\nIntro\n" +
+				"\n2\n01:02:03.004 --> 01:06:40.000\nQ -> A\n",
+		},
+		{
+			name:                 "Invalid format",
+			target:               "/audio/2/chapters?format=xml",
+			mockBehavior:         func(s *mock_service.MockChapter) {},
+			expectedStatusCode:   400,
+			expectedContentType:  "application/json; charset=utf-8",
+			expectedResponseBody: `{"message":"invalid query"}`,
+		},
+		{
+			name:   "No access",
+			target: "/audio/2/chapters",
+			mockBehavior: func(s *mock_service.MockChapter) {
+				s.EXPECT().GetChapters(1, 2).Return(storage.AudioChapters{}, storage.FileNotFound)
+			},
+			expectedStatusCode:   404,
+			expectedContentType:  "application/json; charset=utf-8",
+			expectedResponseBody: `{"message":"file not found or you haven't access"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			chapter := mock_service.NewMockChapter(c)
+			testCase.mockBehavior(chapter)
+
+			handler := NewHandler(&service.Service{Chapter: chapter})
+
+			r := gin.New()
+			r.GET("/audio/:id/chapters", func(c *gin.Context) {
+				c.Set(userCtx, 1)
+			}, handler.getChapters)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", testCase.target, nil)
+
+			r.ServeHTTP(w, req)
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedContentType, w.Header().Get("Content-Type"))
+			assert.Equal(t, testCase.expectedResponseBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_setChapters(t *testing.T) {
+	type mockBehavior func(s *mock_service.MockChapter)
+
+	testTable := []struct {
+		name                 string
+		inputBody            string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:      "OK",
+			inputBody: `{"chapters":[{"start_ms":0,"title":"Intro"},{"start_ms":5000,"title":"Main","image":"https://example.com/a.png"}]}`,
+			mockBehavior: func(s *mock_service.MockChapter) {
+				s.EXPECT().SetChapters(1, 2, []storage.Chapter{
+					{StartMs: 0, Title: "Intro"},
+					{StartMs: 5000, Title: "Main", Image: "https://example.com/a.png"},
+				}).Return(nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"status":"ok"}`,
+		},
+		{
+			name:                 "Duplicate start",
+			inputBody:            `{"chapters":[{"start_ms":0,"title":"Intro"},{"start_ms":0,"title":"Main"}]}`,
+			mockBehavior:         func(s *mock_service.MockChapter) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"more than one chapter starts at 0 ms"}`,
+		},
+		{
+			name:                 "Empty title",
+			inputBody:            `{"chapters":[{"start_ms":0,"title":" "}]}`,
+			mockBehavior:         func(s *mock_service.MockChapter) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"title must be from 1 to 256 characters without control characters"}`,
+		},
+		{
+			name:                 "Invalid url",
+			inputBody:            `{"chapters":[{"start_ms":0,"title":"Intro","url":"javascript:alert(1)"}]}`,
+			mockBehavior:         func(s *mock_service.MockChapter) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"url must be http or https url up to 2048 characters"}`,
+		},
+		{
+			name:                 "No chapters",
+			inputBody:            `{}`,
+			mockBehavior:         func(s *mock_service.MockChapter) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"invalid input body"}`,
+		},
+		{
+			name:      "Not owner",
+			inputBody: `{"chapters":[{"start_ms":0,"title":"Intro"}]}`,
+			mockBehavior: func(s *mock_service.MockChapter) {
+				s.EXPECT().SetChapters(1, 2, []storage.Chapter{{StartMs: 0, Title: "Intro"}}).Return(storage.NotOwner)
+			},
+			expectedStatusCode:   404,
+			expectedResponseBody: `{"message":"you are not owner or audio not exists"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			chapter := mock_service.NewMockChapter(c)
+			testCase.mockBehavior(chapter)
+
+			handler := NewHandler(&service.Service{Chapter: chapter})
+
+			r := gin.New()
+			r.PUT("/audio/:id/chapters", func(c *gin.Context) {
+				c.Set(userCtx, 1)
+			}, handler.setChapters)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("PUT", "/audio/2/chapters", bytes.NewBufferString(testCase.inputBody))
+
+			r.ServeHTTP(w, req)
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedResponseBody, w.Body.String())
+		})
+	}
+}
@@ -0,0 +1,369 @@
+package handler
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	storage "github.com/mahadeva604/audio-storage"
+	"net/http"
+	"strconv"
+)
+
+// @Summary Get collection list
+// @Security ApiKeyAuth
+// @Tags collection
+// @Description get own collections and collections shared with you
+// @ID get-all-collections
+// @Accept  json
+// @Produce  json
+// @Param offset query integer true "offset" minimum(0)
+// @Param limit query integer true "limit"  minimum(1)
+// @Param order_type query string true "order type" Enums(owner,alphabet)
+// @Success 200 {object} storage.CollectionListJson
+// @Failure 400 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /api/collections/ [get]
+func (h *Handler) getAllCollections(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	var input storage.CollectionListParam
+	if err := c.BindQuery(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid query")
+		return
+	}
+
+	result, err := h.services.GetCollectionList(userId, input)
+	if err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// @Summary Create collection
+// @Security ApiKeyAuth
+// @Tags collection
+// @Description create collection
+// @ID create-collection
+// @Accept  json
+// @Produce  json
+// @Param input body storage.CollectionInput true "collection info"
+// @Success 200 {object} idResponse
+// @Failure 400 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /api/collections/ [post]
+func (h *Handler) createCollection(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	var input storage.CollectionInput
+	if err := c.BindJSON(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid input body")
+		return
+	}
+
+	collectionId, err := h.services.CreateCollection(userId, input)
+	if err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, idResponse{
+		ID: collectionId,
+	})
+}
+
+// @Summary Get collection items
+// @Security ApiKeyAuth
+// @Tags collection
+// @Description get audio of collection in collection order
+// @ID get-collection-items
+// @Accept  json
+// @Produce  json
+// @Param id path int true "collection id"
+// @Param offset query integer true "offset" minimum(0)
+// @Param limit query integer true "limit"  minimum(1)
+// @Success 200 {object} storage.CollectionItemsJson
+// @Failure 400,404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /api/collections/{id} [get]
+func (h *Handler) getCollectionItems(c *gin.Context) {
+	userId, collectionId, ok := getCollectionParams(c)
+	if !ok {
+		return
+	}
+
+	var input storage.CollectionItemsParam
+	if err := c.BindQuery(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid query")
+		return
+	}
+
+	result, err := h.services.GetCollectionItems(userId, collectionId, input)
+	if err != nil {
+		newCollectionErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// @Summary Rename collection
+// @Security ApiKeyAuth
+// @Tags collection
+// @Description rename collection
+// @ID update-collection
+// @Accept  json
+// @Produce  json
+// @Param id path int true "collection id"
+// @Param input body storage.CollectionInput true "collection info"
+// @Success 200 {object} statusResponse
+// @Failure 400,404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /api/collections/{id} [put]
+func (h *Handler) updateCollection(c *gin.Context) {
+	userId, collectionId, ok := getCollectionParams(c)
+	if !ok {
+		return
+	}
+
+	var input storage.CollectionInput
+	if err := c.BindJSON(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid input body")
+		return
+	}
+
+	if err := h.services.UpdateCollection(userId, collectionId, input); err != nil {
+		newCollectionErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, statusResponse{"ok"})
+}
+
+// @Summary Delete collection
+// @Security ApiKeyAuth
+// @Tags collection
+// @Description delete collection, audio in it is kept
+// @ID delete-collection
+// @Accept  json
+// @Produce  json
+// @Param id path int true "collection id"
+// @Success 200 {object} statusResponse
+// @Failure 400,404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /api/collections/{id} [delete]
+func (h *Handler) deleteCollection(c *gin.Context) {
+	userId, collectionId, ok := getCollectionParams(c)
+	if !ok {
+		return
+	}
+
+	if err := h.services.DeleteCollection(userId, collectionId); err != nil {
+		newCollectionErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, statusResponse{"ok"})
+}
+
+// @Summary Add audio to collection
+// @Security ApiKeyAuth
+// @Tags collection
+// @Description add own or shared with you audio to the end of collection
+// @ID add-collection-item
+// @Accept  json
+// @Produce  json
+// @Param id path int true "collection id"
+// @Param input body storage.CollectionItemInput true "audio to add"
+// @Success 200 {object} statusResponse
+// @Failure 400,404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /api/collections/{id}/items [post]
+func (h *Handler) addCollectionItem(c *gin.Context) {
+	userId, collectionId, ok := getCollectionParams(c)
+	if !ok {
+		return
+	}
+
+	var input storage.CollectionItemInput
+	if err := c.BindJSON(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid input body")
+		return
+	}
+
+	if err := h.services.AddCollectionItem(userId, collectionId, input.AudioId); err != nil {
+		newCollectionErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, statusResponse{"ok"})
+}
+
+// @Summary Remove audio from collection
+// @Security ApiKeyAuth
+// @Tags collection
+// @Description remove audio from collection
+// @ID remove-collection-item
+// @Accept  json
+// @Produce  json
+// @Param id path int true "collection id"
+// @Param audio_id path int true "audio id"
+// @Success 200 {object} statusResponse
+// @Failure 400,404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /api/collections/{id}/items/{audio_id} [delete]
+func (h *Handler) removeCollectionItem(c *gin.Context) {
+	userId, collectionId, ok := getCollectionParams(c)
+	if !ok {
+		return
+	}
+
+	audioId, err := strconv.Atoi(c.Param("audio_id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid audio id param")
+		return
+	}
+
+	if err := h.services.RemoveCollectionItem(userId, collectionId, audioId); err != nil {
+		newCollectionErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, statusResponse{"ok"})
+}
+
+// @Summary Reorder collection
+// @Security ApiKeyAuth
+// @Tags collection
+// @Description set new order of audio in collection, every audio of collection must be listed once
+// @ID reorder-collection
+// @Accept  json
+// @Produce  json
+// @Param id path int true "collection id"
+// @Param input body storage.CollectionOrderInput true "audio ids in new order"
+// @Success 200 {object} statusResponse
+// @Failure 400,404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /api/collections/{id}/items [put]
+func (h *Handler) reorderCollection(c *gin.Context) {
+	userId, collectionId, ok := getCollectionParams(c)
+	if !ok {
+		return
+	}
+
+	var input storage.CollectionOrderInput
+	if err := c.BindJSON(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid input body")
+		return
+	}
+
+	if err := h.services.ReorderCollection(userId, collectionId, input.AudioIds); err != nil {
+		newCollectionErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, statusResponse{"ok"})
+}
+
+// @Summary Share collection
+// @Security ApiKeyAuth
+// @Tags collection
+// @Description share collection, user gets access to all its audio
+// @ID share-collection
+// @Accept  json
+// @Produce  json
+// @Param id path int true "collection id"
+// @Param input body storage.ShareInput true "share to"
+// @Success 200 {object} statusResponse
+// @Failure 400,404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /api/collections/{id}/share [post]
+func (h *Handler) shareCollection(c *gin.Context) {
+	h.updateCollectionShare(c, h.services.ShareCollection)
+}
+
+// @Summary Unshare collection
+// @Security ApiKeyAuth
+// @Tags collection
+// @Description unshare collection
+// @ID unshare-collection
+// @Accept  json
+// @Produce  json
+// @Param id path int true "collection id"
+// @Param input body storage.ShareInput true "unshare from"
+// @Success 200 {object} statusResponse
+// @Failure 400,404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /api/collections/{id}/share [delete]
+func (h *Handler) unshareCollection(c *gin.Context) {
+	h.updateCollectionShare(c, h.services.UnshareCollection)
+}
+
+func (h *Handler) updateCollectionShare(c *gin.Context, update func(userID, collectionId, shareId int) error) {
+	userId, collectionId, ok := getCollectionParams(c)
+	if !ok {
+		return
+	}
+
+	var input storage.ShareInput
+	if err := c.BindJSON(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid input body")
+		return
+	}
+
+	if err := update(userId, collectionId, input.ShareTo); err != nil {
+		newCollectionErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, statusResponse{"ok"})
+}
+
+func getCollectionParams(c *gin.Context) (int, int, bool) {
+	userId, err := getUserId(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return 0, 0, false
+	}
+
+	collectionId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid collection id param")
+		return 0, 0, false
+	}
+
+	return userId, collectionId, true
+}
+
+func newCollectionErrorResponse(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, storage.CollectionNotFound), errors.Is(err, storage.NotCollectionOwner),
+		errors.Is(err, storage.CollectionItemNotFound):
+		newErrorResponse(c, http.StatusNotFound, err.Error())
+	case errors.Is(err, storage.CollectionItemExists), errors.Is(err, storage.CollectionItemNotAllowed),
+		errors.Is(err, storage.WrongCollectionOrder), errors.Is(err, storage.ShareExists),
+		errors.Is(err, storage.ShareUserNotExists), errors.Is(err, storage.SelfShareCollection),
+		errors.Is(err, storage.SelfUnshareCollection):
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+	default:
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+	}
+}
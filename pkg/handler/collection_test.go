@@ -0,0 +1,502 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	storage "github.com/mahadeva604/audio-storage"
+	"github.com/mahadeva604/audio-storage/pkg/service"
+	mock_service "github.com/mahadeva604/audio-storage/pkg/service/mocks"
+	"github.com/stretchr/testify/assert"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+)
+
+func TestHandler_getAllCollections(t *testing.T) {
+	type mockBehavior func(s *mock_service.MockCollection, userId int, input storage.CollectionListParam)
+
+	offset, limit := 0, 10
+
+	testTable := []struct {
+		name                 string
+		offset               string
+		limit                string
+		orderType            string
+		userId               int
+		input                storage.CollectionListParam
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:      "OK",
+			offset:    strconv.Itoa(offset),
+			limit:     strconv.Itoa(limit),
+			orderType: "alphabet",
+			userId:    1,
+			input: storage.CollectionListParam{
+				Offset:    &offset,
+				Limit:     &limit,
+				OrderType: "alphabet",
+			},
+			mockBehavior: func(s *mock_service.MockCollection, userId int, input storage.CollectionListParam) {
+				s.EXPECT().GetCollectionList(userId, input).Return(storage.CollectionListJson{
+					TotalCount: 1,
+					Records: []storage.CollectionList{
+						{
+							Id:         1,
+							Title:      "collection 1",
+							IsOwner:    true,
+							Owner:      1,
+							Name:       "user 1",
+							ItemsCount: 2,
+						},
+					},
+				}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"total_count":1,"records":[{"id":1,"title":"collection 1","is_owner":true,"owner_id":1,"owner_name":"user 1","items_count":2}]}`,
+		},
+		{
+			name:                 "User not found",
+			offset:               strconv.Itoa(offset),
+			limit:                strconv.Itoa(limit),
+			orderType:            "alphabet",
+			mockBehavior:         func(s *mock_service.MockCollection, userId int, input storage.CollectionListParam) {},
+			expectedStatusCode:   500,
+			expectedResponseBody: `{"message":"user id not found"}`,
+		},
+		{
+			name:                 "Invalid param",
+			offset:               strconv.Itoa(offset),
+			limit:                strconv.Itoa(limit),
+			orderType:            "UNKNOWN",
+			userId:               1,
+			mockBehavior:         func(s *mock_service.MockCollection, userId int, input storage.CollectionListParam) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"invalid query"}`,
+		},
+		{
+			name:      "Service error",
+			offset:    strconv.Itoa(offset),
+			limit:     strconv.Itoa(limit),
+			orderType: "owner",
+			userId:    1,
+			input: storage.CollectionListParam{
+				Offset:    &offset,
+				Limit:     &limit,
+				OrderType: "owner",
+			},
+			mockBehavior: func(s *mock_service.MockCollection, userId int, input storage.CollectionListParam) {
+				s.EXPECT().GetCollectionList(userId, input).Return(storage.CollectionListJson{}, errors.New("service error"))
+			},
+			expectedStatusCode:   500,
+			expectedResponseBody: `{"message":"service error"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			collection := mock_service.NewMockCollection(c)
+			testCase.mockBehavior(collection, testCase.userId, testCase.input)
+
+			services := &service.Service{Collection: collection}
+			handler := NewHandler(services)
+
+			r := gin.New()
+			if testCase.userId != 0 {
+				r.GET("/collections", func(c *gin.Context) {
+					c.Set(userCtx, testCase.userId)
+				}, handler.getAllCollections)
+			} else {
+				r.GET("/collections", handler.getAllCollections)
+			}
+
+			w := httptest.NewRecorder()
+			params := url.Values{"offset": {testCase.offset}, "limit": {testCase.limit}, "order_type": {testCase.orderType}}.Encode()
+			req := httptest.NewRequest("GET", "/collections?"+params, nil)
+
+			r.ServeHTTP(w, req)
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedResponseBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_createCollection(t *testing.T) {
+	type mockBehavior func(s *mock_service.MockCollection, userId int, input storage.CollectionInput)
+
+	testTable := []struct {
+		name                 string
+		userId               int
+		inputBody            string
+		input                storage.CollectionInput
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:      "OK",
+			userId:    1,
+			inputBody: `{"title":"collection"}`,
+			input:     storage.CollectionInput{Title: "collection"},
+			mockBehavior: func(s *mock_service.MockCollection, userId int, input storage.CollectionInput) {
+				s.EXPECT().CreateCollection(userId, input).Return(3, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"id":3}`,
+		},
+		{
+			name:                 "User not found",
+			inputBody:            `{"title":"collection"}`,
+			mockBehavior:         func(s *mock_service.MockCollection, userId int, input storage.CollectionInput) {},
+			expectedStatusCode:   500,
+			expectedResponseBody: `{"message":"user id not found"}`,
+		},
+		{
+			name:                 "Invalid input",
+			userId:               1,
+			inputBody:            `{}`,
+			mockBehavior:         func(s *mock_service.MockCollection, userId int, input storage.CollectionInput) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"invalid input body"}`,
+		},
+		{
+			name:      "Service error",
+			userId:    1,
+			inputBody: `{"title":"collection"}`,
+			input:     storage.CollectionInput{Title: "collection"},
+			mockBehavior: func(s *mock_service.MockCollection, userId int, input storage.CollectionInput) {
+				s.EXPECT().CreateCollection(userId, input).Return(0, errors.New("service error"))
+			},
+			expectedStatusCode:   500,
+			expectedResponseBody: `{"message":"service error"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			collection := mock_service.NewMockCollection(c)
+			testCase.mockBehavior(collection, testCase.userId, testCase.input)
+
+			services := &service.Service{Collection: collection}
+			handler := NewHandler(services)
+
+			r := gin.New()
+			if testCase.userId != 0 {
+				r.POST("/collections", func(c *gin.Context) {
+					c.Set(userCtx, testCase.userId)
+				}, handler.createCollection)
+			} else {
+				r.POST("/collections", handler.createCollection)
+			}
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/collections", bytes.NewBufferString(testCase.inputBody))
+
+			r.ServeHTTP(w, req)
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedResponseBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_getCollectionItems(t *testing.T) {
+	type mockBehavior func(s *mock_service.MockCollection, userId, collectionId int, input storage.CollectionItemsParam)
+
+	offset, limit := 0, 10
+
+	testTable := []struct {
+		name                 string
+		userId               int
+		collectionId         int
+		input                storage.CollectionItemsParam
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:         "OK",
+			userId:       1,
+			collectionId: 2,
+			input:        storage.CollectionItemsParam{Offset: &offset, Limit: &limit},
+			mockBehavior: func(s *mock_service.MockCollection, userId, collectionId int, input storage.CollectionItemsParam) {
+				s.EXPECT().GetCollectionItems(userId, collectionId, input).Return(storage.CollectionItemsJson{
+					TotalCount: 1,
+					Records: []storage.CollectionItem{
+						{Id: 4, Title: "audio 4", Owner: 3, Name: "user 3", Position: 1},
+					},
+				}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"total_count":1,"records":[{"id":4,"title":"audio 4","owner_id":3,"owner_name":"user 3","position":1}]}`,
+		},
+		{
+			name:                 "Invalid collection id",
+			userId:               1,
+			mockBehavior:         func(s *mock_service.MockCollection, userId, collectionId int, input storage.CollectionItemsParam) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"invalid collection id param"}`,
+		},
+		{
+			name:         "Collection not found",
+			userId:       1,
+			collectionId: 2,
+			input:        storage.CollectionItemsParam{Offset: &offset, Limit: &limit},
+			mockBehavior: func(s *mock_service.MockCollection, userId, collectionId int, input storage.CollectionItemsParam) {
+				s.EXPECT().GetCollectionItems(userId, collectionId, input).Return(storage.CollectionItemsJson{}, storage.CollectionNotFound)
+			},
+			expectedStatusCode:   404,
+			expectedResponseBody: `{"message":"collection not found or you haven't access"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			collection := mock_service.NewMockCollection(c)
+			testCase.mockBehavior(collection, testCase.userId, testCase.collectionId, testCase.input)
+
+			services := &service.Service{Collection: collection}
+			handler := NewHandler(services)
+
+			r := gin.New()
+			r.GET("/collections/:id", func(c *gin.Context) {
+				c.Set(userCtx, testCase.userId)
+			}, handler.getCollectionItems)
+
+			w := httptest.NewRecorder()
+			target := fmt.Sprintf("/collections/%d", testCase.collectionId)
+			if testCase.collectionId == 0 {
+				target = "/collections/wrong_id"
+			}
+			params := url.Values{"offset": {strconv.Itoa(offset)}, "limit": {strconv.Itoa(limit)}}.Encode()
+			req := httptest.NewRequest("GET", target+"?"+params, nil)
+
+			r.ServeHTTP(w, req)
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedResponseBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_updateCollectionItems(t *testing.T) {
+	type mockBehavior func(s *mock_service.MockCollection, userId, collectionId int)
+
+	testTable := []struct {
+		name                 string
+		method               string
+		target               string
+		inputBody            string
+		userId               int
+		collectionId         int
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:         "OK add item",
+			method:       "POST",
+			target:       "/collections/2/items",
+			inputBody:    `{"audio_id":5}`,
+			userId:       1,
+			collectionId: 2,
+			mockBehavior: func(s *mock_service.MockCollection, userId, collectionId int) {
+				s.EXPECT().AddCollectionItem(userId, collectionId, 5).Return(nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"status":"ok"}`,
+		},
+		{
+			name:         "Add item not allowed",
+			method:       "POST",
+			target:       "/collections/2/items",
+			inputBody:    `{"audio_id":5}`,
+			userId:       1,
+			collectionId: 2,
+			mockBehavior: func(s *mock_service.MockCollection, userId, collectionId int) {
+				s.EXPECT().AddCollectionItem(userId, collectionId, 5).Return(storage.CollectionItemNotAllowed)
+			},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"you are not owner of collection or haven't access to audio"}`,
+		},
+		{
+			name:         "OK remove item",
+			method:       "DELETE",
+			target:       "/collections/2/items/5",
+			userId:       1,
+			collectionId: 2,
+			mockBehavior: func(s *mock_service.MockCollection, userId, collectionId int) {
+				s.EXPECT().RemoveCollectionItem(userId, collectionId, 5).Return(nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"status":"ok"}`,
+		},
+		{
+			name:                 "Remove item invalid audio id",
+			method:               "DELETE",
+			target:               "/collections/2/items/wrong_id",
+			userId:               1,
+			collectionId:         2,
+			mockBehavior:         func(s *mock_service.MockCollection, userId, collectionId int) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"invalid audio id param"}`,
+		},
+		{
+			name:         "OK reorder",
+			method:       "PUT",
+			target:       "/collections/2/items",
+			inputBody:    `{"audio_ids":[5,3,4]}`,
+			userId:       1,
+			collectionId: 2,
+			mockBehavior: func(s *mock_service.MockCollection, userId, collectionId int) {
+				s.EXPECT().ReorderCollection(userId, collectionId, []int{5, 3, 4}).Return(nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"status":"ok"}`,
+		},
+		{
+			name:         "Reorder wrong order",
+			method:       "PUT",
+			target:       "/collections/2/items",
+			inputBody:    `{"audio_ids":[5]}`,
+			userId:       1,
+			collectionId: 2,
+			mockBehavior: func(s *mock_service.MockCollection, userId, collectionId int) {
+				s.EXPECT().ReorderCollection(userId, collectionId, []int{5}).Return(storage.WrongCollectionOrder)
+			},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"order must contain every audio of collection exactly once"}`,
+		},
+		{
+			name:                 "Reorder invalid input",
+			method:               "PUT",
+			target:               "/collections/2/items",
+			inputBody:            `{"audio_ids":"5"}`,
+			userId:               1,
+			collectionId:         2,
+			mockBehavior:         func(s *mock_service.MockCollection, userId, collectionId int) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"invalid input body"}`,
+		},
+		{
+			name:         "OK rename",
+			method:       "PUT",
+			target:       "/collections/2",
+			inputBody:    `{"title":"new title"}`,
+			userId:       1,
+			collectionId: 2,
+			mockBehavior: func(s *mock_service.MockCollection, userId, collectionId int) {
+				s.EXPECT().UpdateCollection(userId, collectionId, storage.CollectionInput{Title: "new title"}).Return(nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"status":"ok"}`,
+		},
+		{
+			name:         "Delete not owner",
+			method:       "DELETE",
+			target:       "/collections/2",
+			userId:       1,
+			collectionId: 2,
+			mockBehavior: func(s *mock_service.MockCollection, userId, collectionId int) {
+				s.EXPECT().DeleteCollection(userId, collectionId).Return(storage.NotCollectionOwner)
+			},
+			expectedStatusCode:   404,
+			expectedResponseBody: `{"message":"you are not owner or collection not exists"}`,
+		},
+		{
+			name:         "OK share",
+			method:       "POST",
+			target:       "/collections/2/share",
+			inputBody:    `{"share_to":3}`,
+			userId:       1,
+			collectionId: 2,
+			mockBehavior: func(s *mock_service.MockCollection, userId, collectionId int) {
+				s.EXPECT().ShareCollection(userId, collectionId, 3).Return(nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"status":"ok"}`,
+		},
+		{
+			name:         "Share to yourself",
+			method:       "POST",
+			target:       "/collections/2/share",
+			inputBody:    `{"share_to":1}`,
+			userId:       1,
+			collectionId: 2,
+			mockBehavior: func(s *mock_service.MockCollection, userId, collectionId int) {
+				s.EXPECT().ShareCollection(userId, collectionId, 1).Return(storage.SelfShareCollection)
+			},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"can't share own collection to yourself"}`,
+		},
+		{
+			name:         "Unshare service error",
+			method:       "DELETE",
+			target:       "/collections/2/share",
+			inputBody:    `{"share_to":3}`,
+			userId:       1,
+			collectionId: 2,
+			mockBehavior: func(s *mock_service.MockCollection, userId, collectionId int) {
+				s.EXPECT().UnshareCollection(userId, collectionId, 3).Return(errors.New("service error"))
+			},
+			expectedStatusCode:   500,
+			expectedResponseBody: `{"message":"service error"}`,
+		},
+		{
+			name:                 "User not found",
+			method:               "POST",
+			target:               "/collections/2/share",
+			inputBody:            `{"share_to":3}`,
+			mockBehavior:         func(s *mock_service.MockCollection, userId, collectionId int) {},
+			expectedStatusCode:   500,
+			expectedResponseBody: `{"message":"user id not found"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			collection := mock_service.NewMockCollection(c)
+			testCase.mockBehavior(collection, testCase.userId, testCase.collectionId)
+
+			services := &service.Service{Collection: collection}
+			handler := NewHandler(services)
+
+			r := gin.New()
+			if testCase.userId != 0 {
+				r.Use(func(c *gin.Context) {
+					c.Set(userCtx, testCase.userId)
+				})
+			}
+			r.PUT("/collections/:id", handler.updateCollection)
+			r.DELETE("/collections/:id", handler.deleteCollection)
+			r.POST("/collections/:id/items", handler.addCollectionItem)
+			r.PUT("/collections/:id/items", handler.reorderCollection)
+			r.DELETE("/collections/:id/items/:audio_id", handler.removeCollectionItem)
+			r.POST("/collections/:id/share", handler.shareCollection)
+			r.DELETE("/collections/:id/share", handler.unshareCollection)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(testCase.method, testCase.target, bytes.NewBufferString(testCase.inputBody))
+
+			r.ServeHTTP(w, req)
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedResponseBody, w.Body.String())
+		})
+	}
+}
@@ -0,0 +1,182 @@
+package handler
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	storage "github.com/mahadeva604/audio-storage"
+	"net/http"
+	"strconv"
+)
+
+// @Summary Get audio comments
+// @Security ApiKeyAuth
+// @Tags comment
+// @Description get top level comments of audio ordered by time offset, each with its replies
+// @ID get-comments
+// @Accept  json
+// @Produce  json
+// @Param id path int true "audio id"
+// @Param offset query integer true "offset" minimum(0)
+// @Param limit query integer true "limit" minimum(1)
+// @Success 200 {object} storage.CommentListJson
+// @Failure 400,404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /api/audio/{id}/comments [get]
+func (h *Handler) getComments(c *gin.Context) {
+	userId, audioId, ok := getAudioParams(c)
+	if !ok {
+		return
+	}
+
+	var input storage.CommentListParam
+	if err := c.BindQuery(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid query")
+		return
+	}
+
+	result, err := h.services.GetComments(userId, audioId, input)
+	if err != nil {
+		newCommentErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// @Summary Add comment
+// @Security ApiKeyAuth
+// @Tags comment
+// @Description add comment at start_ms or start_ms to end_ms range of audio in milliseconds, or reply to a top level comment with parent_id
+// @ID add-comment
+// @Accept  json
+// @Produce  json
+// @Param id path int true "audio id"
+// @Param input body storage.CommentInput true "comment"
+// @Success 200 {object} idResponse
+// @Failure 400,404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /api/audio/{id}/comments [post]
+func (h *Handler) addComment(c *gin.Context) {
+	userId, audioId, ok := getAudioParams(c)
+	if !ok {
+		return
+	}
+
+	var input storage.CommentInput
+	if err := c.BindJSON(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid input body")
+		return
+	}
+
+	if err := input.Validate(); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	commentId, err := h.services.AddComment(userId, audioId, input)
+	if err != nil {
+		newCommentErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, idResponse{
+		ID: commentId,
+	})
+}
+
+// @Summary Update comment
+// @Security ApiKeyAuth
+// @Tags comment
+// @Description update text and offset of own comment, replies have no offset
+// @ID update-comment
+// @Accept  json
+// @Produce  json
+// @Param id path int true "audio id"
+// @Param comment_id path int true "comment id"
+// @Param input body storage.CommentUpdate true "comment"
+// @Success 200 {object} statusResponse
+// @Failure 400,403,404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /api/audio/{id}/comments/{comment_id} [put]
+func (h *Handler) updateComment(c *gin.Context) {
+	userId, audioId, commentId, ok := getCommentParams(c)
+	if !ok {
+		return
+	}
+
+	var input storage.CommentUpdate
+	if err := c.BindJSON(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid input body")
+		return
+	}
+
+	if err := input.Validate(); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := h.services.UpdateComment(userId, audioId, commentId, input); err != nil {
+		newCommentErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, statusResponse{"ok"})
+}
+
+// @Summary Delete comment
+// @Security ApiKeyAuth
+// @Tags comment
+// @Description delete comment with its replies, allowed for its author and owner of audio
+// @ID delete-comment
+// @Produce  json
+// @Param id path int true "audio id"
+// @Param comment_id path int true "comment id"
+// @Success 200 {object} statusResponse
+// @Failure 400,404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /api/audio/{id}/comments/{comment_id} [delete]
+func (h *Handler) deleteComment(c *gin.Context) {
+	userId, audioId, commentId, ok := getCommentParams(c)
+	if !ok {
+		return
+	}
+
+	if err := h.services.DeleteComment(userId, audioId, commentId); err != nil {
+		newCommentErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, statusResponse{"ok"})
+}
+
+func getCommentParams(c *gin.Context) (int, int, int, bool) {
+	userId, audioId, ok := getAudioParams(c)
+	if !ok {
+		return 0, 0, 0, false
+	}
+
+	commentId, err := strconv.Atoi(c.Param("comment_id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid comment id param")
+		return 0, 0, 0, false
+	}
+
+	return userId, audioId, commentId, true
+}
+
+func newCommentErrorResponse(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, storage.FileNotFound), errors.Is(err, storage.CommentNotFound):
+		newErrorResponse(c, http.StatusNotFound, err.Error())
+	case errors.Is(err, storage.NotCommentAuthor):
+		newErrorResponse(c, http.StatusForbidden, err.Error())
+	case errors.Is(err, storage.CommentReplyOffset):
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+	default:
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+	}
+}
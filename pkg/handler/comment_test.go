@@ -0,0 +1,303 @@
+package handler
+
+import (
+	"bytes"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	storage "github.com/mahadeva604/audio-storage"
+	"github.com/mahadeva604/audio-storage/pkg/service"
+	mock_service "github.com/mahadeva604/audio-storage/pkg/service/mocks"
+	"github.com/stretchr/testify/assert"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHandler_getComments(t *testing.T) {
+	type mockBehavior func(s *mock_service.MockComment)
+
+	offset, limit := 0, 10
+	start, parent := 1500, 1
+	at := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	testTable := []struct {
+		name                 string
+		target               string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:   "OK",
+			target: "/audio/2/comments?offset=0&limit=10",
+			mockBehavior: func(s *mock_service.MockComment) {
+				s.EXPECT().GetComments(1, 2, storage.CommentListParam{Offset: &offset, Limit: &limit}).Return(storage.CommentListJson{
+					TotalCount: 1,
+					Records: []storage.Comment{
+						{Id: 1, UserId: 1, Name: "owner", StartMs: &start, Text: "nice", CreatedAt: at, UpdatedAt: at,
+							Replies: []storage.Comment{
+								{Id: 2, ParentId: &parent, UserId: 3, Name: "guest", Text: "agree", CreatedAt: at, UpdatedAt: at},
+							}},
+					},
+				}, nil)
+			},
+			expectedStatusCode: 200,
+			expectedResponseBody: `{"total_count":1,"records":[{"id":1,"user_id":1,"user_name":"owner","start_ms":1500,"text":"nice",` +
+				`"created_at":"2021-06-01T12:00:00Z","updated_at":"2021-06-01T12:00:00Z","replies":[{"id":2,"parent_id":1,"user_id":3,` +
+				`"user_name":"guest","text":"agree","created_at":"2021-06-01T12:00:00Z","updated_at":"2021-06-01T12:00:00Z"}]}]}`,
+		},
+		{
+			name:                 "Invalid query",
+			target:               "/audio/2/comments?offset=0",
+			mockBehavior:         func(s *mock_service.MockComment) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"invalid query"}`,
+		},
+		{
+			name:   "No access",
+			target: "/audio/2/comments?offset=0&limit=10",
+			mockBehavior: func(s *mock_service.MockComment) {
+				s.EXPECT().GetComments(1, 2, storage.CommentListParam{Offset: &offset, Limit: &limit}).Return(storage.CommentListJson{}, storage.FileNotFound)
+			},
+			expectedStatusCode:   404,
+			expectedResponseBody: `{"message":"` + storage.FileNotFound.Error() + `"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			comment := mock_service.NewMockComment(c)
+			testCase.mockBehavior(comment)
+
+			handler := NewHandler(&service.Service{Comment: comment})
+
+			r := gin.New()
+			r.GET("/audio/:id/comments", func(c *gin.Context) {
+				c.Set(userCtx, 1)
+			}, handler.getComments)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", testCase.target, nil)
+
+			r.ServeHTTP(w, req)
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedResponseBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_addComment(t *testing.T) {
+	type mockBehavior func(s *mock_service.MockComment)
+
+	start, end, parent := 1000, 2000, 1
+
+	testTable := []struct {
+		name                 string
+		inputBody            string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:      "OK",
+			inputBody: `{"text":"nice","start_ms":1000,"end_ms":2000}`,
+			mockBehavior: func(s *mock_service.MockComment) {
+				s.EXPECT().AddComment(1, 2, storage.CommentInput{Text: "nice", StartMs: &start, EndMs: &end}).Return(5, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"id":5}`,
+		},
+		{
+			name:      "OK reply",
+			inputBody: `{"text":"agree","parent_id":1}`,
+			mockBehavior: func(s *mock_service.MockComment) {
+				s.EXPECT().AddComment(1, 2, storage.CommentInput{Text: "agree", ParentId: &parent}).Return(6, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"id":6}`,
+		},
+		{
+			name:                 "Without offset",
+			inputBody:            `{"text":"nice"}`,
+			mockBehavior:         func(s *mock_service.MockComment) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"start_ms is required for comment without parent_id"}`,
+		},
+		{
+			name:                 "Reply with offset",
+			inputBody:            `{"text":"agree","parent_id":1,"start_ms":1000}`,
+			mockBehavior:         func(s *mock_service.MockComment) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"replies belong to thread and can't have start_ms or end_ms"}`,
+		},
+		{
+			name:                 "End before start",
+			inputBody:            `{"text":"nice","start_ms":2000,"end_ms":1000}`,
+			mockBehavior:         func(s *mock_service.MockComment) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"end_ms is before start_ms"}`,
+		},
+		{
+			name:      "Parent not found",
+			inputBody: `{"text":"agree","parent_id":1}`,
+			mockBehavior: func(s *mock_service.MockComment) {
+				s.EXPECT().AddComment(1, 2, storage.CommentInput{Text: "agree", ParentId: &parent}).Return(0, storage.CommentNotFound)
+			},
+			expectedStatusCode:   404,
+			expectedResponseBody: `{"message":"comment not found or you haven't access"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			comment := mock_service.NewMockComment(c)
+			testCase.mockBehavior(comment)
+
+			handler := NewHandler(&service.Service{Comment: comment})
+
+			r := gin.New()
+			r.POST("/audio/:id/comments", func(c *gin.Context) {
+				c.Set(userCtx, 1)
+			}, handler.addComment)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/audio/2/comments", bytes.NewBufferString(testCase.inputBody))
+
+			r.ServeHTTP(w, req)
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedResponseBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_updateComment(t *testing.T) {
+	type mockBehavior func(s *mock_service.MockComment)
+
+	testTable := []struct {
+		name                 string
+		target               string
+		inputBody            string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:      "OK",
+			target:    "/audio/2/comments/3",
+			inputBody: `{"text":"edited"}`,
+			mockBehavior: func(s *mock_service.MockComment) {
+				s.EXPECT().UpdateComment(1, 2, 3, storage.CommentUpdate{Text: "edited"}).Return(nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"status":"ok"}`,
+		},
+		{
+			name:                 "Invalid comment id",
+			target:               "/audio/2/comments/wrong",
+			inputBody:            `{"text":"edited"}`,
+			mockBehavior:         func(s *mock_service.MockComment) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"invalid comment id param"}`,
+		},
+		{
+			name:                 "End without start",
+			target:               "/audio/2/comments/3",
+			inputBody:            `{"text":"edited","end_ms":1000}`,
+			mockBehavior:         func(s *mock_service.MockComment) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"end_ms can't be set without start_ms"}`,
+		},
+		{
+			name:      "Not author",
+			target:    "/audio/2/comments/3",
+			inputBody: `{"text":"edited"}`,
+			mockBehavior: func(s *mock_service.MockComment) {
+				s.EXPECT().UpdateComment(1, 2, 3, storage.CommentUpdate{Text: "edited"}).Return(storage.NotCommentAuthor)
+			},
+			expectedStatusCode:   403,
+			expectedResponseBody: `{"message":"you are not author of the comment"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			comment := mock_service.NewMockComment(c)
+			testCase.mockBehavior(comment)
+
+			handler := NewHandler(&service.Service{Comment: comment})
+
+			r := gin.New()
+			r.PUT("/audio/:id/comments/:comment_id", func(c *gin.Context) {
+				c.Set(userCtx, 1)
+			}, handler.updateComment)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("PUT", testCase.target, bytes.NewBufferString(testCase.inputBody))
+
+			r.ServeHTTP(w, req)
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedResponseBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_deleteComment(t *testing.T) {
+	type mockBehavior func(s *mock_service.MockComment)
+
+	testTable := []struct {
+		name                 string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name: "OK",
+			mockBehavior: func(s *mock_service.MockComment) {
+				s.EXPECT().DeleteComment(1, 2, 3).Return(nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"status":"ok"}`,
+		},
+		{
+			name: "Not found",
+			mockBehavior: func(s *mock_service.MockComment) {
+				s.EXPECT().DeleteComment(1, 2, 3).Return(storage.CommentNotFound)
+			},
+			expectedStatusCode:   404,
+			expectedResponseBody: `{"message":"comment not found or you haven't access"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			comment := mock_service.NewMockComment(c)
+			testCase.mockBehavior(comment)
+
+			handler := NewHandler(&service.Service{Comment: comment})
+
+			r := gin.New()
+			r.DELETE("/audio/:id/comments/:comment_id", func(c *gin.Context) {
+				c.Set(userCtx, 1)
+			}, handler.deleteComment)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("DELETE", "/audio/2/comments/3", nil)
+
+			r.ServeHTTP(w, req)
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedResponseBody, w.Body.String())
+		})
+	}
+}
@@ -0,0 +1,250 @@
+package handler
+
+import (
+	"encoding/xml"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	storage "github.com/mahadeva604/audio-storage"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// @Summary Publish collection as podcast
+// @Security ApiKeyAuth
+// @Tags feed
+// @Description publish collection as RSS feed or update feed info, feed url stays the same on update. Only audio uploaded by you is published, audio shared with you is left out
+// @ID publish-feed
+// @Accept  json
+// @Produce  json
+// @Param id path int true "collection id"
+// @Param input body storage.FeedInput true "podcast info"
+// @Success 200 {object} storage.FeedUrlResponse
+// @Failure 400,404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /api/collections/{id}/feed [put]
+func (h *Handler) publishFeed(c *gin.Context) {
+	userId, collectionId, ok := getCollectionParams(c)
+	if !ok {
+		return
+	}
+
+	var input storage.FeedInput
+	if err := c.BindJSON(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid input body")
+		return
+	}
+
+	feedUrl, err := h.services.PublishFeed(userId, collectionId, input)
+	if err != nil {
+		newFeedErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, storage.FeedUrlResponse{Url: feedUrl})
+}
+
+// @Summary Get podcast info
+// @Security ApiKeyAuth
+// @Tags feed
+// @Description get podcast info and private feed url
+// @ID get-feed-settings
+// @Accept  json
+// @Produce  json
+// @Param id path int true "collection id"
+// @Success 200 {object} storage.FeedSettings
+// @Failure 400,404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /api/collections/{id}/feed [get]
+func (h *Handler) getFeedSettings(c *gin.Context) {
+	userId, collectionId, ok := getCollectionParams(c)
+	if !ok {
+		return
+	}
+
+	settings, err := h.services.GetFeedSettings(userId, collectionId)
+	if err != nil {
+		newFeedErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+// @Summary Rotate feed url
+// @Security ApiKeyAuth
+// @Tags feed
+// @Description generate new private feed url, old url stops working
+// @ID rotate-feed-token
+// @Accept  json
+// @Produce  json
+// @Param id path int true "collection id"
+// @Success 200 {object} storage.FeedUrlResponse
+// @Failure 400,404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /api/collections/{id}/feed/token [post]
+func (h *Handler) rotateFeedToken(c *gin.Context) {
+	userId, collectionId, ok := getCollectionParams(c)
+	if !ok {
+		return
+	}
+
+	feedUrl, err := h.services.RotateFeedToken(userId, collectionId)
+	if err != nil {
+		newFeedErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, storage.FeedUrlResponse{Url: feedUrl})
+}
+
+// @Summary Unpublish podcast
+// @Security ApiKeyAuth
+// @Tags feed
+// @Description stop publishing collection as RSS feed
+// @ID delete-feed
+// @Accept  json
+// @Produce  json
+// @Param id path int true "collection id"
+// @Success 200 {object} statusResponse
+// @Failure 400,404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /api/collections/{id}/feed [delete]
+func (h *Handler) deleteFeed(c *gin.Context) {
+	userId, collectionId, ok := getCollectionParams(c)
+	if !ok {
+		return
+	}
+
+	if err := h.services.DeleteFeed(userId, collectionId); err != nil {
+		newFeedErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, statusResponse{"ok"})
+}
+
+// @Summary Get podcast feed
+// @Tags feed
+// @Description RSS 2.0 feed with iTunes tags, available by private url without authorization
+// @ID get-feed
+// @Produce  application/rss+xml
+// @Param token path string true "feed token"
+// @Success 200 "RSS feed"
+// @Failure 404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /feeds/{token} [get]
+func (h *Handler) getFeed(c *gin.Context) {
+	token, err := uuid.Parse(c.Param("token"))
+	if err != nil {
+		newErrorResponse(c, http.StatusNotFound, storage.FeedNotFound.Error())
+		return
+	}
+
+	feed, err := h.services.GetFeed(token.String())
+	if errors.Is(err, storage.FeedNotFound) {
+		newErrorResponse(c, http.StatusNotFound, err.Error())
+		return
+	}
+
+	if err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if err := feed.Validate(); err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	out, err := xml.Marshal(feed)
+	if err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.Data(http.StatusOK, storage.FeedContentType, append([]byte(xml.Header), out...))
+}
+
+// @Summary Download podcast episode
+// @Tags feed
+// @Description download aac file by signed url from podcast feed
+// @ID download-signed-file
+// @Produce  audio/aac
+// @Param id path string true "audio id with .aac extension"
+// @Param collection query integer true "collection id of feed"
+// @Param token query string true "feed token"
+// @Param expires query integer true "expiration unix time"
+// @Param signature query string true "url signature"
+// @Success 200 "Success Download"
+// @Failure 400,403,404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /download/{id} [get]
+func (h *Handler) downloadSignedAudio(c *gin.Context) {
+	audioId, err := strconv.Atoi(strings.TrimSuffix(c.Param("id"), storage.FileExt))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid audio id param")
+		return
+	}
+
+	collectionId, err := strconv.Atoi(c.Query("collection"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid collection param")
+		return
+	}
+
+	expires, err := strconv.ParseInt(c.Query("expires"), 10, 64)
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid expires param")
+		return
+	}
+
+	audio, err := h.services.GetSignedFile(audioId, collectionId, c.Query("token"), expires, c.Query("signature"))
+	if errors.Is(err, storage.WrongSignature) {
+		newErrorResponse(c, http.StatusForbidden, err.Error())
+		return
+	}
+
+	if errors.Is(err, storage.FileNotFound) {
+		newErrorResponse(c, http.StatusNotFound, err.Error())
+		return
+	}
+
+	if err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	fileId, err := uuid.Parse(audio.FilePath)
+	if err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	file, fileSize, err := h.services.GetFile(fileId)
+	if err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer file.Close()
+
+	c.DataFromReader(http.StatusOK, fileSize, storage.EpisodeMimeType, file, map[string]string{"Content-Disposition": audio.Title + storage.FileExt})
+}
+
+func newFeedErrorResponse(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, storage.FeedNotFound), errors.Is(err, storage.NotCollectionOwner):
+		newErrorResponse(c, http.StatusNotFound, err.Error())
+	case errors.Is(err, storage.InvalidFeed):
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+	default:
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+	}
+}
@@ -0,0 +1,417 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	storage "github.com/mahadeva604/audio-storage"
+	"github.com/mahadeva604/audio-storage/pkg/service"
+	mock_service "github.com/mahadeva604/audio-storage/pkg/service/mocks"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHandler_publishFeed(t *testing.T) {
+	type mockBehavior func(s *mock_service.MockFeed, userId, collectionId int, input storage.FeedInput)
+
+	input := storage.FeedInput{
+		Description: "description",
+		ImageUrl:    "https://example.com/cover.png",
+		Language:    "en",
+		Category:    "Technology",
+	}
+
+	testTable := []struct {
+		name                 string
+		userId               int
+		inputBody            string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:      "OK",
+			userId:    1,
+			inputBody: `{"description":"description","image_url":"https://example.com/cover.png","language":"en","category":"Technology"}`,
+			mockBehavior: func(s *mock_service.MockFeed, userId, collectionId int, input storage.FeedInput) {
+				s.EXPECT().PublishFeed(userId, collectionId, input).Return("http://localhost/feeds/token", nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"url":"http://localhost/feeds/token"}`,
+		},
+		{
+			name:                 "Invalid image url",
+			userId:               1,
+			inputBody:            `{"description":"description","image_url":"cover.png","language":"en","category":"Technology"}`,
+			mockBehavior:         func(s *mock_service.MockFeed, userId, collectionId int, input storage.FeedInput) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"invalid input body"}`,
+		},
+		{
+			name:      "Not owner",
+			userId:    1,
+			inputBody: `{"description":"description","image_url":"https://example.com/cover.png","language":"en","category":"Technology"}`,
+			mockBehavior: func(s *mock_service.MockFeed, userId, collectionId int, input storage.FeedInput) {
+				s.EXPECT().PublishFeed(userId, collectionId, input).Return("", storage.NotCollectionOwner)
+			},
+			expectedStatusCode:   404,
+			expectedResponseBody: `{"message":"you are not owner or collection not exists"}`,
+		},
+		{
+			name:      "Invalid feed",
+			userId:    1,
+			inputBody: `{"description":"description","image_url":"https://example.com/cover.png","language":"en","category":"Technology"}`,
+			mockBehavior: func(s *mock_service.MockFeed, userId, collectionId int, input storage.FeedInput) {
+				s.EXPECT().PublishFeed(userId, collectionId, input).
+					Return("", fmt.Errorf("%w: every episode must have enclosure with url, length and type", storage.InvalidFeed))
+			},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"feed is invalid: every episode must have enclosure with url, length and type"}`,
+		},
+		{
+			name:                 "User not found",
+			mockBehavior:         func(s *mock_service.MockFeed, userId, collectionId int, input storage.FeedInput) {},
+			expectedStatusCode:   500,
+			expectedResponseBody: `{"message":"user id not found"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			feed := mock_service.NewMockFeed(c)
+			testCase.mockBehavior(feed, testCase.userId, 2, input)
+
+			services := &service.Service{Feed: feed}
+			handler := NewHandler(services)
+
+			r := gin.New()
+			if testCase.userId != 0 {
+				r.PUT("/collections/:id/feed", func(c *gin.Context) {
+					c.Set(userCtx, testCase.userId)
+				}, handler.publishFeed)
+			} else {
+				r.PUT("/collections/:id/feed", handler.publishFeed)
+			}
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("PUT", "/collections/2/feed", bytes.NewBufferString(testCase.inputBody))
+
+			r.ServeHTTP(w, req)
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedResponseBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_updateFeed(t *testing.T) {
+	type mockBehavior func(s *mock_service.MockFeed, userId, collectionId int)
+
+	testTable := []struct {
+		name                 string
+		method               string
+		target               string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:   "OK settings",
+			method: "GET",
+			target: "/collections/2/feed",
+			mockBehavior: func(s *mock_service.MockFeed, userId, collectionId int) {
+				s.EXPECT().GetFeedSettings(userId, collectionId).Return(storage.FeedSettings{
+					Token:       "token",
+					Url:         "http://localhost/feeds/token",
+					Description: "description",
+					ImageUrl:    "https://example.com/cover.png",
+					Language:    "en",
+					Category:    "Technology",
+				}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"url":"http://localhost/feeds/token","description":"description","author":"","image_url":"https://example.com/cover.png","language":"en","category":"Technology","explicit":false}`,
+		},
+		{
+			name:   "Settings not found",
+			method: "GET",
+			target: "/collections/2/feed",
+			mockBehavior: func(s *mock_service.MockFeed, userId, collectionId int) {
+				s.EXPECT().GetFeedSettings(userId, collectionId).Return(storage.FeedSettings{}, storage.FeedNotFound)
+			},
+			expectedStatusCode:   404,
+			expectedResponseBody: `{"message":"feed not found"}`,
+		},
+		{
+			name:   "OK rotate",
+			method: "POST",
+			target: "/collections/2/feed/token",
+			mockBehavior: func(s *mock_service.MockFeed, userId, collectionId int) {
+				s.EXPECT().RotateFeedToken(userId, collectionId).Return("http://localhost/feeds/new", nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"url":"http://localhost/feeds/new"}`,
+		},
+		{
+			name:   "OK delete",
+			method: "DELETE",
+			target: "/collections/2/feed",
+			mockBehavior: func(s *mock_service.MockFeed, userId, collectionId int) {
+				s.EXPECT().DeleteFeed(userId, collectionId).Return(nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"status":"ok"}`,
+		},
+		{
+			name:   "Delete service error",
+			method: "DELETE",
+			target: "/collections/2/feed",
+			mockBehavior: func(s *mock_service.MockFeed, userId, collectionId int) {
+				s.EXPECT().DeleteFeed(userId, collectionId).Return(errors.New("service error"))
+			},
+			expectedStatusCode:   500,
+			expectedResponseBody: `{"message":"service error"}`,
+		},
+		{
+			name:                 "Invalid collection id",
+			method:               "DELETE",
+			target:               "/collections/wrong_id/feed",
+			mockBehavior:         func(s *mock_service.MockFeed, userId, collectionId int) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"invalid collection id param"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			feed := mock_service.NewMockFeed(c)
+			testCase.mockBehavior(feed, 1, 2)
+
+			services := &service.Service{Feed: feed}
+			handler := NewHandler(services)
+
+			r := gin.New()
+			r.Use(func(c *gin.Context) {
+				c.Set(userCtx, 1)
+			})
+			r.GET("/collections/:id/feed", handler.getFeedSettings)
+			r.DELETE("/collections/:id/feed", handler.deleteFeed)
+			r.POST("/collections/:id/feed/token", handler.rotateFeedToken)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(testCase.method, testCase.target, nil)
+
+			r.ServeHTTP(w, req)
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedResponseBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_getFeed(t *testing.T) {
+	type mockBehavior func(s *mock_service.MockFeed, token string)
+
+	token := uuid.New().String()
+	channel := storage.RssChannel{
+		Title:          "podcast",
+		Link:           "http://localhost",
+		Description:    "description",
+		Language:       "en",
+		ItunesAuthor:   "author",
+		ItunesImage:    storage.RssItunesImage{Href: "https://example.com/cover.png"},
+		ItunesCategory: storage.RssItunesCategory{Text: "Technology"},
+		ItunesExplicit: "false",
+		Items: []storage.RssItem{
+			storage.NewRssItem(storage.FeedEpisode{
+				AudioId:  3,
+				Title:    "episode",
+				Duration: 61,
+				Size:     1024,
+				AddedAt:  time.Date(2021, 6, 1, 10, 0, 0, 0, time.UTC),
+			}, "http://localhost/download/3.aac?expires=1&signature=abc"),
+		},
+	}
+
+	testTable := []struct {
+		name                 string
+		token                string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:  "OK",
+			token: token,
+			mockBehavior: func(s *mock_service.MockFeed, token string) {
+				s.EXPECT().GetFeed(token).Return(storage.NewRssFeed(channel), nil)
+			},
+			expectedStatusCode: 200,
+			expectedResponseBody: `<?xml version="1.0" encoding="UTF-8"?>` + "\n" +
+				`<rss version="2.0" xmlns:itunes="http://www.itunes.com/dtds/podcast-1.0.dtd"><channel>` +
+				`<title>podcast</title><link>http://localhost</link><description>description</description><language>en</language>` +
+				`<itunes:author>author</itunes:author><itunes:image href="https://example.com/cover.png"></itunes:image>` +
+				`<itunes:category text="Technology"></itunes:category><itunes:explicit>false</itunes:explicit>` +
+				`<item><title>episode</title><enclosure url="http://localhost/download/3.aac?expires=1&amp;signature=abc" length="1024" type="audio/aac"></enclosure>` +
+				`<guid isPermaLink="false">audio-storage-3</guid><pubDate>Tue, 01 Jun 2021 10:00:00 +0000</pubDate><itunes:duration>61</itunes:duration></item>` +
+				`</channel></rss>`,
+		},
+		{
+			name:                 "Invalid token",
+			token:                "wrong_token",
+			mockBehavior:         func(s *mock_service.MockFeed, token string) {},
+			expectedStatusCode:   404,
+			expectedResponseBody: `{"message":"feed not found"}`,
+		},
+		{
+			name:  "Feed not found",
+			token: token,
+			mockBehavior: func(s *mock_service.MockFeed, token string) {
+				s.EXPECT().GetFeed(token).Return(storage.RssFeed{}, storage.FeedNotFound)
+			},
+			expectedStatusCode:   404,
+			expectedResponseBody: `{"message":"feed not found"}`,
+		},
+		{
+			name:  "Invalid feed",
+			token: token,
+			mockBehavior: func(s *mock_service.MockFeed, token string) {
+				invalid := channel
+				invalid.ItunesImage = storage.RssItunesImage{Href: "cover.png"}
+				s.EXPECT().GetFeed(token).Return(storage.NewRssFeed(invalid), nil)
+			},
+			expectedStatusCode:   500,
+			expectedResponseBody: `{"message":"feed artwork must be an absolute url"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			feed := mock_service.NewMockFeed(c)
+			testCase.mockBehavior(feed, testCase.token)
+
+			services := &service.Service{Feed: feed}
+			handler := NewHandler(services)
+
+			r := gin.New()
+			r.GET("/feeds/:token", handler.getFeed)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/feeds/"+testCase.token, nil)
+
+			r.ServeHTTP(w, req)
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedResponseBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_downloadSignedAudio(t *testing.T) {
+	type mockBehavior func(s1 *mock_service.MockFeed, s2 *mock_service.MockStorage, fileId uuid.UUID)
+
+	testTable := []struct {
+		name                 string
+		target               string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:   "OK",
+			target: "/download/3.aac?collection=2&token=t&expires=100&signature=abc",
+			mockBehavior: func(s1 *mock_service.MockFeed, s2 *mock_service.MockStorage, fileId uuid.UUID) {
+				s1.EXPECT().GetSignedFile(3, 2, "t", int64(100), "abc").Return(storage.DownloadAudio{Title: "audio", FilePath: fileId.String()}, nil)
+				s2.EXPECT().GetFile(fileId).Return(io.NopCloser(strings.NewReader("file content")), int64(12), nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: "file content",
+		},
+		{
+			name:                 "Invalid audio id",
+			target:               "/download/wrong.aac?collection=2&token=t&expires=100&signature=abc",
+			mockBehavior:         func(s1 *mock_service.MockFeed, s2 *mock_service.MockStorage, fileId uuid.UUID) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"invalid audio id param"}`,
+		},
+		{
+			name:                 "Invalid expires",
+			target:               "/download/3.aac?collection=2&token=t&signature=abc",
+			mockBehavior:         func(s1 *mock_service.MockFeed, s2 *mock_service.MockStorage, fileId uuid.UUID) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"invalid expires param"}`,
+		},
+		{
+			name:   "Wrong signature",
+			target: "/download/3.aac?collection=2&token=t&expires=100&signature=abc",
+			mockBehavior: func(s1 *mock_service.MockFeed, s2 *mock_service.MockStorage, fileId uuid.UUID) {
+				s1.EXPECT().GetSignedFile(3, 2, "t", int64(100), "abc").Return(storage.DownloadAudio{}, storage.WrongSignature)
+			},
+			expectedStatusCode:   403,
+			expectedResponseBody: `{"message":"download link is wrong or expired"}`,
+		},
+		{
+			name:                 "Invalid collection",
+			target:               "/download/3.aac?token=t&expires=100&signature=abc",
+			mockBehavior:         func(s1 *mock_service.MockFeed, s2 *mock_service.MockStorage, fileId uuid.UUID) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"invalid collection param"}`,
+		},
+		{
+			name:   "Removed from feed",
+			target: "/download/3.aac?collection=2&token=t&expires=100&signature=abc",
+			mockBehavior: func(s1 *mock_service.MockFeed, s2 *mock_service.MockStorage, fileId uuid.UUID) {
+				s1.EXPECT().GetSignedFile(3, 2, "t", int64(100), "abc").Return(storage.DownloadAudio{}, storage.FileNotFound)
+			},
+			expectedStatusCode:   404,
+			expectedResponseBody: `{"message":"` + storage.FileNotFound.Error() + `"}`,
+		},
+		{
+			name:   "Get file error",
+			target: "/download/3.aac?collection=2&token=t&expires=100&signature=abc",
+			mockBehavior: func(s1 *mock_service.MockFeed, s2 *mock_service.MockStorage, fileId uuid.UUID) {
+				s1.EXPECT().GetSignedFile(3, 2, "t", int64(100), "abc").Return(storage.DownloadAudio{Title: "audio", FilePath: fileId.String()}, nil)
+				s2.EXPECT().GetFile(fileId).Return(nil, int64(0), errors.New("file error"))
+			},
+			expectedStatusCode:   500,
+			expectedResponseBody: `{"message":"file error"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			feed := mock_service.NewMockFeed(c)
+			strg := mock_service.NewMockStorage(c)
+			testCase.mockBehavior(feed, strg, uuid.New())
+
+			services := &service.Service{Feed: feed, Storage: strg}
+			handler := NewHandler(services)
+
+			r := gin.New()
+			r.GET("/download/:id", handler.downloadSignedAudio)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", testCase.target, nil)
+
+			r.ServeHTTP(w, req)
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedResponseBody, w.Body.String())
+		})
+	}
+}
@@ -2,6 +2,7 @@ package handler
 
 import (
 	"github.com/gin-gonic/gin"
+	storage "github.com/mahadeva604/audio-storage"
 	"github.com/mahadeva604/audio-storage/pkg/service"
 
 	"github.com/swaggo/gin-swagger"
@@ -28,25 +29,134 @@ func (h *Handler) InitRoutes() *gin.Engine {
 		auth.POST("/sign-up", h.signUp)
 		auth.POST("/sign-in", h.signIn)
 		auth.POST("/refresh", h.refreshTokens)
+		auth.POST("/logout", h.userIdentity, h.noAPIKey, h.logout)
+		auth.POST("/logout-all", h.userIdentity, h.noAPIKey, h.logoutAll)
+		auth.POST("/password-reset", h.requestPasswordReset)
+		auth.POST("/password-reset/confirm", h.resetPassword)
 	}
 
+	router.GET("/.well-known/jwks.json", h.getJWKS)
+	router.GET("/feeds/:token", h.getFeed)
+	router.GET("/download/:id", h.downloadSignedAudio)
+
+	// routes accepting API keys require scopes, access tokens have every scope
+	readAudio := h.scope(storage.ScopeAudioRead)
+	writeAudio := h.scope(storage.ScopeAudioWrite)
+	writeShare := h.scope(storage.ScopeShareWrite)
+
 	api := router.Group("/api", h.userIdentity)
 	{
-		audio := api.Group("/audio")
+		audio := api.Group("/audio", h.methodScope(storage.ScopeAudioRead, storage.ScopeAudioWrite))
 		{
 			audio.GET("/", h.getAllAudio)
 			audio.POST("/", h.uploadAudio)
+			audio.GET("/search", h.searchAudio)
 			audio.PUT("/:id", h.addDescription)
+			audio.PATCH("/:id", h.updateAudio)
 			audio.GET("/:id", h.downloadAudio)
+			audio.GET("/:id/metadata", h.getAudio)
+			audio.PUT("/:id/metadata", h.addDescription)
+			audio.PATCH("/:id/metadata", h.updateAudio)
+			audio.GET("/:id/history", h.getAudioHistory)
+			audio.GET("/:id/artwork", h.getAudioArtwork)
+			audio.PUT("/:id/artwork", h.setAudioArtwork)
+			audio.DELETE("/:id/artwork", h.deleteAudioArtwork)
+			audio.GET("/:id/comments", h.getComments)
+			audio.POST("/:id/comments", h.addComment)
+			audio.PUT("/:id/comments/:comment_id", h.updateComment)
+			audio.DELETE("/:id/comments/:comment_id", h.deleteComment)
+			audio.GET("/:id/chapters", h.getChapters)
+			audio.PUT("/:id/chapters", h.setChapters)
+			audio.DELETE("/:id/chapters", h.deleteChapters)
+			audio.GET("/:id/transcript", h.getTranscript)
+			audio.PUT("/:id/transcript", h.setTranscript)
+			audio.DELETE("/:id/transcript", h.deleteTranscript)
+			audio.GET("/:id/transcript/job", h.getTranscription)
+			audio.POST("/:id/transcript/job", h.startTranscription)
+			audio.GET("/:id/jobs", h.getAudioJobs)
+			audio.GET("/:id/hls/:name", h.getHLSFile)
+			audio.GET("/:id/tags", h.getAudioTags)
+			audio.POST("/:id/tags", h.addAudioTags)
+			audio.DELETE("/:id/tags/:tag", h.removeAudioTag)
+		}
+
+		api.GET("/tags/", readAudio, h.getTags)
+
+		metadataSchema := api.Group("/metadata-schema", h.methodScope(storage.ScopeAudioRead, storage.ScopeAudioWrite))
+		{
+			metadataSchema.GET("", h.getMetadataSchema)
+			metadataSchema.PUT("", h.setMetadataSchema)
+			metadataSchema.DELETE("", h.deleteMetadataSchema)
 		}
 
-		share := api.Group("share")
+		share := api.Group("share", writeShare)
 		{
 			share.POST("/:id", h.shareAudio)
 			share.DELETE("/:id", h.unshareAudio)
 		}
 
-		api.GET("/shares", h.getSharedAudio)
+		api.GET("/shares", readAudio, h.getSharedAudio)
+
+		invitations := api.Group("/invitations", h.methodScope(storage.ScopeAudioRead, storage.ScopeShareWrite))
+		{
+			invitations.GET("/", h.getInvitations)
+			invitations.POST("/:id/accept", h.acceptInvitation)
+			invitations.POST("/:id/decline", h.declineInvitation)
+		}
+
+		blocks := api.Group("/blocks", h.methodScope(storage.ScopeAudioRead, storage.ScopeShareWrite))
+		{
+			blocks.GET("/", h.getBlockedSenders)
+			blocks.POST("/", h.blockSender)
+			blocks.DELETE("/", h.unblockSender)
+		}
+
+		autoAccept := api.Group("/auto-accept", h.methodScope(storage.ScopeAudioRead, storage.ScopeShareWrite))
+		{
+			autoAccept.GET("/", h.getAutoAcceptSenders)
+			autoAccept.POST("/", h.addAutoAccept)
+			autoAccept.DELETE("/", h.removeAutoAccept)
+		}
+
+		collections := api.Group("/collections")
+		{
+			collections.GET("/", readAudio, h.getAllCollections)
+			collections.POST("/", writeAudio, h.createCollection)
+			collections.GET("/:id", readAudio, h.getCollectionItems)
+			collections.PUT("/:id", writeAudio, h.updateCollection)
+			collections.DELETE("/:id", writeAudio, h.deleteCollection)
+			collections.GET("/:id/artwork", readAudio, h.getCollectionArtwork)
+			collections.PUT("/:id/artwork", writeAudio, h.setCollectionArtwork)
+			collections.DELETE("/:id/artwork", writeAudio, h.deleteCollectionArtwork)
+			collections.POST("/:id/items", writeAudio, h.addCollectionItem)
+			collections.PUT("/:id/items", writeAudio, h.reorderCollection)
+			collections.DELETE("/:id/items/:audio_id", writeAudio, h.removeCollectionItem)
+			collections.POST("/:id/share", writeShare, h.shareCollection)
+			collections.DELETE("/:id/share", writeShare, h.unshareCollection)
+			collections.GET("/:id/feed", readAudio, h.getFeedSettings)
+			collections.PUT("/:id/feed", writeShare, h.publishFeed)
+			collections.DELETE("/:id/feed", writeShare, h.deleteFeed)
+			collections.POST("/:id/feed/token", writeShare, h.rotateFeedToken)
+		}
+
+		me := api.Group("/me", h.noAPIKey)
+		{
+			me.GET("/sessions", h.getSessions)
+			me.DELETE("/sessions/:id", h.deleteSession)
+			me.GET("/security-events", h.getSecurityEvents)
+			me.PUT("/password", h.changePassword)
+			me.PUT("/email", h.setEmail)
+			me.GET("/api-keys", h.getAPIKeys)
+			me.POST("/api-keys", h.createAPIKey)
+			me.DELETE("/api-keys/:id", h.deleteAPIKey)
+		}
+
+		admin := api.Group("/admin", h.noAPIKey, h.adminIdentity)
+		{
+			admin.GET("/jobs", h.getJobs)
+			admin.POST("/jobs", h.enqueueJob)
+			admin.POST("/jobs/:id/retry", h.retryJob)
+		}
 	}
 
 	return router
@@ -0,0 +1,52 @@
+package handler
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	storage "github.com/mahadeva604/audio-storage"
+	"net/http"
+)
+
+// @Summary Get HLS file
+// @Security ApiKeyAuth
+// @Tags audio
+// @Description get playlist or packed audio segment of audio you have access to, the package is made in background after upload
+// @ID get-hls-file
+// @Produce  application/vnd.apple.mpegurl,audio/aac
+// @Param id path int true "audio id"
+// @Param name path string true "index.m3u8 or segment file named in it"
+// @Success 200 "HLS file"
+// @Failure 400,404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /api/audio/{id}/hls/{name} [get]
+func (h *Handler) getHLSFile(c *gin.Context) {
+	userId, audioId, ok := getAudioParams(c)
+	if !ok {
+		return
+	}
+
+	name := c.Param("name")
+	if !storage.HLSFile(name) {
+		newErrorResponse(c, http.StatusBadRequest, "invalid name param")
+		return
+	}
+
+	data, err := h.services.GetHLSFile(userId, audioId, name)
+	if err != nil {
+		switch {
+		case errors.Is(err, storage.FileNotFound), errors.Is(err, storage.HLSNotFound):
+			newErrorResponse(c, http.StatusNotFound, err.Error())
+		default:
+			newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	contentType := "audio/aac"
+	if name == storage.HLSPlaylist {
+		contentType = "application/vnd.apple.mpegurl"
+	}
+
+	c.Data(http.StatusOK, contentType, data)
+}
@@ -0,0 +1,265 @@
+package handler
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	storage "github.com/mahadeva604/audio-storage"
+	"net/http"
+	"strconv"
+)
+
+// @Summary Get pending invitations
+// @Security ApiKeyAuth
+// @Tags invitation
+// @Description get audio and collections shared with you that wait for accept or decline
+// @ID get-invitations
+// @Accept  json
+// @Produce  json
+// @Param offset query integer true "offset" minimum(0)
+// @Param limit query integer true "limit"  minimum(1)
+// @Success 200 {object} storage.InvitationListJson
+// @Failure 400 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /api/invitations/ [get]
+func (h *Handler) getInvitations(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	var input storage.InvitationListParam
+	if err := c.BindQuery(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid query")
+		return
+	}
+
+	result, err := h.services.GetInvitations(userId, input)
+	if err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// @Summary Accept invitation
+// @Security ApiKeyAuth
+// @Tags invitation
+// @Description accept audio or collection shared with you
+// @ID accept-invitation
+// @Accept  json
+// @Produce  json
+// @Param id path int true "audio or collection id"
+// @Param type query string false "invitation type" Enums(audio, collection) default(audio)
+// @Success 200 {object} statusResponse
+// @Failure 400,404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /api/invitations/{id}/accept [post]
+func (h *Handler) acceptInvitation(c *gin.Context) {
+	h.answerInvitation(c, h.services.AcceptInvitation, h.services.AcceptCollectionInvitation)
+}
+
+// @Summary Decline invitation
+// @Security ApiKeyAuth
+// @Tags invitation
+// @Description decline audio or collection shared with you, already accepted ones can be declined too
+// @ID decline-invitation
+// @Accept  json
+// @Produce  json
+// @Param id path int true "audio or collection id"
+// @Param type query string false "invitation type" Enums(audio, collection) default(audio)
+// @Success 200 {object} statusResponse
+// @Failure 400,404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /api/invitations/{id}/decline [post]
+func (h *Handler) declineInvitation(c *gin.Context) {
+	h.answerInvitation(c, h.services.DeclineInvitation, h.services.DeclineCollectionInvitation)
+}
+
+func (h *Handler) answerInvitation(c *gin.Context, answerAudio, answerCollection func(userID, id int) error) {
+	userId, err := getUserId(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	answer, idName := answerAudio, "audio"
+	switch c.DefaultQuery("type", storage.InvitationAudio) {
+	case storage.InvitationAudio:
+	case storage.InvitationCollection:
+		answer, idName = answerCollection, "collection"
+	default:
+		newErrorResponse(c, http.StatusBadRequest, "invalid type param")
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid "+idName+" id param")
+		return
+	}
+
+	err = answer(userId, id)
+	if errors.Is(err, storage.InvitationNotFound) {
+		newErrorResponse(c, http.StatusNotFound, err.Error())
+		return
+	}
+
+	if err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, statusResponse{"ok"})
+}
+
+// @Summary Get blocked users
+// @Security ApiKeyAuth
+// @Tags invitation
+// @Description get users whose shares are declined automatically
+// @ID get-blocked-senders
+// @Accept  json
+// @Produce  json
+// @Success 200 {array} storage.Sender
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /api/blocks/ [get]
+func (h *Handler) getBlockedSenders(c *gin.Context) {
+	h.getSenders(c, h.services.GetBlockedSenders)
+}
+
+// @Summary Block user
+// @Security ApiKeyAuth
+// @Tags invitation
+// @Description decline all pending and future shares from user
+// @ID block-sender
+// @Accept  json
+// @Produce  json
+// @Param input body storage.SenderInput true "user to block"
+// @Success 200 {object} statusResponse
+// @Failure 400 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /api/blocks/ [post]
+func (h *Handler) blockSender(c *gin.Context) {
+	h.updateSender(c, h.services.BlockSender)
+}
+
+// @Summary Unblock user
+// @Security ApiKeyAuth
+// @Tags invitation
+// @Description unblock user
+// @ID unblock-sender
+// @Accept  json
+// @Produce  json
+// @Param input body storage.SenderInput true "user to unblock"
+// @Success 200 {object} statusResponse
+// @Failure 400,404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /api/blocks/ [delete]
+func (h *Handler) unblockSender(c *gin.Context) {
+	h.updateSender(c, h.services.UnblockSender)
+}
+
+// @Summary Get auto accept list
+// @Security ApiKeyAuth
+// @Tags invitation
+// @Description get users whose shares are accepted automatically
+// @ID get-auto-accept
+// @Accept  json
+// @Produce  json
+// @Success 200 {array} storage.Sender
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /api/auto-accept/ [get]
+func (h *Handler) getAutoAcceptSenders(c *gin.Context) {
+	h.getSenders(c, h.services.GetAutoAcceptSenders)
+}
+
+// @Summary Add user to auto accept list
+// @Security ApiKeyAuth
+// @Tags invitation
+// @Description accept future shares from user automatically
+// @ID add-auto-accept
+// @Accept  json
+// @Produce  json
+// @Param input body storage.SenderInput true "user to auto accept"
+// @Success 200 {object} statusResponse
+// @Failure 400 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /api/auto-accept/ [post]
+func (h *Handler) addAutoAccept(c *gin.Context) {
+	h.updateSender(c, h.services.AddAutoAccept)
+}
+
+// @Summary Remove user from auto accept list
+// @Security ApiKeyAuth
+// @Tags invitation
+// @Description remove user from auto accept list
+// @ID remove-auto-accept
+// @Accept  json
+// @Produce  json
+// @Param input body storage.SenderInput true "user to remove"
+// @Success 200 {object} statusResponse
+// @Failure 400,404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /api/auto-accept/ [delete]
+func (h *Handler) removeAutoAccept(c *gin.Context) {
+	h.updateSender(c, h.services.RemoveAutoAccept)
+}
+
+func (h *Handler) getSenders(c *gin.Context, list func(userID int) ([]storage.Sender, error)) {
+	userId, err := getUserId(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	result, err := list(userId)
+	if err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *Handler) updateSender(c *gin.Context, update func(userID, senderId int) error) {
+	userId, err := getUserId(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	var input storage.SenderInput
+	if err := c.BindJSON(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid input body")
+		return
+	}
+
+	err = update(userId, input.UserId)
+	if errors.Is(err, storage.BlockNotFound) || errors.Is(err, storage.AutoAcceptNotFound) {
+		newErrorResponse(c, http.StatusNotFound, err.Error())
+		return
+	}
+
+	if errors.Is(err, storage.BlockExists) || errors.Is(err, storage.AutoAcceptExists) || errors.Is(err, storage.SenderNotExists) ||
+		errors.Is(err, storage.SelfBlock) || errors.Is(err, storage.SelfAutoAccept) {
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, statusResponse{"ok"})
+}
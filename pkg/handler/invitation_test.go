@@ -0,0 +1,515 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	storage "github.com/mahadeva604/audio-storage"
+	"github.com/mahadeva604/audio-storage/pkg/service"
+	mock_service "github.com/mahadeva604/audio-storage/pkg/service/mocks"
+	"github.com/stretchr/testify/assert"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+)
+
+func TestHandler_getInvitations(t *testing.T) {
+	type mockBehavior func(s *mock_service.MockInvitation, userId int, input storage.InvitationListParam)
+
+	offset, limit := 0, 2
+
+	testTable := []struct {
+		name                 string
+		offset               string
+		limit                string
+		userId               int
+		input                storage.InvitationListParam
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:   "OK",
+			offset: strconv.Itoa(offset),
+			limit:  strconv.Itoa(limit),
+			userId: 1,
+			input: storage.InvitationListParam{
+				Offset: &offset,
+				Limit:  &limit,
+			},
+			mockBehavior: func(s *mock_service.MockInvitation, userId int, input storage.InvitationListParam) {
+				s.EXPECT().GetInvitations(userId, input).Return(storage.InvitationListJson{
+					TotalCount: 1,
+					Invitations: []storage.Invitation{
+						{
+							Type:      storage.InvitationAudio,
+							AudioId:   2,
+							Title:     "audio 2",
+							OwnerId:   3,
+							OwnerName: "user 3",
+						},
+					},
+				}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"total_count":1,"invitations":[{"type":"audio","audio_id":2,"title":"audio 2","owner_id":3,"owner_name":"user 3"}]}`,
+		},
+		{
+			name:                 "User not found",
+			offset:               strconv.Itoa(offset),
+			limit:                strconv.Itoa(limit),
+			mockBehavior:         func(s *mock_service.MockInvitation, userId int, input storage.InvitationListParam) {},
+			expectedStatusCode:   500,
+			expectedResponseBody: `{"message":"user id not found"}`,
+		},
+		{
+			name:                 "Invalid query",
+			offset:               "bad field",
+			limit:                strconv.Itoa(limit),
+			userId:               1,
+			mockBehavior:         func(s *mock_service.MockInvitation, userId int, input storage.InvitationListParam) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"invalid query"}`,
+		},
+		{
+			name:   "Service error",
+			offset: strconv.Itoa(offset),
+			limit:  strconv.Itoa(limit),
+			userId: 1,
+			input: storage.InvitationListParam{
+				Offset: &offset,
+				Limit:  &limit,
+			},
+			mockBehavior: func(s *mock_service.MockInvitation, userId int, input storage.InvitationListParam) {
+				s.EXPECT().GetInvitations(userId, input).Return(storage.InvitationListJson{}, errors.New("service error"))
+			},
+			expectedStatusCode:   500,
+			expectedResponseBody: `{"message":"service error"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			invitation := mock_service.NewMockInvitation(c)
+			testCase.mockBehavior(invitation, testCase.userId, testCase.input)
+
+			services := &service.Service{Invitation: invitation}
+			handler := NewHandler(services)
+
+			r := gin.New()
+			if testCase.userId != 0 {
+				r.GET("/invitations", func(c *gin.Context) {
+					c.Set(userCtx, testCase.userId)
+				}, handler.getInvitations)
+			} else {
+				r.GET("/invitations", handler.getInvitations)
+			}
+
+			w := httptest.NewRecorder()
+			params := url.Values{"offset": {testCase.offset}, "limit": {testCase.limit}}.Encode()
+			req := httptest.NewRequest("GET", "/invitations?"+params, nil)
+
+			r.ServeHTTP(w, req)
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedResponseBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_answerInvitation(t *testing.T) {
+	type mockBehavior func(s *mock_service.MockInvitation, userId, audioId int)
+
+	testTable := []struct {
+		name                 string
+		action               string
+		invitationType       string
+		userId               int
+		audioId              int
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:    "OK accept",
+			action:  "accept",
+			userId:  1,
+			audioId: 2,
+			mockBehavior: func(s *mock_service.MockInvitation, userId, audioId int) {
+				s.EXPECT().AcceptInvitation(userId, audioId).Return(nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"status":"ok"}`,
+		},
+		{
+			name:    "OK decline",
+			action:  "decline",
+			userId:  1,
+			audioId: 2,
+			mockBehavior: func(s *mock_service.MockInvitation, userId, audioId int) {
+				s.EXPECT().DeclineInvitation(userId, audioId).Return(nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"status":"ok"}`,
+		},
+		{
+			name:           "OK accept collection",
+			action:         "accept",
+			invitationType: "collection",
+			userId:         1,
+			audioId:        4,
+			mockBehavior: func(s *mock_service.MockInvitation, userId, collectionId int) {
+				s.EXPECT().AcceptCollectionInvitation(userId, collectionId).Return(nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"status":"ok"}`,
+		},
+		{
+			name:           "OK decline collection",
+			action:         "decline",
+			invitationType: "collection",
+			userId:         1,
+			audioId:        4,
+			mockBehavior: func(s *mock_service.MockInvitation, userId, collectionId int) {
+				s.EXPECT().DeclineCollectionInvitation(userId, collectionId).Return(nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"status":"ok"}`,
+		},
+		{
+			name:                 "Invalid collection id",
+			action:               "accept",
+			invitationType:       "collection",
+			userId:               1,
+			mockBehavior:         func(s *mock_service.MockInvitation, userId, audioId int) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"invalid collection id param"}`,
+		},
+		{
+			name:                 "Invalid type",
+			action:               "accept",
+			invitationType:       "playlist",
+			userId:               1,
+			audioId:              2,
+			mockBehavior:         func(s *mock_service.MockInvitation, userId, audioId int) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"invalid type param"}`,
+		},
+		{
+			name:                 "User not found",
+			action:               "accept",
+			audioId:              2,
+			mockBehavior:         func(s *mock_service.MockInvitation, userId, audioId int) {},
+			expectedStatusCode:   500,
+			expectedResponseBody: `{"message":"user id not found"}`,
+		},
+		{
+			name:                 "Invalid audio id",
+			action:               "accept",
+			userId:               1,
+			mockBehavior:         func(s *mock_service.MockInvitation, userId, audioId int) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"invalid audio id param"}`,
+		},
+		{
+			name:    "Invitation not found",
+			action:  "decline",
+			userId:  1,
+			audioId: 2,
+			mockBehavior: func(s *mock_service.MockInvitation, userId, audioId int) {
+				s.EXPECT().DeclineInvitation(userId, audioId).Return(storage.InvitationNotFound)
+			},
+			expectedStatusCode:   404,
+			expectedResponseBody: `{"message":"invitation not found"}`,
+		},
+		{
+			name:    "Service error",
+			action:  "accept",
+			userId:  1,
+			audioId: 2,
+			mockBehavior: func(s *mock_service.MockInvitation, userId, audioId int) {
+				s.EXPECT().AcceptInvitation(userId, audioId).Return(errors.New("service error"))
+			},
+			expectedStatusCode:   500,
+			expectedResponseBody: `{"message":"service error"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			invitation := mock_service.NewMockInvitation(c)
+			testCase.mockBehavior(invitation, testCase.userId, testCase.audioId)
+
+			services := &service.Service{Invitation: invitation}
+			handler := NewHandler(services)
+
+			r := gin.New()
+			if testCase.userId != 0 {
+				r.Use(func(c *gin.Context) {
+					c.Set(userCtx, testCase.userId)
+				})
+			}
+			r.POST("/invitations/:id/accept", handler.acceptInvitation)
+			r.POST("/invitations/:id/decline", handler.declineInvitation)
+
+			w := httptest.NewRecorder()
+			target := fmt.Sprintf("/invitations/%d/%s", testCase.audioId, testCase.action)
+			if testCase.audioId == 0 {
+				target = fmt.Sprintf("/invitations/wrong_id/%s", testCase.action)
+			}
+			if testCase.invitationType != "" {
+				target += "?type=" + testCase.invitationType
+			}
+			req := httptest.NewRequest("POST", target, nil)
+
+			r.ServeHTTP(w, req)
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedResponseBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_updateSender(t *testing.T) {
+	type mockBehavior func(s *mock_service.MockInvitation, userId, senderId int)
+
+	testTable := []struct {
+		name                 string
+		method               string
+		target               string
+		userId               int
+		inputBody            string
+		senderId             int
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:      "OK block",
+			method:    "POST",
+			target:    "/blocks",
+			userId:    1,
+			inputBody: `{"user_id":2}`,
+			senderId:  2,
+			mockBehavior: func(s *mock_service.MockInvitation, userId, senderId int) {
+				s.EXPECT().BlockSender(userId, senderId).Return(nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"status":"ok"}`,
+		},
+		{
+			name:      "Block yourself",
+			method:    "POST",
+			target:    "/blocks",
+			userId:    1,
+			inputBody: `{"user_id":1}`,
+			senderId:  1,
+			mockBehavior: func(s *mock_service.MockInvitation, userId, senderId int) {
+				s.EXPECT().BlockSender(userId, senderId).Return(storage.SelfBlock)
+			},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"can't block yourself"}`,
+		},
+		{
+			name:      "Unblock not blocked",
+			method:    "DELETE",
+			target:    "/blocks",
+			userId:    1,
+			inputBody: `{"user_id":2}`,
+			senderId:  2,
+			mockBehavior: func(s *mock_service.MockInvitation, userId, senderId int) {
+				s.EXPECT().UnblockSender(userId, senderId).Return(storage.BlockNotFound)
+			},
+			expectedStatusCode:   404,
+			expectedResponseBody: `{"message":"user is not blocked"}`,
+		},
+		{
+			name:      "Auto accept exists",
+			method:    "POST",
+			target:    "/auto-accept",
+			userId:    1,
+			inputBody: `{"user_id":2}`,
+			senderId:  2,
+			mockBehavior: func(s *mock_service.MockInvitation, userId, senderId int) {
+				s.EXPECT().AddAutoAccept(userId, senderId).Return(storage.AutoAcceptExists)
+			},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"user already in auto accept list"}`,
+		},
+		{
+			name:      "Auto accept yourself",
+			method:    "POST",
+			target:    "/auto-accept",
+			userId:    1,
+			inputBody: `{"user_id":1}`,
+			senderId:  1,
+			mockBehavior: func(s *mock_service.MockInvitation, userId, senderId int) {
+				s.EXPECT().AddAutoAccept(userId, senderId).Return(storage.SelfAutoAccept)
+			},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"can't auto accept shares from yourself"}`,
+		},
+		{
+			name:      "OK remove auto accept",
+			method:    "DELETE",
+			target:    "/auto-accept",
+			userId:    1,
+			inputBody: `{"user_id":2}`,
+			senderId:  2,
+			mockBehavior: func(s *mock_service.MockInvitation, userId, senderId int) {
+				s.EXPECT().RemoveAutoAccept(userId, senderId).Return(nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"status":"ok"}`,
+		},
+		{
+			name:                 "User not found",
+			method:               "POST",
+			target:               "/blocks",
+			inputBody:            `{"user_id":2}`,
+			mockBehavior:         func(s *mock_service.MockInvitation, userId, senderId int) {},
+			expectedStatusCode:   500,
+			expectedResponseBody: `{"message":"user id not found"}`,
+		},
+		{
+			name:                 "Invalid input",
+			method:               "POST",
+			target:               "/blocks",
+			userId:               1,
+			mockBehavior:         func(s *mock_service.MockInvitation, userId, senderId int) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"invalid input body"}`,
+		},
+		{
+			name:      "Service error",
+			method:    "POST",
+			target:    "/blocks",
+			userId:    1,
+			inputBody: `{"user_id":2}`,
+			senderId:  2,
+			mockBehavior: func(s *mock_service.MockInvitation, userId, senderId int) {
+				s.EXPECT().BlockSender(userId, senderId).Return(errors.New("service error"))
+			},
+			expectedStatusCode:   500,
+			expectedResponseBody: `{"message":"service error"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			invitation := mock_service.NewMockInvitation(c)
+			testCase.mockBehavior(invitation, testCase.userId, testCase.senderId)
+
+			services := &service.Service{Invitation: invitation}
+			handler := NewHandler(services)
+
+			r := gin.New()
+			if testCase.userId != 0 {
+				r.Use(func(c *gin.Context) {
+					c.Set(userCtx, testCase.userId)
+				})
+			}
+			r.POST("/blocks", handler.blockSender)
+			r.DELETE("/blocks", handler.unblockSender)
+			r.POST("/auto-accept", handler.addAutoAccept)
+			r.DELETE("/auto-accept", handler.removeAutoAccept)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(testCase.method, testCase.target, bytes.NewBufferString(testCase.inputBody))
+
+			r.ServeHTTP(w, req)
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedResponseBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_getSenders(t *testing.T) {
+	type mockBehavior func(s *mock_service.MockInvitation, userId int)
+
+	testTable := []struct {
+		name                 string
+		target               string
+		userId               int
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:   "OK blocked",
+			target: "/blocks",
+			userId: 1,
+			mockBehavior: func(s *mock_service.MockInvitation, userId int) {
+				s.EXPECT().GetBlockedSenders(userId).Return([]storage.Sender{{UserId: 2, Name: "user 2"}}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `[{"id":2,"name":"user 2"}]`,
+		},
+		{
+			name:   "OK auto accept",
+			target: "/auto-accept",
+			userId: 1,
+			mockBehavior: func(s *mock_service.MockInvitation, userId int) {
+				s.EXPECT().GetAutoAcceptSenders(userId).Return([]storage.Sender{}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `[]`,
+		},
+		{
+			name:                 "User not found",
+			target:               "/blocks",
+			mockBehavior:         func(s *mock_service.MockInvitation, userId int) {},
+			expectedStatusCode:   500,
+			expectedResponseBody: `{"message":"user id not found"}`,
+		},
+		{
+			name:   "Service error",
+			target: "/auto-accept",
+			userId: 1,
+			mockBehavior: func(s *mock_service.MockInvitation, userId int) {
+				s.EXPECT().GetAutoAcceptSenders(userId).Return(nil, errors.New("service error"))
+			},
+			expectedStatusCode:   500,
+			expectedResponseBody: `{"message":"service error"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			invitation := mock_service.NewMockInvitation(c)
+			testCase.mockBehavior(invitation, testCase.userId)
+
+			services := &service.Service{Invitation: invitation}
+			handler := NewHandler(services)
+
+			r := gin.New()
+			if testCase.userId != 0 {
+				r.Use(func(c *gin.Context) {
+					c.Set(userCtx, testCase.userId)
+				})
+			}
+			r.GET("/blocks", handler.getBlockedSenders)
+			r.GET("/auto-accept", handler.getAutoAcceptSenders)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", testCase.target, nil)
+
+			r.ServeHTTP(w, req)
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedResponseBody, w.Body.String())
+		})
+	}
+}
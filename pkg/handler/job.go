@@ -0,0 +1,141 @@
+package handler
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	storage "github.com/mahadeva604/audio-storage"
+	"net/http"
+	"strconv"
+)
+
+// @Summary Get audio jobs
+// @Security ApiKeyAuth
+// @Tags job
+// @Description get the latest background job of every type of audio you have access to
+// @ID get-audio-jobs
+// @Produce  json
+// @Param id path int true "audio id"
+// @Success 200 {array} storage.Job
+// @Failure 400,404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /api/audio/{id}/jobs [get]
+func (h *Handler) getAudioJobs(c *gin.Context) {
+	userId, audioId, ok := getAudioParams(c)
+	if !ok {
+		return
+	}
+
+	jobs, err := h.services.GetAudioJobs(userId, audioId)
+	if err != nil {
+		newJobErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, jobs)
+}
+
+// @Summary Get jobs
+// @Security ApiKeyAuth
+// @Tags admin
+// @Description get background jobs of every user newest first, admin only
+// @ID get-jobs
+// @Produce  json
+// @Param status query string false "job status" Enums(queued,running,done,dead)
+// @Param type query string false "job type" Enums(probe,remux,hls,checksum,cleanup)
+// @Param offset query integer true "offset" minimum(0)
+// @Param limit query integer true "limit" minimum(1)
+// @Success 200 {object} storage.JobListJson
+// @Failure 400,403 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /api/admin/jobs [get]
+func (h *Handler) getJobs(c *gin.Context) {
+	var input storage.JobListParam
+	if err := c.BindQuery(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid query")
+		return
+	}
+
+	jobs, err := h.services.GetJobs(input)
+	if err != nil {
+		newJobErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, jobs)
+}
+
+// @Summary Queue job
+// @Security ApiKeyAuth
+// @Tags admin
+// @Description queue background job of audio or cleanup of unreferenced files, admin only
+// @ID enqueue-job
+// @Accept  json
+// @Produce  json
+// @Param input body storage.JobInput true "job"
+// @Success 202 {object} idResponse
+// @Failure 400,403,404,409 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /api/admin/jobs [post]
+func (h *Handler) enqueueJob(c *gin.Context) {
+	var input storage.JobInput
+	if err := c.BindJSON(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid input body")
+		return
+	}
+
+	if err := input.Validate(); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	jobId, err := h.services.EnqueueJob(input)
+	if err != nil {
+		newJobErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, idResponse{
+		ID: jobId,
+	})
+}
+
+// @Summary Retry dead job
+// @Security ApiKeyAuth
+// @Tags admin
+// @Description queue dead job again with attempts reset, admin only
+// @ID retry-job
+// @Produce  json
+// @Param id path int true "job id"
+// @Success 202 {object} statusResponse
+// @Failure 400,403,404,409 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /api/admin/jobs/{id}/retry [post]
+func (h *Handler) retryJob(c *gin.Context) {
+	jobId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid job id param")
+		return
+	}
+
+	if err := h.services.RetryJob(jobId); err != nil {
+		newJobErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, statusResponse{"ok"})
+}
+
+func newJobErrorResponse(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, storage.FileNotFound), errors.Is(err, storage.JobNotFound):
+		newErrorResponse(c, http.StatusNotFound, err.Error())
+	case errors.Is(err, storage.JobActive), errors.Is(err, storage.JobNotDead):
+		newErrorResponse(c, http.StatusConflict, err.Error())
+	default:
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+	}
+}
@@ -0,0 +1,393 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	storage "github.com/mahadeva604/audio-storage"
+	"github.com/mahadeva604/audio-storage/pkg/service"
+	mock_service "github.com/mahadeva604/audio-storage/pkg/service/mocks"
+	"github.com/stretchr/testify/assert"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHandler_getAudioJobs(t *testing.T) {
+	at := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	audioId := 2
+
+	testTable := []struct {
+		name                 string
+		audioId              string
+		mockBehavior         func(s *mock_service.MockJob)
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:    "OK",
+			audioId: "2",
+			mockBehavior: func(s *mock_service.MockJob) {
+				s.EXPECT().GetAudioJobs(1, 2).Return([]storage.Job{
+					{Id: 7, Type: storage.JobHLS, AudioId: &audioId, Status: storage.JobQueued, Attempts: 1, MaxAttempts: 5,
+						Error: "read error", RunAt: at, CreatedAt: at, UpdatedAt: at},
+				}, nil)
+			},
+			expectedStatusCode: 200,
+			expectedResponseBody: `[{"id":7,"type":"hls","audio_id":2,"status":"queued","attempts":1,"max_attempts":5,"error":"read error",` +
+				`"run_at":"2021-06-01T12:00:00Z","created_at":"2021-06-01T12:00:00Z","updated_at":"2021-06-01T12:00:00Z"}]`,
+		},
+		{
+			name:    "OK no jobs",
+			audioId: "2",
+			mockBehavior: func(s *mock_service.MockJob) {
+				s.EXPECT().GetAudioJobs(1, 2).Return([]storage.Job{}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `[]`,
+		},
+		{
+			name:    "Not found",
+			audioId: "2",
+			mockBehavior: func(s *mock_service.MockJob) {
+				s.EXPECT().GetAudioJobs(1, 2).Return(nil, storage.FileNotFound)
+			},
+			expectedStatusCode:   404,
+			expectedResponseBody: `{"message":"file not found or you haven't access"}`,
+		},
+		{
+			name:                 "Invalid id",
+			audioId:              "x",
+			mockBehavior:         func(s *mock_service.MockJob) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"invalid audio id param"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			job := mock_service.NewMockJob(c)
+			testCase.mockBehavior(job)
+
+			handler := NewHandler(&service.Service{Job: job})
+
+			r := gin.New()
+			r.GET("/audio/:id/jobs", func(c *gin.Context) {
+				c.Set(userCtx, 1)
+			}, handler.getAudioJobs)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/audio/"+testCase.audioId+"/jobs", nil)
+
+			r.ServeHTTP(w, req)
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedResponseBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_getJobs(t *testing.T) {
+	offset, limit := 0, 10
+
+	testTable := []struct {
+		name                 string
+		query                string
+		mockBehavior         func(s *mock_service.MockJob)
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:  "OK",
+			query: "?status=dead&type=checksum&offset=0&limit=10",
+			mockBehavior: func(s *mock_service.MockJob) {
+				s.EXPECT().GetJobs(storage.JobListParam{Status: storage.JobDead, Type: storage.JobChecksum, Offset: &offset, Limit: &limit}).
+					Return(storage.JobListJson{Records: []storage.Job{}}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"total_count":0,"records":[]}`,
+		},
+		{
+			name:                 "Invalid status",
+			query:                "?status=failed&offset=0&limit=10",
+			mockBehavior:         func(s *mock_service.MockJob) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"invalid query"}`,
+		},
+		{
+			name:                 "Missing limit",
+			query:                "?offset=0",
+			mockBehavior:         func(s *mock_service.MockJob) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"invalid query"}`,
+		},
+		{
+			name:  "Service error",
+			query: "?offset=0&limit=10",
+			mockBehavior: func(s *mock_service.MockJob) {
+				s.EXPECT().GetJobs(storage.JobListParam{Offset: &offset, Limit: &limit}).Return(storage.JobListJson{}, errors.New("db error"))
+			},
+			expectedStatusCode:   500,
+			expectedResponseBody: `{"message":"db error"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			job := mock_service.NewMockJob(c)
+			testCase.mockBehavior(job)
+
+			handler := NewHandler(&service.Service{Job: job})
+
+			r := gin.New()
+			r.GET("/admin/jobs", handler.getJobs)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/admin/jobs"+testCase.query, nil)
+
+			r.ServeHTTP(w, req)
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedResponseBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_enqueueJob(t *testing.T) {
+	audioId := 2
+
+	testTable := []struct {
+		name                 string
+		inputBody            string
+		mockBehavior         func(s *mock_service.MockJob)
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:      "OK",
+			inputBody: `{"type":"hls","audio_id":2}`,
+			mockBehavior: func(s *mock_service.MockJob) {
+				s.EXPECT().EnqueueJob(storage.JobInput{Type: storage.JobHLS, AudioId: &audioId}).Return(7, nil)
+			},
+			expectedStatusCode:   202,
+			expectedResponseBody: `{"id":7}`,
+		},
+		{
+			name:      "OK cleanup",
+			inputBody: `{"type":"cleanup"}`,
+			mockBehavior: func(s *mock_service.MockJob) {
+				s.EXPECT().EnqueueJob(storage.JobInput{Type: storage.JobCleanup}).Return(8, nil)
+			},
+			expectedStatusCode:   202,
+			expectedResponseBody: `{"id":8}`,
+		},
+		{
+			name:                 "Unknown type",
+			inputBody:            `{"type":"transcode","audio_id":2}`,
+			mockBehavior:         func(s *mock_service.MockJob) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"invalid input body"}`,
+		},
+		{
+			name:                 "Missing audio",
+			inputBody:            `{"type":"probe"}`,
+			mockBehavior:         func(s *mock_service.MockJob) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"audio_id is required for probe job"}`,
+		},
+		{
+			name:                 "Cleanup with audio",
+			inputBody:            `{"type":"cleanup","audio_id":2}`,
+			mockBehavior:         func(s *mock_service.MockJob) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"cleanup job can't have audio_id"}`,
+		},
+		{
+			name:      "Already queued",
+			inputBody: `{"type":"hls","audio_id":2}`,
+			mockBehavior: func(s *mock_service.MockJob) {
+				s.EXPECT().EnqueueJob(storage.JobInput{Type: storage.JobHLS, AudioId: &audioId}).Return(0, storage.JobActive)
+			},
+			expectedStatusCode:   409,
+			expectedResponseBody: `{"message":"job of the same type is already queued or running"}`,
+		},
+		{
+			name:      "Audio not found",
+			inputBody: `{"type":"hls","audio_id":2}`,
+			mockBehavior: func(s *mock_service.MockJob) {
+				s.EXPECT().EnqueueJob(storage.JobInput{Type: storage.JobHLS, AudioId: &audioId}).Return(0, storage.FileNotFound)
+			},
+			expectedStatusCode:   404,
+			expectedResponseBody: `{"message":"file not found or you haven't access"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			job := mock_service.NewMockJob(c)
+			testCase.mockBehavior(job)
+
+			handler := NewHandler(&service.Service{Job: job})
+
+			r := gin.New()
+			r.POST("/admin/jobs", handler.enqueueJob)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/admin/jobs", bytes.NewBufferString(testCase.inputBody))
+
+			r.ServeHTTP(w, req)
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedResponseBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_retryJob(t *testing.T) {
+	testTable := []struct {
+		name                 string
+		jobId                string
+		mockBehavior         func(s *mock_service.MockJob)
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:  "OK",
+			jobId: "7",
+			mockBehavior: func(s *mock_service.MockJob) {
+				s.EXPECT().RetryJob(7).Return(nil)
+			},
+			expectedStatusCode:   202,
+			expectedResponseBody: `{"status":"ok"}`,
+		},
+		{
+			name:  "Not dead",
+			jobId: "7",
+			mockBehavior: func(s *mock_service.MockJob) {
+				s.EXPECT().RetryJob(7).Return(storage.JobNotDead)
+			},
+			expectedStatusCode:   409,
+			expectedResponseBody: `{"message":"only dead jobs can be retried"}`,
+		},
+		{
+			name:  "Not found",
+			jobId: "7",
+			mockBehavior: func(s *mock_service.MockJob) {
+				s.EXPECT().RetryJob(7).Return(storage.JobNotFound)
+			},
+			expectedStatusCode:   404,
+			expectedResponseBody: `{"message":"job not found"}`,
+		},
+		{
+			name:                 "Invalid id",
+			jobId:                "x",
+			mockBehavior:         func(s *mock_service.MockJob) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"invalid job id param"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			job := mock_service.NewMockJob(c)
+			testCase.mockBehavior(job)
+
+			handler := NewHandler(&service.Service{Job: job})
+
+			r := gin.New()
+			r.POST("/admin/jobs/:id/retry", handler.retryJob)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/admin/jobs/"+testCase.jobId+"/retry", nil)
+
+			r.ServeHTTP(w, req)
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedResponseBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_getHLSFile(t *testing.T) {
+	testTable := []struct {
+		name                 string
+		fileName             string
+		mockBehavior         func(s *mock_service.MockHLS)
+		expectedStatusCode   int
+		expectedContentType  string
+		expectedResponseBody string
+	}{
+		{
+			name:     "OK playlist",
+			fileName: "index.m3u8",
+			mockBehavior: func(s *mock_service.MockHLS) {
+				s.EXPECT().GetHLSFile(1, 2, "index.m3u8").Return([]byte("#EXTM3U\n"), nil)
+			},
+			expectedStatusCode:   200,
+			expectedContentType:  "application/vnd.apple.mpegurl",
+			expectedResponseBody: "#EXTM3U\n",
+		},
+		{
+			name:     "OK segment",
+			fileName: "segment00001.aac",
+			mockBehavior: func(s *mock_service.MockHLS) {
+				s.EXPECT().GetHLSFile(1, 2, "segment00001.aac").Return([]byte("ID3"), nil)
+			},
+			expectedStatusCode:   200,
+			expectedContentType:  "audio/aac",
+			expectedResponseBody: "ID3",
+		},
+		{
+			name:     "Not packaged",
+			fileName: "index.m3u8",
+			mockBehavior: func(s *mock_service.MockHLS) {
+				s.EXPECT().GetHLSFile(1, 2, "index.m3u8").Return(nil, storage.HLSNotFound)
+			},
+			expectedStatusCode:   404,
+			expectedContentType:  "application/json; charset=utf-8",
+			expectedResponseBody: `{"message":"HLS playlist or segment not found"}`,
+		},
+		{
+			name:                 "Invalid name",
+			fileName:             "secret.aac",
+			mockBehavior:         func(s *mock_service.MockHLS) {},
+			expectedStatusCode:   400,
+			expectedContentType:  "application/json; charset=utf-8",
+			expectedResponseBody: `{"message":"invalid name param"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			hls := mock_service.NewMockHLS(c)
+			testCase.mockBehavior(hls)
+
+			handler := NewHandler(&service.Service{HLS: hls})
+
+			r := gin.New()
+			r.GET("/audio/:id/hls/:name", func(c *gin.Context) {
+				c.Set(userCtx, 1)
+			}, handler.getHLSFile)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/audio/2/hls/"+testCase.fileName, nil)
+
+			r.ServeHTTP(w, req)
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedContentType, w.Header().Get("Content-Type"))
+			assert.Equal(t, testCase.expectedResponseBody, w.Body.String())
+		})
+	}
+}
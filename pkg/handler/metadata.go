@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	storage "github.com/mahadeva604/audio-storage"
+	"net/http"
+)
+
+// @Summary Get metadata schema
+// @Security ApiKeyAuth
+// @Tags metadata
+// @Description get JSON schema custom fields of your audio are validated against
+// @ID get-metadata-schema
+// @Accept  json
+// @Produce  json
+// @Success 200 {object} storage.MetadataSchema
+// @Failure 404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /api/metadata-schema [get]
+func (h *Handler) getMetadataSchema(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	schema, err := h.services.GetMetadataSchema(userId)
+	if err != nil {
+		newMetadataSchemaErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, schema)
+}
+
+// @Summary Set metadata schema
+// @Security ApiKeyAuth
+// @Tags metadata
+// @Description set JSON schema custom fields of your audio are validated against on update, stored values aren't checked
+// @ID set-metadata-schema
+// @Accept  json
+// @Produce  json
+// @Param input body storage.MetadataSchema true "JSON schema"
+// @Success 200 {object} statusResponse
+// @Failure 400 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /api/metadata-schema [put]
+func (h *Handler) setMetadataSchema(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	var input storage.MetadataSchema
+	if err := c.BindJSON(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid input body")
+		return
+	}
+
+	if err := h.services.SetMetadataSchema(userId, input); err != nil {
+		newMetadataSchemaErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, statusResponse{"ok"})
+}
+
+// @Summary Delete metadata schema
+// @Security ApiKeyAuth
+// @Tags metadata
+// @Description delete metadata schema, custom fields aren't validated anymore
+// @ID delete-metadata-schema
+// @Accept  json
+// @Produce  json
+// @Success 200 {object} statusResponse
+// @Failure 404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /api/metadata-schema [delete]
+func (h *Handler) deleteMetadataSchema(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if err := h.services.DeleteMetadataSchema(userId); err != nil {
+		newMetadataSchemaErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, statusResponse{"ok"})
+}
+
+func newMetadataSchemaErrorResponse(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, storage.MetadataSchemaNotFound):
+		newErrorResponse(c, http.StatusNotFound, err.Error())
+	case errors.Is(err, storage.InvalidMetadataSchema):
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+	default:
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+	}
+}
@@ -0,0 +1,133 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	storage "github.com/mahadeva604/audio-storage"
+	"github.com/mahadeva604/audio-storage/pkg/service"
+	mock_service "github.com/mahadeva604/audio-storage/pkg/service/mocks"
+	"github.com/stretchr/testify/assert"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHandler_metadataSchema(t *testing.T) {
+	type mockBehavior func(s *mock_service.MockMetadataSchema, userId int)
+
+	schema := json.RawMessage(`{"type":"object"}`)
+
+	testTable := []struct {
+		name                 string
+		method               string
+		inputBody            string
+		userId               int
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:   "OK get",
+			method: "GET",
+			userId: 1,
+			mockBehavior: func(s *mock_service.MockMetadataSchema, userId int) {
+				s.EXPECT().GetMetadataSchema(userId).Return(storage.MetadataSchema{Schema: schema}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"schema":{"type":"object"}}`,
+		},
+		{
+			name:   "Get not found",
+			method: "GET",
+			userId: 1,
+			mockBehavior: func(s *mock_service.MockMetadataSchema, userId int) {
+				s.EXPECT().GetMetadataSchema(userId).Return(storage.MetadataSchema{}, storage.MetadataSchemaNotFound)
+			},
+			expectedStatusCode:   404,
+			expectedResponseBody: `{"message":"metadata schema not found"}`,
+		},
+		{
+			name:      "OK set",
+			method:    "PUT",
+			inputBody: `{"schema":{"type":"object"}}`,
+			userId:    1,
+			mockBehavior: func(s *mock_service.MockMetadataSchema, userId int) {
+				s.EXPECT().SetMetadataSchema(userId, storage.MetadataSchema{Schema: schema}).Return(nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"status":"ok"}`,
+		},
+		{
+			name:                 "Set invalid input",
+			method:               "PUT",
+			inputBody:            `{}`,
+			userId:               1,
+			mockBehavior:         func(s *mock_service.MockMetadataSchema, userId int) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"invalid input body"}`,
+		},
+		{
+			name:      "Set invalid schema",
+			method:    "PUT",
+			inputBody: `{"schema":{"type":"object"}}`,
+			userId:    1,
+			mockBehavior: func(s *mock_service.MockMetadataSchema, userId int) {
+				s.EXPECT().SetMetadataSchema(userId, storage.MetadataSchema{Schema: schema}).
+					Return(fmt.Errorf("%w: bad type", storage.InvalidMetadataSchema))
+			},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"metadata schema is not a valid JSON schema: bad type"}`,
+		},
+		{
+			name:   "OK delete",
+			method: "DELETE",
+			userId: 1,
+			mockBehavior: func(s *mock_service.MockMetadataSchema, userId int) {
+				s.EXPECT().DeleteMetadataSchema(userId).Return(nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"status":"ok"}`,
+		},
+		{
+			name:   "Delete service error",
+			method: "DELETE",
+			userId: 1,
+			mockBehavior: func(s *mock_service.MockMetadataSchema, userId int) {
+				s.EXPECT().DeleteMetadataSchema(userId).Return(errors.New("service error"))
+			},
+			expectedStatusCode:   500,
+			expectedResponseBody: `{"message":"service error"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			metadataSchema := mock_service.NewMockMetadataSchema(c)
+			testCase.mockBehavior(metadataSchema, testCase.userId)
+
+			services := &service.Service{MetadataSchema: metadataSchema}
+			handler := NewHandler(services)
+
+			r := gin.New()
+			setUser := func(c *gin.Context) {
+				c.Set(userCtx, testCase.userId)
+			}
+			r.GET("/metadata-schema", setUser, handler.getMetadataSchema)
+			r.PUT("/metadata-schema", setUser, handler.setMetadataSchema)
+			r.DELETE("/metadata-schema", setUser, handler.deleteMetadataSchema)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(testCase.method, "/metadata-schema", bytes.NewBufferString(testCase.inputBody))
+
+			r.ServeHTTP(w, req)
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedResponseBody, w.Body.String())
+		})
+	}
+}
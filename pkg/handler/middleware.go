@@ -2,7 +2,9 @@ package handler
 
 import (
 	"errors"
+	"fmt"
 	"github.com/gin-gonic/gin"
+	storage "github.com/mahadeva604/audio-storage"
 	"net/http"
 	"strings"
 )
@@ -10,6 +12,8 @@ import (
 const (
 	authorizationHeader = "Authorization"
 	userCtx             = "userId"
+	tokenCtx            = "accessToken"
+	apiKeyCtx           = "apiKey"
 )
 
 func (h *Handler) userIdentity(c *gin.Context) {
@@ -30,6 +34,18 @@ func (h *Handler) userIdentity(c *gin.Context) {
 		return
 	}
 
+	if strings.HasPrefix(headerParts[1], storage.APIKeyPrefix) {
+		owner, err := h.services.ParseAPIKey(headerParts[1])
+		if err != nil {
+			newErrorResponse(c, http.StatusUnauthorized, err.Error())
+			return
+		}
+
+		c.Set(userCtx, owner.UserId)
+		c.Set(apiKeyCtx, owner)
+		return
+	}
+
 	userId, err := h.services.Authorization.ParseToken(headerParts[1])
 	if err != nil {
 		newErrorResponse(c, http.StatusUnauthorized, err.Error())
@@ -37,6 +53,66 @@ func (h *Handler) userIdentity(c *gin.Context) {
 	}
 
 	c.Set(userCtx, userId)
+	c.Set(tokenCtx, headerParts[1])
+}
+
+// adminIdentity allows only admins, it runs after userIdentity
+func (h *Handler) adminIdentity(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	isAdmin, err := h.services.Authorization.IsAdmin(userId)
+	if err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if !isAdmin {
+		newErrorResponse(c, http.StatusForbidden, storage.NotAdmin.Error())
+		return
+	}
+}
+
+// scope allows API keys with scope, access tokens have every scope. It runs after userIdentity
+func (h *Handler) scope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		owner, ok := getAPIKeyOwner(c)
+		if ok && !owner.HasScope(scope) {
+			newErrorResponse(c, http.StatusForbidden, fmt.Sprintf("%s: %s", storage.InsufficientScope.Error(), scope))
+		}
+	}
+}
+
+// methodScope requires read scope for GET requests and write scope for the others
+func (h *Handler) methodScope(read, write string) gin.HandlerFunc {
+	readScope, writeScope := h.scope(read), h.scope(write)
+	return func(c *gin.Context) {
+		if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+			readScope(c)
+		} else {
+			writeScope(c)
+		}
+	}
+}
+
+// noAPIKey allows only access tokens, API keys can't manage account
+func (h *Handler) noAPIKey(c *gin.Context) {
+	if _, ok := getAPIKeyOwner(c); ok {
+		newErrorResponse(c, http.StatusForbidden, storage.APIKeyNotAllowed.Error())
+	}
+}
+
+func getAPIKeyOwner(c *gin.Context) (storage.APIKeyOwner, bool) {
+	owner, ok := c.Get(apiKeyCtx)
+	if !ok {
+		return storage.APIKeyOwner{}, false
+	}
+
+	apiKeyOwner, ok := owner.(storage.APIKeyOwner)
+	return apiKeyOwner, ok
 }
 
 func getUserId(c *gin.Context) (int, error) {
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"github.com/gin-gonic/gin"
 	"github.com/golang/mock/gomock"
+	storage "github.com/mahadeva604/audio-storage"
 	"github.com/mahadeva604/audio-storage/pkg/service"
 	mock_service "github.com/mahadeva604/audio-storage/pkg/service/mocks"
 	"github.com/stretchr/testify/assert"
@@ -97,3 +98,184 @@ func TestHandler_userIdentity(t *testing.T) {
 		})
 	}
 }
+
+func TestHandler_adminIdentity(t *testing.T) {
+	type mockBehavior func(s *mock_service.MockAuthorization)
+
+	testTable := []struct {
+		name                 string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name: "OK",
+			mockBehavior: func(s *mock_service.MockAuthorization) {
+				s.EXPECT().IsAdmin(1).Return(true, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: "ok",
+		},
+		{
+			name: "Not admin",
+			mockBehavior: func(s *mock_service.MockAuthorization) {
+				s.EXPECT().IsAdmin(1).Return(false, nil)
+			},
+			expectedStatusCode:   403,
+			expectedResponseBody: `{"message":"admin rights are required"}`,
+		},
+		{
+			name: "Service error",
+			mockBehavior: func(s *mock_service.MockAuthorization) {
+				s.EXPECT().IsAdmin(1).Return(false, errors.New("service error"))
+			},
+			expectedStatusCode:   500,
+			expectedResponseBody: `{"message":"service error"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			auth := mock_service.NewMockAuthorization(c)
+			testCase.mockBehavior(auth)
+
+			handler := NewHandler(&service.Service{Authorization: auth})
+
+			r := gin.New()
+			r.GET("/admin", func(c *gin.Context) {
+				c.Set(userCtx, 1)
+			}, handler.adminIdentity, func(c *gin.Context) {
+				c.String(200, "ok")
+			})
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/admin", nil)
+
+			r.ServeHTTP(w, req)
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedResponseBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_apiKeyIdentity(t *testing.T) {
+	owner := storage.APIKeyOwner{UserId: 1, Scopes: []string{storage.ScopeAudioRead}}
+
+	testTable := []struct {
+		name                 string
+		method               string
+		path                 string
+		mockBehavior         func(s *mock_service.MockAPIKey)
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:   "OK",
+			method: "GET",
+			path:   "/audio",
+			mockBehavior: func(s *mock_service.MockAPIKey) {
+				s.EXPECT().ParseAPIKey("aspat_key").Return(owner, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: "1",
+		},
+		{
+			name:   "Invalid key",
+			method: "GET",
+			path:   "/audio",
+			mockBehavior: func(s *mock_service.MockAPIKey) {
+				s.EXPECT().ParseAPIKey("aspat_key").Return(storage.APIKeyOwner{}, storage.InvalidAPIKey)
+			},
+			expectedStatusCode:   401,
+			expectedResponseBody: `{"message":"api key is invalid or expired"}`,
+		},
+		{
+			name:   "Insufficient scope",
+			method: "POST",
+			path:   "/audio",
+			mockBehavior: func(s *mock_service.MockAPIKey) {
+				s.EXPECT().ParseAPIKey("aspat_key").Return(owner, nil)
+			},
+			expectedStatusCode:   403,
+			expectedResponseBody: `{"message":"api key doesn't have required scope: audio:write"}`,
+		},
+		{
+			name:   "Share scope",
+			method: "POST",
+			path:   "/share",
+			mockBehavior: func(s *mock_service.MockAPIKey) {
+				s.EXPECT().ParseAPIKey("aspat_key").Return(owner, nil)
+			},
+			expectedStatusCode:   403,
+			expectedResponseBody: `{"message":"api key doesn't have required scope: share:write"}`,
+		},
+		{
+			name:   "Account route",
+			method: "GET",
+			path:   "/me",
+			mockBehavior: func(s *mock_service.MockAPIKey) {
+				s.EXPECT().ParseAPIKey("aspat_key").Return(owner, nil)
+			},
+			expectedStatusCode:   403,
+			expectedResponseBody: `{"message":"api keys can't be used here"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			apiKey := mock_service.NewMockAPIKey(c)
+			testCase.mockBehavior(apiKey)
+
+			handler := NewHandler(&service.Service{APIKey: apiKey})
+
+			writeUserId := func(c *gin.Context) {
+				id, _ := c.Get(userCtx)
+				c.String(200, "%d", id)
+			}
+
+			r := gin.New()
+			audio := r.Group("/audio", handler.userIdentity, handler.methodScope(storage.ScopeAudioRead, storage.ScopeAudioWrite))
+			audio.GET("", writeUserId)
+			audio.POST("", writeUserId)
+			r.POST("/share", handler.userIdentity, handler.scope(storage.ScopeShareWrite), writeUserId)
+			r.GET("/me", handler.userIdentity, handler.noAPIKey, writeUserId)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(testCase.method, testCase.path, nil)
+			req.Header.Set("Authorization", "Bearer aspat_key")
+
+			r.ServeHTTP(w, req)
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedResponseBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_scopeAccessToken(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	auth := mock_service.NewMockAuthorization(c)
+	auth.EXPECT().ParseToken("token_string").Return(1, nil)
+
+	handler := NewHandler(&service.Service{Authorization: auth})
+
+	r := gin.New()
+	r.POST("/share", handler.userIdentity, handler.scope(storage.ScopeShareWrite), handler.noAPIKey, func(c *gin.Context) {
+		c.String(200, "ok")
+	})
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("POST", "/share", nil)
+	req.Header.Set("Authorization", "Bearer token_string")
+
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, "ok", w.Body.String())
+}
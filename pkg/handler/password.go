@@ -0,0 +1,141 @@
+package handler
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	storage "github.com/mahadeva604/audio-storage"
+	"net/http"
+)
+
+// @Summary Change password
+// @Security ApiKeyAuth
+// @Tags password
+// @Description change password, other sessions are closed and access tokens are revoked. Session of refresh_token is kept if it is given, API keys are deleted if revoke_api_keys is set
+// @ID change-password
+// @Accept  json
+// @Produce  json
+// @Param input body storage.ChangePasswordInput true "old and new password"
+// @Success 200 {object} statusResponse
+// @Failure 400 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /api/me/password [put]
+func (h *Handler) changePassword(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	var input storage.ChangePasswordInput
+	if err := c.BindJSON(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid input body")
+		return
+	}
+
+	if err := h.services.ChangePassword(userId, input); err != nil {
+		newPasswordErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, statusResponse{"ok"})
+}
+
+// @Summary Set email
+// @Security ApiKeyAuth
+// @Tags password
+// @Description set email password reset tokens are sent to, empty email removes it. Unused reset tokens are dropped
+// @ID set-email
+// @Accept  json
+// @Produce  json
+// @Param input body storage.EmailInput true "password and new email"
+// @Success 200 {object} statusResponse
+// @Failure 400 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /api/me/email [put]
+func (h *Handler) setEmail(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	var input storage.EmailInput
+	if err := c.BindJSON(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid input body")
+		return
+	}
+
+	if err := h.services.SetEmail(userId, input); err != nil {
+		newPasswordErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, statusResponse{"ok"})
+}
+
+// @Summary Request password reset
+// @Tags password
+// @Description send password reset token to email of user in background. The response is the same whether user exists or not, 503 is returned when too many resets are queued
+// @ID request-password-reset
+// @Accept  json
+// @Produce  json
+// @Param input body storage.PasswordResetRequest true "username"
+// @Success 200 {object} statusResponse
+// @Failure 400 {object} errorResponse
+// @Failure 500,503 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /auth/password-reset [post]
+func (h *Handler) requestPasswordReset(c *gin.Context) {
+	var input storage.PasswordResetRequest
+	if err := c.BindJSON(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid input body")
+		return
+	}
+
+	if err := h.services.RequestPasswordReset(input); err != nil {
+		newPasswordErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, statusResponse{"ok"})
+}
+
+// @Summary Reset password
+// @Tags password
+// @Description set new password with reset token, the token can be used once. Every session is closed and API keys are deleted
+// @ID reset-password
+// @Accept  json
+// @Produce  json
+// @Param input body storage.ResetPasswordInput true "reset token and new password"
+// @Success 200 {object} statusResponse
+// @Failure 400 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /auth/password-reset/confirm [post]
+func (h *Handler) resetPassword(c *gin.Context) {
+	var input storage.ResetPasswordInput
+	if err := c.BindJSON(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid input body")
+		return
+	}
+
+	if err := h.services.ResetPassword(input); err != nil {
+		newPasswordErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, statusResponse{"ok"})
+}
+
+func newPasswordErrorResponse(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, storage.WrongPassword), errors.Is(err, storage.InvalidResetToken):
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+	case errors.Is(err, storage.ResetQueueFull):
+		newErrorResponse(c, http.StatusServiceUnavailable, err.Error())
+	default:
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+	}
+}
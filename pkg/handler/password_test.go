@@ -0,0 +1,284 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	storage "github.com/mahadeva604/audio-storage"
+	"github.com/mahadeva604/audio-storage/pkg/service"
+	mock_service "github.com/mahadeva604/audio-storage/pkg/service/mocks"
+	"github.com/stretchr/testify/assert"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHandler_changePassword(t *testing.T) {
+	testTable := []struct {
+		name                 string
+		inputBody            string
+		mockBehavior         func(s *mock_service.MockPassword)
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:      "OK",
+			inputBody: `{"old_password":"qwerty_1","new_password":"qwerty_12","refresh_token":"token"}`,
+			mockBehavior: func(s *mock_service.MockPassword) {
+				s.EXPECT().ChangePassword(1, storage.ChangePasswordInput{
+					OldPassword: "qwerty_1", NewPassword: "qwerty_12", RefreshToken: "token",
+				}).Return(nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"status":"ok"}`,
+		},
+		{
+			name:                 "Short password",
+			inputBody:            `{"old_password":"qwerty_1","new_password":"qwerty"}`,
+			mockBehavior:         func(s *mock_service.MockPassword) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"invalid input body"}`,
+		},
+		{
+			name:      "Wrong password",
+			inputBody: `{"old_password":"qwerty_2","new_password":"qwerty_12"}`,
+			mockBehavior: func(s *mock_service.MockPassword) {
+				s.EXPECT().ChangePassword(1, storage.ChangePasswordInput{OldPassword: "qwerty_2", NewPassword: "qwerty_12"}).
+					Return(storage.WrongPassword)
+			},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"password is wrong"}`,
+		},
+		{
+			name:      "Service error",
+			inputBody: `{"old_password":"qwerty_1","new_password":"qwerty_12"}`,
+			mockBehavior: func(s *mock_service.MockPassword) {
+				s.EXPECT().ChangePassword(1, storage.ChangePasswordInput{OldPassword: "qwerty_1", NewPassword: "qwerty_12"}).
+					Return(errors.New("service error"))
+			},
+			expectedStatusCode:   500,
+			expectedResponseBody: `{"message":"service error"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			password := mock_service.NewMockPassword(c)
+			testCase.mockBehavior(password)
+
+			handler := NewHandler(&service.Service{Password: password})
+
+			r := gin.New()
+			r.PUT("/me/password", func(c *gin.Context) {
+				c.Set(userCtx, 1)
+			}, handler.changePassword)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("PUT", "/me/password", bytes.NewBufferString(testCase.inputBody))
+
+			r.ServeHTTP(w, req)
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedResponseBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_setEmail(t *testing.T) {
+	testTable := []struct {
+		name                 string
+		inputBody            string
+		mockBehavior         func(s *mock_service.MockPassword)
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:      "OK",
+			inputBody: `{"password":"qwerty_1","email":"user_1@example.com"}`,
+			mockBehavior: func(s *mock_service.MockPassword) {
+				s.EXPECT().SetEmail(1, storage.EmailInput{Password: "qwerty_1", Email: "user_1@example.com"}).Return(nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"status":"ok"}`,
+		},
+		{
+			name:      "OK remove",
+			inputBody: `{"password":"qwerty_1"}`,
+			mockBehavior: func(s *mock_service.MockPassword) {
+				s.EXPECT().SetEmail(1, storage.EmailInput{Password: "qwerty_1"}).Return(nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"status":"ok"}`,
+		},
+		{
+			name:                 "Invalid email",
+			inputBody:            `{"password":"qwerty_1","email":"user_1"}`,
+			mockBehavior:         func(s *mock_service.MockPassword) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"invalid input body"}`,
+		},
+		{
+			name:      "Wrong password",
+			inputBody: `{"password":"qwerty_2","email":"user_1@example.com"}`,
+			mockBehavior: func(s *mock_service.MockPassword) {
+				s.EXPECT().SetEmail(1, storage.EmailInput{Password: "qwerty_2", Email: "user_1@example.com"}).
+					Return(storage.WrongPassword)
+			},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"password is wrong"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			password := mock_service.NewMockPassword(c)
+			testCase.mockBehavior(password)
+
+			handler := NewHandler(&service.Service{Password: password})
+
+			r := gin.New()
+			r.PUT("/me/email", func(c *gin.Context) {
+				c.Set(userCtx, 1)
+			}, handler.setEmail)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("PUT", "/me/email", bytes.NewBufferString(testCase.inputBody))
+
+			r.ServeHTTP(w, req)
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedResponseBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_requestPasswordReset(t *testing.T) {
+	testTable := []struct {
+		name                 string
+		inputBody            string
+		mockBehavior         func(s *mock_service.MockPassword)
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:      "OK",
+			inputBody: `{"username":"user_1"}`,
+			mockBehavior: func(s *mock_service.MockPassword) {
+				s.EXPECT().RequestPasswordReset(storage.PasswordResetRequest{Username: "user_1"}).Return(nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"status":"ok"}`,
+		},
+		{
+			name:                 "Empty username",
+			inputBody:            `{}`,
+			mockBehavior:         func(s *mock_service.MockPassword) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"invalid input body"}`,
+		},
+		{
+			name:      "Queue full",
+			inputBody: `{"username":"user_1"}`,
+			mockBehavior: func(s *mock_service.MockPassword) {
+				s.EXPECT().RequestPasswordReset(storage.PasswordResetRequest{Username: "user_1"}).Return(storage.ResetQueueFull)
+			},
+			expectedStatusCode:   503,
+			expectedResponseBody: `{"message":"too many password reset requests, try again later"}`,
+		},
+		{
+			name:      "Service error",
+			inputBody: `{"username":"user_1"}`,
+			mockBehavior: func(s *mock_service.MockPassword) {
+				s.EXPECT().RequestPasswordReset(storage.PasswordResetRequest{Username: "user_1"}).Return(errors.New("service error"))
+			},
+			expectedStatusCode:   500,
+			expectedResponseBody: `{"message":"service error"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			password := mock_service.NewMockPassword(c)
+			testCase.mockBehavior(password)
+
+			handler := NewHandler(&service.Service{Password: password})
+
+			r := gin.New()
+			r.POST("/password-reset", handler.requestPasswordReset)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/password-reset", bytes.NewBufferString(testCase.inputBody))
+
+			r.ServeHTTP(w, req)
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedResponseBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_resetPassword(t *testing.T) {
+	input := storage.ResetPasswordInput{Token: "token", NewPassword: "qwerty_12"}
+
+	testTable := []struct {
+		name                 string
+		inputBody            string
+		mockBehavior         func(s *mock_service.MockPassword)
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:      "OK",
+			inputBody: `{"token":"token","new_password":"qwerty_12"}`,
+			mockBehavior: func(s *mock_service.MockPassword) {
+				s.EXPECT().ResetPassword(input).Return(nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"status":"ok"}`,
+		},
+		{
+			name:                 "No token",
+			inputBody:            `{"new_password":"qwerty_12"}`,
+			mockBehavior:         func(s *mock_service.MockPassword) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"invalid input body"}`,
+		},
+		{
+			name:      "Invalid token",
+			inputBody: `{"token":"token","new_password":"qwerty_12"}`,
+			mockBehavior: func(s *mock_service.MockPassword) {
+				s.EXPECT().ResetPassword(input).Return(storage.InvalidResetToken)
+			},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"password reset token is invalid, used or expired"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			password := mock_service.NewMockPassword(c)
+			testCase.mockBehavior(password)
+
+			handler := NewHandler(&service.Service{Password: password})
+
+			r := gin.New()
+			r.POST("/password-reset/confirm", handler.resetPassword)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/password-reset/confirm", bytes.NewBufferString(testCase.inputBody))
+
+			r.ServeHTTP(w, req)
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedResponseBody, w.Body.String())
+		})
+	}
+}
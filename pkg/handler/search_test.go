@@ -0,0 +1,103 @@
+package handler
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	storage "github.com/mahadeva604/audio-storage"
+	"github.com/mahadeva604/audio-storage/pkg/service"
+	mock_service "github.com/mahadeva604/audio-storage/pkg/service/mocks"
+	"github.com/stretchr/testify/assert"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHandler_searchAudio(t *testing.T) {
+	type mockBehavior func(s *mock_service.MockSearch, userId int, input storage.SearchParam)
+
+	offset, limit := 0, 10
+
+	testTable := []struct {
+		name                 string
+		target               string
+		input                storage.SearchParam
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:   "OK",
+			target: "/audio/search?q=jazz&offset=0&limit=10",
+			input:  storage.SearchParam{Query: "jazz", Offset: &offset, Limit: &limit},
+			mockBehavior: func(s *mock_service.MockSearch, userId int, input storage.SearchParam) {
+				s.EXPECT().SearchAudio(userId, input).Return(storage.SearchResultJson{
+					TotalCount: 1,
+					Records: []storage.SearchResult{
+						{Id: 3, Title: "jazz live", Owner: 2, Name: "user 2", Rank: 0.5, Snippet: "<mark>jazz</mark> live"},
+					},
+				}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"total_count":1,"records":[{"id":3,"name":"jazz live","is_owner":false,"owner_id":2,"owner_name":"user 2","rank":0.5,"snippet":"\u003cmark\u003ejazz\u003c/mark\u003e live"}]}`,
+		},
+		{
+			name:   "OK transcript cue",
+			target: "/audio/search?q=jazz&offset=0&limit=10",
+			input:  storage.SearchParam{Query: "jazz", Offset: &offset, Limit: &limit},
+			mockBehavior: func(s *mock_service.MockSearch, userId int, input storage.SearchParam) {
+				s.EXPECT().SearchAudio(userId, input).Return(storage.SearchResultJson{
+					TotalCount: 1,
+					Records: []storage.SearchResult{
+						{Id: 3, Title: "meeting", Owner: 2, Name: "user 2", Rank: 0.1, Snippet: "meeting",
+							Cue: &storage.SearchCue{StartMs: 61000, EndMs: 64500, Snippet: "<mark>jazz</mark>"}},
+					},
+				}, nil)
+			},
+			expectedStatusCode: 200,
+			expectedResponseBody: `{"total_count":1,"records":[{"id":3,"name":"meeting","is_owner":false,"owner_id":2,"owner_name":"user 2","rank":0.1,"snippet":"meeting",` +
+				`"cue":{"start_ms":61000,"end_ms":64500,"snippet":"\u003cmark\u003ejazz\u003c/mark\u003e"}}]}`,
+		},
+		{
+			name:                 "Empty query",
+			target:               "/audio/search?q=&offset=0&limit=10",
+			mockBehavior:         func(s *mock_service.MockSearch, userId int, input storage.SearchParam) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"invalid query"}`,
+		},
+		{
+			name:   "Service error",
+			target: "/audio/search?q=jazz&offset=0&limit=10",
+			input:  storage.SearchParam{Query: "jazz", Offset: &offset, Limit: &limit},
+			mockBehavior: func(s *mock_service.MockSearch, userId int, input storage.SearchParam) {
+				s.EXPECT().SearchAudio(userId, input).Return(storage.SearchResultJson{}, errors.New("service error"))
+			},
+			expectedStatusCode:   500,
+			expectedResponseBody: `{"message":"service error"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			search := mock_service.NewMockSearch(c)
+			testCase.mockBehavior(search, 1, testCase.input)
+
+			services := &service.Service{Search: search}
+			handler := NewHandler(services)
+
+			r := gin.New()
+			r.GET("/audio/search", func(c *gin.Context) {
+				c.Set(userCtx, 1)
+			}, handler.searchAudio)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", testCase.target, nil)
+
+			r.ServeHTTP(w, req)
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedResponseBody, w.Body.String())
+		})
+	}
+}
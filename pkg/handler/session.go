@@ -0,0 +1,124 @@
+package handler
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	storage "github.com/mahadeva604/audio-storage"
+	"net/http"
+	"strconv"
+)
+
+const maxUserAgentLength = 512
+
+// @Summary Get sessions
+// @Security ApiKeyAuth
+// @Tags session
+// @Description get devices you are signed in on, recently used first
+// @ID get-sessions
+// @Produce  json
+// @Success 200 {array} storage.Session
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /api/me/sessions [get]
+func (h *Handler) getSessions(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	sessions, err := h.services.GetSessions(userId)
+	if err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, sessions)
+}
+
+// @Summary Delete session
+// @Security ApiKeyAuth
+// @Tags session
+// @Description sign out device, its refresh token and access tokens of user are revoked. Other devices get new access tokens by refresh
+// @ID delete-session
+// @Produce  json
+// @Param id path int true "session id"
+// @Success 200 {object} statusResponse
+// @Failure 400,404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /api/me/sessions/{id} [delete]
+func (h *Handler) deleteSession(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	sessionId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid session id param")
+		return
+	}
+
+	if err := h.services.DeleteSession(userId, sessionId); err != nil {
+		newSessionErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, statusResponse{"ok"})
+}
+
+// @Summary Get security events
+// @Security ApiKeyAuth
+// @Tags session
+// @Description get suspicious activity on your account, newest first
+// @ID get-security-events
+// @Produce  json
+// @Param offset query integer true "offset" minimum(0)
+// @Param limit query integer true "limit"  minimum(1)
+// @Success 200 {object} storage.SecurityEventListJson
+// @Failure 400 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /api/me/security-events [get]
+func (h *Handler) getSecurityEvents(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	var input storage.SecurityEventListParam
+	if err := c.BindQuery(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid query")
+		return
+	}
+
+	result, err := h.services.GetSecurityEvents(userId, input)
+	if err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// sessionClient describes client of request, device name is given on sign-in only
+func sessionClient(c *gin.Context, deviceName string) storage.SessionClient {
+	userAgent := c.Request.UserAgent()
+	if len(userAgent) > maxUserAgentLength {
+		userAgent = userAgent[:maxUserAgentLength]
+	}
+
+	return storage.SessionClient{DeviceName: deviceName, UserAgent: userAgent, IP: c.ClientIP()}
+}
+
+func newSessionErrorResponse(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, storage.SessionNotFound):
+		newErrorResponse(c, http.StatusNotFound, err.Error())
+	default:
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+	}
+}
@@ -0,0 +1,190 @@
+package handler
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	storage "github.com/mahadeva604/audio-storage"
+	"github.com/mahadeva604/audio-storage/pkg/service"
+	mock_service "github.com/mahadeva604/audio-storage/pkg/service/mocks"
+	"github.com/stretchr/testify/assert"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHandler_getSessions(t *testing.T) {
+	at := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	testTable := []struct {
+		name                 string
+		mockBehavior         func(s *mock_service.MockAuthorization)
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name: "OK",
+			mockBehavior: func(s *mock_service.MockAuthorization) {
+				s.EXPECT().GetSessions(1).Return([]storage.Session{
+					{Id: 2, SessionClient: storage.SessionClient{DeviceName: "phone", UserAgent: "okhttp/4.9.0", IP: "192.0.2.2"},
+						CreatedAt: at, LastUsedAt: at, ExpiresAt: at.Add(time.Hour)},
+				}, nil)
+			},
+			expectedStatusCode: 200,
+			expectedResponseBody: `[{"id":2,"device_name":"phone","user_agent":"okhttp/4.9.0","ip":"192.0.2.2",` +
+				`"created_at":"2021-06-01T12:00:00Z","last_used_at":"2021-06-01T12:00:00Z","expires_at":"2021-06-01T13:00:00Z"}]`,
+		},
+		{
+			name: "Service error",
+			mockBehavior: func(s *mock_service.MockAuthorization) {
+				s.EXPECT().GetSessions(1).Return(nil, errors.New("service error"))
+			},
+			expectedStatusCode:   500,
+			expectedResponseBody: `{"message":"service error"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			auth := mock_service.NewMockAuthorization(c)
+			testCase.mockBehavior(auth)
+
+			handler := NewHandler(&service.Service{Authorization: auth})
+
+			r := gin.New()
+			r.GET("/me/sessions", func(c *gin.Context) {
+				c.Set(userCtx, 1)
+			}, handler.getSessions)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/me/sessions", nil)
+
+			r.ServeHTTP(w, req)
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedResponseBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_deleteSession(t *testing.T) {
+	testTable := []struct {
+		name                 string
+		sessionId            string
+		mockBehavior         func(s *mock_service.MockAuthorization)
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:      "OK",
+			sessionId: "2",
+			mockBehavior: func(s *mock_service.MockAuthorization) {
+				s.EXPECT().DeleteSession(1, 2).Return(nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"status":"ok"}`,
+		},
+		{
+			name:      "Not found",
+			sessionId: "3",
+			mockBehavior: func(s *mock_service.MockAuthorization) {
+				s.EXPECT().DeleteSession(1, 3).Return(storage.SessionNotFound)
+			},
+			expectedStatusCode:   404,
+			expectedResponseBody: `{"message":"session not found"}`,
+		},
+		{
+			name:                 "Invalid id",
+			sessionId:            "x",
+			mockBehavior:         func(s *mock_service.MockAuthorization) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"invalid session id param"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			auth := mock_service.NewMockAuthorization(c)
+			testCase.mockBehavior(auth)
+
+			handler := NewHandler(&service.Service{Authorization: auth})
+
+			r := gin.New()
+			r.DELETE("/me/sessions/:id", func(c *gin.Context) {
+				c.Set(userCtx, 1)
+			}, handler.deleteSession)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("DELETE", "/me/sessions/"+testCase.sessionId, nil)
+
+			r.ServeHTTP(w, req)
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedResponseBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_getSecurityEvents(t *testing.T) {
+	at := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	limit, offset := 10, 0
+	sessionId := 5
+
+	testTable := []struct {
+		name                 string
+		query                string
+		mockBehavior         func(s *mock_service.MockAuthorization)
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:  "OK",
+			query: "?limit=10&offset=0",
+			mockBehavior: func(s *mock_service.MockAuthorization) {
+				s.EXPECT().GetSecurityEvents(1, storage.SecurityEventListParam{Limit: &limit, Offset: &offset}).
+					Return(storage.SecurityEventListJson{TotalCount: 1, Records: []storage.SecurityEvent{
+						{Id: 7, Type: storage.SecurityRefreshTokenReused, SessionId: &sessionId,
+							SessionClient: storage.SessionClient{DeviceName: "laptop", IP: "192.0.2.1"}, CreatedAt: at},
+					}}, nil)
+			},
+			expectedStatusCode: 200,
+			expectedResponseBody: `{"total_count":1,"records":[{"id":7,"type":"refresh_token_reused","session_id":5,` +
+				`"device_name":"laptop","user_agent":"","ip":"192.0.2.1","created_at":"2021-06-01T12:00:00Z"}]}`,
+		},
+		{
+			name:                 "Invalid query",
+			query:                "?limit=0&offset=0",
+			mockBehavior:         func(s *mock_service.MockAuthorization) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"invalid query"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			auth := mock_service.NewMockAuthorization(c)
+			testCase.mockBehavior(auth)
+
+			handler := NewHandler(&service.Service{Authorization: auth})
+
+			r := gin.New()
+			r.GET("/me/security-events", func(c *gin.Context) {
+				c.Set(userCtx, 1)
+			}, handler.getSecurityEvents)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/me/security-events"+testCase.query, nil)
+
+			r.ServeHTTP(w, req)
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedResponseBody, w.Body.String())
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"errors"
 	"github.com/gin-gonic/gin"
 	storage "github.com/mahadeva604/audio-storage"
 	"net/http"
@@ -96,13 +97,15 @@ func (h *Handler) unshareAudio(c *gin.Context) {
 // @Summary Get share list
 // @Security ApiKeyAuth
 // @Tags share
-// @Description get share list
+// @Description get share list. Without offset pages are navigated with next_cursor and prev_cursor and total count is returned only with with_count. Offset mode returns storage.ShareListJson
 // @ID get-share-list
 // @Accept  json
 // @Produce  json
-// @Param offset query integer true "offset" minimum(0)
-// @Param limit query integer true "limit"  minimum(1)
-// @Success 200 {object} storage.ShareListJson
+// @Param offset query integer false "offset, switches to offset mode" minimum(0)
+// @Param limit query integer true "limit"  minimum(1) maximum(1000)
+// @Param cursor query string false "next_cursor or prev_cursor of previous page"
+// @Param with_count query boolean false "return total count in cursor mode"
+// @Success 200 {object} storage.SharePageJson
 // @Failure 400 {object} errorResponse
 // @Failure 500 {object} errorResponse
 // @Failure default {object} errorResponse
@@ -115,7 +118,24 @@ func (h *Handler) getSharedAudio(c *gin.Context) {
 		return
 	}
 
-	result, err := h.services.GetSharedList(input)
+	if err := input.Validate(); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var result interface{}
+	var err error
+	if input.Offset != nil {
+		result, err = h.services.GetSharedList(input)
+	} else {
+		result, err = h.services.GetSharedPage(input)
+	}
+
+	if errors.Is(err, storage.InvalidCursor) {
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
 	if err != nil {
 		newErrorResponse(c, http.StatusInternalServerError, err.Error())
 		return
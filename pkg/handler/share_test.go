@@ -225,6 +225,7 @@ func TestHandler_getSharedAudio(t *testing.T) {
 		name                 string
 		offset               string
 		limit                string
+		cursor               string
 		inputShare           storage.ShareListParam
 		mockBehavior         mockBehavior
 		expectedStatusCode   int
@@ -266,6 +267,66 @@ func TestHandler_getSharedAudio(t *testing.T) {
 			expectedStatusCode:   400,
 			expectedResponseBody: `{"message":"invalid input body"}`,
 		},
+		{
+			name:   "OK cursor mode",
+			limit:  strconv.Itoa(limit),
+			cursor: "cursor",
+			inputShare: storage.ShareListParam{
+				Limit:  &limit,
+				Cursor: "cursor",
+			},
+			mockBehavior: func(s *mock_service.MockShare, input storage.ShareListParam) {
+				s.EXPECT().GetSharedPage(input).Return(storage.SharePageJson{
+					Users: []storage.ShareListCount{
+						{
+							UserId:     3,
+							Name:       "user 3",
+							ShareCount: 1,
+						},
+					},
+					PrevCursor: "prev",
+				}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"users":[{"id":3,"name":"user 3","shared_records":1}],"prev_cursor":"prev"}`,
+		},
+		{
+			name:   "Invalid cursor",
+			limit:  strconv.Itoa(limit),
+			cursor: "wrong",
+			inputShare: storage.ShareListParam{
+				Limit:  &limit,
+				Cursor: "wrong",
+			},
+			mockBehavior: func(s *mock_service.MockShare, input storage.ShareListParam) {
+				s.EXPECT().GetSharedPage(input).Return(storage.SharePageJson{}, storage.InvalidCursor)
+			},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"cursor is invalid or made for another sort order"}`,
+		},
+		{
+			name:                 "Negative limit",
+			limit:                "-1",
+			mockBehavior:         func(s *mock_service.MockShare, input storage.ShareListParam) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"invalid input body"}`,
+		},
+		{
+			name:                 "Zero limit",
+			limit:                "0",
+			mockBehavior:         func(s *mock_service.MockShare, input storage.ShareListParam) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"invalid input body"}`,
+		},
+		{
+			name:                 "Offset with cursor",
+			offset:               strconv.Itoa(offset),
+			limit:                strconv.Itoa(limit),
+			cursor:               "cursor",
+			mockBehavior:         func(s *mock_service.MockShare, input storage.ShareListParam) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"offset can't be used with cursor or with_count"}`,
+		},
 		{
 			name:   "Service fail",
 			offset: strconv.Itoa(offset),
@@ -297,8 +358,14 @@ func TestHandler_getSharedAudio(t *testing.T) {
 			r.GET("/shares", handler.getSharedAudio)
 
 			w := httptest.NewRecorder()
-			params := url.Values{"offset": {testCase.offset}, "limit": {testCase.limit}}.Encode()
-			req := httptest.NewRequest("GET", "/shares?"+params, nil)
+			params := url.Values{"limit": {testCase.limit}}
+			if testCase.offset != "" {
+				params.Set("offset", testCase.offset)
+			}
+			if testCase.cursor != "" {
+				params.Set("cursor", testCase.cursor)
+			}
+			req := httptest.NewRequest("GET", "/shares?"+params.Encode(), nil)
 
 			r.ServeHTTP(w, req)
 			assert.Equal(t, testCase.expectedStatusCode, w.Code)
@@ -0,0 +1,162 @@
+package handler
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	storage "github.com/mahadeva604/audio-storage"
+	"net/http"
+	"strconv"
+)
+
+// @Summary Get audio tags
+// @Security ApiKeyAuth
+// @Tags tag
+// @Description get owner tags and your personal tags of audio
+// @ID get-audio-tags
+// @Accept  json
+// @Produce  json
+// @Param id path int true "audio id"
+// @Success 200 {array} storage.AudioTag
+// @Failure 400,404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /api/audio/{id}/tags [get]
+func (h *Handler) getAudioTags(c *gin.Context) {
+	userId, audioId, ok := getAudioParams(c)
+	if !ok {
+		return
+	}
+
+	tags, err := h.services.GetAudioTags(userId, audioId)
+	if err != nil {
+		newTagErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, tags)
+}
+
+// @Summary Add tags to audio
+// @Security ApiKeyAuth
+// @Tags tag
+// @Description add tags to audio, tags of audio owner are visible to everyone who has access to audio, other tags are personal
+// @ID add-audio-tags
+// @Accept  json
+// @Produce  json
+// @Param id path int true "audio id"
+// @Param input body storage.TagsInput true "tags"
+// @Success 200 {object} statusResponse
+// @Failure 400,404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /api/audio/{id}/tags [post]
+func (h *Handler) addAudioTags(c *gin.Context) {
+	userId, audioId, ok := getAudioParams(c)
+	if !ok {
+		return
+	}
+
+	var input storage.TagsInput
+	if err := c.BindJSON(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid input body")
+		return
+	}
+
+	if err := h.services.AddTags(userId, audioId, input); err != nil {
+		newTagErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, statusResponse{"ok"})
+}
+
+// @Summary Remove tag from audio
+// @Security ApiKeyAuth
+// @Tags tag
+// @Description remove tag you added to audio
+// @ID remove-audio-tag
+// @Accept  json
+// @Produce  json
+// @Param id path int true "audio id"
+// @Param tag path string true "tag"
+// @Success 200 {object} statusResponse
+// @Failure 400,404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /api/audio/{id}/tags/{tag} [delete]
+func (h *Handler) removeAudioTag(c *gin.Context) {
+	userId, audioId, ok := getAudioParams(c)
+	if !ok {
+		return
+	}
+
+	if err := h.services.RemoveTag(userId, audioId, c.Param("tag")); err != nil {
+		newTagErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, statusResponse{"ok"})
+}
+
+// @Summary Get tags
+// @Security ApiKeyAuth
+// @Tags tag
+// @Description get tags visible to you with number of audio, most used first. Use prefix for autocomplete
+// @ID get-tags
+// @Accept  json
+// @Produce  json
+// @Param prefix query string false "tag prefix"
+// @Param limit query integer true "limit" minimum(1)
+// @Success 200 {array} storage.TagCount
+// @Failure 400 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /api/tags/ [get]
+func (h *Handler) getTags(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	var input storage.TagListParam
+	if err := c.BindQuery(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid query")
+		return
+	}
+
+	tags, err := h.services.GetTags(userId, input)
+	if err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, tags)
+}
+
+func getAudioParams(c *gin.Context) (int, int, bool) {
+	userId, err := getUserId(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return 0, 0, false
+	}
+
+	audioId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid audio id param")
+		return 0, 0, false
+	}
+
+	return userId, audioId, true
+}
+
+func newTagErrorResponse(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, storage.FileNotFound), errors.Is(err, storage.TagNotFound):
+		newErrorResponse(c, http.StatusNotFound, err.Error())
+	case errors.Is(err, storage.InvalidTag):
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+	default:
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+	}
+}
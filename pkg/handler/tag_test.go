@@ -0,0 +1,200 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	storage "github.com/mahadeva604/audio-storage"
+	"github.com/mahadeva604/audio-storage/pkg/service"
+	mock_service "github.com/mahadeva604/audio-storage/pkg/service/mocks"
+	"github.com/stretchr/testify/assert"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHandler_addAudioTags(t *testing.T) {
+	type mockBehavior func(s *mock_service.MockTag, userId, audioId int, input storage.TagsInput)
+
+	testTable := []struct {
+		name                 string
+		inputBody            string
+		input                storage.TagsInput
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:      "OK",
+			inputBody: `{"tags":["Jazz","live"]}`,
+			input:     storage.TagsInput{Tags: []string{"Jazz", "live"}},
+			mockBehavior: func(s *mock_service.MockTag, userId, audioId int, input storage.TagsInput) {
+				s.EXPECT().AddTags(userId, audioId, input).Return(nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"status":"ok"}`,
+		},
+		{
+			name:                 "Empty tags",
+			inputBody:            `{"tags":[]}`,
+			mockBehavior:         func(s *mock_service.MockTag, userId, audioId int, input storage.TagsInput) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"invalid input body"}`,
+		},
+		{
+			name:      "Invalid tag",
+			inputBody: `{"tags":[" "]}`,
+			input:     storage.TagsInput{Tags: []string{" "}},
+			mockBehavior: func(s *mock_service.MockTag, userId, audioId int, input storage.TagsInput) {
+				s.EXPECT().AddTags(userId, audioId, input).Return(storage.InvalidTag)
+			},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"tag must be from 1 to 64 characters"}`,
+		},
+		{
+			name:      "No access",
+			inputBody: `{"tags":["jazz"]}`,
+			input:     storage.TagsInput{Tags: []string{"jazz"}},
+			mockBehavior: func(s *mock_service.MockTag, userId, audioId int, input storage.TagsInput) {
+				s.EXPECT().AddTags(userId, audioId, input).Return(storage.FileNotFound)
+			},
+			expectedStatusCode:   404,
+			expectedResponseBody: `{"message":"file not found or you haven't access"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			tag := mock_service.NewMockTag(c)
+			testCase.mockBehavior(tag, 1, 2, testCase.input)
+
+			services := &service.Service{Tag: tag}
+			handler := NewHandler(services)
+
+			r := gin.New()
+			r.POST("/audio/:id/tags", func(c *gin.Context) {
+				c.Set(userCtx, 1)
+			}, handler.addAudioTags)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/audio/2/tags", bytes.NewBufferString(testCase.inputBody))
+
+			r.ServeHTTP(w, req)
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedResponseBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_audioTags(t *testing.T) {
+	type mockBehavior func(s *mock_service.MockTag)
+
+	testTable := []struct {
+		name                 string
+		method               string
+		target               string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:   "OK get",
+			method: "GET",
+			target: "/audio/2/tags",
+			mockBehavior: func(s *mock_service.MockTag) {
+				s.EXPECT().GetAudioTags(1, 2).Return([]storage.AudioTag{{Name: "jazz"}, {Name: "to listen", Personal: true}}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `[{"name":"jazz","personal":false},{"name":"to listen","personal":true}]`,
+		},
+		{
+			name:                 "Invalid audio id",
+			method:               "GET",
+			target:               "/audio/wrong/tags",
+			mockBehavior:         func(s *mock_service.MockTag) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"invalid audio id param"}`,
+		},
+		{
+			name:   "OK remove",
+			method: "DELETE",
+			target: "/audio/2/tags/to%20listen",
+			mockBehavior: func(s *mock_service.MockTag) {
+				s.EXPECT().RemoveTag(1, 2, "to listen").Return(nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"status":"ok"}`,
+		},
+		{
+			name:   "Remove not found",
+			method: "DELETE",
+			target: "/audio/2/tags/jazz",
+			mockBehavior: func(s *mock_service.MockTag) {
+				s.EXPECT().RemoveTag(1, 2, "jazz").Return(storage.TagNotFound)
+			},
+			expectedStatusCode:   404,
+			expectedResponseBody: `{"message":"tag not found"}`,
+		},
+		{
+			name:   "OK tag cloud",
+			method: "GET",
+			target: "/tags/?prefix=ja&limit=10",
+			mockBehavior: func(s *mock_service.MockTag) {
+				limit := 10
+				s.EXPECT().GetTags(1, storage.TagListParam{Prefix: "ja", Limit: &limit}).Return([]storage.TagCount{{Name: "jazz", Count: 5}}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `[{"name":"jazz","count":5}]`,
+		},
+		{
+			name:                 "Tag cloud without limit",
+			method:               "GET",
+			target:               "/tags/",
+			mockBehavior:         func(s *mock_service.MockTag) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"invalid query"}`,
+		},
+		{
+			name:   "Tag cloud service error",
+			method: "GET",
+			target: "/tags/?limit=10",
+			mockBehavior: func(s *mock_service.MockTag) {
+				limit := 10
+				s.EXPECT().GetTags(1, storage.TagListParam{Limit: &limit}).Return(nil, errors.New("service error"))
+			},
+			expectedStatusCode:   500,
+			expectedResponseBody: `{"message":"service error"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			tag := mock_service.NewMockTag(c)
+			testCase.mockBehavior(tag)
+
+			services := &service.Service{Tag: tag}
+			handler := NewHandler(services)
+
+			r := gin.New()
+			r.Use(func(c *gin.Context) {
+				c.Set(userCtx, 1)
+			})
+			r.GET("/audio/:id/tags", handler.getAudioTags)
+			r.DELETE("/audio/:id/tags/:tag", handler.removeAudioTag)
+			r.GET("/tags/", handler.getTags)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(testCase.method, testCase.target, nil)
+
+			r.ServeHTTP(w, req)
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedResponseBody, w.Body.String())
+		})
+	}
+}
@@ -0,0 +1,192 @@
+package handler
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	storage "github.com/mahadeva604/audio-storage"
+	"io"
+	"net/http"
+)
+
+// @Summary Get audio transcript
+// @Security ApiKeyAuth
+// @Tags transcript
+// @Description get transcript of audio you have access to as json, WebVTT or SRT
+// @ID get-transcript
+// @Produce  json,text/vtt,application/x-subrip
+// @Param id path int true "audio id"
+// @Param format query string false "transcript format, json by default" Enums(json,webvtt,srt)
+// @Success 200 {object} storage.TranscriptJson
+// @Failure 400,404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /api/audio/{id}/transcript [get]
+func (h *Handler) getTranscript(c *gin.Context) {
+	userId, audioId, ok := getAudioParams(c)
+	if !ok {
+		return
+	}
+
+	var input storage.TranscriptParam
+	if err := c.BindQuery(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid query")
+		return
+	}
+
+	transcript, err := h.services.GetTranscript(userId, audioId)
+	if err != nil {
+		newTranscriptErrorResponse(c, err)
+		return
+	}
+
+	switch input.Format {
+	case storage.TranscriptFormatVTT:
+		c.Data(http.StatusOK, "text/vtt; charset=utf-8", []byte(transcript.WebVTT()))
+	case storage.TranscriptFormatSRT:
+		c.Data(http.StatusOK, "application/x-subrip; charset=utf-8", []byte(transcript.SRT()))
+	default:
+		c.JSON(http.StatusOK, storage.TranscriptJson{Cues: transcript})
+	}
+}
+
+// @Summary Set audio transcript
+// @Security ApiKeyAuth
+// @Tags transcript
+// @Description replace transcript of own audio with WebVTT or SRT file, markup is removed and cues are ordered by start
+// @ID set-transcript
+// @Accept multipart/form-data
+// @Produce  json
+// @Param id path int true "audio id"
+// @Param file formData file true "WebVTT or SRT file"
+// @Success 200 {object} statusResponse
+// @Failure 400,404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /api/audio/{id}/transcript [put]
+func (h *Handler) setTranscript(c *gin.Context) {
+	userId, audioId, ok := getAudioParams(c)
+	if !ok {
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, storage.MaxTranscriptUploadSize)
+
+	file, _, err := c.Request.FormFile("file")
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	transcript, err := storage.ParseTranscript(data)
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := h.services.SetTranscript(userId, audioId, transcript); err != nil {
+		newTranscriptErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, statusResponse{"ok"})
+}
+
+// @Summary Delete audio transcript
+// @Security ApiKeyAuth
+// @Tags transcript
+// @Description delete transcript of own audio
+// @ID delete-transcript
+// @Produce  json
+// @Param id path int true "audio id"
+// @Success 200 {object} statusResponse
+// @Failure 400,404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /api/audio/{id}/transcript [delete]
+func (h *Handler) deleteTranscript(c *gin.Context) {
+	userId, audioId, ok := getAudioParams(c)
+	if !ok {
+		return
+	}
+
+	if err := h.services.DeleteTranscript(userId, audioId); err != nil {
+		newTranscriptErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, statusResponse{"ok"})
+}
+
+// @Summary Get transcription job
+// @Security ApiKeyAuth
+// @Tags transcript
+// @Description get state of the last speech-to-text job of audio you have access to
+// @ID get-transcription
+// @Produce  json
+// @Param id path int true "audio id"
+// @Success 200 {object} storage.TranscriptionJob
+// @Failure 400,404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /api/audio/{id}/transcript/job [get]
+func (h *Handler) getTranscription(c *gin.Context) {
+	userId, audioId, ok := getAudioParams(c)
+	if !ok {
+		return
+	}
+
+	job, err := h.services.GetTranscription(userId, audioId)
+	if err != nil {
+		newTranscriptErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
+
+// @Summary Start transcription job
+// @Security ApiKeyAuth
+// @Tags transcript
+// @Description run speech-to-text on own audio in background, its result replaces transcript. Uploaded files are transcribed automatically
+// @ID start-transcription
+// @Produce  json
+// @Param id path int true "audio id"
+// @Success 202 {object} statusResponse
+// @Failure 400,404,409 {object} errorResponse
+// @Failure 500,501 {object} errorResponse
+// @Failure default {object} errorResponse
+// @Router /api/audio/{id}/transcript/job [post]
+func (h *Handler) startTranscription(c *gin.Context) {
+	userId, audioId, ok := getAudioParams(c)
+	if !ok {
+		return
+	}
+
+	if err := h.services.StartTranscription(userId, audioId); err != nil {
+		newTranscriptErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, statusResponse{"ok"})
+}
+
+func newTranscriptErrorResponse(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, storage.TranscriptNotFound), errors.Is(err, storage.NotOwner),
+		errors.Is(err, storage.TranscriptionNotFound):
+		newErrorResponse(c, http.StatusNotFound, err.Error())
+	case errors.Is(err, storage.TranscriptionActive):
+		newErrorResponse(c, http.StatusConflict, err.Error())
+	case errors.Is(err, storage.TranscriptionDisabled):
+		newErrorResponse(c, http.StatusNotImplemented, err.Error())
+	default:
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+	}
+}
@@ -0,0 +1,335 @@
+package handler
+
+import (
+	"bytes"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	storage "github.com/mahadeva604/audio-storage"
+	"github.com/mahadeva604/audio-storage/pkg/service"
+	mock_service "github.com/mahadeva604/audio-storage/pkg/service/mocks"
+	"github.com/stretchr/testify/assert"
+	"mime/multipart"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHandler_getTranscript(t *testing.T) {
+	type mockBehavior func(s *mock_service.MockTranscript)
+
+	transcript := storage.Transcript{
+		{StartMs: 1500, EndMs: 4000, Text: "Hello & welcome"},
+		{StartMs: 3723004, EndMs: 3725000, Text: "a --> b\nsecond line"},
+	}
+
+	testTable := []struct {
+		name                 string
+		target               string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedContentType  string
+		expectedResponseBody string
+	}{
+		{
+			name:   "OK json",
+			target: "/audio/2/transcript",
+			mockBehavior: func(s *mock_service.MockTranscript) {
+				s.EXPECT().GetTranscript(1, 2).Return(transcript, nil)
+			},
+			expectedStatusCode:  200,
+			expectedContentType: "application/json; charset=utf-8",
+			expectedResponseBody: `{"cues":[{"start_ms":1500,"end_ms":4000,"text":"Hello \u0026 welcome"},` +
+				`{"start_ms":3723004,"end_ms":3725000,"text":"a --\u003e b\nsecond line"}]}`,
+		},
+		{
+			name:   "OK webvtt",
+			target: "/audio/2/transcript?format=webvtt",
+			mockBehavior: func(s *mock_service.MockTranscript) {
+				s.EXPECT().GetTranscript(1, 2).Return(transcript, nil)
+			},
+			expectedStatusCode:  200,
+			expectedContentType: "text/vtt; charset=utf-8",
+			expectedResponseBody: "WEBVTT\n\n00:00:01.500 --> 00:00:04.000\nHello &amp; welcome\n" +
+				"\n01:02:03.004 --> 01:02:05.000\na --&gt; b\nsecond line\n",
+		},
+		{
+			name:   "OK srt",
+			target: "/audio/2/transcript?format=srt",
+			mockBehavior: func(s *mock_service.MockTranscript) {
+				s.EXPECT().GetTranscript(1, 2).Return(transcript, nil)
+			},
+			expectedStatusCode:  200,
+			expectedContentType: "application/x-subrip; charset=utf-8",
+			expectedResponseBody: "1\n00:00:01,500 --> 00:00:04,000\nHello & welcome\n" +
+				"\n2\n01:02:03,004 --> 01:02:05,000\na -> b\nsecond line\n",
+		},
+		{
+			name:                 "Invalid format",
+			target:               "/audio/2/transcript?format=ass",
+			mockBehavior:         func(s *mock_service.MockTranscript) {},
+			expectedStatusCode:   400,
+			expectedContentType:  "application/json; charset=utf-8",
+			expectedResponseBody: `{"message":"invalid query"}`,
+		},
+		{
+			name:   "Not found",
+			target: "/audio/2/transcript",
+			mockBehavior: func(s *mock_service.MockTranscript) {
+				s.EXPECT().GetTranscript(1, 2).Return(nil, storage.TranscriptNotFound)
+			},
+			expectedStatusCode:   404,
+			expectedContentType:  "application/json; charset=utf-8",
+			expectedResponseBody: `{"message":"transcript not found or you haven't access"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			transcript := mock_service.NewMockTranscript(c)
+			testCase.mockBehavior(transcript)
+
+			handler := NewHandler(&service.Service{Transcript: transcript})
+
+			r := gin.New()
+			r.GET("/audio/:id/transcript", func(c *gin.Context) {
+				c.Set(userCtx, 1)
+			}, handler.getTranscript)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", testCase.target, nil)
+
+			r.ServeHTTP(w, req)
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedContentType, w.Header().Get("Content-Type"))
+			assert.Equal(t, testCase.expectedResponseBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_setTranscript(t *testing.T) {
+	type mockBehavior func(s *mock_service.MockTranscript)
+
+	testTable := []struct {
+		name                 string
+		file                 string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name: "OK webvtt",
+			file: "\uFEFFWEBVTT - meeting\r\nKind: captions\r\n\r\nNOTE edited by hand\r\n\r\nSTYLE\r\n::cue { color: red }\r\n\r\n" +
+				"intro\r\n00:01.000 --> 00:04.000 align:start\r\n<v Roger>Hello &amp; <b>welcome</b>\r\n\r\n" +
+				"00:00.500 --> 00:00.900\r\n  first  \r\n\r\n" +
+				"01:00:00.000 --> 01:00:01.000\r\n<c.loud></c>\r\n",
+			mockBehavior: func(s *mock_service.MockTranscript) {
+				s.EXPECT().SetTranscript(1, 2, storage.Transcript{
+					{StartMs: 500, EndMs: 900, Text: "first"},
+					{StartMs: 1000, EndMs: 4000, Text: "Hello & welcome"},
+				}).Return(nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"status":"ok"}`,
+		},
+		{
+			name: "OK srt",
+			file: "1\n00:00:01,000 --> 00:00:02,500\n<i>Hello</i>\nworld\n\n2\n00:00:03,000 --> 00:00:04,000\nA &amp; B\n",
+			mockBehavior: func(s *mock_service.MockTranscript) {
+				s.EXPECT().SetTranscript(1, 2, storage.Transcript{
+					{StartMs: 1000, EndMs: 2500, Text: "Hello\nworld"},
+					{StartMs: 3000, EndMs: 4000, Text: "A &amp; B"},
+				}).Return(nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"status":"ok"}`,
+		},
+		{
+			name:                 "No cues",
+			file:                 "WEBVTT\n\nNOTE nothing here\n",
+			mockBehavior:         func(s *mock_service.MockTranscript) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"transcript must be WebVTT or SRT file: file has no cues"}`,
+		},
+		{
+			name:                 "Invalid timestamp",
+			file:                 "WEBVTT\n\n00:01.000 --> 00:61.000\ntext\n",
+			mockBehavior:         func(s *mock_service.MockTranscript) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"transcript must be WebVTT or SRT file: cue at line 3: invalid timestamp \"00:61.000\""}`,
+		},
+		{
+			name:                 "End before start",
+			file:                 "1\n00:00:02,000 --> 00:00:01,000\ntext\n",
+			mockBehavior:         func(s *mock_service.MockTranscript) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"transcript must be WebVTT or SRT file: cue at line 1: end time must be after start time"}`,
+		},
+		{
+			name:                 "No timing",
+			file:                 "just some text\nwithout cues\n",
+			mockBehavior:         func(s *mock_service.MockTranscript) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"transcript must be WebVTT or SRT file: cue at line 1: timing line is missing"}`,
+		},
+		{
+			name:                 "Not UTF-8",
+			file:                 "WEBVTT\n\n00:01.000 --> 00:02.000\n\xff\xfe\n",
+			mockBehavior:         func(s *mock_service.MockTranscript) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"message":"transcript must be WebVTT or SRT file: file must be UTF-8 encoded"}`,
+		},
+		{
+			name: "Not owner",
+			file: "1\n00:00:01,000 --> 00:00:02,000\ntext\n",
+			mockBehavior: func(s *mock_service.MockTranscript) {
+				s.EXPECT().SetTranscript(1, 2, storage.Transcript{{StartMs: 1000, EndMs: 2000, Text: "text"}}).Return(storage.NotOwner)
+			},
+			expectedStatusCode:   404,
+			expectedResponseBody: `{"message":"you are not owner or audio not exists"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			transcript := mock_service.NewMockTranscript(c)
+			testCase.mockBehavior(transcript)
+
+			handler := NewHandler(&service.Service{Transcript: transcript})
+
+			r := gin.New()
+			r.PUT("/audio/:id/transcript", func(c *gin.Context) {
+				c.Set(userCtx, 1)
+			}, handler.setTranscript)
+
+			body := &bytes.Buffer{}
+			writer := multipart.NewWriter(body)
+			part, err := writer.CreateFormFile("file", "transcript.vtt")
+			if err != nil {
+				t.Fatal(err)
+			}
+			part.Write([]byte(testCase.file))
+			writer.Close()
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("PUT", "/audio/2/transcript", body)
+			req.Header.Set("Content-Type", writer.FormDataContentType())
+
+			r.ServeHTTP(w, req)
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedResponseBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_startTranscription(t *testing.T) {
+	testTable := []struct {
+		name                 string
+		err                  error
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:                 "OK",
+			expectedStatusCode:   202,
+			expectedResponseBody: `{"status":"ok"}`,
+		},
+		{
+			name:                 "Already running",
+			err:                  storage.TranscriptionActive,
+			expectedStatusCode:   409,
+			expectedResponseBody: `{"message":"transcription of audio is already queued or running"}`,
+		},
+		{
+			name:                 "Disabled",
+			err:                  storage.TranscriptionDisabled,
+			expectedStatusCode:   501,
+			expectedResponseBody: `{"message":"speech-to-text provider is not configured"}`,
+		},
+		{
+			name:                 "Not owner",
+			err:                  storage.NotOwner,
+			expectedStatusCode:   404,
+			expectedResponseBody: `{"message":"you are not owner or audio not exists"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			transcription := mock_service.NewMockTranscription(c)
+			transcription.EXPECT().StartTranscription(1, 2).Return(testCase.err)
+
+			handler := NewHandler(&service.Service{Transcription: transcription})
+
+			r := gin.New()
+			r.POST("/audio/:id/transcript/job", func(c *gin.Context) {
+				c.Set(userCtx, 1)
+			}, handler.startTranscription)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/audio/2/transcript/job", nil)
+
+			r.ServeHTTP(w, req)
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedResponseBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_getTranscription(t *testing.T) {
+	at := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	testTable := []struct {
+		name                 string
+		job                  storage.TranscriptionJob
+		err                  error
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:               "OK",
+			job:                storage.TranscriptionJob{AudioId: 2, Status: storage.TranscriptionFailed, Error: "engine: exit status 1", CreatedAt: at, UpdatedAt: at},
+			expectedStatusCode: 200,
+			expectedResponseBody: `{"audio_id":2,"status":"failed","error":"engine: exit status 1",` +
+				`"created_at":"2021-06-01T12:00:00Z","updated_at":"2021-06-01T12:00:00Z"}`,
+		},
+		{
+			name:                 "Not found",
+			err:                  storage.TranscriptionNotFound,
+			expectedStatusCode:   404,
+			expectedResponseBody: `{"message":"transcription job not found or you haven't access"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			transcription := mock_service.NewMockTranscription(c)
+			transcription.EXPECT().GetTranscription(1, 2).Return(testCase.job, testCase.err)
+
+			handler := NewHandler(&service.Service{Transcription: transcription})
+
+			r := gin.New()
+			r.GET("/audio/:id/transcript/job", func(c *gin.Context) {
+				c.Set(userCtx, 1)
+			}, handler.getTranscription)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/audio/2/transcript/job", nil)
+
+			r.ServeHTTP(w, req)
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedResponseBody, w.Body.String())
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"bufio"
+	"io"
+)
+
+const (
+	adtsHeaderSize = 7
+	adtsMaxFrame   = 1<<13 - 1
+	// adtsFrameSamples is number of samples in every raw data block
+	adtsFrameSamples = 1024
+)
+
+var adtsSampleRates = []int{96000, 88200, 64000, 48000, 44100, 32000, 24000, 22050, 16000, 12000, 11025, 8000, 7350}
+
+type adtsFrame struct {
+	sampleRate int
+	channels   int
+	length     int
+	samples    int
+}
+
+// adtsStream is summary of frames read by scanADTS
+type adtsStream struct {
+	frames     int
+	samples    int64
+	size       int64
+	skipped    int64
+	sampleRate int
+	channels   int
+}
+
+func adtsSync(b []byte) bool {
+	return len(b) > 1 && b[0] == 0xFF && b[1]&0xF6 == 0xF0
+}
+
+func parseADTSHeader(b []byte) (adtsFrame, bool) {
+	if len(b) < adtsHeaderSize || !adtsSync(b) {
+		return adtsFrame{}, false
+	}
+
+	index := int(b[2]>>2) & 0x0F
+	if index >= len(adtsSampleRates) {
+		return adtsFrame{}, false
+	}
+
+	headerSize := adtsHeaderSize
+	if b[1]&0x01 == 0 {
+		// header is followed by CRC
+		headerSize += 2
+	}
+
+	length := int(b[3]&0x03)<<11 | int(b[4])<<3 | int(b[5])>>5
+	if length <= headerSize {
+		return adtsFrame{}, false
+	}
+
+	return adtsFrame{
+		sampleRate: adtsSampleRates[index],
+		channels:   int(b[2]&0x01)<<2 | int(b[3])>>6,
+		length:     length,
+		samples:    (int(b[6]&0x03) + 1) * adtsFrameSamples,
+	}, true
+}
+
+// scanADTS calls fn with every valid frame of stream. Frame is valid if it is
+// complete, has the sample rate of the first frame and is followed by another
+// frame or the end of stream, other bytes are skipped
+func scanADTS(r io.Reader, fn func(frame []byte, info adtsFrame) error) (adtsStream, error) {
+	var stream adtsStream
+	br := bufio.NewReaderSize(r, 2*adtsMaxFrame)
+
+	for {
+		head, err := br.Peek(adtsHeaderSize)
+		if err != nil && err != io.EOF {
+			return stream, err
+		}
+		if len(head) < adtsHeaderSize {
+			stream.skipped += int64(len(head))
+			return stream, nil
+		}
+
+		frame, ok := parseADTSHeader(head)
+		if ok && stream.frames > 0 && frame.sampleRate != stream.sampleRate {
+			ok = false
+		}
+
+		var data []byte
+		if ok {
+			data, err = br.Peek(frame.length + 2)
+			if err != nil && err != io.EOF {
+				return stream, err
+			}
+			if len(data) < frame.length || (len(data) == frame.length+2 && !adtsSync(data[frame.length:])) {
+				ok = false
+			}
+		}
+
+		if !ok {
+			br.Discard(1)
+			stream.skipped++
+			continue
+		}
+
+		if fn != nil {
+			if err := fn(data[:frame.length], frame); err != nil {
+				return stream, err
+			}
+		}
+		br.Discard(frame.length)
+
+		if stream.frames == 0 {
+			stream.sampleRate = frame.sampleRate
+			stream.channels = frame.channels
+		}
+		stream.frames++
+		stream.samples += int64(frame.samples)
+		stream.size += int64(frame.length)
+	}
+}
+
+// duration returns duration of stream in seconds
+func (s adtsStream) duration() float64 {
+	if s.sampleRate == 0 {
+		return 0
+	}
+	return float64(s.samples) / float64(s.sampleRate)
+}
+
+// bitrate returns average bitrate in bits per second
+func (s adtsStream) bitrate() int {
+	if s.samples == 0 {
+		return 0
+	}
+	return int(float64(s.size*8) * float64(s.sampleRate) / float64(s.samples))
+}
@@ -0,0 +1,202 @@
+package repository
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/google/uuid"
+	storage "github.com/mahadeva604/audio-storage"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// adtsTestFrame returns AAC LC frame without CRC of 44.1 kHz stereo audio
+func adtsTestFrame(payload int) []byte {
+	length := adtsHeaderSize + payload
+	frame := []byte{
+		0xFF, 0xF1,
+		1<<6 | 4<<2,
+		2<<6 | byte(length>>11&0x03),
+		byte(length >> 3),
+		byte(length&0x07)<<5 | 0x1F,
+		0xFC,
+	}
+	return append(frame, make([]byte, payload)...)
+}
+
+func adtsTestStream(frames int) []byte {
+	stream := make([]byte, 0, frames*(adtsHeaderSize+100))
+	for i := 0; i < frames; i++ {
+		stream = append(stream, adtsTestFrame(100)...)
+	}
+	return stream
+}
+
+func TestScanADTS(t *testing.T) {
+	frame := adtsTestFrame(100)
+
+	testTable := []struct {
+		name            string
+		data            []byte
+		expectedFrames  int
+		expectedSkipped int64
+	}{
+		{
+			name:           "OK",
+			data:           concat(frame, frame, frame),
+			expectedFrames: 3,
+		},
+		{
+			name:            "OK junk skipped",
+			data:            concat([]byte("junk"), frame, []byte{0xFF, 0xF1, 0x00}, frame),
+			expectedFrames:  2,
+			expectedSkipped: 7,
+		},
+		{
+			name:            "OK truncated frame skipped",
+			data:            concat(frame, frame[:50]),
+			expectedFrames:  1,
+			expectedSkipped: 50,
+		},
+		{
+			name:            "Not ADTS",
+			data:            []byte("not an audio file"),
+			expectedSkipped: 17,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			var out bytes.Buffer
+			stream, err := scanADTS(bytes.NewReader(testCase.data), func(frame []byte, _ adtsFrame) error {
+				out.Write(frame)
+				return nil
+			})
+
+			assert.NoError(t, err)
+			assert.Equal(t, testCase.expectedFrames, stream.frames)
+			assert.Equal(t, testCase.expectedSkipped, stream.skipped)
+			assert.Equal(t, string(bytes.Repeat(frame, testCase.expectedFrames)), out.String())
+		})
+	}
+}
+
+func TestStorageFS_NormalizeFile(t *testing.T) {
+	frame := adtsTestFrame(100)
+	r := NewStorageFS(t.TempDir() + "/")
+	fileId := uuid.New()
+
+	assert.NoError(t, os.WriteFile(r.FilePath(fileId), concat([]byte("junk"), frame, frame[:10]), 0644))
+
+	size, err := r.NormalizeFile(fileId)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(len(frame)), size)
+
+	data, err := os.ReadFile(r.FilePath(fileId))
+	assert.NoError(t, err)
+	assert.Equal(t, frame, data)
+
+	_, err = os.Stat(r.FilePath(fileId) + tmpExt)
+	assert.True(t, os.IsNotExist(err))
+
+	assert.NoError(t, os.WriteFile(r.FilePath(fileId), []byte("not an audio file"), 0644))
+	_, err = r.NormalizeFile(fileId)
+	assert.Equal(t, storage.NotAacFile, err)
+}
+
+func TestStorageFS_ProbeFile(t *testing.T) {
+	r := NewStorageFS(t.TempDir() + "/")
+	fileId := uuid.New()
+
+	// 431 frames of 1024 samples are 10.008 seconds at 44.1 kHz
+	assert.NoError(t, os.WriteFile(r.FilePath(fileId), adtsTestStream(431), 0644))
+
+	probe, err := r.ProbeFile(fileId)
+	assert.NoError(t, err)
+	assert.Equal(t, storage.AudioProbe{Duration: 10, SampleRate: 44100, Channels: 2, Bitrate: 36864}, probe)
+
+	checksum, err := r.ChecksumFile(fileId)
+	assert.NoError(t, err)
+	assert.Len(t, checksum, 64)
+}
+
+func TestStorageFS_PackageHLS(t *testing.T) {
+	r := NewStorageFS(t.TempDir() + "/")
+	fileId := uuid.New()
+
+	assert.NoError(t, os.WriteFile(r.FilePath(fileId), adtsTestStream(400), 0644))
+	assert.NoError(t, r.PackageHLS(fileId))
+
+	playlist, err := r.GetHLSFile(fileId, storage.HLSPlaylist)
+	assert.NoError(t, err)
+	assert.Equal(t, "#EXTM3U\n#EXT-X-VERSION:3\n#EXT-X-TARGETDURATION:7\n#EXT-X-MEDIA-SEQUENCE:0\n#EXT-X-PLAYLIST-TYPE:VOD\n"+
+		"#EXTINF:6.014,\nsegment00000.aac\n#EXTINF:3.274,\nsegment00001.aac\n#EXT-X-ENDLIST\n", string(playlist))
+
+	segment, err := r.GetHLSFile(fileId, "segment00001.aac")
+	assert.NoError(t, err)
+	tag := hlsTimestamp(259 * 1024 * hlsClock / 44100)
+	assert.Equal(t, tag, segment[:len(tag)])
+	assert.Equal(t, adtsTestStream(141), segment[len(tag):])
+
+	_, err = r.GetHLSFile(fileId, "segment00002.aac")
+	assert.Equal(t, storage.HLSNotFound, err)
+
+	_, err = r.GetHLSFile(fileId, "../"+fileId.String()+storage.FileExt)
+	assert.Equal(t, storage.HLSNotFound, err)
+}
+
+func TestHLSTimestamp(t *testing.T) {
+	owner := "com.apple.streaming.transportStreamTimestamp\x00"
+	expected := concat(
+		[]byte{'I', 'D', '3', 4, 0, 0, 0, 0, 0, byte(10 + len(owner) + 8)},
+		[]byte{'P', 'R', 'I', 'V', 0, 0, 0, byte(len(owner) + 8), 0, 0},
+		[]byte(owner),
+		[]byte{0, 0, 0, 1, 0, 0, 0, 2},
+	)
+
+	assert.Equal(t, expected, hlsTimestamp(1<<32+2))
+}
+
+func TestStorageFS_ListFiles(t *testing.T) {
+	dir := t.TempDir() + "/"
+	r := NewStorageFS(dir)
+
+	oldAudio, newAudio, artwork := uuid.New(), uuid.New(), uuid.New()
+	old := time.Now().Add(-2 * time.Hour)
+	paths := map[string]bool{
+		r.FilePath(oldAudio):                               true,
+		r.FilePath(newAudio):                               false,
+		r.artworkPath(artwork, storage.ArtworkSmall):       true,
+		r.artworkPath(artwork, storage.ArtworkLarge):       true,
+		r.FilePath(oldAudio) + tmpExt:                      true,
+		filepath.Join(dir, "notes.txt"):                    true,
+		filepath.Join(dir, hlsDir, oldAudio.String(), "x"): true,
+	}
+	for path, isOld := range paths {
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		assert.NoError(t, os.WriteFile(path, []byte("data"), 0644))
+		if isOld {
+			assert.NoError(t, os.Chtimes(path, old, old))
+		}
+	}
+	assert.NoError(t, os.Chtimes(r.hlsPath(oldAudio), old, old))
+
+	files, err := r.ListFiles(time.Hour)
+	assert.NoError(t, err)
+	assert.Equal(t, storage.OrphanFiles{
+		Audio:   []string{oldAudio.String()},
+		Artwork: []string{artwork.String()},
+		HLS:     []string{oldAudio.String()},
+		Temp:    []string{r.FilePath(oldAudio) + tmpExt},
+	}, files)
+
+	assert.NoError(t, r.DeleteFiles(files))
+	for path := range paths {
+		_, err := os.Stat(path)
+		kept := path == r.FilePath(newAudio) || strings.HasSuffix(path, "notes.txt")
+		assert.Equal(t, kept, err == nil, fmt.Sprintf("%s kept", path))
+	}
+}
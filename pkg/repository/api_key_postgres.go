@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	storage "github.com/mahadeva604/audio-storage"
+)
+
+// apiKeyColumns are columns of apiKeyDb
+const apiKeyColumns = "api_key_id, name, prefix, scopes, created_at, expires_at, last_used_at"
+
+type apiKeyDb struct {
+	storage.APIKey
+	Scopes pq.StringArray `db:"scopes"`
+}
+
+func (k apiKeyDb) apiKey() storage.APIKey {
+	key := k.APIKey
+	key.Scopes = k.Scopes
+	return key
+}
+
+type APIKeyPostgres struct {
+	db *sqlx.DB
+}
+
+func NewAPIKeyPostgres(db *sqlx.DB) *APIKeyPostgres {
+	return &APIKeyPostgres{db: db}
+}
+
+func (r *APIKeyPostgres) CreateAPIKey(userId int, input storage.APIKeyInput, prefix, keyHash string) (storage.APIKey, error) {
+	var key apiKeyDb
+	query := fmt.Sprintf(`INSERT INTO %s (user_id, name, prefix, key_hash, scopes, expires_at)
+								VALUES ($1, $2, $3, $4, $5, $6) RETURNING %s`, apiKeysTable, apiKeyColumns)
+	err := r.db.Get(&key, query, userId, input.Name, prefix, keyHash, pq.Array(input.Scopes), input.ExpiresAt)
+
+	return key.apiKey(), err
+}
+
+// GetAPIKeys returns keys of user including expired ones, newest first
+func (r *APIKeyPostgres) GetAPIKeys(userId int) ([]storage.APIKey, error) {
+	var rows []apiKeyDb
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE user_id = $1 ORDER BY api_key_id DESC", apiKeyColumns, apiKeysTable)
+	if err := r.db.Select(&rows, query, userId); err != nil {
+		return nil, err
+	}
+
+	keys := make([]storage.APIKey, 0, len(rows))
+	for _, row := range rows {
+		keys = append(keys, row.apiKey())
+	}
+
+	return keys, nil
+}
+
+func (r *APIKeyPostgres) DeleteAPIKey(userId, keyId int) error {
+	query := fmt.Sprintf("DELETE FROM %s WHERE api_key_id = $1 AND user_id = $2", apiKeysTable)
+	result, err := r.db.Exec(query, keyId, userId)
+
+	return checkAffected(result, err, storage.APIKeyNotFound)
+}
+
+// UseAPIKey returns owner of not expired key and records its use
+func (r *APIKeyPostgres) UseAPIKey(keyHash string) (storage.APIKeyOwner, error) {
+	var row struct {
+		UserId int            `db:"user_id"`
+		Scopes pq.StringArray `db:"scopes"`
+	}
+	query := fmt.Sprintf(`UPDATE %s SET last_used_at = now()
+								WHERE key_hash = $1 AND (expires_at IS NULL OR expires_at > now())
+								RETURNING user_id, scopes`, apiKeysTable)
+	err := r.db.Get(&row, query, keyHash)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return storage.APIKeyOwner{}, storage.InvalidAPIKey
+	}
+
+	return storage.APIKeyOwner{UserId: row.UserId, Scopes: row.Scopes}, err
+}
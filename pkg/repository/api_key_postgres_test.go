@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	storage "github.com/mahadeva604/audio-storage"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestAPIKeyPostgres(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+	db := sqlx.NewDb(mockDB, "sqlmock")
+
+	r := NewAPIKeyPostgres(db)
+
+	createdAt := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	columns := []string{"api_key_id", "name", "prefix", "scopes", "created_at", "expires_at", "last_used_at"}
+
+	t.Run("OK create", func(t *testing.T) {
+		expiresAt := createdAt.Add(24 * time.Hour)
+		rows := sqlmock.NewRows(columns).AddRow(2, "backup", "aspat_AbCdEf", "{audio:read,share:write}", createdAt, expiresAt, nil)
+		mock.ExpectQuery(`INSERT INTO api_keys \(user_id, name, prefix, key_hash, scopes, expires_at\)
+								VALUES \(\$1, \$2, \$3, \$4, \$5, \$6\) RETURNING api_key_id, name, prefix, scopes, created_at, expires_at, last_used_at`).
+			WithArgs(1, "backup", "aspat_AbCdEf", "hash", "{\"audio:read\",\"share:write\"}", &expiresAt).WillReturnRows(rows)
+
+		key, err := r.CreateAPIKey(1, storage.APIKeyInput{
+			Name:      "backup",
+			Scopes:    []string{storage.ScopeAudioRead, storage.ScopeShareWrite},
+			ExpiresAt: &expiresAt,
+		}, "aspat_AbCdEf", "hash")
+		assert.NoError(t, err)
+		assert.Equal(t, storage.APIKey{
+			Id:        2,
+			Name:      "backup",
+			Prefix:    "aspat_AbCdEf",
+			Scopes:    []string{storage.ScopeAudioRead, storage.ScopeShareWrite},
+			CreatedAt: createdAt,
+			ExpiresAt: &expiresAt,
+		}, key)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("OK get", func(t *testing.T) {
+		rows := sqlmock.NewRows(columns).
+			AddRow(3, "sync", "aspat_GhIjKl", "{audio:read}", createdAt, nil, createdAt.Add(time.Hour))
+		mock.ExpectQuery(`SELECT api_key_id, name, prefix, scopes, created_at, expires_at, last_used_at FROM api_keys
+								WHERE user_id = \$1 ORDER BY api_key_id DESC`).WithArgs(1).WillReturnRows(rows)
+
+		lastUsedAt := createdAt.Add(time.Hour)
+		keys, err := r.GetAPIKeys(1)
+		assert.NoError(t, err)
+		assert.Equal(t, []storage.APIKey{
+			{Id: 3, Name: "sync", Prefix: "aspat_GhIjKl", Scopes: []string{storage.ScopeAudioRead}, CreatedAt: createdAt, LastUsedAt: &lastUsedAt},
+		}, keys)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Delete not found", func(t *testing.T) {
+		mock.ExpectExec(`DELETE FROM api_keys WHERE api_key_id = \$1 AND user_id = \$2`).
+			WithArgs(2, 1).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		assert.ErrorIs(t, r.DeleteAPIKey(1, 2), storage.APIKeyNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("OK use", func(t *testing.T) {
+		rows := sqlmock.NewRows([]string{"user_id", "scopes"}).AddRow(1, "{audio:read}")
+		mock.ExpectQuery(`UPDATE api_keys SET last_used_at = now\(\)
+								WHERE key_hash = \$1 AND \(expires_at IS NULL OR expires_at > now\(\)\)
+								RETURNING user_id, scopes`).WithArgs("hash").WillReturnRows(rows)
+
+		owner, err := r.UseAPIKey("hash")
+		assert.NoError(t, err)
+		assert.Equal(t, storage.APIKeyOwner{UserId: 1, Scopes: []string{storage.ScopeAudioRead}}, owner)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Use expired", func(t *testing.T) {
+		mock.ExpectQuery(`UPDATE api_keys SET last_used_at = now\(\)`).WithArgs("hash").
+			WillReturnRows(sqlmock.NewRows([]string{"user_id", "scopes"}))
+
+		_, err := r.UseAPIKey("hash")
+		assert.ErrorIs(t, err, storage.InvalidAPIKey)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
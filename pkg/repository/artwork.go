@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"bytes"
+	storage "github.com/mahadeva604/audio-storage"
+	"golang.org/x/image/draw"
+	"image"
+	"image/jpeg"
+	"image/png"
+
+	_ "golang.org/x/image/webp"
+)
+
+const artworkQuality = 85
+
+// resizeArtwork decodes PNG, JPEG or WebP image and makes every size of
+// storage.ArtworkSizes. Opaque images are encoded to JPEG, others to PNG
+func resizeArtwork(data []byte) (map[string][]byte, error) {
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || (format != "png" && format != "jpeg" && format != "webp") {
+		return nil, storage.InvalidArtwork
+	}
+	if config.Width <= 0 || config.Height <= 0 || config.Width*config.Height > storage.MaxArtworkPixels {
+		return nil, storage.InvalidArtwork
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, storage.InvalidArtwork
+	}
+
+	sizes := make(map[string][]byte, len(storage.ArtworkSizes))
+	for name, max := range storage.ArtworkSizes {
+		resized := fitArtwork(img, max)
+
+		var out bytes.Buffer
+		if resized.Opaque() {
+			err = jpeg.Encode(&out, resized, &jpeg.Options{Quality: artworkQuality})
+		} else {
+			err = png.Encode(&out, resized)
+		}
+		if err != nil {
+			return nil, err
+		}
+		sizes[name] = out.Bytes()
+	}
+
+	return sizes, nil
+}
+
+// fitArtwork scales image down to fit max x max keeping aspect ratio
+func fitArtwork(img image.Image, max int) *image.RGBA {
+	width, height := img.Bounds().Dx(), img.Bounds().Dy()
+	if width > max || height > max {
+		if width >= height {
+			width, height = max, height*max/width
+		} else {
+			width, height = width*max/height, max
+		}
+	}
+	if width < 1 {
+		width = 1
+	}
+	if height < 1 {
+		height = 1
+	}
+
+	resized := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(resized, resized.Bounds(), img, img.Bounds(), draw.Src, nil)
+
+	return resized
+}
@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	storage "github.com/mahadeva604/audio-storage"
+)
+
+type ArtworkPostgres struct {
+	db *sqlx.DB
+}
+
+func NewArtworkPostgres(db *sqlx.DB) *ArtworkPostgres {
+	return &ArtworkPostgres{db: db}
+}
+
+// SetAudioArtwork sets artwork of own audio, artworkId is empty to remove it.
+// It returns id of the previous artwork or empty string
+func (r *ArtworkPostgres) SetAudioArtwork(userID, audioId int, artworkId string) (string, error) {
+	var previous string
+	query := fmt.Sprintf(`UPDATE %[1]s a SET artwork_id = NULLIF($1, '')::uuid FROM %[1]s o
+							WHERE o.audio_id = a.audio_id AND a.audio_id = $2 AND a.user_id = $3
+							RETURNING coalesce(o.artwork_id::text, '')`, audiosTable)
+	err := r.db.Get(&previous, query, artworkId, audioId, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", storage.NotOwner
+	}
+
+	return previous, err
+}
+
+// GetAudioArtwork returns artwork id of audio accessible by user
+func (r *ArtworkPostgres) GetAudioArtwork(userID, audioId int) (string, error) {
+	var artworkId string
+	query := fmt.Sprintf(`SELECT coalesce(artwork_id::text, '') FROM %s
+							WHERE audio_id = $1 AND audio_id IN (SELECT audio_id FROM %s WHERE user_id = $2)`,
+		audiosTable, audioAccessView)
+	err := r.db.Get(&artworkId, query, audioId, userID)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && artworkId == "") {
+		return "", storage.ArtworkNotFound
+	}
+
+	return artworkId, err
+}
+
+// SetCollectionArtwork sets artwork of own collection, artworkId is empty to
+// remove it. It returns id of the previous artwork or empty string
+func (r *ArtworkPostgres) SetCollectionArtwork(userID, collectionId int, artworkId string) (string, error) {
+	var previous string
+	query := fmt.Sprintf(`UPDATE %[1]s c SET artwork_id = NULLIF($1, '')::uuid FROM %[1]s o
+							WHERE o.collection_id = c.collection_id AND c.collection_id = $2 AND c.user_id = $3
+							RETURNING coalesce(o.artwork_id::text, '')`, collectionsTable)
+	err := r.db.Get(&previous, query, artworkId, collectionId, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", storage.NotCollectionOwner
+	}
+
+	return previous, err
+}
+
+// GetCollectionArtwork returns artwork id of own or shared collection
+func (r *ArtworkPostgres) GetCollectionArtwork(userID, collectionId int) (string, error) {
+	var artworkId string
+	query := fmt.Sprintf(`SELECT coalesce(artwork_id::text, '') FROM %s WHERE collection_id = $1
+							AND (user_id = $2 OR collection_id IN (SELECT collection_id FROM %s WHERE user_id = $2 AND status = 'accepted'))`,
+		collectionsTable, collectionSharesTable)
+	err := r.db.Get(&artworkId, query, collectionId, userID)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && artworkId == "") {
+		return "", storage.ArtworkNotFound
+	}
+
+	return artworkId, err
+}
@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"database/sql"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	storage "github.com/mahadeva604/audio-storage"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestArtworkPostgres(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+	db := sqlx.NewDb(mockDB, "sqlmock")
+
+	r := NewArtworkPostgres(db)
+
+	artworkId := "6ba7b810-9dad-11d1-80b4-00c04fd430c8"
+
+	t.Run("OK set audio artwork", func(t *testing.T) {
+		rows := sqlmock.NewRows([]string{"artwork_id"}).AddRow("")
+		mock.ExpectQuery(`UPDATE audios a SET artwork_id = NULLIF\(\$1, ''\)::uuid FROM audios o (.+) RETURNING`).
+			WithArgs(artworkId, 2, 1).WillReturnRows(rows)
+
+		previous, err := r.SetAudioArtwork(1, 2, artworkId)
+		assert.NoError(t, err)
+		assert.Equal(t, "", previous)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("OK remove audio artwork", func(t *testing.T) {
+		rows := sqlmock.NewRows([]string{"artwork_id"}).AddRow(artworkId)
+		mock.ExpectQuery(`UPDATE audios a SET artwork_id`).WithArgs("", 2, 1).WillReturnRows(rows)
+
+		previous, err := r.SetAudioArtwork(1, 2, "")
+		assert.NoError(t, err)
+		assert.Equal(t, artworkId, previous)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Error set audio artwork not owner", func(t *testing.T) {
+		mock.ExpectQuery(`UPDATE audios a SET artwork_id`).WithArgs(artworkId, 2, 1).WillReturnError(sql.ErrNoRows)
+
+		_, err := r.SetAudioArtwork(1, 2, artworkId)
+		assert.Equal(t, storage.NotOwner, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("OK get audio artwork", func(t *testing.T) {
+		rows := sqlmock.NewRows([]string{"artwork_id"}).AddRow(artworkId)
+		mock.ExpectQuery(`SELECT (.+) FROM audios WHERE audio_id = \$1 AND audio_id IN \(SELECT audio_id FROM audio_access WHERE user_id = \$2\)`).
+			WithArgs(2, 1).WillReturnRows(rows)
+
+		got, err := r.GetAudioArtwork(1, 2)
+		assert.NoError(t, err)
+		assert.Equal(t, artworkId, got)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Error get audio without artwork", func(t *testing.T) {
+		rows := sqlmock.NewRows([]string{"artwork_id"}).AddRow("")
+		mock.ExpectQuery(`SELECT (.+) FROM audios`).WithArgs(2, 1).WillReturnRows(rows)
+
+		_, err := r.GetAudioArtwork(1, 2)
+		assert.Equal(t, storage.ArtworkNotFound, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Error get audio artwork no access", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT (.+) FROM audios`).WithArgs(2, 1).WillReturnError(sql.ErrNoRows)
+
+		_, err := r.GetAudioArtwork(1, 2)
+		assert.Equal(t, storage.ArtworkNotFound, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("OK set collection artwork", func(t *testing.T) {
+		rows := sqlmock.NewRows([]string{"artwork_id"}).AddRow("")
+		mock.ExpectQuery(`UPDATE collections c SET artwork_id = NULLIF\(\$1, ''\)::uuid FROM collections o (.+) RETURNING`).
+			WithArgs(artworkId, 3, 1).WillReturnRows(rows)
+
+		_, err := r.SetCollectionArtwork(1, 3, artworkId)
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Error set collection artwork not owner", func(t *testing.T) {
+		mock.ExpectQuery(`UPDATE collections c SET artwork_id`).WithArgs(artworkId, 3, 1).WillReturnError(sql.ErrNoRows)
+
+		_, err := r.SetCollectionArtwork(1, 3, artworkId)
+		assert.Equal(t, storage.NotCollectionOwner, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("OK get collection artwork", func(t *testing.T) {
+		rows := sqlmock.NewRows([]string{"artwork_id"}).AddRow(artworkId)
+		mock.ExpectQuery(`SELECT (.+) FROM collections WHERE collection_id = \$1 AND \(user_id = \$2 OR collection_id IN \(SELECT collection_id FROM collection_shares WHERE user_id = \$2 AND status = 'accepted'\)\)`).
+			WithArgs(3, 1).WillReturnRows(rows)
+
+		got, err := r.GetCollectionArtwork(1, 3)
+		assert.NoError(t, err)
+		assert.Equal(t, artworkId, got)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Error get collection artwork no access", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT (.+) FROM collections`).WithArgs(3, 1).WillReturnError(sql.ErrNoRows)
+
+		_, err := r.GetCollectionArtwork(1, 3)
+		assert.Equal(t, storage.ArtworkNotFound, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"bytes"
+	"encoding/binary"
+	storage "github.com/mahadeva604/audio-storage"
+	"github.com/stretchr/testify/assert"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func encodeTestImage(t *testing.T, format string, width, height int, opaque bool) []byte {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	alpha := uint8(255)
+	if !opaque {
+		alpha = 128
+	}
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			img.Set(x, y, color.NRGBA{R: uint8(x), G: uint8(y), B: 100, A: alpha})
+		}
+	}
+
+	var out bytes.Buffer
+	var err error
+	if format == "jpeg" {
+		err = jpeg.Encode(&out, img, nil)
+	} else {
+		err = png.Encode(&out, img)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return out.Bytes()
+}
+
+func TestResizeArtwork(t *testing.T) {
+	testTable := []struct {
+		name           string
+		image          []byte
+		expectedFormat string
+		expectedSizes  map[string]image.Point
+		expectedErr    error
+	}{
+		{
+			name:           "OK jpeg",
+			image:          encodeTestImage(t, "jpeg", 1600, 800, true),
+			expectedFormat: "jpeg",
+			expectedSizes: map[string]image.Point{
+				storage.ArtworkSmall:  {X: 150, Y: 75},
+				storage.ArtworkMedium: {X: 600, Y: 300},
+				storage.ArtworkLarge:  {X: 1200, Y: 600},
+			},
+		},
+		{
+			name:           "OK small transparent png isn't upscaled",
+			image:          encodeTestImage(t, "png", 200, 400, false),
+			expectedFormat: "png",
+			expectedSizes: map[string]image.Point{
+				storage.ArtworkSmall:  {X: 75, Y: 150},
+				storage.ArtworkMedium: {X: 200, Y: 400},
+				storage.ArtworkLarge:  {X: 200, Y: 400},
+			},
+		},
+		{
+			name:        "Not an image",
+			image:       []byte("GIF89a"),
+			expectedErr: storage.InvalidArtwork,
+		},
+		{
+			name:        "Too many pixels",
+			image:       pngHeader(9000, 9000),
+			expectedErr: storage.InvalidArtwork,
+		},
+		{
+			name:        "Broken image",
+			image:       pngHeader(10, 10),
+			expectedErr: storage.InvalidArtwork,
+		},
+	}
+
+	config, err := png.DecodeConfig(bytes.NewReader(pngHeader(9000, 9000)))
+	assert.NoError(t, err)
+	assert.Equal(t, 9000, config.Width)
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			sizes, err := resizeArtwork(testCase.image)
+			if testCase.expectedErr != nil {
+				assert.Equal(t, testCase.expectedErr, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Len(t, sizes, len(testCase.expectedSizes))
+			for name, expected := range testCase.expectedSizes {
+				config, format, err := image.DecodeConfig(bytes.NewReader(sizes[name]))
+				assert.NoError(t, err)
+				assert.Equal(t, testCase.expectedFormat, format)
+				assert.Equal(t, expected, image.Point{X: config.Width, Y: config.Height}, name)
+			}
+		})
+	}
+}
+
+// pngHeader is a PNG signature and IHDR chunk without image data
+func pngHeader(width, height int) []byte {
+	ihdr := make([]byte, 17)
+	copy(ihdr, "IHDR")
+	binary.BigEndian.PutUint32(ihdr[4:], uint32(width))
+	binary.BigEndian.PutUint32(ihdr[8:], uint32(height))
+	ihdr[12], ihdr[13] = 8, 2
+
+	header := concat([]byte("\x89PNG\r\n\x1a\n"), []byte{0, 0, 0, 13}, ihdr, make([]byte, 4))
+	binary.BigEndian.PutUint32(header[29:], crc32.ChecksumIEEE(ihdr))
+
+	return header
+}
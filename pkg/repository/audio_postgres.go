@@ -2,10 +2,14 @@ package repository
 
 import (
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 	storage "github.com/mahadeva604/audio-storage"
+	"strconv"
+	"time"
 )
 
 type AudioPostgres struct {
@@ -16,18 +20,19 @@ func NewAudioPostgres(db *sqlx.DB) *AudioPostgres {
 	return &AudioPostgres{db: db}
 }
 
-func (r *AudioPostgres) UploadFile(userId int, path string) (int, error) {
+// UploadFile adds audio with metadata read from tags of the file
+func (r *AudioPostgres) UploadFile(userId int, path string, size int64, metadata storage.AudioMetadata) (int, error) {
 	var audioId int
-	query := fmt.Sprintf(`INSERT INTO %s (user_id, title, duration, file_path)
-							VALUES ($1, '', 0, $2) RETURNING audio_id`, audiosTable)
-	err := r.db.Get(&audioId, query, userId, path)
+	query := fmt.Sprintf(`INSERT INTO %s (user_id, uploaded_by, title, artist, album, duration, file_path, size, format)
+							VALUES ($1, $1, $2, $3, $4, 0, $5, $6, $7) RETURNING audio_id`, audiosTable)
+	err := r.db.Get(&audioId, query, userId, metadata.Title, metadata.Artist, metadata.Album, path, size, storage.FormatAac)
 
 	return audioId, err
 }
 
 func (r *AudioPostgres) DownloadFile(userID, audioId int) (storage.DownloadAudio, error) {
 	var audio storage.DownloadAudio
-	query := fmt.Sprintf("SELECT title, file_path FROM %s a LEFT JOIN %s r USING (audio_id) WHERE audio_id = $1 and (a.user_id = $2 or r.user_id = $2)", audiosTable, sharesTable)
+	query := fmt.Sprintf("SELECT title, artist, album, file_path FROM %s a WHERE audio_id = $1 AND EXISTS (SELECT 1 FROM %s v WHERE v.audio_id = a.audio_id AND v.user_id = $2)", audiosTable, audioAccessView)
 	err := r.db.Get(&audio, query, audioId, userID)
 
 	if err == sql.ErrNoRows {
@@ -37,65 +42,433 @@ func (r *AudioPostgres) DownloadFile(userID, audioId int) (storage.DownloadAudio
 	return audio, err
 }
 
-func (r *AudioPostgres) AddDescription(userID, audioId int, input storage.UpdateAudio) error {
-	query := fmt.Sprintf("UPDATE %s SET title = $1, duration = $2 WHERE user_id = $3 and audio_id = $4", audiosTable)
+// audioColumns are columns of storage.Audio
+const audioColumns = `audio_id, user_id, title, duration, description, artist, album, recorded_at, language, location, custom,
+						sample_rate, channels, bitrate, checksum, uploaded_by, created_at, updated_at, version`
 
-	result, err := r.db.Exec(query, input.Title, input.Duration, userID, audioId)
+func (r *AudioPostgres) GetAudio(userID, audioId int) (storage.Audio, error) {
+	var audio storage.Audio
+	query := fmt.Sprintf(`SELECT %s FROM %s
+							WHERE audio_id = $1 AND audio_id IN (SELECT audio_id FROM %s WHERE user_id = $2)`,
+		audioColumns, audiosTable, audioAccessView)
+	err := r.db.Get(&audio, query, audioId, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return audio, storage.FileNotFound
+	}
+
+	return audio, err
+}
+
+// AddDescription replaces metadata of audio only if it is still of given version
+func (r *AudioPostgres) AddDescription(userID, audioId, version int, metadata storage.AudioMetadata) (storage.Audio, error) {
+	var audio storage.Audio
+
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return audio, err
+	}
 
+	var old storage.AudioMetadata
+	query := fmt.Sprintf(`SELECT title, duration, description, artist, album, recorded_at, language, location, custom
+						FROM %s WHERE user_id = $1 and audio_id = $2 FOR UPDATE`, audiosTable)
+	if err := tx.Get(&old, query, userID, audioId); err != nil {
+		tx.Rollback()
+		if errors.Is(err, sql.ErrNoRows) {
+			return audio, storage.NotOwner
+		}
+		return audio, err
+	}
+
+	query = fmt.Sprintf(`UPDATE %s SET title = $1, duration = $2, description = $3, artist = $4, album = $5,
+						recorded_at = $6, language = $7, location = $8, custom = $9,
+						updated_at = now(), version = version + 1
+						WHERE user_id = $10 and audio_id = $11 and version = $12
+						RETURNING %s`, audiosTable, audioColumns)
+	err = tx.Get(&audio, query, metadata.Title, metadata.Duration, metadata.Description, metadata.Artist, metadata.Album,
+		metadata.RecordedAt, metadata.Language, metadata.Location, metadata.Custom, userID, audioId, version)
 	if err != nil {
-		return err
+		tx.Rollback()
+		if errors.Is(err, sql.ErrNoRows) {
+			return audio, storage.VersionMismatch
+		}
+		return audio, err
 	}
 
-	if rowsAff, err := result.RowsAffected(); rowsAff == 0 && err == nil {
-		return storage.NotOwner
+	changes, err := metadataChanges(old, metadata)
+	if err != nil {
+		tx.Rollback()
+		return audio, err
 	}
 
-	return err
+	query = fmt.Sprintf("INSERT INTO %s (audio_id, user_id, field, old_value, new_value) VALUES ($1, $2, $3, $4, $5)", historyTable)
+	for _, change := range changes {
+		if _, err := tx.Exec(query, audioId, userID, change.Field, change.OldValue, change.NewValue); err != nil {
+			tx.Rollback()
+			return audio, err
+		}
+	}
+
+	return audio, tx.Commit()
 }
 
-func (r *AudioPostgres) GetAudioList(userID int, input storage.AudioListParam) (storage.AudioListJson, error) {
+// metadataChanges lists fields that differ, values are formatted as text
+func metadataChanges(old, updated storage.AudioMetadata) ([]storage.HistoryRecord, error) {
+	oldCustom, err := json.Marshal(old.Custom)
+	if err != nil {
+		return nil, err
+	}
+	newCustom, err := json.Marshal(updated.Custom)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := []storage.HistoryRecord{
+		{Field: storage.HistoryFieldTitle, OldValue: old.Title, NewValue: updated.Title},
+		{Field: storage.HistoryFieldDuration, OldValue: strconv.Itoa(old.Duration), NewValue: strconv.Itoa(updated.Duration)},
+		{Field: storage.HistoryFieldDescription, OldValue: old.Description, NewValue: updated.Description},
+		{Field: storage.HistoryFieldArtist, OldValue: old.Artist, NewValue: updated.Artist},
+		{Field: storage.HistoryFieldAlbum, OldValue: old.Album, NewValue: updated.Album},
+		{Field: storage.HistoryFieldRecordedAt, OldValue: formatTime(old.RecordedAt), NewValue: formatTime(updated.RecordedAt)},
+		{Field: storage.HistoryFieldLanguage, OldValue: old.Language, NewValue: updated.Language},
+		{Field: storage.HistoryFieldLocation, OldValue: old.Location, NewValue: updated.Location},
+		{Field: storage.HistoryFieldCustom, OldValue: string(oldCustom), NewValue: string(newCustom)},
+	}
+
+	var changes []storage.HistoryRecord
+	for _, field := range fields {
+		if field.OldValue != field.NewValue {
+			changes = append(changes, field)
+		}
+	}
+
+	return changes, nil
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+
+	return t.UTC().Format(time.RFC3339)
+}
+
+func (r *AudioPostgres) GetAudioHistory(userID, audioId int, input storage.HistoryListParam) (storage.HistoryListJson, error) {
+	var exists bool
+	query := fmt.Sprintf("SELECT true FROM %s WHERE user_id = $1 and audio_id = $2", audiosTable)
+	if err := r.db.Get(&exists, query, userID, audioId); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return storage.HistoryListJson{}, storage.NotOwner
+		}
+		return storage.HistoryListJson{}, err
+	}
+
+	query = fmt.Sprintf(`SELECT count(*) OVER() AS full_count, h.field, h.old_value, h.new_value, h.user_id, u.name, h.changed_at
+						FROM %s h
+						JOIN %s u ON h.user_id = u.user_id
+						WHERE h.audio_id = $1
+						ORDER BY h.changed_at DESC, h.history_id DESC
+						OFFSET $2 LIMIT $3`, historyTable, usersTable)
+
+	records := make([]storage.HistoryRecord, 0)
+
+	var record storage.HistoryRecordDb
+	var totalCount int
+
+	rows, err := r.db.Queryx(query, audioId, input.Offset, input.Limit)
+	if err != nil {
+		return storage.HistoryListJson{}, err
+	}
+	for rows.Next() {
+
+		err := rows.StructScan(&record)
+		if err != nil {
+			return storage.HistoryListJson{}, err
+		}
+		totalCount = record.Count
+		records = append(records, record.HistoryRecord)
+	}
+
+	return storage.HistoryListJson{TotalCount: totalCount, Records: records}, err
+}
+
+// audioSortColumns whitelists sort param values
+var audioSortColumns = map[string]string{
+	"created_at": "created_at",
+	"updated_at": "updated_at",
+	"duration":   "duration",
+	"size":       "size",
+	"title":      "title",
+}
+
+// audioListOrder returns stable order for list param, audio_id breaks ties
+func audioListOrder(input storage.AudioListParam) (sortOrder, error) {
+	id := sortKey{alias: "audio_id", expr: "audio_id"}
+
+	if input.Sort == "" {
+		switch input.OrderType {
+		case "owner", "":
+			return sortOrder{keys: []sortKey{
+				{alias: "is_owner", expr: "(user_id = $1)", desc: true},
+				{alias: "name", expr: "name"},
+				{alias: "title", expr: "title"},
+				id,
+			}}, nil
+		case "alphabet":
+			return sortOrder{keys: []sortKey{{alias: "title", expr: "title"}, id}}, nil
+		default:
+			return sortOrder{}, errors.New("unknown order type")
+		}
+	}
+
+	column, ok := audioSortColumns[input.Sort]
+	if !ok {
+		return sortOrder{}, errors.New("unknown order type")
+	}
+
+	desc := input.Direction == "desc" || (input.Direction == "" && input.Sort != "title")
+	id.desc = desc
+
+	return sortOrder{keys: []sortKey{{alias: column, expr: column, desc: desc}, id}}, nil
+}
+
+// audioSortValues returns values of order keys for audio
+func audioSortValues(order sortOrder, audio storage.AudioList, keys storage.AudioSortKeys) []interface{} {
+	columns := map[string]interface{}{
+		"audio_id":   audio.Id,
+		"is_owner":   audio.IsOwner,
+		"name":       audio.Name,
+		"title":      audio.Title,
+		"duration":   keys.Duration,
+		"size":       keys.Size,
+		"created_at": audio.CreatedAt,
+		"updated_at": audio.UpdatedAt,
+	}
+
+	values := make([]interface{}, len(order.keys))
+	for i, key := range order.keys {
+		values[i] = columns[key.alias]
+	}
+
+	return values
+}
+
+// audioListFilter adds conditions of list param to query builder, userID
+// must already be the first argument
+func audioListFilter(b *queryBuilder, input storage.AudioListParam) {
+	b.where(fmt.Sprintf("(user_id = $1 OR audio_id IN (SELECT audio_id FROM %s WHERE user_id = $1 AND status = 'accepted'))", sharesTable))
+
+	// only owner tags and own personal tags are matched, tags other
+	// recipients put on the same audio are private to them
+	if len(input.Tags) > 0 {
+		minMatches := 1
+		if input.TagMode != storage.TagModeOr {
+			minMatches = len(input.Tags)
+		}
+		b.where(fmt.Sprintf(`audio_id IN (SELECT t.audio_id FROM %s t
+							JOIN %s ta ON t.audio_id = ta.audio_id
+							WHERE t.tag = ANY(%s) AND t.user_id IN ($1, ta.user_id)
+							GROUP BY t.audio_id HAVING count(DISTINCT t.tag) >= %s)`,
+			tagsTable, audiosTable, b.arg(pq.Array(input.Tags)), b.arg(minMatches)))
+	}
 
-	var orderType string
-	if input.OrderType == "owner" {
-		orderType = "is_owner DESC, name, title"
-	} else if input.OrderType == "alphabet" {
-		orderType = "title"
-	} else {
-		return storage.AudioListJson{}, errors.New("unknown order type")
+	switch input.Owner {
+	case "me":
+		b.where("user_id = $1")
+	case "others":
+		b.where("user_id <> $1")
 	}
 
-	query := fmt.Sprintf(`SELECT full_count, audio_id, title, is_owner, o.user_id, o.name,
+	if input.OwnerId != nil {
+		b.where("user_id = " + b.arg(*input.OwnerId))
+	}
+
+	switch input.SharedState {
+	case "shared":
+		b.where(fmt.Sprintf("user_id = $1 AND EXISTS (SELECT 1 FROM %s s WHERE s.audio_id = a.audio_id AND s.status <> 'declined')", sharesTable))
+	case "private":
+		b.where(fmt.Sprintf("user_id = $1 AND NOT EXISTS (SELECT 1 FROM %s s WHERE s.audio_id = a.audio_id AND s.status <> 'declined')", sharesTable))
+	case "received":
+		b.where("user_id <> $1")
+	}
+
+	if input.Format != "" {
+		b.where("format = " + b.arg(input.Format))
+	}
+
+	if input.MinDuration != nil {
+		b.where("duration >= " + b.arg(*input.MinDuration))
+	}
+
+	if input.MaxDuration != nil {
+		b.where("duration <= " + b.arg(*input.MaxDuration))
+	}
+
+	if input.CreatedFrom != nil {
+		b.where("created_at >= " + b.arg(*input.CreatedFrom))
+	}
+
+	if input.CreatedTo != nil {
+		b.where("created_at <= " + b.arg(*input.CreatedTo))
+	}
+
+	if input.UpdatedFrom != nil {
+		b.where("updated_at >= " + b.arg(*input.UpdatedFrom))
+	}
+
+	if input.UpdatedTo != nil {
+		b.where("updated_at <= " + b.arg(*input.UpdatedTo))
+	}
+
+	texts := []struct{ column, value string }{
+		{"artist", input.Artist},
+		{"album", input.Album},
+		{"language", input.Language},
+		{"location", input.Location},
+	}
+	for _, text := range texts {
+		if text.value != "" {
+			b.where(fmt.Sprintf("lower(%s) = lower(%s)", text.column, b.arg(text.value)))
+		}
+	}
+
+	if input.RecordedFrom != nil {
+		b.where("recorded_at >= " + b.arg(*input.RecordedFrom))
+	}
+
+	if input.RecordedTo != nil {
+		b.where("recorded_at <= " + b.arg(*input.RecordedTo))
+	}
+
+	// custom values are compared as text so 1 matches both "1" and 1
+	for _, filter := range input.Custom {
+		key, value, _ := storage.SplitCustomFilter(filter)
+		b.where(fmt.Sprintf("custom ->> %s = %s", b.arg(key), b.arg(value)))
+	}
+}
+
+// audioListQuery returns list of audio with shares, conditions and paging
+// are built by caller
+func audioListQuery(conditions string, order sortOrder, fullCount, paging string) string {
+	return fmt.Sprintf(`SELECT full_count, o.audio_id, title, is_owner, o.user_id, o.name,
+						uploaded_by, created_at, updated_at, duration, size,
 						COALESCE(r.user_id, 0) AS shared_to_id, COALESCE(u.name, '') AS shared_to_name
 						FROM
 						(SELECT
-    						count(*) OVER() AS full_count, audio_id, title,
+    						%s AS full_count, audio_id, title,
     						CASE WHEN user_id = $1 THEN true ELSE false END AS is_owner,
-    						user_id, name
-						FROM %s
-						JOIN users USING (user_id)
-						WHERE user_id = $1
-						OR audio_id IN (SELECT audio_id FROM shares WHERE user_id = $1)
-						ORDER BY %[2]s
-						OFFSET $2 LIMIT $3) o
-						LEFT JOIN shares r USING (audio_id)
-						LEFT JOIN users u ON r.user_id = u.user_id
-						ORDER BY %[2]s`, audiosTable, orderType)
+    						user_id, name, uploaded_by, created_at, updated_at, duration, size
+						FROM %s a
+						JOIN %s USING (user_id)
+						WHERE %s
+						ORDER BY %s
+						%s) o
+						LEFT JOIN %s r ON o.audio_id = r.audio_id AND r.status = 'accepted'
+						LEFT JOIN %s u ON r.user_id = u.user_id
+						ORDER BY %s`, fullCount, audiosTable, usersTable, conditions, order.sql(""), paging, sharesTable, usersTable, order.sql("o."))
+}
+
+func (r *AudioPostgres) GetAudioList(userID int, input storage.AudioListParam) (storage.AudioListJson, error) {
+	order, err := audioListOrder(input)
+	if err != nil {
+		return storage.AudioListJson{}, err
+	}
+
+	b := newQueryBuilder(userID, input.Offset, input.Limit)
+	audioListFilter(b, input)
 
+	query := audioListQuery(b.conditionsSQL(), order, "count(*) OVER()", "OFFSET $2 LIMIT $3")
+
+	records, _, totalCount, err := r.queryAudioList(query, b.args)
+	if err != nil {
+		return storage.AudioListJson{}, err
+	}
+
+	return storage.AudioListJson{TotalCount: totalCount, Records: records}, nil
+}
+
+func (r *AudioPostgres) GetAudioPage(userID int, input storage.AudioListParam) (storage.AudioPageJson, error) {
+	order, err := audioListOrder(input)
+	if err != nil {
+		return storage.AudioPageJson{}, err
+	}
+
+	var c *cursor
+	if input.Cursor != "" {
+		decoded, err := decodeCursor(input.Cursor, order)
+		if err != nil {
+			return storage.AudioPageJson{}, err
+		}
+		c = &decoded
+	}
+
+	b := newQueryBuilder(userID)
+	audioListFilter(b, input)
+
+	var totalCount *int
+	if input.WithCount {
+		var count int
+		query := fmt.Sprintf("SELECT count(*) FROM %s a JOIN %s USING (user_id) WHERE %s", audiosTable, usersTable, b.conditionsSQL())
+		if err := r.db.Get(&count, query, b.args...); err != nil {
+			return storage.AudioPageJson{}, err
+		}
+		totalCount = &count
+	}
+
+	// going backward rows before cursor are fetched in reverse order
+	queryOrder := order
+	if c != nil {
+		if c.Prev {
+			queryOrder = order.reverse()
+		}
+		b.where(queryOrder.after(b, c.Values))
+	}
+
+	query := audioListQuery(b.conditionsSQL(), queryOrder, "0", "LIMIT "+b.arg(*input.Limit+1))
+
+	records, keys, _, err := r.queryAudioList(query, b.args)
+	if err != nil {
+		return storage.AudioPageJson{}, err
+	}
+
+	more := len(records) > *input.Limit
+	if more {
+		records, keys = records[:*input.Limit], keys[:*input.Limit]
+	}
+
+	if c != nil && c.Prev {
+		for i, j := 0, len(records)-1; i < j; i, j = i+1, j-1 {
+			records[i], records[j] = records[j], records[i]
+			keys[i], keys[j] = keys[j], keys[i]
+		}
+	}
+
+	result := storage.AudioPageJson{TotalCount: totalCount, Records: records}
+	if last := len(records) - 1; last >= 0 {
+		result.NextCursor, result.PrevCursor = pageCursors(order, c, more,
+			audioSortValues(order, records[0], keys[0]), audioSortValues(order, records[last], keys[last]))
+	}
+
+	return result, nil
+}
+
+// queryAudioList runs list query and groups shares of every audio
+func (r *AudioPostgres) queryAudioList(query string, args []interface{}) ([]storage.AudioList, []storage.AudioSortKeys, int, error) {
 	resultOut := make([]storage.AudioList, 0)
+	sortKeys := make([]storage.AudioSortKeys, 0)
 
 	var result storage.AudioListDb
 	var lastAudio storage.AudioList
 	var totalCount int
 
-	rows, err := r.db.Queryx(query, userID, input.Offset, input.Limit)
+	rows, err := r.db.Queryx(query, args...)
 	if err != nil {
-		return storage.AudioListJson{}, err
+		return nil, nil, 0, err
 	}
 	for rows.Next() {
 
 		err := rows.StructScan(&result)
 		if err != nil {
-			return storage.AudioListJson{}, err
+			return nil, nil, 0, err
 		}
 		totalCount = result.Count
 		if lastAudio == result.AudioList {
@@ -106,6 +479,7 @@ func (r *AudioPostgres) GetAudioList(userID int, input storage.AudioListParam) (
 			}
 		} else {
 			resultOut = append(resultOut, result.AudioList)
+			sortKeys = append(sortKeys, result.AudioSortKeys)
 			lastIndex := len(resultOut) - 1
 			emptyShare := storage.ShareList{}
 			if result.ShareList != emptyShare {
@@ -117,5 +491,5 @@ func (r *AudioPostgres) GetAudioList(userID int, input storage.AudioListParam) (
 		lastAudio = result.AudioList
 	}
 
-	return storage.AudioListJson{TotalCount: totalCount, Records: resultOut}, err
+	return resultOut, sortKeys, totalCount, rows.Err()
 }
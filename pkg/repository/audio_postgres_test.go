@@ -2,12 +2,16 @@ package repository
 
 import (
 	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
 	"errors"
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 	storage "github.com/mahadeva604/audio-storage"
 	"github.com/stretchr/testify/assert"
 	"testing"
+	"time"
 )
 
 func TestAudioPostgres_UploadFile(t *testing.T) {
@@ -25,6 +29,7 @@ func TestAudioPostgres_UploadFile(t *testing.T) {
 		name            string
 		userId          int
 		path            string
+		metadata        storage.AudioMetadata
 		mockBehavior    mockBehavior
 		expectedAudioId int
 		expectErr       bool
@@ -35,7 +40,18 @@ func TestAudioPostgres_UploadFile(t *testing.T) {
 			path:   "file_path",
 			mockBehavior: func(userId int, path string, audioId int) {
 				rows := sqlmock.NewRows([]string{"audio_id"}).AddRow(audioId)
-				mock.ExpectQuery("INSERT INTO audios").WithArgs(userId, path).WillReturnRows(rows)
+				mock.ExpectQuery("INSERT INTO audios").WithArgs(userId, "", "", "", path, 1024, storage.FormatAac).WillReturnRows(rows)
+			},
+			expectedAudioId: 2,
+		},
+		{
+			name:     "OK with tags",
+			userId:   1,
+			path:     "file_path",
+			metadata: storage.AudioMetadata{Title: "title", Artist: "artist", Album: "album"},
+			mockBehavior: func(userId int, path string, audioId int) {
+				rows := sqlmock.NewRows([]string{"audio_id"}).AddRow(audioId)
+				mock.ExpectQuery("INSERT INTO audios").WithArgs(userId, "title", "artist", "album", path, 1024, storage.FormatAac).WillReturnRows(rows)
 			},
 			expectedAudioId: 2,
 		},
@@ -44,7 +60,7 @@ func TestAudioPostgres_UploadFile(t *testing.T) {
 			userId:    1,
 			expectErr: true,
 			mockBehavior: func(userId int, path string, audioId int) {
-				mock.ExpectQuery("INSERT INTO audios").WithArgs(userId, path).WillReturnError(errors.New("path is empty"))
+				mock.ExpectQuery("INSERT INTO audios").WithArgs(userId, "", "", "", path, 1024, storage.FormatAac).WillReturnError(errors.New("path is empty"))
 			},
 		},
 	}
@@ -53,7 +69,7 @@ func TestAudioPostgres_UploadFile(t *testing.T) {
 		t.Run(testCase.name, func(t *testing.T) {
 			testCase.mockBehavior(testCase.userId, testCase.path, testCase.expectedAudioId)
 
-			gotAudioId, err := r.UploadFile(testCase.userId, testCase.path)
+			gotAudioId, err := r.UploadFile(testCase.userId, testCase.path, 1024, testCase.metadata)
 			if testCase.expectErr {
 				assert.Error(t, err)
 			} else {
@@ -94,11 +110,13 @@ func TestAudioPostgres_DownloadFile(t *testing.T) {
 			title:    "title 1",
 			filePath: "file path 1",
 			mockBehavior: func(userId int, audioId int, title string, filePath string) {
-				rows := sqlmock.NewRows([]string{"title", "file_path"}).AddRow(title, filePath)
-				mock.ExpectQuery(`SELECT (.+) FROM audios a LEFT JOIN shares r USING \(audio_id\) WHERE (.+)`).WithArgs(audioId, userId).WillReturnRows(rows)
+				rows := sqlmock.NewRows([]string{"title", "artist", "album", "file_path"}).AddRow(title, "artist 1", "album 1", filePath)
+				mock.ExpectQuery(`SELECT (.+) FROM audios a WHERE audio_id = \$1 AND EXISTS \(SELECT 1 FROM audio_access (.+)\)`).WithArgs(audioId, userId).WillReturnRows(rows)
 			},
 			expectedAudioData: storage.DownloadAudio{
 				Title:    "title 1",
+				Artist:   "artist 1",
+				Album:    "album 1",
 				FilePath: "file path 1",
 			},
 		},
@@ -109,7 +127,7 @@ func TestAudioPostgres_DownloadFile(t *testing.T) {
 			expectErr:     true,
 			expectErrType: storage.FileNotFound,
 			mockBehavior: func(userId int, audioId int, title string, filePath string) {
-				mock.ExpectQuery(`SELECT (.+) FROM audios a LEFT JOIN shares r USING \(audio_id\) WHERE (.+)`).WithArgs(audioId, userId).WillReturnError(sql.ErrNoRows)
+				mock.ExpectQuery(`SELECT (.+) FROM audios a WHERE audio_id = \$1 AND EXISTS \(SELECT 1 FROM audio_access (.+)\)`).WithArgs(audioId, userId).WillReturnError(sql.ErrNoRows)
 			},
 		},
 		{
@@ -118,7 +136,7 @@ func TestAudioPostgres_DownloadFile(t *testing.T) {
 			audioId:   2,
 			expectErr: true,
 			mockBehavior: func(userId int, audioId int, title string, filePath string) {
-				mock.ExpectQuery(`SELECT (.+) FROM audios a LEFT JOIN shares r USING \(audio_id\) WHERE (.+)`).WithArgs(audioId, userId).WillReturnError(errors.New("other error"))
+				mock.ExpectQuery(`SELECT (.+) FROM audios a WHERE audio_id = \$1 AND EXISTS \(SELECT 1 FROM audio_access (.+)\)`).WithArgs(audioId, userId).WillReturnError(errors.New("other error"))
 			},
 		},
 	}
@@ -142,6 +160,63 @@ func TestAudioPostgres_DownloadFile(t *testing.T) {
 	}
 }
 
+// audioRows returns row of storage.Audio columns with given metadata
+func audioRows(audioId, userId int, metadata storage.AudioMetadata, at time.Time, version int) *sqlmock.Rows {
+	custom, _ := metadata.Custom.Value()
+	return sqlmock.NewRows([]string{"audio_id", "user_id", "title", "duration", "description", "artist", "album", "recorded_at",
+		"language", "location", "custom", "uploaded_by", "created_at", "updated_at", "version"}).
+		AddRow(audioId, userId, metadata.Title, metadata.Duration, metadata.Description, metadata.Artist, metadata.Album, metadata.RecordedAt,
+			metadata.Language, metadata.Location, custom, userId, at, at, version)
+}
+
+func TestAudioPostgres_GetAudio(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+	db := sqlx.NewDb(mockDB, "sqlmock")
+
+	r := NewAudioPostgres(db)
+
+	at := time.Date(2021, 6, 1, 10, 0, 0, 0, time.UTC)
+	query := `SELECT (.+) FROM audios WHERE audio_id = \$1 AND audio_id IN \(SELECT audio_id FROM audio_access WHERE user_id = \$2\)`
+	metadata := storage.AudioMetadata{
+		Title:       "audio 2",
+		Duration:    67,
+		Description: "description",
+		Artist:      "artist",
+		RecordedAt:  &at,
+		Language:    "en",
+		Custom:      storage.CustomFields{"episode": "12"},
+	}
+
+	t.Run("OK", func(t *testing.T) {
+		mock.ExpectQuery(query).WithArgs(2, 1).WillReturnRows(audioRows(2, 3, metadata, at, 5))
+
+		audio, err := r.GetAudio(1, 2)
+		assert.NoError(t, err)
+		assert.Equal(t, storage.Audio{
+			Id:            2,
+			UserId:        3,
+			AudioMetadata: metadata,
+			UploadedBy:    3,
+			CreatedAt:     at,
+			UpdatedAt:     at,
+			Version:       5,
+		}, audio)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Error not found", func(t *testing.T) {
+		mock.ExpectQuery(query).WithArgs(2, 1).WillReturnError(sql.ErrNoRows)
+
+		_, err := r.GetAudio(1, 2)
+		assert.Equal(t, storage.FileNotFound, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
 func TestAudioPostgres_AddDescription(t *testing.T) {
 	mockDB, mock, err := sqlmock.New()
 	if err != nil {
@@ -151,56 +226,104 @@ func TestAudioPostgres_AddDescription(t *testing.T) {
 	db := sqlx.NewDb(mockDB, "sqlmock")
 
 	r := NewAudioPostgres(db)
-	type mockBehavior func(userId int, audioId int, input storage.UpdateAudio)
+	type mockBehavior func(userId, audioId int, metadata storage.AudioMetadata)
 
-	tittle := "title 1"
-	duration := 77
+	at := time.Date(2021, 6, 1, 10, 0, 0, 0, time.UTC)
+	old := storage.AudioMetadata{Title: "old title", Duration: 77, Custom: storage.CustomFields{}}
+	metadata := storage.AudioMetadata{
+		Title:      "title 1",
+		Duration:   77,
+		RecordedAt: &at,
+		Custom:     storage.CustomFields{"episode": "12"},
+	}
+
+	selectQuery := "SELECT title, duration, description, artist, album, recorded_at, language, location, custom FROM audios WHERE (.+) FOR UPDATE"
+	updateQuery := `UPDATE audios SET (.+) WHERE user_id = \$10 and audio_id = \$11 and version = \$12 RETURNING (.+)`
+	oldRows := func(m storage.AudioMetadata) *sqlmock.Rows {
+		custom, _ := m.Custom.Value()
+		return sqlmock.NewRows([]string{"title", "duration", "description", "artist", "album", "recorded_at", "language", "location", "custom"}).
+			AddRow(m.Title, m.Duration, m.Description, m.Artist, m.Album, m.RecordedAt, m.Language, m.Location, custom)
+	}
+	updateArgs := func(userId, audioId int, m storage.AudioMetadata) []driver.Value {
+		return []driver.Value{m.Title, m.Duration, m.Description, m.Artist, m.Album, m.RecordedAt, m.Language, m.Location, m.Custom, userId, audioId, 3}
+	}
 
 	testTable := []struct {
 		name            string
 		userId          int
 		audioId         int
-		input           storage.UpdateAudio
+		metadata        storage.AudioMetadata
 		mockBehavior    mockBehavior
+		expectedData    storage.Audio
 		expectedErr     bool
 		expectedErrType error
 	}{
 		{
-			name:    "OK",
-			userId:  1,
-			audioId: 1,
-			input: storage.UpdateAudio{
-				Title:    &tittle,
-				Duration: &duration,
-			},
-			mockBehavior: func(userId int, audioId int, input storage.UpdateAudio) {
-				mock.ExpectExec("UPDATE audios SET (.+) WHERE (.+)").WithArgs(input.Title, input.Duration, userId, audioId).WillReturnResult(sqlmock.NewResult(0, 1))
+			name:     "OK",
+			userId:   1,
+			audioId:  1,
+			metadata: metadata,
+			mockBehavior: func(userId, audioId int, metadata storage.AudioMetadata) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(selectQuery).WithArgs(userId, audioId).WillReturnRows(oldRows(old))
+				mock.ExpectQuery(updateQuery).WithArgs(updateArgs(userId, audioId, metadata)...).WillReturnRows(audioRows(audioId, userId, metadata, at, 4))
+				mock.ExpectExec("INSERT INTO audio_history").WithArgs(audioId, userId, storage.HistoryFieldTitle, "old title", "title 1").WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("INSERT INTO audio_history").WithArgs(audioId, userId, storage.HistoryFieldRecordedAt, "", "2021-06-01T10:00:00Z").WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("INSERT INTO audio_history").WithArgs(audioId, userId, storage.HistoryFieldCustom, "{}", `{"episode":"12"}`).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
 			},
+			expectedData: storage.Audio{Id: 1, UserId: 1, AudioMetadata: metadata, UploadedBy: 1, CreatedAt: at, UpdatedAt: at, Version: 4},
 		},
 		{
-			name:    "Error not updated",
-			userId:  1,
-			audioId: 1,
-			input: storage.UpdateAudio{
-				Title:    &tittle,
-				Duration: &duration,
+			name:     "OK nothing changed",
+			userId:   1,
+			audioId:  1,
+			metadata: metadata,
+			mockBehavior: func(userId, audioId int, metadata storage.AudioMetadata) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(selectQuery).WithArgs(userId, audioId).WillReturnRows(oldRows(metadata))
+				mock.ExpectQuery(updateQuery).WithArgs(updateArgs(userId, audioId, metadata)...).WillReturnRows(audioRows(audioId, userId, metadata, at, 4))
+				mock.ExpectCommit()
 			},
-			mockBehavior: func(userId int, audioId int, input storage.UpdateAudio) {
-				mock.ExpectExec("UPDATE audios SET (.+) WHERE (.+)").WithArgs(input.Title, input.Duration, userId, audioId).WillReturnResult(sqlmock.NewResult(0, 0))
+			expectedData: storage.Audio{Id: 1, UserId: 1, AudioMetadata: metadata, UploadedBy: 1, CreatedAt: at, UpdatedAt: at, Version: 4},
+		},
+		{
+			name:     "Error not owner",
+			userId:   1,
+			audioId:  1,
+			metadata: metadata,
+			mockBehavior: func(userId, audioId int, metadata storage.AudioMetadata) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(selectQuery).WithArgs(userId, audioId).WillReturnError(sql.ErrNoRows)
+				mock.ExpectRollback()
 			},
 			expectedErr:     true,
 			expectedErrType: storage.NotOwner,
 		},
 		{
-			name:    "Error",
-			userId:  1,
-			audioId: 1,
-			input: storage.UpdateAudio{
-				Title:    &tittle,
-				Duration: &duration,
+			name:     "Error version mismatch",
+			userId:   1,
+			audioId:  1,
+			metadata: metadata,
+			mockBehavior: func(userId, audioId int, metadata storage.AudioMetadata) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(selectQuery).WithArgs(userId, audioId).WillReturnRows(oldRows(old))
+				mock.ExpectQuery(updateQuery).WithArgs(updateArgs(userId, audioId, metadata)...).WillReturnError(sql.ErrNoRows)
+				mock.ExpectRollback()
 			},
-			mockBehavior: func(userId int, audioId int, input storage.UpdateAudio) {
-				mock.ExpectExec("UPDATE audios SET (.+) WHERE (.+)").WithArgs(input.Title, input.Duration, userId, audioId).WillReturnError(errors.New("some error"))
+			expectedErr:     true,
+			expectedErrType: storage.VersionMismatch,
+		},
+		{
+			name:     "Error",
+			userId:   1,
+			audioId:  1,
+			metadata: metadata,
+			mockBehavior: func(userId, audioId int, metadata storage.AudioMetadata) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(selectQuery).WithArgs(userId, audioId).WillReturnRows(oldRows(old))
+				mock.ExpectQuery(updateQuery).WithArgs(updateArgs(userId, audioId, metadata)...).WillReturnError(errors.New("some error"))
+				mock.ExpectRollback()
 			},
 			expectedErr: true,
 		},
@@ -208,9 +331,9 @@ func TestAudioPostgres_AddDescription(t *testing.T) {
 
 	for _, testCase := range testTable {
 		t.Run(testCase.name, func(t *testing.T) {
-			testCase.mockBehavior(testCase.userId, testCase.audioId, testCase.input)
+			testCase.mockBehavior(testCase.userId, testCase.audioId, testCase.metadata)
 
-			err = r.AddDescription(testCase.userId, testCase.audioId, testCase.input)
+			audio, err := r.AddDescription(testCase.userId, testCase.audioId, 3, testCase.metadata)
 			if testCase.expectedErr {
 				assert.Error(t, err)
 				if testCase.expectedErrType != nil {
@@ -218,12 +341,57 @@ func TestAudioPostgres_AddDescription(t *testing.T) {
 				}
 			} else {
 				assert.NoError(t, err)
+				assert.Equal(t, testCase.expectedData, audio)
 			}
 			assert.NoError(t, mock.ExpectationsWereMet())
 		})
 	}
 }
 
+func TestAudioPostgres_GetAudioHistory(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+	db := sqlx.NewDb(mockDB, "sqlmock")
+
+	r := NewAudioPostgres(db)
+
+	offset, limit := 0, 10
+	input := storage.HistoryListParam{Offset: &offset, Limit: &limit}
+	at := time.Date(2021, 6, 1, 10, 0, 0, 0, time.UTC)
+
+	t.Run("OK", func(t *testing.T) {
+		mock.ExpectQuery("SELECT true FROM audios WHERE (.+)").WithArgs(1, 2).
+			WillReturnRows(sqlmock.NewRows([]string{"bool"}).AddRow(true))
+		rows := sqlmock.NewRows([]string{"full_count", "field", "old_value", "new_value", "user_id", "name", "changed_at"}).
+			AddRow(2, "title", "old", "new", 1, "user 1", at).
+			AddRow(2, "duration", "0", "77", 1, "user 1", at)
+		mock.ExpectQuery(`SELECT (.+) FROM audio_history h JOIN users u (.+) ORDER BY h.changed_at DESC, h.history_id DESC`).
+			WithArgs(2, &offset, &limit).WillReturnRows(rows)
+
+		history, err := r.GetAudioHistory(1, 2, input)
+		assert.NoError(t, err)
+		assert.Equal(t, storage.HistoryListJson{
+			TotalCount: 2,
+			Records: []storage.HistoryRecord{
+				{Field: "title", OldValue: "old", NewValue: "new", UserId: 1, Name: "user 1", ChangedAt: at},
+				{Field: "duration", OldValue: "0", NewValue: "77", UserId: 1, Name: "user 1", ChangedAt: at},
+			},
+		}, history)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Error not owner", func(t *testing.T) {
+		mock.ExpectQuery("SELECT true FROM audios WHERE (.+)").WithArgs(1, 2).WillReturnError(sql.ErrNoRows)
+
+		_, err := r.GetAudioHistory(1, 2, input)
+		assert.Equal(t, storage.NotOwner, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
 func TestAudioPostgres_GetAudioList(t *testing.T) {
 	mockDB, mock, err := sqlmock.New()
 	if err != nil {
@@ -236,6 +404,9 @@ func TestAudioPostgres_GetAudioList(t *testing.T) {
 	type mockBehavior func(userId int, input storage.AudioListParam)
 
 	offset, limit := 0, 1
+	ownerId, minDuration, maxDuration := 2, 10, 600
+	createdFrom := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	updatedTo := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
 
 	testTable := []struct {
 		name          string
@@ -255,7 +426,7 @@ func TestAudioPostgres_GetAudioList(t *testing.T) {
 				OrderType: "owner",
 			},
 			mockBehavior: func(userId int, input storage.AudioListParam) {
-				query := `SELECT (.+) FROM \(SELECT (.+) FROM audios (.+) ORDER BY is_owner DESC, name, title OFFSET \$2 LIMIT \$3\) (.+)  ORDER BY is_owner DESC, name, title`
+				query := `SELECT (.+) FROM \(SELECT (.+) FROM audios (.+) ORDER BY is_owner DESC, name, title, audio_id OFFSET \$2 LIMIT \$3\) (.+)  ORDER BY o.is_owner DESC, o.name, o.title, o.audio_id`
 				rows := sqlmock.NewRows([]string{"full_count", "audio_id", "title", "is_owner", "user_id", "name", "shared_to_id", "shared_to_name"}).
 					AddRow(10, 1, "audio 1", true, 1, "user 1", 2, "user 2").
 					AddRow(10, 1, "audio 1", true, 1, "user 1", 3, "user 3").
@@ -316,7 +487,7 @@ func TestAudioPostgres_GetAudioList(t *testing.T) {
 				OrderType: "alphabet",
 			},
 			mockBehavior: func(userId int, input storage.AudioListParam) {
-				query := `SELECT (.+) FROM \(SELECT (.+) FROM audios (.+) ORDER BY title OFFSET \$2 LIMIT \$3\) (.+)  ORDER BY title`
+				query := `SELECT (.+) FROM \(SELECT (.+) FROM audios (.+) ORDER BY title, audio_id OFFSET \$2 LIMIT \$3\) (.+)  ORDER BY o.title, o.audio_id`
 				rows := sqlmock.NewRows([]string{"full_count", "audio_id", "title", "is_owner", "user_id", "name", "shared_to_id", "shared_to_name"}).
 					AddRow(10, 1, "audio 1", true, 1, "user 1", 2, "user 2").
 					AddRow(10, 1, "audio 1", true, 1, "user 1", 3, "user 3").
@@ -368,6 +539,146 @@ func TestAudioPostgres_GetAudioList(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:   "OK all tags",
+			userId: 1,
+			input: storage.AudioListParam{
+				Offset:    &offset,
+				Limit:     &limit,
+				OrderType: "alphabet",
+				Tags:      []string{"jazz", "live"},
+			},
+			mockBehavior: func(userId int, input storage.AudioListParam) {
+				query := `SELECT (.+) FROM \(SELECT (.+) FROM audios (.+) AND audio_id IN \(SELECT t.audio_id FROM audio_tags t (.+) HAVING count\(DISTINCT t.tag\) >= \$5\) ORDER BY title, audio_id OFFSET \$2 LIMIT \$3\) (.+)  ORDER BY o.title, o.audio_id`
+				rows := sqlmock.NewRows([]string{"full_count", "audio_id", "title", "is_owner", "user_id", "name", "shared_to_id", "shared_to_name"}).
+					AddRow(1, 2, "audio 2", true, 1, "user 1", 0, "")
+				mock.ExpectQuery(query).WithArgs(userId, input.Offset, input.Limit, pq.Array(input.Tags), 2).WillReturnRows(rows)
+			},
+			expectData: storage.AudioListJson{
+				TotalCount: 1,
+				Records: []storage.AudioList{
+					{
+						Id:      2,
+						Title:   "audio 2",
+						IsOwner: true,
+						Owner:   1,
+						Name:    "user 1",
+					},
+				},
+			},
+		},
+		{
+			name:   "OK any tag",
+			userId: 1,
+			input: storage.AudioListParam{
+				Offset:    &offset,
+				Limit:     &limit,
+				OrderType: "alphabet",
+				Tags:      []string{"jazz", "live"},
+				TagMode:   storage.TagModeOr,
+			},
+			mockBehavior: func(userId int, input storage.AudioListParam) {
+				query := `SELECT (.+) FROM \(SELECT (.+) FROM audios (.+) AND audio_id IN \(SELECT t.audio_id FROM audio_tags t (.+) HAVING count\(DISTINCT t.tag\) >= \$5\) ORDER BY title, audio_id OFFSET \$2 LIMIT \$3\) (.+)  ORDER BY o.title, o.audio_id`
+				rows := sqlmock.NewRows([]string{"full_count", "audio_id", "title", "is_owner", "user_id", "name", "shared_to_id", "shared_to_name"}).
+					AddRow(1, 2, "audio 2", true, 1, "user 1", 0, "")
+				mock.ExpectQuery(query).WithArgs(userId, input.Offset, input.Limit, pq.Array(input.Tags), 1).WillReturnRows(rows)
+			},
+			expectData: storage.AudioListJson{
+				TotalCount: 1,
+				Records: []storage.AudioList{
+					{
+						Id:      2,
+						Title:   "audio 2",
+						IsOwner: true,
+						Owner:   1,
+						Name:    "user 1",
+					},
+				},
+			},
+		},
+		{
+			name:   "OK filters and sort",
+			userId: 1,
+			input: storage.AudioListParam{
+				Offset:      &offset,
+				Limit:       &limit,
+				Sort:        "size",
+				Direction:   "asc",
+				OwnerId:     &ownerId,
+				SharedState: "received",
+				Format:      storage.FormatAac,
+				MinDuration: &minDuration,
+				MaxDuration: &maxDuration,
+				CreatedFrom: &createdFrom,
+				UpdatedTo:   &updatedTo,
+			},
+			mockBehavior: func(userId int, input storage.AudioListParam) {
+				query := `SELECT (.+) FROM \(SELECT (.+) FROM audios a (.+) WHERE \(user_id = \$1 OR (.+)\) AND user_id = \$4 AND user_id <> \$1 ` +
+					`AND format = \$5 AND duration >= \$6 AND duration <= \$7 AND created_at >= \$8 AND updated_at <= \$9 ` +
+					`ORDER BY size, audio_id OFFSET \$2 LIMIT \$3\) (.+) ORDER BY o.size, o.audio_id`
+				rows := sqlmock.NewRows([]string{"full_count", "audio_id", "title", "is_owner", "user_id", "name", "shared_to_id", "shared_to_name"}).
+					AddRow(1, 3, "audio 3", false, 2, "user 2", 1, "user 1")
+				mock.ExpectQuery(query).WithArgs(userId, input.Offset, input.Limit, ownerId, storage.FormatAac, minDuration, maxDuration, createdFrom, updatedTo).WillReturnRows(rows)
+			},
+			expectData: storage.AudioListJson{
+				TotalCount: 1,
+				Records: []storage.AudioList{
+					{
+						Id:      3,
+						Title:   "audio 3",
+						IsOwner: false,
+						Owner:   2,
+						Name:    "user 2",
+						Shares: &[]storage.ShareList{
+							{
+								UserId: 1,
+								Name:   "user 1",
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:   "OK metadata filters",
+			userId: 1,
+			input: storage.AudioListParam{
+				Offset:       &offset,
+				Limit:        &limit,
+				Artist:       "Artist",
+				Language:     "en",
+				RecordedFrom: &createdFrom,
+				Custom:       []string{"episode:12", "guest:a:b"},
+			},
+			mockBehavior: func(userId int, input storage.AudioListParam) {
+				query := `SELECT (.+) FROM \(SELECT (.+) WHERE (.+) AND lower\(artist\) = lower\(\$4\) AND lower\(language\) = lower\(\$5\) ` +
+					`AND recorded_at >= \$6 AND custom ->> \$7 = \$8 AND custom ->> \$9 = \$10 ORDER BY (.+)`
+				rows := sqlmock.NewRows([]string{"full_count", "audio_id", "title", "is_owner", "user_id", "name", "shared_to_id", "shared_to_name"})
+				mock.ExpectQuery(query).WithArgs(userId, input.Offset, input.Limit, "Artist", "en", createdFrom, "episode", "12", "guest", "a:b").WillReturnRows(rows)
+			},
+			expectData: storage.AudioListJson{
+				Records: []storage.AudioList{},
+			},
+		},
+		{
+			name:   "OK shared by me newest first",
+			userId: 1,
+			input: storage.AudioListParam{
+				Offset:      &offset,
+				Limit:       &limit,
+				Sort:        "created_at",
+				SharedState: "shared",
+			},
+			mockBehavior: func(userId int, input storage.AudioListParam) {
+				query := `SELECT (.+) FROM \(SELECT (.+) WHERE (.+) AND user_id = \$1 AND EXISTS \(SELECT 1 FROM shares s (.+)\) ` +
+					`ORDER BY created_at DESC, audio_id DESC OFFSET \$2 LIMIT \$3\) (.+) ORDER BY o.created_at DESC, o.audio_id DESC`
+				rows := sqlmock.NewRows([]string{"full_count", "audio_id", "title", "is_owner", "user_id", "name", "shared_to_id", "shared_to_name"})
+				mock.ExpectQuery(query).WithArgs(userId, input.Offset, input.Limit).WillReturnRows(rows)
+			},
+			expectData: storage.AudioListJson{
+				Records: []storage.AudioList{},
+			},
+		},
 		{
 			name:   "Error query",
 			userId: 1,
@@ -377,7 +688,7 @@ func TestAudioPostgres_GetAudioList(t *testing.T) {
 				OrderType: "owner",
 			},
 			mockBehavior: func(userId int, input storage.AudioListParam) {
-				query := `SELECT (.+) FROM \(SELECT (.+) FROM audios (.+) ORDER BY is_owner DESC, name, title OFFSET \$2 LIMIT \$3\) (.+)  ORDER BY is_owner DESC, name, title`
+				query := `SELECT (.+) FROM \(SELECT (.+) FROM audios (.+) ORDER BY is_owner DESC, name, title, audio_id OFFSET \$2 LIMIT \$3\) (.+)  ORDER BY o.is_owner DESC, o.name, o.title, o.audio_id`
 				mock.ExpectQuery(query).WithArgs(userId, input.Offset, input.Limit).WillReturnError(errors.New("query error"))
 			},
 			expectErr:     true,
@@ -405,7 +716,7 @@ func TestAudioPostgres_GetAudioList(t *testing.T) {
 				OrderType: "owner",
 			},
 			mockBehavior: func(userId int, input storage.AudioListParam) {
-				query := `SELECT (.+) FROM \(SELECT (.+) FROM audios (.+) ORDER BY is_owner DESC, name, title OFFSET \$2 LIMIT \$3\) (.+)  ORDER BY is_owner DESC, name, title`
+				query := `SELECT (.+) FROM \(SELECT (.+) FROM audios (.+) ORDER BY is_owner DESC, name, title, audio_id OFFSET \$2 LIMIT \$3\) (.+)  ORDER BY o.is_owner DESC, o.name, o.title, o.audio_id`
 				rows := sqlmock.NewRows([]string{"wrong_row"}).
 					AddRow("wrong_row")
 				mock.ExpectQuery(query).WithArgs(userId, input.Offset, input.Limit).WillReturnRows(rows)
@@ -432,3 +743,90 @@ func TestAudioPostgres_GetAudioList(t *testing.T) {
 		})
 	}
 }
+
+func TestAudioPostgres_GetAudioPage(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+	db := sqlx.NewDb(mockDB, "sqlmock")
+
+	r := NewAudioPostgres(db)
+
+	limit := 2
+	order, _ := audioListOrder(storage.AudioListParam{OrderType: "alphabet"})
+	columns := []string{"full_count", "audio_id", "title", "is_owner", "user_id", "name", "uploaded_by", "created_at", "updated_at", "duration", "size", "shared_to_id", "shared_to_name"}
+	at := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("OK first page", func(t *testing.T) {
+		query := `SELECT (.+) FROM \(SELECT 0 AS full_count, (.+) WHERE \(user_id = \$1 OR (.+)\) ORDER BY title, audio_id LIMIT \$2\) (.+) ORDER BY o.title, o.audio_id`
+		rows := sqlmock.NewRows(columns).
+			AddRow(0, 1, "audio 1", true, 1, "user 1", 1, at, at, 10, 100, 2, "user 2").
+			AddRow(0, 1, "audio 1", true, 1, "user 1", 1, at, at, 10, 100, 3, "user 3").
+			AddRow(0, 2, "audio 2", true, 1, "user 1", 1, at, at, 10, 100, 0, "").
+			AddRow(0, 3, "audio 3", false, 2, "user 2", 2, at, at, 10, 100, 1, "user 1")
+		mock.ExpectQuery(query).WithArgs(1, limit+1).WillReturnRows(rows)
+
+		page, err := r.GetAudioPage(1, storage.AudioListParam{Limit: &limit, OrderType: "alphabet"})
+		assert.NoError(t, err)
+		assert.Equal(t, storage.AudioPageJson{
+			Records: []storage.AudioList{
+				{Id: 1, Title: "audio 1", IsOwner: true, Owner: 1, Name: "user 1", UploadedBy: 1, CreatedAt: at, UpdatedAt: at, Shares: &[]storage.ShareList{{UserId: 2, Name: "user 2"}, {UserId: 3, Name: "user 3"}}},
+				{Id: 2, Title: "audio 2", IsOwner: true, Owner: 1, Name: "user 1", UploadedBy: 1, CreatedAt: at, UpdatedAt: at},
+			},
+			NextCursor: encodeCursor(order, []interface{}{"audio 2", 2}, false),
+		}, page)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("OK next page with count", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT count\(\*\) FROM audios a JOIN users USING \(user_id\) WHERE (.+)`).WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+		query := `SELECT (.+) WHERE (.+) AND \(\(title > \$2\) OR \(title = \$2 AND audio_id > \$3\)\) ORDER BY title, audio_id LIMIT \$4\) (.+) ORDER BY o.title, o.audio_id`
+		rows := sqlmock.NewRows(columns).
+			AddRow(0, 3, "audio 3", false, 2, "user 2", 2, at, at, 10, 100, 1, "user 1")
+		mock.ExpectQuery(query).WithArgs(1, "audio 2", json.Number("2"), limit+1).WillReturnRows(rows)
+
+		cursor := encodeCursor(order, []interface{}{"audio 2", 2}, false)
+		page, err := r.GetAudioPage(1, storage.AudioListParam{Limit: &limit, OrderType: "alphabet", Cursor: cursor, WithCount: true})
+		assert.NoError(t, err)
+
+		count := 3
+		assert.Equal(t, storage.AudioPageJson{
+			TotalCount: &count,
+			Records: []storage.AudioList{
+				{Id: 3, Title: "audio 3", Owner: 2, Name: "user 2", UploadedBy: 2, CreatedAt: at, UpdatedAt: at, Shares: &[]storage.ShareList{{UserId: 1, Name: "user 1"}}},
+			},
+			PrevCursor: encodeCursor(order, []interface{}{"audio 3", 3}, true),
+		}, page)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("OK previous page", func(t *testing.T) {
+		query := `SELECT (.+) WHERE (.+) AND \(\(title < \$2\) OR \(title = \$2 AND audio_id < \$3\)\) ORDER BY title DESC, audio_id DESC LIMIT \$4\) (.+) ORDER BY o.title DESC, o.audio_id DESC`
+		rows := sqlmock.NewRows(columns).
+			AddRow(0, 2, "audio 2", true, 1, "user 1", 1, at, at, 10, 100, 0, "").
+			AddRow(0, 1, "audio 1", true, 1, "user 1", 1, at, at, 10, 100, 2, "user 2")
+		mock.ExpectQuery(query).WithArgs(1, "audio 3", json.Number("3"), limit+1).WillReturnRows(rows)
+
+		cursor := encodeCursor(order, []interface{}{"audio 3", 3}, true)
+		page, err := r.GetAudioPage(1, storage.AudioListParam{Limit: &limit, OrderType: "alphabet", Cursor: cursor})
+		assert.NoError(t, err)
+		assert.Equal(t, storage.AudioPageJson{
+			Records: []storage.AudioList{
+				{Id: 1, Title: "audio 1", IsOwner: true, Owner: 1, Name: "user 1", UploadedBy: 1, CreatedAt: at, UpdatedAt: at, Shares: &[]storage.ShareList{{UserId: 2, Name: "user 2"}}},
+				{Id: 2, Title: "audio 2", IsOwner: true, Owner: 1, Name: "user 1", UploadedBy: 1, CreatedAt: at, UpdatedAt: at},
+			},
+			NextCursor: encodeCursor(order, []interface{}{"audio 2", 2}, false),
+		}, page)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Error cursor of another order", func(t *testing.T) {
+		cursor := encodeCursor(order, []interface{}{"audio 2", 2}, false)
+		_, err := r.GetAudioPage(1, storage.AudioListParam{Limit: &limit, Sort: "size", Cursor: cursor})
+		assert.Equal(t, storage.InvalidCursor, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"github.com/jmoiron/sqlx"
 	"github.com/lib/pq"
@@ -20,8 +21,8 @@ func NewAuthPostgres(db *sqlx.DB) *AuthPostgres {
 func (r *AuthPostgres) CreateUser(user storage.User) (int, error) {
 	var userId int
 
-	query := fmt.Sprintf("INSERT INTO %s (name, username, password_hash) VALUES($1, $2, crypt($3, gen_salt('bf'))) RETURNING user_id", usersTable)
-	err := r.db.Get(&userId, query, user.Name, user.Username, user.Password)
+	query := fmt.Sprintf("INSERT INTO %s (name, username, password_hash, email) VALUES($1, $2, crypt($3, gen_salt('bf')), NULLIF($4, '')) RETURNING user_id", usersTable)
+	err := r.db.Get(&userId, query, user.Name, user.Username, user.Password, user.Email)
 
 	if _, ok := err.(*pq.Error); ok && err.(*pq.Error).Code == "23505" {
 		err = storage.UserExists
@@ -43,29 +44,221 @@ func (r *AuthPostgres) GetUser(username, password string) (storage.User, error)
 	return user, err
 }
 
-func (r *AuthPostgres) SetRefreshToken(userId int, refreshToken string, refreshTokenTTL time.Duration) error {
-	query := fmt.Sprintf(`INSERT INTO %s VALUES ($1, $2, now() + interval '%d seconds')
-								ON CONFLICT (user_id) DO
-								UPDATE SET refresh_token = excluded.refresh_token,
-								expires_in = excluded.expires_in`, tokenTable, int64(refreshTokenTTL.Seconds()))
-	_, err := r.db.Exec(query, userId, refreshToken)
+// SetRefreshToken opens new session, expired sessions of user are pruned at the same time
+func (r *AuthPostgres) SetRefreshToken(userId int, tokenHash string, client storage.SessionClient, refreshTokenTTL time.Duration) error {
+	query := fmt.Sprintf(`WITH expired AS (DELETE FROM %[1]s WHERE user_id = $1 AND expires_at < now())
+								INSERT INTO %[1]s (user_id, token_hash, device_name, user_agent, ip, expires_at)
+								VALUES ($1, $2, $3, $4, $5, now() + interval '%[2]d seconds')`, sessionsTable, int64(refreshTokenTTL.Seconds()))
+	_, err := r.db.Exec(query, userId, tokenHash, client.DeviceName, client.UserAgent, client.IP)
 	return err
 }
 
-func (r *AuthPostgres) UpdateRefreshToken(oldRefreshToken string, newRefreshToken string, refreshTokenTTL time.Duration) (int, error) {
-	var userId int
+// UpdateRefreshToken rotates refresh token of session and records the client which used it.
+// Replaced token stays known, presenting it again revokes the session and
+// returns RefreshTokenReused with user of the session
+func (r *AuthPostgres) UpdateRefreshToken(oldTokenHash string, newTokenHash string, client storage.SessionClient, refreshTokenTTL time.Duration) (int, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return 0, err
+	}
+
+	var session storage.Session
 	query := fmt.Sprintf(`UPDATE %s
-								SET refresh_token = $1, expires_in = now() + interval '%d seconds'
-								WHERE refresh_token = $2 AND expires_in > now() RETURNING user_id`, tokenTable, int64(refreshTokenTTL.Seconds()))
-	err := r.db.Get(&userId, query, newRefreshToken, oldRefreshToken)
+								SET token_hash = $1, expires_at = now() + interval '%d seconds', last_used_at = now(),
+								user_agent = $3, ip = $4
+								WHERE token_hash = $2 AND expires_at > now() RETURNING session_id, user_id`, sessionsTable, int64(refreshTokenTTL.Seconds()))
+	err = tx.Get(&session, query, newTokenHash, oldTokenHash, client.UserAgent, client.IP)
 
-	if err == sql.ErrNoRows {
-		err = storage.WrongRefreshToken
+	if errors.Is(err, sql.ErrNoRows) {
+		userId, err := r.revokeFamily(tx, oldTokenHash, client)
+		if err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+		if err := tx.Commit(); err != nil {
+			return 0, err
+		}
+		return userId, storage.RefreshTokenReused
+	}
+	if err != nil {
+		tx.Rollback()
+		return 0, err
 	}
 
+	query = fmt.Sprintf("INSERT INTO %s (token_hash, session_id, replaced_by) VALUES ($1, $2, $3)", rotatedTokensTable)
+	if _, err := tx.Exec(query, oldTokenHash, session.Id, newTokenHash); err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	return session.UserId, tx.Commit()
+}
+
+// revokeFamily deletes not expired session which had rotated token, revokes
+// access tokens of its user and records security event, unknown token is
+// WrongRefreshToken
+func (r *AuthPostgres) revokeFamily(tx *sqlx.Tx, tokenHash string, client storage.SessionClient) (int, error) {
+	var session storage.Session
+	query := fmt.Sprintf(`DELETE FROM %s s USING %s t
+								WHERE t.token_hash = $1 AND s.session_id = t.session_id AND s.expires_at > now()
+								RETURNING s.session_id, s.user_id, s.device_name`, sessionsTable, rotatedTokensTable)
+	err := tx.Get(&session, query, tokenHash)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, storage.WrongRefreshToken
+	}
 	if err != nil {
 		return 0, err
 	}
 
-	return userId, nil
+	// access tokens of the session may be used by whoever reused the token
+	if err := revokeAccessTokens(tx, session.UserId); err != nil {
+		return 0, err
+	}
+
+	query = fmt.Sprintf(`INSERT INTO %s (user_id, type, session_id, device_name, user_agent, ip)
+								VALUES ($1, $2, $3, $4, $5, $6)`, securityEventsTable)
+	_, err = tx.Exec(query, session.UserId, storage.SecurityRefreshTokenReused, session.Id, session.DeviceName,
+		client.UserAgent, client.IP)
+
+	return session.UserId, err
+}
+
+func (r *AuthPostgres) IsAdmin(userId int) (bool, error) {
+	var isAdmin bool
+	query := fmt.Sprintf("SELECT is_admin FROM %s WHERE user_id = $1", usersTable)
+	err := r.db.Get(&isAdmin, query, userId)
+
+	if err == sql.ErrNoRows {
+		err = storage.UserNotFound
+	}
+
+	return isAdmin, err
+}
+
+// DeleteRefreshToken closes session of refresh token, unknown token is ignored
+func (r *AuthPostgres) DeleteRefreshToken(userId int, tokenHash string) error {
+	query := fmt.Sprintf("DELETE FROM %s WHERE user_id = $1 AND token_hash = $2", sessionsTable)
+	_, err := r.db.Exec(query, userId, tokenHash)
+
+	return err
+}
+
+// DeleteRefreshTokens closes every session of user and revokes its access tokens
+func (r *AuthPostgres) DeleteRefreshTokens(userId int) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+
+	query := fmt.Sprintf("DELETE FROM %s WHERE user_id = $1", sessionsTable)
+	if _, err := tx.Exec(query, userId); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := revokeAccessTokens(tx, userId); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetSessions returns not expired sessions of user, recently used first
+func (r *AuthPostgres) GetSessions(userId int) ([]storage.Session, error) {
+	sessions := make([]storage.Session, 0)
+	query := fmt.Sprintf(`SELECT session_id, device_name, user_agent, ip, created_at, last_used_at, expires_at FROM %s
+								WHERE user_id = $1 AND expires_at > now() ORDER BY last_used_at DESC, session_id DESC`, sessionsTable)
+	err := r.db.Select(&sessions, query, userId)
+
+	return sessions, err
+}
+
+// DeleteSession closes session and revokes access tokens of user, other
+// sessions get new ones by refresh
+func (r *AuthPostgres) DeleteSession(userId, sessionId int) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+
+	query := fmt.Sprintf("DELETE FROM %s WHERE session_id = $1 AND user_id = $2", sessionsTable)
+	result, err := tx.Exec(query, sessionId, userId)
+	if err := checkAffected(result, err, storage.SessionNotFound); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := revokeAccessTokens(tx, userId); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// revokeAccessTokens revokes access tokens of user issued before now. Issue
+// time of token has second precision, so tokens issued in the current second
+// stay valid, otherwise token got by refresh right after would be revoked too
+func revokeAccessTokens(tx *sqlx.Tx, userId int) error {
+	query := fmt.Sprintf("UPDATE %s SET tokens_valid_after = date_trunc('second', now()) WHERE user_id = $1", usersTable)
+	_, err := tx.Exec(query, userId)
+	return err
+}
+
+// RevokeAccessToken adds token id to denylist until the token expires,
+// entries of already expired tokens are pruned at the same time
+func (r *AuthPostgres) RevokeAccessToken(jti string, expiresAt time.Time) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	query := fmt.Sprintf("DELETE FROM %s WHERE expires_at < now()", revokedTokensTable)
+	if _, err := tx.Exec(query); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	query = fmt.Sprintf("INSERT INTO %s (jti, expires_at) VALUES ($1, $2) ON CONFLICT (jti) DO NOTHING", revokedTokensTable)
+	if _, err := tx.Exec(query, jti, expiresAt); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// IsAccessTokenRevoked reports if token is in denylist or was issued before
+// access tokens of its user were revoked
+func (r *AuthPostgres) IsAccessTokenRevoked(userId int, jti string, issuedAt time.Time) (bool, error) {
+	var revoked bool
+	query := fmt.Sprintf(`SELECT EXISTS (SELECT 1 FROM %s WHERE jti = $1)
+								OR EXISTS (SELECT 1 FROM %s WHERE user_id = $2 AND tokens_valid_after > $3)`,
+		revokedTokensTable, usersTable)
+	err := r.db.Get(&revoked, query, jti, userId, issuedAt)
+
+	return revoked, err
+}
+
+// GetSecurityEvents returns security events of user, newest first
+func (r *AuthPostgres) GetSecurityEvents(userId int, input storage.SecurityEventListParam) (storage.SecurityEventListJson, error) {
+	query := fmt.Sprintf(`SELECT count(*) OVER() AS full_count, event_id, type, session_id, device_name, user_agent, ip, created_at
+								FROM %s WHERE user_id = $1
+								ORDER BY event_id DESC
+								OFFSET $2 LIMIT $3`, securityEventsTable)
+
+	var rows []storage.SecurityEventDb
+	if err := r.db.Select(&rows, query, userId, input.Offset, input.Limit); err != nil {
+		return storage.SecurityEventListJson{}, err
+	}
+
+	result := storage.SecurityEventListJson{Records: make([]storage.SecurityEvent, 0, len(rows))}
+	for _, row := range rows {
+		result.TotalCount = row.Count
+		result.Records = append(result.Records, row.SecurityEvent)
+	}
+
+	return result, nil
 }
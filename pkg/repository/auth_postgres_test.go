@@ -41,7 +41,7 @@ func TestAuthPostgres_CreateUser(t *testing.T) {
 			},
 			mockBehavior: func(user storage.User, userId int) {
 				rows := sqlmock.NewRows([]string{"user_id"}).AddRow(userId)
-				mock.ExpectQuery("INSERT INTO users").WithArgs(user.Name, user.Username, user.Password).WillReturnRows(rows)
+				mock.ExpectQuery("INSERT INTO users").WithArgs(user.Name, user.Username, user.Password, user.Email).WillReturnRows(rows)
 			},
 			expectedUserId: 1,
 		},
@@ -53,7 +53,7 @@ func TestAuthPostgres_CreateUser(t *testing.T) {
 				Password: "secret",
 			},
 			mockBehavior: func(user storage.User, userId int) {
-				mock.ExpectQuery("INSERT INTO users").WithArgs(user.Name, user.Username, user.Password).WillReturnError(&pq.Error{Code: "23505"})
+				mock.ExpectQuery("INSERT INTO users").WithArgs(user.Name, user.Username, user.Password, user.Email).WillReturnError(&pq.Error{Code: "23505"})
 			},
 			expectedErr:     true,
 			expectedErrType: storage.UserExists,
@@ -66,7 +66,7 @@ func TestAuthPostgres_CreateUser(t *testing.T) {
 				Password: "secret",
 			},
 			mockBehavior: func(user storage.User, userId int) {
-				mock.ExpectQuery("INSERT INTO users").WithArgs(user.Name, user.Username, user.Password).WillReturnError(errors.New("query error"))
+				mock.ExpectQuery("INSERT INTO users").WithArgs(user.Name, user.Username, user.Password, user.Email).WillReturnError(errors.New("query error"))
 			},
 			expectedErr:     true,
 			expectedErrType: errors.New("query error"),
@@ -166,12 +166,13 @@ func TestAuthPostgres_SetRefreshToken(t *testing.T) {
 	db := sqlx.NewDb(mockDB, "sqlmock")
 
 	r := NewAuthPostgres(db)
-	type mockBehavior func(userId int, refreshToken string, refreshTokenTTL time.Duration)
+	type mockBehavior func(userId int, refreshToken string, client storage.SessionClient, refreshTokenTTL time.Duration)
 
 	testTable := []struct {
 		name            string
 		userId          int
 		refreshToken    string
+		client          storage.SessionClient
 		refreshTokenTTL time.Duration
 		mockBehavior    mockBehavior
 		expectedErr     bool
@@ -179,32 +180,36 @@ func TestAuthPostgres_SetRefreshToken(t *testing.T) {
 		{
 			name:            "OK",
 			userId:          1,
-			refreshToken:    "refresh_token_string",
+			refreshToken:    "refresh_token_hash",
+			client:          storage.SessionClient{DeviceName: "laptop", UserAgent: "curl/7.68.0", IP: "192.0.2.1"},
 			refreshTokenTTL: time.Second,
-			mockBehavior: func(userId int, refreshToken string, refreshTokenTTL time.Duration) {
-				query := fmt.Sprintf(`INSERT INTO refresh_tokens VALUES \(\$1, \$2, now\(\) \+ interval \'%d seconds\'\)
-											ON CONFLICT \(user_id\) DO UPDATE SET (.+)`, int64(refreshTokenTTL.Seconds()))
-				mock.ExpectExec(query).WithArgs(userId, refreshToken).WillReturnResult(sqlmock.NewResult(0, 1))
+			mockBehavior: func(userId int, refreshToken string, client storage.SessionClient, refreshTokenTTL time.Duration) {
+				query := fmt.Sprintf(`WITH expired AS \(DELETE FROM sessions WHERE user_id = \$1 AND expires_at < now\(\)\)
+								INSERT INTO sessions \(user_id, token_hash, device_name, user_agent, ip, expires_at\)
+								VALUES \(\$1, \$2, \$3, \$4, \$5, now\(\) \+ interval '%d seconds'\)`, int64(refreshTokenTTL.Seconds()))
+				mock.ExpectExec(query).WithArgs(userId, refreshToken, client.DeviceName, client.UserAgent, client.IP).WillReturnResult(sqlmock.NewResult(0, 1))
 			},
 		},
 		{
 			name:            "Error",
 			userId:          1,
-			refreshToken:    "refresh_token_string",
+			refreshToken:    "refresh_token_hash",
+			client:          storage.SessionClient{DeviceName: "laptop", UserAgent: "curl/7.68.0", IP: "192.0.2.1"},
 			refreshTokenTTL: time.Second,
-			mockBehavior: func(userId int, refreshToken string, refreshTokenTTL time.Duration) {
-				query := fmt.Sprintf(`INSERT INTO refresh_tokens VALUES \(\$1, \$2, now\(\) \+ interval \'%d seconds\'\)
-											ON CONFLICT \(user_id\) DO UPDATE SET (.+)`, int64(refreshTokenTTL.Seconds()))
-				mock.ExpectExec(query).WithArgs(userId, refreshToken).WillReturnError(errors.New("query error"))
+			mockBehavior: func(userId int, refreshToken string, client storage.SessionClient, refreshTokenTTL time.Duration) {
+				query := fmt.Sprintf(`WITH expired AS \(DELETE FROM sessions WHERE user_id = \$1 AND expires_at < now\(\)\)
+								INSERT INTO sessions \(user_id, token_hash, device_name, user_agent, ip, expires_at\)
+								VALUES \(\$1, \$2, \$3, \$4, \$5, now\(\) \+ interval '%d seconds'\)`, int64(refreshTokenTTL.Seconds()))
+				mock.ExpectExec(query).WithArgs(userId, refreshToken, client.DeviceName, client.UserAgent, client.IP).WillReturnError(errors.New("query error"))
 			},
 			expectedErr: true,
 		},
 	}
 	for _, testCase := range testTable {
 		t.Run(testCase.name, func(t *testing.T) {
-			testCase.mockBehavior(testCase.userId, testCase.refreshToken, testCase.refreshTokenTTL)
+			testCase.mockBehavior(testCase.userId, testCase.refreshToken, testCase.client, testCase.refreshTokenTTL)
 
-			err := r.SetRefreshToken(testCase.userId, testCase.refreshToken, testCase.refreshTokenTTL)
+			err := r.SetRefreshToken(testCase.userId, testCase.refreshToken, testCase.client, testCase.refreshTokenTTL)
 			if testCase.expectedErr {
 				assert.Error(t, err)
 			} else {
@@ -224,76 +229,295 @@ func TestAuthPostgres_UpdateRefreshToken(t *testing.T) {
 	db := sqlx.NewDb(mockDB, "sqlmock")
 
 	r := NewAuthPostgres(db)
-	type mockBehavior func(userId int, oldRefreshToken string, newRefreshToken string, refreshTokenTTL time.Duration)
+
+	client := storage.SessionClient{UserAgent: "curl/7.68.0", IP: "192.0.2.1"}
+	refreshTokenTTL := time.Second
+	update := fmt.Sprintf(`UPDATE sessions SET token_hash = \$1, expires_at = now\(\) \+ interval '%d seconds', last_used_at = now\(\),
+								user_agent = \$3, ip = \$4
+								WHERE token_hash = \$2 AND expires_at > now\(\) RETURNING session_id, user_id`, int64(refreshTokenTTL.Seconds()))
+	revoke := `DELETE FROM sessions s USING rotated_tokens t
+								WHERE t.token_hash = \$1 AND s.session_id = t.session_id AND s.expires_at > now\(\)
+								RETURNING s.session_id, s.user_id, s.device_name`
 
 	testTable := []struct {
 		name            string
-		userId          int
-		oldRefreshToken string
-		newRefreshToken string
-		refreshTokenTTL time.Duration
-		mockBehavior    mockBehavior
-		expectedErr     bool
+		mockBehavior    func()
+		expectedUserId  int
 		expectedErrType error
 	}{
 		{
-			name:            "OK",
-			userId:          1,
-			oldRefreshToken: "old_refresh_token",
-			newRefreshToken: "new_refresh_token",
-			refreshTokenTTL: time.Second,
-			mockBehavior: func(userId int, oldRefreshToken string, newRefreshToken string, refreshTokenTTL time.Duration) {
-				query := fmt.Sprintf(`UPDATE refresh_tokens SET refresh_token = \$1, expires_in = now\(\) \+ interval '%d seconds'
-								WHERE refresh_token = \$2 AND expires_in > now\(\) RETURNING user_id`, int64(refreshTokenTTL.Seconds()))
-				rows := sqlmock.NewRows([]string{"user_id"}).AddRow(userId)
-				mock.ExpectQuery(query).WithArgs(newRefreshToken, oldRefreshToken).WillReturnRows(rows)
+			name: "OK",
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(update).WithArgs("new_hash", "old_hash", client.UserAgent, client.IP).
+					WillReturnRows(sqlmock.NewRows([]string{"session_id", "user_id"}).AddRow(5, 1))
+				mock.ExpectExec(`INSERT INTO rotated_tokens \(token_hash, session_id, replaced_by\) VALUES \(\$1, \$2, \$3\)`).
+					WithArgs("old_hash", 5, "new_hash").WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
 			},
+			expectedUserId: 1,
 		},
 		{
-			name:            "Error no rows",
-			userId:          1,
-			oldRefreshToken: "old_refresh_token",
-			newRefreshToken: "new_refresh_token",
-			refreshTokenTTL: time.Second,
-			mockBehavior: func(userId int, oldRefreshToken string, newRefreshToken string, refreshTokenTTL time.Duration) {
-				query := fmt.Sprintf(`UPDATE refresh_tokens SET refresh_token = \$1, expires_in = now\(\) \+ interval '%d seconds'
-								WHERE refresh_token = \$2 AND expires_in > now\(\) RETURNING user_id`, int64(refreshTokenTTL.Seconds()))
-				rows := sqlmock.NewRows([]string{"user_id"})
-				mock.ExpectQuery(query).WithArgs(newRefreshToken, oldRefreshToken).WillReturnRows(rows)
+			name: "Reused token",
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(update).WithArgs("new_hash", "old_hash", client.UserAgent, client.IP).
+					WillReturnRows(sqlmock.NewRows([]string{"session_id", "user_id"}))
+				mock.ExpectQuery(revoke).WithArgs("old_hash").
+					WillReturnRows(sqlmock.NewRows([]string{"session_id", "user_id", "device_name"}).AddRow(5, 1, "laptop"))
+				mock.ExpectExec(`UPDATE users SET tokens_valid_after = date_trunc\('second', now\(\)\) WHERE user_id = \$1`).
+					WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(`INSERT INTO security_events \(user_id, type, session_id, device_name, user_agent, ip\)`).
+					WithArgs(1, storage.SecurityRefreshTokenReused, 5, "laptop", client.UserAgent, client.IP).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			expectedUserId:  1,
+			expectedErrType: storage.RefreshTokenReused,
+		},
+		{
+			name: "Unknown token",
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(update).WithArgs("new_hash", "old_hash", client.UserAgent, client.IP).
+					WillReturnRows(sqlmock.NewRows([]string{"session_id", "user_id"}))
+				mock.ExpectQuery(revoke).WithArgs("old_hash").
+					WillReturnRows(sqlmock.NewRows([]string{"session_id", "user_id", "device_name"}))
+				mock.ExpectRollback()
 			},
-			expectedErr:     true,
 			expectedErrType: storage.WrongRefreshToken,
 		},
 		{
-			name:            "Error query",
-			userId:          1,
-			oldRefreshToken: "old_refresh_token",
-			newRefreshToken: "new_refresh_token",
-			refreshTokenTTL: time.Second,
-			mockBehavior: func(userId int, oldRefreshToken string, newRefreshToken string, refreshTokenTTL time.Duration) {
-				query := fmt.Sprintf(`UPDATE refresh_tokens SET refresh_token = \$1, expires_in = now\(\) \+ interval '%d seconds'
-								WHERE refresh_token = \$2 AND expires_in > now\(\) RETURNING user_id`, int64(refreshTokenTTL.Seconds()))
-				mock.ExpectQuery(query).WithArgs(newRefreshToken, oldRefreshToken).WillReturnError(errors.New("query error"))
+			name: "Error query",
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(update).WithArgs("new_hash", "old_hash", client.UserAgent, client.IP).
+					WillReturnError(errors.New("query error"))
+				mock.ExpectRollback()
 			},
-			expectedErr:     true,
 			expectedErrType: errors.New("query error"),
 		},
 	}
 	for _, testCase := range testTable {
 		t.Run(testCase.name, func(t *testing.T) {
-			testCase.mockBehavior(testCase.userId, testCase.oldRefreshToken, testCase.newRefreshToken, testCase.refreshTokenTTL)
+			testCase.mockBehavior()
 
-			gotUserId, err := r.UpdateRefreshToken(testCase.oldRefreshToken, testCase.newRefreshToken, testCase.refreshTokenTTL)
-			if testCase.expectedErr {
-				assert.Error(t, err)
-				if testCase.expectedErrType != nil {
-					assert.Equal(t, testCase.expectedErrType, err)
-				}
+			gotUserId, err := r.UpdateRefreshToken("old_hash", "new_hash", client, refreshTokenTTL)
+			assert.Equal(t, testCase.expectedErrType, err)
+			assert.Equal(t, testCase.expectedUserId, gotUserId)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestAuthPostgres_IsAdmin(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+	db := sqlx.NewDb(mockDB, "sqlmock")
+
+	r := NewAuthPostgres(db)
+	type mockBehavior func(userId int)
+
+	testTable := []struct {
+		name            string
+		userId          int
+		mockBehavior    mockBehavior
+		expectedData    bool
+		expectedErrType error
+	}{
+		{
+			name:   "OK admin",
+			userId: 1,
+			mockBehavior: func(userId int) {
+				rows := sqlmock.NewRows([]string{"is_admin"}).AddRow(true)
+				mock.ExpectQuery(`SELECT is_admin FROM users WHERE user_id = \$1`).WithArgs(userId).WillReturnRows(rows)
+			},
+			expectedData: true,
+		},
+		{
+			name:   "OK not admin",
+			userId: 1,
+			mockBehavior: func(userId int) {
+				rows := sqlmock.NewRows([]string{"is_admin"}).AddRow(false)
+				mock.ExpectQuery(`SELECT is_admin FROM users`).WithArgs(userId).WillReturnRows(rows)
+			},
+		},
+		{
+			name:   "User not found",
+			userId: 1,
+			mockBehavior: func(userId int) {
+				mock.ExpectQuery(`SELECT is_admin FROM users`).WithArgs(userId).WillReturnRows(sqlmock.NewRows([]string{"is_admin"}))
+			},
+			expectedErrType: storage.UserNotFound,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior(testCase.userId)
+
+			gotData, err := r.IsAdmin(testCase.userId)
+			if testCase.expectedErrType != nil {
+				assert.Equal(t, testCase.expectedErrType, err)
 			} else {
 				assert.NoError(t, err)
-				assert.Equal(t, testCase.userId, gotUserId)
+				assert.Equal(t, testCase.expectedData, gotData)
 			}
 			assert.NoError(t, mock.ExpectationsWereMet())
 		})
 	}
 }
+
+func TestAuthPostgres_Revocation(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+	db := sqlx.NewDb(mockDB, "sqlmock")
+
+	r := NewAuthPostgres(db)
+
+	jti := "2f1f9d8e-3c4b-4a5a-9e6f-7a8b9c0d1e2f"
+	expiresAt := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("OK delete refresh token", func(t *testing.T) {
+		mock.ExpectExec(`DELETE FROM sessions WHERE user_id = \$1 AND token_hash = \$2`).
+			WithArgs(1, "token").WillReturnResult(sqlmock.NewResult(0, 1))
+
+		assert.NoError(t, r.DeleteRefreshToken(1, "token"))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("OK delete refresh tokens", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(`DELETE FROM sessions WHERE user_id = \$1`).WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectExec(`UPDATE users SET tokens_valid_after = date_trunc\('second', now\(\)\) WHERE user_id = \$1`).
+			WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		assert.NoError(t, r.DeleteRefreshTokens(1))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("OK revoke", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(`DELETE FROM revoked_tokens WHERE expires_at < now\(\)`).WillReturnResult(sqlmock.NewResult(0, 3))
+		mock.ExpectExec(`INSERT INTO revoked_tokens \(jti, expires_at\) VALUES \(\$1, \$2\) ON CONFLICT \(jti\) DO NOTHING`).
+			WithArgs(jti, expiresAt).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		assert.NoError(t, r.RevokeAccessToken(jti, expiresAt))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Revoke error", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(`DELETE FROM revoked_tokens`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`INSERT INTO revoked_tokens`).WithArgs(jti, expiresAt).WillReturnError(errors.New("db error"))
+		mock.ExpectRollback()
+
+		assert.EqualError(t, r.RevokeAccessToken(jti, expiresAt), "db error")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("OK is revoked", func(t *testing.T) {
+		issuedAt := expiresAt.Add(-15 * time.Minute)
+		mock.ExpectQuery(`SELECT EXISTS \(SELECT 1 FROM revoked_tokens WHERE jti = \$1\)
+								OR EXISTS \(SELECT 1 FROM users WHERE user_id = \$2 AND tokens_valid_after > \$3\)`).
+			WithArgs(jti, 1, issuedAt).WillReturnRows(sqlmock.NewRows([]string{"?column?"}).AddRow(true))
+
+		revoked, err := r.IsAccessTokenRevoked(1, jti, issuedAt)
+		assert.NoError(t, err)
+		assert.True(t, revoked)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestAuthPostgres_Sessions(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+	db := sqlx.NewDb(mockDB, "sqlmock")
+
+	r := NewAuthPostgres(db)
+
+	createdAt := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("OK get sessions", func(t *testing.T) {
+		rows := sqlmock.NewRows([]string{"session_id", "device_name", "user_agent", "ip", "created_at", "last_used_at", "expires_at"}).
+			AddRow(2, "phone", "okhttp/4.9.0", "192.0.2.2", createdAt, createdAt.Add(time.Hour), createdAt.Add(48*time.Hour)).
+			AddRow(1, "", "curl/7.68.0", "192.0.2.1", createdAt, createdAt, createdAt.Add(24*time.Hour))
+		mock.ExpectQuery(`SELECT session_id, device_name, user_agent, ip, created_at, last_used_at, expires_at FROM sessions
+								WHERE user_id = \$1 AND expires_at > now\(\) ORDER BY last_used_at DESC, session_id DESC`).
+			WithArgs(1).WillReturnRows(rows)
+
+		sessions, err := r.GetSessions(1)
+		assert.NoError(t, err)
+		assert.Equal(t, []storage.Session{
+			{
+				Id:            2,
+				SessionClient: storage.SessionClient{DeviceName: "phone", UserAgent: "okhttp/4.9.0", IP: "192.0.2.2"},
+				CreatedAt:     createdAt,
+				LastUsedAt:    createdAt.Add(time.Hour),
+				ExpiresAt:     createdAt.Add(48 * time.Hour),
+			},
+			{
+				Id:            1,
+				SessionClient: storage.SessionClient{UserAgent: "curl/7.68.0", IP: "192.0.2.1"},
+				CreatedAt:     createdAt,
+				LastUsedAt:    createdAt,
+				ExpiresAt:     createdAt.Add(24 * time.Hour),
+			},
+		}, sessions)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("OK delete session", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(`DELETE FROM sessions WHERE session_id = \$1 AND user_id = \$2`).
+			WithArgs(2, 1).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`UPDATE users SET tokens_valid_after`).WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		assert.NoError(t, r.DeleteSession(1, 2))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("OK get security events", func(t *testing.T) {
+		sessionId := 5
+		rows := sqlmock.NewRows([]string{"full_count", "event_id", "type", "session_id", "device_name", "user_agent", "ip", "created_at"}).
+			AddRow(3, 7, storage.SecurityRefreshTokenReused, sessionId, "laptop", "curl/7.68.0", "192.0.2.1", createdAt)
+		mock.ExpectQuery(`SELECT count\(\*\) OVER\(\) AS full_count, event_id, type, session_id, device_name, user_agent, ip, created_at
+								FROM security_events WHERE user_id = \$1
+								ORDER BY event_id DESC
+								OFFSET \$2 LIMIT \$3`).WithArgs(1, 2, 1).WillReturnRows(rows)
+
+		limit, offset := 1, 2
+		result, err := r.GetSecurityEvents(1, storage.SecurityEventListParam{Limit: &limit, Offset: &offset})
+		assert.NoError(t, err)
+		assert.Equal(t, storage.SecurityEventListJson{TotalCount: 3, Records: []storage.SecurityEvent{{
+			Id:            7,
+			Type:          storage.SecurityRefreshTokenReused,
+			SessionId:     &sessionId,
+			SessionClient: storage.SessionClient{DeviceName: "laptop", UserAgent: "curl/7.68.0", IP: "192.0.2.1"},
+			CreatedAt:     createdAt,
+		}}}, result)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Session of other user", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(`DELETE FROM sessions WHERE session_id = \$1 AND user_id = \$2`).
+			WithArgs(3, 1).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		assert.Equal(t, storage.SessionNotFound, r.DeleteSession(1, 3))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
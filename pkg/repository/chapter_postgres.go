@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	storage "github.com/mahadeva604/audio-storage"
+)
+
+type ChapterPostgres struct {
+	db *sqlx.DB
+}
+
+func NewChapterPostgres(db *sqlx.DB) *ChapterPostgres {
+	return &ChapterPostgres{db: db}
+}
+
+// SetChapters replaces chapters of own audio
+func (r *ChapterPostgres) SetChapters(userID, audioId int, chapters []storage.Chapter) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+
+	var exists bool
+	query := fmt.Sprintf("SELECT true FROM %s WHERE audio_id = $1 AND user_id = $2 FOR UPDATE", audiosTable)
+	if err := tx.Get(&exists, query, audioId, userID); err != nil {
+		tx.Rollback()
+		if errors.Is(err, sql.ErrNoRows) {
+			return storage.NotOwner
+		}
+		return err
+	}
+
+	query = fmt.Sprintf("DELETE FROM %s WHERE audio_id = $1", chaptersTable)
+	if _, err := tx.Exec(query, audioId); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if len(chapters) > 0 {
+		starts := make([]int64, len(chapters))
+		titles := make([]string, len(chapters))
+		urls := make([]string, len(chapters))
+		images := make([]string, len(chapters))
+		for i, chapter := range chapters {
+			starts[i] = int64(chapter.StartMs)
+			titles[i] = chapter.Title
+			urls[i] = chapter.Url
+			images[i] = chapter.Image
+		}
+
+		query = fmt.Sprintf(`INSERT INTO %s (audio_id, start_ms, title, url, image)
+								SELECT $1, * FROM unnest($2::integer[], $3::text[], $4::text[], $5::text[])`, chaptersTable)
+		if _, err := tx.Exec(query, audioId, pq.Array(starts), pq.Array(titles), pq.Array(urls), pq.Array(images)); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetChapters returns chapters of audio user has access to with audio duration
+func (r *ChapterPostgres) GetChapters(userID, audioId int) (storage.AudioChapters, error) {
+	var chapters storage.AudioChapters
+	query := fmt.Sprintf(`SELECT duration FROM %s
+							WHERE audio_id = $1 AND audio_id IN (SELECT audio_id FROM %s WHERE user_id = $2)`,
+		audiosTable, audioAccessView)
+	if err := r.db.Get(&chapters.Duration, query, audioId, userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return chapters, storage.FileNotFound
+		}
+		return chapters, err
+	}
+
+	chapters.Chapters = make([]storage.Chapter, 0)
+	query = fmt.Sprintf("SELECT start_ms, title, url, image FROM %s WHERE audio_id = $1 ORDER BY start_ms", chaptersTable)
+	err := r.db.Select(&chapters.Chapters, query, audioId)
+
+	return chapters, err
+}
@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	storage "github.com/mahadeva604/audio-storage"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestChapterPostgres_SetChapters(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+	db := sqlx.NewDb(mockDB, "sqlmock")
+
+	r := NewChapterPostgres(db)
+
+	chapters := []storage.Chapter{
+		{StartMs: 0, Title: "Intro"},
+		{StartMs: 60000, Title: "Agenda", Url: "https://example.com", Image: "https://example.com/a.png"},
+	}
+
+	testTable := []struct {
+		name            string
+		chapters        []storage.Chapter
+		mockBehavior    func()
+		expectedErrType error
+	}{
+		{
+			name:     "OK",
+			chapters: chapters,
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`SELECT true FROM audios WHERE audio_id = \$1 AND user_id = \$2 FOR UPDATE`).WithArgs(2, 1).
+					WillReturnRows(sqlmock.NewRows([]string{"bool"}).AddRow(true))
+				mock.ExpectExec(`DELETE FROM audio_chapters WHERE audio_id = \$1`).WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 3))
+				mock.ExpectExec(`INSERT INTO audio_chapters \(audio_id, start_ms, title, url, image\) SELECT \$1, \* FROM unnest`).
+					WithArgs(2, pq.Array([]int64{0, 60000}), pq.Array([]string{"Intro", "Agenda"}),
+						pq.Array([]string{"", "https://example.com"}), pq.Array([]string{"", "https://example.com/a.png"})).
+					WillReturnResult(sqlmock.NewResult(0, 2))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "OK delete all",
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`SELECT true FROM audios`).WithArgs(2, 1).
+					WillReturnRows(sqlmock.NewRows([]string{"bool"}).AddRow(true))
+				mock.ExpectExec(`DELETE FROM audio_chapters`).WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 3))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name:     "Not owner",
+			chapters: chapters,
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`SELECT true FROM audios`).WithArgs(2, 1).WillReturnError(sql.ErrNoRows)
+				mock.ExpectRollback()
+			},
+			expectedErrType: storage.NotOwner,
+		},
+		{
+			name:     "Insert error",
+			chapters: chapters,
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`SELECT true FROM audios`).WithArgs(2, 1).
+					WillReturnRows(sqlmock.NewRows([]string{"bool"}).AddRow(true))
+				mock.ExpectExec(`DELETE FROM audio_chapters`).WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(`INSERT INTO audio_chapters`).WillReturnError(errors.New("insert error"))
+				mock.ExpectRollback()
+			},
+			expectedErrType: errors.New("insert error"),
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior()
+
+			err := r.SetChapters(1, 2, testCase.chapters)
+			if testCase.expectedErrType != nil {
+				assert.Equal(t, testCase.expectedErrType, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestChapterPostgres_GetChapters(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+	db := sqlx.NewDb(mockDB, "sqlmock")
+
+	r := NewChapterPostgres(db)
+
+	t.Run("OK", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT duration FROM audios WHERE audio_id = \$1 AND audio_id IN \(SELECT audio_id FROM audio_access WHERE user_id = \$2\)`).
+			WithArgs(2, 1).WillReturnRows(sqlmock.NewRows([]string{"duration"}).AddRow(3600))
+		mock.ExpectQuery(`SELECT start_ms, title, url, image FROM audio_chapters WHERE audio_id = \$1 ORDER BY start_ms`).WithArgs(2).
+			WillReturnRows(sqlmock.NewRows([]string{"start_ms", "title", "url", "image"}).
+				AddRow(0, "Intro", "", "").
+				AddRow(60000, "Agenda", "https://example.com", ""))
+
+		got, err := r.GetChapters(1, 2)
+		assert.NoError(t, err)
+		assert.Equal(t, storage.AudioChapters{
+			Duration: 3600,
+			Chapters: []storage.Chapter{
+				{StartMs: 0, Title: "Intro"},
+				{StartMs: 60000, Title: "Agenda", Url: "https://example.com"},
+			},
+		}, got)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("No access", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT duration FROM audios`).WithArgs(2, 1).WillReturnError(sql.ErrNoRows)
+
+		_, err := r.GetChapters(1, 2)
+		assert.Equal(t, storage.FileNotFound, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,248 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	storage "github.com/mahadeva604/audio-storage"
+	"sort"
+)
+
+type CollectionPostgres struct {
+	db *sqlx.DB
+}
+
+func NewCollectionPostgres(db *sqlx.DB) *CollectionPostgres {
+	return &CollectionPostgres{db: db}
+}
+
+func (r *CollectionPostgres) CreateCollection(userID int, input storage.CollectionInput) (int, error) {
+	var collectionId int
+	query := fmt.Sprintf("INSERT INTO %s (user_id, title) VALUES ($1, $2) RETURNING collection_id", collectionsTable)
+	err := r.db.Get(&collectionId, query, userID, input.Title)
+
+	return collectionId, err
+}
+
+func (r *CollectionPostgres) UpdateCollection(userID, collectionId int, input storage.CollectionInput) error {
+	query := fmt.Sprintf("UPDATE %s SET title = $1 WHERE collection_id = $2 AND user_id = $3", collectionsTable)
+	result, err := r.db.Exec(query, input.Title, collectionId, userID)
+
+	return checkAffected(result, err, storage.NotCollectionOwner)
+}
+
+func (r *CollectionPostgres) DeleteCollection(userID, collectionId int) error {
+	query := fmt.Sprintf("DELETE FROM %s WHERE collection_id = $1 AND user_id = $2", collectionsTable)
+	result, err := r.db.Exec(query, collectionId, userID)
+
+	return checkAffected(result, err, storage.NotCollectionOwner)
+}
+
+func (r *CollectionPostgres) GetCollectionList(userID int, input storage.CollectionListParam) (storage.CollectionListJson, error) {
+
+	var orderType string
+	if input.OrderType == "owner" {
+		orderType = "is_owner DESC, name, title"
+	} else if input.OrderType == "alphabet" {
+		orderType = "title"
+	} else {
+		return storage.CollectionListJson{}, errors.New("unknown order type")
+	}
+
+	query := fmt.Sprintf(`SELECT count(*) OVER() AS full_count, c.collection_id, c.title,
+						CASE WHEN c.user_id = $1 THEN true ELSE false END AS is_owner,
+						c.user_id, u.name,
+						(SELECT count(*) FROM %s ci WHERE ci.collection_id = c.collection_id) AS items_count
+						FROM %s c
+						JOIN %s u USING (user_id)
+						WHERE c.user_id = $1
+						OR c.collection_id IN (SELECT collection_id FROM %s WHERE user_id = $1 AND status = 'accepted')
+						ORDER BY %s
+						OFFSET $2 LIMIT $3`, collectionItemsTable, collectionsTable, usersTable, collectionSharesTable, orderType)
+
+	rows, err := r.db.Queryx(query, userID, input.Offset, input.Limit)
+	if err != nil {
+		return storage.CollectionListJson{}, err
+	}
+	var result storage.CollectionListDb
+	var totalCount int
+	collections := make([]storage.CollectionList, 0)
+
+	for rows.Next() {
+
+		err := rows.StructScan(&result)
+		if err != nil {
+			return storage.CollectionListJson{}, err
+		}
+		totalCount = result.Count
+		collections = append(collections, result.CollectionList)
+	}
+
+	return storage.CollectionListJson{TotalCount: totalCount, Records: collections}, err
+}
+
+func (r *CollectionPostgres) GetCollectionItems(userID, collectionId int, input storage.CollectionItemsParam) (storage.CollectionItemsJson, error) {
+	var ok bool
+	query := fmt.Sprintf(`SELECT true FROM %s WHERE collection_id = $1
+								AND (user_id = $2 OR collection_id IN (SELECT collection_id FROM %s WHERE user_id = $2 AND status = 'accepted'))`,
+		collectionsTable, collectionSharesTable)
+	err := r.db.Get(&ok, query, collectionId, userID)
+
+	if err == sql.ErrNoRows {
+		return storage.CollectionItemsJson{}, storage.CollectionNotFound
+	}
+
+	if err != nil {
+		return storage.CollectionItemsJson{}, err
+	}
+
+	// Items the collection owner lost access to are hidden from everyone
+	query = fmt.Sprintf(`SELECT count(*) OVER() AS full_count, a.audio_id, a.title, a.user_id, u.name, ci.position
+						FROM %s ci
+						JOIN %s c USING (collection_id)
+						JOIN %s a ON ci.audio_id = a.audio_id
+						JOIN %s u ON a.user_id = u.user_id
+						WHERE ci.collection_id = $1
+						AND EXISTS (SELECT 1 FROM %s v WHERE v.audio_id = a.audio_id AND v.user_id = c.user_id)
+						ORDER BY ci.position
+						OFFSET $2 LIMIT $3`, collectionItemsTable, collectionsTable, audiosTable, usersTable, audioAccessView)
+
+	rows, err := r.db.Queryx(query, collectionId, input.Offset, input.Limit)
+	if err != nil {
+		return storage.CollectionItemsJson{}, err
+	}
+	var result storage.CollectionItemDb
+	var totalCount int
+	items := make([]storage.CollectionItem, 0)
+
+	for rows.Next() {
+
+		err := rows.StructScan(&result)
+		if err != nil {
+			return storage.CollectionItemsJson{}, err
+		}
+		totalCount = result.Count
+		items = append(items, result.CollectionItem)
+	}
+
+	return storage.CollectionItemsJson{TotalCount: totalCount, Records: items}, err
+}
+
+func (r *CollectionPostgres) AddCollectionItem(userID, collectionId, audioId int) error {
+	// Only audio the owner has direct access to can be added, so collections
+	// can't be used to reshare audio received through another collection
+	query := fmt.Sprintf(`INSERT INTO %s (collection_id, audio_id, position)
+								SELECT c.collection_id, $3,
+								COALESCE((SELECT max(position) FROM %[1]s WHERE collection_id = c.collection_id), 0) + 1
+								FROM %s c
+								WHERE c.collection_id = $1 AND c.user_id = $2
+								AND EXISTS (SELECT 1 FROM %s a LEFT JOIN %s s ON a.audio_id = s.audio_id AND s.user_id = $2 AND s.status = 'accepted'
+								WHERE a.audio_id = $3 AND (a.user_id = $2 OR s.user_id IS NOT NULL))`,
+		collectionItemsTable, collectionsTable, audiosTable, sharesTable)
+	result, err := r.db.Exec(query, collectionId, userID, audioId)
+
+	if _, ok := err.(*pq.Error); ok && err.(*pq.Error).Code == "23505" {
+		return storage.CollectionItemExists
+	}
+
+	return checkAffected(result, err, storage.CollectionItemNotAllowed)
+}
+
+func (r *CollectionPostgres) RemoveCollectionItem(userID, collectionId, audioId int) error {
+	query := fmt.Sprintf(`DELETE FROM %s WHERE collection_id = (SELECT collection_id FROM %s
+								WHERE collection_id = $1 AND user_id = $2) AND audio_id = $3`, collectionItemsTable, collectionsTable)
+	result, err := r.db.Exec(query, collectionId, userID, audioId)
+
+	return checkAffected(result, err, storage.CollectionItemNotFound)
+}
+
+func (r *CollectionPostgres) ReorderCollection(userID, collectionId int, audioIds []int) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+
+	var ok bool
+	query := fmt.Sprintf("SELECT true FROM %s WHERE collection_id = $1 AND user_id = $2 FOR UPDATE", collectionsTable)
+	err = tx.Get(&ok, query, collectionId, userID)
+	if err == sql.ErrNoRows {
+		err = storage.NotCollectionOwner
+	}
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	var current []int
+	query = fmt.Sprintf("SELECT audio_id FROM %s WHERE collection_id = $1", collectionItemsTable)
+	if err := tx.Select(&current, query, collectionId); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if !samePermutation(current, audioIds) {
+		tx.Rollback()
+		return storage.WrongCollectionOrder
+	}
+
+	query = fmt.Sprintf(`UPDATE %s ci SET position = o.position
+								FROM unnest($2::integer[]) WITH ORDINALITY AS o(audio_id, position)
+								WHERE ci.collection_id = $1 AND ci.audio_id = o.audio_id`, collectionItemsTable)
+	if _, err := tx.Exec(query, collectionId, pq.Array(audioIds)); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ShareCollection invites user to collection the same way audio is shared, the
+// collection is accessible after the invitation is accepted
+func (r *CollectionPostgres) ShareCollection(userID, collectionId, shareId int) error {
+	query := fmt.Sprintf(`INSERT INTO %s (collection_id, user_id, status) SELECT collection_id, $1,
+								CASE WHEN EXISTS (SELECT 1 FROM %s WHERE user_id = $1 AND blocked_id = c.user_id) THEN 'declined'
+								WHEN EXISTS (SELECT 1 FROM %s WHERE user_id = $1 AND sender_id = c.user_id) THEN 'accepted'
+								ELSE 'pending' END
+								FROM %s c WHERE collection_id = $2 and user_id = $3`, collectionSharesTable, blocksTable, autoAcceptTable, collectionsTable)
+	result, err := r.db.Exec(query, shareId, collectionId, userID)
+
+	if _, ok := err.(*pq.Error); ok {
+		switch err.(*pq.Error).Code {
+		case "23505":
+			return storage.ShareExists
+		case "23503":
+			return storage.ShareUserNotExists
+		}
+	}
+
+	return checkAffected(result, err, storage.NotCollectionOwner)
+}
+
+func (r *CollectionPostgres) UnshareCollection(userID, collectionId, shareId int) error {
+	query := fmt.Sprintf(`DELETE FROM %s WHERE collection_id = (SELECT collection_id FROM %s
+								WHERE collection_id = $1 and user_id = $2) AND user_id = $3`, collectionSharesTable, collectionsTable)
+	result, err := r.db.Exec(query, collectionId, userID, shareId)
+
+	return checkAffected(result, err, storage.NotCollectionOwner)
+}
+
+func samePermutation(current, order []int) bool {
+	if len(current) != len(order) {
+		return false
+	}
+
+	a := append([]int(nil), current...)
+	b := append([]int(nil), order...)
+	sort.Ints(a)
+	sort.Ints(b)
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
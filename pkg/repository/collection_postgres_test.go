@@ -0,0 +1,659 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	storage "github.com/mahadeva604/audio-storage"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestCollectionPostgres_CreateCollection(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+	db := sqlx.NewDb(mockDB, "sqlmock")
+
+	r := NewCollectionPostgres(db)
+	type mockBehavior func(userId int, input storage.CollectionInput, collectionId int)
+
+	testTable := []struct {
+		name                 string
+		userId               int
+		input                storage.CollectionInput
+		mockBehavior         mockBehavior
+		expectedCollectionId int
+		expectErr            bool
+	}{
+		{
+			name:   "OK",
+			userId: 1,
+			input:  storage.CollectionInput{Title: "collection"},
+			mockBehavior: func(userId int, input storage.CollectionInput, collectionId int) {
+				rows := sqlmock.NewRows([]string{"collection_id"}).AddRow(collectionId)
+				mock.ExpectQuery("INSERT INTO collections").WithArgs(userId, input.Title).WillReturnRows(rows)
+			},
+			expectedCollectionId: 2,
+		},
+		{
+			name:   "Error",
+			userId: 1,
+			input:  storage.CollectionInput{Title: "collection"},
+			mockBehavior: func(userId int, input storage.CollectionInput, collectionId int) {
+				mock.ExpectQuery("INSERT INTO collections").WithArgs(userId, input.Title).WillReturnError(errors.New("query error"))
+			},
+			expectErr: true,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior(testCase.userId, testCase.input, testCase.expectedCollectionId)
+
+			gotCollectionId, err := r.CreateCollection(testCase.userId, testCase.input)
+			if testCase.expectErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, testCase.expectedCollectionId, gotCollectionId)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestCollectionPostgres_UpdateCollection(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+	db := sqlx.NewDb(mockDB, "sqlmock")
+
+	r := NewCollectionPostgres(db)
+	type mockBehavior func(userId, collectionId int)
+
+	testTable := []struct {
+		name            string
+		remove          bool
+		userId          int
+		collectionId    int
+		mockBehavior    mockBehavior
+		expectedErr     bool
+		expectedErrType error
+	}{
+		{
+			name:         "OK rename",
+			userId:       1,
+			collectionId: 2,
+			mockBehavior: func(userId, collectionId int) {
+				mock.ExpectExec("UPDATE collections SET title = (.+)").WithArgs("title", collectionId, userId).WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			name:         "Error rename not owner",
+			userId:       1,
+			collectionId: 2,
+			mockBehavior: func(userId, collectionId int) {
+				mock.ExpectExec("UPDATE collections SET title = (.+)").WithArgs("title", collectionId, userId).WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			expectedErr:     true,
+			expectedErrType: storage.NotCollectionOwner,
+		},
+		{
+			name:         "OK delete",
+			remove:       true,
+			userId:       1,
+			collectionId: 2,
+			mockBehavior: func(userId, collectionId int) {
+				mock.ExpectExec("DELETE FROM collections").WithArgs(collectionId, userId).WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			name:         "Error delete",
+			remove:       true,
+			userId:       1,
+			collectionId: 2,
+			mockBehavior: func(userId, collectionId int) {
+				mock.ExpectExec("DELETE FROM collections").WithArgs(collectionId, userId).WillReturnError(errors.New("query error"))
+			},
+			expectedErr:     true,
+			expectedErrType: errors.New("query error"),
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior(testCase.userId, testCase.collectionId)
+
+			if testCase.remove {
+				err = r.DeleteCollection(testCase.userId, testCase.collectionId)
+			} else {
+				err = r.UpdateCollection(testCase.userId, testCase.collectionId, storage.CollectionInput{Title: "title"})
+			}
+
+			if testCase.expectedErr {
+				assert.Error(t, err)
+				if testCase.expectedErrType != nil {
+					assert.Equal(t, testCase.expectedErrType, err)
+				}
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestCollectionPostgres_GetCollectionList(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+	db := sqlx.NewDb(mockDB, "sqlmock")
+
+	r := NewCollectionPostgres(db)
+	type mockBehavior func(userId int, input storage.CollectionListParam)
+
+	offset, limit := 0, 2
+
+	testTable := []struct {
+		name          string
+		userId        int
+		input         storage.CollectionListParam
+		mockBehavior  mockBehavior
+		expectErr     bool
+		expectErrType error
+		expectData    storage.CollectionListJson
+	}{
+		{
+			name:   "OK owner order",
+			userId: 1,
+			input:  storage.CollectionListParam{Offset: &offset, Limit: &limit, OrderType: "owner"},
+			mockBehavior: func(userId int, input storage.CollectionListParam) {
+				rows := sqlmock.NewRows([]string{"full_count", "collection_id", "title", "is_owner", "user_id", "name", "items_count"}).
+					AddRow(5, 1, "collection 1", true, 1, "user 1", 3).
+					AddRow(5, 2, "collection 2", false, 2, "user 2", 0)
+				mock.ExpectQuery(`SELECT (.+) FROM collections c (.+) ORDER BY is_owner DESC, name, title OFFSET \$2 LIMIT \$3`).
+					WithArgs(userId, input.Offset, input.Limit).WillReturnRows(rows)
+			},
+			expectData: storage.CollectionListJson{
+				TotalCount: 5,
+				Records: []storage.CollectionList{
+					{Id: 1, Title: "collection 1", IsOwner: true, Owner: 1, Name: "user 1", ItemsCount: 3},
+					{Id: 2, Title: "collection 2", IsOwner: false, Owner: 2, Name: "user 2", ItemsCount: 0},
+				},
+			},
+		},
+		{
+			name:   "Error query",
+			userId: 1,
+			input:  storage.CollectionListParam{Offset: &offset, Limit: &limit, OrderType: "alphabet"},
+			mockBehavior: func(userId int, input storage.CollectionListParam) {
+				mock.ExpectQuery(`SELECT (.+) FROM collections c (.+) ORDER BY title OFFSET \$2 LIMIT \$3`).
+					WithArgs(userId, input.Offset, input.Limit).WillReturnError(errors.New("query error"))
+			},
+			expectErr:     true,
+			expectErrType: errors.New("query error"),
+		},
+		{
+			name:          "Error unknown order",
+			userId:        1,
+			input:         storage.CollectionListParam{Offset: &offset, Limit: &limit, OrderType: "unknown"},
+			mockBehavior:  func(userId int, input storage.CollectionListParam) {},
+			expectErr:     true,
+			expectErrType: errors.New("unknown order type"),
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior(testCase.userId, testCase.input)
+
+			output, err := r.GetCollectionList(testCase.userId, testCase.input)
+			if testCase.expectErr {
+				assert.Error(t, err)
+				if testCase.expectErrType != nil {
+					assert.Equal(t, testCase.expectErrType, err)
+				}
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, testCase.expectData, output)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestCollectionPostgres_GetCollectionItems(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+	db := sqlx.NewDb(mockDB, "sqlmock")
+
+	r := NewCollectionPostgres(db)
+	type mockBehavior func(userId, collectionId int, input storage.CollectionItemsParam)
+
+	offset, limit := 0, 2
+	accessQuery := `SELECT true FROM collections WHERE collection_id = \$1 (.+)`
+	itemsQuery := `SELECT (.+) FROM collection_items ci (.+) ORDER BY ci.position OFFSET \$2 LIMIT \$3`
+
+	testTable := []struct {
+		name          string
+		userId        int
+		collectionId  int
+		input         storage.CollectionItemsParam
+		mockBehavior  mockBehavior
+		expectErr     bool
+		expectErrType error
+		expectData    storage.CollectionItemsJson
+	}{
+		{
+			name:         "OK",
+			userId:       1,
+			collectionId: 2,
+			input:        storage.CollectionItemsParam{Offset: &offset, Limit: &limit},
+			mockBehavior: func(userId, collectionId int, input storage.CollectionItemsParam) {
+				mock.ExpectQuery(accessQuery).WithArgs(collectionId, userId).WillReturnRows(sqlmock.NewRows([]string{"bool"}).AddRow(true))
+				rows := sqlmock.NewRows([]string{"full_count", "audio_id", "title", "user_id", "name", "position"}).
+					AddRow(2, 5, "audio 5", 1, "user 1", 1).
+					AddRow(2, 3, "audio 3", 3, "user 3", 2)
+				mock.ExpectQuery(itemsQuery).WithArgs(collectionId, input.Offset, input.Limit).WillReturnRows(rows)
+			},
+			expectData: storage.CollectionItemsJson{
+				TotalCount: 2,
+				Records: []storage.CollectionItem{
+					{Id: 5, Title: "audio 5", Owner: 1, Name: "user 1", Position: 1},
+					{Id: 3, Title: "audio 3", Owner: 3, Name: "user 3", Position: 2},
+				},
+			},
+		},
+		{
+			name:         "Error no access",
+			userId:       1,
+			collectionId: 2,
+			input:        storage.CollectionItemsParam{Offset: &offset, Limit: &limit},
+			mockBehavior: func(userId, collectionId int, input storage.CollectionItemsParam) {
+				mock.ExpectQuery(accessQuery).WithArgs(collectionId, userId).WillReturnError(sql.ErrNoRows)
+			},
+			expectErr:     true,
+			expectErrType: storage.CollectionNotFound,
+		},
+		{
+			name:         "Error items query",
+			userId:       1,
+			collectionId: 2,
+			input:        storage.CollectionItemsParam{Offset: &offset, Limit: &limit},
+			mockBehavior: func(userId, collectionId int, input storage.CollectionItemsParam) {
+				mock.ExpectQuery(accessQuery).WithArgs(collectionId, userId).WillReturnRows(sqlmock.NewRows([]string{"bool"}).AddRow(true))
+				mock.ExpectQuery(itemsQuery).WithArgs(collectionId, input.Offset, input.Limit).WillReturnError(errors.New("query error"))
+			},
+			expectErr:     true,
+			expectErrType: errors.New("query error"),
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior(testCase.userId, testCase.collectionId, testCase.input)
+
+			output, err := r.GetCollectionItems(testCase.userId, testCase.collectionId, testCase.input)
+			if testCase.expectErr {
+				assert.Error(t, err)
+				if testCase.expectErrType != nil {
+					assert.Equal(t, testCase.expectErrType, err)
+				}
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, testCase.expectData, output)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestCollectionPostgres_AddCollectionItem(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+	db := sqlx.NewDb(mockDB, "sqlmock")
+
+	r := NewCollectionPostgres(db)
+	type mockBehavior func(userId, collectionId, audioId int)
+
+	testTable := []struct {
+		name            string
+		userId          int
+		collectionId    int
+		audioId         int
+		mockBehavior    mockBehavior
+		expectedErr     bool
+		expectedErrType error
+	}{
+		{
+			name:         "OK",
+			userId:       1,
+			collectionId: 2,
+			audioId:      3,
+			mockBehavior: func(userId, collectionId, audioId int) {
+				mock.ExpectExec("INSERT INTO collection_items (.+) SELECT (.+)").WithArgs(collectionId, userId, audioId).WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			name:         "Error exists",
+			userId:       1,
+			collectionId: 2,
+			audioId:      3,
+			mockBehavior: func(userId, collectionId, audioId int) {
+				mock.ExpectExec("INSERT INTO collection_items (.+) SELECT (.+)").WithArgs(collectionId, userId, audioId).WillReturnError(&pq.Error{Code: "23505"})
+			},
+			expectedErr:     true,
+			expectedErrType: storage.CollectionItemExists,
+		},
+		{
+			name:         "Error not allowed",
+			userId:       1,
+			collectionId: 2,
+			audioId:      3,
+			mockBehavior: func(userId, collectionId, audioId int) {
+				mock.ExpectExec("INSERT INTO collection_items (.+) SELECT (.+)").WithArgs(collectionId, userId, audioId).WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			expectedErr:     true,
+			expectedErrType: storage.CollectionItemNotAllowed,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior(testCase.userId, testCase.collectionId, testCase.audioId)
+
+			err := r.AddCollectionItem(testCase.userId, testCase.collectionId, testCase.audioId)
+			if testCase.expectedErr {
+				assert.Error(t, err)
+				if testCase.expectedErrType != nil {
+					assert.Equal(t, testCase.expectedErrType, err)
+				}
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestCollectionPostgres_RemoveCollectionItem(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+	db := sqlx.NewDb(mockDB, "sqlmock")
+
+	r := NewCollectionPostgres(db)
+	type mockBehavior func(userId, collectionId, audioId int)
+
+	testTable := []struct {
+		name            string
+		userId          int
+		collectionId    int
+		audioId         int
+		mockBehavior    mockBehavior
+		expectedErr     bool
+		expectedErrType error
+	}{
+		{
+			name:         "OK",
+			userId:       1,
+			collectionId: 2,
+			audioId:      3,
+			mockBehavior: func(userId, collectionId, audioId int) {
+				mock.ExpectExec(`DELETE FROM collection_items WHERE collection_id = \(SELECT (.+)\)`).WithArgs(collectionId, userId, audioId).WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			name:         "Error not found",
+			userId:       1,
+			collectionId: 2,
+			audioId:      3,
+			mockBehavior: func(userId, collectionId, audioId int) {
+				mock.ExpectExec(`DELETE FROM collection_items WHERE collection_id = \(SELECT (.+)\)`).WithArgs(collectionId, userId, audioId).WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			expectedErr:     true,
+			expectedErrType: storage.CollectionItemNotFound,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior(testCase.userId, testCase.collectionId, testCase.audioId)
+
+			err := r.RemoveCollectionItem(testCase.userId, testCase.collectionId, testCase.audioId)
+			if testCase.expectedErr {
+				assert.Error(t, err)
+				if testCase.expectedErrType != nil {
+					assert.Equal(t, testCase.expectedErrType, err)
+				}
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestCollectionPostgres_ReorderCollection(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+	db := sqlx.NewDb(mockDB, "sqlmock")
+
+	r := NewCollectionPostgres(db)
+	type mockBehavior func(userId, collectionId int, audioIds []int)
+
+	ownerQuery := `SELECT true FROM collections WHERE (.+) FOR UPDATE`
+	itemsQuery := `SELECT audio_id FROM collection_items WHERE collection_id = \$1`
+
+	testTable := []struct {
+		name            string
+		userId          int
+		collectionId    int
+		audioIds        []int
+		mockBehavior    mockBehavior
+		expectedErr     bool
+		expectedErrType error
+	}{
+		{
+			name:         "OK",
+			userId:       1,
+			collectionId: 2,
+			audioIds:     []int{5, 3, 4},
+			mockBehavior: func(userId, collectionId int, audioIds []int) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(ownerQuery).WithArgs(collectionId, userId).WillReturnRows(sqlmock.NewRows([]string{"bool"}).AddRow(true))
+				mock.ExpectQuery(itemsQuery).WithArgs(collectionId).WillReturnRows(sqlmock.NewRows([]string{"audio_id"}).AddRow(3).AddRow(4).AddRow(5))
+				mock.ExpectExec(`UPDATE collection_items ci SET position = (.+)`).WithArgs(collectionId, pq.Array(audioIds)).WillReturnResult(sqlmock.NewResult(0, 3))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name:         "Error not owner",
+			userId:       1,
+			collectionId: 2,
+			audioIds:     []int{5, 3, 4},
+			mockBehavior: func(userId, collectionId int, audioIds []int) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(ownerQuery).WithArgs(collectionId, userId).WillReturnError(sql.ErrNoRows)
+				mock.ExpectRollback()
+			},
+			expectedErr:     true,
+			expectedErrType: storage.NotCollectionOwner,
+		},
+		{
+			name:         "Error missing audio",
+			userId:       1,
+			collectionId: 2,
+			audioIds:     []int{5, 3},
+			mockBehavior: func(userId, collectionId int, audioIds []int) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(ownerQuery).WithArgs(collectionId, userId).WillReturnRows(sqlmock.NewRows([]string{"bool"}).AddRow(true))
+				mock.ExpectQuery(itemsQuery).WithArgs(collectionId).WillReturnRows(sqlmock.NewRows([]string{"audio_id"}).AddRow(3).AddRow(4).AddRow(5))
+				mock.ExpectRollback()
+			},
+			expectedErr:     true,
+			expectedErrType: storage.WrongCollectionOrder,
+		},
+		{
+			name:         "Error duplicated audio",
+			userId:       1,
+			collectionId: 2,
+			audioIds:     []int{5, 3, 3},
+			mockBehavior: func(userId, collectionId int, audioIds []int) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(ownerQuery).WithArgs(collectionId, userId).WillReturnRows(sqlmock.NewRows([]string{"bool"}).AddRow(true))
+				mock.ExpectQuery(itemsQuery).WithArgs(collectionId).WillReturnRows(sqlmock.NewRows([]string{"audio_id"}).AddRow(3).AddRow(4).AddRow(5))
+				mock.ExpectRollback()
+			},
+			expectedErr:     true,
+			expectedErrType: storage.WrongCollectionOrder,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior(testCase.userId, testCase.collectionId, testCase.audioIds)
+
+			err := r.ReorderCollection(testCase.userId, testCase.collectionId, testCase.audioIds)
+			if testCase.expectedErr {
+				assert.Error(t, err)
+				if testCase.expectedErrType != nil {
+					assert.Equal(t, testCase.expectedErrType, err)
+				}
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestCollectionPostgres_ShareCollection(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+	db := sqlx.NewDb(mockDB, "sqlmock")
+
+	r := NewCollectionPostgres(db)
+	type mockBehavior func(shareId, collectionId, userID int)
+
+	testTable := []struct {
+		name            string
+		unshare         bool
+		shareID         int
+		collectionId    int
+		userId          int
+		mockBehavior    mockBehavior
+		expectedErr     bool
+		expectedErrType error
+	}{
+		{
+			name:         "OK",
+			shareID:      1,
+			collectionId: 2,
+			userId:       3,
+			mockBehavior: func(shareId, collectionId, userID int) {
+				mock.ExpectExec(`INSERT INTO collection_shares \(collection_id, user_id, status\) SELECT (.+) FROM collections c`).WithArgs(shareId, collectionId, userID).WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			name:         "Error share exists",
+			shareID:      1,
+			collectionId: 2,
+			userId:       3,
+			mockBehavior: func(shareId, collectionId, userID int) {
+				mock.ExpectExec(`INSERT INTO collection_shares \(collection_id, user_id, status\) SELECT (.+) FROM collections c`).WithArgs(shareId, collectionId, userID).WillReturnError(&pq.Error{Code: "23505"})
+			},
+			expectedErr:     true,
+			expectedErrType: storage.ShareExists,
+		},
+		{
+			name:         "Error user not exists",
+			shareID:      1,
+			collectionId: 2,
+			userId:       3,
+			mockBehavior: func(shareId, collectionId, userID int) {
+				mock.ExpectExec(`INSERT INTO collection_shares \(collection_id, user_id, status\) SELECT (.+) FROM collections c`).WithArgs(shareId, collectionId, userID).WillReturnError(&pq.Error{Code: "23503"})
+			},
+			expectedErr:     true,
+			expectedErrType: storage.ShareUserNotExists,
+		},
+		{
+			name:         "Error not owner",
+			shareID:      1,
+			collectionId: 2,
+			userId:       3,
+			mockBehavior: func(shareId, collectionId, userID int) {
+				mock.ExpectExec(`INSERT INTO collection_shares \(collection_id, user_id, status\) SELECT (.+) FROM collections c`).WithArgs(shareId, collectionId, userID).WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			expectedErr:     true,
+			expectedErrType: storage.NotCollectionOwner,
+		},
+		{
+			name:         "OK unshare",
+			unshare:      true,
+			shareID:      1,
+			collectionId: 2,
+			userId:       3,
+			mockBehavior: func(shareId, collectionId, userID int) {
+				mock.ExpectExec(`DELETE FROM collection_shares WHERE collection_id = \(SELECT (.+)\)`).WithArgs(collectionId, userID, shareId).WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			name:         "Error unshare not owner",
+			unshare:      true,
+			shareID:      1,
+			collectionId: 2,
+			userId:       3,
+			mockBehavior: func(shareId, collectionId, userID int) {
+				mock.ExpectExec(`DELETE FROM collection_shares WHERE collection_id = \(SELECT (.+)\)`).WithArgs(collectionId, userID, shareId).WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			expectedErr:     true,
+			expectedErrType: storage.NotCollectionOwner,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior(testCase.shareID, testCase.collectionId, testCase.userId)
+
+			if testCase.unshare {
+				err = r.UnshareCollection(testCase.userId, testCase.collectionId, testCase.shareID)
+			} else {
+				err = r.ShareCollection(testCase.userId, testCase.collectionId, testCase.shareID)
+			}
+
+			if testCase.expectedErr {
+				assert.Error(t, err)
+				if testCase.expectedErrType != nil {
+					assert.Equal(t, testCase.expectedErrType, err)
+				}
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
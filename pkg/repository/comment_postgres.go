@@ -0,0 +1,162 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	storage "github.com/mahadeva604/audio-storage"
+)
+
+type CommentPostgres struct {
+	db *sqlx.DB
+}
+
+func NewCommentPostgres(db *sqlx.DB) *CommentPostgres {
+	return &CommentPostgres{db: db}
+}
+
+const commentColumns = `c.comment_id, c.parent_id, c.user_id, u.name, c.start_ms, c.end_ms, c.text, c.created_at, c.updated_at`
+
+func (r *CommentPostgres) checkAccess(userID, audioId int) error {
+	var ok bool
+	query := fmt.Sprintf("SELECT true FROM %s WHERE audio_id = $1 AND user_id = $2", audioAccessView)
+	err := r.db.Get(&ok, query, audioId, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return storage.FileNotFound
+	}
+
+	return err
+}
+
+// AddComment adds comment to audio accessible by user, parent must be top level comment of the same audio
+func (r *CommentPostgres) AddComment(userID, audioId int, input storage.CommentInput) (int, error) {
+	if err := r.checkAccess(userID, audioId); err != nil {
+		return 0, err
+	}
+
+	var commentId int
+	query := fmt.Sprintf(`INSERT INTO %[1]s (audio_id, user_id, parent_id, start_ms, end_ms, text)
+							SELECT $1, $2, $3, $4, $5, $6
+							WHERE $3::integer IS NULL
+							OR EXISTS (SELECT 1 FROM %[1]s WHERE comment_id = $3 AND audio_id = $1 AND parent_id IS NULL)
+							RETURNING comment_id`, commentsTable)
+	err := r.db.Get(&commentId, query, audioId, userID, input.ParentId, input.StartMs, input.EndMs, input.Text)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, storage.CommentNotFound
+	}
+
+	return commentId, err
+}
+
+// GetComments returns page of top level comments ordered by offset, every
+// comment of the page has all its replies
+func (r *CommentPostgres) GetComments(userID, audioId int, input storage.CommentListParam) (storage.CommentListJson, error) {
+	if err := r.checkAccess(userID, audioId); err != nil {
+		return storage.CommentListJson{}, err
+	}
+
+	query := fmt.Sprintf(`SELECT count(*) OVER() AS full_count, %s
+						FROM %s c
+						JOIN %s u ON c.user_id = u.user_id
+						WHERE c.audio_id = $1 AND c.parent_id IS NULL
+						ORDER BY c.start_ms, c.comment_id
+						OFFSET $2 LIMIT $3`, commentColumns, commentsTable, usersTable)
+
+	var rows []storage.CommentDb
+	if err := r.db.Select(&rows, query, audioId, input.Offset, input.Limit); err != nil {
+		return storage.CommentListJson{}, err
+	}
+
+	result := storage.CommentListJson{Records: make([]storage.Comment, 0, len(rows))}
+	if len(rows) == 0 {
+		return result, nil
+	}
+
+	ids := make([]int, len(rows))
+	index := make(map[int]int, len(rows))
+	for i, row := range rows {
+		result.TotalCount = row.Count
+		result.Records = append(result.Records, row.Comment)
+		ids[i] = row.Id
+		index[row.Id] = i
+	}
+
+	query = fmt.Sprintf(`SELECT %s
+						FROM %s c
+						JOIN %s u ON c.user_id = u.user_id
+						WHERE c.parent_id = ANY($1)
+						ORDER BY c.created_at, c.comment_id`, commentColumns, commentsTable, usersTable)
+
+	var replies []storage.Comment
+	if err := r.db.Select(&replies, query, pq.Array(ids)); err != nil {
+		return storage.CommentListJson{}, err
+	}
+
+	for _, reply := range replies {
+		parent := &result.Records[index[*reply.ParentId]]
+		parent.Replies = append(parent.Replies, reply)
+	}
+
+	return result, nil
+}
+
+// UpdateComment changes own comment of audio user still has access to
+func (r *CommentPostgres) UpdateComment(userID, audioId, commentId int, input storage.CommentUpdate) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+
+	var comment struct {
+		UserId   int  `db:"user_id"`
+		ParentId *int `db:"parent_id"`
+	}
+	query := fmt.Sprintf(`SELECT user_id, parent_id FROM %s
+							WHERE comment_id = $1 AND audio_id = $2
+							AND EXISTS (SELECT 1 FROM %s WHERE audio_id = $2 AND user_id = $3)
+							FOR UPDATE`, commentsTable, audioAccessView)
+	err = tx.Get(&comment, query, commentId, audioId, userID)
+	if err == sql.ErrNoRows {
+		err = storage.CommentNotFound
+	}
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if comment.UserId != userID {
+		tx.Rollback()
+		return storage.NotCommentAuthor
+	}
+
+	if comment.ParentId != nil && input.StartMs != nil {
+		tx.Rollback()
+		return storage.CommentReplyOffset
+	}
+
+	// end_ms is replaced together with start_ms
+	query = fmt.Sprintf(`UPDATE %s SET text = $1,
+							start_ms = coalesce($2, start_ms),
+							end_ms = CASE WHEN $2::integer IS NULL THEN end_ms ELSE $3 END,
+							updated_at = now()
+							WHERE comment_id = $4`, commentsTable)
+	if _, err := tx.Exec(query, input.Text, input.StartMs, input.EndMs, commentId); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// DeleteComment deletes comment with its replies, comment can be deleted by
+// its author or by owner of audio
+func (r *CommentPostgres) DeleteComment(userID, audioId, commentId int) error {
+	query := fmt.Sprintf(`DELETE FROM %s c WHERE comment_id = $1 AND audio_id = $2
+							AND (user_id = $3 OR EXISTS (SELECT 1 FROM %s a WHERE a.audio_id = c.audio_id AND a.user_id = $3))`,
+		commentsTable, audiosTable)
+	result, err := r.db.Exec(query, commentId, audioId, userID)
+
+	return checkAffected(result, err, storage.CommentNotFound)
+}
@@ -0,0 +1,266 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	storage "github.com/mahadeva604/audio-storage"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestCommentPostgres_AddComment(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+	db := sqlx.NewDb(mockDB, "sqlmock")
+
+	r := NewCommentPostgres(db)
+
+	start, end, parent := 1000, 2500, 5
+
+	testTable := []struct {
+		name            string
+		input           storage.CommentInput
+		mockBehavior    func(input storage.CommentInput)
+		expectedId      int
+		expectedErrType error
+	}{
+		{
+			name:  "OK",
+			input: storage.CommentInput{Text: "text", StartMs: &start, EndMs: &end},
+			mockBehavior: func(input storage.CommentInput) {
+				mock.ExpectQuery(`SELECT true FROM audio_access WHERE audio_id = \$1 AND user_id = \$2`).WithArgs(2, 1).
+					WillReturnRows(sqlmock.NewRows([]string{"bool"}).AddRow(true))
+				mock.ExpectQuery(`INSERT INTO audio_comments (.+) WHERE \$3::integer IS NULL OR EXISTS`).
+					WithArgs(2, 1, input.ParentId, input.StartMs, input.EndMs, "text").
+					WillReturnRows(sqlmock.NewRows([]string{"comment_id"}).AddRow(7))
+			},
+			expectedId: 7,
+		},
+		{
+			name:  "No access",
+			input: storage.CommentInput{Text: "text", StartMs: &start},
+			mockBehavior: func(input storage.CommentInput) {
+				mock.ExpectQuery(`SELECT true FROM audio_access`).WithArgs(2, 1).WillReturnError(sql.ErrNoRows)
+			},
+			expectedErrType: storage.FileNotFound,
+		},
+		{
+			name:  "Parent not found",
+			input: storage.CommentInput{Text: "reply", ParentId: &parent},
+			mockBehavior: func(input storage.CommentInput) {
+				mock.ExpectQuery(`SELECT true FROM audio_access`).WithArgs(2, 1).
+					WillReturnRows(sqlmock.NewRows([]string{"bool"}).AddRow(true))
+				mock.ExpectQuery(`INSERT INTO audio_comments`).
+					WithArgs(2, 1, input.ParentId, input.StartMs, input.EndMs, "reply").WillReturnError(sql.ErrNoRows)
+			},
+			expectedErrType: storage.CommentNotFound,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior(testCase.input)
+
+			id, err := r.AddComment(1, 2, testCase.input)
+			if testCase.expectedErrType != nil {
+				assert.Equal(t, testCase.expectedErrType, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, testCase.expectedId, id)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestCommentPostgres_GetComments(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+	db := sqlx.NewDb(mockDB, "sqlmock")
+
+	r := NewCommentPostgres(db)
+
+	offset, limit := 0, 10
+	at := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	first, second, end := 500, 1500, 3000
+	columns := []string{"comment_id", "parent_id", "user_id", "name", "start_ms", "end_ms", "text", "created_at", "updated_at"}
+
+	t.Run("OK", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT true FROM audio_access`).WithArgs(2, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"bool"}).AddRow(true))
+		mock.ExpectQuery(`SELECT count\(\*\) OVER\(\) AS full_count, (.+) FROM audio_comments c JOIN users u (.+) WHERE c.audio_id = \$1 AND c.parent_id IS NULL ORDER BY c.start_ms, c.comment_id OFFSET \$2 LIMIT \$3`).
+			WithArgs(2, &offset, &limit).
+			WillReturnRows(sqlmock.NewRows(append([]string{"full_count"}, columns...)).
+				AddRow(3, 1, nil, 1, "owner", first, nil, "first", at, at).
+				AddRow(3, 2, nil, 3, "guest", second, end, "second", at, at))
+		mock.ExpectQuery(`SELECT (.+) FROM audio_comments c JOIN users u (.+) WHERE c.parent_id = ANY\(\$1\) ORDER BY c.created_at, c.comment_id`).
+			WithArgs(pq.Array([]int{1, 2})).
+			WillReturnRows(sqlmock.NewRows(columns).
+				AddRow(4, 2, 1, "owner", nil, nil, "reply 1", at, at).
+				AddRow(5, 2, 3, "guest", nil, nil, "reply 2", at, at))
+
+		got, err := r.GetComments(1, 2, storage.CommentListParam{Offset: &offset, Limit: &limit})
+		assert.NoError(t, err)
+
+		parent := 2
+		assert.Equal(t, storage.CommentListJson{
+			TotalCount: 3,
+			Records: []storage.Comment{
+				{Id: 1, UserId: 1, Name: "owner", StartMs: &first, Text: "first", CreatedAt: at, UpdatedAt: at},
+				{Id: 2, UserId: 3, Name: "guest", StartMs: &second, EndMs: &end, Text: "second", CreatedAt: at, UpdatedAt: at,
+					Replies: []storage.Comment{
+						{Id: 4, ParentId: &parent, UserId: 1, Name: "owner", Text: "reply 1", CreatedAt: at, UpdatedAt: at},
+						{Id: 5, ParentId: &parent, UserId: 3, Name: "guest", Text: "reply 2", CreatedAt: at, UpdatedAt: at},
+					}},
+			},
+		}, got)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("OK empty", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT true FROM audio_access`).WithArgs(2, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"bool"}).AddRow(true))
+		mock.ExpectQuery(`SELECT count\(\*\) OVER\(\)`).WithArgs(2, &offset, &limit).
+			WillReturnRows(sqlmock.NewRows(append([]string{"full_count"}, columns...)))
+
+		got, err := r.GetComments(1, 2, storage.CommentListParam{Offset: &offset, Limit: &limit})
+		assert.NoError(t, err)
+		assert.Equal(t, storage.CommentListJson{Records: []storage.Comment{}}, got)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("No access", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT true FROM audio_access`).WithArgs(2, 1).WillReturnError(sql.ErrNoRows)
+
+		_, err := r.GetComments(1, 2, storage.CommentListParam{Offset: &offset, Limit: &limit})
+		assert.Equal(t, storage.FileNotFound, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestCommentPostgres_UpdateComment(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+	db := sqlx.NewDb(mockDB, "sqlmock")
+
+	r := NewCommentPostgres(db)
+
+	start, parent := 100, 1
+
+	testTable := []struct {
+		name            string
+		input           storage.CommentUpdate
+		mockBehavior    func(input storage.CommentUpdate)
+		expectedErrType error
+	}{
+		{
+			name:  "OK",
+			input: storage.CommentUpdate{Text: "new", StartMs: &start},
+			mockBehavior: func(input storage.CommentUpdate) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`SELECT user_id, parent_id FROM audio_comments WHERE comment_id = \$1 AND audio_id = \$2 AND EXISTS \(SELECT 1 FROM audio_access (.+)\) FOR UPDATE`).
+					WithArgs(3, 2, 1).WillReturnRows(sqlmock.NewRows([]string{"user_id", "parent_id"}).AddRow(1, nil))
+				mock.ExpectExec(`UPDATE audio_comments SET text = \$1, start_ms = coalesce\(\$2, start_ms\)`).
+					WithArgs("new", input.StartMs, input.EndMs, 3).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name:  "Not found",
+			input: storage.CommentUpdate{Text: "new"},
+			mockBehavior: func(input storage.CommentUpdate) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`SELECT user_id, parent_id FROM audio_comments`).WithArgs(3, 2, 1).WillReturnError(sql.ErrNoRows)
+				mock.ExpectRollback()
+			},
+			expectedErrType: storage.CommentNotFound,
+		},
+		{
+			name:  "Not author",
+			input: storage.CommentUpdate{Text: "new"},
+			mockBehavior: func(input storage.CommentUpdate) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`SELECT user_id, parent_id FROM audio_comments`).WithArgs(3, 2, 1).
+					WillReturnRows(sqlmock.NewRows([]string{"user_id", "parent_id"}).AddRow(4, nil))
+				mock.ExpectRollback()
+			},
+			expectedErrType: storage.NotCommentAuthor,
+		},
+		{
+			name:  "Reply offset",
+			input: storage.CommentUpdate{Text: "new", StartMs: &start},
+			mockBehavior: func(input storage.CommentUpdate) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`SELECT user_id, parent_id FROM audio_comments`).WithArgs(3, 2, 1).
+					WillReturnRows(sqlmock.NewRows([]string{"user_id", "parent_id"}).AddRow(1, parent))
+				mock.ExpectRollback()
+			},
+			expectedErrType: storage.CommentReplyOffset,
+		},
+		{
+			name:  "Update error",
+			input: storage.CommentUpdate{Text: "new"},
+			mockBehavior: func(input storage.CommentUpdate) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`SELECT user_id, parent_id FROM audio_comments`).WithArgs(3, 2, 1).
+					WillReturnRows(sqlmock.NewRows([]string{"user_id", "parent_id"}).AddRow(1, parent))
+				mock.ExpectExec(`UPDATE audio_comments`).WithArgs("new", input.StartMs, input.EndMs, 3).WillReturnError(errors.New("query error"))
+				mock.ExpectRollback()
+			},
+			expectedErrType: errors.New("query error"),
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior(testCase.input)
+
+			err := r.UpdateComment(1, 2, 3, testCase.input)
+			if testCase.expectedErrType != nil {
+				assert.Equal(t, testCase.expectedErrType, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestCommentPostgres_DeleteComment(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+	db := sqlx.NewDb(mockDB, "sqlmock")
+
+	r := NewCommentPostgres(db)
+
+	t.Run("OK", func(t *testing.T) {
+		mock.ExpectExec(`DELETE FROM audio_comments c WHERE comment_id = \$1 AND audio_id = \$2 AND \(user_id = \$3 OR EXISTS \(SELECT 1 FROM audios a (.+)\)\)`).
+			WithArgs(3, 2, 1).WillReturnResult(sqlmock.NewResult(0, 1))
+
+		assert.NoError(t, r.DeleteComment(1, 2, 3))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Not found", func(t *testing.T) {
+		mock.ExpectExec(`DELETE FROM audio_comments`).WithArgs(3, 2, 1).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		assert.Equal(t, storage.CommentNotFound, r.DeleteComment(1, 2, 3))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
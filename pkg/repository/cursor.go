@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	storage "github.com/mahadeva604/audio-storage"
+)
+
+// cursor points to the first or the last row of a page. It is opaque for
+// clients and only valid for the order it was made for
+type cursor struct {
+	Order  string        `json:"o"`
+	Values []interface{} `json:"v"`
+	Prev   bool          `json:"p,omitempty"`
+}
+
+func encodeCursor(order sortOrder, values []interface{}, prev bool) string {
+	data, _ := json.Marshal(cursor{Order: order.name(), Values: values, Prev: prev})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(s string, order sortOrder) (cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return cursor{}, storage.InvalidCursor
+	}
+
+	// numbers are kept as strings so that big ids and sizes don't lose precision
+	var c cursor
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&c); err != nil {
+		return cursor{}, storage.InvalidCursor
+	}
+
+	if c.Order != order.name() || len(c.Values) != len(order.keys) {
+		return cursor{}, storage.InvalidCursor
+	}
+
+	for _, value := range c.Values {
+		switch value.(type) {
+		case json.Number, string, bool:
+		default:
+			return cursor{}, storage.InvalidCursor
+		}
+	}
+
+	return c, nil
+}
+
+// pageCursors returns cursors of neighbour pages. More tells if a row was
+// fetched beyond the page, first and last are sort key values of the first
+// and the last row of the page
+func pageCursors(order sortOrder, c *cursor, more bool, first, last []interface{}) (next, prev string) {
+	backward := c != nil && c.Prev
+
+	// going backward there is always a next page, the one cursor came from
+	if more || backward {
+		next = encodeCursor(order, last, false)
+	}
+
+	if (more && backward) || (c != nil && !backward) {
+		prev = encodeCursor(order, first, true)
+	}
+
+	return next, prev
+}
@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"encoding/json"
+	storage "github.com/mahadeva604/audio-storage"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestSortOrder_after(t *testing.T) {
+	order := sortOrder{keys: []sortKey{
+		{alias: "is_owner", expr: "(user_id = $1)", desc: true},
+		{alias: "title", expr: "title"},
+		{alias: "audio_id", expr: "audio_id"},
+	}}
+
+	b := newQueryBuilder(1)
+	condition := order.after(b, []interface{}{true, "title", 5})
+
+	assert.Equal(t, "(((user_id = $1) < $2) OR ((user_id = $1) = $2 AND title > $3) OR ((user_id = $1) = $2 AND title = $3 AND audio_id > $4))", condition)
+	assert.Equal(t, []interface{}{1, true, "title", 5}, b.args)
+	assert.Equal(t, "o.is_owner DESC, o.title, o.audio_id", order.sql("o."))
+	assert.Equal(t, "is_owner, title DESC, audio_id DESC", order.reverse().sql(""))
+}
+
+func TestCursor(t *testing.T) {
+	order := sortOrder{keys: []sortKey{{alias: "size", expr: "size", desc: true}, {alias: "audio_id", expr: "audio_id", desc: true}}}
+	other := sortOrder{keys: []sortKey{{alias: "title", expr: "title"}, {alias: "audio_id", expr: "audio_id"}}}
+
+	encoded := encodeCursor(order, []interface{}{int64(9007199254740993), 7}, true)
+
+	c, err := decodeCursor(encoded, order)
+	assert.NoError(t, err)
+	assert.Equal(t, cursor{Order: order.name(), Values: []interface{}{json.Number("9007199254740993"), json.Number("7")}, Prev: true}, c)
+
+	_, err = decodeCursor(encoded, other)
+	assert.Equal(t, storage.InvalidCursor, err)
+
+	_, err = decodeCursor("not a cursor", order)
+	assert.Equal(t, storage.InvalidCursor, err)
+
+	nested := encodeCursor(order, []interface{}{[]int{1}, 7}, false)
+	_, err = decodeCursor(nested, order)
+	assert.Equal(t, storage.InvalidCursor, err)
+}
+
+func TestPageCursors(t *testing.T) {
+	order := sortOrder{keys: []sortKey{{alias: "audio_id", expr: "audio_id"}}}
+	first, last := []interface{}{1}, []interface{}{2}
+
+	testTable := []struct {
+		name         string
+		cursor       *cursor
+		more         bool
+		expectedNext string
+		expectedPrev string
+	}{
+		{
+			name: "Only page",
+		},
+		{
+			name:         "First page",
+			more:         true,
+			expectedNext: encodeCursor(order, last, false),
+		},
+		{
+			name:         "Middle page forward",
+			cursor:       &cursor{},
+			more:         true,
+			expectedNext: encodeCursor(order, last, false),
+			expectedPrev: encodeCursor(order, first, true),
+		},
+		{
+			name:         "Last page",
+			cursor:       &cursor{},
+			expectedPrev: encodeCursor(order, first, true),
+		},
+		{
+			name:         "Middle page backward",
+			cursor:       &cursor{Prev: true},
+			more:         true,
+			expectedNext: encodeCursor(order, last, false),
+			expectedPrev: encodeCursor(order, first, true),
+		},
+		{
+			name:         "First page backward",
+			cursor:       &cursor{Prev: true},
+			expectedNext: encodeCursor(order, last, false),
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			next, prev := pageCursors(order, testCase.cursor, testCase.more, first, last)
+			assert.Equal(t, testCase.expectedNext, next)
+			assert.Equal(t, testCase.expectedPrev, prev)
+		})
+	}
+}
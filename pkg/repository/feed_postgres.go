@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	storage "github.com/mahadeva604/audio-storage"
+)
+
+type FeedPostgres struct {
+	db *sqlx.DB
+}
+
+func NewFeedPostgres(db *sqlx.DB) *FeedPostgres {
+	return &FeedPostgres{db: db}
+}
+
+func (r *FeedPostgres) PublishFeed(userID, collectionId int, token string, input storage.FeedInput) (string, error) {
+	// Token is kept when an already published feed is updated
+	var feedToken string
+	query := fmt.Sprintf(`INSERT INTO %s (collection_id, token, description, author, image_url, language, category, explicit)
+								SELECT collection_id, $1, $2, $3, $4, $5, $6, $7 FROM %s
+								WHERE collection_id = $8 AND user_id = $9
+								ON CONFLICT (collection_id) DO
+								UPDATE SET description = excluded.description, author = excluded.author,
+								image_url = excluded.image_url, language = excluded.language,
+								category = excluded.category, explicit = excluded.explicit
+								RETURNING token`, feedsTable, collectionsTable)
+	err := r.db.Get(&feedToken, query, token, input.Description, input.Author, input.ImageUrl,
+		input.Language, input.Category, input.Explicit, collectionId, userID)
+
+	if err == sql.ErrNoRows {
+		err = storage.NotCollectionOwner
+	}
+
+	return feedToken, err
+}
+
+func (r *FeedPostgres) GetFeedSettings(userID, collectionId int) (storage.FeedSettings, error) {
+	var settings storage.FeedSettings
+	query := fmt.Sprintf(`SELECT token, description, author, image_url, language, category, explicit
+								FROM %s f JOIN %s c USING (collection_id)
+								WHERE collection_id = $1 AND c.user_id = $2`, feedsTable, collectionsTable)
+	err := r.db.Get(&settings, query, collectionId, userID)
+
+	if err == sql.ErrNoRows {
+		err = storage.FeedNotFound
+	}
+
+	return settings, err
+}
+
+func (r *FeedPostgres) RotateFeedToken(userID, collectionId int, token string) error {
+	query := fmt.Sprintf(`UPDATE %s SET token = $1 WHERE collection_id = (SELECT collection_id FROM %s
+								WHERE collection_id = $2 AND user_id = $3)`, feedsTable, collectionsTable)
+	result, err := r.db.Exec(query, token, collectionId, userID)
+
+	return checkAffected(result, err, storage.FeedNotFound)
+}
+
+func (r *FeedPostgres) DeleteFeed(userID, collectionId int) error {
+	query := fmt.Sprintf(`DELETE FROM %s WHERE collection_id = (SELECT collection_id FROM %s
+								WHERE collection_id = $1 AND user_id = $2)`, feedsTable, collectionsTable)
+	result, err := r.db.Exec(query, collectionId, userID)
+
+	return checkAffected(result, err, storage.FeedNotFound)
+}
+
+func (r *FeedPostgres) GetFeedChannel(token string) (storage.FeedChannel, error) {
+	var channel storage.FeedChannel
+	query := fmt.Sprintf(`SELECT f.collection_id, c.title, u.name, f.token, f.description, f.author, f.image_url, f.language, f.category, f.explicit
+								FROM %s f
+								JOIN %s c USING (collection_id)
+								JOIN %s u ON c.user_id = u.user_id
+								WHERE f.token = $1`, feedsTable, collectionsTable, usersTable)
+	err := r.db.Get(&channel, query, token)
+
+	if err == sql.ErrNoRows {
+		err = storage.FeedNotFound
+	}
+
+	return channel, err
+}
+
+// GetCollectionChannel returns channel of collection which isn't published yet,
+// feed settings are left empty
+func (r *FeedPostgres) GetCollectionChannel(userID, collectionId int) (storage.FeedChannel, error) {
+	var channel storage.FeedChannel
+	query := fmt.Sprintf(`SELECT c.collection_id, c.title, u.name
+								FROM %s c JOIN %s u ON c.user_id = u.user_id
+								WHERE c.collection_id = $1 AND c.user_id = $2`, collectionsTable, usersTable)
+	err := r.db.Get(&channel, query, collectionId, userID)
+
+	if err == sql.ErrNoRows {
+		err = storage.NotCollectionOwner
+	}
+
+	return channel, err
+}
+
+// GetFeedEpisodes returns items of collection uploaded by its owner, audio
+// shared with the owner isn't published without consent of its uploader
+func (r *FeedPostgres) GetFeedEpisodes(collectionId int) ([]storage.FeedEpisode, error) {
+	query := fmt.Sprintf(`SELECT a.audio_id, a.title, a.duration, a.file_path, a.size, ci.added_at
+								FROM %s c
+								JOIN %s ci USING (collection_id)
+								JOIN %s a ON ci.audio_id = a.audio_id
+								WHERE c.collection_id = $1 AND a.user_id = c.user_id
+								ORDER BY ci.position`, collectionsTable, collectionItemsTable, audiosTable)
+
+	episodes := make([]storage.FeedEpisode, 0)
+	err := r.db.Select(&episodes, query, collectionId)
+
+	return episodes, err
+}
+
+// GetFeedFile returns audio only while it is an episode of feed published with
+// token, so that links stop working when feed is deleted, rotated or item is removed
+func (r *FeedPostgres) GetFeedFile(audioId, collectionId int, token string) (storage.DownloadAudio, error) {
+	var audio storage.DownloadAudio
+	query := fmt.Sprintf(`SELECT a.title, a.file_path
+								FROM %s f
+								JOIN %s c USING (collection_id)
+								JOIN %s ci USING (collection_id)
+								JOIN %s a ON ci.audio_id = a.audio_id
+								WHERE a.audio_id = $1 AND f.collection_id = $2 AND f.token = $3 AND a.user_id = c.user_id`,
+		feedsTable, collectionsTable, collectionItemsTable, audiosTable)
+	err := r.db.Get(&audio, query, audioId, collectionId, token)
+
+	if err == sql.ErrNoRows {
+		err = storage.FileNotFound
+	}
+
+	return audio, err
+}
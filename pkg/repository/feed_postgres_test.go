@@ -0,0 +1,376 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	storage "github.com/mahadeva604/audio-storage"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestFeedPostgres_PublishFeed(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+	db := sqlx.NewDb(mockDB, "sqlmock")
+
+	r := NewFeedPostgres(db)
+	type mockBehavior func(userId, collectionId int, token string, input storage.FeedInput)
+
+	input := storage.FeedInput{
+		Description: "description",
+		Author:      "author",
+		ImageUrl:    "https://example.com/cover.png",
+		Language:    "en",
+		Category:    "Technology",
+	}
+
+	testTable := []struct {
+		name            string
+		userId          int
+		collectionId    int
+		token           string
+		mockBehavior    mockBehavior
+		expectedToken   string
+		expectedErr     bool
+		expectedErrType error
+	}{
+		{
+			name:         "OK new feed",
+			userId:       1,
+			collectionId: 2,
+			token:        "new token",
+			mockBehavior: func(userId, collectionId int, token string, input storage.FeedInput) {
+				rows := sqlmock.NewRows([]string{"token"}).AddRow(token)
+				mock.ExpectQuery("INSERT INTO collection_feeds (.+) ON CONFLICT (.+) RETURNING token").
+					WithArgs(token, input.Description, input.Author, input.ImageUrl, input.Language, input.Category, input.Explicit, collectionId, userId).
+					WillReturnRows(rows)
+			},
+			expectedToken: "new token",
+		},
+		{
+			name:         "OK existing feed keeps token",
+			userId:       1,
+			collectionId: 2,
+			token:        "new token",
+			mockBehavior: func(userId, collectionId int, token string, input storage.FeedInput) {
+				rows := sqlmock.NewRows([]string{"token"}).AddRow("old token")
+				mock.ExpectQuery("INSERT INTO collection_feeds (.+) ON CONFLICT (.+) RETURNING token").
+					WithArgs(token, input.Description, input.Author, input.ImageUrl, input.Language, input.Category, input.Explicit, collectionId, userId).
+					WillReturnRows(rows)
+			},
+			expectedToken: "old token",
+		},
+		{
+			name:         "Error not owner",
+			userId:       1,
+			collectionId: 2,
+			token:        "new token",
+			mockBehavior: func(userId, collectionId int, token string, input storage.FeedInput) {
+				mock.ExpectQuery("INSERT INTO collection_feeds (.+) ON CONFLICT (.+) RETURNING token").
+					WithArgs(token, input.Description, input.Author, input.ImageUrl, input.Language, input.Category, input.Explicit, collectionId, userId).
+					WillReturnError(sql.ErrNoRows)
+			},
+			expectedErr:     true,
+			expectedErrType: storage.NotCollectionOwner,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior(testCase.userId, testCase.collectionId, testCase.token, input)
+
+			gotToken, err := r.PublishFeed(testCase.userId, testCase.collectionId, testCase.token, input)
+			if testCase.expectedErr {
+				assert.Error(t, err)
+				if testCase.expectedErrType != nil {
+					assert.Equal(t, testCase.expectedErrType, err)
+				}
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, testCase.expectedToken, gotToken)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestFeedPostgres_GetFeedSettings(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+	db := sqlx.NewDb(mockDB, "sqlmock")
+
+	r := NewFeedPostgres(db)
+	type mockBehavior func(userId, collectionId int)
+
+	testTable := []struct {
+		name            string
+		userId          int
+		collectionId    int
+		mockBehavior    mockBehavior
+		expectedData    storage.FeedSettings
+		expectedErr     bool
+		expectedErrType error
+	}{
+		{
+			name:         "OK",
+			userId:       1,
+			collectionId: 2,
+			mockBehavior: func(userId, collectionId int) {
+				rows := sqlmock.NewRows([]string{"token", "description", "author", "image_url", "language", "category", "explicit"}).
+					AddRow("token", "description", "author", "https://example.com/cover.png", "en", "Technology", true)
+				mock.ExpectQuery("SELECT (.+) FROM collection_feeds f JOIN collections c (.+)").WithArgs(collectionId, userId).WillReturnRows(rows)
+			},
+			expectedData: storage.FeedSettings{
+				Token:       "token",
+				Description: "description",
+				Author:      "author",
+				ImageUrl:    "https://example.com/cover.png",
+				Language:    "en",
+				Category:    "Technology",
+				Explicit:    true,
+			},
+		},
+		{
+			name:         "Error not found",
+			userId:       1,
+			collectionId: 2,
+			mockBehavior: func(userId, collectionId int) {
+				mock.ExpectQuery("SELECT (.+) FROM collection_feeds f JOIN collections c (.+)").WithArgs(collectionId, userId).WillReturnError(sql.ErrNoRows)
+			},
+			expectedErr:     true,
+			expectedErrType: storage.FeedNotFound,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior(testCase.userId, testCase.collectionId)
+
+			settings, err := r.GetFeedSettings(testCase.userId, testCase.collectionId)
+			if testCase.expectedErr {
+				assert.Error(t, err)
+				if testCase.expectedErrType != nil {
+					assert.Equal(t, testCase.expectedErrType, err)
+				}
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, testCase.expectedData, settings)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestFeedPostgres_UpdateFeed(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+	db := sqlx.NewDb(mockDB, "sqlmock")
+
+	r := NewFeedPostgres(db)
+	type mockBehavior func(userId, collectionId int)
+
+	testTable := []struct {
+		name            string
+		remove          bool
+		userId          int
+		collectionId    int
+		mockBehavior    mockBehavior
+		expectedErr     bool
+		expectedErrType error
+	}{
+		{
+			name:         "OK rotate",
+			userId:       1,
+			collectionId: 2,
+			mockBehavior: func(userId, collectionId int) {
+				mock.ExpectExec("UPDATE collection_feeds SET token = (.+)").WithArgs("token", collectionId, userId).WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			name:         "Error rotate not found",
+			userId:       1,
+			collectionId: 2,
+			mockBehavior: func(userId, collectionId int) {
+				mock.ExpectExec("UPDATE collection_feeds SET token = (.+)").WithArgs("token", collectionId, userId).WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			expectedErr:     true,
+			expectedErrType: storage.FeedNotFound,
+		},
+		{
+			name:         "OK delete",
+			remove:       true,
+			userId:       1,
+			collectionId: 2,
+			mockBehavior: func(userId, collectionId int) {
+				mock.ExpectExec("DELETE FROM collection_feeds").WithArgs(collectionId, userId).WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			name:         "Error delete",
+			remove:       true,
+			userId:       1,
+			collectionId: 2,
+			mockBehavior: func(userId, collectionId int) {
+				mock.ExpectExec("DELETE FROM collection_feeds").WithArgs(collectionId, userId).WillReturnError(errors.New("query error"))
+			},
+			expectedErr:     true,
+			expectedErrType: errors.New("query error"),
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior(testCase.userId, testCase.collectionId)
+
+			if testCase.remove {
+				err = r.DeleteFeed(testCase.userId, testCase.collectionId)
+			} else {
+				err = r.RotateFeedToken(testCase.userId, testCase.collectionId, "token")
+			}
+
+			if testCase.expectedErr {
+				assert.Error(t, err)
+				if testCase.expectedErrType != nil {
+					assert.Equal(t, testCase.expectedErrType, err)
+				}
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestFeedPostgres_GetFeed(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+	db := sqlx.NewDb(mockDB, "sqlmock")
+
+	r := NewFeedPostgres(db)
+
+	token := "token"
+	addedAt := time.Date(2021, 6, 1, 10, 0, 0, 0, time.UTC)
+
+	t.Run("OK channel", func(t *testing.T) {
+		rows := sqlmock.NewRows([]string{"collection_id", "title", "name", "token", "description", "author", "image_url", "language", "category", "explicit"}).
+			AddRow(2, "collection", "user 1", token, "description", "", "https://example.com/cover.png", "en", "Technology", false)
+		mock.ExpectQuery(`SELECT (.+) FROM collection_feeds f (.+) WHERE f.token = \$1`).WithArgs(token).WillReturnRows(rows)
+
+		channel, err := r.GetFeedChannel(token)
+		assert.NoError(t, err)
+		assert.Equal(t, storage.FeedChannel{
+			CollectionId: 2,
+			Title:        "collection",
+			OwnerName:    "user 1",
+			FeedSettings: storage.FeedSettings{
+				Token:       token,
+				Description: "description",
+				ImageUrl:    "https://example.com/cover.png",
+				Language:    "en",
+				Category:    "Technology",
+			},
+		}, channel)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Error channel not found", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT (.+) FROM collection_feeds f (.+) WHERE f.token = \$1`).WithArgs(token).WillReturnError(sql.ErrNoRows)
+
+		_, err := r.GetFeedChannel(token)
+		assert.Equal(t, storage.FeedNotFound, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("OK episodes", func(t *testing.T) {
+		rows := sqlmock.NewRows([]string{"audio_id", "title", "duration", "file_path", "size", "added_at"}).
+			AddRow(3, "audio 3", 120, "file path 3", 1024, addedAt)
+		mock.ExpectQuery(`SELECT a.audio_id, a.title, a.duration, a.file_path, a.size, ci.added_at
+								FROM collections c (.+) WHERE c.collection_id = \$1 AND a.user_id = c.user_id
+								ORDER BY ci.position`).WithArgs(2).WillReturnRows(rows)
+
+		episodes, err := r.GetFeedEpisodes(2)
+		assert.NoError(t, err)
+		assert.Equal(t, []storage.FeedEpisode{
+			{AudioId: 3, Title: "audio 3", Duration: 120, FilePath: "file path 3", Size: 1024, AddedAt: addedAt},
+		}, episodes)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("OK shared audio isn't published", func(t *testing.T) {
+		// audio 4 of the collection is only shared with its owner
+		rows := sqlmock.NewRows([]string{"audio_id", "title", "duration", "file_path", "size", "added_at"})
+		mock.ExpectQuery(`SELECT (.+) WHERE c.collection_id = \$1 AND a.user_id = c.user_id`).WithArgs(3).WillReturnRows(rows)
+
+		episodes, err := r.GetFeedEpisodes(3)
+		assert.NoError(t, err)
+		assert.Equal(t, []storage.FeedEpisode{}, episodes)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("OK collection channel", func(t *testing.T) {
+		rows := sqlmock.NewRows([]string{"collection_id", "title", "name"}).AddRow(2, "collection", "user 1")
+		mock.ExpectQuery(`SELECT c.collection_id, c.title, u.name
+								FROM collections c JOIN users u ON c.user_id = u.user_id
+								WHERE c.collection_id = \$1 AND c.user_id = \$2`).WithArgs(2, 1).WillReturnRows(rows)
+
+		channel, err := r.GetCollectionChannel(1, 2)
+		assert.NoError(t, err)
+		assert.Equal(t, storage.FeedChannel{CollectionId: 2, Title: "collection", OwnerName: "user 1"}, channel)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Error not collection owner", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT c.collection_id, c.title, u.name`).WithArgs(2, 5).WillReturnError(sql.ErrNoRows)
+
+		_, err := r.GetCollectionChannel(5, 2)
+		assert.Equal(t, storage.NotCollectionOwner, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("OK file", func(t *testing.T) {
+		rows := sqlmock.NewRows([]string{"title", "file_path"}).AddRow("audio 3", "file path 3")
+		mock.ExpectQuery(`SELECT a.title, a.file_path
+								FROM collection_feeds f
+								JOIN collections c USING \(collection_id\)
+								JOIN collection_items ci USING \(collection_id\)
+								JOIN audios a ON ci.audio_id = a.audio_id
+								WHERE a.audio_id = \$1 AND f.collection_id = \$2 AND f.token = \$3 AND a.user_id = c.user_id`).
+			WithArgs(3, 2, token).WillReturnRows(rows)
+
+		audio, err := r.GetFeedFile(3, 2, token)
+		assert.NoError(t, err)
+		assert.Equal(t, storage.DownloadAudio{Title: "audio 3", FilePath: "file path 3"}, audio)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Error shared file", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT a.title, a.file_path FROM collection_feeds f (.+) AND a.user_id = c.user_id`).WithArgs(4, 2, token).WillReturnError(sql.ErrNoRows)
+
+		_, err := r.GetFeedFile(4, 2, token)
+		assert.Equal(t, storage.FileNotFound, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Error file not in feed", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT a.title, a.file_path FROM collection_feeds f`).WithArgs(3, 2, "rotated").WillReturnError(sql.ErrNoRows)
+
+		_, err := r.GetFeedFile(3, 2, "rotated")
+		assert.Equal(t, storage.FileNotFound, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,166 @@
+package repository
+
+import (
+	"encoding/binary"
+	"fmt"
+	"github.com/google/uuid"
+	storage "github.com/mahadeva604/audio-storage"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	hlsDir = "hls"
+	// hlsClock is clock rate of MPEG-2 transport stream timestamps
+	hlsClock = 90000
+)
+
+// PackageHLS splits stored file into packed audio segments with VOD playlist,
+// previous package of the file is replaced
+func (r StorageFS) PackageHLS(fileId uuid.UUID) error {
+	file, err := os.Open(r.FilePath(fileId))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	dir := r.hlsPath(fileId)
+	tmp := dir + tmpExt
+	if err := os.RemoveAll(tmp); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(tmp, 0755); err != nil {
+		return err
+	}
+
+	p := hlsPackager{dir: tmp}
+	stream, err := scanADTS(file, p.add)
+	if err == nil {
+		err = p.close()
+	}
+	if err == nil && stream.frames == 0 {
+		err = storage.NotAacFile
+	}
+	if err == nil {
+		err = writeHLSPlaylist(filepath.Join(tmp, storage.HLSPlaylist), p.segments, stream.sampleRate)
+	}
+	if err != nil {
+		p.close()
+		os.RemoveAll(tmp)
+		return err
+	}
+
+	if err := os.RemoveAll(dir); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, dir)
+}
+
+// GetHLSFile returns playlist or segment of HLS package of stored file
+func (r StorageFS) GetHLSFile(fileId uuid.UUID, name string) ([]byte, error) {
+	if !storage.HLSFile(name) {
+		return nil, storage.HLSNotFound
+	}
+
+	data, err := os.ReadFile(filepath.Join(r.hlsPath(fileId), name))
+	if os.IsNotExist(err) {
+		return nil, storage.HLSNotFound
+	}
+
+	return data, err
+}
+
+func (r StorageFS) hlsPath(fileId uuid.UUID) string {
+	return filepath.Join(r.dirPath, hlsDir, fileId.String())
+}
+
+// hlsPackager writes frames to segments of storage.HLSSegmentDuration
+type hlsPackager struct {
+	dir      string
+	out      *os.File
+	start    int64
+	samples  int64
+	segments []int64
+}
+
+func (p *hlsPackager) add(frame []byte, info adtsFrame) error {
+	segment := int64(storage.HLSSegmentDuration.Seconds()) * int64(info.sampleRate)
+	if p.out != nil && p.samples-p.start >= segment {
+		if err := p.close(); err != nil {
+			return err
+		}
+	}
+
+	if p.out == nil {
+		name := fmt.Sprintf("segment%05d.aac", len(p.segments))
+		out, err := os.Create(filepath.Join(p.dir, name))
+		if err != nil {
+			return err
+		}
+		p.out = out
+		p.start = p.samples
+
+		pts := p.start * hlsClock / int64(info.sampleRate)
+		if _, err := p.out.Write(hlsTimestamp(pts)); err != nil {
+			return err
+		}
+	}
+
+	p.samples += int64(info.samples)
+	_, err := p.out.Write(frame)
+
+	return err
+}
+
+// close finishes current segment and records its length in samples
+func (p *hlsPackager) close() error {
+	if p.out == nil {
+		return nil
+	}
+
+	err := p.out.Close()
+	p.out = nil
+	p.segments = append(p.segments, p.samples-p.start)
+
+	return err
+}
+
+func writeHLSPlaylist(path string, segments []int64, sampleRate int) error {
+	target := 0
+	for _, samples := range segments {
+		if d := int(math.Ceil(float64(samples) / float64(sampleRate))); d > target {
+			target = d
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "#EXTM3U\n#EXT-X-VERSION:3\n#EXT-X-TARGETDURATION:%d\n#EXT-X-MEDIA-SEQUENCE:0\n#EXT-X-PLAYLIST-TYPE:VOD\n", target)
+	for i, samples := range segments {
+		fmt.Fprintf(&b, "#EXTINF:%.3f,\nsegment%05d.aac\n", float64(samples)/float64(sampleRate), i)
+	}
+	b.WriteString("#EXT-X-ENDLIST\n")
+
+	return os.WriteFile(path, []byte(b.String()), 0644)
+}
+
+// hlsTimestamp returns ID3 tag with transport stream timestamp required at
+// the start of every packed audio segment, pts is in 90 kHz units
+func hlsTimestamp(pts int64) []byte {
+	const owner = "com.apple.streaming.transportStreamTimestamp\x00"
+
+	frame := []byte{'P', 'R', 'I', 'V'}
+	frame = append(frame, syncsafeBytes(len(owner)+8)...)
+	frame = append(frame, 0, 0)
+	frame = append(frame, owner...)
+	timestamp := make([]byte, 8)
+	binary.BigEndian.PutUint64(timestamp, uint64(pts)&(1<<33-1))
+	frame = append(frame, timestamp...)
+
+	tag := []byte{'I', 'D', '3', 4, 0, 0}
+	tag = append(tag, syncsafeBytes(len(frame))...)
+
+	return append(tag, frame...)
+}
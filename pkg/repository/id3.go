@@ -0,0 +1,574 @@
+package repository
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	storage "github.com/mahadeva604/audio-storage"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"unicode/utf16"
+)
+
+const (
+	id3v2HeaderSize = 10
+	id3v1Size       = 128
+	apeFooterSize   = 32
+
+	// apeHasHeader flag is set when APE tag has a header besides the footer
+	apeHasHeader = 1 << 31
+	// frontCover is ID3v2 picture type of front cover
+	frontCover = 3
+	// maxTocEntries is the most child elements CTOC frame can list
+	maxTocEntries = 255
+)
+
+// readTags reads leading ID3v2 and trailing APEv2 and ID3v1 tags of file.
+// It returns [start, end) range of audio stream between the tags. ID3v2
+// values take precedence over APE ones and APE over ID3v1
+func readTags(file io.ReaderAt, size int64) (storage.AudioTags, int64, int64, error) {
+	var id3v2, ape, id3v1 storage.AudioTags
+	start, end := int64(0), size
+
+	// several ID3v2 tags may be prepended one after another
+	for end-start >= id3v2HeaderSize {
+		header := make([]byte, id3v2HeaderSize)
+		if _, err := file.ReadAt(header, start); err != nil {
+			return storage.AudioTags{}, 0, 0, err
+		}
+		if string(header[:3]) != "ID3" || !syncsafe(header[6:10]) {
+			break
+		}
+
+		tagSize := int64(syncsafeInt(header[6:10]))
+		total := id3v2HeaderSize + tagSize
+		if header[3] == 4 && header[5]&0x10 != 0 {
+			// footer
+			total += id3v2HeaderSize
+		}
+		if total > end-start {
+			return storage.AudioTags{}, 0, 0, storage.NotAacFile
+		}
+
+		body := make([]byte, tagSize)
+		if _, err := file.ReadAt(body, start+id3v2HeaderSize); err != nil {
+			return storage.AudioTags{}, 0, 0, err
+		}
+		mergeTags(&id3v2, parseID3v2(header, body))
+		start += total
+	}
+
+	if end-start >= id3v1Size {
+		tag := make([]byte, id3v1Size)
+		if _, err := file.ReadAt(tag, end-id3v1Size); err != nil {
+			return storage.AudioTags{}, 0, 0, err
+		}
+		if string(tag[:3]) == "TAG" {
+			id3v1 = parseID3v1(tag)
+			end -= id3v1Size
+		}
+	}
+
+	if end-start >= apeFooterSize {
+		footer := make([]byte, apeFooterSize)
+		if _, err := file.ReadAt(footer, end-apeFooterSize); err != nil {
+			return storage.AudioTags{}, 0, 0, err
+		}
+		if string(footer[:8]) == "APETAGEX" {
+			// size includes items and footer but not header
+			tagSize := int64(binary.LittleEndian.Uint32(footer[12:16]))
+			total := tagSize
+			if binary.LittleEndian.Uint32(footer[20:24])&apeHasHeader != 0 {
+				total += apeFooterSize
+			}
+			if tagSize < apeFooterSize || total > end-start {
+				return storage.AudioTags{}, 0, 0, storage.NotAacFile
+			}
+
+			items := make([]byte, tagSize-apeFooterSize)
+			if _, err := file.ReadAt(items, end-tagSize); err != nil {
+				return storage.AudioTags{}, 0, 0, err
+			}
+			ape = parseAPE(items, int(binary.LittleEndian.Uint32(footer[16:20])))
+			end -= total
+		}
+	}
+
+	mergeTags(&id3v2, ape)
+	mergeTags(&id3v2, id3v1)
+
+	return id3v2, start, end, nil
+}
+
+// mergeTags fills empty values of dst from src
+func mergeTags(dst *storage.AudioTags, src storage.AudioTags) {
+	if dst.Title == "" {
+		dst.Title = src.Title
+	}
+	if dst.Artist == "" {
+		dst.Artist = src.Artist
+	}
+	if dst.Album == "" {
+		dst.Album = src.Album
+	}
+	if dst.Cover == nil {
+		dst.Cover = src.Cover
+	}
+	if dst.Chapters == nil {
+		dst.Chapters = src.Chapters
+	}
+}
+
+// parseID3v2 reads text, picture and chapter frames of ID3v2.2, v2.3 and
+// v2.4 tags, compressed and encrypted frames are skipped
+func parseID3v2(header, body []byte) storage.AudioTags {
+	var tags storage.AudioTags
+
+	version, flags := header[3], header[5]
+	if version < 2 || version > 4 || (version == 2 && flags&0x40 != 0) {
+		return tags
+	}
+
+	if version < 4 && flags&0x80 != 0 {
+		body = removeUnsync(body)
+	}
+
+	if version > 2 && flags&0x40 != 0 && len(body) >= 4 {
+		// extended header, in v2.3 its size doesn't include size field itself
+		extSize := syncsafeInt(body[:4])
+		if version == 3 {
+			extSize = int(binary.BigEndian.Uint32(body[:4])) + 4
+		}
+		if extSize > len(body) {
+			return tags
+		}
+		body = body[extSize:]
+	}
+
+	isFront := false
+	forEachFrame(version, body, func(id string, data []byte) {
+		switch id {
+		case "TIT2", "TT2":
+			tags.Title = decodeText(data)
+		case "TPE1", "TP1":
+			tags.Artist = decodeText(data)
+		case "TALB", "TAL":
+			tags.Album = decodeText(data)
+		case "APIC", "PIC":
+			picture, pictureType, ok := decodePicture(id, data)
+			if ok && (tags.Cover == nil || (!isFront && pictureType == frontCover)) {
+				tags.Cover = picture
+				isFront = pictureType == frontCover
+			}
+		case "CHAP":
+			if chapter, ok := decodeChapter(version, data); ok {
+				tags.Chapters = append(tags.Chapters, chapter)
+			}
+		}
+	})
+
+	sort.Slice(tags.Chapters, func(i, j int) bool {
+		return tags.Chapters[i].StartMs < tags.Chapters[j].StartMs
+	})
+
+	return tags
+}
+
+// forEachFrame calls fn with id and data of every readable frame in body,
+// it stops at padding or at a frame which doesn't fit
+func forEachFrame(version byte, body []byte, fn func(id string, data []byte)) {
+	idSize, headerSize := 4, 10
+	if version == 2 {
+		idSize, headerSize = 3, 6
+	}
+
+	for len(body) >= headerSize && body[0] != 0 {
+		id := string(body[:idSize])
+
+		var frameSize int
+		var frameFlags uint16
+		switch version {
+		case 2:
+			frameSize = int(body[3])<<16 | int(body[4])<<8 | int(body[5])
+		case 3:
+			frameSize = int(binary.BigEndian.Uint32(body[4:8]))
+			frameFlags = binary.BigEndian.Uint16(body[8:10])
+		case 4:
+			frameSize = syncsafeInt(body[4:8])
+			frameFlags = binary.BigEndian.Uint16(body[8:10])
+		}
+		if frameSize < 0 || frameSize > len(body)-headerSize {
+			return
+		}
+
+		data := body[headerSize : headerSize+frameSize]
+		body = body[headerSize+frameSize:]
+
+		if data, ok := frameData(version, frameFlags, data); ok {
+			fn(id, data)
+		}
+	}
+}
+
+// decodeChapter reads start time, TIT2 title and WXXX url of CHAP frame,
+// end time and byte offsets are ignored as chapter lasts until the next one
+func decodeChapter(version byte, data []byte) (storage.Chapter, bool) {
+	end := bytes.IndexByte(data, 0)
+	if end < 0 || len(data)-end-1 < 16 {
+		return storage.Chapter{}, false
+	}
+	data = data[end+1:]
+
+	chapter := storage.Chapter{StartMs: int(binary.BigEndian.Uint32(data[:4]))}
+	forEachFrame(version, data[16:], func(id string, data []byte) {
+		switch id {
+		case "TIT2":
+			chapter.Title = decodeText(data)
+		case "WXXX":
+			chapter.Url = decodeUserUrl(data)
+		}
+	})
+
+	return chapter, true
+}
+
+// decodeUserUrl returns url of WXXX frame, description is skipped
+func decodeUserUrl(data []byte) string {
+	if len(data) < 1 {
+		return ""
+	}
+	encoding, data := data[0], data[1:]
+
+	end := textEnd(encoding, data)
+	if end < 0 {
+		return ""
+	}
+
+	return decodeText(append([]byte{0}, data[end:]...))
+}
+
+// frameData strips grouping and data length bytes of frame and reverses
+// unsynchronisation. It returns false for frames which can't be read
+func frameData(version byte, flags uint16, data []byte) ([]byte, bool) {
+	switch version {
+	case 3:
+		if flags&0x00C0 != 0 {
+			return nil, false
+		}
+		if flags&0x0020 != 0 {
+			if len(data) < 1 {
+				return nil, false
+			}
+			data = data[1:]
+		}
+	case 4:
+		if flags&0x000C != 0 {
+			return nil, false
+		}
+		if flags&0x0040 != 0 {
+			if len(data) < 1 {
+				return nil, false
+			}
+			data = data[1:]
+		}
+		if flags&0x0001 != 0 {
+			if len(data) < 4 {
+				return nil, false
+			}
+			data = data[4:]
+		}
+		if flags&0x0002 != 0 {
+			data = removeUnsync(data)
+		}
+	}
+
+	return data, true
+}
+
+// decodePicture returns image data and picture type of APIC or v2.2 PIC frame
+func decodePicture(id string, data []byte) ([]byte, byte, bool) {
+	if len(data) < 1 {
+		return nil, 0, false
+	}
+	encoding, data := data[0], data[1:]
+
+	// MIME type in APIC, three chars image format in PIC
+	if id == "PIC" {
+		if len(data) < 3 {
+			return nil, 0, false
+		}
+		data = data[3:]
+	} else {
+		end := bytes.IndexByte(data, 0)
+		if end < 0 {
+			return nil, 0, false
+		}
+		data = data[end+1:]
+	}
+
+	if len(data) < 1 {
+		return nil, 0, false
+	}
+	pictureType, data := data[0], data[1:]
+
+	// skip description
+	end := textEnd(encoding, data)
+	if end < 0 {
+		return nil, 0, false
+	}
+	data = data[end:]
+
+	if len(data) == 0 || !strings.HasPrefix(http.DetectContentType(data), "image/") {
+		return nil, 0, false
+	}
+
+	return data, pictureType, true
+}
+
+// textEnd returns index after terminator of text in encoding or -1
+func textEnd(encoding byte, data []byte) int {
+	if encoding == 1 || encoding == 2 {
+		for i := 0; i+1 < len(data); i += 2 {
+			if data[i] == 0 && data[i+1] == 0 {
+				return i + 2
+			}
+		}
+		return -1
+	}
+
+	end := bytes.IndexByte(data, 0)
+	if end < 0 {
+		return -1
+	}
+
+	return end + 1
+}
+
+// decodeText decodes text frame, only the first of multiple values is returned
+func decodeText(data []byte) string {
+	if len(data) < 1 {
+		return ""
+	}
+	encoding, data := data[0], data[1:]
+
+	var text string
+	switch encoding {
+	case 0:
+		text = decodeLatin1(data)
+	case 1:
+		text = decodeUTF16(data, true)
+	case 2:
+		text = decodeUTF16(data, false)
+	case 3:
+		text = string(data)
+	}
+
+	if end := strings.IndexByte(text, 0); end >= 0 {
+		text = text[:end]
+	}
+
+	return strings.TrimSpace(text)
+}
+
+func decodeLatin1(data []byte) string {
+	runes := make([]rune, len(data))
+	for i, b := range data {
+		runes[i] = rune(b)
+	}
+
+	return string(runes)
+}
+
+// decodeUTF16 decodes big endian UTF-16, byte order mark is read if bom is set
+func decodeUTF16(data []byte, bom bool) string {
+	order := binary.ByteOrder(binary.BigEndian)
+	if bom && len(data) >= 2 {
+		if data[0] == 0xFF && data[1] == 0xFE {
+			order = binary.LittleEndian
+			data = data[2:]
+		} else if data[0] == 0xFE && data[1] == 0xFF {
+			data = data[2:]
+		}
+	}
+
+	units := make([]uint16, len(data)/2)
+	for i := range units {
+		units[i] = order.Uint16(data[2*i:])
+	}
+
+	return string(utf16.Decode(units))
+}
+
+func parseID3v1(tag []byte) storage.AudioTags {
+	field := func(data []byte) string {
+		if end := bytes.IndexByte(data, 0); end >= 0 {
+			data = data[:end]
+		}
+		return strings.TrimSpace(decodeLatin1(data))
+	}
+
+	return storage.AudioTags{
+		Title:  field(tag[3:33]),
+		Artist: field(tag[33:63]),
+		Album:  field(tag[63:93]),
+	}
+}
+
+// parseAPE reads text items and front cover of APEv2 tag
+func parseAPE(items []byte, count int) storage.AudioTags {
+	var tags storage.AudioTags
+
+	for i := 0; i < count && len(items) >= 8; i++ {
+		valueSize := int(binary.LittleEndian.Uint32(items[:4]))
+		itemFlags := binary.LittleEndian.Uint32(items[4:8])
+		items = items[8:]
+
+		keyEnd := bytes.IndexByte(items, 0)
+		if keyEnd < 0 || valueSize < 0 || valueSize > len(items)-keyEnd-1 {
+			break
+		}
+		key := strings.ToLower(string(items[:keyEnd]))
+		value := items[keyEnd+1 : keyEnd+1+valueSize]
+		items = items[keyEnd+1+valueSize:]
+
+		// bits 1-2 are item type, 0 is UTF-8 text
+		text := itemFlags&0x6 == 0
+		switch {
+		case key == "title" && text:
+			tags.Title = decodeText(append([]byte{3}, value...))
+		case key == "artist" && text:
+			tags.Artist = decodeText(append([]byte{3}, value...))
+		case key == "album" && text:
+			tags.Album = decodeText(append([]byte{3}, value...))
+		case key == "cover art (front)" && !text:
+			// file name goes before image data
+			if end := bytes.IndexByte(value, 0); end >= 0 && end+1 < len(value) &&
+				strings.HasPrefix(http.DetectContentType(value[end+1:]), "image/") {
+				tags.Cover = value[end+1:]
+			}
+		}
+	}
+
+	return tags
+}
+
+// encodeID3v2 makes ID3v2.3 tag with UTF-16 text frames, front cover and
+// chapters with table of contents, it returns nil if there is nothing to write
+func encodeID3v2(tags storage.AudioTags) []byte {
+	var frames bytes.Buffer
+	writeFrame := func(frames *bytes.Buffer, id string, data []byte) {
+		frames.WriteString(id)
+		binary.Write(frames, binary.BigEndian, uint32(len(data)))
+		frames.Write([]byte{0, 0})
+		frames.Write(data)
+	}
+
+	for _, frame := range []struct{ id, value string }{
+		{"TIT2", tags.Title},
+		{"TPE1", tags.Artist},
+		{"TALB", tags.Album},
+	} {
+		if frame.value != "" {
+			writeFrame(&frames, frame.id, encodeUTF16(frame.value))
+		}
+	}
+
+	if tags.Cover != nil {
+		var picture bytes.Buffer
+		picture.WriteByte(0)
+		picture.WriteString(http.DetectContentType(tags.Cover))
+		picture.Write([]byte{0, frontCover, 0})
+		picture.Write(tags.Cover)
+		writeFrame(&frames, "APIC", picture.Bytes())
+	}
+
+	if len(tags.Chapters) > 0 {
+		// chapters beyond table of contents capacity aren't written
+		chapters := tags.Chapters
+		if len(chapters) > maxTocEntries {
+			chapters = chapters[:maxTocEntries]
+		}
+
+		// top level table of contents with ordered child chapters
+		var toc bytes.Buffer
+		toc.WriteString("toc\x00")
+		toc.Write([]byte{0x03, byte(len(chapters))})
+		for i := range chapters {
+			fmt.Fprintf(&toc, "chp%d\x00", i)
+		}
+		writeFrame(&frames, "CTOC", toc.Bytes())
+
+		for i, chapter := range chapters {
+			end := tags.Duration * 1000
+			if i+1 < len(tags.Chapters) {
+				end = tags.Chapters[i+1].StartMs
+			}
+			if end <= chapter.StartMs {
+				end = chapter.StartMs + 1
+			}
+
+			var chap bytes.Buffer
+			fmt.Fprintf(&chap, "chp%d\x00", i)
+			// byte offsets are unset
+			binary.Write(&chap, binary.BigEndian, []uint32{uint32(chapter.StartMs), uint32(end), 0xFFFFFFFF, 0xFFFFFFFF})
+			writeFrame(&chap, "TIT2", encodeUTF16(chapter.Title))
+			if chapter.Url != "" {
+				// latin1 url with empty description
+				writeFrame(&chap, "WXXX", append([]byte{0, 0}, chapter.Url...))
+			}
+			writeFrame(&frames, "CHAP", chap.Bytes())
+		}
+	}
+
+	if frames.Len() == 0 {
+		return nil
+	}
+
+	size := frames.Len()
+	tag := []byte{'I', 'D', '3', 3, 0, 0, byte(size >> 21 & 0x7F), byte(size >> 14 & 0x7F), byte(size >> 7 & 0x7F), byte(size & 0x7F)}
+
+	return append(tag, frames.Bytes()...)
+}
+
+// encodeUTF16 encodes text with encoding byte of UTF-16 with byte order mark
+func encodeUTF16(text string) []byte {
+	data := []byte{1, 0xFF, 0xFE}
+	for _, unit := range utf16.Encode([]rune(text)) {
+		data = append(data, byte(unit), byte(unit>>8))
+	}
+
+	return data
+}
+
+func syncsafe(data []byte) bool {
+	for _, b := range data {
+		if b&0x80 != 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+func syncsafeInt(data []byte) int {
+	return int(data[0])<<21 | int(data[1])<<14 | int(data[2])<<7 | int(data[3])
+}
+
+func syncsafeBytes(size int) []byte {
+	return []byte{byte(size >> 21 & 0x7F), byte(size >> 14 & 0x7F), byte(size >> 7 & 0x7F), byte(size & 0x7F)}
+}
+
+// removeUnsync reverses unsynchronisation which inserts zero after every 0xFF
+func removeUnsync(data []byte) []byte {
+	out := make([]byte, 0, len(data))
+	for i := 0; i < len(data); i++ {
+		out = append(out, data[i])
+		if data[i] == 0xFF && i+1 < len(data) && data[i+1] == 0 {
+			i++
+		}
+	}
+
+	return out
+}
@@ -0,0 +1,175 @@
+package repository
+
+import (
+	"bytes"
+	"encoding/binary"
+	storage "github.com/mahadeva604/audio-storage"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func concat(parts ...[]byte) []byte {
+	return bytes.Join(parts, nil)
+}
+
+func id3v2Tag(version byte, frames ...[]byte) []byte {
+	body := concat(frames...)
+	return concat([]byte{'I', 'D', '3', version, 0, 0}, syncsafeBytes(len(body)), body)
+}
+
+func id3v2Frame(version byte, id string, data []byte) []byte {
+	switch version {
+	case 2:
+		return concat([]byte(id), []byte{byte(len(data) >> 16), byte(len(data) >> 8), byte(len(data))}, data)
+	case 3:
+		size := make([]byte, 4)
+		binary.BigEndian.PutUint32(size, uint32(len(data)))
+		return concat([]byte(id), size, []byte{0, 0}, data)
+	}
+	return concat([]byte(id), syncsafeBytes(len(data)), []byte{0, 0}, data)
+}
+
+func apicData(picture []byte) []byte {
+	return concat([]byte{0}, []byte("image/png"), []byte{0, 3}, []byte("desc"), []byte{0}, picture)
+}
+
+func chapFrame(version byte, elementId string, startMs uint32, subframes ...[]byte) []byte {
+	times := make([]byte, 16)
+	binary.BigEndian.PutUint32(times, startMs)
+	binary.BigEndian.PutUint32(times[4:], startMs+1000)
+	return id3v2Frame(version, "CHAP", concat([]byte(elementId), []byte{0}, times, concat(subframes...)))
+}
+
+func id3v1Tag(title, artist, album string) []byte {
+	tag := make([]byte, id3v1Size)
+	copy(tag, "TAG")
+	copy(tag[3:33], title)
+	copy(tag[33:63], artist)
+	copy(tag[63:93], album)
+	return tag
+}
+
+func apeTag(items map[string]string) []byte {
+	var body []byte
+	for key, value := range items {
+		header := make([]byte, 8)
+		binary.LittleEndian.PutUint32(header, uint32(len(value)))
+		body = concat(body, header, []byte(key), []byte{0}, []byte(value))
+	}
+
+	footer := make([]byte, apeFooterSize)
+	copy(footer, "APETAGEX")
+	binary.LittleEndian.PutUint32(footer[8:], 2000)
+	binary.LittleEndian.PutUint32(footer[12:], uint32(len(body)+apeFooterSize))
+	binary.LittleEndian.PutUint32(footer[16:], uint32(len(items)))
+	return concat(body, footer)
+}
+
+func TestReadTags(t *testing.T) {
+	stream := []byte{0xFF, 0xF1, 0x50, 0x80}
+	cover := []byte("\xFF\xD8\xFFjpeg")
+	utf16le := []byte{1, 0xFF, 0xFE, 'T', 0, 'i', 0, 0x42, 0x04}
+	unsynced := []byte{0, 'a', 0xFF, 0x00, 'b'}
+
+	testTable := []struct {
+		name         string
+		prefix       []byte
+		suffix       []byte
+		expectedTags storage.AudioTags
+		expectErr    bool
+	}{
+		{
+			name: "No tags",
+		},
+		{
+			name: "ID3v2.2",
+			prefix: id3v2Tag(2,
+				id3v2Frame(2, "TT2", append([]byte{0}, "t\xE9te"...)),
+				id3v2Frame(2, "TP1", append([]byte{3}, "artist\x00second"...)),
+				id3v2Frame(2, "PIC", concat([]byte{0}, []byte("JPG"), []byte{3, 0}, cover)),
+			),
+			expectedTags: storage.AudioTags{Title: "téte", Artist: "artist", Cover: cover},
+		},
+		{
+			name: "ID3v2.3 UTF-16 and front cover preferred",
+			prefix: id3v2Tag(3,
+				id3v2Frame(3, "TIT2", utf16le),
+				id3v2Frame(3, "TALB", []byte{2, 0, 'a', 0, 'l'}),
+				id3v2Frame(3, "APIC", concat([]byte{0}, []byte("image/png"), []byte{0, 4, 0}, []byte("\x89PNG\r\n\x1a\nback"))),
+				id3v2Frame(3, "APIC", concat([]byte{1}, []byte("image/jpeg"), []byte{0, 3, 0xFF, 0xFE, 'd', 0, 0, 0}, cover)),
+			),
+			expectedTags: storage.AudioTags{Title: "Tiт", Album: "al", Cover: cover},
+		},
+		{
+			name: "ID3v2.4 unsynchronised frame",
+			prefix: id3v2Tag(4,
+				concat([]byte("TIT2"), syncsafeBytes(len(unsynced)), []byte{0, 0x02}, unsynced),
+				[]byte{0, 0, 0, 0},
+			),
+			expectedTags: storage.AudioTags{Title: "aÿb"},
+		},
+		{
+			name: "Two ID3v2 tags and APE with ID3v1",
+			prefix: concat(
+				id3v2Tag(4, id3v2Frame(4, "TIT2", append([]byte{3}, "first"...))),
+				id3v2Tag(3, id3v2Frame(3, "TIT2", append([]byte{0}, "second"...)), id3v2Frame(3, "TPE1", append([]byte{0}, "artist"...))),
+			),
+			suffix:       concat(apeTag(map[string]string{"Album": "ape album"}), id3v1Tag("v1", "v1", "v1 album")),
+			expectedTags: storage.AudioTags{Title: "first", Artist: "artist", Album: "ape album"},
+		},
+		{
+			name: "ID3v2.4 chapters",
+			prefix: id3v2Tag(4,
+				id3v2Frame(4, "CTOC", concat([]byte("toc"), []byte{0, 3, 2}, []byte("b\x00a\x00"))),
+				chapFrame(4, "b", 5000,
+					id3v2Frame(4, "TIT2", append([]byte{3}, "second"...)),
+					id3v2Frame(4, "WXXX", concat([]byte{0}, []byte("link\x00"), []byte("https://example.com"))),
+				),
+				chapFrame(4, "a", 0, id3v2Frame(4, "TIT2", append([]byte{0}, "first"...))),
+				[]byte("CHAP"), syncsafeBytes(3), []byte{0, 0}, []byte("bad"),
+			),
+			expectedTags: storage.AudioTags{Chapters: []storage.Chapter{
+				{StartMs: 0, Title: "first"},
+				{StartMs: 5000, Title: "second", Url: "https://example.com"},
+			}},
+		},
+		{
+			name:      "Truncated ID3v2",
+			prefix:    concat([]byte{'I', 'D', '3', 3, 0, 0}, syncsafeBytes(100)),
+			expectErr: true,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			file := concat(testCase.prefix, stream, testCase.suffix)
+			tags, start, end, err := readTags(bytes.NewReader(file), int64(len(file)))
+			if testCase.expectErr {
+				assert.Equal(t, storage.NotAacFile, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, testCase.expectedTags, tags)
+				assert.Equal(t, int64(len(testCase.prefix)), start)
+				assert.Equal(t, int64(len(testCase.prefix)+len(stream)), end)
+			}
+		})
+	}
+}
+
+func TestEncodeID3v2(t *testing.T) {
+	assert.Nil(t, encodeID3v2(storage.AudioTags{}))
+
+	chapters := []storage.Chapter{
+		{StartMs: 0, Title: "вступление"},
+		{StartMs: 61000, Title: "main", Url: "https://example.com/main"},
+	}
+	tags := storage.AudioTags{Title: "title", Artist: "исполнитель", Album: "album", Cover: []byte("\x89PNG\r\n\x1a\ncover"), Chapters: chapters, Duration: 60}
+	tag := encodeID3v2(tags)
+
+	got, start, end, err := readTags(bytes.NewReader(tag), int64(len(tag)))
+	assert.NoError(t, err)
+	tags.Duration = 0
+	assert.Equal(t, tags, got)
+	assert.Equal(t, int64(len(tag)), start)
+	assert.Equal(t, start, end)
+}
@@ -0,0 +1,181 @@
+package repository
+
+import (
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	storage "github.com/mahadeva604/audio-storage"
+)
+
+type InvitationPostgres struct {
+	db *sqlx.DB
+}
+
+func NewInvitationPostgres(db *sqlx.DB) *InvitationPostgres {
+	return &InvitationPostgres{db: db}
+}
+
+func (r *InvitationPostgres) GetInvitations(userID int, input storage.InvitationListParam) (storage.InvitationListJson, error) {
+	query := fmt.Sprintf(`SELECT count(*) OVER() AS full_count, i.* FROM (
+									SELECT '%[1]s' AS type, s.audio_id, 0 AS collection_id, a.title, a.user_id, u.name
+									FROM %[3]s s
+									JOIN %[4]s a USING (audio_id)
+									JOIN %[5]s u ON a.user_id = u.user_id
+									WHERE s.user_id = $1 AND s.status = 'pending'
+									UNION ALL
+									SELECT '%[2]s', 0, cs.collection_id, c.title, c.user_id, u.name
+									FROM %[6]s cs
+									JOIN %[7]s c USING (collection_id)
+									JOIN %[5]s u ON c.user_id = u.user_id
+									WHERE cs.user_id = $1 AND cs.status = 'pending'
+								) i
+								ORDER BY i.name, i.title
+								OFFSET $2 LIMIT $3`, storage.InvitationAudio, storage.InvitationCollection,
+		sharesTable, audiosTable, usersTable, collectionSharesTable, collectionsTable)
+
+	rows, err := r.db.Queryx(query, userID, input.Offset, input.Limit)
+	if err != nil {
+		return storage.InvitationListJson{}, err
+	}
+	var result storage.InvitationListDb
+	var totalCount int
+	invitations := make([]storage.Invitation, 0)
+
+	for rows.Next() {
+
+		err := rows.StructScan(&result)
+		if err != nil {
+			return storage.InvitationListJson{}, err
+		}
+		totalCount = result.Count
+		invitations = append(invitations, result.Invitation)
+	}
+
+	return storage.InvitationListJson{TotalCount: totalCount, Invitations: invitations}, err
+}
+
+func (r *InvitationPostgres) AcceptInvitation(userID, audioId int) error {
+	query := fmt.Sprintf("UPDATE %s SET status = 'accepted' WHERE audio_id = $1 AND user_id = $2 AND status = 'pending'", sharesTable)
+
+	return r.setStatus(query, audioId, userID)
+}
+
+func (r *InvitationPostgres) DeclineInvitation(userID, audioId int) error {
+	// Accepted shares can be declined too, so the recipient is able to leave a share
+	query := fmt.Sprintf("UPDATE %s SET status = 'declined' WHERE audio_id = $1 AND user_id = $2 AND status IN ('pending', 'accepted')", sharesTable)
+
+	return r.setStatus(query, audioId, userID)
+}
+
+func (r *InvitationPostgres) AcceptCollectionInvitation(userID, collectionId int) error {
+	query := fmt.Sprintf("UPDATE %s SET status = 'accepted' WHERE collection_id = $1 AND user_id = $2 AND status = 'pending'", collectionSharesTable)
+
+	return r.setStatus(query, collectionId, userID)
+}
+
+func (r *InvitationPostgres) DeclineCollectionInvitation(userID, collectionId int) error {
+	query := fmt.Sprintf("UPDATE %s SET status = 'declined' WHERE collection_id = $1 AND user_id = $2 AND status IN ('pending', 'accepted')", collectionSharesTable)
+
+	return r.setStatus(query, collectionId, userID)
+}
+
+func (r *InvitationPostgres) setStatus(query string, id, userID int) error {
+	result, err := r.db.Exec(query, id, userID)
+
+	return checkAffected(result, err, storage.InvitationNotFound)
+}
+
+func (r *InvitationPostgres) BlockSender(userID, senderId int) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+
+	query := fmt.Sprintf("INSERT INTO %s (user_id, blocked_id) VALUES ($1, $2)", blocksTable)
+	if _, err := tx.Exec(query, userID, senderId); err != nil {
+		tx.Rollback()
+		return senderError(err, storage.BlockExists)
+	}
+
+	query = fmt.Sprintf("DELETE FROM %s WHERE user_id = $1 AND sender_id = $2", autoAcceptTable)
+	if _, err := tx.Exec(query, userID, senderId); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	query = fmt.Sprintf(`UPDATE %s SET status = 'declined' WHERE user_id = $1 AND status = 'pending'
+								AND audio_id IN (SELECT audio_id FROM %s WHERE user_id = $2)`, sharesTable, audiosTable)
+	if _, err := tx.Exec(query, userID, senderId); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	query = fmt.Sprintf(`UPDATE %s SET status = 'declined' WHERE user_id = $1 AND status = 'pending'
+								AND collection_id IN (SELECT collection_id FROM %s WHERE user_id = $2)`, collectionSharesTable, collectionsTable)
+	if _, err := tx.Exec(query, userID, senderId); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *InvitationPostgres) UnblockSender(userID, senderId int) error {
+	query := fmt.Sprintf("DELETE FROM %s WHERE user_id = $1 AND blocked_id = $2", blocksTable)
+
+	return r.deleteSender(query, userID, senderId, storage.BlockNotFound)
+}
+
+func (r *InvitationPostgres) GetBlockedSenders(userID int) ([]storage.Sender, error) {
+	query := fmt.Sprintf(`SELECT u.user_id, u.name FROM %s b
+								JOIN %s u ON b.blocked_id = u.user_id
+								WHERE b.user_id = $1 ORDER BY u.name`, blocksTable, usersTable)
+
+	senders := make([]storage.Sender, 0)
+	err := r.db.Select(&senders, query, userID)
+
+	return senders, err
+}
+
+func (r *InvitationPostgres) AddAutoAccept(userID, senderId int) error {
+	query := fmt.Sprintf("INSERT INTO %s (user_id, sender_id) VALUES ($1, $2)", autoAcceptTable)
+	_, err := r.db.Exec(query, userID, senderId)
+
+	return senderError(err, storage.AutoAcceptExists)
+}
+
+func (r *InvitationPostgres) RemoveAutoAccept(userID, senderId int) error {
+	query := fmt.Sprintf("DELETE FROM %s WHERE user_id = $1 AND sender_id = $2", autoAcceptTable)
+
+	return r.deleteSender(query, userID, senderId, storage.AutoAcceptNotFound)
+}
+
+func (r *InvitationPostgres) GetAutoAcceptSenders(userID int) ([]storage.Sender, error) {
+	query := fmt.Sprintf(`SELECT u.user_id, u.name FROM %s s
+								JOIN %s u ON s.sender_id = u.user_id
+								WHERE s.user_id = $1 ORDER BY u.name`, autoAcceptTable, usersTable)
+
+	senders := make([]storage.Sender, 0)
+	err := r.db.Select(&senders, query, userID)
+
+	return senders, err
+}
+
+func (r *InvitationPostgres) deleteSender(query string, userID, senderId int, notFound error) error {
+	result, err := r.db.Exec(query, userID, senderId)
+
+	return checkAffected(result, err, notFound)
+}
+
+func senderError(err error, exists error) error {
+	if _, ok := err.(*pq.Error); ok {
+		switch err.(*pq.Error).Code {
+		case "23505":
+			return exists
+		case "23503":
+			return storage.SenderNotExists
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,510 @@
+package repository
+
+import (
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	storage "github.com/mahadeva604/audio-storage"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestInvitationPostgres_GetInvitations(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+	db := sqlx.NewDb(mockDB, "sqlmock")
+
+	r := NewInvitationPostgres(db)
+	type mockBehavior func(userId int, input storage.InvitationListParam)
+
+	offset, limit := 0, 2
+	query := `SELECT (.+) FROM shares s (.+) WHERE s.user_id = \$1 AND s.status = 'pending'
+									UNION ALL (.+) FROM collection_shares cs (.+) WHERE cs.user_id = \$1 AND cs.status = 'pending' (.+) OFFSET \$2 LIMIT \$3`
+
+	testTable := []struct {
+		name         string
+		userId       int
+		input        storage.InvitationListParam
+		mockBehavior mockBehavior
+		expectErr    bool
+		expectData   storage.InvitationListJson
+	}{
+		{
+			name:   "OK",
+			userId: 1,
+			input:  storage.InvitationListParam{Offset: &offset, Limit: &limit},
+			mockBehavior: func(userId int, input storage.InvitationListParam) {
+				rows := sqlmock.NewRows([]string{"full_count", "type", "audio_id", "collection_id", "title", "user_id", "name"}).
+					AddRow(3, "audio", 1, 0, "audio 1", 2, "user 2").
+					AddRow(3, "collection", 0, 4, "collection 4", 3, "user 3")
+				mock.ExpectQuery(query).WithArgs(userId, input.Offset, input.Limit).WillReturnRows(rows)
+			},
+			expectData: storage.InvitationListJson{
+				TotalCount: 3,
+				Invitations: []storage.Invitation{
+					{Type: storage.InvitationAudio, AudioId: 1, Title: "audio 1", OwnerId: 2, OwnerName: "user 2"},
+					{Type: storage.InvitationCollection, CollectionId: 4, Title: "collection 4", OwnerId: 3, OwnerName: "user 3"},
+				},
+			},
+		},
+		{
+			name:   "Error query",
+			userId: 1,
+			input:  storage.InvitationListParam{Offset: &offset, Limit: &limit},
+			mockBehavior: func(userId int, input storage.InvitationListParam) {
+				mock.ExpectQuery(query).WithArgs(userId, input.Offset, input.Limit).WillReturnError(errors.New("query error"))
+			},
+			expectErr: true,
+		},
+		{
+			name:   "Error scan",
+			userId: 1,
+			input:  storage.InvitationListParam{Offset: &offset, Limit: &limit},
+			mockBehavior: func(userId int, input storage.InvitationListParam) {
+				rows := sqlmock.NewRows([]string{"wrong_row"}).AddRow("wrong_row")
+				mock.ExpectQuery(query).WithArgs(userId, input.Offset, input.Limit).WillReturnRows(rows)
+			},
+			expectErr: true,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior(testCase.userId, testCase.input)
+
+			output, err := r.GetInvitations(testCase.userId, testCase.input)
+			if testCase.expectErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, testCase.expectData, output)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestInvitationPostgres_AnswerInvitation(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+	db := sqlx.NewDb(mockDB, "sqlmock")
+
+	r := NewInvitationPostgres(db)
+	type mockBehavior func(query string, userId, audioId int)
+
+	testTable := []struct {
+		name            string
+		accept          bool
+		userId          int
+		audioId         int
+		mockBehavior    mockBehavior
+		expectedErr     bool
+		expectedErrType error
+	}{
+		{
+			name:    "OK accept",
+			accept:  true,
+			userId:  1,
+			audioId: 2,
+			mockBehavior: func(query string, userId, audioId int) {
+				mock.ExpectExec(query).WithArgs(audioId, userId).WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			name:    "OK decline",
+			userId:  1,
+			audioId: 2,
+			mockBehavior: func(query string, userId, audioId int) {
+				mock.ExpectExec(query).WithArgs(audioId, userId).WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			name:    "Error not found",
+			accept:  true,
+			userId:  1,
+			audioId: 2,
+			mockBehavior: func(query string, userId, audioId int) {
+				mock.ExpectExec(query).WithArgs(audioId, userId).WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			expectedErr:     true,
+			expectedErrType: storage.InvitationNotFound,
+		},
+		{
+			name:    "Error query",
+			userId:  1,
+			audioId: 2,
+			mockBehavior: func(query string, userId, audioId int) {
+				mock.ExpectExec(query).WithArgs(audioId, userId).WillReturnError(errors.New("query error"))
+			},
+			expectedErr:     true,
+			expectedErrType: errors.New("query error"),
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			if testCase.accept {
+				testCase.mockBehavior("UPDATE shares SET status = 'accepted' WHERE (.+)", testCase.userId, testCase.audioId)
+				err = r.AcceptInvitation(testCase.userId, testCase.audioId)
+			} else {
+				testCase.mockBehavior("UPDATE shares SET status = 'declined' WHERE (.+)", testCase.userId, testCase.audioId)
+				err = r.DeclineInvitation(testCase.userId, testCase.audioId)
+			}
+
+			if testCase.expectedErr {
+				assert.Error(t, err)
+				if testCase.expectedErrType != nil {
+					assert.Equal(t, testCase.expectedErrType, err)
+				}
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestInvitationPostgres_AnswerCollectionInvitation(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+	db := sqlx.NewDb(mockDB, "sqlmock")
+
+	r := NewInvitationPostgres(db)
+
+	t.Run("OK accept", func(t *testing.T) {
+		mock.ExpectExec(`UPDATE collection_shares SET status = 'accepted' WHERE collection_id = \$1 AND user_id = \$2 AND status = 'pending'`).
+			WithArgs(4, 1).WillReturnResult(sqlmock.NewResult(0, 1))
+
+		assert.NoError(t, r.AcceptCollectionInvitation(1, 4))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Error decline not found", func(t *testing.T) {
+		mock.ExpectExec(`UPDATE collection_shares SET status = 'declined' WHERE collection_id = \$1 AND user_id = \$2 AND status IN \('pending', 'accepted'\)`).
+			WithArgs(4, 1).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		assert.Equal(t, storage.InvitationNotFound, r.DeclineCollectionInvitation(1, 4))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestInvitationPostgres_BlockSender(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+	db := sqlx.NewDb(mockDB, "sqlmock")
+
+	r := NewInvitationPostgres(db)
+	type mockBehavior func(userId, senderId int)
+
+	testTable := []struct {
+		name            string
+		userId          int
+		senderId        int
+		mockBehavior    mockBehavior
+		expectedErr     bool
+		expectedErrType error
+	}{
+		{
+			name:     "OK",
+			userId:   1,
+			senderId: 2,
+			mockBehavior: func(userId, senderId int) {
+				mock.ExpectBegin()
+				mock.ExpectExec("INSERT INTO share_blocks").WithArgs(userId, senderId).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("DELETE FROM share_auto_accept").WithArgs(userId, senderId).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec("UPDATE shares SET status = 'declined' (.+)").WithArgs(userId, senderId).WillReturnResult(sqlmock.NewResult(0, 3))
+				mock.ExpectExec("UPDATE collection_shares SET status = 'declined' (.+)").WithArgs(userId, senderId).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name:     "Error block exists",
+			userId:   1,
+			senderId: 2,
+			mockBehavior: func(userId, senderId int) {
+				mock.ExpectBegin()
+				mock.ExpectExec("INSERT INTO share_blocks").WithArgs(userId, senderId).WillReturnError(&pq.Error{Code: "23505"})
+				mock.ExpectRollback()
+			},
+			expectedErr:     true,
+			expectedErrType: storage.BlockExists,
+		},
+		{
+			name:     "Error user not exists",
+			userId:   1,
+			senderId: 2,
+			mockBehavior: func(userId, senderId int) {
+				mock.ExpectBegin()
+				mock.ExpectExec("INSERT INTO share_blocks").WithArgs(userId, senderId).WillReturnError(&pq.Error{Code: "23503"})
+				mock.ExpectRollback()
+			},
+			expectedErr:     true,
+			expectedErrType: storage.SenderNotExists,
+		},
+		{
+			name:     "Error decline pending",
+			userId:   1,
+			senderId: 2,
+			mockBehavior: func(userId, senderId int) {
+				mock.ExpectBegin()
+				mock.ExpectExec("INSERT INTO share_blocks").WithArgs(userId, senderId).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("DELETE FROM share_auto_accept").WithArgs(userId, senderId).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec("UPDATE shares SET status = 'declined' (.+)").WithArgs(userId, senderId).WillReturnError(errors.New("query error"))
+				mock.ExpectRollback()
+			},
+			expectedErr:     true,
+			expectedErrType: errors.New("query error"),
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior(testCase.userId, testCase.senderId)
+
+			err := r.BlockSender(testCase.userId, testCase.senderId)
+			if testCase.expectedErr {
+				assert.Error(t, err)
+				if testCase.expectedErrType != nil {
+					assert.Equal(t, testCase.expectedErrType, err)
+				}
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestInvitationPostgres_RemoveSender(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+	db := sqlx.NewDb(mockDB, "sqlmock")
+
+	r := NewInvitationPostgres(db)
+	type mockBehavior func(userId, senderId int)
+
+	testTable := []struct {
+		name            string
+		block           bool
+		userId          int
+		senderId        int
+		mockBehavior    mockBehavior
+		expectedErr     bool
+		expectedErrType error
+	}{
+		{
+			name:     "OK unblock",
+			block:    true,
+			userId:   1,
+			senderId: 2,
+			mockBehavior: func(userId, senderId int) {
+				mock.ExpectExec("DELETE FROM share_blocks").WithArgs(userId, senderId).WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			name:     "Error not blocked",
+			block:    true,
+			userId:   1,
+			senderId: 2,
+			mockBehavior: func(userId, senderId int) {
+				mock.ExpectExec("DELETE FROM share_blocks").WithArgs(userId, senderId).WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			expectedErr:     true,
+			expectedErrType: storage.BlockNotFound,
+		},
+		{
+			name:     "OK remove auto accept",
+			userId:   1,
+			senderId: 2,
+			mockBehavior: func(userId, senderId int) {
+				mock.ExpectExec("DELETE FROM share_auto_accept").WithArgs(userId, senderId).WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			name:     "Error not in auto accept",
+			userId:   1,
+			senderId: 2,
+			mockBehavior: func(userId, senderId int) {
+				mock.ExpectExec("DELETE FROM share_auto_accept").WithArgs(userId, senderId).WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			expectedErr:     true,
+			expectedErrType: storage.AutoAcceptNotFound,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior(testCase.userId, testCase.senderId)
+
+			if testCase.block {
+				err = r.UnblockSender(testCase.userId, testCase.senderId)
+			} else {
+				err = r.RemoveAutoAccept(testCase.userId, testCase.senderId)
+			}
+
+			if testCase.expectedErr {
+				assert.Error(t, err)
+				if testCase.expectedErrType != nil {
+					assert.Equal(t, testCase.expectedErrType, err)
+				}
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestInvitationPostgres_AddAutoAccept(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+	db := sqlx.NewDb(mockDB, "sqlmock")
+
+	r := NewInvitationPostgres(db)
+	type mockBehavior func(userId, senderId int)
+
+	testTable := []struct {
+		name            string
+		userId          int
+		senderId        int
+		mockBehavior    mockBehavior
+		expectedErr     bool
+		expectedErrType error
+	}{
+		{
+			name:     "OK",
+			userId:   1,
+			senderId: 2,
+			mockBehavior: func(userId, senderId int) {
+				mock.ExpectExec("INSERT INTO share_auto_accept").WithArgs(userId, senderId).WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			name:     "Error exists",
+			userId:   1,
+			senderId: 2,
+			mockBehavior: func(userId, senderId int) {
+				mock.ExpectExec("INSERT INTO share_auto_accept").WithArgs(userId, senderId).WillReturnError(&pq.Error{Code: "23505"})
+			},
+			expectedErr:     true,
+			expectedErrType: storage.AutoAcceptExists,
+		},
+		{
+			name:     "Error user not exists",
+			userId:   1,
+			senderId: 2,
+			mockBehavior: func(userId, senderId int) {
+				mock.ExpectExec("INSERT INTO share_auto_accept").WithArgs(userId, senderId).WillReturnError(&pq.Error{Code: "23503"})
+			},
+			expectedErr:     true,
+			expectedErrType: storage.SenderNotExists,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior(testCase.userId, testCase.senderId)
+
+			err := r.AddAutoAccept(testCase.userId, testCase.senderId)
+			if testCase.expectedErr {
+				assert.Error(t, err)
+				if testCase.expectedErrType != nil {
+					assert.Equal(t, testCase.expectedErrType, err)
+				}
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestInvitationPostgres_GetSenders(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+	db := sqlx.NewDb(mockDB, "sqlmock")
+
+	r := NewInvitationPostgres(db)
+	type mockBehavior func(userId int)
+
+	testTable := []struct {
+		name         string
+		block        bool
+		userId       int
+		mockBehavior mockBehavior
+		expectErr    bool
+		expectData   []storage.Sender
+	}{
+		{
+			name:   "OK blocked",
+			block:  true,
+			userId: 1,
+			mockBehavior: func(userId int) {
+				rows := sqlmock.NewRows([]string{"user_id", "name"}).AddRow(2, "user 2")
+				mock.ExpectQuery("SELECT (.+) FROM share_blocks b (.+)").WithArgs(userId).WillReturnRows(rows)
+			},
+			expectData: []storage.Sender{{UserId: 2, Name: "user 2"}},
+		},
+		{
+			name:   "OK auto accept",
+			userId: 1,
+			mockBehavior: func(userId int) {
+				rows := sqlmock.NewRows([]string{"user_id", "name"}).AddRow(3, "user 3")
+				mock.ExpectQuery("SELECT (.+) FROM share_auto_accept s (.+)").WithArgs(userId).WillReturnRows(rows)
+			},
+			expectData: []storage.Sender{{UserId: 3, Name: "user 3"}},
+		},
+		{
+			name:   "Error query",
+			block:  true,
+			userId: 1,
+			mockBehavior: func(userId int) {
+				mock.ExpectQuery("SELECT (.+) FROM share_blocks b (.+)").WithArgs(userId).WillReturnError(errors.New("query error"))
+			},
+			expectErr: true,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior(testCase.userId)
+
+			var output []storage.Sender
+			if testCase.block {
+				output, err = r.GetBlockedSenders(testCase.userId)
+			} else {
+				output, err = r.GetAutoAcceptSenders(testCase.userId)
+			}
+
+			if testCase.expectErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, testCase.expectData, output)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
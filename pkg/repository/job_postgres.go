@@ -0,0 +1,229 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	storage "github.com/mahadeva604/audio-storage"
+	"time"
+)
+
+// jobColumns are columns of storage.Job
+const jobColumns = "job_id, type, audio_id, status, attempts, max_attempts, error, run_at, created_at, updated_at"
+
+type JobPostgres struct {
+	db *sqlx.DB
+}
+
+func NewJobPostgres(db *sqlx.DB) *JobPostgres {
+	return &JobPostgres{db: db}
+}
+
+// EnqueueJob queues job unless a job of the same type and audio is already queued or running
+func (r *JobPostgres) EnqueueJob(jobType string, audioId *int, maxAttempts int) (int, error) {
+	var jobId int
+	query := fmt.Sprintf(`INSERT INTO %s (type, audio_id, max_attempts) VALUES ($1, $2, $3)
+							ON CONFLICT (type, COALESCE(audio_id, 0)) WHERE status IN ('queued', 'running') DO NOTHING
+							RETURNING job_id`, jobsTable)
+	err := r.db.Get(&jobId, query, jobType, audioId, maxAttempts)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, storage.JobActive
+	}
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23503" {
+		return 0, storage.FileNotFound
+	}
+
+	return jobId, err
+}
+
+// ClaimJob locks the oldest due job for lease, jobs locked by other workers
+// are skipped. Every claim gets a new claim token which fences writes of
+// workers that lost the lease. It returns JobNotFound when there is nothing to run
+func (r *JobPostgres) ClaimJob(lease time.Duration) (storage.JobTask, error) {
+	var task storage.JobTask
+	query := fmt.Sprintf(`UPDATE %[1]s j
+							SET status = 'running', attempts = j.attempts + 1, claim_token = gen_random_uuid(),
+								locked_until = now() + interval '%[3]d seconds', updated_at = now()
+							FROM (SELECT q.job_id, a.user_id, a.file_path, a.checksum FROM %[1]s q
+									LEFT JOIN %[2]s a ON a.audio_id = q.audio_id
+									WHERE q.status = 'queued' AND q.run_at <= now()
+									ORDER BY q.run_at, q.job_id
+									FOR UPDATE OF q SKIP LOCKED
+									LIMIT 1) t
+							WHERE j.job_id = t.job_id
+							RETURNING j.job_id, j.type, j.audio_id, j.status, j.attempts, j.max_attempts, j.error, j.run_at,
+								j.created_at, j.updated_at, j.claim_token, t.user_id, t.file_path, t.checksum`,
+		jobsTable, audiosTable, int64(lease.Seconds()))
+	err := r.db.Get(&task, query)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return task, storage.JobNotFound
+	}
+
+	return task, err
+}
+
+// ExtendJob renews lease of running job. It returns JobLeaseLost when the job
+// was reaped or claimed by another worker
+func (r *JobPostgres) ExtendJob(jobId int, claimToken string, lease time.Duration) error {
+	query := fmt.Sprintf(`UPDATE %s SET locked_until = now() + interval '%d seconds'
+							WHERE job_id = $1 AND claim_token = $2 AND status = 'running'`, jobsTable, int64(lease.Seconds()))
+	result, err := r.db.Exec(query, jobId, claimToken)
+
+	return checkAffected(result, err, storage.JobLeaseLost)
+}
+
+func (r *JobPostgres) CompleteJob(jobId int, claimToken string) error {
+	query := fmt.Sprintf(`UPDATE %s SET status = 'done', error = '', claim_token = NULL, locked_until = NULL, updated_at = now()
+							WHERE job_id = $1 AND claim_token = $2 AND status = 'running'`, jobsTable)
+	result, err := r.db.Exec(query, jobId, claimToken)
+
+	return checkAffected(result, err, storage.JobLeaseLost)
+}
+
+// FailJob queues job again at retryAt, job without retryAt is dead
+func (r *JobPostgres) FailJob(jobId int, claimToken, message string, retryAt *time.Time) error {
+	query := fmt.Sprintf(`UPDATE %s SET status = CASE WHEN $4::timestamptz IS NULL THEN 'dead' ELSE 'queued' END,
+							error = $3, run_at = COALESCE($4, run_at), claim_token = NULL, locked_until = NULL, updated_at = now()
+							WHERE job_id = $1 AND claim_token = $2 AND status = 'running'`, jobsTable)
+	result, err := r.db.Exec(query, jobId, claimToken, message, retryAt)
+
+	return checkAffected(result, err, storage.JobLeaseLost)
+}
+
+// ReapJobs returns running jobs with expired lease to the queue, they were
+// left by a stopped server. Jobs without attempts left are dead
+func (r *JobPostgres) ReapJobs() (int64, error) {
+	query := fmt.Sprintf(`UPDATE %s SET status = CASE WHEN attempts < max_attempts THEN 'queued' ELSE 'dead' END,
+							error = 'job lease expired', run_at = now(), claim_token = NULL, locked_until = NULL, updated_at = now()
+							WHERE status = 'running' AND locked_until < now()`, jobsTable)
+	result, err := r.db.Exec(query)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// GetAudioJobs returns the latest job of every type of audio user has access to
+func (r *JobPostgres) GetAudioJobs(userID, audioId int) ([]storage.Job, error) {
+	var ok bool
+	query := fmt.Sprintf("SELECT true FROM %s WHERE audio_id = $1 AND user_id = $2", audioAccessView)
+	if err := r.db.Get(&ok, query, audioId, userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.FileNotFound
+		}
+		return nil, err
+	}
+
+	jobs := make([]storage.Job, 0)
+	query = fmt.Sprintf(`SELECT DISTINCT ON (type) %s FROM %s WHERE audio_id = $1 ORDER BY type, job_id DESC`,
+		jobColumns, jobsTable)
+	err := r.db.Select(&jobs, query, audioId)
+
+	return jobs, err
+}
+
+// GetJobs returns jobs of every user, newest first
+func (r *JobPostgres) GetJobs(input storage.JobListParam) (storage.JobListJson, error) {
+	b := newQueryBuilder(input.Offset, input.Limit)
+	if input.Status != "" {
+		b.where("status = " + b.arg(input.Status))
+	}
+	if input.Type != "" {
+		b.where("type = " + b.arg(input.Type))
+	}
+
+	query := fmt.Sprintf(`SELECT count(*) OVER() AS full_count, %s FROM %s
+						WHERE %s
+						ORDER BY job_id DESC
+						OFFSET $1 LIMIT $2`, jobColumns, jobsTable, b.conditionsSQL())
+
+	var rows []storage.JobDb
+	if err := r.db.Select(&rows, query, b.args...); err != nil {
+		return storage.JobListJson{}, err
+	}
+
+	result := storage.JobListJson{Records: make([]storage.Job, 0, len(rows))}
+	for _, row := range rows {
+		result.TotalCount = row.Count
+		result.Records = append(result.Records, row.Job)
+	}
+
+	return result, nil
+}
+
+// RetryJob queues dead job again with attempts reset
+func (r *JobPostgres) RetryJob(jobId int) error {
+	var status string
+	query := fmt.Sprintf("SELECT status FROM %s WHERE job_id = $1", jobsTable)
+	if err := r.db.Get(&status, query, jobId); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return storage.JobNotFound
+		}
+		return err
+	}
+
+	if status != storage.JobDead {
+		return storage.JobNotDead
+	}
+
+	query = fmt.Sprintf(`UPDATE %s SET status = 'queued', attempts = 0, run_at = now(), updated_at = now()
+							WHERE job_id = $1 AND status = 'dead'`, jobsTable)
+	result, err := r.db.Exec(query, jobId)
+
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+		return storage.JobActive
+	}
+
+	return checkAffected(result, err, storage.JobNotDead)
+}
+
+// SetAudioProbe saves stream parameters, duration is filled only if it wasn't set by user
+func (r *JobPostgres) SetAudioProbe(audioId int, probe storage.AudioProbe) error {
+	query := fmt.Sprintf(`UPDATE %s SET sample_rate = $2, channels = $3, bitrate = $4,
+							duration = CASE WHEN duration = 0 THEN $5 ELSE duration END
+							WHERE audio_id = $1`, audiosTable)
+	_, err := r.db.Exec(query, audioId, probe.SampleRate, probe.Channels, probe.Bitrate, probe.Duration)
+
+	return err
+}
+
+// SetAudioFile saves size of rewritten file and resets its checksum
+func (r *JobPostgres) SetAudioFile(audioId int, size int64) error {
+	query := fmt.Sprintf("UPDATE %s SET size = $2, checksum = NULL WHERE audio_id = $1", audiosTable)
+	_, err := r.db.Exec(query, audioId, size)
+
+	return err
+}
+
+func (r *JobPostgres) SetAudioChecksum(audioId int, checksum string) error {
+	query := fmt.Sprintf("UPDATE %s SET checksum = $2 WHERE audio_id = $1", audiosTable)
+	_, err := r.db.Exec(query, audioId, checksum)
+
+	return err
+}
+
+// GetOrphanFiles returns files which aren't referenced by any audio or
+// collection, temporary files are never referenced
+func (r *JobPostgres) GetOrphanFiles(files storage.OrphanFiles) (storage.OrphanFiles, error) {
+	orphans := storage.OrphanFiles{Temp: files.Temp}
+
+	query := fmt.Sprintf(`SELECT f FROM unnest($1::text[]) f WHERE NOT EXISTS (SELECT 1 FROM %s WHERE file_path = f)`, audiosTable)
+	if err := r.db.Select(&orphans.Audio, query, pq.Array(files.Audio)); err != nil {
+		return orphans, err
+	}
+	if err := r.db.Select(&orphans.HLS, query, pq.Array(files.HLS)); err != nil {
+		return orphans, err
+	}
+
+	query = fmt.Sprintf(`SELECT f FROM unnest($1::text[]) f
+							WHERE NOT EXISTS (SELECT 1 FROM %s WHERE artwork_id::text = f)
+							AND NOT EXISTS (SELECT 1 FROM %s WHERE artwork_id::text = f)`, audiosTable, collectionsTable)
+	err := r.db.Select(&orphans.Artwork, query, pq.Array(files.Artwork))
+
+	return orphans, err
+}
@@ -0,0 +1,259 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	storage "github.com/mahadeva604/audio-storage"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestJobPostgres(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+	db := sqlx.NewDb(mockDB, "sqlmock")
+
+	r := NewJobPostgres(db)
+
+	at := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	audioId := 2
+	fileId := "7e1c1d2a-1f3e-4a44-9e0e-0d9a3d8c6f11"
+	claimToken := "3b9f5c0e-8d2a-4c61-a0f7-52e1d4b6c9a3"
+	columns := []string{"job_id", "type", "audio_id", "status", "attempts", "max_attempts", "error", "run_at", "created_at", "updated_at"}
+
+	t.Run("OK enqueue", func(t *testing.T) {
+		mock.ExpectQuery(`INSERT INTO jobs \(type, audio_id, max_attempts\) VALUES \(\$1, \$2, \$3\) ON CONFLICT \(type, COALESCE\(audio_id, 0\)\) WHERE status IN \('queued', 'running'\) DO NOTHING RETURNING job_id`).
+			WithArgs("remux", &audioId, 5).WillReturnRows(sqlmock.NewRows([]string{"job_id"}).AddRow(7))
+
+		jobId, err := r.EnqueueJob(storage.JobRemux, &audioId, 5)
+		assert.NoError(t, err)
+		assert.Equal(t, 7, jobId)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Enqueue active", func(t *testing.T) {
+		mock.ExpectQuery(`INSERT INTO jobs`).WithArgs("cleanup", nil, 5).WillReturnError(sql.ErrNoRows)
+
+		_, err := r.EnqueueJob(storage.JobCleanup, nil, 5)
+		assert.Equal(t, storage.JobActive, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Enqueue audio not found", func(t *testing.T) {
+		mock.ExpectQuery(`INSERT INTO jobs`).WithArgs("hls", &audioId, 5).WillReturnError(&pq.Error{Code: "23503"})
+
+		_, err := r.EnqueueJob(storage.JobHLS, &audioId, 5)
+		assert.Equal(t, storage.FileNotFound, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("OK claim", func(t *testing.T) {
+		mock.ExpectQuery(`UPDATE jobs j SET status = 'running', attempts = j.attempts \+ 1, claim_token = gen_random_uuid\(\), locked_until = now\(\) \+ interval '600 seconds', updated_at = now\(\) FROM \(SELECT (.+) FOR UPDATE OF q SKIP LOCKED LIMIT 1\) t WHERE j.job_id = t.job_id RETURNING (.+)`).
+			WillReturnRows(sqlmock.NewRows(append(columns, "claim_token", "user_id", "file_path", "checksum")).
+				AddRow(7, "probe", 2, "running", 1, 5, "", at, at, at, claimToken, 1, fileId, nil))
+
+		task, err := r.ClaimJob(10 * time.Minute)
+		assert.NoError(t, err)
+		userId, path := 1, fileId
+		assert.Equal(t, storage.JobTask{
+			Job: storage.Job{Id: 7, Type: storage.JobProbe, AudioId: &audioId, Status: storage.JobRunning, Attempts: 1, MaxAttempts: 5,
+				RunAt: at, CreatedAt: at, UpdatedAt: at},
+			ClaimToken: claimToken,
+			UserId:     &userId,
+			FilePath:   &path,
+		}, task)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Claim empty queue", func(t *testing.T) {
+		mock.ExpectQuery(`UPDATE jobs j SET status = 'running'`).WillReturnError(sql.ErrNoRows)
+
+		_, err := r.ClaimJob(time.Minute)
+		assert.Equal(t, storage.JobNotFound, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("OK extend", func(t *testing.T) {
+		mock.ExpectExec(`UPDATE jobs SET locked_until = now\(\) \+ interval '600 seconds' WHERE job_id = \$1 AND claim_token = \$2 AND status = 'running'`).
+			WithArgs(7, claimToken).WillReturnResult(sqlmock.NewResult(0, 1))
+
+		assert.NoError(t, r.ExtendJob(7, claimToken, 10*time.Minute))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Extend lease lost", func(t *testing.T) {
+		mock.ExpectExec(`UPDATE jobs SET locked_until`).WithArgs(7, claimToken).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		assert.Equal(t, storage.JobLeaseLost, r.ExtendJob(7, claimToken, 10*time.Minute))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("OK complete", func(t *testing.T) {
+		mock.ExpectExec(`UPDATE jobs SET status = 'done', error = '', claim_token = NULL, locked_until = NULL, updated_at = now\(\) WHERE job_id = \$1 AND claim_token = \$2 AND status = 'running'`).
+			WithArgs(7, claimToken).WillReturnResult(sqlmock.NewResult(0, 1))
+
+		assert.NoError(t, r.CompleteJob(7, claimToken))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Complete lease lost", func(t *testing.T) {
+		mock.ExpectExec(`UPDATE jobs SET status = 'done'`).WithArgs(7, claimToken).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		assert.Equal(t, storage.JobLeaseLost, r.CompleteJob(7, claimToken))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("OK fail with retry", func(t *testing.T) {
+		mock.ExpectExec(`UPDATE jobs SET status = CASE WHEN \$4::timestamptz IS NULL THEN 'dead' ELSE 'queued' END, (.+) WHERE job_id = \$1 AND claim_token = \$2 AND status = 'running'`).
+			WithArgs(7, claimToken, "read error", &at).WillReturnResult(sqlmock.NewResult(0, 1))
+
+		assert.NoError(t, r.FailJob(7, claimToken, "read error", &at))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("OK fail dead", func(t *testing.T) {
+		mock.ExpectExec(`UPDATE jobs SET status = CASE`).WithArgs(7, claimToken, "not aac", nil).WillReturnResult(sqlmock.NewResult(0, 1))
+
+		assert.NoError(t, r.FailJob(7, claimToken, "not aac", nil))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("OK reap", func(t *testing.T) {
+		mock.ExpectExec(`UPDATE jobs SET status = CASE WHEN attempts < max_attempts THEN 'queued' ELSE 'dead' END, (.+) WHERE status = 'running' AND locked_until < now\(\)`).
+			WillReturnResult(sqlmock.NewResult(0, 3))
+
+		count, err := r.ReapJobs()
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), count)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("OK get audio jobs", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT true FROM audio_access WHERE audio_id = \$1 AND user_id = \$2`).WithArgs(2, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"bool"}).AddRow(true))
+		mock.ExpectQuery(`SELECT DISTINCT ON \(type\) (.+) FROM jobs WHERE audio_id = \$1 ORDER BY type, job_id DESC`).WithArgs(2).
+			WillReturnRows(sqlmock.NewRows(columns).
+				AddRow(8, "hls", 2, "queued", 0, 5, "", at, at, at).
+				AddRow(7, "remux", 2, "done", 1, 5, "", at, at, at))
+
+		jobs, err := r.GetAudioJobs(1, 2)
+		assert.NoError(t, err)
+		assert.Equal(t, []storage.Job{
+			{Id: 8, Type: storage.JobHLS, AudioId: &audioId, Status: storage.JobQueued, MaxAttempts: 5, RunAt: at, CreatedAt: at, UpdatedAt: at},
+			{Id: 7, Type: storage.JobRemux, AudioId: &audioId, Status: storage.JobDone, Attempts: 1, MaxAttempts: 5, RunAt: at, CreatedAt: at, UpdatedAt: at},
+		}, jobs)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Get audio jobs no access", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT true FROM audio_access`).WithArgs(2, 1).WillReturnError(sql.ErrNoRows)
+
+		_, err := r.GetAudioJobs(1, 2)
+		assert.Equal(t, storage.FileNotFound, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("OK get jobs", func(t *testing.T) {
+		offset, limit := 0, 10
+		mock.ExpectQuery(`SELECT count\(\*\) OVER\(\) AS full_count, (.+) FROM jobs WHERE status = \$3 AND type = \$4 ORDER BY job_id DESC OFFSET \$1 LIMIT \$2`).
+			WithArgs(&offset, &limit, "dead", "checksum").
+			WillReturnRows(sqlmock.NewRows(append([]string{"full_count"}, columns...)).
+				AddRow(1, 9, "checksum", 2, "dead", 1, 5, "checksum mismatch", at, at, at))
+
+		result, err := r.GetJobs(storage.JobListParam{Status: storage.JobDead, Type: storage.JobChecksum, Offset: &offset, Limit: &limit})
+		assert.NoError(t, err)
+		assert.Equal(t, storage.JobListJson{TotalCount: 1, Records: []storage.Job{
+			{Id: 9, Type: storage.JobChecksum, AudioId: &audioId, Status: storage.JobDead, Attempts: 1, MaxAttempts: 5,
+				Error: "checksum mismatch", RunAt: at, CreatedAt: at, UpdatedAt: at},
+		}}, result)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("OK get jobs empty", func(t *testing.T) {
+		offset, limit := 0, 10
+		mock.ExpectQuery(`SELECT count\(\*\) OVER\(\) AS full_count, (.+) FROM jobs WHERE TRUE ORDER BY job_id DESC`).
+			WithArgs(&offset, &limit).WillReturnRows(sqlmock.NewRows(append([]string{"full_count"}, columns...)))
+
+		result, err := r.GetJobs(storage.JobListParam{Offset: &offset, Limit: &limit})
+		assert.NoError(t, err)
+		assert.Equal(t, storage.JobListJson{Records: []storage.Job{}}, result)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("OK retry", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT status FROM jobs WHERE job_id = \$1`).WithArgs(9).
+			WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow("dead"))
+		mock.ExpectExec(`UPDATE jobs SET status = 'queued', attempts = 0, run_at = now\(\), updated_at = now\(\) WHERE job_id = \$1 AND status = 'dead'`).
+			WithArgs(9).WillReturnResult(sqlmock.NewResult(0, 1))
+
+		assert.NoError(t, r.RetryJob(9))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Retry not found", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT status FROM jobs`).WithArgs(9).WillReturnError(sql.ErrNoRows)
+
+		assert.Equal(t, storage.JobNotFound, r.RetryJob(9))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Retry not dead", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT status FROM jobs`).WithArgs(9).WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow("running"))
+
+		assert.Equal(t, storage.JobNotDead, r.RetryJob(9))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Retry with pending job of the same type", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT status FROM jobs`).WithArgs(9).WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow("dead"))
+		mock.ExpectExec(`UPDATE jobs SET status = 'queued'`).WithArgs(9).WillReturnError(&pq.Error{Code: "23505"})
+
+		assert.Equal(t, storage.JobActive, r.RetryJob(9))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("OK set probe", func(t *testing.T) {
+		mock.ExpectExec(`UPDATE audios SET sample_rate = \$2, channels = \$3, bitrate = \$4, duration = CASE WHEN duration = 0 THEN \$5 ELSE duration END WHERE audio_id = \$1`).
+			WithArgs(2, 44100, 2, 128000, 61).WillReturnResult(sqlmock.NewResult(0, 1))
+
+		assert.NoError(t, r.SetAudioProbe(2, storage.AudioProbe{Duration: 61, SampleRate: 44100, Channels: 2, Bitrate: 128000}))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("OK set file", func(t *testing.T) {
+		mock.ExpectExec(`UPDATE audios SET size = \$2, checksum = NULL WHERE audio_id = \$1`).
+			WithArgs(2, 1000).WillReturnResult(sqlmock.NewResult(0, 1))
+
+		assert.NoError(t, r.SetAudioFile(2, 1000))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("OK get orphan files", func(t *testing.T) {
+		files := storage.OrphanFiles{Audio: []string{"a1", "a2"}, Artwork: []string{"w1"}, HLS: []string{"a1"}, Temp: []string{"saved/t.tmp"}}
+		mock.ExpectQuery(`SELECT f FROM unnest\(\$1::text\[\]\) f WHERE NOT EXISTS \(SELECT 1 FROM audios WHERE file_path = f\)`).
+			WithArgs(pq.Array(files.Audio)).WillReturnRows(sqlmock.NewRows([]string{"f"}).AddRow("a2"))
+		mock.ExpectQuery(`SELECT f FROM unnest`).WithArgs(pq.Array(files.HLS)).WillReturnRows(sqlmock.NewRows([]string{"f"}))
+		mock.ExpectQuery(`SELECT f FROM unnest\(\$1::text\[\]\) f WHERE NOT EXISTS \(SELECT 1 FROM audios WHERE artwork_id::text = f\) AND NOT EXISTS \(SELECT 1 FROM collections WHERE artwork_id::text = f\)`).
+			WithArgs(pq.Array(files.Artwork)).WillReturnRows(sqlmock.NewRows([]string{"f"}).AddRow("w1"))
+
+		orphans, err := r.GetOrphanFiles(files)
+		assert.NoError(t, err)
+		assert.Equal(t, storage.OrphanFiles{Audio: []string{"a2"}, Artwork: []string{"w1"}, Temp: []string{"saved/t.tmp"}}, orphans)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Get orphan files error", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT f FROM unnest`).WillReturnError(errors.New("db error"))
+
+		_, err := r.GetOrphanFiles(storage.OrphanFiles{})
+		assert.EqualError(t, err, "db error")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	storage "github.com/mahadeva604/audio-storage"
+)
+
+type MetadataSchemaPostgres struct {
+	db *sqlx.DB
+}
+
+func NewMetadataSchemaPostgres(db *sqlx.DB) *MetadataSchemaPostgres {
+	return &MetadataSchemaPostgres{db: db}
+}
+
+func (r *MetadataSchemaPostgres) GetMetadataSchema(userID int) ([]byte, error) {
+	var schema []byte
+	query := fmt.Sprintf("SELECT schema FROM %s WHERE user_id = $1", metadataSchemasTable)
+	err := r.db.Get(&schema, query, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, storage.MetadataSchemaNotFound
+	}
+
+	return schema, err
+}
+
+func (r *MetadataSchemaPostgres) SetMetadataSchema(userID int, schema []byte) error {
+	query := fmt.Sprintf(`INSERT INTO %s (user_id, schema) VALUES ($1, $2)
+						ON CONFLICT (user_id) DO UPDATE SET schema = EXCLUDED.schema`, metadataSchemasTable)
+	_, err := r.db.Exec(query, userID, string(schema))
+
+	return err
+}
+
+func (r *MetadataSchemaPostgres) DeleteMetadataSchema(userID int) error {
+	query := fmt.Sprintf("DELETE FROM %s WHERE user_id = $1", metadataSchemasTable)
+	result, err := r.db.Exec(query, userID)
+
+	return checkAffected(result, err, storage.MetadataSchemaNotFound)
+}
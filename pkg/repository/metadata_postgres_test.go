@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	storage "github.com/mahadeva604/audio-storage"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestMetadataSchemaPostgres(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+	db := sqlx.NewDb(mockDB, "sqlmock")
+
+	r := NewMetadataSchemaPostgres(db)
+
+	schema := []byte(`{"type":"object"}`)
+
+	t.Run("OK get", func(t *testing.T) {
+		rows := sqlmock.NewRows([]string{"schema"}).AddRow(schema)
+		mock.ExpectQuery(`SELECT schema FROM metadata_schemas WHERE user_id = \$1`).WithArgs(1).WillReturnRows(rows)
+
+		got, err := r.GetMetadataSchema(1)
+		assert.NoError(t, err)
+		assert.Equal(t, schema, got)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Error get not found", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT schema FROM metadata_schemas WHERE user_id = \$1`).WithArgs(1).WillReturnError(sql.ErrNoRows)
+
+		_, err := r.GetMetadataSchema(1)
+		assert.Equal(t, storage.MetadataSchemaNotFound, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("OK set", func(t *testing.T) {
+		mock.ExpectExec(`INSERT INTO metadata_schemas (.+) ON CONFLICT \(user_id\) DO UPDATE`).WithArgs(1, string(schema)).WillReturnResult(sqlmock.NewResult(0, 1))
+
+		assert.NoError(t, r.SetMetadataSchema(1, schema))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Error set", func(t *testing.T) {
+		mock.ExpectExec(`INSERT INTO metadata_schemas`).WithArgs(1, string(schema)).WillReturnError(errors.New("query error"))
+
+		assert.Error(t, r.SetMetadataSchema(1, schema))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("OK delete", func(t *testing.T) {
+		mock.ExpectExec(`DELETE FROM metadata_schemas WHERE user_id = \$1`).WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+
+		assert.NoError(t, r.DeleteMetadataSchema(1))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Error delete not found", func(t *testing.T) {
+		mock.ExpectExec(`DELETE FROM metadata_schemas WHERE user_id = \$1`).WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		assert.Equal(t, storage.MetadataSchemaNotFound, r.DeleteMetadataSchema(1))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
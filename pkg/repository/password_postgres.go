@@ -0,0 +1,166 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	storage "github.com/mahadeva604/audio-storage"
+	"time"
+)
+
+type PasswordPostgres struct {
+	db *sqlx.DB
+}
+
+func NewPasswordPostgres(db *sqlx.DB) *PasswordPostgres {
+	return &PasswordPostgres{db: db}
+}
+
+// ChangePassword sets new password if old one is right, closes every session
+// except the one of keepTokenHash and revokes access tokens. API keys are
+// deleted if revokeAPIKeys is set
+func (r *PasswordPostgres) ChangePassword(userId int, oldPassword, newPassword, keepTokenHash string, revokeAPIKeys bool) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+
+	query := fmt.Sprintf(`UPDATE %s SET password_hash = crypt($3, gen_salt('bf'))
+								WHERE user_id = $1 AND password_hash = crypt($2, password_hash)`, usersTable)
+	result, err := tx.Exec(query, userId, oldPassword, newPassword)
+	if err := checkAffected(result, err, storage.WrongPassword); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	query = fmt.Sprintf("DELETE FROM %s WHERE user_id = $1 AND token_hash <> $2", sessionsTable)
+	if _, err := tx.Exec(query, userId, keepTokenHash); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := revokeAccessTokens(tx, userId); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if revokeAPIKeys {
+		if err := deleteAPIKeys(tx, userId); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	if err := deleteResetTokens(tx, userId); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// CreateResetToken stores reset token of user and returns email to send it to,
+// UserNotFound is returned if user doesn't exist or has no email
+func (r *PasswordPostgres) CreateResetToken(username, tokenHash string, ttl time.Duration) (string, error) {
+	var email string
+	query := fmt.Sprintf(`WITH target AS (SELECT user_id, email FROM %[1]s WHERE username = $1 AND email IS NOT NULL),
+								expired AS (DELETE FROM %[2]s WHERE user_id IN (SELECT user_id FROM target) AND expires_at < now()),
+								token AS (INSERT INTO %[2]s (token_hash, user_id, expires_at)
+									SELECT $2, user_id, now() + interval '%[3]d seconds' FROM target)
+								SELECT email FROM target`, usersTable, passwordResetTable, int64(ttl.Seconds()))
+	err := r.db.Get(&email, query, username, tokenHash)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", storage.UserNotFound
+	}
+
+	return email, err
+}
+
+// ResetPassword uses reset token once to set new password, every session,
+// access token and API key of its user is revoked and other reset tokens are dropped
+func (r *PasswordPostgres) ResetPassword(tokenHash, newPassword string) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+
+	var userId int
+	query := fmt.Sprintf(`UPDATE %s SET used_at = now()
+								WHERE token_hash = $1 AND used_at IS NULL AND expires_at > now() RETURNING user_id`, passwordResetTable)
+	err = tx.Get(&userId, query, tokenHash)
+	if errors.Is(err, sql.ErrNoRows) {
+		err = storage.InvalidResetToken
+	}
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	query = fmt.Sprintf("UPDATE %s SET password_hash = crypt($2, gen_salt('bf')) WHERE user_id = $1", usersTable)
+	if _, err := tx.Exec(query, userId, newPassword); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	query = fmt.Sprintf("DELETE FROM %s WHERE user_id = $1", sessionsTable)
+	if _, err := tx.Exec(query, userId); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := revokeAccessTokens(tx, userId); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := deleteAPIKeys(tx, userId); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := deleteResetTokens(tx, userId); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// SetEmail sets email if password is right, reset tokens sent to previous
+// email are dropped
+func (r *PasswordPostgres) SetEmail(userId int, password, email string) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+
+	query := fmt.Sprintf(`UPDATE %s SET email = NULLIF($3, '')
+								WHERE user_id = $1 AND password_hash = crypt($2, password_hash)`, usersTable)
+	result, err := tx.Exec(query, userId, password, email)
+	if err := checkAffected(result, err, storage.WrongPassword); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := deleteResetTokens(tx, userId); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func deleteAPIKeys(tx *sqlx.Tx, userId int) error {
+	query := fmt.Sprintf("DELETE FROM %s WHERE user_id = $1", apiKeysTable)
+	_, err := tx.Exec(query, userId)
+	return err
+}
+
+// deleteResetTokens drops unused reset tokens of user, used ones are kept as record
+func deleteResetTokens(tx *sqlx.Tx, userId int) error {
+	query := fmt.Sprintf("DELETE FROM %s WHERE user_id = $1 AND used_at IS NULL", passwordResetTable)
+	_, err := tx.Exec(query, userId)
+	return err
+}
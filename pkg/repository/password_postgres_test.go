@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	storage "github.com/mahadeva604/audio-storage"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestPasswordPostgres(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+	db := sqlx.NewDb(mockDB, "sqlmock")
+
+	r := NewPasswordPostgres(db)
+
+	t.Run("OK change", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE users SET password_hash = crypt\(\$3, gen_salt\('bf'\)\)
+								WHERE user_id = \$1 AND password_hash = crypt\(\$2, password_hash\)`).
+			WithArgs(1, "old", "new").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`DELETE FROM sessions WHERE user_id = \$1 AND token_hash <> \$2`).
+			WithArgs(1, "hash").WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectExec(`UPDATE users SET tokens_valid_after = date_trunc\('second', now\(\)\) WHERE user_id = \$1`).
+			WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`DELETE FROM password_reset_tokens WHERE user_id = \$1 AND used_at IS NULL`).
+			WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		assert.NoError(t, r.ChangePassword(1, "old", "new", "hash", false))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Change with wrong password", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE users SET password_hash`).
+			WithArgs(1, "wrong", "new").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		assert.ErrorIs(t, r.ChangePassword(1, "wrong", "new", "", false), storage.WrongPassword)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("OK change revoking api keys", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE users SET password_hash`).
+			WithArgs(1, "old", "new").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`DELETE FROM sessions`).WithArgs(1, "").WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectExec(`UPDATE users SET tokens_valid_after`).WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`DELETE FROM api_keys WHERE user_id = \$1`).WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectExec(`DELETE FROM password_reset_tokens`).WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		assert.NoError(t, r.ChangePassword(1, "old", "new", "", true))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("OK set email", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE users SET email = NULLIF\(\$3, ''\)
+								WHERE user_id = \$1 AND password_hash = crypt\(\$2, password_hash\)`).
+			WithArgs(1, "qwerty", "user_1@example.com").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`DELETE FROM password_reset_tokens WHERE user_id = \$1 AND used_at IS NULL`).
+			WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		assert.NoError(t, r.SetEmail(1, "qwerty", "user_1@example.com"))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Set email with wrong password", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE users SET email`).
+			WithArgs(1, "wrong", "").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		assert.ErrorIs(t, r.SetEmail(1, "wrong", ""), storage.WrongPassword)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("OK create reset token", func(t *testing.T) {
+		mock.ExpectQuery(`WITH target AS \(SELECT user_id, email FROM users WHERE username = \$1 AND email IS NOT NULL\),
+								expired AS \(DELETE FROM password_reset_tokens WHERE user_id IN \(SELECT user_id FROM target\) AND expires_at < now\(\)\),
+								token AS \(INSERT INTO password_reset_tokens \(token_hash, user_id, expires_at\)
+									SELECT \$2, user_id, now\(\) \+ interval '3600 seconds' FROM target\)
+								SELECT email FROM target`).
+			WithArgs("user_1", "hash").WillReturnRows(sqlmock.NewRows([]string{"email"}).AddRow("user_1@example.com"))
+
+		email, err := r.CreateResetToken("user_1", "hash", time.Hour)
+		assert.NoError(t, err)
+		assert.Equal(t, "user_1@example.com", email)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Create reset token without email", func(t *testing.T) {
+		mock.ExpectQuery(`WITH target AS`).WithArgs("user_2", "hash").WillReturnRows(sqlmock.NewRows([]string{"email"}))
+
+		_, err := r.CreateResetToken("user_2", "hash", time.Hour)
+		assert.ErrorIs(t, err, storage.UserNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("OK reset", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`UPDATE password_reset_tokens SET used_at = now\(\)
+								WHERE token_hash = \$1 AND used_at IS NULL AND expires_at > now\(\) RETURNING user_id`).
+			WithArgs("hash").WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(1))
+		mock.ExpectExec(`UPDATE users SET password_hash = crypt\(\$2, gen_salt\('bf'\)\) WHERE user_id = \$1`).
+			WithArgs(1, "new").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`DELETE FROM sessions WHERE user_id = \$1`).
+			WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 3))
+		mock.ExpectExec(`UPDATE users SET tokens_valid_after`).WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`DELETE FROM api_keys WHERE user_id = \$1`).
+			WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`DELETE FROM password_reset_tokens WHERE user_id = \$1 AND used_at IS NULL`).
+			WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		assert.NoError(t, r.ResetPassword("hash", "new"))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Reset with used token", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`UPDATE password_reset_tokens SET used_at = now\(\)`).
+			WithArgs("hash").WillReturnRows(sqlmock.NewRows([]string{"user_id"}))
+		mock.ExpectRollback()
+
+		assert.ErrorIs(t, r.ResetPassword("hash", "new"), storage.InvalidResetToken)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -1,15 +1,36 @@
 package repository
 
 import (
+	"database/sql"
 	"fmt"
 	"github.com/jmoiron/sqlx"
 )
 
 const (
-	usersTable  = "users"
-	audiosTable = "audios"
-	sharesTable = "shares"
-	tokenTable  = "refresh_tokens"
+	usersTable            = "users"
+	audiosTable           = "audios"
+	sharesTable           = "shares"
+	sessionsTable         = "sessions"
+	blocksTable           = "share_blocks"
+	autoAcceptTable       = "share_auto_accept"
+	collectionsTable      = "collections"
+	collectionItemsTable  = "collection_items"
+	collectionSharesTable = "collection_shares"
+	audioAccessView       = "audio_access"
+	feedsTable            = "collection_feeds"
+	tagsTable             = "audio_tags"
+	searchConfigTable     = "search_config"
+	historyTable          = "audio_history"
+	metadataSchemasTable  = "metadata_schemas"
+	commentsTable         = "audio_comments"
+	chaptersTable         = "audio_chapters"
+	transcriptCuesTable   = "transcript_cues"
+	jobsTable             = "jobs"
+	revokedTokensTable    = "revoked_tokens"
+	rotatedTokensTable    = "rotated_tokens"
+	securityEventsTable   = "security_events"
+	apiKeysTable          = "api_keys"
+	passwordResetTable    = "password_reset_tokens"
 )
 
 type Config struct {
@@ -35,3 +56,16 @@ func NewPostgresDB(cfg Config) (*sqlx.DB, error) {
 
 	return db, nil
 }
+
+// checkAffected returns notAffected error when the statement changed no rows
+func checkAffected(result sql.Result, err error, notAffected error) error {
+	if err != nil {
+		return err
+	}
+
+	if rowsAff, err := result.RowsAffected(); rowsAff == 0 && err == nil {
+		return notAffected
+	}
+
+	return err
+}
@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+)
+
+// queryBuilder collects conditions and positional arguments of a dynamic
+// query. Only SQL written in this package goes into conditions, user input
+// is always passed as an argument
+type queryBuilder struct {
+	conditions []string
+	args       []interface{}
+}
+
+func newQueryBuilder(args ...interface{}) *queryBuilder {
+	return &queryBuilder{args: args}
+}
+
+// arg adds value to arguments and returns its placeholder
+func (b *queryBuilder) arg(value interface{}) string {
+	b.args = append(b.args, value)
+	return fmt.Sprintf("$%d", len(b.args))
+}
+
+func (b *queryBuilder) where(condition string) {
+	b.conditions = append(b.conditions, condition)
+}
+
+// conditionsSQL returns conditions joined with AND, or TRUE if there are none
+func (b *queryBuilder) conditionsSQL() string {
+	if len(b.conditions) == 0 {
+		return "TRUE"
+	}
+	return strings.Join(b.conditions, " AND ")
+}
+
+// sortKey is a whitelisted sort column. Alias is the column name in the
+// select list, expr is the same value usable in WHERE
+type sortKey struct {
+	alias string
+	expr  string
+	desc  bool
+}
+
+// sortOrder is a whitelisted ORDER BY clause, the last key must be unique
+// so that the order is stable and can be used for keyset pagination
+type sortOrder struct {
+	keys []sortKey
+}
+
+// sql renders order with every column qualified by prefix
+func (o sortOrder) sql(prefix string) string {
+	columns := make([]string, len(o.keys))
+	for i, key := range o.keys {
+		columns[i] = prefix + key.alias
+		if key.desc {
+			columns[i] += " DESC"
+		}
+	}
+	return strings.Join(columns, ", ")
+}
+
+// name identifies order in cursors
+func (o sortOrder) name() string {
+	return o.sql("")
+}
+
+func (o sortOrder) reverse() sortOrder {
+	keys := make([]sortKey, len(o.keys))
+	for i, key := range o.keys {
+		keys[i] = key
+		keys[i].desc = !key.desc
+	}
+	return sortOrder{keys: keys}
+}
+
+// after returns condition matching rows that follow values in this order
+func (o sortOrder) after(b *queryBuilder, values []interface{}) string {
+	placeholders := make([]string, len(values))
+	for i, value := range values {
+		placeholders[i] = b.arg(value)
+	}
+
+	alternatives := make([]string, len(o.keys))
+	for i, key := range o.keys {
+		parts := make([]string, 0, i+1)
+		for j := 0; j < i; j++ {
+			parts = append(parts, fmt.Sprintf("%s = %s", o.keys[j].expr, placeholders[j]))
+		}
+
+		operator := ">"
+		if key.desc {
+			operator = "<"
+		}
+		parts = append(parts, fmt.Sprintf("%s %s %s", key.expr, operator, placeholders[i]))
+
+		alternatives[i] = "(" + strings.Join(parts, " AND ") + ")"
+	}
+
+	return "(" + strings.Join(alternatives, " OR ") + ")"
+}
@@ -11,40 +11,208 @@ import (
 type Authorization interface {
 	CreateUser(user storage.User) (int, error)
 	GetUser(username, password string) (storage.User, error)
-	SetRefreshToken(username int, refreshToken string, refreshTokenTTL time.Duration) error
-	UpdateRefreshToken(oldRefreshToken string, newRefreshToken string, refreshTokenTTL time.Duration) (int, error)
+	SetRefreshToken(userId int, tokenHash string, client storage.SessionClient, refreshTokenTTL time.Duration) error
+	UpdateRefreshToken(oldTokenHash string, newTokenHash string, client storage.SessionClient, refreshTokenTTL time.Duration) (int, error)
+	IsAdmin(userId int) (bool, error)
+	DeleteRefreshToken(userId int, tokenHash string) error
+	DeleteRefreshTokens(userId int) error
+	GetSessions(userId int) ([]storage.Session, error)
+	DeleteSession(userId, sessionId int) error
+	GetSecurityEvents(userId int, input storage.SecurityEventListParam) (storage.SecurityEventListJson, error)
+	RevokeAccessToken(jti string, expiresAt time.Time) error
+	IsAccessTokenRevoked(userId int, jti string, issuedAt time.Time) (bool, error)
+}
+
+type Password interface {
+	ChangePassword(userId int, oldPassword, newPassword, keepTokenHash string, revokeAPIKeys bool) error
+	CreateResetToken(username, tokenHash string, ttl time.Duration) (string, error)
+	ResetPassword(tokenHash, newPassword string) error
+	SetEmail(userId int, password, email string) error
+}
+
+type APIKey interface {
+	CreateAPIKey(userId int, input storage.APIKeyInput, prefix, keyHash string) (storage.APIKey, error)
+	GetAPIKeys(userId int) ([]storage.APIKey, error)
+	DeleteAPIKey(userId, keyId int) error
+	UseAPIKey(keyHash string) (storage.APIKeyOwner, error)
 }
 
 type Audio interface {
-	UploadFile(userId int, path string) (int, error)
-	AddDescription(userID, audioId int, input storage.UpdateAudio) error
+	UploadFile(userId int, path string, size int64, metadata storage.AudioMetadata) (int, error)
+	GetAudio(userID, audioId int) (storage.Audio, error)
+	AddDescription(userID, audioId, version int, metadata storage.AudioMetadata) (storage.Audio, error)
 	DownloadFile(userID, audioId int) (storage.DownloadAudio, error)
 	GetAudioList(userID int, input storage.AudioListParam) (storage.AudioListJson, error)
+	GetAudioPage(userID int, input storage.AudioListParam) (storage.AudioPageJson, error)
+	GetAudioHistory(userID, audioId int, input storage.HistoryListParam) (storage.HistoryListJson, error)
+}
+
+type MetadataSchema interface {
+	GetMetadataSchema(userID int) ([]byte, error)
+	SetMetadataSchema(userID int, schema []byte) error
+	DeleteMetadataSchema(userID int) error
+}
+
+type Artwork interface {
+	SetAudioArtwork(userID, audioId int, artworkId string) (string, error)
+	GetAudioArtwork(userID, audioId int) (string, error)
+	SetCollectionArtwork(userID, collectionId int, artworkId string) (string, error)
+	GetCollectionArtwork(userID, collectionId int) (string, error)
+}
+
+type Comment interface {
+	AddComment(userID, audioId int, input storage.CommentInput) (int, error)
+	GetComments(userID, audioId int, input storage.CommentListParam) (storage.CommentListJson, error)
+	UpdateComment(userID, audioId, commentId int, input storage.CommentUpdate) error
+	DeleteComment(userID, audioId, commentId int) error
+}
+
+type Chapter interface {
+	SetChapters(userID, audioId int, chapters []storage.Chapter) error
+	GetChapters(userID, audioId int) (storage.AudioChapters, error)
+}
+
+type Transcript interface {
+	SetTranscript(userID, audioId int, transcript storage.Transcript) error
+	GetTranscript(userID, audioId int) (storage.Transcript, error)
+	DeleteTranscript(userID, audioId int) error
+}
+
+type Transcription interface {
+	QueueTranscription(userID, audioId, maxAttempts int) error
+	GetTranscription(userID, audioId int) (storage.TranscriptionJob, error)
+}
+
+type Job interface {
+	EnqueueJob(jobType string, audioId *int, maxAttempts int) (int, error)
+	ClaimJob(lease time.Duration) (storage.JobTask, error)
+	ExtendJob(jobId int, claimToken string, lease time.Duration) error
+	CompleteJob(jobId int, claimToken string) error
+	FailJob(jobId int, claimToken, message string, retryAt *time.Time) error
+	ReapJobs() (int64, error)
+	GetAudioJobs(userID, audioId int) ([]storage.Job, error)
+	GetJobs(input storage.JobListParam) (storage.JobListJson, error)
+	RetryJob(jobId int) error
+	SetAudioProbe(audioId int, probe storage.AudioProbe) error
+	SetAudioFile(audioId int, size int64) error
+	SetAudioChecksum(audioId int, checksum string) error
+	GetOrphanFiles(files storage.OrphanFiles) (storage.OrphanFiles, error)
 }
 
 type Share interface {
 	ShareAudio(userID, audioId, shareId int) error
 	UnshareAudio(userID, audioId, shareId int) error
 	GetSharedList(input storage.ShareListParam) (storage.ShareListJson, error)
+	GetSharedPage(input storage.ShareListParam) (storage.SharePageJson, error)
+}
+
+type Invitation interface {
+	GetInvitations(userID int, input storage.InvitationListParam) (storage.InvitationListJson, error)
+	AcceptInvitation(userID, audioId int) error
+	DeclineInvitation(userID, audioId int) error
+	AcceptCollectionInvitation(userID, collectionId int) error
+	DeclineCollectionInvitation(userID, collectionId int) error
+	BlockSender(userID, senderId int) error
+	UnblockSender(userID, senderId int) error
+	GetBlockedSenders(userID int) ([]storage.Sender, error)
+	AddAutoAccept(userID, senderId int) error
+	RemoveAutoAccept(userID, senderId int) error
+	GetAutoAcceptSenders(userID int) ([]storage.Sender, error)
+}
+
+type Collection interface {
+	CreateCollection(userID int, input storage.CollectionInput) (int, error)
+	UpdateCollection(userID, collectionId int, input storage.CollectionInput) error
+	DeleteCollection(userID, collectionId int) error
+	GetCollectionList(userID int, input storage.CollectionListParam) (storage.CollectionListJson, error)
+	GetCollectionItems(userID, collectionId int, input storage.CollectionItemsParam) (storage.CollectionItemsJson, error)
+	AddCollectionItem(userID, collectionId, audioId int) error
+	RemoveCollectionItem(userID, collectionId, audioId int) error
+	ReorderCollection(userID, collectionId int, audioIds []int) error
+	ShareCollection(userID, collectionId, shareId int) error
+	UnshareCollection(userID, collectionId, shareId int) error
+}
+
+type Feed interface {
+	PublishFeed(userID, collectionId int, token string, input storage.FeedInput) (string, error)
+	GetFeedSettings(userID, collectionId int) (storage.FeedSettings, error)
+	RotateFeedToken(userID, collectionId int, token string) error
+	DeleteFeed(userID, collectionId int) error
+	GetFeedChannel(token string) (storage.FeedChannel, error)
+	GetCollectionChannel(userID, collectionId int) (storage.FeedChannel, error)
+	GetFeedEpisodes(collectionId int) ([]storage.FeedEpisode, error)
+	GetFeedFile(audioId, collectionId int, token string) (storage.DownloadAudio, error)
+}
+
+type Tag interface {
+	AddTags(userID, audioId int, tags []string) error
+	RemoveTag(userID, audioId int, tag string) error
+	GetAudioTags(userID, audioId int) ([]storage.AudioTag, error)
+	GetTags(userID int, input storage.TagListParam) ([]storage.TagCount, error)
+}
+
+type Search interface {
+	SetSearchLanguage(language string) error
+	SearchAudio(userID int, input storage.SearchParam) (storage.SearchResultJson, error)
 }
 
 type Storage interface {
-	StoreFile(fileId uuid.UUID, file io.ReadSeeker) error
+	StoreFile(fileId uuid.UUID, file io.ReaderAt, size int64) (storage.StoredFile, error)
 	GetFile(fileId uuid.UUID) (io.ReadCloser, int64, error)
+	GetTaggedFile(fileId uuid.UUID, tags storage.AudioTags) (io.ReadCloser, int64, error)
+	StoreArtwork(artworkId uuid.UUID, image []byte) error
+	GetArtwork(artworkId uuid.UUID, size string) ([]byte, error)
+	DeleteArtwork(artworkId uuid.UUID) error
+	FilePath(fileId uuid.UUID) string
+	NormalizeFile(fileId uuid.UUID) (int64, error)
+	ProbeFile(fileId uuid.UUID) (storage.AudioProbe, error)
+	ChecksumFile(fileId uuid.UUID) (string, error)
+	PackageHLS(fileId uuid.UUID) error
+	GetHLSFile(fileId uuid.UUID, name string) ([]byte, error)
+	ListFiles(grace time.Duration) (storage.OrphanFiles, error)
+	DeleteFiles(files storage.OrphanFiles) error
 }
 
 type Repository struct {
 	Authorization
+	Password
+	APIKey
 	Audio
+	MetadataSchema
+	Artwork
+	Comment
+	Chapter
+	Transcript
+	Transcription
+	Job
 	Share
+	Invitation
+	Collection
+	Feed
+	Tag
+	Search
 	Storage
 }
 
 func NewRepository(db *sqlx.DB, dirPath string) *Repository {
 	return &Repository{
-		Authorization: NewAuthPostgres(db),
-		Audio:         NewAudioPostgres(db),
-		Share:         NewSharePostgres(db),
-		Storage:       NewStorageFS(dirPath),
+		Authorization:  NewAuthPostgres(db),
+		Password:       NewPasswordPostgres(db),
+		APIKey:         NewAPIKeyPostgres(db),
+		Audio:          NewAudioPostgres(db),
+		MetadataSchema: NewMetadataSchemaPostgres(db),
+		Artwork:        NewArtworkPostgres(db),
+		Comment:        NewCommentPostgres(db),
+		Chapter:        NewChapterPostgres(db),
+		Transcript:     NewTranscriptPostgres(db),
+		Transcription:  NewTranscriptionPostgres(db),
+		Job:            NewJobPostgres(db),
+		Share:          NewSharePostgres(db),
+		Invitation:     NewInvitationPostgres(db),
+		Collection:     NewCollectionPostgres(db),
+		Feed:           NewFeedPostgres(db),
+		Tag:            NewTagPostgres(db),
+		Search:         NewSearchPostgres(db),
+		Storage:        NewStorageFS(dirPath),
 	}
 }
@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	storage "github.com/mahadeva604/audio-storage"
+)
+
+var snippetOptions = fmt.Sprintf("StartSel=%s, StopSel=%s, MaxWords=30, MinWords=10, MaxFragments=2",
+	storage.SnippetStartSel, storage.SnippetStopSel)
+
+type SearchPostgres struct {
+	db *sqlx.DB
+}
+
+func NewSearchPostgres(db *sqlx.DB) *SearchPostgres {
+	return &SearchPostgres{db: db}
+}
+
+// SetSearchLanguage switches text search configuration and rebuilds search
+// vectors if it has changed
+func (r *SearchPostgres) SetSearchLanguage(language string) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+
+	query := fmt.Sprintf("UPDATE %s SET language = $1::regconfig WHERE language <> $1::regconfig", searchConfigTable)
+	result, err := tx.Exec(query, language)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if rowsAff, err := result.RowsAffected(); rowsAff == 0 || err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	// search vectors are recomputed by triggers on update
+	for _, table := range []string{usersTable, audiosTable, transcriptCuesTable} {
+		if _, err := tx.Exec(fmt.Sprintf("UPDATE %s SET search_vector = NULL", table)); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *SearchPostgres) SearchAudio(userID int, input storage.SearchParam) (storage.SearchResultJson, error) {
+	query := fmt.Sprintf(`SELECT count(*) OVER() AS full_count, a.audio_id, a.title, a.user_id = $1 AS is_owner, a.user_id, u.name,
+						ts_rank(a.search_vector || u.search_vector, q.query) + coalesce(c.rank, 0) AS rank,
+						ts_headline(q.language, a.title || E'\n' || a.description, q.query, $3) AS snippet,
+						c.start_ms AS cue_start_ms, c.end_ms AS cue_end_ms,
+						ts_headline(q.language, c.text, q.query, $3) AS cue_snippet
+						FROM %s a
+						JOIN %s u ON a.user_id = u.user_id
+						CROSS JOIN (SELECT websearch_to_tsquery(language, $2) AS query, language FROM %s) q
+						LEFT JOIN LATERAL (SELECT start_ms, end_ms, text, ts_rank(search_vector, q.query) AS rank FROM %s
+							WHERE audio_id = a.audio_id AND search_vector @@ q.query
+							ORDER BY rank DESC, position LIMIT 1) c ON true
+						WHERE a.audio_id IN (SELECT audio_id FROM %s WHERE user_id = $1)
+						AND (a.search_vector @@ q.query OR u.search_vector @@ q.query OR c.start_ms IS NOT NULL
+						OR EXISTS (SELECT 1 FROM %s t WHERE t.audio_id = a.audio_id AND t.user_id = $1
+						AND to_tsvector(q.language, t.tag) @@ q.query))
+						ORDER BY rank DESC, a.audio_id
+						OFFSET $4 LIMIT $5`, audiosTable, usersTable, searchConfigTable, transcriptCuesTable, audioAccessView, tagsTable)
+
+	results := make([]storage.SearchResult, 0)
+
+	var result storage.SearchResultDb
+	var totalCount int
+
+	rows, err := r.db.Queryx(query, userID, input.Query, snippetOptions, input.Offset, input.Limit)
+	if err != nil {
+		return storage.SearchResultJson{}, err
+	}
+	for rows.Next() {
+
+		err := rows.StructScan(&result)
+		if err != nil {
+			return storage.SearchResultJson{}, err
+		}
+		totalCount = result.Count
+		result.Cue = nil
+		if result.CueStartMs != nil && result.CueEndMs != nil && result.CueSnippet != nil {
+			result.Cue = &storage.SearchCue{StartMs: *result.CueStartMs, EndMs: *result.CueEndMs, Snippet: *result.CueSnippet}
+		}
+		results = append(results, result.SearchResult)
+	}
+
+	return storage.SearchResultJson{TotalCount: totalCount, Records: results}, err
+}
@@ -0,0 +1,176 @@
+package repository
+
+import (
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	storage "github.com/mahadeva604/audio-storage"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestSearchPostgres_SetSearchLanguage(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+	db := sqlx.NewDb(mockDB, "sqlmock")
+
+	r := NewSearchPostgres(db)
+	type mockBehavior func(language string)
+
+	testTable := []struct {
+		name            string
+		language        string
+		mockBehavior    mockBehavior
+		expectedErr     bool
+		expectedErrType error
+	}{
+		{
+			name:     "OK changed",
+			language: "russian",
+			mockBehavior: func(language string) {
+				mock.ExpectBegin()
+				mock.ExpectExec(`UPDATE search_config SET language = (.+)`).WithArgs(language).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(`UPDATE users SET search_vector = NULL`).WillReturnResult(sqlmock.NewResult(0, 2))
+				mock.ExpectExec(`UPDATE audios SET search_vector = NULL`).WillReturnResult(sqlmock.NewResult(0, 5))
+				mock.ExpectExec(`UPDATE transcript_cues SET search_vector = NULL`).WillReturnResult(sqlmock.NewResult(0, 40))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name:     "OK not changed",
+			language: "english",
+			mockBehavior: func(language string) {
+				mock.ExpectBegin()
+				mock.ExpectExec(`UPDATE search_config SET language = (.+)`).WithArgs(language).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectRollback()
+			},
+		},
+		{
+			name:     "Error unknown language",
+			language: "klingon",
+			mockBehavior: func(language string) {
+				mock.ExpectBegin()
+				mock.ExpectExec(`UPDATE search_config SET language = (.+)`).WithArgs(language).WillReturnError(errors.New("text search configuration does not exist"))
+				mock.ExpectRollback()
+			},
+			expectedErr:     true,
+			expectedErrType: errors.New("text search configuration does not exist"),
+		},
+		{
+			name:     "Error rebuild",
+			language: "russian",
+			mockBehavior: func(language string) {
+				mock.ExpectBegin()
+				mock.ExpectExec(`UPDATE search_config SET language = (.+)`).WithArgs(language).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(`UPDATE users SET search_vector = NULL`).WillReturnError(errors.New("update error"))
+				mock.ExpectRollback()
+			},
+			expectedErr:     true,
+			expectedErrType: errors.New("update error"),
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior(testCase.language)
+
+			err := r.SetSearchLanguage(testCase.language)
+			if testCase.expectedErr {
+				assert.Error(t, err)
+				if testCase.expectedErrType != nil {
+					assert.Equal(t, testCase.expectedErrType, err)
+				}
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestSearchPostgres_SearchAudio(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+	db := sqlx.NewDb(mockDB, "sqlmock")
+
+	r := NewSearchPostgres(db)
+	type mockBehavior func(userId int, input storage.SearchParam)
+
+	offset, limit := 0, 10
+	query := `SELECT count\(\*\) OVER\(\) AS full_count, (.+) FROM audios a (.+) websearch_to_tsquery\(language, \$2\) (.+) LEFT JOIN LATERAL (.+) FROM transcript_cues (.+) ORDER BY rank DESC, a.audio_id OFFSET \$4 LIMIT \$5`
+	columns := []string{"full_count", "audio_id", "title", "is_owner", "user_id", "name", "rank", "snippet", "cue_start_ms", "cue_end_ms", "cue_snippet"}
+
+	testTable := []struct {
+		name          string
+		userId        int
+		input         storage.SearchParam
+		mockBehavior  mockBehavior
+		expectErr     bool
+		expectErrType error
+		expectData    storage.SearchResultJson
+	}{
+		{
+			name:   "OK",
+			userId: 1,
+			input:  storage.SearchParam{Query: "jazz", Offset: &offset, Limit: &limit},
+			mockBehavior: func(userId int, input storage.SearchParam) {
+				rows := sqlmock.NewRows(columns).
+					AddRow(2, 3, "jazz live", false, 2, "user 2", 0.6, "\x02jazz\x03 live", 61000, 64500, "some \x02jazz\x03 here").
+					AddRow(2, 1, "audio 1", true, 1, "user 1", 0.1, "audio 1", nil, nil, nil)
+				mock.ExpectQuery(query).WithArgs(userId, input.Query, snippetOptions, input.Offset, input.Limit).WillReturnRows(rows)
+			},
+			expectData: storage.SearchResultJson{
+				TotalCount: 2,
+				Records: []storage.SearchResult{
+					{Id: 3, Title: "jazz live", Owner: 2, Name: "user 2", Rank: 0.6, Snippet: "\x02jazz\x03 live",
+						Cue: &storage.SearchCue{StartMs: 61000, EndMs: 64500, Snippet: "some \x02jazz\x03 here"}},
+					{Id: 1, Title: "audio 1", IsOwner: true, Owner: 1, Name: "user 1", Rank: 0.1, Snippet: "audio 1"},
+				},
+			},
+		},
+		{
+			name:   "OK nothing found",
+			userId: 1,
+			input:  storage.SearchParam{Query: "rock", Offset: &offset, Limit: &limit},
+			mockBehavior: func(userId int, input storage.SearchParam) {
+				rows := sqlmock.NewRows(columns)
+				mock.ExpectQuery(query).WithArgs(userId, input.Query, snippetOptions, input.Offset, input.Limit).WillReturnRows(rows)
+			},
+			expectData: storage.SearchResultJson{Records: []storage.SearchResult{}},
+		},
+		{
+			name:   "Error query",
+			userId: 1,
+			input:  storage.SearchParam{Query: "jazz", Offset: &offset, Limit: &limit},
+			mockBehavior: func(userId int, input storage.SearchParam) {
+				mock.ExpectQuery(query).WithArgs(userId, input.Query, snippetOptions, input.Offset, input.Limit).WillReturnError(errors.New("query error"))
+			},
+			expectErr:     true,
+			expectErrType: errors.New("query error"),
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior(testCase.userId, testCase.input)
+
+			output, err := r.SearchAudio(testCase.userId, testCase.input)
+			if testCase.expectErr {
+				assert.Error(t, err)
+				if testCase.expectErrType != nil {
+					assert.Equal(t, testCase.expectErrType, err)
+				}
+			} else {
+				assert.Equal(t, testCase.expectData, output)
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -16,8 +16,13 @@ func NewSharePostgres(db *sqlx.DB) *SharePostgres {
 }
 
 func (r *SharePostgres) ShareAudio(userID, audioId, shareId int) error {
-	query := fmt.Sprintf(`INSERT INTO %s SELECT audio_id, $1 FROM %s
-								WHERE audio_id = $2 and user_id = $3`, sharesTable, audiosTable)
+	// Shares from blocked senders are stored as declined so the sender can't tell
+	// that the recipient blocked them
+	query := fmt.Sprintf(`INSERT INTO %s (audio_id, user_id, status) SELECT audio_id, $1,
+								CASE WHEN EXISTS (SELECT 1 FROM %s WHERE user_id = $1 AND blocked_id = a.user_id) THEN 'declined'
+								WHEN EXISTS (SELECT 1 FROM %s WHERE user_id = $1 AND sender_id = a.user_id) THEN 'accepted'
+								ELSE 'pending' END
+								FROM %s a WHERE audio_id = $2 and user_id = $3`, sharesTable, blocksTable, autoAcceptTable, audiosTable)
 	result, err := r.db.Exec(query, shareId, audioId, userID)
 
 	if _, ok := err.(*pq.Error); ok {
@@ -62,6 +67,7 @@ func (r *SharePostgres) GetSharedList(input storage.ShareListParam) (storage.Sha
 								FROM %s s
 								JOIN %s a USING (audio_id)
 								JOIN %s u ON a.user_id = u.user_id
+								WHERE s.status = 'accepted'
 								GROUP BY a.user_id, name ORDER BY name
 								OFFSET $1 LIMIT $2`, sharesTable, audiosTable, usersTable)
 
@@ -85,3 +91,71 @@ func (r *SharePostgres) GetSharedList(input storage.ShareListParam) (storage.Sha
 
 	return storage.ShareListJson{Count: totalCount, Users: shareList}, err
 }
+
+var shareListOrder = sortOrder{keys: []sortKey{{alias: "name", expr: "u.name"}, {alias: "user_id", expr: "a.user_id"}}}
+
+func (r *SharePostgres) GetSharedPage(input storage.ShareListParam) (storage.SharePageJson, error) {
+	var c *cursor
+	if input.Cursor != "" {
+		decoded, err := decodeCursor(input.Cursor, shareListOrder)
+		if err != nil {
+			return storage.SharePageJson{}, err
+		}
+		c = &decoded
+	}
+
+	b := newQueryBuilder()
+	b.where("s.status = 'accepted'")
+
+	var totalCount *int
+	if input.WithCount {
+		var count int
+		query := fmt.Sprintf(`SELECT count(DISTINCT a.user_id) FROM %s s JOIN %s a USING (audio_id) WHERE %s`,
+			sharesTable, audiosTable, b.conditionsSQL())
+		if err := r.db.Get(&count, query, b.args...); err != nil {
+			return storage.SharePageJson{}, err
+		}
+		totalCount = &count
+	}
+
+	// going backward rows before cursor are fetched in reverse order
+	order := shareListOrder
+	if c != nil {
+		if c.Prev {
+			order = shareListOrder.reverse()
+		}
+		b.where(order.after(b, c.Values))
+	}
+
+	query := fmt.Sprintf(`SELECT a.user_id, name, count(*) AS count
+								FROM %s s
+								JOIN %s a USING (audio_id)
+								JOIN %s u ON a.user_id = u.user_id
+								WHERE %s
+								GROUP BY a.user_id, name ORDER BY %s
+								LIMIT %s`, sharesTable, audiosTable, usersTable, b.conditionsSQL(), order.sql(""), b.arg(*input.Limit+1))
+
+	users := make([]storage.ShareListCount, 0)
+	if err := r.db.Select(&users, query, b.args...); err != nil {
+		return storage.SharePageJson{}, err
+	}
+
+	more := len(users) > *input.Limit
+	if more {
+		users = users[:*input.Limit]
+	}
+
+	if c != nil && c.Prev {
+		for i, j := 0, len(users)-1; i < j; i, j = i+1, j-1 {
+			users[i], users[j] = users[j], users[i]
+		}
+	}
+
+	result := storage.SharePageJson{Count: totalCount, Users: users}
+	if last := len(users) - 1; last >= 0 {
+		result.NextCursor, result.PrevCursor = pageCursors(shareListOrder, c, more,
+			[]interface{}{users[0].Name, users[0].UserId}, []interface{}{users[last].Name, users[last].UserId})
+	}
+
+	return result, nil
+}
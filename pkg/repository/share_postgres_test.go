@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"encoding/json"
 	"errors"
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/jmoiron/sqlx"
@@ -37,7 +38,7 @@ func TestSharePostgres_ShareAudio(t *testing.T) {
 			userId:  3,
 			mockBehavior: func(shareId, audioId, userID int) {
 				result := sqlmock.NewResult(0, 1)
-				mock.ExpectExec("INSERT INTO shares SELECT (.+) FROM audios").WithArgs(shareId, audioId, userID).WillReturnResult(result)
+				mock.ExpectExec(`INSERT INTO shares \(audio_id, user_id, status\) SELECT (.+) FROM audios`).WithArgs(shareId, audioId, userID).WillReturnResult(result)
 			},
 		},
 		{
@@ -46,7 +47,7 @@ func TestSharePostgres_ShareAudio(t *testing.T) {
 			audioId: 2,
 			userId:  3,
 			mockBehavior: func(shareId, audioId, userID int) {
-				mock.ExpectExec("INSERT INTO shares SELECT (.+) FROM audios").WithArgs(shareId, audioId, userID).WillReturnError(errors.New("query error"))
+				mock.ExpectExec(`INSERT INTO shares \(audio_id, user_id, status\) SELECT (.+) FROM audios`).WithArgs(shareId, audioId, userID).WillReturnError(errors.New("query error"))
 			},
 			expectedErr:     true,
 			expectedErrType: errors.New("query error"),
@@ -57,7 +58,7 @@ func TestSharePostgres_ShareAudio(t *testing.T) {
 			audioId: 2,
 			userId:  3,
 			mockBehavior: func(shareId, audioId, userID int) {
-				mock.ExpectExec("INSERT INTO shares SELECT (.+) FROM audios").WithArgs(shareId, audioId, userID).WillReturnError(&pq.Error{Code: "23505"})
+				mock.ExpectExec(`INSERT INTO shares \(audio_id, user_id, status\) SELECT (.+) FROM audios`).WithArgs(shareId, audioId, userID).WillReturnError(&pq.Error{Code: "23505"})
 			},
 			expectedErr:     true,
 			expectedErrType: storage.ShareExists,
@@ -68,7 +69,7 @@ func TestSharePostgres_ShareAudio(t *testing.T) {
 			audioId: 2,
 			userId:  3,
 			mockBehavior: func(shareId, audioId, userID int) {
-				mock.ExpectExec("INSERT INTO shares SELECT (.+) FROM audios").WithArgs(shareId, audioId, userID).WillReturnError(&pq.Error{Code: "23503"})
+				mock.ExpectExec(`INSERT INTO shares \(audio_id, user_id, status\) SELECT (.+) FROM audios`).WithArgs(shareId, audioId, userID).WillReturnError(&pq.Error{Code: "23503"})
 			},
 			expectedErr:     true,
 			expectedErrType: storage.ShareUserNotExists,
@@ -80,7 +81,7 @@ func TestSharePostgres_ShareAudio(t *testing.T) {
 			userId:  3,
 			mockBehavior: func(shareId, audioId, userID int) {
 				result := sqlmock.NewResult(0, 0)
-				mock.ExpectExec("INSERT INTO shares SELECT (.+) FROM audios").WithArgs(shareId, audioId, userID).WillReturnResult(result)
+				mock.ExpectExec(`INSERT INTO shares \(audio_id, user_id, status\) SELECT (.+) FROM audios`).WithArgs(shareId, audioId, userID).WillReturnResult(result)
 			},
 			expectedErr:     true,
 			expectedErrType: storage.NotOwner,
@@ -212,6 +213,7 @@ func TestSharePostgres_GetSharedList(t *testing.T) {
 				query := `SELECT (.+) FROM shares s
 						JOIN audios a USING \(audio_id\)
 						JOIN users u ON a.user_id = u.user_id
+						WHERE s.status = 'accepted'
 						GROUP BY a.user_id, name ORDER BY name
 						OFFSET \$1 LIMIT \$2`
 				mock.ExpectQuery(query).WithArgs(offset, limit).WillReturnRows(rows)
@@ -255,6 +257,7 @@ func TestSharePostgres_GetSharedList(t *testing.T) {
 				query := `SELECT (.+) FROM shares s
 						JOIN audios a USING \(audio_id\)
 						JOIN users u ON a.user_id = u.user_id
+						WHERE s.status = 'accepted'
 						GROUP BY a.user_id, name ORDER BY name
 						OFFSET \$1 LIMIT \$2`
 				mock.ExpectQuery(query).WithArgs(offset, limit).WillReturnError(errors.New("query error"))
@@ -271,6 +274,7 @@ func TestSharePostgres_GetSharedList(t *testing.T) {
 				query := `SELECT (.+) FROM shares s
 						JOIN audios a USING \(audio_id\)
 						JOIN users u ON a.user_id = u.user_id
+						WHERE s.status = 'accepted'
 						GROUP BY a.user_id, name ORDER BY name
 						OFFSET \$1 LIMIT \$2`
 				mock.ExpectQuery(query).WithArgs(offset, limit).WillReturnRows(rows)
@@ -301,3 +305,55 @@ func TestSharePostgres_GetSharedList(t *testing.T) {
 		})
 	}
 }
+
+func TestSharePostgres_GetSharedPage(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+	db := sqlx.NewDb(mockDB, "sqlmock")
+
+	r := NewSharePostgres(db)
+
+	limit := 1
+
+	t.Run("OK first page with count", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT count\(DISTINCT a.user_id\) FROM shares s (.+)`).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+		rows := sqlmock.NewRows([]string{"user_id", "name", "count"}).AddRow(1, "user 1", 3).AddRow(2, "user 2", 1)
+		mock.ExpectQuery(`SELECT a.user_id, name, count\(\*\) AS count FROM shares s (.+) GROUP BY a.user_id, name ORDER BY name, user_id LIMIT \$1`).
+			WithArgs(limit + 1).WillReturnRows(rows)
+
+		page, err := r.GetSharedPage(storage.ShareListParam{Limit: &limit, WithCount: true})
+		assert.NoError(t, err)
+
+		count := 2
+		assert.Equal(t, storage.SharePageJson{
+			Count:      &count,
+			Users:      []storage.ShareListCount{{UserId: 1, Name: "user 1", ShareCount: 3}},
+			NextCursor: encodeCursor(shareListOrder, []interface{}{"user 1", 1}, false),
+		}, page)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("OK last page", func(t *testing.T) {
+		rows := sqlmock.NewRows([]string{"user_id", "name", "count"}).AddRow(2, "user 2", 1)
+		mock.ExpectQuery(`SELECT (.+) WHERE s.status = 'accepted' AND \(\(u.name > \$1\) OR \(u.name = \$1 AND a.user_id > \$2\)\) (.+) LIMIT \$3`).
+			WithArgs("user 1", json.Number("1"), limit+1).WillReturnRows(rows)
+
+		cursor := encodeCursor(shareListOrder, []interface{}{"user 1", 1}, false)
+		page, err := r.GetSharedPage(storage.ShareListParam{Limit: &limit, Cursor: cursor})
+		assert.NoError(t, err)
+		assert.Equal(t, storage.SharePageJson{
+			Users:      []storage.ShareListCount{{UserId: 2, Name: "user 2", ShareCount: 1}},
+			PrevCursor: encodeCursor(shareListOrder, []interface{}{"user 2", 2}, true),
+		}, page)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Error invalid cursor", func(t *testing.T) {
+		_, err := r.GetSharedPage(storage.ShareListParam{Limit: &limit, Cursor: "wrong"})
+		assert.Equal(t, storage.InvalidCursor, err)
+	})
+}
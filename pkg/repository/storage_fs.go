@@ -1,10 +1,22 @@
 package repository
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"github.com/google/uuid"
 	storage "github.com/mahadeva604/audio-storage"
 	"io"
+	"math"
 	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	artworkExt = ".artwork"
+	tmpExt     = ".tmp"
 )
 
 type StorageFS struct {
@@ -15,40 +27,46 @@ func NewStorageFS(dirPath string) *StorageFS {
 	return &StorageFS{dirPath: dirPath}
 }
 
-func (r StorageFS) StoreFile(fileId uuid.UUID, file io.ReadSeeker) error {
+// StoreFile saves audio stream without embedded tags
+func (r StorageFS) StoreFile(fileId uuid.UUID, file io.ReaderAt, size int64) (storage.StoredFile, error) {
+	tags, start, end, err := readTags(file, size)
+	if err != nil {
+		return storage.StoredFile{}, err
+	}
+
+	stream := io.NewSectionReader(file, start, end-start)
+
 	// Read two bytes to check magic number
 
 	buffer := make([]byte, 2)
-	_, err := io.ReadFull(file, buffer)
+	_, err = io.ReadFull(stream, buffer)
 	if err != nil {
-		return err
+		return storage.StoredFile{}, err
 	}
 
 	if ok := storage.Aac(buffer); !ok {
-		return storage.NotAacFile
+		return storage.StoredFile{}, storage.NotAacFile
 	}
 
-	file.Seek(0, io.SeekStart)
-
-	newFileName := fileId.String() + storage.FileExt
+	stream.Seek(0, io.SeekStart)
 
-	out, err := os.Create(r.dirPath + newFileName)
+	out, err := os.Create(r.FilePath(fileId))
 	if err != nil {
-		return err
+		return storage.StoredFile{}, err
 	}
 	defer out.Close()
 
-	_, err = io.Copy(out, file)
+	_, err = io.Copy(out, stream)
 
 	if err != nil {
-		return err
+		return storage.StoredFile{}, err
 	}
 
-	return nil
+	return storage.StoredFile{Size: end - start, Tags: tags}, nil
 }
 
 func (r StorageFS) GetFile(fileId uuid.UUID) (io.ReadCloser, int64, error) {
-	file, err := os.Open(r.dirPath + fileId.String() + storage.FileExt)
+	file, err := os.Open(r.FilePath(fileId))
 	if err != nil {
 		return nil, 0, err
 	}
@@ -59,3 +77,226 @@ func (r StorageFS) GetFile(fileId uuid.UUID) (io.ReadCloser, int64, error) {
 
 	return file, fileStat.Size(), nil
 }
+
+// FilePath returns path of stored audio file for tools working with files
+func (r StorageFS) FilePath(fileId uuid.UUID) string {
+	return r.dirPath + fileId.String() + storage.FileExt
+}
+
+// taggedFile reads ID3 tag and then the file
+type taggedFile struct {
+	io.Reader
+	io.Closer
+}
+
+// GetTaggedFile returns file with ID3v2 tag made of tags written in front of it
+func (r StorageFS) GetTaggedFile(fileId uuid.UUID, tags storage.AudioTags) (io.ReadCloser, int64, error) {
+	file, size, err := r.GetFile(fileId)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	tag := encodeID3v2(tags)
+
+	return taggedFile{Reader: io.MultiReader(bytes.NewReader(tag), file), Closer: file}, size + int64(len(tag)), nil
+}
+
+// StoreArtwork validates image and saves every size of it
+func (r StorageFS) StoreArtwork(artworkId uuid.UUID, image []byte) error {
+	sizes, err := resizeArtwork(image)
+	if err != nil {
+		return err
+	}
+
+	for size, data := range sizes {
+		if err := os.WriteFile(r.artworkPath(artworkId, size), data, 0644); err != nil {
+			r.DeleteArtwork(artworkId)
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r StorageFS) GetArtwork(artworkId uuid.UUID, size string) ([]byte, error) {
+	data, err := os.ReadFile(r.artworkPath(artworkId, size))
+	if os.IsNotExist(err) {
+		return nil, storage.ArtworkNotFound
+	}
+
+	return data, err
+}
+
+func (r StorageFS) DeleteArtwork(artworkId uuid.UUID) error {
+	for size := range storage.ArtworkSizes {
+		if err := os.Remove(r.artworkPath(artworkId, size)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r StorageFS) artworkPath(artworkId uuid.UUID, size string) string {
+	return r.dirPath + artworkId.String() + "_" + size + artworkExt
+}
+
+// NormalizeFile rewrites stored file with valid ADTS frames only, junk between
+// frames and truncated frames are dropped. It returns size of the file
+func (r StorageFS) NormalizeFile(fileId uuid.UUID) (int64, error) {
+	path := r.FilePath(fileId)
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	tmp := path + tmpExt
+	out, err := os.Create(tmp)
+	if err != nil {
+		return 0, err
+	}
+
+	stream, err := scanADTS(file, func(frame []byte, _ adtsFrame) error {
+		_, err := out.Write(frame)
+		return err
+	})
+	if err == nil && stream.frames == 0 {
+		err = storage.NotAacFile
+	}
+	if err == nil {
+		err = out.Sync()
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil || stream.skipped == 0 {
+		os.Remove(tmp)
+		return stream.size, err
+	}
+
+	return stream.size, os.Rename(tmp, path)
+}
+
+// ProbeFile reads every frame of stored file to get its parameters
+func (r StorageFS) ProbeFile(fileId uuid.UUID) (storage.AudioProbe, error) {
+	file, err := os.Open(r.FilePath(fileId))
+	if err != nil {
+		return storage.AudioProbe{}, err
+	}
+	defer file.Close()
+
+	stream, err := scanADTS(file, nil)
+	if err != nil {
+		return storage.AudioProbe{}, err
+	}
+	if stream.frames == 0 {
+		return storage.AudioProbe{}, storage.NotAacFile
+	}
+
+	return storage.AudioProbe{
+		Duration:   int(math.Round(stream.duration())),
+		SampleRate: stream.sampleRate,
+		Channels:   stream.channels,
+		Bitrate:    stream.bitrate(),
+	}, nil
+}
+
+// ChecksumFile returns hex encoded SHA-256 of stored file
+func (r StorageFS) ChecksumFile(fileId uuid.UUID) (string, error) {
+	file, err := os.Open(r.FilePath(fileId))
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// ListFiles returns stored files not modified for grace period, they are
+// candidates for cleanup. Unfinished temporary files are listed by path
+func (r StorageFS) ListFiles(grace time.Duration) (storage.OrphanFiles, error) {
+	var files storage.OrphanFiles
+	before := time.Now().Add(-grace)
+
+	entries, err := os.ReadDir(r.dirPath)
+	if err != nil {
+		return files, err
+	}
+	hls, err := os.ReadDir(filepath.Join(r.dirPath, hlsDir))
+	if err != nil && !os.IsNotExist(err) {
+		return files, err
+	}
+
+	artwork := make(map[string]bool)
+	for _, entry := range append(entries, hls...) {
+		info, err := entry.Info()
+		if err != nil || !info.ModTime().Before(before) {
+			continue
+		}
+
+		name := entry.Name()
+		switch {
+		case strings.HasSuffix(name, tmpExt):
+			dir := r.dirPath
+			if entry.IsDir() {
+				dir = filepath.Join(r.dirPath, hlsDir)
+			}
+			files.Temp = append(files.Temp, filepath.Join(dir, name))
+		case entry.IsDir():
+			if _, err := uuid.Parse(name); err == nil {
+				files.HLS = append(files.HLS, name)
+			}
+		case strings.HasSuffix(name, storage.FileExt):
+			if id, err := uuid.Parse(strings.TrimSuffix(name, storage.FileExt)); err == nil {
+				files.Audio = append(files.Audio, id.String())
+			}
+		case strings.HasSuffix(name, artworkExt):
+			id, err := uuid.Parse(strings.SplitN(name, "_", 2)[0])
+			if err == nil && !artwork[id.String()] {
+				artwork[id.String()] = true
+				files.Artwork = append(files.Artwork, id.String())
+			}
+		}
+	}
+
+	return files, nil
+}
+
+// DeleteFiles removes listed files, missing files are skipped
+func (r StorageFS) DeleteFiles(files storage.OrphanFiles) error {
+	for _, name := range files.Audio {
+		if err := os.Remove(r.dirPath + name + storage.FileExt); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	for _, name := range files.Artwork {
+		id, err := uuid.Parse(name)
+		if err != nil {
+			return err
+		}
+		if err := r.DeleteArtwork(id); err != nil {
+			return err
+		}
+	}
+
+	for _, name := range files.HLS {
+		if err := os.RemoveAll(filepath.Join(r.dirPath, hlsDir, name)); err != nil {
+			return err
+		}
+	}
+
+	for _, path := range files.Temp {
+		if err := os.RemoveAll(path); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
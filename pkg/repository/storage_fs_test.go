@@ -7,42 +7,66 @@ import (
 	storage "github.com/mahadeva604/audio-storage"
 	"github.com/stretchr/testify/assert"
 	"io"
+	"os"
 	"testing"
 )
 
 func TestStorageFS_StoreFile(t *testing.T) {
+	cover := []byte("\x89PNG\r\n\x1a\ncover")
+	stream := []byte{0xFF, 0xF1, 0x50, 0x80}
+
 	testTable := []struct {
 		name            string
-		fileId          uuid.UUID
-		file            io.ReadSeeker
+		file            []byte
+		expectedFile    storage.StoredFile
+		expectedStream  []byte
 		expectedErr     bool
 		expectedErrType error
 	}{
 		{
-			name:   "OK",
-			fileId: uuid.New(),
-			file:   bytes.NewReader([]byte{0xFF, 0xF1}),
+			name:           "OK",
+			file:           []byte{0xFF, 0xF1},
+			expectedFile:   storage.StoredFile{Size: 2},
+			expectedStream: []byte{0xFF, 0xF1},
+		},
+		{
+			name: "OK tags stripped",
+			file: concat(
+				id3v2Tag(3, id3v2Frame(3, "TIT2", append([]byte{0}, "title"...)), id3v2Frame(3, "APIC", apicData(cover))),
+				stream,
+				id3v1Tag("v1 title", "v1 artist", "v1 album"),
+			),
+			expectedFile: storage.StoredFile{
+				Size: int64(len(stream)),
+				Tags: storage.AudioTags{Title: "title", Artist: "v1 artist", Album: "v1 album", Cover: cover},
+			},
+			expectedStream: stream,
 		},
 		{
 			name:            "EOF",
-			fileId:          uuid.New(),
-			file:            bytes.NewReader([]byte{}),
+			file:            []byte{},
 			expectedErr:     true,
 			expectedErrType: errors.New("EOF"),
 		},
 		{
 			name:            "Error not aac file",
-			fileId:          uuid.New(),
-			file:            bytes.NewReader([]byte{0x12, 0x34, 0x56}),
+			file:            []byte{0x12, 0x34, 0x56},
 			expectedErr:     true,
 			expectedErrType: storage.NotAacFile,
 		},
+		{
+			name:            "Error only tag",
+			file:            id3v2Tag(3, id3v2Frame(3, "TIT2", append([]byte{0}, "title"...))),
+			expectedErr:     true,
+			expectedErrType: errors.New("EOF"),
+		},
 	}
 	for _, testCase := range testTable {
 		t.Run(testCase.name, func(t *testing.T) {
-			tmpdir := t.TempDir()
+			tmpdir := t.TempDir() + "/"
 			s := NewStorageFS(tmpdir)
-			err := s.StoreFile(testCase.fileId, testCase.file)
+			fileId := uuid.New()
+			stored, err := s.StoreFile(fileId, bytes.NewReader(testCase.file), int64(len(testCase.file)))
 			if testCase.expectedErr {
 				assert.Error(t, err)
 				if testCase.expectedErrType != nil {
@@ -50,7 +74,67 @@ func TestStorageFS_StoreFile(t *testing.T) {
 				}
 			} else {
 				assert.NoError(t, err)
+				assert.Equal(t, testCase.expectedFile, stored)
+
+				data, err := os.ReadFile(tmpdir + fileId.String() + storage.FileExt)
+				assert.NoError(t, err)
+				assert.Equal(t, testCase.expectedStream, data)
 			}
 		})
 	}
 }
+
+func TestStorageFS_GetTaggedFile(t *testing.T) {
+	cover := []byte("\x89PNG\r\n\x1a\ncover")
+	stream := []byte{0xFF, 0xF1, 0x50, 0x80}
+
+	tmpdir := t.TempDir() + "/"
+	s := NewStorageFS(tmpdir)
+	fileId := uuid.New()
+
+	_, err := s.StoreFile(fileId, bytes.NewReader(stream), int64(len(stream)))
+	assert.NoError(t, err)
+
+	tagged, size, err := s.GetTaggedFile(fileId, storage.AudioTags{Title: "Название", Album: "album", Cover: cover})
+	assert.NoError(t, err)
+	data, err := io.ReadAll(tagged)
+	assert.NoError(t, err)
+	assert.NoError(t, tagged.Close())
+	assert.Equal(t, int64(len(data)), size)
+
+	tags, start, end, err := readTags(bytes.NewReader(data), size)
+	assert.NoError(t, err)
+	assert.Equal(t, storage.AudioTags{Title: "Название", Album: "album", Cover: cover}, tags)
+	assert.Equal(t, stream, data[start:end])
+
+	plain, size, err := s.GetTaggedFile(fileId, storage.AudioTags{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(len(stream)), size)
+	plain.Close()
+
+	_, _, err = s.GetTaggedFile(uuid.New(), storage.AudioTags{Title: "title"})
+	assert.Error(t, err)
+}
+
+func TestStorageFS_Artwork(t *testing.T) {
+	tmpdir := t.TempDir() + "/"
+	s := NewStorageFS(tmpdir)
+	artworkId := uuid.New()
+
+	err := s.StoreArtwork(artworkId, []byte("not an image"))
+	assert.Equal(t, storage.InvalidArtwork, err)
+
+	err = s.StoreArtwork(artworkId, encodeTestImage(t, "png", 300, 200, true))
+	assert.NoError(t, err)
+
+	for size := range storage.ArtworkSizes {
+		data, err := s.GetArtwork(artworkId, size)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, data)
+	}
+
+	assert.NoError(t, s.DeleteArtwork(artworkId))
+	_, err = s.GetArtwork(artworkId, storage.ArtworkSmall)
+	assert.Equal(t, storage.ArtworkNotFound, err)
+	assert.NoError(t, s.DeleteArtwork(artworkId))
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	storage "github.com/mahadeva604/audio-storage"
+	"strings"
+)
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+type TagPostgres struct {
+	db *sqlx.DB
+}
+
+func NewTagPostgres(db *sqlx.DB) *TagPostgres {
+	return &TagPostgres{db: db}
+}
+
+func (r *TagPostgres) AddTags(userID, audioId int, tags []string) error {
+	if err := r.checkAccess(userID, audioId); err != nil {
+		return err
+	}
+
+	query := fmt.Sprintf(`INSERT INTO %s (audio_id, user_id, tag)
+								SELECT $1, $2, unnest($3::varchar[])
+								ON CONFLICT DO NOTHING`, tagsTable)
+	_, err := r.db.Exec(query, audioId, userID, pq.Array(tags))
+
+	return err
+}
+
+func (r *TagPostgres) RemoveTag(userID, audioId int, tag string) error {
+	query := fmt.Sprintf("DELETE FROM %s WHERE audio_id = $1 AND user_id = $2 AND tag = $3", tagsTable)
+	result, err := r.db.Exec(query, audioId, userID, tag)
+
+	return checkAffected(result, err, storage.TagNotFound)
+}
+
+func (r *TagPostgres) GetAudioTags(userID, audioId int) ([]storage.AudioTag, error) {
+	if err := r.checkAccess(userID, audioId); err != nil {
+		return nil, err
+	}
+
+	tags := make([]storage.AudioTag, 0)
+	query := fmt.Sprintf(`SELECT DISTINCT t.tag, t.user_id <> a.user_id AS personal
+								FROM %s t JOIN %s a ON t.audio_id = a.audio_id
+								WHERE t.audio_id = $1 AND t.user_id IN ($2, a.user_id)
+								ORDER BY t.tag`, tagsTable, audiosTable)
+	err := r.db.Select(&tags, query, audioId, userID)
+
+	return tags, err
+}
+
+func (r *TagPostgres) GetTags(userID int, input storage.TagListParam) ([]storage.TagCount, error) {
+	tags := make([]storage.TagCount, 0)
+	query := fmt.Sprintf(`SELECT t.tag, count(DISTINCT t.audio_id) AS count
+								FROM %s t
+								JOIN %s a ON t.audio_id = a.audio_id
+								JOIN %s v ON t.audio_id = v.audio_id AND v.user_id = $1
+								WHERE t.user_id IN ($1, a.user_id) AND t.tag LIKE $2 || '%%'
+								GROUP BY t.tag
+								ORDER BY count DESC, t.tag
+								LIMIT $3`, tagsTable, audiosTable, audioAccessView)
+	err := r.db.Select(&tags, query, userID, likeEscaper.Replace(input.Prefix), input.Limit)
+
+	return tags, err
+}
+
+func (r *TagPostgres) checkAccess(userID, audioId int) error {
+	var ok bool
+	query := fmt.Sprintf("SELECT EXISTS (SELECT 1 FROM %s WHERE audio_id = $1 AND user_id = $2)", audioAccessView)
+	if err := r.db.Get(&ok, query, audioId, userID); err != nil {
+		return err
+	}
+
+	if !ok {
+		return storage.FileNotFound
+	}
+
+	return nil
+}
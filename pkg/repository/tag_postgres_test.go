@@ -0,0 +1,179 @@
+package repository
+
+import (
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	storage "github.com/mahadeva604/audio-storage"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestTagPostgres_AddTags(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+	db := sqlx.NewDb(mockDB, "sqlmock")
+
+	r := NewTagPostgres(db)
+	type mockBehavior func(userId, audioId int, tags []string)
+
+	testTable := []struct {
+		name            string
+		userId          int
+		audioId         int
+		tags            []string
+		mockBehavior    mockBehavior
+		expectedErr     bool
+		expectedErrType error
+	}{
+		{
+			name:    "OK",
+			userId:  1,
+			audioId: 2,
+			tags:    []string{"jazz", "live"},
+			mockBehavior: func(userId, audioId int, tags []string) {
+				mock.ExpectQuery(`SELECT EXISTS \(SELECT 1 FROM audio_access (.+)\)`).WithArgs(audioId, userId).
+					WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+				mock.ExpectExec(`INSERT INTO audio_tags (.+) ON CONFLICT DO NOTHING`).WithArgs(audioId, userId, pq.Array(tags)).
+					WillReturnResult(sqlmock.NewResult(0, 2))
+			},
+		},
+		{
+			name:    "Error no access",
+			userId:  1,
+			audioId: 2,
+			tags:    []string{"jazz"},
+			mockBehavior: func(userId, audioId int, tags []string) {
+				mock.ExpectQuery(`SELECT EXISTS \(SELECT 1 FROM audio_access (.+)\)`).WithArgs(audioId, userId).
+					WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+			},
+			expectedErr:     true,
+			expectedErrType: storage.FileNotFound,
+		},
+		{
+			name:    "Error insert",
+			userId:  1,
+			audioId: 2,
+			tags:    []string{"jazz"},
+			mockBehavior: func(userId, audioId int, tags []string) {
+				mock.ExpectQuery(`SELECT EXISTS \(SELECT 1 FROM audio_access (.+)\)`).WithArgs(audioId, userId).
+					WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+				mock.ExpectExec(`INSERT INTO audio_tags (.+) ON CONFLICT DO NOTHING`).WithArgs(audioId, userId, pq.Array(tags)).
+					WillReturnError(errors.New("insert error"))
+			},
+			expectedErr:     true,
+			expectedErrType: errors.New("insert error"),
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior(testCase.userId, testCase.audioId, testCase.tags)
+
+			err := r.AddTags(testCase.userId, testCase.audioId, testCase.tags)
+			if testCase.expectedErr {
+				assert.Error(t, err)
+				if testCase.expectedErrType != nil {
+					assert.Equal(t, testCase.expectedErrType, err)
+				}
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestTagPostgres_RemoveTag(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+	db := sqlx.NewDb(mockDB, "sqlmock")
+
+	r := NewTagPostgres(db)
+
+	t.Run("OK", func(t *testing.T) {
+		mock.ExpectExec("DELETE FROM audio_tags").WithArgs(2, 1, "jazz").WillReturnResult(sqlmock.NewResult(0, 1))
+
+		assert.NoError(t, r.RemoveTag(1, 2, "jazz"))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Error not found", func(t *testing.T) {
+		mock.ExpectExec("DELETE FROM audio_tags").WithArgs(2, 1, "jazz").WillReturnResult(sqlmock.NewResult(0, 0))
+
+		assert.Equal(t, storage.TagNotFound, r.RemoveTag(1, 2, "jazz"))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestTagPostgres_GetAudioTags(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+	db := sqlx.NewDb(mockDB, "sqlmock")
+
+	r := NewTagPostgres(db)
+
+	t.Run("OK", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT EXISTS \(SELECT 1 FROM audio_access (.+)\)`).WithArgs(2, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+		rows := sqlmock.NewRows([]string{"tag", "personal"}).AddRow("jazz", false).AddRow("to listen", true)
+		mock.ExpectQuery(`SELECT DISTINCT t.tag, (.+) FROM audio_tags t (.+) ORDER BY t.tag`).WithArgs(2, 1).WillReturnRows(rows)
+
+		tags, err := r.GetAudioTags(1, 2)
+		assert.NoError(t, err)
+		assert.Equal(t, []storage.AudioTag{{Name: "jazz"}, {Name: "to listen", Personal: true}}, tags)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Error no access", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT EXISTS \(SELECT 1 FROM audio_access (.+)\)`).WithArgs(2, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+
+		_, err := r.GetAudioTags(1, 2)
+		assert.Equal(t, storage.FileNotFound, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestTagPostgres_GetTags(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+	db := sqlx.NewDb(mockDB, "sqlmock")
+
+	r := NewTagPostgres(db)
+	limit := 10
+
+	t.Run("OK", func(t *testing.T) {
+		rows := sqlmock.NewRows([]string{"tag", "count"}).AddRow("jazz", 5).AddRow("jam", 1)
+		mock.ExpectQuery(`SELECT t.tag, count\(DISTINCT t.audio_id\) AS count FROM audio_tags t (.+) GROUP BY t.tag ORDER BY count DESC, t.tag LIMIT \$3`).
+			WithArgs(1, "ja", &limit).WillReturnRows(rows)
+
+		tags, err := r.GetTags(1, storage.TagListParam{Prefix: "ja", Limit: &limit})
+		assert.NoError(t, err)
+		assert.Equal(t, []storage.TagCount{{Name: "jazz", Count: 5}, {Name: "jam", Count: 1}}, tags)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("OK prefix escaped", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT t.tag, (.+) FROM audio_tags t`).
+			WithArgs(1, `100\%`, &limit).WillReturnRows(sqlmock.NewRows([]string{"tag", "count"}))
+
+		tags, err := r.GetTags(1, storage.TagListParam{Prefix: "100%", Limit: &limit})
+		assert.NoError(t, err)
+		assert.Equal(t, []storage.TagCount{}, tags)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	storage "github.com/mahadeva604/audio-storage"
+)
+
+type TranscriptPostgres struct {
+	db *sqlx.DB
+}
+
+func NewTranscriptPostgres(db *sqlx.DB) *TranscriptPostgres {
+	return &TranscriptPostgres{db: db}
+}
+
+// SetTranscript replaces transcript of own audio
+func (r *TranscriptPostgres) SetTranscript(userID, audioId int, transcript storage.Transcript) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+
+	var exists bool
+	query := fmt.Sprintf("SELECT true FROM %s WHERE audio_id = $1 AND user_id = $2 FOR UPDATE", audiosTable)
+	if err := tx.Get(&exists, query, audioId, userID); err != nil {
+		tx.Rollback()
+		if errors.Is(err, sql.ErrNoRows) {
+			return storage.NotOwner
+		}
+		return err
+	}
+
+	query = fmt.Sprintf("DELETE FROM %s WHERE audio_id = $1", transcriptCuesTable)
+	if _, err := tx.Exec(query, audioId); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	starts := make([]int64, len(transcript))
+	ends := make([]int64, len(transcript))
+	texts := make([]string, len(transcript))
+	for i, cue := range transcript {
+		starts[i] = int64(cue.StartMs)
+		ends[i] = int64(cue.EndMs)
+		texts[i] = cue.Text
+	}
+
+	query = fmt.Sprintf(`INSERT INTO %s (audio_id, position, start_ms, end_ms, text)
+							SELECT $1, c.position, c.start_ms, c.end_ms, c.text
+							FROM unnest($2::integer[], $3::integer[], $4::text[]) WITH ORDINALITY AS c(start_ms, end_ms, text, position)`,
+		transcriptCuesTable)
+	if _, err := tx.Exec(query, audioId, pq.Array(starts), pq.Array(ends), pq.Array(texts)); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetTranscript returns transcript of audio user has access to
+func (r *TranscriptPostgres) GetTranscript(userID, audioId int) (storage.Transcript, error) {
+	transcript := make(storage.Transcript, 0)
+	query := fmt.Sprintf(`SELECT start_ms, end_ms, text FROM %s
+							WHERE audio_id = $1 AND audio_id IN (SELECT audio_id FROM %s WHERE user_id = $2)
+							ORDER BY position`, transcriptCuesTable, audioAccessView)
+	if err := r.db.Select(&transcript, query, audioId, userID); err != nil {
+		return nil, err
+	}
+
+	if len(transcript) == 0 {
+		return nil, storage.TranscriptNotFound
+	}
+
+	return transcript, nil
+}
+
+func (r *TranscriptPostgres) DeleteTranscript(userID, audioId int) error {
+	query := fmt.Sprintf(`DELETE FROM %s t USING %s a
+							WHERE t.audio_id = a.audio_id AND a.audio_id = $1 AND a.user_id = $2`,
+		transcriptCuesTable, audiosTable)
+	result, err := r.db.Exec(query, audioId, userID)
+
+	return checkAffected(result, err, storage.TranscriptNotFound)
+}
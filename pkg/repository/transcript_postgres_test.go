@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	storage "github.com/mahadeva604/audio-storage"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestTranscriptPostgres_SetTranscript(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+	db := sqlx.NewDb(mockDB, "sqlmock")
+
+	r := NewTranscriptPostgres(db)
+
+	transcript := storage.Transcript{
+		{StartMs: 0, EndMs: 1500, Text: "hello"},
+		{StartMs: 1500, EndMs: 3000, Text: "world"},
+	}
+
+	testTable := []struct {
+		name            string
+		mockBehavior    func()
+		expectedErrType error
+	}{
+		{
+			name: "OK",
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`SELECT true FROM audios WHERE audio_id = \$1 AND user_id = \$2 FOR UPDATE`).WithArgs(2, 1).
+					WillReturnRows(sqlmock.NewRows([]string{"bool"}).AddRow(true))
+				mock.ExpectExec(`DELETE FROM transcript_cues WHERE audio_id = \$1`).WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 10))
+				mock.ExpectExec(`INSERT INTO transcript_cues \(audio_id, position, start_ms, end_ms, text\) (.+) WITH ORDINALITY`).
+					WithArgs(2, pq.Array([]int64{0, 1500}), pq.Array([]int64{1500, 3000}), pq.Array([]string{"hello", "world"})).
+					WillReturnResult(sqlmock.NewResult(0, 2))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "Not owner",
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`SELECT true FROM audios`).WithArgs(2, 1).WillReturnError(sql.ErrNoRows)
+				mock.ExpectRollback()
+			},
+			expectedErrType: storage.NotOwner,
+		},
+		{
+			name: "Insert error",
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`SELECT true FROM audios`).WithArgs(2, 1).
+					WillReturnRows(sqlmock.NewRows([]string{"bool"}).AddRow(true))
+				mock.ExpectExec(`DELETE FROM transcript_cues`).WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(`INSERT INTO transcript_cues`).WillReturnError(errors.New("insert error"))
+				mock.ExpectRollback()
+			},
+			expectedErrType: errors.New("insert error"),
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior()
+
+			err := r.SetTranscript(1, 2, transcript)
+			if testCase.expectedErrType != nil {
+				assert.Equal(t, testCase.expectedErrType, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestTranscriptPostgres_GetTranscript(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+	db := sqlx.NewDb(mockDB, "sqlmock")
+
+	r := NewTranscriptPostgres(db)
+	query := `SELECT start_ms, end_ms, text FROM transcript_cues WHERE audio_id = \$1 AND audio_id IN \(SELECT audio_id FROM audio_access WHERE user_id = \$2\) ORDER BY position`
+
+	t.Run("OK", func(t *testing.T) {
+		mock.ExpectQuery(query).WithArgs(2, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"start_ms", "end_ms", "text"}).AddRow(0, 1500, "hello").AddRow(1500, 3000, "world"))
+
+		got, err := r.GetTranscript(1, 2)
+		assert.NoError(t, err)
+		assert.Equal(t, storage.Transcript{
+			{StartMs: 0, EndMs: 1500, Text: "hello"},
+			{StartMs: 1500, EndMs: 3000, Text: "world"},
+		}, got)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Not found", func(t *testing.T) {
+		mock.ExpectQuery(query).WithArgs(2, 1).WillReturnRows(sqlmock.NewRows([]string{"start_ms", "end_ms", "text"}))
+
+		_, err := r.GetTranscript(1, 2)
+		assert.Equal(t, storage.TranscriptNotFound, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("OK delete", func(t *testing.T) {
+		mock.ExpectExec(`DELETE FROM transcript_cues t USING audios a WHERE t.audio_id = a.audio_id AND a.audio_id = \$1 AND a.user_id = \$2`).
+			WithArgs(2, 1).WillReturnResult(sqlmock.NewResult(0, 2))
+
+		assert.NoError(t, r.DeleteTranscript(1, 2))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Delete not found", func(t *testing.T) {
+		mock.ExpectExec(`DELETE FROM transcript_cues`).WithArgs(2, 1).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		assert.Equal(t, storage.TranscriptNotFound, r.DeleteTranscript(1, 2))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	storage "github.com/mahadeva604/audio-storage"
+)
+
+type TranscriptionPostgres struct {
+	db *sqlx.DB
+}
+
+func NewTranscriptionPostgres(db *sqlx.DB) *TranscriptionPostgres {
+	return &TranscriptionPostgres{db: db}
+}
+
+// QueueTranscription queues transcribe job for own audio unless one is already queued or running
+func (r *TranscriptionPostgres) QueueTranscription(userID, audioId, maxAttempts int) error {
+	var exists bool
+	query := fmt.Sprintf("SELECT true FROM %s WHERE audio_id = $1 AND user_id = $2", audiosTable)
+	if err := r.db.Get(&exists, query, audioId, userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return storage.NotOwner
+		}
+		return err
+	}
+
+	query = fmt.Sprintf(`INSERT INTO %s (type, audio_id, max_attempts) VALUES ($1, $2, $3)
+							ON CONFLICT (type, COALESCE(audio_id, 0)) WHERE status IN ('queued', 'running') DO NOTHING`, jobsTable)
+	result, err := r.db.Exec(query, storage.JobTranscribe, audioId, maxAttempts)
+
+	return checkAffected(result, err, storage.TranscriptionActive)
+}
+
+// GetTranscription returns state of the last transcribe job of audio user has
+// access to, dead job is failed
+func (r *TranscriptionPostgres) GetTranscription(userID, audioId int) (storage.TranscriptionJob, error) {
+	var job storage.TranscriptionJob
+	query := fmt.Sprintf(`SELECT audio_id, CASE WHEN status = 'dead' THEN 'failed' ELSE status END AS status,
+							error, created_at, updated_at FROM %s
+							WHERE type = $1 AND audio_id = $2 AND audio_id IN (SELECT audio_id FROM %s WHERE user_id = $3)
+							ORDER BY job_id DESC LIMIT 1`,
+		jobsTable, audioAccessView)
+	err := r.db.Get(&job, query, storage.JobTranscribe, audioId, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return job, storage.TranscriptionNotFound
+	}
+
+	return job, err
+}
@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"database/sql"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	storage "github.com/mahadeva604/audio-storage"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestTranscriptionPostgres(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+	db := sqlx.NewDb(mockDB, "sqlmock")
+
+	r := NewTranscriptionPostgres(db)
+
+	t.Run("OK queue", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT true FROM audios WHERE audio_id = \$1 AND user_id = \$2`).WithArgs(2, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"bool"}).AddRow(true))
+		mock.ExpectExec(`INSERT INTO jobs \(type, audio_id, max_attempts\) VALUES \(\$1, \$2, \$3\) ON CONFLICT \(type, COALESCE\(audio_id, 0\)\) WHERE status IN \('queued', 'running'\) DO NOTHING`).
+			WithArgs("transcribe", 2, 5).WillReturnResult(sqlmock.NewResult(0, 1))
+
+		assert.NoError(t, r.QueueTranscription(1, 2, 5))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Queue not owner", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT true FROM audios`).WithArgs(2, 1).WillReturnError(sql.ErrNoRows)
+
+		assert.Equal(t, storage.NotOwner, r.QueueTranscription(1, 2, 5))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Queue already running", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT true FROM audios`).WithArgs(2, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"bool"}).AddRow(true))
+		mock.ExpectExec(`INSERT INTO jobs`).WithArgs("transcribe", 2, 5).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		assert.Equal(t, storage.TranscriptionActive, r.QueueTranscription(1, 2, 5))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("OK get", func(t *testing.T) {
+		at := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+		mock.ExpectQuery(`SELECT audio_id, CASE WHEN status = 'dead' THEN 'failed' ELSE status END AS status, error, created_at, updated_at FROM jobs WHERE type = \$1 AND audio_id = \$2 AND audio_id IN \(SELECT audio_id FROM audio_access WHERE user_id = \$3\) ORDER BY job_id DESC LIMIT 1`).
+			WithArgs("transcribe", 2, 1).WillReturnRows(sqlmock.NewRows([]string{"audio_id", "status", "error", "created_at", "updated_at"}).
+			AddRow(2, "failed", "engine error", at, at))
+
+		job, err := r.GetTranscription(1, 2)
+		assert.NoError(t, err)
+		assert.Equal(t, storage.TranscriptionJob{AudioId: 2, Status: storage.TranscriptionFailed, Error: "engine error", CreatedAt: at, UpdatedAt: at}, job)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Get not found", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT audio_id, (.+) FROM jobs`).WithArgs("transcribe", 2, 1).WillReturnError(sql.ErrNoRows)
+
+		_, err := r.GetTranscription(1, 2)
+		assert.Equal(t, storage.TranscriptionNotFound, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,53 @@
+package service
+
+import (
+	storage "github.com/mahadeva604/audio-storage"
+	"github.com/mahadeva604/audio-storage/pkg/repository"
+	"strings"
+)
+
+// apiKeyShownSize is number of random characters kept in prefix to recognize key
+const apiKeyShownSize = 6
+
+type APIKeyService struct {
+	repo repository.APIKey
+}
+
+func NewAPIKeyService(repo repository.APIKey) *APIKeyService {
+	return &APIKeyService{repo: repo}
+}
+
+// CreateAPIKey returns new key, it can't be shown again as only its hash is stored
+func (s *APIKeyService) CreateAPIKey(userId int, input storage.APIKeyInput) (storage.NewAPIKey, error) {
+	secret, err := randomToken()
+	if err != nil {
+		return storage.NewAPIKey{}, err
+	}
+
+	key := storage.APIKeyPrefix + secret
+	prefix := key[:len(storage.APIKeyPrefix)+apiKeyShownSize]
+
+	apiKey, err := s.repo.CreateAPIKey(userId, input, prefix, hashToken(key))
+	if err != nil {
+		return storage.NewAPIKey{}, err
+	}
+
+	return storage.NewAPIKey{APIKey: apiKey, Key: key}, nil
+}
+
+func (s *APIKeyService) GetAPIKeys(userId int) ([]storage.APIKey, error) {
+	return s.repo.GetAPIKeys(userId)
+}
+
+func (s *APIKeyService) DeleteAPIKey(userId, keyId int) error {
+	return s.repo.DeleteAPIKey(userId, keyId)
+}
+
+// ParseAPIKey returns owner of valid key, its use is recorded
+func (s *APIKeyService) ParseAPIKey(key string) (storage.APIKeyOwner, error) {
+	if !strings.HasPrefix(key, storage.APIKeyPrefix) {
+		return storage.APIKeyOwner{}, storage.InvalidAPIKey
+	}
+
+	return s.repo.UseAPIKey(hashToken(key))
+}
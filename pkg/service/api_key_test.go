@@ -0,0 +1,59 @@
+package service
+
+import (
+	storage "github.com/mahadeva604/audio-storage"
+	"github.com/mahadeva604/audio-storage/pkg/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+// apiKeyRepo keeps owners of keys by hash
+type apiKeyRepo struct {
+	repository.APIKey
+	owners map[string]storage.APIKeyOwner
+	prefix string
+}
+
+func (r *apiKeyRepo) CreateAPIKey(userId int, input storage.APIKeyInput, prefix, keyHash string) (storage.APIKey, error) {
+	r.owners[keyHash] = storage.APIKeyOwner{UserId: userId, Scopes: input.Scopes}
+	r.prefix = prefix
+	return storage.APIKey{Id: 1, Name: input.Name, Prefix: prefix, Scopes: input.Scopes}, nil
+}
+
+func (r *apiKeyRepo) UseAPIKey(keyHash string) (storage.APIKeyOwner, error) {
+	owner, ok := r.owners[keyHash]
+	if !ok {
+		return storage.APIKeyOwner{}, storage.InvalidAPIKey
+	}
+	return owner, nil
+}
+
+func TestAPIKeyService(t *testing.T) {
+	repo := &apiKeyRepo{owners: map[string]storage.APIKeyOwner{}}
+	s := NewAPIKeyService(repo)
+
+	key, err := s.CreateAPIKey(1, storage.APIKeyInput{Name: "backup", Scopes: []string{storage.ScopeAudioRead}})
+	require.NoError(t, err)
+
+	assert.True(t, strings.HasPrefix(key.Key, storage.APIKeyPrefix))
+	assert.Len(t, key.Key, len(storage.APIKeyPrefix)+43)
+	assert.Equal(t, key.Key[:len(storage.APIKeyPrefix)+6], key.Prefix)
+	assert.Equal(t, key.Prefix, repo.prefix)
+	assert.NotContains(t, repo.owners, key.Key, "key must be stored hashed")
+
+	owner, err := s.ParseAPIKey(key.Key)
+	assert.NoError(t, err)
+	assert.Equal(t, storage.APIKeyOwner{UserId: 1, Scopes: []string{storage.ScopeAudioRead}}, owner)
+
+	_, err = s.ParseAPIKey(key.Key + "x")
+	assert.ErrorIs(t, err, storage.InvalidAPIKey)
+
+	_, err = s.ParseAPIKey(strings.TrimPrefix(key.Key, storage.APIKeyPrefix))
+	assert.ErrorIs(t, err, storage.InvalidAPIKey)
+
+	other, err := s.CreateAPIKey(1, storage.APIKeyInput{Name: "sync", Scopes: []string{storage.ScopeAudioRead}})
+	require.NoError(t, err)
+	assert.NotEqual(t, key.Key, other.Key)
+}
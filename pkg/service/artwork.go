@@ -0,0 +1,118 @@
+package service
+
+import (
+	"github.com/google/uuid"
+	storage "github.com/mahadeva604/audio-storage"
+	"github.com/mahadeva604/audio-storage/pkg/repository"
+)
+
+type ArtworkService struct {
+	repo  repository.Artwork
+	files repository.Storage
+}
+
+func NewArtworkService(repo repository.Artwork, files repository.Storage) *ArtworkService {
+	return &ArtworkService{repo: repo, files: files}
+}
+
+func (s *ArtworkService) SetAudioArtwork(userID, audioId int, image []byte) error {
+	return s.setArtwork(image, func(artworkId string) (string, error) {
+		return s.repo.SetAudioArtwork(userID, audioId, artworkId)
+	})
+}
+
+func (s *ArtworkService) DeleteAudioArtwork(userID, audioId int) error {
+	previous, err := s.repo.SetAudioArtwork(userID, audioId, "")
+	if err != nil {
+		return err
+	}
+	if previous == "" {
+		return storage.ArtworkNotFound
+	}
+
+	return deleteArtworkFiles(s.files, previous)
+}
+
+func (s *ArtworkService) GetAudioArtwork(userID, audioId int, size string) (storage.Artwork, error) {
+	artworkId, err := s.repo.GetAudioArtwork(userID, audioId)
+	if err != nil {
+		return storage.Artwork{}, err
+	}
+
+	return s.getArtwork(artworkId, size)
+}
+
+func (s *ArtworkService) SetCollectionArtwork(userID, collectionId int, image []byte) error {
+	return s.setArtwork(image, func(artworkId string) (string, error) {
+		return s.repo.SetCollectionArtwork(userID, collectionId, artworkId)
+	})
+}
+
+func (s *ArtworkService) DeleteCollectionArtwork(userID, collectionId int) error {
+	previous, err := s.repo.SetCollectionArtwork(userID, collectionId, "")
+	if err != nil {
+		return err
+	}
+	if previous == "" {
+		return storage.ArtworkNotFound
+	}
+
+	return deleteArtworkFiles(s.files, previous)
+}
+
+func (s *ArtworkService) GetCollectionArtwork(userID, collectionId int, size string) (storage.Artwork, error) {
+	artworkId, err := s.repo.GetCollectionArtwork(userID, collectionId)
+	if err != nil {
+		return storage.Artwork{}, err
+	}
+
+	return s.getArtwork(artworkId, size)
+}
+
+// setArtwork stores image under new id before it is set, so artwork being
+// replaced stays readable until the update
+func (s *ArtworkService) setArtwork(image []byte, set func(artworkId string) (string, error)) error {
+	artworkId := uuid.New()
+	if err := s.files.StoreArtwork(artworkId, image); err != nil {
+		return err
+	}
+
+	previous, err := set(artworkId.String())
+	if err != nil {
+		s.files.DeleteArtwork(artworkId)
+		return err
+	}
+
+	return deleteArtworkFiles(s.files, previous)
+}
+
+func (s *ArtworkService) getArtwork(artworkId, size string) (storage.Artwork, error) {
+	if size == "" {
+		size = storage.ArtworkLarge
+	}
+
+	id, err := uuid.Parse(artworkId)
+	if err != nil {
+		return storage.Artwork{}, err
+	}
+
+	data, err := s.files.GetArtwork(id, size)
+	if err != nil {
+		return storage.Artwork{}, err
+	}
+
+	return storage.Artwork{Id: artworkId, Data: data}, nil
+}
+
+func deleteArtworkFiles(files repository.Storage, artworkId string) error {
+	if artworkId == "" {
+		return nil
+	}
+
+	id, err := uuid.Parse(artworkId)
+	if err != nil {
+		return err
+	}
+
+	return files.DeleteArtwork(id)
+}
@@ -1,30 +1,116 @@
 package service
 
 import (
+	"errors"
 	storage "github.com/mahadeva604/audio-storage"
 	"github.com/mahadeva604/audio-storage/pkg/repository"
 )
 
 type AudioService struct {
-	repo repository.Audio
+	repo    repository.Audio
+	schemas repository.MetadataSchema
 }
 
-func NewAudioService(repo repository.Audio) *AudioService {
-	return &AudioService{repo: repo}
+func NewAudioService(repo repository.Audio, schemas repository.MetadataSchema) *AudioService {
+	return &AudioService{repo: repo, schemas: schemas}
 }
 
-func (s *AudioService) UploadFile(userId int, path string) (int, error) {
-	return s.repo.UploadFile(userId, path)
+func (s *AudioService) UploadFile(userId int, path string, size int64, metadata storage.AudioMetadata) (int, error) {
+	return s.repo.UploadFile(userId, path, size, metadata)
 }
 
 func (s *AudioService) DownloadFile(userID, audioId int) (storage.DownloadAudio, error) {
 	return s.repo.DownloadFile(userID, audioId)
 }
 
-func (s *AudioService) AddDescription(userID, audioId int, input storage.UpdateAudio) error {
-	return s.repo.AddDescription(userID, audioId, input)
+func (s *AudioService) GetAudio(userID, audioId int) (storage.Audio, error) {
+	return s.repo.GetAudio(userID, audioId)
+}
+
+// AddDescription applies update to current metadata if audio is of one of
+// versions, nil versions match any. Repository replaces metadata only if
+// audio wasn't changed since it was read
+func (s *AudioService) AddDescription(userID, audioId int, versions []int, input storage.UpdateAudio) (storage.Audio, error) {
+	audio, err := s.repo.GetAudio(userID, audioId)
+	if err != nil {
+		if errors.Is(err, storage.FileNotFound) {
+			return audio, storage.NotOwner
+		}
+		return audio, err
+	}
+
+	if audio.UserId != userID {
+		return storage.Audio{}, storage.NotOwner
+	}
+
+	if versions != nil && !containsVersion(versions, audio.Version) {
+		return storage.Audio{}, storage.VersionMismatch
+	}
+
+	metadata := input.Apply(audio.AudioMetadata)
+	if err := metadata.Validate(); err != nil {
+		return storage.Audio{}, err
+	}
+
+	if input.TouchesCustom() {
+		if err := s.validateCustomFields(userID, metadata.Custom); err != nil {
+			return storage.Audio{}, err
+		}
+	}
+
+	return s.repo.AddDescription(userID, audioId, audio.Version, metadata)
+}
+
+func containsVersion(versions []int, version int) bool {
+	for _, v := range versions {
+		if v == version {
+			return true
+		}
+	}
+	return false
+}
+
+// validateCustomFields checks custom fields against metadata schema of user if it is set
+func (s *AudioService) validateCustomFields(userID int, custom storage.CustomFields) error {
+	schema, err := s.schemas.GetMetadataSchema(userID)
+	if errors.Is(err, storage.MetadataSchemaNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return validateBySchema(schema, custom)
 }
 
 func (s *AudioService) GetAudioList(userID int, input storage.AudioListParam) (storage.AudioListJson, error) {
+	if err := normalizeListTags(&input); err != nil {
+		return storage.AudioListJson{}, err
+	}
 	return s.repo.GetAudioList(userID, input)
 }
+
+func (s *AudioService) GetAudioPage(userID int, input storage.AudioListParam) (storage.AudioPageJson, error) {
+	if err := normalizeListTags(&input); err != nil {
+		return storage.AudioPageJson{}, err
+	}
+	return s.repo.GetAudioPage(userID, input)
+}
+
+func (s *AudioService) GetAudioHistory(userID, audioId int, input storage.HistoryListParam) (storage.HistoryListJson, error) {
+	return s.repo.GetAudioHistory(userID, audioId, input)
+}
+
+func normalizeListTags(input *storage.AudioListParam) error {
+	if len(input.Tags) == 0 {
+		return nil
+	}
+
+	tags, err := storage.NormalizeTags(input.Tags)
+	if err != nil {
+		return err
+	}
+	input.Tags = tags
+
+	return nil
+}
@@ -1,28 +1,41 @@
 package service
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
-	"github.com/dgrijalva/jwt-go"
 	"github.com/google/uuid"
 	storage "github.com/mahadeva604/audio-storage"
 	"github.com/mahadeva604/audio-storage/pkg/repository"
+	"github.com/sirupsen/logrus"
 	"time"
 )
 
-type tokenClaims struct {
-	jwt.StandardClaims
-	UserId int `json:"user_id"`
+type AuthConfig struct {
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+	// Issuer and Audience are set in access tokens and required in parsed
+	// ones, empty values aren't checked
+	Issuer   string
+	Audience string
+	// Leeway is allowed clock skew of servers issuing and verifying tokens
+	Leeway time.Duration
+	Keys   KeySet
 }
 
 type AuthService struct {
 	repo            repository.Authorization
-	secretKey       []byte
-	accessTokenTTL  time.Duration
+	keys            KeySet
+	issuer          TokenIssuer
+	verifier        TokenVerifier
 	refreshTokenTTL time.Duration
 }
 
-func NewAuthService(repo repository.Authorization, secretKey []byte, accessTokenTTL, refreshTokenTTL time.Duration) *AuthService {
-	return &AuthService{repo: repo, secretKey: secretKey, accessTokenTTL: accessTokenTTL, refreshTokenTTL: refreshTokenTTL}
+func NewAuthService(repo repository.Authorization, cfg AuthConfig) *AuthService {
+	tokens := NewJWTTokens(cfg)
+	return &AuthService{repo: repo, keys: cfg.Keys, issuer: tokens, verifier: tokens, refreshTokenTTL: cfg.RefreshTokenTTL}
 }
 
 func (s *AuthService) CreateUser(user storage.User) (int, error) {
@@ -35,69 +48,132 @@ func (s *AuthService) GenerateAccessToken(username, password string) (int, strin
 		return 0, "", err
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, &tokenClaims{
-		jwt.StandardClaims{
-			ExpiresAt: time.Now().Add(s.accessTokenTTL).Unix(),
-			IssuedAt:  time.Now().Unix(),
-		},
-		user.Id,
-	})
-
-	signedToken, err := token.SignedString(s.secretKey)
+	signedToken, err := s.issuer.Issue(user.Id)
 
 	return user.Id, signedToken, err
 }
 
 func (s *AuthService) UpdateAccessToken(userId int) (string, error) {
+	return s.issuer.Issue(userId)
+}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, &tokenClaims{
-		jwt.StandardClaims{
-			ExpiresAt: time.Now().Add(s.accessTokenTTL).Unix(),
-			IssuedAt:  time.Now().Unix(),
-		},
-		userId,
-	})
-
-	return token.SignedString(s.secretKey)
+// JWKS returns public keys access tokens can be verified with
+func (s *AuthService) JWKS() storage.JWKS {
+	return s.keys.JWKS()
 }
 
-func (s *AuthService) GenerateRefreshToken(userId int) (string, error) {
+// GenerateRefreshToken opens new session of user, other sessions stay valid
+func (s *AuthService) GenerateRefreshToken(userId int, client storage.SessionClient) (string, error) {
 
 	refreshToken := uuid.New().String()
 
-	err := s.repo.SetRefreshToken(userId, refreshToken, s.refreshTokenTTL)
+	err := s.repo.SetRefreshToken(userId, hashToken(refreshToken), client, s.refreshTokenTTL)
 	if err != nil {
 		return "", err
 	}
 	return refreshToken, nil
 }
 
-func (s *AuthService) UpdateRefreshToken(oldRefreshToken string) (int, string, error) {
+func (s *AuthService) UpdateRefreshToken(oldRefreshToken string, client storage.SessionClient) (int, string, error) {
 
 	newRefreshToken := uuid.New().String()
 
-	userId, err := s.repo.UpdateRefreshToken(oldRefreshToken, newRefreshToken, s.refreshTokenTTL)
+	userId, err := s.repo.UpdateRefreshToken(hashToken(oldRefreshToken), hashToken(newRefreshToken), client, s.refreshTokenTTL)
+	if errors.Is(err, storage.RefreshTokenReused) {
+		logrus.Warnf("rotated refresh token of user %d is reused from %s, session is revoked", userId, client.IP)
+	}
 	if err != nil {
 		return 0, "", err
 	}
 	return userId, newRefreshToken, nil
 }
 
+func (s *AuthService) GetSessions(userId int) ([]storage.Session, error) {
+	return s.repo.GetSessions(userId)
+}
+
+// DeleteSession closes session and revokes access tokens of user, other
+// sessions get new ones by refresh
+func (s *AuthService) DeleteSession(userId, sessionId int) error {
+	return s.repo.DeleteSession(userId, sessionId)
+}
+
+func (s *AuthService) GetSecurityEvents(userId int, input storage.SecurityEventListParam) (storage.SecurityEventListJson, error) {
+	return s.repo.GetSecurityEvents(userId, input)
+}
+
+// randomTokenSize is number of random bytes in API keys and password reset tokens
+const randomTokenSize = 32
+
+// randomToken returns base64url encoded random bytes
+func randomToken() (string, error) {
+	b := make([]byte, randomTokenSize)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashToken returns hex encoded sha256 of refresh token, only hashes are stored
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// ParseToken returns user of valid access token which isn't revoked
 func (s *AuthService) ParseToken(accessToken string) (int, error) {
-	token, err := jwt.ParseWithClaims(accessToken, &tokenClaims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("invalid signing method")
-		}
-		return []byte(s.secretKey), nil
-	})
+	claims, err := s.verifier.Verify(accessToken)
 	if err != nil {
 		return 0, err
 	}
 
-	claims, ok := token.Claims.(*tokenClaims)
-	if !ok {
-		return 0, errors.New("token claims are not of type *tokenClaims")
+	revoked, err := s.repo.IsAccessTokenRevoked(claims.UserId, claims.Id, claims.IssuedAt)
+	if err != nil {
+		return 0, err
+	}
+	if revoked {
+		return 0, storage.TokenRevoked
 	}
 
 	return claims.UserId, nil
 }
+
+// Logout revokes access token and refresh token of the same session
+func (s *AuthService) Logout(accessToken, refreshToken string) error {
+	claims, err := s.verifier.Verify(accessToken)
+	if err != nil {
+		return err
+	}
+
+	if _, err := uuid.Parse(refreshToken); err != nil {
+		return storage.WrongRefreshToken
+	}
+
+	if err := s.repo.DeleteRefreshToken(claims.UserId, hashToken(refreshToken)); err != nil {
+		return err
+	}
+
+	return s.revoke(claims)
+}
+
+// LogoutAll revokes every access and refresh token of user
+func (s *AuthService) LogoutAll(accessToken string) error {
+	claims, err := s.verifier.Verify(accessToken)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.DeleteRefreshTokens(claims.UserId); err != nil {
+		return err
+	}
+
+	return s.revoke(claims)
+}
+
+func (s *AuthService) revoke(claims TokenClaims) error {
+	return s.repo.RevokeAccessToken(claims.Id, claims.ExpiresAt)
+}
+
+func (s *AuthService) IsAdmin(userId int) (bool, error) {
+	return s.repo.IsAdmin(userId)
+}
@@ -0,0 +1,197 @@
+package service
+
+import (
+	"github.com/golang-jwt/jwt/v4"
+	storage "github.com/mahadeva604/audio-storage"
+	"github.com/mahadeva604/audio-storage/pkg/repository"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+// authRepo keeps revoked tokens in memory, methods which aren't overridden
+// panic on nil embedded interface
+type authRepo struct {
+	repository.Authorization
+	revoked       map[string]time.Time
+	refreshTokens map[string]int
+	// rotated links replaced token to the token which replaced it
+	rotated    map[string]string
+	validAfter map[int]time.Time
+}
+
+func newAuthRepo() *authRepo {
+	return &authRepo{revoked: map[string]time.Time{}, refreshTokens: map[string]int{}, rotated: map[string]string{},
+		validAfter: map[int]time.Time{}}
+}
+
+func (r *authRepo) SetRefreshToken(userId int, tokenHash string, client storage.SessionClient, refreshTokenTTL time.Duration) error {
+	r.refreshTokens[tokenHash] = userId
+	return nil
+}
+
+func (r *authRepo) UpdateRefreshToken(oldTokenHash string, newTokenHash string, client storage.SessionClient, refreshTokenTTL time.Duration) (int, error) {
+	userId, ok := r.refreshTokens[oldTokenHash]
+	if ok {
+		delete(r.refreshTokens, oldTokenHash)
+		r.refreshTokens[newTokenHash] = userId
+		r.rotated[oldTokenHash] = newTokenHash
+		return userId, nil
+	}
+
+	current, ok := r.rotated[oldTokenHash]
+	if !ok {
+		return 0, storage.WrongRefreshToken
+	}
+	for next, ok := r.rotated[current]; ok; next, ok = r.rotated[current] {
+		current = next
+	}
+	userId = r.refreshTokens[current]
+	delete(r.refreshTokens, current)
+	return userId, storage.RefreshTokenReused
+}
+
+func (r *authRepo) DeleteRefreshToken(userId int, refreshToken string) error {
+	if r.refreshTokens[refreshToken] == userId {
+		delete(r.refreshTokens, refreshToken)
+	}
+	return nil
+}
+
+func (r *authRepo) DeleteRefreshTokens(userId int) error {
+	for token, id := range r.refreshTokens {
+		if id == userId {
+			delete(r.refreshTokens, token)
+		}
+	}
+	r.validAfter[userId] = time.Now()
+	return nil
+}
+
+func (r *authRepo) RevokeAccessToken(jti string, expiresAt time.Time) error {
+	r.revoked[jti] = expiresAt
+	return nil
+}
+
+func (r *authRepo) IsAccessTokenRevoked(userId int, jti string, issuedAt time.Time) (bool, error) {
+	_, ok := r.revoked[jti]
+	return ok || issuedAt.Before(r.validAfter[userId]), nil
+}
+
+func newTestAuthService(repo repository.Authorization) *AuthService {
+	keys, err := NewKeySet(NewHMACKey("test", []byte("secret")))
+	if err != nil {
+		panic(err)
+	}
+	return NewAuthService(repo, AuthConfig{AccessTokenTTL: time.Minute, RefreshTokenTTL: time.Hour, Keys: keys})
+}
+
+func TestAuthService_ParseToken(t *testing.T) {
+	repo := newAuthRepo()
+	s := newTestAuthService(repo)
+
+	token, err := s.UpdateAccessToken(1)
+	assert.NoError(t, err)
+
+	userId, err := s.ParseToken(token)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, userId)
+
+	noIdToken := jwt.NewWithClaims(jwt.SigningMethodHS256, &accessClaims{
+		jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute))}, 1,
+	})
+	noIdToken.Header["kid"] = "test"
+	noId, err := noIdToken.SignedString([]byte("secret"))
+	assert.NoError(t, err)
+	_, err = s.ParseToken(noId)
+	assert.EqualError(t, err, "token has no valid id")
+
+	_, err = s.ParseToken(token + "x")
+	assert.Error(t, err)
+}
+
+func TestAuthService_RefreshToken(t *testing.T) {
+	repo := newAuthRepo()
+	s := newTestAuthService(repo)
+
+	laptop, err := s.GenerateRefreshToken(1, storage.SessionClient{DeviceName: "laptop"})
+	assert.NoError(t, err)
+	phone, err := s.GenerateRefreshToken(1, storage.SessionClient{DeviceName: "phone"})
+	assert.NoError(t, err)
+
+	// every sign-in opens its own session and only hashes are stored
+	assert.Equal(t, map[string]int{hashToken(laptop): 1, hashToken(phone): 1}, repo.refreshTokens)
+
+	userId, rotated, err := s.UpdateRefreshToken(phone, storage.SessionClient{})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, userId)
+	assert.Equal(t, map[string]int{hashToken(laptop): 1, hashToken(rotated): 1}, repo.refreshTokens)
+
+	_, _, err = s.UpdateRefreshToken(laptop+"x", storage.SessionClient{})
+	assert.Equal(t, storage.WrongRefreshToken, err)
+
+	// reuse of rotated token revokes the whole session, other sessions stay
+	_, _, err = s.UpdateRefreshToken(phone, storage.SessionClient{})
+	assert.Equal(t, storage.RefreshTokenReused, err)
+	assert.Equal(t, map[string]int{hashToken(laptop): 1}, repo.refreshTokens)
+
+	_, _, err = s.UpdateRefreshToken(rotated, storage.SessionClient{})
+	assert.Equal(t, storage.WrongRefreshToken, err)
+}
+
+func TestAuthService_Logout(t *testing.T) {
+	refreshToken := "5d0c3b58-3f0e-4f5e-a1d5-0c2a9f3e4b6d"
+	otherToken := "8a4e2f1c-6b7d-4c3e-9f0a-1b2c3d4e5f60"
+
+	t.Run("OK logout", func(t *testing.T) {
+		repo := newAuthRepo()
+		repo.refreshTokens[hashToken(refreshToken)] = 1
+		repo.refreshTokens[hashToken(otherToken)] = 1
+		s := newTestAuthService(repo)
+
+		token, err := s.UpdateAccessToken(1)
+		assert.NoError(t, err)
+
+		assert.NoError(t, s.Logout(token, refreshToken))
+		assert.Equal(t, map[string]int{hashToken(otherToken): 1}, repo.refreshTokens)
+
+		_, err = s.ParseToken(token)
+		assert.Equal(t, storage.TokenRevoked, err)
+	})
+
+	t.Run("OK logout all", func(t *testing.T) {
+		repo := newAuthRepo()
+		repo.refreshTokens[hashToken(refreshToken)] = 1
+		repo.refreshTokens[hashToken(otherToken)] = 2
+		s := newTestAuthService(repo)
+
+		token, err := s.UpdateAccessToken(1)
+		assert.NoError(t, err)
+		other, err := s.UpdateAccessToken(1)
+		assert.NoError(t, err)
+		otherUser, err := s.UpdateAccessToken(2)
+		assert.NoError(t, err)
+
+		assert.NoError(t, s.LogoutAll(token))
+		assert.Equal(t, map[string]int{hashToken(otherToken): 2}, repo.refreshTokens)
+
+		_, err = s.ParseToken(token)
+		assert.Equal(t, storage.TokenRevoked, err)
+		_, err = s.ParseToken(other)
+		assert.Equal(t, storage.TokenRevoked, err)
+		userId, err := s.ParseToken(otherUser)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, userId)
+	})
+
+	t.Run("Wrong refresh token", func(t *testing.T) {
+		repo := newAuthRepo()
+		s := newTestAuthService(repo)
+
+		token, err := s.UpdateAccessToken(1)
+		assert.NoError(t, err)
+
+		assert.Equal(t, storage.WrongRefreshToken, s.Logout(token, "not a token"))
+		assert.Empty(t, repo.revoked)
+	})
+}
@@ -0,0 +1,22 @@
+package service
+
+import (
+	storage "github.com/mahadeva604/audio-storage"
+	"github.com/mahadeva604/audio-storage/pkg/repository"
+)
+
+type ChapterService struct {
+	repo repository.Chapter
+}
+
+func NewChapterService(repo repository.Chapter) *ChapterService {
+	return &ChapterService{repo: repo}
+}
+
+func (s *ChapterService) SetChapters(userID, audioId int, chapters []storage.Chapter) error {
+	return s.repo.SetChapters(userID, audioId, chapters)
+}
+
+func (s *ChapterService) GetChapters(userID, audioId int) (storage.AudioChapters, error) {
+	return s.repo.GetChapters(userID, audioId)
+}
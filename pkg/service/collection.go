@@ -0,0 +1,73 @@
+package service
+
+import (
+	"errors"
+	storage "github.com/mahadeva604/audio-storage"
+	"github.com/mahadeva604/audio-storage/pkg/repository"
+)
+
+type CollectionService struct {
+	repo     repository.Collection
+	artworks repository.Artwork
+	files    repository.Storage
+}
+
+func NewCollectionService(repo repository.Collection, artworks repository.Artwork, files repository.Storage) *CollectionService {
+	return &CollectionService{repo: repo, artworks: artworks, files: files}
+}
+
+func (s *CollectionService) CreateCollection(userID int, input storage.CollectionInput) (int, error) {
+	return s.repo.CreateCollection(userID, input)
+}
+
+func (s *CollectionService) UpdateCollection(userID, collectionId int, input storage.CollectionInput) error {
+	return s.repo.UpdateCollection(userID, collectionId, input)
+}
+
+// DeleteCollection deletes collection and files of its artwork
+func (s *CollectionService) DeleteCollection(userID, collectionId int) error {
+	artworkId, err := s.artworks.GetCollectionArtwork(userID, collectionId)
+	if err != nil && !errors.Is(err, storage.ArtworkNotFound) {
+		return err
+	}
+
+	if err := s.repo.DeleteCollection(userID, collectionId); err != nil {
+		return err
+	}
+
+	return deleteArtworkFiles(s.files, artworkId)
+}
+
+func (s *CollectionService) GetCollectionList(userID int, input storage.CollectionListParam) (storage.CollectionListJson, error) {
+	return s.repo.GetCollectionList(userID, input)
+}
+
+func (s *CollectionService) GetCollectionItems(userID, collectionId int, input storage.CollectionItemsParam) (storage.CollectionItemsJson, error) {
+	return s.repo.GetCollectionItems(userID, collectionId, input)
+}
+
+func (s *CollectionService) AddCollectionItem(userID, collectionId, audioId int) error {
+	return s.repo.AddCollectionItem(userID, collectionId, audioId)
+}
+
+func (s *CollectionService) RemoveCollectionItem(userID, collectionId, audioId int) error {
+	return s.repo.RemoveCollectionItem(userID, collectionId, audioId)
+}
+
+func (s *CollectionService) ReorderCollection(userID, collectionId int, audioIds []int) error {
+	return s.repo.ReorderCollection(userID, collectionId, audioIds)
+}
+
+func (s *CollectionService) ShareCollection(userID, collectionId, shareId int) error {
+	if userID == shareId {
+		return storage.SelfShareCollection
+	}
+	return s.repo.ShareCollection(userID, collectionId, shareId)
+}
+
+func (s *CollectionService) UnshareCollection(userID, collectionId, shareId int) error {
+	if userID == shareId {
+		return storage.SelfUnshareCollection
+	}
+	return s.repo.UnshareCollection(userID, collectionId, shareId)
+}
@@ -0,0 +1,30 @@
+package service
+
+import (
+	storage "github.com/mahadeva604/audio-storage"
+	"github.com/mahadeva604/audio-storage/pkg/repository"
+)
+
+type CommentService struct {
+	repo repository.Comment
+}
+
+func NewCommentService(repo repository.Comment) *CommentService {
+	return &CommentService{repo: repo}
+}
+
+func (s *CommentService) AddComment(userID, audioId int, input storage.CommentInput) (int, error) {
+	return s.repo.AddComment(userID, audioId, input)
+}
+
+func (s *CommentService) GetComments(userID, audioId int, input storage.CommentListParam) (storage.CommentListJson, error) {
+	return s.repo.GetComments(userID, audioId, input)
+}
+
+func (s *CommentService) UpdateComment(userID, audioId, commentId int, input storage.CommentUpdate) error {
+	return s.repo.UpdateComment(userID, audioId, commentId, input)
+}
+
+func (s *CommentService) DeleteComment(userID, audioId, commentId int) error {
+	return s.repo.DeleteComment(userID, audioId, commentId)
+}
@@ -0,0 +1,172 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/google/uuid"
+	storage "github.com/mahadeva604/audio-storage"
+	"github.com/mahadeva604/audio-storage/pkg/repository"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type FeedConfig struct {
+	BaseURL     string
+	DownloadTTL time.Duration
+}
+
+// feedUrlKeyLabel derives key of download urls from the secret key, which
+// also signs JWT, so that a signature of one can't be used as the other
+const feedUrlKeyLabel = "feed-url"
+
+type FeedService struct {
+	repo       repository.Feed
+	signingKey []byte
+	cfg        FeedConfig
+}
+
+func NewFeedService(repo repository.Feed, secretKey []byte, cfg FeedConfig) *FeedService {
+	cfg.BaseURL = strings.TrimSuffix(cfg.BaseURL, "/")
+
+	mac := hmac.New(sha256.New, secretKey)
+	mac.Write([]byte(feedUrlKeyLabel))
+
+	return &FeedService{repo: repo, signingKey: mac.Sum(nil), cfg: cfg}
+}
+
+// PublishFeed checks that podcast apps accept the feed before it is published
+func (s *FeedService) PublishFeed(userID, collectionId int, input storage.FeedInput) (string, error) {
+	channel, err := s.repo.GetCollectionChannel(userID, collectionId)
+	if err != nil {
+		return "", err
+	}
+	channel.FeedSettings = storage.FeedSettings{
+		Description: input.Description,
+		Author:      input.Author,
+		ImageUrl:    input.ImageUrl,
+		Language:    input.Language,
+		Category:    input.Category,
+		Explicit:    input.Explicit,
+	}
+
+	episodes, err := s.repo.GetFeedEpisodes(collectionId)
+	if err != nil {
+		return "", err
+	}
+
+	if err := s.buildFeed(channel, episodes).Validate(); err != nil {
+		return "", fmt.Errorf("%w: %s", storage.InvalidFeed, err)
+	}
+
+	token, err := s.repo.PublishFeed(userID, collectionId, uuid.New().String(), input)
+	if err != nil {
+		return "", err
+	}
+	return s.feedUrl(token), nil
+}
+
+func (s *FeedService) GetFeedSettings(userID, collectionId int) (storage.FeedSettings, error) {
+	settings, err := s.repo.GetFeedSettings(userID, collectionId)
+	if err != nil {
+		return storage.FeedSettings{}, err
+	}
+	settings.Url = s.feedUrl(settings.Token)
+	return settings, nil
+}
+
+func (s *FeedService) RotateFeedToken(userID, collectionId int) (string, error) {
+	token := uuid.New().String()
+	if err := s.repo.RotateFeedToken(userID, collectionId, token); err != nil {
+		return "", err
+	}
+	return s.feedUrl(token), nil
+}
+
+func (s *FeedService) DeleteFeed(userID, collectionId int) error {
+	return s.repo.DeleteFeed(userID, collectionId)
+}
+
+func (s *FeedService) GetFeed(token string) (storage.RssFeed, error) {
+	channel, err := s.repo.GetFeedChannel(token)
+	if err != nil {
+		return storage.RssFeed{}, err
+	}
+
+	episodes, err := s.repo.GetFeedEpisodes(channel.CollectionId)
+	if err != nil {
+		return storage.RssFeed{}, err
+	}
+
+	return s.buildFeed(channel, episodes), nil
+}
+
+func (s *FeedService) buildFeed(channel storage.FeedChannel, episodes []storage.FeedEpisode) storage.RssFeed {
+	author := channel.Author
+	if author == "" {
+		author = channel.OwnerName
+	}
+
+	rssChannel := storage.RssChannel{
+		Title:          channel.Title,
+		Link:           s.cfg.BaseURL,
+		Description:    channel.Description,
+		Language:       channel.Language,
+		ItunesAuthor:   author,
+		ItunesImage:    storage.RssItunesImage{Href: channel.ImageUrl},
+		ItunesCategory: storage.RssItunesCategory{Text: channel.Category},
+		ItunesExplicit: strconv.FormatBool(channel.Explicit),
+		Items:          make([]storage.RssItem, 0, len(episodes)),
+	}
+
+	expires := s.downloadExpires()
+	for i, episode := range episodes {
+		if episode.Title == "" {
+			episode.Title = fmt.Sprintf("Episode %d", i+1)
+		}
+
+		rssChannel.Items = append(rssChannel.Items, storage.NewRssItem(episode, s.downloadUrl(episode.AudioId, channel.CollectionId, channel.Token, expires)))
+	}
+
+	return storage.NewRssFeed(rssChannel)
+}
+
+// GetSignedFile returns episode of feed the url was signed for, the feed must
+// still be published with the same token
+func (s *FeedService) GetSignedFile(audioId, collectionId int, token string, expires int64, signature string) (storage.DownloadAudio, error) {
+	expected := s.sign(audioId, collectionId, token, expires)
+	if time.Now().Unix() > expires || !hmac.Equal([]byte(signature), []byte(expected)) {
+		return storage.DownloadAudio{}, storage.WrongSignature
+	}
+	return s.repo.GetFeedFile(audioId, collectionId, token)
+}
+
+func (s *FeedService) feedUrl(token string) string {
+	return s.cfg.BaseURL + "/feeds/" + token
+}
+
+// downloadExpires is rounded up to the next day, so enclosure urls stay
+// the same for every feed request during a day
+func (s *FeedService) downloadExpires() int64 {
+	day := 24 * time.Hour
+	return time.Now().Add(s.cfg.DownloadTTL).Truncate(day).Add(day).Unix()
+}
+
+func (s *FeedService) downloadUrl(audioId, collectionId int, token string, expires int64) string {
+	query := url.Values{
+		"collection": {strconv.Itoa(collectionId)},
+		"token":      {token},
+		"expires":    {strconv.FormatInt(expires, 10)},
+		"signature":  {s.sign(audioId, collectionId, token, expires)},
+	}
+	return fmt.Sprintf("%s/download/%d%s?%s", s.cfg.BaseURL, audioId, storage.FileExt, query.Encode())
+}
+
+func (s *FeedService) sign(audioId, collectionId int, token string, expires int64) string {
+	mac := hmac.New(sha256.New, s.signingKey)
+	fmt.Fprintf(mac, "%d:%d:%s:%d", audioId, collectionId, token, expires)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	storage "github.com/mahadeva604/audio-storage"
+	"github.com/mahadeva604/audio-storage/pkg/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// feedRepo has one collection, its feed is published with token
+type feedRepo struct {
+	repository.Feed
+	token     string
+	episodes  []storage.FeedEpisode
+	published bool
+}
+
+func (r *feedRepo) PublishFeed(userID, collectionId int, token string, input storage.FeedInput) (string, error) {
+	r.published = true
+	return r.token, nil
+}
+
+func (r *feedRepo) GetCollectionChannel(userID, collectionId int) (storage.FeedChannel, error) {
+	return storage.FeedChannel{CollectionId: 2, Title: "collection", OwnerName: "user 1"}, nil
+}
+
+func (r *feedRepo) GetFeedChannel(token string) (storage.FeedChannel, error) {
+	return storage.FeedChannel{CollectionId: 2, Title: "collection", OwnerName: "user 1", FeedSettings: storage.FeedSettings{
+		Token: r.token, Description: "description", ImageUrl: "https://example.com/cover.png", Language: "en", Category: "Technology",
+	}}, nil
+}
+
+func (r *feedRepo) GetFeedEpisodes(collectionId int) ([]storage.FeedEpisode, error) {
+	return r.episodes, nil
+}
+
+func (r *feedRepo) GetFeedFile(audioId, collectionId int, token string) (storage.DownloadAudio, error) {
+	if token != r.token {
+		return storage.DownloadAudio{}, storage.FileNotFound
+	}
+	return storage.DownloadAudio{Title: "audio"}, nil
+}
+
+func TestFeedService_PublishFeed(t *testing.T) {
+	input := storage.FeedInput{Description: "description", ImageUrl: "https://example.com/cover.png", Language: "en", Category: "Technology"}
+
+	repo := &feedRepo{token: "token", episodes: []storage.FeedEpisode{{AudioId: 3, Title: "audio", Size: 0}}}
+	s := NewFeedService(repo, []byte("secret"), FeedConfig{BaseURL: "http://localhost/", DownloadTTL: time.Hour})
+
+	_, err := s.PublishFeed(1, 2, input)
+	assert.ErrorIs(t, err, storage.InvalidFeed)
+	assert.False(t, repo.published)
+
+	repo.episodes[0].Size = 1024
+	feedUrl, err := s.PublishFeed(1, 2, input)
+	assert.NoError(t, err)
+	assert.Equal(t, "http://localhost/feeds/token", feedUrl)
+}
+
+func TestFeedService_GetSignedFile(t *testing.T) {
+	repo := &feedRepo{token: "token", episodes: []storage.FeedEpisode{{AudioId: 3, Title: "audio", Size: 1024}}}
+	s := NewFeedService(repo, []byte("secret"), FeedConfig{BaseURL: "http://localhost", DownloadTTL: time.Hour})
+
+	feed, err := s.GetFeed("token")
+	require.NoError(t, err)
+	require.NoError(t, feed.Validate())
+	require.Len(t, feed.Channel.Items, 1)
+
+	enclosure, err := url.Parse(feed.Channel.Items[0].Enclosure.Url)
+	require.NoError(t, err)
+	assert.Equal(t, "/download/3.aac", enclosure.Path)
+
+	query := enclosure.Query()
+	assert.Equal(t, "2", query.Get("collection"))
+	assert.Equal(t, "token", query.Get("token"))
+	expires, err := strconv.ParseInt(query.Get("expires"), 10, 64)
+	require.NoError(t, err)
+	signature := query.Get("signature")
+
+	_, err = s.GetSignedFile(3, 2, "token", expires, signature)
+	assert.NoError(t, err)
+
+	_, err = s.GetSignedFile(4, 2, "token", expires, signature)
+	assert.ErrorIs(t, err, storage.WrongSignature)
+
+	_, err = s.GetSignedFile(3, 5, "token", expires, signature)
+	assert.ErrorIs(t, err, storage.WrongSignature)
+
+	_, err = s.GetSignedFile(3, 2, "other", expires, signature)
+	assert.ErrorIs(t, err, storage.WrongSignature)
+
+	_, err = s.GetSignedFile(3, 2, "token", time.Now().Add(-time.Minute).Unix(), signature)
+	assert.ErrorIs(t, err, storage.WrongSignature)
+
+	// download urls aren't signed with the secret key itself
+	mac := hmac.New(sha256.New, []byte("secret"))
+	fmt.Fprintf(mac, "%d:%d:%s:%d", 3, 2, "token", expires)
+	assert.NotEqual(t, hex.EncodeToString(mac.Sum(nil)), signature)
+
+	repo.token = "rotated"
+	_, err = s.GetSignedFile(3, 2, "token", expires, signature)
+	assert.ErrorIs(t, err, storage.FileNotFound)
+}
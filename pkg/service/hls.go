@@ -0,0 +1,30 @@
+package service
+
+import (
+	"github.com/google/uuid"
+	"github.com/mahadeva604/audio-storage/pkg/repository"
+)
+
+type HLSService struct {
+	repo  repository.Audio
+	files repository.Storage
+}
+
+func NewHLSService(repo repository.Audio, files repository.Storage) *HLSService {
+	return &HLSService{repo: repo, files: files}
+}
+
+// GetHLSFile returns playlist or segment of audio user has access to
+func (s *HLSService) GetHLSFile(userID, audioId int, name string) ([]byte, error) {
+	audio, err := s.repo.DownloadFile(userID, audioId)
+	if err != nil {
+		return nil, err
+	}
+
+	fileId, err := uuid.Parse(audio.FilePath)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.files.GetHLSFile(fileId, name)
+}
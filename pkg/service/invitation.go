@@ -0,0 +1,64 @@
+package service
+
+import (
+	storage "github.com/mahadeva604/audio-storage"
+	"github.com/mahadeva604/audio-storage/pkg/repository"
+)
+
+type InvitationService struct {
+	repo repository.Invitation
+}
+
+func NewInvitationService(repo repository.Invitation) *InvitationService {
+	return &InvitationService{repo: repo}
+}
+
+func (s *InvitationService) GetInvitations(userID int, input storage.InvitationListParam) (storage.InvitationListJson, error) {
+	return s.repo.GetInvitations(userID, input)
+}
+
+func (s *InvitationService) AcceptInvitation(userID, audioId int) error {
+	return s.repo.AcceptInvitation(userID, audioId)
+}
+
+func (s *InvitationService) DeclineInvitation(userID, audioId int) error {
+	return s.repo.DeclineInvitation(userID, audioId)
+}
+
+func (s *InvitationService) AcceptCollectionInvitation(userID, collectionId int) error {
+	return s.repo.AcceptCollectionInvitation(userID, collectionId)
+}
+
+func (s *InvitationService) DeclineCollectionInvitation(userID, collectionId int) error {
+	return s.repo.DeclineCollectionInvitation(userID, collectionId)
+}
+
+func (s *InvitationService) BlockSender(userID, senderId int) error {
+	if userID == senderId {
+		return storage.SelfBlock
+	}
+	return s.repo.BlockSender(userID, senderId)
+}
+
+func (s *InvitationService) UnblockSender(userID, senderId int) error {
+	return s.repo.UnblockSender(userID, senderId)
+}
+
+func (s *InvitationService) GetBlockedSenders(userID int) ([]storage.Sender, error) {
+	return s.repo.GetBlockedSenders(userID)
+}
+
+func (s *InvitationService) AddAutoAccept(userID, senderId int) error {
+	if userID == senderId {
+		return storage.SelfAutoAccept
+	}
+	return s.repo.AddAutoAccept(userID, senderId)
+}
+
+func (s *InvitationService) RemoveAutoAccept(userID, senderId int) error {
+	return s.repo.RemoveAutoAccept(userID, senderId)
+}
+
+func (s *InvitationService) GetAutoAcceptSenders(userID int) ([]storage.Sender, error) {
+	return s.repo.GetAutoAcceptSenders(userID)
+}
@@ -0,0 +1,331 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	storage "github.com/mahadeva604/audio-storage"
+	"github.com/mahadeva604/audio-storage/pkg/repository"
+	"github.com/sirupsen/logrus"
+	"io/fs"
+	"sync"
+	"time"
+)
+
+type JobConfig struct {
+	Workers      int
+	PollInterval time.Duration
+	MaxAttempts  int
+	// RetryBackoff is doubled after every failed attempt up to MaxRetryBackoff
+	RetryBackoff    time.Duration
+	MaxRetryBackoff time.Duration
+	// Lease is time a job may run without renewal, then it is considered lost
+	// and retried. Workers renew it every third of Lease while job runs
+	Lease           time.Duration
+	CleanupInterval time.Duration
+	// CleanupGrace keeps files just stored by running uploads from cleanup
+	CleanupGrace time.Duration
+	// Transcription runs transcribe jobs
+	Transcription TranscriptionConfig
+}
+
+// JobService queues background jobs in Postgres and runs them, any number of
+// servers can run workers on the same queue
+type JobService struct {
+	repo        repository.Job
+	transcripts repository.Transcript
+	files       repository.Storage
+	cfg         JobConfig
+}
+
+func NewJobService(repo repository.Job, transcripts repository.Transcript, files repository.Storage, cfg JobConfig) *JobService {
+	if cfg.Workers < 1 {
+		cfg.Workers = 1
+	}
+	if cfg.MaxAttempts < 1 {
+		cfg.MaxAttempts = 1
+	}
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = time.Second
+	}
+	if cfg.Lease <= 0 {
+		cfg.Lease = 10 * time.Minute
+	}
+	return &JobService{repo: repo, transcripts: transcripts, files: files, cfg: cfg}
+}
+
+// ProcessAudio queues processing of uploaded audio, the remux job queues the others
+func (s *JobService) ProcessAudio(audioId int) error {
+	_, err := s.repo.EnqueueJob(storage.JobRemux, &audioId, s.cfg.MaxAttempts)
+	return err
+}
+
+func (s *JobService) EnqueueJob(input storage.JobInput) (int, error) {
+	return s.repo.EnqueueJob(input.Type, input.AudioId, s.cfg.MaxAttempts)
+}
+
+func (s *JobService) GetAudioJobs(userID, audioId int) ([]storage.Job, error) {
+	return s.repo.GetAudioJobs(userID, audioId)
+}
+
+func (s *JobService) GetJobs(input storage.JobListParam) (storage.JobListJson, error) {
+	return s.repo.GetJobs(input)
+}
+
+func (s *JobService) RetryJob(jobId int) error {
+	return s.repo.RetryJob(jobId)
+}
+
+// Run runs workers until ctx is done. It also returns jobs of stopped
+// servers to the queue and queues cleanup every CleanupInterval
+func (s *JobService) Run(ctx context.Context) {
+	var workers sync.WaitGroup
+	for i := 0; i < s.cfg.Workers; i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			s.work(ctx)
+		}()
+	}
+
+	s.maintain(ctx)
+	workers.Wait()
+}
+
+func (s *JobService) work(ctx context.Context) {
+	for ctx.Err() == nil {
+		task, err := s.repo.ClaimJob(s.cfg.Lease)
+		if err == nil {
+			s.runJob(task)
+			continue
+		}
+		if !errors.Is(err, storage.JobNotFound) {
+			logrus.Errorf("can't claim job: %s", err.Error())
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(s.cfg.PollInterval):
+		}
+	}
+}
+
+func (s *JobService) maintain(ctx context.Context) {
+	reap := time.NewTicker(s.cfg.PollInterval)
+	defer reap.Stop()
+
+	var cleanup <-chan time.Time
+	if s.cfg.CleanupInterval > 0 {
+		ticker := time.NewTicker(s.cfg.CleanupInterval)
+		defer ticker.Stop()
+		cleanup = ticker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-reap.C:
+			if _, err := s.repo.ReapJobs(); err != nil {
+				logrus.Errorf("can't reap expired jobs: %s", err.Error())
+			}
+		case <-cleanup:
+			_, err := s.repo.EnqueueJob(storage.JobCleanup, nil, s.cfg.MaxAttempts)
+			if err != nil && !errors.Is(err, storage.JobActive) {
+				logrus.Errorf("can't queue cleanup: %s", err.Error())
+			}
+		}
+	}
+}
+
+// runJob runs claimed job, failed job is retried with backoff until it has
+// no attempts left or fails permanently, then it is dead
+func (s *JobService) runJob(task storage.JobTask) {
+	stop := make(chan struct{})
+	renewed := make(chan struct{})
+	go func() {
+		defer close(renewed)
+		s.renewLease(task, stop)
+	}()
+
+	err := s.handleJob(task)
+	close(stop)
+	<-renewed
+
+	if err == nil {
+		if err := s.repo.CompleteJob(task.Id, task.ClaimToken); err != nil {
+			logrus.Errorf("can't complete job %d: %s", task.Id, err.Error())
+		}
+		return
+	}
+
+	var retryAt *time.Time
+	if task.Attempts < task.MaxAttempts && !permanentJobError(err) {
+		at := time.Now().Add(s.backoff(task.Attempts))
+		retryAt = &at
+	}
+
+	if err := s.repo.FailJob(task.Id, task.ClaimToken, err.Error(), retryAt); err != nil {
+		logrus.Errorf("can't fail job %d: %s", task.Id, err.Error())
+	}
+}
+
+// renewLease extends lease of running job until stop is closed, so long jobs
+// aren't reaped while they are still running
+func (s *JobService) renewLease(task storage.JobTask, stop <-chan struct{}) {
+	ticker := time.NewTicker(s.cfg.Lease / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			err := s.repo.ExtendJob(task.Id, task.ClaimToken, s.cfg.Lease)
+			if errors.Is(err, storage.JobLeaseLost) {
+				logrus.Warnf("job %d: %s", task.Id, err.Error())
+				return
+			}
+			if err != nil {
+				logrus.Errorf("can't extend lease of job %d: %s", task.Id, err.Error())
+			}
+		}
+	}
+}
+
+func (s *JobService) handleJob(task storage.JobTask) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+
+	if task.Type == storage.JobCleanup {
+		return s.cleanup()
+	}
+
+	if task.AudioId == nil || task.FilePath == nil {
+		return storage.FileNotFound
+	}
+	audioId := *task.AudioId
+
+	fileId, err := uuid.Parse(*task.FilePath)
+	if err != nil {
+		return err
+	}
+
+	switch task.Type {
+	case storage.JobRemux:
+		return s.remux(audioId, fileId)
+	case storage.JobProbe:
+		probe, err := s.files.ProbeFile(fileId)
+		if err != nil {
+			return err
+		}
+		return s.repo.SetAudioProbe(audioId, probe)
+	case storage.JobHLS:
+		return s.files.PackageHLS(fileId)
+	case storage.JobChecksum:
+		return s.checksum(audioId, fileId, task.Checksum)
+	case storage.JobTranscribe:
+		return s.transcribe(*task.UserId, audioId, fileId)
+	}
+
+	return fmt.Errorf("unknown job type %q", task.Type)
+}
+
+// remux normalizes stored file and queues jobs working with the result
+func (s *JobService) remux(audioId int, fileId uuid.UUID) error {
+	size, err := s.files.NormalizeFile(fileId)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.SetAudioFile(audioId, size); err != nil {
+		return err
+	}
+
+	for _, jobType := range []string{storage.JobProbe, storage.JobChecksum, storage.JobHLS} {
+		_, err := s.repo.EnqueueJob(jobType, &audioId, s.cfg.MaxAttempts)
+		if err != nil && !errors.Is(err, storage.JobActive) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checksum saves checksum of file, once it is saved file is verified against it
+func (s *JobService) checksum(audioId int, fileId uuid.UUID, expected *string) error {
+	sum, err := s.files.ChecksumFile(fileId)
+	if err != nil {
+		return err
+	}
+
+	if expected == nil {
+		return s.repo.SetAudioChecksum(audioId, sum)
+	}
+
+	if *expected != sum {
+		return fmt.Errorf("%w: expected %s, got %s", storage.ChecksumMismatch, *expected, sum)
+	}
+
+	return nil
+}
+
+// transcribe replaces transcript of audio with the one made by provider
+func (s *JobService) transcribe(userID, audioId int, fileId uuid.UUID) error {
+	provider := s.cfg.Transcription.Provider
+	if provider == nil {
+		return storage.TranscriptionDisabled
+	}
+
+	ctx := context.Background()
+	if s.cfg.Transcription.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.cfg.Transcription.Timeout)
+		defer cancel()
+	}
+
+	transcript, err := provider.Transcribe(ctx, s.files.FilePath(fileId))
+	if err != nil {
+		return err
+	}
+
+	return s.transcripts.SetTranscript(userID, audioId, transcript)
+}
+
+// cleanup removes files left by failed uploads and replaced artwork
+func (s *JobService) cleanup() error {
+	files, err := s.files.ListFiles(s.cfg.CleanupGrace)
+	if err != nil {
+		return err
+	}
+
+	orphans, err := s.repo.GetOrphanFiles(files)
+	if err != nil {
+		return err
+	}
+
+	return s.files.DeleteFiles(orphans)
+}
+
+func (s *JobService) backoff(attempt int) time.Duration {
+	backoff := s.cfg.RetryBackoff
+	for i := 1; i < attempt && backoff < s.cfg.MaxRetryBackoff; i++ {
+		backoff *= 2
+	}
+	if s.cfg.MaxRetryBackoff > 0 && backoff > s.cfg.MaxRetryBackoff {
+		backoff = s.cfg.MaxRetryBackoff
+	}
+
+	return backoff
+}
+
+// permanentJobError reports if retrying the job can't help
+func permanentJobError(err error) bool {
+	return errors.Is(err, storage.NotAacFile) || errors.Is(err, storage.ChecksumMismatch) ||
+		errors.Is(err, storage.FileNotFound) || errors.Is(err, fs.ErrNotExist) ||
+		errors.Is(err, storage.TranscriptionDisabled) || errors.Is(err, storage.InvalidTranscript)
+}
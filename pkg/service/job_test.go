@@ -0,0 +1,242 @@
+package service
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	storage "github.com/mahadeva604/audio-storage"
+	"github.com/mahadeva604/audio-storage/pkg/repository"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+// jobRepo records what jobs write, methods which aren't overridden panic on
+// nil embedded interfaces
+type jobRepo struct {
+	repository.Job
+	repository.Transcript
+	repository.Storage
+	fileErr   error
+	delay     time.Duration
+	extended  int
+	checksum  string
+	queued    []string
+	completed bool
+	failed    string
+	retryAt   *time.Time
+	saved     map[string]interface{}
+}
+
+func (r *jobRepo) EnqueueJob(jobType string, audioId *int, maxAttempts int) (int, error) {
+	if jobType == storage.JobHLS {
+		return 0, storage.JobActive
+	}
+	r.queued = append(r.queued, jobType)
+	return len(r.queued), nil
+}
+
+func (r *jobRepo) ExtendJob(jobId int, claimToken string, lease time.Duration) error {
+	r.extended++
+	return nil
+}
+
+func (r *jobRepo) CompleteJob(jobId int, claimToken string) error {
+	r.completed = true
+	return nil
+}
+
+func (r *jobRepo) FailJob(jobId int, claimToken, message string, retryAt *time.Time) error {
+	r.failed = message
+	r.retryAt = retryAt
+	return nil
+}
+
+func (r *jobRepo) SetAudioFile(audioId int, size int64) error {
+	r.saved["size"] = size
+	return nil
+}
+
+func (r *jobRepo) SetAudioProbe(audioId int, probe storage.AudioProbe) error {
+	r.saved["probe"] = probe
+	return nil
+}
+
+func (r *jobRepo) SetAudioChecksum(audioId int, checksum string) error {
+	r.saved["checksum"] = checksum
+	return nil
+}
+
+func (r *jobRepo) SetTranscript(userID, audioId int, transcript storage.Transcript) error {
+	r.saved["transcript"] = transcript
+	return nil
+}
+
+func (r *jobRepo) FilePath(fileId uuid.UUID) string {
+	return "/data/" + fileId.String()
+}
+
+func (r *jobRepo) NormalizeFile(fileId uuid.UUID) (int64, error) {
+	return 1000, r.fileErr
+}
+
+func (r *jobRepo) ProbeFile(fileId uuid.UUID) (storage.AudioProbe, error) {
+	time.Sleep(r.delay)
+	return storage.AudioProbe{Duration: 61, SampleRate: 44100, Channels: 2, Bitrate: 128000}, r.fileErr
+}
+
+func (r *jobRepo) ChecksumFile(fileId uuid.UUID) (string, error) {
+	return r.checksum, r.fileErr
+}
+
+func TestJobService_runJob(t *testing.T) {
+	audioId := 2
+	path := uuid.New().String()
+	oldSum := "old"
+
+	userId := 1
+	transcript := storage.Transcript{{StartMs: 0, EndMs: 1000, Text: "hello"}}
+
+	task := func(jobType string, attempts int) storage.JobTask {
+		return storage.JobTask{
+			Job:      storage.Job{Id: 7, Type: jobType, AudioId: &audioId, Attempts: attempts, MaxAttempts: 3},
+			UserId:   &userId,
+			FilePath: &path,
+		}
+	}
+
+	testTable := []struct {
+		name              string
+		task              storage.JobTask
+		provider          TranscriptionProvider
+		fileErr           error
+		checksum          string
+		expectedCompleted bool
+		expectedFailed    string
+		expectedRetry     bool
+		expectedQueued    []string
+		expectedSaved     map[string]interface{}
+	}{
+		{
+			name:              "OK remux",
+			task:              task(storage.JobRemux, 1),
+			expectedCompleted: true,
+			expectedQueued:    []string{storage.JobProbe, storage.JobChecksum},
+			expectedSaved:     map[string]interface{}{"size": int64(1000)},
+		},
+		{
+			name:              "OK probe",
+			task:              task(storage.JobProbe, 1),
+			expectedCompleted: true,
+			expectedSaved:     map[string]interface{}{"probe": storage.AudioProbe{Duration: 61, SampleRate: 44100, Channels: 2, Bitrate: 128000}},
+		},
+		{
+			name:              "OK first checksum",
+			task:              task(storage.JobChecksum, 1),
+			checksum:          "new",
+			expectedCompleted: true,
+			expectedSaved:     map[string]interface{}{"checksum": "new"},
+		},
+		{
+			name: "Checksum mismatch",
+			task: func() storage.JobTask {
+				t := task(storage.JobChecksum, 1)
+				t.Checksum = &oldSum
+				return t
+			}(),
+			checksum:       "new",
+			expectedFailed: "stored file doesn't match its checksum: expected old, got new",
+			expectedSaved:  map[string]interface{}{},
+		},
+		{
+			name:           "Retry",
+			task:           task(storage.JobProbe, 2),
+			fileErr:        errors.New("read error"),
+			expectedFailed: "read error",
+			expectedRetry:  true,
+			expectedSaved:  map[string]interface{}{},
+		},
+		{
+			name:           "No attempts left",
+			task:           task(storage.JobProbe, 3),
+			fileErr:        errors.New("read error"),
+			expectedFailed: "read error",
+			expectedSaved:  map[string]interface{}{},
+		},
+		{
+			name:           "Not aac file",
+			task:           task(storage.JobRemux, 1),
+			fileErr:        storage.NotAacFile,
+			expectedFailed: storage.NotAacFile.Error(),
+			expectedSaved:  map[string]interface{}{},
+		},
+		{
+			name:              "OK transcribe",
+			task:              task(storage.JobTranscribe, 1),
+			provider:          FakeProvider{Transcript: transcript},
+			expectedCompleted: true,
+			expectedSaved:     map[string]interface{}{"transcript": transcript},
+		},
+		{
+			name:           "Transcription provider failed",
+			task:           task(storage.JobTranscribe, 1),
+			provider:       FakeProvider{Err: errors.New("engine crashed")},
+			expectedFailed: "engine crashed",
+			expectedRetry:  true,
+			expectedSaved:  map[string]interface{}{},
+		},
+		{
+			name:           "Transcription disabled",
+			task:           task(storage.JobTranscribe, 1),
+			expectedFailed: storage.TranscriptionDisabled.Error(),
+			expectedSaved:  map[string]interface{}{},
+		},
+		{
+			name:           "Unknown type",
+			task:           task("transcode", 1),
+			expectedFailed: `unknown job type "transcode"`,
+			expectedRetry:  true,
+			expectedSaved:  map[string]interface{}{},
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			repo := &jobRepo{fileErr: testCase.fileErr, checksum: testCase.checksum, saved: map[string]interface{}{}}
+			s := NewJobService(repo, repo, repo, JobConfig{MaxAttempts: 3, RetryBackoff: time.Minute, MaxRetryBackoff: time.Hour,
+				Transcription: TranscriptionConfig{Provider: testCase.provider}})
+
+			s.runJob(testCase.task)
+
+			assert.Equal(t, testCase.expectedCompleted, repo.completed)
+			assert.Equal(t, testCase.expectedFailed, repo.failed)
+			assert.Equal(t, testCase.expectedRetry, repo.retryAt != nil)
+			assert.Equal(t, testCase.expectedQueued, repo.queued)
+			assert.Equal(t, testCase.expectedSaved, repo.saved)
+		})
+	}
+}
+
+func TestJobService_renewLease(t *testing.T) {
+	audioId := 2
+	path := uuid.New().String()
+	repo := &jobRepo{delay: 100 * time.Millisecond, saved: map[string]interface{}{}}
+	s := NewJobService(repo, repo, repo, JobConfig{MaxAttempts: 3, Lease: 30 * time.Millisecond})
+
+	s.runJob(storage.JobTask{
+		Job:        storage.Job{Id: 7, Type: storage.JobProbe, AudioId: &audioId, Attempts: 1, MaxAttempts: 3},
+		ClaimToken: uuid.New().String(),
+		FilePath:   &path,
+	})
+
+	assert.True(t, repo.completed)
+	assert.GreaterOrEqual(t, repo.extended, 2)
+}
+
+func TestJobService_backoff(t *testing.T) {
+	s := NewJobService(nil, nil, nil, JobConfig{RetryBackoff: 30 * time.Second, MaxRetryBackoff: 2 * time.Minute})
+
+	assert.Equal(t, 30*time.Second, s.backoff(1))
+	assert.Equal(t, time.Minute, s.backoff(2))
+	assert.Equal(t, 2*time.Minute, s.backoff(3))
+	assert.Equal(t, 2*time.Minute, s.backoff(10))
+}
@@ -0,0 +1,213 @@
+package service
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt/v4"
+	storage "github.com/mahadeva604/audio-storage"
+	"math/big"
+	"sort"
+)
+
+// SigningKey signs or verifies access tokens, Id is sent in kid header
+type SigningKey struct {
+	Id     string
+	Method jwt.SigningMethod
+	// Private is nil for keys kept only to verify tokens signed before rotation
+	Private interface{}
+	Public  interface{}
+}
+
+// KeySet has key new tokens are signed with and every key tokens are verified
+// with, retired keys stay until tokens signed with them expire
+type KeySet struct {
+	signing SigningKey
+	keys    map[string]SigningKey
+}
+
+func NewKeySet(signing SigningKey, verification ...SigningKey) (KeySet, error) {
+	if signing.Private == nil {
+		return KeySet{}, fmt.Errorf("signing key %q has no private key", signing.Id)
+	}
+
+	set := KeySet{signing: signing, keys: map[string]SigningKey{signing.Id: signing}}
+	for _, key := range verification {
+		if _, ok := set.keys[key.Id]; ok {
+			return KeySet{}, fmt.Errorf("duplicate key id %q", key.Id)
+		}
+		set.keys[key.Id] = key
+	}
+
+	return set, nil
+}
+
+// find returns verification key of token, its algorithm must be the one of key
+func (s KeySet) find(token *jwt.Token) (SigningKey, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, ok := s.keys[kid]
+	if !ok {
+		return SigningKey{}, fmt.Errorf("unknown signing key %q", kid)
+	}
+
+	if token.Method.Alg() != key.Method.Alg() {
+		return SigningKey{}, errors.New("invalid signing method")
+	}
+
+	return key, nil
+}
+
+// algorithms returns algorithms of keys, tokens of other algorithms are rejected
+func (s KeySet) algorithms() []string {
+	var algs []string
+	seen := map[string]bool{}
+	for _, key := range s.sorted() {
+		if alg := key.Method.Alg(); !seen[alg] {
+			seen[alg] = true
+			algs = append(algs, alg)
+		}
+	}
+
+	return algs
+}
+
+// NewHMACKey returns HS256 key, it can't be published in JWKS
+func NewHMACKey(id string, secret []byte) SigningKey {
+	return SigningKey{Id: id, Method: jwt.SigningMethodHS256, Private: secret, Public: secret}
+}
+
+// ParseSigningKey parses PEM encoded key of RS256, ES256 or EdDSA algorithm.
+// Public key can only verify tokens
+func ParseSigningKey(id, alg string, data []byte) (SigningKey, error) {
+	key, err := parsePEMKey(data)
+	if err != nil {
+		return SigningKey{}, fmt.Errorf("key %q: %w", id, err)
+	}
+
+	var private, public interface{}
+	switch k := key.(type) {
+	case *rsa.PrivateKey, *ecdsa.PrivateKey, ed25519.PrivateKey:
+		private = k
+		public = k.(crypto.Signer).Public()
+	default:
+		public = k
+	}
+
+	var method jwt.SigningMethod
+	switch alg {
+	case jwt.SigningMethodRS256.Alg():
+		method = jwt.SigningMethodRS256
+		_, ok := public.(*rsa.PublicKey)
+		err = checkKeyType(ok)
+	case jwt.SigningMethodES256.Alg():
+		method = jwt.SigningMethodES256
+		k, ok := public.(*ecdsa.PublicKey)
+		err = checkKeyType(ok && k.Curve == elliptic.P256())
+	case jwt.SigningMethodEdDSA.Alg():
+		method = jwt.SigningMethodEdDSA
+		_, ok := public.(ed25519.PublicKey)
+		err = checkKeyType(ok)
+	default:
+		err = fmt.Errorf("unsupported algorithm %q", alg)
+	}
+	if err != nil {
+		return SigningKey{}, fmt.Errorf("key %q: %w", id, err)
+	}
+
+	return SigningKey{Id: id, Method: method, Private: private, Public: public}, nil
+}
+
+func checkKeyType(ok bool) error {
+	if !ok {
+		return jwt.ErrInvalidKeyType
+	}
+	return nil
+}
+
+// parsePEMKey returns private key or, if there is none, public key of PEM block
+func parsePEMKey(data []byte) (interface{}, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	if key, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParseECPrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParsePKIXPublicKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParsePKCS1PublicKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+}
+
+// JWKS returns public keys of set, HMAC keys are secret and skipped
+func (s KeySet) JWKS() storage.JWKS {
+	jwks := storage.JWKS{Keys: make([]storage.JWK, 0, len(s.keys))}
+	for _, key := range s.sorted() {
+		jwk := storage.JWK{Kid: key.Id, Use: "sig", Alg: key.Method.Alg()}
+		switch k := key.Public.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = encodeJWKInt(k.N, 0)
+			jwk.E = encodeJWKInt(big.NewInt(int64(k.E)), 0)
+		case *ecdsa.PublicKey:
+			size := (k.Curve.Params().BitSize + 7) / 8
+			jwk.Kty = "EC"
+			jwk.Crv = k.Curve.Params().Name
+			jwk.X = encodeJWKInt(k.X, size)
+			jwk.Y = encodeJWKInt(k.Y, size)
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(k)
+		default:
+			continue
+		}
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+
+	return jwks
+}
+
+// sorted returns signing key first, then the others by id
+func (s KeySet) sorted() []SigningKey {
+	keys := []SigningKey{s.signing}
+	ids := make([]string, 0, len(s.keys))
+	for id := range s.keys {
+		if id != s.signing.Id {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		keys = append(keys, s.keys[id])
+	}
+
+	return keys
+}
+
+// encodeJWKInt encodes big-endian bytes of n, padded to size if it is set
+func encodeJWKInt(n *big.Int, size int) string {
+	b := n.Bytes()
+	if len(b) < size {
+		b = append(make([]byte, size-len(b)), b...)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
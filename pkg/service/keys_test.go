@@ -0,0 +1,235 @@
+package service
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"github.com/golang-jwt/jwt/v4"
+	storage "github.com/mahadeva604/audio-storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testKeys struct {
+	rsa     *rsa.PrivateKey
+	ec      *ecdsa.PrivateKey
+	ed      ed25519.PrivateKey
+	ecP384  *ecdsa.PrivateKey
+	rsaPEM  []byte
+	rsaPub  []byte
+	ecPEM   []byte
+	edPEM   []byte
+	edPub   []byte
+	p384PEM []byte
+}
+
+func newTestKeys(t *testing.T) testKeys {
+	var k testKeys
+	var err error
+
+	k.rsa, err = rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	k.ec, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	k.ecP384, err = ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+	_, k.ed, err = ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	k.rsaPEM = pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(k.rsa)})
+	k.rsaPub = publicPEM(t, &k.rsa.PublicKey)
+
+	ecDer, err := x509.MarshalECPrivateKey(k.ec)
+	require.NoError(t, err)
+	k.ecPEM = pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: ecDer})
+
+	p384Der, err := x509.MarshalPKCS8PrivateKey(k.ecP384)
+	require.NoError(t, err)
+	k.p384PEM = pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: p384Der})
+
+	edDer, err := x509.MarshalPKCS8PrivateKey(k.ed)
+	require.NoError(t, err)
+	k.edPEM = pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: edDer})
+	k.edPub = publicPEM(t, k.ed.Public())
+
+	return k
+}
+
+func publicPEM(t *testing.T, key interface{}) []byte {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+}
+
+func newKeyAuthService(t *testing.T, cfg AuthConfig, signing SigningKey, verification ...SigningKey) *AuthService {
+	keys, err := NewKeySet(signing, verification...)
+	require.NoError(t, err)
+
+	cfg.AccessTokenTTL = time.Minute
+	cfg.Keys = keys
+	return NewAuthService(newAuthRepo(), cfg)
+}
+
+func TestParseSigningKey(t *testing.T) {
+	k := newTestKeys(t)
+
+	testTable := []struct {
+		name        string
+		alg         string
+		data        []byte
+		private     bool
+		expectedErr string
+	}{
+		{name: "OK RS256", alg: "RS256", data: k.rsaPEM, private: true},
+		{name: "OK RS256 public", alg: "RS256", data: k.rsaPub},
+		{name: "OK ES256", alg: "ES256", data: k.ecPEM, private: true},
+		{name: "OK EdDSA", alg: "EdDSA", data: k.edPEM, private: true},
+		{name: "OK EdDSA public", alg: "EdDSA", data: k.edPub},
+		{name: "Key of other algorithm", alg: "ES256", data: k.rsaPEM, expectedErr: `key "key": key is of invalid type`},
+		{name: "Curve of other algorithm", alg: "ES256", data: k.p384PEM, expectedErr: `key "key": key is of invalid type`},
+		{name: "Unsupported algorithm", alg: "PS256", data: k.rsaPEM, expectedErr: `key "key": unsupported algorithm "PS256"`},
+		{name: "Not PEM", alg: "RS256", data: []byte("secret"), expectedErr: `key "key": no PEM block found`},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			key, err := ParseSigningKey("key", testCase.alg, testCase.data)
+			if testCase.expectedErr != "" {
+				assert.EqualError(t, err, testCase.expectedErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, "key", key.Id)
+			assert.Equal(t, testCase.alg, key.Method.Alg())
+			assert.Equal(t, testCase.private, key.Private != nil)
+			assert.NotNil(t, key.Public)
+		})
+	}
+
+	_, err := NewKeySet(SigningKey{Id: "public", Method: jwt.SigningMethodRS256, Public: &k.rsa.PublicKey})
+	assert.EqualError(t, err, `signing key "public" has no private key`)
+
+	_, err = NewKeySet(NewHMACKey("key", []byte("secret")), NewHMACKey("key", []byte("other")))
+	assert.EqualError(t, err, `duplicate key id "key"`)
+}
+
+func TestAuthService_SigningKeys(t *testing.T) {
+	k := newTestKeys(t)
+
+	for _, testCase := range []struct {
+		alg  string
+		data []byte
+	}{
+		{alg: "RS256", data: k.rsaPEM},
+		{alg: "ES256", data: k.ecPEM},
+		{alg: "EdDSA", data: k.edPEM},
+	} {
+		t.Run("OK "+testCase.alg, func(t *testing.T) {
+			key, err := ParseSigningKey("key-1", testCase.alg, testCase.data)
+			require.NoError(t, err)
+			s := newKeyAuthService(t, AuthConfig{Issuer: "audio-storage", Audience: "api"}, key)
+
+			token, err := s.UpdateAccessToken(1)
+			require.NoError(t, err)
+
+			parsed, _ := jwt.Parse(token, nil)
+			assert.Equal(t, testCase.alg, parsed.Header["alg"])
+			assert.Equal(t, "key-1", parsed.Header["kid"])
+
+			userId, err := s.ParseToken(token)
+			assert.NoError(t, err)
+			assert.Equal(t, 1, userId)
+		})
+	}
+
+	t.Run("Rotation", func(t *testing.T) {
+		oldKey, err := ParseSigningKey("old", "RS256", k.rsaPEM)
+		require.NoError(t, err)
+		oldPublic, err := ParseSigningKey("old", "RS256", k.rsaPub)
+		require.NoError(t, err)
+		newKey, err := ParseSigningKey("new", "EdDSA", k.edPEM)
+		require.NoError(t, err)
+
+		token, err := newKeyAuthService(t, AuthConfig{}, oldKey).UpdateAccessToken(1)
+		require.NoError(t, err)
+
+		_, err = newKeyAuthService(t, AuthConfig{}, newKey, oldPublic).ParseToken(token)
+		assert.NoError(t, err)
+
+		_, err = newKeyAuthService(t, AuthConfig{}, newKey).ParseToken(token)
+		assert.EqualError(t, err, "signing method RS256 is invalid")
+	})
+
+	t.Run("HS256 signed with public key", func(t *testing.T) {
+		key, err := ParseSigningKey("rsa", "RS256", k.rsaPEM)
+		require.NoError(t, err)
+		s := newKeyAuthService(t, AuthConfig{}, key)
+
+		forged := jwt.NewWithClaims(jwt.SigningMethodHS256, &accessClaims{
+			jwt.RegisteredClaims{ID: "2f1f9d8e-3c4b-4a5a-9e6f-7a8b9c0d1e2f", ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute))}, 1,
+		})
+		forged.Header["kid"] = "rsa"
+		token, err := forged.SignedString(k.rsaPub)
+		require.NoError(t, err)
+
+		_, err = s.ParseToken(token)
+		assert.EqualError(t, err, "signing method HS256 is invalid")
+	})
+
+	t.Run("Issuer and audience", func(t *testing.T) {
+		key := NewHMACKey("hs", []byte("secret"))
+		token, err := newKeyAuthService(t, AuthConfig{Issuer: "audio-storage", Audience: "api"}, key).UpdateAccessToken(1)
+		require.NoError(t, err)
+
+		_, err = newKeyAuthService(t, AuthConfig{Issuer: "other", Audience: "api"}, key).ParseToken(token)
+		assert.EqualError(t, err, "token has invalid issuer")
+
+		_, err = newKeyAuthService(t, AuthConfig{Issuer: "audio-storage", Audience: "other"}, key).ParseToken(token)
+		assert.EqualError(t, err, "token has invalid audience")
+
+		_, err = newKeyAuthService(t, AuthConfig{}, key).ParseToken(token)
+		assert.NoError(t, err)
+	})
+}
+
+func TestKeySet_JWKS(t *testing.T) {
+	k := newTestKeys(t)
+
+	rsaKey, err := ParseSigningKey("rsa", "RS256", k.rsaPub)
+	require.NoError(t, err)
+	ecKey, err := ParseSigningKey("ec", "ES256", k.ecPEM)
+	require.NoError(t, err)
+	edKey, err := ParseSigningKey("ed", "EdDSA", k.edPEM)
+	require.NoError(t, err)
+
+	keys, err := NewKeySet(edKey, NewHMACKey("hs", []byte("secret")), rsaKey, ecKey)
+	require.NoError(t, err)
+
+	jwks := keys.JWKS()
+	require.Len(t, jwks.Keys, 3)
+
+	ed := jwks.Keys[0]
+	assert.Equal(t, storage.JWK{Kty: "OKP", Kid: "ed", Use: "sig", Alg: "EdDSA", Crv: "Ed25519", X: ed.X}, ed)
+	assert.Len(t, ed.X, 43)
+
+	ec := jwks.Keys[1]
+	assert.Equal(t, "EC", ec.Kty)
+	assert.Equal(t, "P-256", ec.Crv)
+	assert.Len(t, ec.X, 43)
+	assert.Len(t, ec.Y, 43)
+
+	rsa := jwks.Keys[2]
+	assert.Equal(t, "RSA", rsa.Kty)
+	assert.Equal(t, "RS256", rsa.Alg)
+	assert.Equal(t, "AQAB", rsa.E)
+	assert.Len(t, rsa.N, 342)
+	assert.False(t, strings.ContainsAny(rsa.N, "+/="))
+}
@@ -0,0 +1,57 @@
+package service
+
+import (
+	"fmt"
+	storage "github.com/mahadeva604/audio-storage"
+	"github.com/mahadeva604/audio-storage/pkg/repository"
+	"github.com/xeipuuv/gojsonschema"
+	"strings"
+)
+
+type MetadataSchemaService struct {
+	repo repository.MetadataSchema
+}
+
+func NewMetadataSchemaService(repo repository.MetadataSchema) *MetadataSchemaService {
+	return &MetadataSchemaService{repo: repo}
+}
+
+func (s *MetadataSchemaService) GetMetadataSchema(userID int) (storage.MetadataSchema, error) {
+	schema, err := s.repo.GetMetadataSchema(userID)
+	return storage.MetadataSchema{Schema: schema}, err
+}
+
+func (s *MetadataSchemaService) SetMetadataSchema(userID int, input storage.MetadataSchema) error {
+	if _, err := gojsonschema.NewSchema(gojsonschema.NewBytesLoader(input.Schema)); err != nil {
+		return fmt.Errorf("%w: %s", storage.InvalidMetadataSchema, err)
+	}
+
+	return s.repo.SetMetadataSchema(userID, input.Schema)
+}
+
+func (s *MetadataSchemaService) DeleteMetadataSchema(userID int) error {
+	return s.repo.DeleteMetadataSchema(userID)
+}
+
+// validateBySchema checks custom fields against JSON schema, schema errors
+// of a stored schema are reported as invalid schema
+func validateBySchema(schema []byte, custom storage.CustomFields) error {
+	if custom == nil {
+		custom = storage.CustomFields{}
+	}
+
+	result, err := gojsonschema.Validate(gojsonschema.NewBytesLoader(schema), gojsonschema.NewGoLoader(custom))
+	if err != nil {
+		return fmt.Errorf("%w: %s", storage.InvalidMetadataSchema, err)
+	}
+
+	if !result.Valid() {
+		details := make([]string, 0, len(result.Errors()))
+		for _, e := range result.Errors() {
+			details = append(details, e.String())
+		}
+		return fmt.Errorf("%w: %s", storage.InvalidCustomFields, strings.Join(details, "; "))
+	}
+
+	return nil
+}
@@ -5,6 +5,7 @@
 package mock_service
 
 import (
+	context "context"
 	io "io"
 	reflect "reflect"
 
@@ -51,6 +52,20 @@ func (mr *MockAuthorizationMockRecorder) CreateUser(user interface{}) *gomock.Ca
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockAuthorization)(nil).CreateUser), user)
 }
 
+// DeleteSession mocks base method.
+func (m *MockAuthorization) DeleteSession(userId, sessionId int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteSession", userId, sessionId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteSession indicates an expected call of DeleteSession.
+func (mr *MockAuthorizationMockRecorder) DeleteSession(userId, sessionId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSession", reflect.TypeOf((*MockAuthorization)(nil).DeleteSession), userId, sessionId)
+}
+
 // GenerateAccessToken mocks base method.
 func (m *MockAuthorization) GenerateAccessToken(username, password string) (int, string, error) {
 	m.ctrl.T.Helper()
@@ -68,18 +83,105 @@ func (mr *MockAuthorizationMockRecorder) GenerateAccessToken(username, password
 }
 
 // GenerateRefreshToken mocks base method.
-func (m *MockAuthorization) GenerateRefreshToken(userId int) (string, error) {
+func (m *MockAuthorization) GenerateRefreshToken(userId int, client storage.SessionClient) (string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GenerateRefreshToken", userId)
+	ret := m.ctrl.Call(m, "GenerateRefreshToken", userId, client)
 	ret0, _ := ret[0].(string)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GenerateRefreshToken indicates an expected call of GenerateRefreshToken.
-func (mr *MockAuthorizationMockRecorder) GenerateRefreshToken(userId interface{}) *gomock.Call {
+func (mr *MockAuthorizationMockRecorder) GenerateRefreshToken(userId, client interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateRefreshToken", reflect.TypeOf((*MockAuthorization)(nil).GenerateRefreshToken), userId, client)
+}
+
+// GetSecurityEvents mocks base method.
+func (m *MockAuthorization) GetSecurityEvents(userId int, input storage.SecurityEventListParam) (storage.SecurityEventListJson, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSecurityEvents", userId, input)
+	ret0, _ := ret[0].(storage.SecurityEventListJson)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSecurityEvents indicates an expected call of GetSecurityEvents.
+func (mr *MockAuthorizationMockRecorder) GetSecurityEvents(userId, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSecurityEvents", reflect.TypeOf((*MockAuthorization)(nil).GetSecurityEvents), userId, input)
+}
+
+// GetSessions mocks base method.
+func (m *MockAuthorization) GetSessions(userId int) ([]storage.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSessions", userId)
+	ret0, _ := ret[0].([]storage.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSessions indicates an expected call of GetSessions.
+func (mr *MockAuthorizationMockRecorder) GetSessions(userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSessions", reflect.TypeOf((*MockAuthorization)(nil).GetSessions), userId)
+}
+
+// IsAdmin mocks base method.
+func (m *MockAuthorization) IsAdmin(userId int) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsAdmin", userId)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IsAdmin indicates an expected call of IsAdmin.
+func (mr *MockAuthorizationMockRecorder) IsAdmin(userId interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateRefreshToken", reflect.TypeOf((*MockAuthorization)(nil).GenerateRefreshToken), userId)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsAdmin", reflect.TypeOf((*MockAuthorization)(nil).IsAdmin), userId)
+}
+
+// JWKS mocks base method.
+func (m *MockAuthorization) JWKS() storage.JWKS {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "JWKS")
+	ret0, _ := ret[0].(storage.JWKS)
+	return ret0
+}
+
+// JWKS indicates an expected call of JWKS.
+func (mr *MockAuthorizationMockRecorder) JWKS() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "JWKS", reflect.TypeOf((*MockAuthorization)(nil).JWKS))
+}
+
+// Logout mocks base method.
+func (m *MockAuthorization) Logout(accessToken, refreshToken string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Logout", accessToken, refreshToken)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Logout indicates an expected call of Logout.
+func (mr *MockAuthorizationMockRecorder) Logout(accessToken, refreshToken interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Logout", reflect.TypeOf((*MockAuthorization)(nil).Logout), accessToken, refreshToken)
+}
+
+// LogoutAll mocks base method.
+func (m *MockAuthorization) LogoutAll(accessToken string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LogoutAll", accessToken)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// LogoutAll indicates an expected call of LogoutAll.
+func (mr *MockAuthorizationMockRecorder) LogoutAll(accessToken interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LogoutAll", reflect.TypeOf((*MockAuthorization)(nil).LogoutAll), accessToken)
 }
 
 // ParseToken mocks base method.
@@ -113,9 +215,9 @@ func (mr *MockAuthorizationMockRecorder) UpdateAccessToken(userId interface{}) *
 }
 
 // UpdateRefreshToken mocks base method.
-func (m *MockAuthorization) UpdateRefreshToken(oldRefreshToken string) (int, string, error) {
+func (m *MockAuthorization) UpdateRefreshToken(oldRefreshToken string, client storage.SessionClient) (int, string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateRefreshToken", oldRefreshToken)
+	ret := m.ctrl.Call(m, "UpdateRefreshToken", oldRefreshToken, client)
 	ret0, _ := ret[0].(int)
 	ret1, _ := ret[1].(string)
 	ret2, _ := ret[2].(error)
@@ -123,9 +225,182 @@ func (m *MockAuthorization) UpdateRefreshToken(oldRefreshToken string) (int, str
 }
 
 // UpdateRefreshToken indicates an expected call of UpdateRefreshToken.
-func (mr *MockAuthorizationMockRecorder) UpdateRefreshToken(oldRefreshToken interface{}) *gomock.Call {
+func (mr *MockAuthorizationMockRecorder) UpdateRefreshToken(oldRefreshToken, client interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateRefreshToken", reflect.TypeOf((*MockAuthorization)(nil).UpdateRefreshToken), oldRefreshToken, client)
+}
+
+// MockPassword is a mock of Password interface.
+type MockPassword struct {
+	ctrl     *gomock.Controller
+	recorder *MockPasswordMockRecorder
+}
+
+// MockPasswordMockRecorder is the mock recorder for MockPassword.
+type MockPasswordMockRecorder struct {
+	mock *MockPassword
+}
+
+// NewMockPassword creates a new mock instance.
+func NewMockPassword(ctrl *gomock.Controller) *MockPassword {
+	mock := &MockPassword{ctrl: ctrl}
+	mock.recorder = &MockPasswordMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPassword) EXPECT() *MockPasswordMockRecorder {
+	return m.recorder
+}
+
+// ChangePassword mocks base method.
+func (m *MockPassword) ChangePassword(userId int, input storage.ChangePasswordInput) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChangePassword", userId, input)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ChangePassword indicates an expected call of ChangePassword.
+func (mr *MockPasswordMockRecorder) ChangePassword(userId, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangePassword", reflect.TypeOf((*MockPassword)(nil).ChangePassword), userId, input)
+}
+
+// Close mocks base method.
+func (m *MockPassword) Close() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Close")
+}
+
+// Close indicates an expected call of Close.
+func (mr *MockPasswordMockRecorder) Close() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockPassword)(nil).Close))
+}
+
+// RequestPasswordReset mocks base method.
+func (m *MockPassword) RequestPasswordReset(input storage.PasswordResetRequest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RequestPasswordReset", input)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RequestPasswordReset indicates an expected call of RequestPasswordReset.
+func (mr *MockPasswordMockRecorder) RequestPasswordReset(input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RequestPasswordReset", reflect.TypeOf((*MockPassword)(nil).RequestPasswordReset), input)
+}
+
+// ResetPassword mocks base method.
+func (m *MockPassword) ResetPassword(input storage.ResetPasswordInput) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResetPassword", input)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResetPassword indicates an expected call of ResetPassword.
+func (mr *MockPasswordMockRecorder) ResetPassword(input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetPassword", reflect.TypeOf((*MockPassword)(nil).ResetPassword), input)
+}
+
+// SetEmail mocks base method.
+func (m *MockPassword) SetEmail(userId int, input storage.EmailInput) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetEmail", userId, input)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetEmail indicates an expected call of SetEmail.
+func (mr *MockPasswordMockRecorder) SetEmail(userId, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetEmail", reflect.TypeOf((*MockPassword)(nil).SetEmail), userId, input)
+}
+
+// MockAPIKey is a mock of APIKey interface.
+type MockAPIKey struct {
+	ctrl     *gomock.Controller
+	recorder *MockAPIKeyMockRecorder
+}
+
+// MockAPIKeyMockRecorder is the mock recorder for MockAPIKey.
+type MockAPIKeyMockRecorder struct {
+	mock *MockAPIKey
+}
+
+// NewMockAPIKey creates a new mock instance.
+func NewMockAPIKey(ctrl *gomock.Controller) *MockAPIKey {
+	mock := &MockAPIKey{ctrl: ctrl}
+	mock.recorder = &MockAPIKeyMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAPIKey) EXPECT() *MockAPIKeyMockRecorder {
+	return m.recorder
+}
+
+// CreateAPIKey mocks base method.
+func (m *MockAPIKey) CreateAPIKey(userId int, input storage.APIKeyInput) (storage.NewAPIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAPIKey", userId, input)
+	ret0, _ := ret[0].(storage.NewAPIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateAPIKey indicates an expected call of CreateAPIKey.
+func (mr *MockAPIKeyMockRecorder) CreateAPIKey(userId, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAPIKey", reflect.TypeOf((*MockAPIKey)(nil).CreateAPIKey), userId, input)
+}
+
+// DeleteAPIKey mocks base method.
+func (m *MockAPIKey) DeleteAPIKey(userId, keyId int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteAPIKey", userId, keyId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteAPIKey indicates an expected call of DeleteAPIKey.
+func (mr *MockAPIKeyMockRecorder) DeleteAPIKey(userId, keyId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAPIKey", reflect.TypeOf((*MockAPIKey)(nil).DeleteAPIKey), userId, keyId)
+}
+
+// GetAPIKeys mocks base method.
+func (m *MockAPIKey) GetAPIKeys(userId int) ([]storage.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAPIKeys", userId)
+	ret0, _ := ret[0].([]storage.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAPIKeys indicates an expected call of GetAPIKeys.
+func (mr *MockAPIKeyMockRecorder) GetAPIKeys(userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAPIKeys", reflect.TypeOf((*MockAPIKey)(nil).GetAPIKeys), userId)
+}
+
+// ParseAPIKey mocks base method.
+func (m *MockAPIKey) ParseAPIKey(key string) (storage.APIKeyOwner, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ParseAPIKey", key)
+	ret0, _ := ret[0].(storage.APIKeyOwner)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ParseAPIKey indicates an expected call of ParseAPIKey.
+func (mr *MockAPIKeyMockRecorder) ParseAPIKey(key interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateRefreshToken", reflect.TypeOf((*MockAuthorization)(nil).UpdateRefreshToken), oldRefreshToken)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ParseAPIKey", reflect.TypeOf((*MockAPIKey)(nil).ParseAPIKey), key)
 }
 
 // MockAudio is a mock of Audio interface.
@@ -152,17 +427,18 @@ func (m *MockAudio) EXPECT() *MockAudioMockRecorder {
 }
 
 // AddDescription mocks base method.
-func (m *MockAudio) AddDescription(userID, audioId int, input storage.UpdateAudio) error {
+func (m *MockAudio) AddDescription(userID, audioId int, versions []int, input storage.UpdateAudio) (storage.Audio, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "AddDescription", userID, audioId, input)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret := m.ctrl.Call(m, "AddDescription", userID, audioId, versions, input)
+	ret0, _ := ret[0].(storage.Audio)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // AddDescription indicates an expected call of AddDescription.
-func (mr *MockAudioMockRecorder) AddDescription(userID, audioId, input interface{}) *gomock.Call {
+func (mr *MockAudioMockRecorder) AddDescription(userID, audioId, versions, input interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddDescription", reflect.TypeOf((*MockAudio)(nil).AddDescription), userID, audioId, input)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddDescription", reflect.TypeOf((*MockAudio)(nil).AddDescription), userID, audioId, versions, input)
 }
 
 // DownloadFile mocks base method.
@@ -180,6 +456,36 @@ func (mr *MockAudioMockRecorder) DownloadFile(userID, audioId interface{}) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DownloadFile", reflect.TypeOf((*MockAudio)(nil).DownloadFile), userID, audioId)
 }
 
+// GetAudio mocks base method.
+func (m *MockAudio) GetAudio(userID, audioId int) (storage.Audio, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAudio", userID, audioId)
+	ret0, _ := ret[0].(storage.Audio)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAudio indicates an expected call of GetAudio.
+func (mr *MockAudioMockRecorder) GetAudio(userID, audioId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAudio", reflect.TypeOf((*MockAudio)(nil).GetAudio), userID, audioId)
+}
+
+// GetAudioHistory mocks base method.
+func (m *MockAudio) GetAudioHistory(userID, audioId int, input storage.HistoryListParam) (storage.HistoryListJson, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAudioHistory", userID, audioId, input)
+	ret0, _ := ret[0].(storage.HistoryListJson)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAudioHistory indicates an expected call of GetAudioHistory.
+func (mr *MockAudioMockRecorder) GetAudioHistory(userID, audioId, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAudioHistory", reflect.TypeOf((*MockAudio)(nil).GetAudioHistory), userID, audioId, input)
+}
+
 // GetAudioList mocks base method.
 func (m *MockAudio) GetAudioList(userID int, input storage.AudioListParam) (storage.AudioListJson, error) {
 	m.ctrl.T.Helper()
@@ -195,136 +501,1332 @@ func (mr *MockAudioMockRecorder) GetAudioList(userID, input interface{}) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAudioList", reflect.TypeOf((*MockAudio)(nil).GetAudioList), userID, input)
 }
 
+// GetAudioPage mocks base method.
+func (m *MockAudio) GetAudioPage(userID int, input storage.AudioListParam) (storage.AudioPageJson, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAudioPage", userID, input)
+	ret0, _ := ret[0].(storage.AudioPageJson)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAudioPage indicates an expected call of GetAudioPage.
+func (mr *MockAudioMockRecorder) GetAudioPage(userID, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAudioPage", reflect.TypeOf((*MockAudio)(nil).GetAudioPage), userID, input)
+}
+
 // UploadFile mocks base method.
-func (m *MockAudio) UploadFile(userId int, path string) (int, error) {
+func (m *MockAudio) UploadFile(userId int, path string, size int64, metadata storage.AudioMetadata) (int, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UploadFile", userId, path)
+	ret := m.ctrl.Call(m, "UploadFile", userId, path, size, metadata)
 	ret0, _ := ret[0].(int)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // UploadFile indicates an expected call of UploadFile.
-func (mr *MockAudioMockRecorder) UploadFile(userId, path interface{}) *gomock.Call {
+func (mr *MockAudioMockRecorder) UploadFile(userId, path, size, metadata interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UploadFile", reflect.TypeOf((*MockAudio)(nil).UploadFile), userId, path)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UploadFile", reflect.TypeOf((*MockAudio)(nil).UploadFile), userId, path, size, metadata)
 }
 
-// MockShare is a mock of Share interface.
-type MockShare struct {
+// MockMetadataSchema is a mock of MetadataSchema interface.
+type MockMetadataSchema struct {
 	ctrl     *gomock.Controller
-	recorder *MockShareMockRecorder
+	recorder *MockMetadataSchemaMockRecorder
 }
 
-// MockShareMockRecorder is the mock recorder for MockShare.
-type MockShareMockRecorder struct {
-	mock *MockShare
+// MockMetadataSchemaMockRecorder is the mock recorder for MockMetadataSchema.
+type MockMetadataSchemaMockRecorder struct {
+	mock *MockMetadataSchema
 }
 
-// NewMockShare creates a new mock instance.
-func NewMockShare(ctrl *gomock.Controller) *MockShare {
-	mock := &MockShare{ctrl: ctrl}
-	mock.recorder = &MockShareMockRecorder{mock}
+// NewMockMetadataSchema creates a new mock instance.
+func NewMockMetadataSchema(ctrl *gomock.Controller) *MockMetadataSchema {
+	mock := &MockMetadataSchema{ctrl: ctrl}
+	mock.recorder = &MockMetadataSchemaMockRecorder{mock}
 	return mock
 }
 
 // EXPECT returns an object that allows the caller to indicate expected use.
-func (m *MockShare) EXPECT() *MockShareMockRecorder {
+func (m *MockMetadataSchema) EXPECT() *MockMetadataSchemaMockRecorder {
 	return m.recorder
 }
 
-// GetSharedList mocks base method.
-func (m *MockShare) GetSharedList(input storage.ShareListParam) (storage.ShareListJson, error) {
+// DeleteMetadataSchema mocks base method.
+func (m *MockMetadataSchema) DeleteMetadataSchema(userID int) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetSharedList", input)
-	ret0, _ := ret[0].(storage.ShareListJson)
-	ret1, _ := ret[1].(error)
-	return ret0, ret1
+	ret := m.ctrl.Call(m, "DeleteMetadataSchema", userID)
+	ret0, _ := ret[0].(error)
+	return ret0
 }
 
-// GetSharedList indicates an expected call of GetSharedList.
-func (mr *MockShareMockRecorder) GetSharedList(input interface{}) *gomock.Call {
+// DeleteMetadataSchema indicates an expected call of DeleteMetadataSchema.
+func (mr *MockMetadataSchemaMockRecorder) DeleteMetadataSchema(userID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSharedList", reflect.TypeOf((*MockShare)(nil).GetSharedList), input)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteMetadataSchema", reflect.TypeOf((*MockMetadataSchema)(nil).DeleteMetadataSchema), userID)
 }
 
-// ShareAudio mocks base method.
-func (m *MockShare) ShareAudio(userID, audioId, shareId int) error {
+// GetMetadataSchema mocks base method.
+func (m *MockMetadataSchema) GetMetadataSchema(userID int) (storage.MetadataSchema, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ShareAudio", userID, audioId, shareId)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret := m.ctrl.Call(m, "GetMetadataSchema", userID)
+	ret0, _ := ret[0].(storage.MetadataSchema)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
-// ShareAudio indicates an expected call of ShareAudio.
-func (mr *MockShareMockRecorder) ShareAudio(userID, audioId, shareId interface{}) *gomock.Call {
+// GetMetadataSchema indicates an expected call of GetMetadataSchema.
+func (mr *MockMetadataSchemaMockRecorder) GetMetadataSchema(userID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ShareAudio", reflect.TypeOf((*MockShare)(nil).ShareAudio), userID, audioId, shareId)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMetadataSchema", reflect.TypeOf((*MockMetadataSchema)(nil).GetMetadataSchema), userID)
 }
 
-// UnshareAudio mocks base method.
-func (m *MockShare) UnshareAudio(userID, audioId, shareId int) error {
+// SetMetadataSchema mocks base method.
+func (m *MockMetadataSchema) SetMetadataSchema(userID int, input storage.MetadataSchema) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UnshareAudio", userID, audioId, shareId)
+	ret := m.ctrl.Call(m, "SetMetadataSchema", userID, input)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// UnshareAudio indicates an expected call of UnshareAudio.
-func (mr *MockShareMockRecorder) UnshareAudio(userID, audioId, shareId interface{}) *gomock.Call {
+// SetMetadataSchema indicates an expected call of SetMetadataSchema.
+func (mr *MockMetadataSchemaMockRecorder) SetMetadataSchema(userID, input interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnshareAudio", reflect.TypeOf((*MockShare)(nil).UnshareAudio), userID, audioId, shareId)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetMetadataSchema", reflect.TypeOf((*MockMetadataSchema)(nil).SetMetadataSchema), userID, input)
 }
 
-// MockStorage is a mock of Storage interface.
-type MockStorage struct {
+// MockArtwork is a mock of Artwork interface.
+type MockArtwork struct {
 	ctrl     *gomock.Controller
-	recorder *MockStorageMockRecorder
+	recorder *MockArtworkMockRecorder
 }
 
-// MockStorageMockRecorder is the mock recorder for MockStorage.
-type MockStorageMockRecorder struct {
-	mock *MockStorage
+// MockArtworkMockRecorder is the mock recorder for MockArtwork.
+type MockArtworkMockRecorder struct {
+	mock *MockArtwork
 }
 
-// NewMockStorage creates a new mock instance.
-func NewMockStorage(ctrl *gomock.Controller) *MockStorage {
-	mock := &MockStorage{ctrl: ctrl}
-	mock.recorder = &MockStorageMockRecorder{mock}
+// NewMockArtwork creates a new mock instance.
+func NewMockArtwork(ctrl *gomock.Controller) *MockArtwork {
+	mock := &MockArtwork{ctrl: ctrl}
+	mock.recorder = &MockArtworkMockRecorder{mock}
 	return mock
 }
 
 // EXPECT returns an object that allows the caller to indicate expected use.
-func (m *MockStorage) EXPECT() *MockStorageMockRecorder {
+func (m *MockArtwork) EXPECT() *MockArtworkMockRecorder {
 	return m.recorder
 }
 
-// GetFile mocks base method.
-func (m *MockStorage) GetFile(fileId uuid.UUID) (io.ReadCloser, int64, error) {
+// DeleteAudioArtwork mocks base method.
+func (m *MockArtwork) DeleteAudioArtwork(userID, audioId int) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetFile", fileId)
-	ret0, _ := ret[0].(io.ReadCloser)
-	ret1, _ := ret[1].(int64)
-	ret2, _ := ret[2].(error)
-	return ret0, ret1, ret2
+	ret := m.ctrl.Call(m, "DeleteAudioArtwork", userID, audioId)
+	ret0, _ := ret[0].(error)
+	return ret0
 }
 
-// GetFile indicates an expected call of GetFile.
-func (mr *MockStorageMockRecorder) GetFile(fileId interface{}) *gomock.Call {
+// DeleteAudioArtwork indicates an expected call of DeleteAudioArtwork.
+func (mr *MockArtworkMockRecorder) DeleteAudioArtwork(userID, audioId interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFile", reflect.TypeOf((*MockStorage)(nil).GetFile), fileId)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAudioArtwork", reflect.TypeOf((*MockArtwork)(nil).DeleteAudioArtwork), userID, audioId)
 }
 
-// StoreFile mocks base method.
-func (m *MockStorage) StoreFile(fileId uuid.UUID, file io.ReadSeeker) error {
+// DeleteCollectionArtwork mocks base method.
+func (m *MockArtwork) DeleteCollectionArtwork(userID, collectionId int) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "StoreFile", fileId, file)
+	ret := m.ctrl.Call(m, "DeleteCollectionArtwork", userID, collectionId)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
+// DeleteCollectionArtwork indicates an expected call of DeleteCollectionArtwork.
+func (mr *MockArtworkMockRecorder) DeleteCollectionArtwork(userID, collectionId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteCollectionArtwork", reflect.TypeOf((*MockArtwork)(nil).DeleteCollectionArtwork), userID, collectionId)
+}
+
+// GetAudioArtwork mocks base method.
+func (m *MockArtwork) GetAudioArtwork(userID, audioId int, size string) (storage.Artwork, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAudioArtwork", userID, audioId, size)
+	ret0, _ := ret[0].(storage.Artwork)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAudioArtwork indicates an expected call of GetAudioArtwork.
+func (mr *MockArtworkMockRecorder) GetAudioArtwork(userID, audioId, size interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAudioArtwork", reflect.TypeOf((*MockArtwork)(nil).GetAudioArtwork), userID, audioId, size)
+}
+
+// GetCollectionArtwork mocks base method.
+func (m *MockArtwork) GetCollectionArtwork(userID, collectionId int, size string) (storage.Artwork, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCollectionArtwork", userID, collectionId, size)
+	ret0, _ := ret[0].(storage.Artwork)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCollectionArtwork indicates an expected call of GetCollectionArtwork.
+func (mr *MockArtworkMockRecorder) GetCollectionArtwork(userID, collectionId, size interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCollectionArtwork", reflect.TypeOf((*MockArtwork)(nil).GetCollectionArtwork), userID, collectionId, size)
+}
+
+// SetAudioArtwork mocks base method.
+func (m *MockArtwork) SetAudioArtwork(userID, audioId int, image []byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetAudioArtwork", userID, audioId, image)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetAudioArtwork indicates an expected call of SetAudioArtwork.
+func (mr *MockArtworkMockRecorder) SetAudioArtwork(userID, audioId, image interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetAudioArtwork", reflect.TypeOf((*MockArtwork)(nil).SetAudioArtwork), userID, audioId, image)
+}
+
+// SetCollectionArtwork mocks base method.
+func (m *MockArtwork) SetCollectionArtwork(userID, collectionId int, image []byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetCollectionArtwork", userID, collectionId, image)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetCollectionArtwork indicates an expected call of SetCollectionArtwork.
+func (mr *MockArtworkMockRecorder) SetCollectionArtwork(userID, collectionId, image interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetCollectionArtwork", reflect.TypeOf((*MockArtwork)(nil).SetCollectionArtwork), userID, collectionId, image)
+}
+
+// MockComment is a mock of Comment interface.
+type MockComment struct {
+	ctrl     *gomock.Controller
+	recorder *MockCommentMockRecorder
+}
+
+// MockCommentMockRecorder is the mock recorder for MockComment.
+type MockCommentMockRecorder struct {
+	mock *MockComment
+}
+
+// NewMockComment creates a new mock instance.
+func NewMockComment(ctrl *gomock.Controller) *MockComment {
+	mock := &MockComment{ctrl: ctrl}
+	mock.recorder = &MockCommentMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockComment) EXPECT() *MockCommentMockRecorder {
+	return m.recorder
+}
+
+// AddComment mocks base method.
+func (m *MockComment) AddComment(userID, audioId int, input storage.CommentInput) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddComment", userID, audioId, input)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddComment indicates an expected call of AddComment.
+func (mr *MockCommentMockRecorder) AddComment(userID, audioId, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddComment", reflect.TypeOf((*MockComment)(nil).AddComment), userID, audioId, input)
+}
+
+// DeleteComment mocks base method.
+func (m *MockComment) DeleteComment(userID, audioId, commentId int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteComment", userID, audioId, commentId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteComment indicates an expected call of DeleteComment.
+func (mr *MockCommentMockRecorder) DeleteComment(userID, audioId, commentId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteComment", reflect.TypeOf((*MockComment)(nil).DeleteComment), userID, audioId, commentId)
+}
+
+// GetComments mocks base method.
+func (m *MockComment) GetComments(userID, audioId int, input storage.CommentListParam) (storage.CommentListJson, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetComments", userID, audioId, input)
+	ret0, _ := ret[0].(storage.CommentListJson)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetComments indicates an expected call of GetComments.
+func (mr *MockCommentMockRecorder) GetComments(userID, audioId, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetComments", reflect.TypeOf((*MockComment)(nil).GetComments), userID, audioId, input)
+}
+
+// UpdateComment mocks base method.
+func (m *MockComment) UpdateComment(userID, audioId, commentId int, input storage.CommentUpdate) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateComment", userID, audioId, commentId, input)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateComment indicates an expected call of UpdateComment.
+func (mr *MockCommentMockRecorder) UpdateComment(userID, audioId, commentId, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateComment", reflect.TypeOf((*MockComment)(nil).UpdateComment), userID, audioId, commentId, input)
+}
+
+// MockChapter is a mock of Chapter interface.
+type MockChapter struct {
+	ctrl     *gomock.Controller
+	recorder *MockChapterMockRecorder
+}
+
+// MockChapterMockRecorder is the mock recorder for MockChapter.
+type MockChapterMockRecorder struct {
+	mock *MockChapter
+}
+
+// NewMockChapter creates a new mock instance.
+func NewMockChapter(ctrl *gomock.Controller) *MockChapter {
+	mock := &MockChapter{ctrl: ctrl}
+	mock.recorder = &MockChapterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockChapter) EXPECT() *MockChapterMockRecorder {
+	return m.recorder
+}
+
+// GetChapters mocks base method.
+func (m *MockChapter) GetChapters(userID, audioId int) (storage.AudioChapters, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetChapters", userID, audioId)
+	ret0, _ := ret[0].(storage.AudioChapters)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetChapters indicates an expected call of GetChapters.
+func (mr *MockChapterMockRecorder) GetChapters(userID, audioId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetChapters", reflect.TypeOf((*MockChapter)(nil).GetChapters), userID, audioId)
+}
+
+// SetChapters mocks base method.
+func (m *MockChapter) SetChapters(userID, audioId int, chapters []storage.Chapter) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetChapters", userID, audioId, chapters)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetChapters indicates an expected call of SetChapters.
+func (mr *MockChapterMockRecorder) SetChapters(userID, audioId, chapters interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetChapters", reflect.TypeOf((*MockChapter)(nil).SetChapters), userID, audioId, chapters)
+}
+
+// MockTranscript is a mock of Transcript interface.
+type MockTranscript struct {
+	ctrl     *gomock.Controller
+	recorder *MockTranscriptMockRecorder
+}
+
+// MockTranscriptMockRecorder is the mock recorder for MockTranscript.
+type MockTranscriptMockRecorder struct {
+	mock *MockTranscript
+}
+
+// NewMockTranscript creates a new mock instance.
+func NewMockTranscript(ctrl *gomock.Controller) *MockTranscript {
+	mock := &MockTranscript{ctrl: ctrl}
+	mock.recorder = &MockTranscriptMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTranscript) EXPECT() *MockTranscriptMockRecorder {
+	return m.recorder
+}
+
+// DeleteTranscript mocks base method.
+func (m *MockTranscript) DeleteTranscript(userID, audioId int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteTranscript", userID, audioId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteTranscript indicates an expected call of DeleteTranscript.
+func (mr *MockTranscriptMockRecorder) DeleteTranscript(userID, audioId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteTranscript", reflect.TypeOf((*MockTranscript)(nil).DeleteTranscript), userID, audioId)
+}
+
+// GetTranscript mocks base method.
+func (m *MockTranscript) GetTranscript(userID, audioId int) (storage.Transcript, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTranscript", userID, audioId)
+	ret0, _ := ret[0].(storage.Transcript)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTranscript indicates an expected call of GetTranscript.
+func (mr *MockTranscriptMockRecorder) GetTranscript(userID, audioId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTranscript", reflect.TypeOf((*MockTranscript)(nil).GetTranscript), userID, audioId)
+}
+
+// SetTranscript mocks base method.
+func (m *MockTranscript) SetTranscript(userID, audioId int, transcript storage.Transcript) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetTranscript", userID, audioId, transcript)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetTranscript indicates an expected call of SetTranscript.
+func (mr *MockTranscriptMockRecorder) SetTranscript(userID, audioId, transcript interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetTranscript", reflect.TypeOf((*MockTranscript)(nil).SetTranscript), userID, audioId, transcript)
+}
+
+// MockTranscription is a mock of Transcription interface.
+type MockTranscription struct {
+	ctrl     *gomock.Controller
+	recorder *MockTranscriptionMockRecorder
+}
+
+// MockTranscriptionMockRecorder is the mock recorder for MockTranscription.
+type MockTranscriptionMockRecorder struct {
+	mock *MockTranscription
+}
+
+// NewMockTranscription creates a new mock instance.
+func NewMockTranscription(ctrl *gomock.Controller) *MockTranscription {
+	mock := &MockTranscription{ctrl: ctrl}
+	mock.recorder = &MockTranscriptionMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTranscription) EXPECT() *MockTranscriptionMockRecorder {
+	return m.recorder
+}
+
+// GetTranscription mocks base method.
+func (m *MockTranscription) GetTranscription(userID, audioId int) (storage.TranscriptionJob, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTranscription", userID, audioId)
+	ret0, _ := ret[0].(storage.TranscriptionJob)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTranscription indicates an expected call of GetTranscription.
+func (mr *MockTranscriptionMockRecorder) GetTranscription(userID, audioId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTranscription", reflect.TypeOf((*MockTranscription)(nil).GetTranscription), userID, audioId)
+}
+
+// StartTranscription mocks base method.
+func (m *MockTranscription) StartTranscription(userID, audioId int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StartTranscription", userID, audioId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StartTranscription indicates an expected call of StartTranscription.
+func (mr *MockTranscriptionMockRecorder) StartTranscription(userID, audioId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartTranscription", reflect.TypeOf((*MockTranscription)(nil).StartTranscription), userID, audioId)
+}
+
+// MockJob is a mock of Job interface.
+type MockJob struct {
+	ctrl     *gomock.Controller
+	recorder *MockJobMockRecorder
+}
+
+// MockJobMockRecorder is the mock recorder for MockJob.
+type MockJobMockRecorder struct {
+	mock *MockJob
+}
+
+// NewMockJob creates a new mock instance.
+func NewMockJob(ctrl *gomock.Controller) *MockJob {
+	mock := &MockJob{ctrl: ctrl}
+	mock.recorder = &MockJobMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockJob) EXPECT() *MockJobMockRecorder {
+	return m.recorder
+}
+
+// EnqueueJob mocks base method.
+func (m *MockJob) EnqueueJob(input storage.JobInput) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnqueueJob", input)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EnqueueJob indicates an expected call of EnqueueJob.
+func (mr *MockJobMockRecorder) EnqueueJob(input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnqueueJob", reflect.TypeOf((*MockJob)(nil).EnqueueJob), input)
+}
+
+// GetAudioJobs mocks base method.
+func (m *MockJob) GetAudioJobs(userID, audioId int) ([]storage.Job, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAudioJobs", userID, audioId)
+	ret0, _ := ret[0].([]storage.Job)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAudioJobs indicates an expected call of GetAudioJobs.
+func (mr *MockJobMockRecorder) GetAudioJobs(userID, audioId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAudioJobs", reflect.TypeOf((*MockJob)(nil).GetAudioJobs), userID, audioId)
+}
+
+// GetJobs mocks base method.
+func (m *MockJob) GetJobs(input storage.JobListParam) (storage.JobListJson, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetJobs", input)
+	ret0, _ := ret[0].(storage.JobListJson)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetJobs indicates an expected call of GetJobs.
+func (mr *MockJobMockRecorder) GetJobs(input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetJobs", reflect.TypeOf((*MockJob)(nil).GetJobs), input)
+}
+
+// ProcessAudio mocks base method.
+func (m *MockJob) ProcessAudio(audioId int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ProcessAudio", audioId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ProcessAudio indicates an expected call of ProcessAudio.
+func (mr *MockJobMockRecorder) ProcessAudio(audioId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ProcessAudio", reflect.TypeOf((*MockJob)(nil).ProcessAudio), audioId)
+}
+
+// RetryJob mocks base method.
+func (m *MockJob) RetryJob(jobId int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RetryJob", jobId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RetryJob indicates an expected call of RetryJob.
+func (mr *MockJobMockRecorder) RetryJob(jobId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RetryJob", reflect.TypeOf((*MockJob)(nil).RetryJob), jobId)
+}
+
+// Run mocks base method.
+func (m *MockJob) Run(ctx context.Context) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Run", ctx)
+}
+
+// Run indicates an expected call of Run.
+func (mr *MockJobMockRecorder) Run(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Run", reflect.TypeOf((*MockJob)(nil).Run), ctx)
+}
+
+// MockHLS is a mock of HLS interface.
+type MockHLS struct {
+	ctrl     *gomock.Controller
+	recorder *MockHLSMockRecorder
+}
+
+// MockHLSMockRecorder is the mock recorder for MockHLS.
+type MockHLSMockRecorder struct {
+	mock *MockHLS
+}
+
+// NewMockHLS creates a new mock instance.
+func NewMockHLS(ctrl *gomock.Controller) *MockHLS {
+	mock := &MockHLS{ctrl: ctrl}
+	mock.recorder = &MockHLSMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockHLS) EXPECT() *MockHLSMockRecorder {
+	return m.recorder
+}
+
+// GetHLSFile mocks base method.
+func (m *MockHLS) GetHLSFile(userID, audioId int, name string) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetHLSFile", userID, audioId, name)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetHLSFile indicates an expected call of GetHLSFile.
+func (mr *MockHLSMockRecorder) GetHLSFile(userID, audioId, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetHLSFile", reflect.TypeOf((*MockHLS)(nil).GetHLSFile), userID, audioId, name)
+}
+
+// MockShare is a mock of Share interface.
+type MockShare struct {
+	ctrl     *gomock.Controller
+	recorder *MockShareMockRecorder
+}
+
+// MockShareMockRecorder is the mock recorder for MockShare.
+type MockShareMockRecorder struct {
+	mock *MockShare
+}
+
+// NewMockShare creates a new mock instance.
+func NewMockShare(ctrl *gomock.Controller) *MockShare {
+	mock := &MockShare{ctrl: ctrl}
+	mock.recorder = &MockShareMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockShare) EXPECT() *MockShareMockRecorder {
+	return m.recorder
+}
+
+// GetSharedList mocks base method.
+func (m *MockShare) GetSharedList(input storage.ShareListParam) (storage.ShareListJson, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSharedList", input)
+	ret0, _ := ret[0].(storage.ShareListJson)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSharedList indicates an expected call of GetSharedList.
+func (mr *MockShareMockRecorder) GetSharedList(input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSharedList", reflect.TypeOf((*MockShare)(nil).GetSharedList), input)
+}
+
+// GetSharedPage mocks base method.
+func (m *MockShare) GetSharedPage(input storage.ShareListParam) (storage.SharePageJson, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSharedPage", input)
+	ret0, _ := ret[0].(storage.SharePageJson)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSharedPage indicates an expected call of GetSharedPage.
+func (mr *MockShareMockRecorder) GetSharedPage(input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSharedPage", reflect.TypeOf((*MockShare)(nil).GetSharedPage), input)
+}
+
+// ShareAudio mocks base method.
+func (m *MockShare) ShareAudio(userID, audioId, shareId int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ShareAudio", userID, audioId, shareId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ShareAudio indicates an expected call of ShareAudio.
+func (mr *MockShareMockRecorder) ShareAudio(userID, audioId, shareId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ShareAudio", reflect.TypeOf((*MockShare)(nil).ShareAudio), userID, audioId, shareId)
+}
+
+// UnshareAudio mocks base method.
+func (m *MockShare) UnshareAudio(userID, audioId, shareId int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnshareAudio", userID, audioId, shareId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UnshareAudio indicates an expected call of UnshareAudio.
+func (mr *MockShareMockRecorder) UnshareAudio(userID, audioId, shareId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnshareAudio", reflect.TypeOf((*MockShare)(nil).UnshareAudio), userID, audioId, shareId)
+}
+
+// MockInvitation is a mock of Invitation interface.
+type MockInvitation struct {
+	ctrl     *gomock.Controller
+	recorder *MockInvitationMockRecorder
+}
+
+// MockInvitationMockRecorder is the mock recorder for MockInvitation.
+type MockInvitationMockRecorder struct {
+	mock *MockInvitation
+}
+
+// NewMockInvitation creates a new mock instance.
+func NewMockInvitation(ctrl *gomock.Controller) *MockInvitation {
+	mock := &MockInvitation{ctrl: ctrl}
+	mock.recorder = &MockInvitationMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockInvitation) EXPECT() *MockInvitationMockRecorder {
+	return m.recorder
+}
+
+// AcceptCollectionInvitation mocks base method.
+func (m *MockInvitation) AcceptCollectionInvitation(userID, collectionId int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AcceptCollectionInvitation", userID, collectionId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AcceptCollectionInvitation indicates an expected call of AcceptCollectionInvitation.
+func (mr *MockInvitationMockRecorder) AcceptCollectionInvitation(userID, collectionId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AcceptCollectionInvitation", reflect.TypeOf((*MockInvitation)(nil).AcceptCollectionInvitation), userID, collectionId)
+}
+
+// AcceptInvitation mocks base method.
+func (m *MockInvitation) AcceptInvitation(userID, audioId int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AcceptInvitation", userID, audioId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AcceptInvitation indicates an expected call of AcceptInvitation.
+func (mr *MockInvitationMockRecorder) AcceptInvitation(userID, audioId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AcceptInvitation", reflect.TypeOf((*MockInvitation)(nil).AcceptInvitation), userID, audioId)
+}
+
+// AddAutoAccept mocks base method.
+func (m *MockInvitation) AddAutoAccept(userID, senderId int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddAutoAccept", userID, senderId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddAutoAccept indicates an expected call of AddAutoAccept.
+func (mr *MockInvitationMockRecorder) AddAutoAccept(userID, senderId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddAutoAccept", reflect.TypeOf((*MockInvitation)(nil).AddAutoAccept), userID, senderId)
+}
+
+// BlockSender mocks base method.
+func (m *MockInvitation) BlockSender(userID, senderId int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BlockSender", userID, senderId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// BlockSender indicates an expected call of BlockSender.
+func (mr *MockInvitationMockRecorder) BlockSender(userID, senderId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BlockSender", reflect.TypeOf((*MockInvitation)(nil).BlockSender), userID, senderId)
+}
+
+// DeclineCollectionInvitation mocks base method.
+func (m *MockInvitation) DeclineCollectionInvitation(userID, collectionId int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeclineCollectionInvitation", userID, collectionId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeclineCollectionInvitation indicates an expected call of DeclineCollectionInvitation.
+func (mr *MockInvitationMockRecorder) DeclineCollectionInvitation(userID, collectionId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeclineCollectionInvitation", reflect.TypeOf((*MockInvitation)(nil).DeclineCollectionInvitation), userID, collectionId)
+}
+
+// DeclineInvitation mocks base method.
+func (m *MockInvitation) DeclineInvitation(userID, audioId int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeclineInvitation", userID, audioId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeclineInvitation indicates an expected call of DeclineInvitation.
+func (mr *MockInvitationMockRecorder) DeclineInvitation(userID, audioId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeclineInvitation", reflect.TypeOf((*MockInvitation)(nil).DeclineInvitation), userID, audioId)
+}
+
+// GetAutoAcceptSenders mocks base method.
+func (m *MockInvitation) GetAutoAcceptSenders(userID int) ([]storage.Sender, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAutoAcceptSenders", userID)
+	ret0, _ := ret[0].([]storage.Sender)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAutoAcceptSenders indicates an expected call of GetAutoAcceptSenders.
+func (mr *MockInvitationMockRecorder) GetAutoAcceptSenders(userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAutoAcceptSenders", reflect.TypeOf((*MockInvitation)(nil).GetAutoAcceptSenders), userID)
+}
+
+// GetBlockedSenders mocks base method.
+func (m *MockInvitation) GetBlockedSenders(userID int) ([]storage.Sender, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBlockedSenders", userID)
+	ret0, _ := ret[0].([]storage.Sender)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBlockedSenders indicates an expected call of GetBlockedSenders.
+func (mr *MockInvitationMockRecorder) GetBlockedSenders(userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBlockedSenders", reflect.TypeOf((*MockInvitation)(nil).GetBlockedSenders), userID)
+}
+
+// GetInvitations mocks base method.
+func (m *MockInvitation) GetInvitations(userID int, input storage.InvitationListParam) (storage.InvitationListJson, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetInvitations", userID, input)
+	ret0, _ := ret[0].(storage.InvitationListJson)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetInvitations indicates an expected call of GetInvitations.
+func (mr *MockInvitationMockRecorder) GetInvitations(userID, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetInvitations", reflect.TypeOf((*MockInvitation)(nil).GetInvitations), userID, input)
+}
+
+// RemoveAutoAccept mocks base method.
+func (m *MockInvitation) RemoveAutoAccept(userID, senderId int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveAutoAccept", userID, senderId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveAutoAccept indicates an expected call of RemoveAutoAccept.
+func (mr *MockInvitationMockRecorder) RemoveAutoAccept(userID, senderId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveAutoAccept", reflect.TypeOf((*MockInvitation)(nil).RemoveAutoAccept), userID, senderId)
+}
+
+// UnblockSender mocks base method.
+func (m *MockInvitation) UnblockSender(userID, senderId int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnblockSender", userID, senderId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UnblockSender indicates an expected call of UnblockSender.
+func (mr *MockInvitationMockRecorder) UnblockSender(userID, senderId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnblockSender", reflect.TypeOf((*MockInvitation)(nil).UnblockSender), userID, senderId)
+}
+
+// MockCollection is a mock of Collection interface.
+type MockCollection struct {
+	ctrl     *gomock.Controller
+	recorder *MockCollectionMockRecorder
+}
+
+// MockCollectionMockRecorder is the mock recorder for MockCollection.
+type MockCollectionMockRecorder struct {
+	mock *MockCollection
+}
+
+// NewMockCollection creates a new mock instance.
+func NewMockCollection(ctrl *gomock.Controller) *MockCollection {
+	mock := &MockCollection{ctrl: ctrl}
+	mock.recorder = &MockCollectionMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCollection) EXPECT() *MockCollectionMockRecorder {
+	return m.recorder
+}
+
+// AddCollectionItem mocks base method.
+func (m *MockCollection) AddCollectionItem(userID, collectionId, audioId int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddCollectionItem", userID, collectionId, audioId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddCollectionItem indicates an expected call of AddCollectionItem.
+func (mr *MockCollectionMockRecorder) AddCollectionItem(userID, collectionId, audioId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddCollectionItem", reflect.TypeOf((*MockCollection)(nil).AddCollectionItem), userID, collectionId, audioId)
+}
+
+// CreateCollection mocks base method.
+func (m *MockCollection) CreateCollection(userID int, input storage.CollectionInput) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateCollection", userID, input)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateCollection indicates an expected call of CreateCollection.
+func (mr *MockCollectionMockRecorder) CreateCollection(userID, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateCollection", reflect.TypeOf((*MockCollection)(nil).CreateCollection), userID, input)
+}
+
+// DeleteCollection mocks base method.
+func (m *MockCollection) DeleteCollection(userID, collectionId int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteCollection", userID, collectionId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteCollection indicates an expected call of DeleteCollection.
+func (mr *MockCollectionMockRecorder) DeleteCollection(userID, collectionId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteCollection", reflect.TypeOf((*MockCollection)(nil).DeleteCollection), userID, collectionId)
+}
+
+// GetCollectionItems mocks base method.
+func (m *MockCollection) GetCollectionItems(userID, collectionId int, input storage.CollectionItemsParam) (storage.CollectionItemsJson, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCollectionItems", userID, collectionId, input)
+	ret0, _ := ret[0].(storage.CollectionItemsJson)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCollectionItems indicates an expected call of GetCollectionItems.
+func (mr *MockCollectionMockRecorder) GetCollectionItems(userID, collectionId, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCollectionItems", reflect.TypeOf((*MockCollection)(nil).GetCollectionItems), userID, collectionId, input)
+}
+
+// GetCollectionList mocks base method.
+func (m *MockCollection) GetCollectionList(userID int, input storage.CollectionListParam) (storage.CollectionListJson, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCollectionList", userID, input)
+	ret0, _ := ret[0].(storage.CollectionListJson)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCollectionList indicates an expected call of GetCollectionList.
+func (mr *MockCollectionMockRecorder) GetCollectionList(userID, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCollectionList", reflect.TypeOf((*MockCollection)(nil).GetCollectionList), userID, input)
+}
+
+// RemoveCollectionItem mocks base method.
+func (m *MockCollection) RemoveCollectionItem(userID, collectionId, audioId int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveCollectionItem", userID, collectionId, audioId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveCollectionItem indicates an expected call of RemoveCollectionItem.
+func (mr *MockCollectionMockRecorder) RemoveCollectionItem(userID, collectionId, audioId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveCollectionItem", reflect.TypeOf((*MockCollection)(nil).RemoveCollectionItem), userID, collectionId, audioId)
+}
+
+// ReorderCollection mocks base method.
+func (m *MockCollection) ReorderCollection(userID, collectionId int, audioIds []int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReorderCollection", userID, collectionId, audioIds)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReorderCollection indicates an expected call of ReorderCollection.
+func (mr *MockCollectionMockRecorder) ReorderCollection(userID, collectionId, audioIds interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReorderCollection", reflect.TypeOf((*MockCollection)(nil).ReorderCollection), userID, collectionId, audioIds)
+}
+
+// ShareCollection mocks base method.
+func (m *MockCollection) ShareCollection(userID, collectionId, shareId int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ShareCollection", userID, collectionId, shareId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ShareCollection indicates an expected call of ShareCollection.
+func (mr *MockCollectionMockRecorder) ShareCollection(userID, collectionId, shareId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ShareCollection", reflect.TypeOf((*MockCollection)(nil).ShareCollection), userID, collectionId, shareId)
+}
+
+// UnshareCollection mocks base method.
+func (m *MockCollection) UnshareCollection(userID, collectionId, shareId int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnshareCollection", userID, collectionId, shareId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UnshareCollection indicates an expected call of UnshareCollection.
+func (mr *MockCollectionMockRecorder) UnshareCollection(userID, collectionId, shareId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnshareCollection", reflect.TypeOf((*MockCollection)(nil).UnshareCollection), userID, collectionId, shareId)
+}
+
+// UpdateCollection mocks base method.
+func (m *MockCollection) UpdateCollection(userID, collectionId int, input storage.CollectionInput) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateCollection", userID, collectionId, input)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateCollection indicates an expected call of UpdateCollection.
+func (mr *MockCollectionMockRecorder) UpdateCollection(userID, collectionId, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateCollection", reflect.TypeOf((*MockCollection)(nil).UpdateCollection), userID, collectionId, input)
+}
+
+// MockFeed is a mock of Feed interface.
+type MockFeed struct {
+	ctrl     *gomock.Controller
+	recorder *MockFeedMockRecorder
+}
+
+// MockFeedMockRecorder is the mock recorder for MockFeed.
+type MockFeedMockRecorder struct {
+	mock *MockFeed
+}
+
+// NewMockFeed creates a new mock instance.
+func NewMockFeed(ctrl *gomock.Controller) *MockFeed {
+	mock := &MockFeed{ctrl: ctrl}
+	mock.recorder = &MockFeedMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockFeed) EXPECT() *MockFeedMockRecorder {
+	return m.recorder
+}
+
+// DeleteFeed mocks base method.
+func (m *MockFeed) DeleteFeed(userID, collectionId int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteFeed", userID, collectionId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteFeed indicates an expected call of DeleteFeed.
+func (mr *MockFeedMockRecorder) DeleteFeed(userID, collectionId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteFeed", reflect.TypeOf((*MockFeed)(nil).DeleteFeed), userID, collectionId)
+}
+
+// GetFeed mocks base method.
+func (m *MockFeed) GetFeed(token string) (storage.RssFeed, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFeed", token)
+	ret0, _ := ret[0].(storage.RssFeed)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFeed indicates an expected call of GetFeed.
+func (mr *MockFeedMockRecorder) GetFeed(token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFeed", reflect.TypeOf((*MockFeed)(nil).GetFeed), token)
+}
+
+// GetFeedSettings mocks base method.
+func (m *MockFeed) GetFeedSettings(userID, collectionId int) (storage.FeedSettings, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFeedSettings", userID, collectionId)
+	ret0, _ := ret[0].(storage.FeedSettings)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFeedSettings indicates an expected call of GetFeedSettings.
+func (mr *MockFeedMockRecorder) GetFeedSettings(userID, collectionId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFeedSettings", reflect.TypeOf((*MockFeed)(nil).GetFeedSettings), userID, collectionId)
+}
+
+// GetSignedFile mocks base method.
+func (m *MockFeed) GetSignedFile(audioId, collectionId int, token string, expires int64, signature string) (storage.DownloadAudio, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSignedFile", audioId, collectionId, token, expires, signature)
+	ret0, _ := ret[0].(storage.DownloadAudio)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSignedFile indicates an expected call of GetSignedFile.
+func (mr *MockFeedMockRecorder) GetSignedFile(audioId, collectionId, token, expires, signature interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSignedFile", reflect.TypeOf((*MockFeed)(nil).GetSignedFile), audioId, collectionId, token, expires, signature)
+}
+
+// PublishFeed mocks base method.
+func (m *MockFeed) PublishFeed(userID, collectionId int, input storage.FeedInput) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PublishFeed", userID, collectionId, input)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PublishFeed indicates an expected call of PublishFeed.
+func (mr *MockFeedMockRecorder) PublishFeed(userID, collectionId, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PublishFeed", reflect.TypeOf((*MockFeed)(nil).PublishFeed), userID, collectionId, input)
+}
+
+// RotateFeedToken mocks base method.
+func (m *MockFeed) RotateFeedToken(userID, collectionId int) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RotateFeedToken", userID, collectionId)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RotateFeedToken indicates an expected call of RotateFeedToken.
+func (mr *MockFeedMockRecorder) RotateFeedToken(userID, collectionId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RotateFeedToken", reflect.TypeOf((*MockFeed)(nil).RotateFeedToken), userID, collectionId)
+}
+
+// MockTag is a mock of Tag interface.
+type MockTag struct {
+	ctrl     *gomock.Controller
+	recorder *MockTagMockRecorder
+}
+
+// MockTagMockRecorder is the mock recorder for MockTag.
+type MockTagMockRecorder struct {
+	mock *MockTag
+}
+
+// NewMockTag creates a new mock instance.
+func NewMockTag(ctrl *gomock.Controller) *MockTag {
+	mock := &MockTag{ctrl: ctrl}
+	mock.recorder = &MockTagMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTag) EXPECT() *MockTagMockRecorder {
+	return m.recorder
+}
+
+// AddTags mocks base method.
+func (m *MockTag) AddTags(userID, audioId int, input storage.TagsInput) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddTags", userID, audioId, input)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddTags indicates an expected call of AddTags.
+func (mr *MockTagMockRecorder) AddTags(userID, audioId, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddTags", reflect.TypeOf((*MockTag)(nil).AddTags), userID, audioId, input)
+}
+
+// GetAudioTags mocks base method.
+func (m *MockTag) GetAudioTags(userID, audioId int) ([]storage.AudioTag, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAudioTags", userID, audioId)
+	ret0, _ := ret[0].([]storage.AudioTag)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAudioTags indicates an expected call of GetAudioTags.
+func (mr *MockTagMockRecorder) GetAudioTags(userID, audioId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAudioTags", reflect.TypeOf((*MockTag)(nil).GetAudioTags), userID, audioId)
+}
+
+// GetTags mocks base method.
+func (m *MockTag) GetTags(userID int, input storage.TagListParam) ([]storage.TagCount, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTags", userID, input)
+	ret0, _ := ret[0].([]storage.TagCount)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTags indicates an expected call of GetTags.
+func (mr *MockTagMockRecorder) GetTags(userID, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTags", reflect.TypeOf((*MockTag)(nil).GetTags), userID, input)
+}
+
+// RemoveTag mocks base method.
+func (m *MockTag) RemoveTag(userID, audioId int, tag string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveTag", userID, audioId, tag)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveTag indicates an expected call of RemoveTag.
+func (mr *MockTagMockRecorder) RemoveTag(userID, audioId, tag interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveTag", reflect.TypeOf((*MockTag)(nil).RemoveTag), userID, audioId, tag)
+}
+
+// MockSearch is a mock of Search interface.
+type MockSearch struct {
+	ctrl     *gomock.Controller
+	recorder *MockSearchMockRecorder
+}
+
+// MockSearchMockRecorder is the mock recorder for MockSearch.
+type MockSearchMockRecorder struct {
+	mock *MockSearch
+}
+
+// NewMockSearch creates a new mock instance.
+func NewMockSearch(ctrl *gomock.Controller) *MockSearch {
+	mock := &MockSearch{ctrl: ctrl}
+	mock.recorder = &MockSearchMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSearch) EXPECT() *MockSearchMockRecorder {
+	return m.recorder
+}
+
+// SearchAudio mocks base method.
+func (m *MockSearch) SearchAudio(userID int, input storage.SearchParam) (storage.SearchResultJson, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SearchAudio", userID, input)
+	ret0, _ := ret[0].(storage.SearchResultJson)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SearchAudio indicates an expected call of SearchAudio.
+func (mr *MockSearchMockRecorder) SearchAudio(userID, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchAudio", reflect.TypeOf((*MockSearch)(nil).SearchAudio), userID, input)
+}
+
+// MockStorage is a mock of Storage interface.
+type MockStorage struct {
+	ctrl     *gomock.Controller
+	recorder *MockStorageMockRecorder
+}
+
+// MockStorageMockRecorder is the mock recorder for MockStorage.
+type MockStorageMockRecorder struct {
+	mock *MockStorage
+}
+
+// NewMockStorage creates a new mock instance.
+func NewMockStorage(ctrl *gomock.Controller) *MockStorage {
+	mock := &MockStorage{ctrl: ctrl}
+	mock.recorder = &MockStorageMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockStorage) EXPECT() *MockStorageMockRecorder {
+	return m.recorder
+}
+
+// GetFile mocks base method.
+func (m *MockStorage) GetFile(fileId uuid.UUID) (io.ReadCloser, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFile", fileId)
+	ret0, _ := ret[0].(io.ReadCloser)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetFile indicates an expected call of GetFile.
+func (mr *MockStorageMockRecorder) GetFile(fileId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFile", reflect.TypeOf((*MockStorage)(nil).GetFile), fileId)
+}
+
+// GetTaggedFile mocks base method.
+func (m *MockStorage) GetTaggedFile(fileId uuid.UUID, tags storage.AudioTags) (io.ReadCloser, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTaggedFile", fileId, tags)
+	ret0, _ := ret[0].(io.ReadCloser)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetTaggedFile indicates an expected call of GetTaggedFile.
+func (mr *MockStorageMockRecorder) GetTaggedFile(fileId, tags interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTaggedFile", reflect.TypeOf((*MockStorage)(nil).GetTaggedFile), fileId, tags)
+}
+
+// StoreFile mocks base method.
+func (m *MockStorage) StoreFile(fileId uuid.UUID, file io.ReaderAt, size int64) (storage.StoredFile, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StoreFile", fileId, file, size)
+	ret0, _ := ret[0].(storage.StoredFile)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
 // StoreFile indicates an expected call of StoreFile.
-func (mr *MockStorageMockRecorder) StoreFile(fileId, file interface{}) *gomock.Call {
+func (mr *MockStorageMockRecorder) StoreFile(fileId, file, size interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StoreFile", reflect.TypeOf((*MockStorage)(nil).StoreFile), fileId, file)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StoreFile", reflect.TypeOf((*MockStorage)(nil).StoreFile), fileId, file, size)
 }
@@ -0,0 +1,83 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// Message is notification to user, To is email address
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Notifier delivers messages to users
+type Notifier interface {
+	Notify(msg Message) error
+}
+
+// LogNotifier only writes messages to log, it is meant for development as
+// messages may contain secrets like password reset tokens
+type LogNotifier struct{}
+
+func (LogNotifier) Notify(msg Message) error {
+	logrus.Infof("notification to %s: %s\n%s", msg.To, msg.Subject, msg.Body)
+	return nil
+}
+
+type SMTPConfig struct {
+	Host string
+	Port string
+	// Username and Password are used with PLAIN auth, empty Username disables auth
+	Username string
+	Password string
+	From     string
+}
+
+// SMTPNotifier sends messages as plain text emails
+type SMTPNotifier struct {
+	addr string
+	from string
+	auth smtp.Auth
+	now  func() time.Time
+}
+
+func NewSMTPNotifier(cfg SMTPConfig) *SMTPNotifier {
+	n := &SMTPNotifier{addr: net.JoinHostPort(cfg.Host, cfg.Port), from: cfg.From, now: time.Now}
+	if cfg.Username != "" {
+		n.auth = smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.Host)
+	}
+	return n
+}
+
+// Notify sends message, STARTTLS is used if the server supports it
+func (n *SMTPNotifier) Notify(msg Message) error {
+	return smtp.SendMail(n.addr, n.auth, n.from, []string{msg.To}, n.email(msg))
+}
+
+func (n *SMTPNotifier) email(msg Message) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", headerValue(n.from))
+	fmt.Fprintf(&b, "To: %s\r\n", headerValue(msg.To))
+	fmt.Fprintf(&b, "Subject: %s\r\n", headerValue(msg.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", n.now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+
+	body := strings.ReplaceAll(msg.Body, "\r\n", "\n")
+	b.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+	b.WriteString("\r\n")
+
+	return b.Bytes()
+}
+
+// headerValue drops line breaks so that value can't add headers
+func headerValue(value string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(value)
+}
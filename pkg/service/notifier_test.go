@@ -0,0 +1,102 @@
+package service
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+)
+
+// smtpMail is mail received by smtpStandIn
+type smtpMail struct {
+	from string
+	to   []string
+	data string
+}
+
+// smtpStandIn accepts one mail without extensions like STARTTLS or AUTH
+func smtpStandIn(t *testing.T) (string, <-chan smtpMail) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { l.Close() })
+
+	mails := make(chan smtpMail, 1)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		text := textproto.NewConn(conn)
+		var mail smtpMail
+		text.PrintfLine("220 localhost ESMTP")
+		for {
+			line, err := text.ReadLine()
+			if err != nil {
+				return
+			}
+
+			switch command := strings.ToUpper(strings.SplitN(line, " ", 2)[0]); command {
+			case "EHLO", "HELO":
+				text.PrintfLine("250 localhost")
+			case "MAIL":
+				mail.from = strings.TrimPrefix(line, "MAIL FROM:")
+				text.PrintfLine("250 OK")
+			case "RCPT":
+				mail.to = append(mail.to, strings.TrimPrefix(line, "RCPT TO:"))
+				text.PrintfLine("250 OK")
+			case "DATA":
+				text.PrintfLine("354 End data with <CR><LF>.<CR><LF>")
+				lines, err := text.ReadDotLines()
+				if err != nil {
+					return
+				}
+				mail.data = strings.Join(lines, "\n")
+				text.PrintfLine("250 OK")
+				mails <- mail
+			case "QUIT":
+				text.PrintfLine("221 Bye")
+				return
+			default:
+				text.PrintfLine("502 Command not implemented")
+			}
+		}
+	}()
+
+	return l.Addr().String(), mails
+}
+
+func TestSMTPNotifier(t *testing.T) {
+	addr, mails := smtpStandIn(t)
+	host, port, err := net.SplitHostPort(addr)
+	require.NoError(t, err)
+
+	n := NewSMTPNotifier(SMTPConfig{Host: host, Port: port, From: "audio-storage@example.com"})
+	n.now = func() time.Time { return time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC) }
+
+	err = n.Notify(Message{
+		To:      "user_1@example.com",
+		Subject: "Password reset\r\nBcc: other@example.com",
+		Body:    "first line\nsecond line",
+	})
+	require.NoError(t, err)
+
+	mail := <-mails
+	assert.Equal(t, "<audio-storage@example.com>", mail.from)
+	assert.Equal(t, []string{"<user_1@example.com>"}, mail.to)
+	assert.Equal(t, strings.Join([]string{
+		"From: audio-storage@example.com",
+		"To: user_1@example.com",
+		"Subject: Password resetBcc: other@example.com",
+		"Date: Tue, 01 Jun 2021 12:00:00 +0000",
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=UTF-8",
+		"",
+		"first line",
+		"second line",
+	}, "\n"), mail.data)
+}
@@ -0,0 +1,160 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	storage "github.com/mahadeva604/audio-storage"
+	"github.com/mahadeva604/audio-storage/pkg/repository"
+	"github.com/sirupsen/logrus"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+type PasswordConfig struct {
+	ResetTokenTTL time.Duration
+	// ResetURL is page of client reset token is passed to in token query
+	// parameter, the message has only the token if it is empty
+	ResetURL string
+	Notifier Notifier
+	// Workers send reset tokens from queue of QueueSize requests, requests
+	// over it are rejected
+	Workers   int
+	QueueSize int
+}
+
+type resetRequest struct {
+	username string
+	token    string
+}
+
+type PasswordService struct {
+	repo    repository.Password
+	cfg     PasswordConfig
+	now     func() time.Time
+	mu      sync.Mutex
+	closed  bool
+	resets  chan resetRequest
+	pending sync.WaitGroup
+	workers sync.WaitGroup
+}
+
+func NewPasswordService(repo repository.Password, cfg PasswordConfig) *PasswordService {
+	if cfg.Workers < 1 {
+		cfg.Workers = 1
+	}
+	if cfg.QueueSize < 1 {
+		cfg.QueueSize = 100
+	}
+
+	s := &PasswordService{repo: repo, cfg: cfg, now: time.Now, resets: make(chan resetRequest, cfg.QueueSize)}
+	for i := 0; i < cfg.Workers; i++ {
+		s.workers.Add(1)
+		go s.sendResetTokens()
+	}
+
+	return s
+}
+
+// ChangePassword closes other sessions, revokes access tokens and optionally
+// API keys. The kept session gets new access token by refresh
+func (s *PasswordService) ChangePassword(userId int, input storage.ChangePasswordInput) error {
+	var keepTokenHash string
+	if input.RefreshToken != "" {
+		keepTokenHash = hashToken(input.RefreshToken)
+	}
+
+	return s.repo.ChangePassword(userId, input.OldPassword, input.NewPassword, keepTokenHash, input.RevokeAPIKeys)
+}
+
+// RequestPasswordReset queues single-use reset token to be sent to email of
+// user. Unknown users, users without email and failed delivery are only
+// logged, so that accounts can't be found out by response or its timing.
+// ResetQueueFull is returned when the queue is full or closed
+func (s *PasswordService) RequestPasswordReset(input storage.PasswordResetRequest) error {
+	token, err := randomToken()
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return storage.ResetQueueFull
+	}
+
+	s.pending.Add(1)
+	select {
+	case s.resets <- resetRequest{username: input.Username, token: token}:
+		return nil
+	default:
+		s.pending.Done()
+		return storage.ResetQueueFull
+	}
+}
+
+// Wait blocks until every queued reset token is sent
+func (s *PasswordService) Wait() {
+	s.pending.Wait()
+}
+
+// Close stops accepting reset requests and waits until queued tokens are sent
+func (s *PasswordService) Close() {
+	s.mu.Lock()
+	if !s.closed {
+		s.closed = true
+		close(s.resets)
+	}
+	s.mu.Unlock()
+
+	s.workers.Wait()
+}
+
+func (s *PasswordService) sendResetTokens() {
+	defer s.workers.Done()
+
+	for request := range s.resets {
+		s.sendResetToken(request.username, request.token)
+		s.pending.Done()
+	}
+}
+
+func (s *PasswordService) sendResetToken(username, token string) {
+	email, err := s.repo.CreateResetToken(username, hashToken(token), s.cfg.ResetTokenTTL)
+	if errors.Is(err, storage.UserNotFound) {
+		logrus.Infof("password reset of %q is requested, user doesn't exist or has no email", username)
+		return
+	}
+	if err != nil {
+		logrus.Errorf("can't create password reset token of %q: %s", username, err.Error())
+		return
+	}
+
+	err = s.cfg.Notifier.Notify(Message{To: email, Subject: "Password reset", Body: s.resetBody(token)})
+	if err != nil {
+		logrus.Errorf("can't send password reset token of %q: %s", username, err.Error())
+	}
+}
+
+func (s *PasswordService) ResetPassword(input storage.ResetPasswordInput) error {
+	return s.repo.ResetPassword(hashToken(input.Token), input.NewPassword)
+}
+
+func (s *PasswordService) SetEmail(userId int, input storage.EmailInput) error {
+	return s.repo.SetEmail(userId, input.Password, input.Email)
+}
+
+func (s *PasswordService) resetBody(token string) string {
+	var b strings.Builder
+	b.WriteString("Password reset of your account was requested.\n\n")
+	if s.cfg.ResetURL != "" {
+		fmt.Fprintf(&b, "Open %s?token=%s to set new password.\n", s.cfg.ResetURL, url.QueryEscape(token))
+	} else {
+		fmt.Fprintf(&b, "Use this token to set new password: %s\n", token)
+	}
+	fmt.Fprintf(&b, "It can be used once until %s.\n\n", s.now().Add(s.cfg.ResetTokenTTL).UTC().Format(time.RFC1123))
+	b.WriteString("If you didn't request it, ignore this message, your password stays the same.\n")
+
+	return b.String()
+}
@@ -0,0 +1,155 @@
+package service
+
+import (
+	"errors"
+	storage "github.com/mahadeva604/audio-storage"
+	"github.com/mahadeva604/audio-storage/pkg/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+)
+
+// passwordRepo keeps reset tokens by hash, used tokens are removed
+type passwordRepo struct {
+	repository.Password
+	emails map[string]string
+	tokens map[string]string
+	reset  map[string]string
+	kept   string
+	revoke bool
+}
+
+func (r *passwordRepo) ChangePassword(userId int, oldPassword, newPassword, keepTokenHash string, revokeAPIKeys bool) error {
+	r.kept = keepTokenHash
+	r.revoke = revokeAPIKeys
+	return nil
+}
+
+func (r *passwordRepo) CreateResetToken(username, tokenHash string, ttl time.Duration) (string, error) {
+	email, ok := r.emails[username]
+	if !ok {
+		return "", storage.UserNotFound
+	}
+	r.tokens[tokenHash] = username
+	return email, nil
+}
+
+func (r *passwordRepo) ResetPassword(tokenHash, newPassword string) error {
+	username, ok := r.tokens[tokenHash]
+	if !ok {
+		return storage.InvalidResetToken
+	}
+	delete(r.tokens, tokenHash)
+	r.reset[username] = newPassword
+	return nil
+}
+
+type messages []Message
+
+func (m *messages) Notify(msg Message) error {
+	*m = append(*m, msg)
+	return nil
+}
+
+// blockingNotifier waits for release before every message is sent
+type blockingNotifier chan struct{}
+
+func (n blockingNotifier) Notify(msg Message) error {
+	<-n
+	return nil
+}
+
+type failingNotifier struct{}
+
+func (failingNotifier) Notify(msg Message) error {
+	return errors.New("smtp is down")
+}
+
+func TestPasswordService_Reset(t *testing.T) {
+	repo := &passwordRepo{
+		emails: map[string]string{"user_1": "user_1@example.com"},
+		tokens: map[string]string{},
+		reset:  map[string]string{},
+	}
+	sent := &messages{}
+	s := NewPasswordService(repo, PasswordConfig{
+		ResetTokenTTL: time.Hour,
+		ResetURL:      "https://example.com/reset",
+		Notifier:      sent,
+	})
+	s.now = func() time.Time { return time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC) }
+
+	require.NoError(t, s.RequestPasswordReset(storage.PasswordResetRequest{Username: "user_1"}))
+	s.Wait()
+	require.Len(t, *sent, 1)
+
+	msg := (*sent)[0]
+	assert.Equal(t, "user_1@example.com", msg.To)
+	assert.Contains(t, msg.Body, "until Tue, 01 Jun 2021 13:00:00 UTC")
+
+	link := regexp.MustCompile(`https://example.com/reset\?token=(\S+) `).FindStringSubmatch(msg.Body)
+	require.Len(t, link, 2)
+	token, err := url.QueryUnescape(link[1])
+	require.NoError(t, err)
+	assert.NotContains(t, repo.tokens, token, "token must be stored hashed")
+
+	assert.NoError(t, s.ResetPassword(storage.ResetPasswordInput{Token: token, NewPassword: "qwerty_12"}))
+	assert.Equal(t, "qwerty_12", repo.reset["user_1"])
+
+	err = s.ResetPassword(storage.ResetPasswordInput{Token: token, NewPassword: "qwerty_13"})
+	assert.ErrorIs(t, err, storage.InvalidResetToken)
+
+	t.Run("Unknown user", func(t *testing.T) {
+		assert.NoError(t, s.RequestPasswordReset(storage.PasswordResetRequest{Username: "user_2"}))
+		s.Wait()
+		assert.Len(t, *sent, 1)
+	})
+
+	t.Run("Notification failed", func(t *testing.T) {
+		failing := NewPasswordService(repo, PasswordConfig{ResetTokenTTL: time.Hour, Notifier: failingNotifier{}})
+		assert.NoError(t, failing.RequestPasswordReset(storage.PasswordResetRequest{Username: "user_1"}))
+		failing.Wait()
+	})
+
+	t.Run("Queue full and closed", func(t *testing.T) {
+		release := make(blockingNotifier)
+		queued := NewPasswordService(repo, PasswordConfig{ResetTokenTTL: time.Hour, Notifier: release, Workers: 1, QueueSize: 1})
+
+		// the worker takes the first request and waits, the second one is queued
+		require.NoError(t, queued.RequestPasswordReset(storage.PasswordResetRequest{Username: "user_1"}))
+		require.Eventually(t, func() bool { return len(queued.resets) == 0 }, time.Second, time.Millisecond)
+		require.NoError(t, queued.RequestPasswordReset(storage.PasswordResetRequest{Username: "user_1"}))
+
+		err := queued.RequestPasswordReset(storage.PasswordResetRequest{Username: "user_1"})
+		assert.ErrorIs(t, err, storage.ResetQueueFull)
+
+		closed := make(chan struct{})
+		go func() {
+			queued.Close()
+			close(closed)
+		}()
+		release <- struct{}{}
+		release <- struct{}{}
+		<-closed
+
+		err = queued.RequestPasswordReset(storage.PasswordResetRequest{Username: "user_1"})
+		assert.ErrorIs(t, err, storage.ResetQueueFull)
+	})
+
+	t.Run("Change keeps session", func(t *testing.T) {
+		input := storage.ChangePasswordInput{OldPassword: "qwerty_12", NewPassword: "qwerty_13", RefreshToken: "refresh"}
+		assert.NoError(t, s.ChangePassword(1, input))
+		assert.Equal(t, hashToken("refresh"), repo.kept)
+
+		assert.False(t, repo.revoke)
+
+		input.RefreshToken = ""
+		input.RevokeAPIKeys = true
+		assert.NoError(t, s.ChangePassword(1, input))
+		assert.Empty(t, repo.kept)
+		assert.True(t, repo.revoke)
+	})
+}
@@ -0,0 +1,30 @@
+package service
+
+import (
+	storage "github.com/mahadeva604/audio-storage"
+	"github.com/mahadeva604/audio-storage/pkg/repository"
+)
+
+type SearchService struct {
+	repo repository.Search
+}
+
+func NewSearchService(repo repository.Search) *SearchService {
+	return &SearchService{repo: repo}
+}
+
+func (s *SearchService) SearchAudio(userID int, input storage.SearchParam) (storage.SearchResultJson, error) {
+	result, err := s.repo.SearchAudio(userID, input)
+	if err != nil {
+		return storage.SearchResultJson{}, err
+	}
+
+	for i := range result.Records {
+		result.Records[i].Snippet = storage.HighlightSnippet(result.Records[i].Snippet)
+		if cue := result.Records[i].Cue; cue != nil {
+			cue.Snippet = storage.HighlightSnippet(cue.Snippet)
+		}
+	}
+
+	return result, nil
+}
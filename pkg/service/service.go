@@ -1,11 +1,11 @@
 package service
 
 import (
+	"context"
 	"github.com/google/uuid"
 	storage "github.com/mahadeva604/audio-storage"
 	"github.com/mahadeva604/audio-storage/pkg/repository"
 	"io"
-	"time"
 )
 
 //go:generate mockgen -source=service.go -destination=mocks/mock.go
@@ -14,41 +14,197 @@ type Authorization interface {
 	CreateUser(user storage.User) (int, error)
 	GenerateAccessToken(username, password string) (int, string, error)
 	UpdateAccessToken(userId int) (string, error)
-	GenerateRefreshToken(userId int) (string, error)
-	UpdateRefreshToken(oldRefreshToken string) (int, string, error)
+	GenerateRefreshToken(userId int, client storage.SessionClient) (string, error)
+	UpdateRefreshToken(oldRefreshToken string, client storage.SessionClient) (int, string, error)
 	ParseToken(token string) (int, error)
+	Logout(accessToken, refreshToken string) error
+	LogoutAll(accessToken string) error
+	GetSessions(userId int) ([]storage.Session, error)
+	DeleteSession(userId, sessionId int) error
+	GetSecurityEvents(userId int, input storage.SecurityEventListParam) (storage.SecurityEventListJson, error)
+	JWKS() storage.JWKS
+	IsAdmin(userId int) (bool, error)
+}
+
+type Password interface {
+	ChangePassword(userId int, input storage.ChangePasswordInput) error
+	RequestPasswordReset(input storage.PasswordResetRequest) error
+	ResetPassword(input storage.ResetPasswordInput) error
+	SetEmail(userId int, input storage.EmailInput) error
+	Close()
+}
+
+type APIKey interface {
+	CreateAPIKey(userId int, input storage.APIKeyInput) (storage.NewAPIKey, error)
+	GetAPIKeys(userId int) ([]storage.APIKey, error)
+	DeleteAPIKey(userId, keyId int) error
+	ParseAPIKey(key string) (storage.APIKeyOwner, error)
 }
 
 type Audio interface {
-	UploadFile(userId int, path string) (int, error)
-	AddDescription(userID, audioId int, input storage.UpdateAudio) error
+	UploadFile(userId int, path string, size int64, metadata storage.AudioMetadata) (int, error)
+	GetAudio(userID, audioId int) (storage.Audio, error)
+	AddDescription(userID, audioId int, versions []int, input storage.UpdateAudio) (storage.Audio, error)
 	DownloadFile(userID, audioId int) (storage.DownloadAudio, error)
 	GetAudioList(userID int, input storage.AudioListParam) (storage.AudioListJson, error)
+	GetAudioPage(userID int, input storage.AudioListParam) (storage.AudioPageJson, error)
+	GetAudioHistory(userID, audioId int, input storage.HistoryListParam) (storage.HistoryListJson, error)
+}
+
+type MetadataSchema interface {
+	GetMetadataSchema(userID int) (storage.MetadataSchema, error)
+	SetMetadataSchema(userID int, input storage.MetadataSchema) error
+	DeleteMetadataSchema(userID int) error
+}
+
+type Artwork interface {
+	SetAudioArtwork(userID, audioId int, image []byte) error
+	DeleteAudioArtwork(userID, audioId int) error
+	GetAudioArtwork(userID, audioId int, size string) (storage.Artwork, error)
+	SetCollectionArtwork(userID, collectionId int, image []byte) error
+	DeleteCollectionArtwork(userID, collectionId int) error
+	GetCollectionArtwork(userID, collectionId int, size string) (storage.Artwork, error)
+}
+
+type Comment interface {
+	AddComment(userID, audioId int, input storage.CommentInput) (int, error)
+	GetComments(userID, audioId int, input storage.CommentListParam) (storage.CommentListJson, error)
+	UpdateComment(userID, audioId, commentId int, input storage.CommentUpdate) error
+	DeleteComment(userID, audioId, commentId int) error
+}
+
+type Chapter interface {
+	SetChapters(userID, audioId int, chapters []storage.Chapter) error
+	GetChapters(userID, audioId int) (storage.AudioChapters, error)
+}
+
+type Transcript interface {
+	SetTranscript(userID, audioId int, transcript storage.Transcript) error
+	GetTranscript(userID, audioId int) (storage.Transcript, error)
+	DeleteTranscript(userID, audioId int) error
+}
+
+type Transcription interface {
+	StartTranscription(userID, audioId int) error
+	GetTranscription(userID, audioId int) (storage.TranscriptionJob, error)
+}
+
+type Job interface {
+	ProcessAudio(audioId int) error
+	EnqueueJob(input storage.JobInput) (int, error)
+	GetAudioJobs(userID, audioId int) ([]storage.Job, error)
+	GetJobs(input storage.JobListParam) (storage.JobListJson, error)
+	RetryJob(jobId int) error
+	Run(ctx context.Context)
+}
+
+type HLS interface {
+	GetHLSFile(userID, audioId int, name string) ([]byte, error)
 }
 
 type Share interface {
 	ShareAudio(userID, audioId, shareId int) error
 	UnshareAudio(userID, audioId, shareId int) error
 	GetSharedList(input storage.ShareListParam) (storage.ShareListJson, error)
+	GetSharedPage(input storage.ShareListParam) (storage.SharePageJson, error)
+}
+
+type Invitation interface {
+	GetInvitations(userID int, input storage.InvitationListParam) (storage.InvitationListJson, error)
+	AcceptInvitation(userID, audioId int) error
+	DeclineInvitation(userID, audioId int) error
+	AcceptCollectionInvitation(userID, collectionId int) error
+	DeclineCollectionInvitation(userID, collectionId int) error
+	BlockSender(userID, senderId int) error
+	UnblockSender(userID, senderId int) error
+	GetBlockedSenders(userID int) ([]storage.Sender, error)
+	AddAutoAccept(userID, senderId int) error
+	RemoveAutoAccept(userID, senderId int) error
+	GetAutoAcceptSenders(userID int) ([]storage.Sender, error)
+}
+
+type Collection interface {
+	CreateCollection(userID int, input storage.CollectionInput) (int, error)
+	UpdateCollection(userID, collectionId int, input storage.CollectionInput) error
+	DeleteCollection(userID, collectionId int) error
+	GetCollectionList(userID int, input storage.CollectionListParam) (storage.CollectionListJson, error)
+	GetCollectionItems(userID, collectionId int, input storage.CollectionItemsParam) (storage.CollectionItemsJson, error)
+	AddCollectionItem(userID, collectionId, audioId int) error
+	RemoveCollectionItem(userID, collectionId, audioId int) error
+	ReorderCollection(userID, collectionId int, audioIds []int) error
+	ShareCollection(userID, collectionId, shareId int) error
+	UnshareCollection(userID, collectionId, shareId int) error
+}
+
+type Feed interface {
+	PublishFeed(userID, collectionId int, input storage.FeedInput) (string, error)
+	GetFeedSettings(userID, collectionId int) (storage.FeedSettings, error)
+	RotateFeedToken(userID, collectionId int) (string, error)
+	DeleteFeed(userID, collectionId int) error
+	GetFeed(token string) (storage.RssFeed, error)
+	GetSignedFile(audioId, collectionId int, token string, expires int64, signature string) (storage.DownloadAudio, error)
+}
+
+type Tag interface {
+	AddTags(userID, audioId int, input storage.TagsInput) error
+	RemoveTag(userID, audioId int, tag string) error
+	GetAudioTags(userID, audioId int) ([]storage.AudioTag, error)
+	GetTags(userID int, input storage.TagListParam) ([]storage.TagCount, error)
+}
+
+type Search interface {
+	SearchAudio(userID int, input storage.SearchParam) (storage.SearchResultJson, error)
 }
 
 type Storage interface {
-	StoreFile(fileId uuid.UUID, file io.ReadSeeker) error
+	StoreFile(fileId uuid.UUID, file io.ReaderAt, size int64) (storage.StoredFile, error)
 	GetFile(fileId uuid.UUID) (io.ReadCloser, int64, error)
+	GetTaggedFile(fileId uuid.UUID, tags storage.AudioTags) (io.ReadCloser, int64, error)
 }
 
 type Service struct {
 	Authorization
+	Password
+	APIKey
 	Audio
+	MetadataSchema
+	Artwork
+	Comment
+	Chapter
+	Transcript
+	Transcription
+	Job
+	HLS
 	Share
+	Invitation
+	Collection
+	Feed
+	Tag
+	Search
 	Storage
 }
 
-func NewService(repos *repository.Repository, secretKey []byte, accessTokenTTL, refreshTokenTTL time.Duration) *Service {
+func NewService(repos *repository.Repository, secretKey []byte, authConfig AuthConfig, passwordConfig PasswordConfig,
+	feedConfig FeedConfig, jobConfig JobConfig) *Service {
 	return &Service{
-		Authorization: NewAuthService(repos, secretKey, accessTokenTTL, refreshTokenTTL),
-		Audio:         NewAudioService(repos),
-		Share:         NewShareService(repos),
-		Storage:       NewStorageService(repos),
+		Authorization:  NewAuthService(repos, authConfig),
+		Password:       NewPasswordService(repos, passwordConfig),
+		APIKey:         NewAPIKeyService(repos),
+		Audio:          NewAudioService(repos, repos),
+		MetadataSchema: NewMetadataSchemaService(repos),
+		Artwork:        NewArtworkService(repos, repos),
+		Comment:        NewCommentService(repos),
+		Chapter:        NewChapterService(repos),
+		Transcript:     NewTranscriptService(repos),
+		Transcription:  NewTranscriptionService(repos, jobConfig),
+		Job:            NewJobService(repos, repos, repos, jobConfig),
+		HLS:            NewHLSService(repos, repos),
+		Share:          NewShareService(repos),
+		Invitation:     NewInvitationService(repos),
+		Collection:     NewCollectionService(repos, repos, repos),
+		Feed:           NewFeedService(repos, secretKey, feedConfig),
+		Tag:            NewTagService(repos),
+		Search:         NewSearchService(repos),
+		Storage:        NewStorageService(repos),
 	}
 }
@@ -31,3 +31,7 @@ func (s *ShareService) UnshareAudio(userID, audioId, shareId int) error {
 func (s *ShareService) GetSharedList(input storage.ShareListParam) (storage.ShareListJson, error) {
 	return s.repo.GetSharedList(input)
 }
+
+func (s *ShareService) GetSharedPage(input storage.ShareListParam) (storage.SharePageJson, error) {
+	return s.repo.GetSharedPage(input)
+}
@@ -2,6 +2,7 @@ package service
 
 import (
 	"github.com/google/uuid"
+	storage "github.com/mahadeva604/audio-storage"
 	"github.com/mahadeva604/audio-storage/pkg/repository"
 	"io"
 )
@@ -14,10 +15,14 @@ func NewStorageService(repo repository.Storage) *StorageService {
 	return &StorageService{repo: repo}
 }
 
-func (s StorageService) StoreFile(fileId uuid.UUID, file io.ReadSeeker) error {
-	return s.repo.StoreFile(fileId, file)
+func (s StorageService) StoreFile(fileId uuid.UUID, file io.ReaderAt, size int64) (storage.StoredFile, error) {
+	return s.repo.StoreFile(fileId, file, size)
 }
 
 func (s StorageService) GetFile(fileId uuid.UUID) (io.ReadCloser, int64, error) {
 	return s.repo.GetFile(fileId)
 }
+
+func (s StorageService) GetTaggedFile(fileId uuid.UUID, tags storage.AudioTags) (io.ReadCloser, int64, error) {
+	return s.repo.GetTaggedFile(fileId, tags)
+}
@@ -0,0 +1,40 @@
+package service
+
+import (
+	storage "github.com/mahadeva604/audio-storage"
+	"github.com/mahadeva604/audio-storage/pkg/repository"
+	"strings"
+)
+
+type TagService struct {
+	repo repository.Tag
+}
+
+func NewTagService(repo repository.Tag) *TagService {
+	return &TagService{repo: repo}
+}
+
+func (s *TagService) AddTags(userID, audioId int, input storage.TagsInput) error {
+	tags, err := storage.NormalizeTags(input.Tags)
+	if err != nil {
+		return err
+	}
+	return s.repo.AddTags(userID, audioId, tags)
+}
+
+func (s *TagService) RemoveTag(userID, audioId int, tag string) error {
+	tag, err := storage.NormalizeTag(tag)
+	if err != nil {
+		return err
+	}
+	return s.repo.RemoveTag(userID, audioId, tag)
+}
+
+func (s *TagService) GetAudioTags(userID, audioId int) ([]storage.AudioTag, error) {
+	return s.repo.GetAudioTags(userID, audioId)
+}
+
+func (s *TagService) GetTags(userID int, input storage.TagListParam) ([]storage.TagCount, error) {
+	input.Prefix = strings.ToLower(strings.TrimSpace(input.Prefix))
+	return s.repo.GetTags(userID, input)
+}
@@ -0,0 +1,136 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+	storage "github.com/mahadeva604/audio-storage"
+	"time"
+)
+
+// TokenClaims are claims of access token AuthService relies on
+type TokenClaims struct {
+	Id        string
+	UserId    int
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+}
+
+type TokenIssuer interface {
+	Issue(userId int) (string, error)
+}
+
+// TokenVerifier checks signature and registered claims of token, it doesn't
+// check if token is revoked
+type TokenVerifier interface {
+	Verify(token string) (TokenClaims, error)
+}
+
+type accessClaims struct {
+	jwt.RegisteredClaims
+	UserId int `json:"user_id"`
+}
+
+// JWTTokens issues and verifies access tokens signed with keys of KeySet
+type JWTTokens struct {
+	keys     KeySet
+	issuer   string
+	audience string
+	ttl      time.Duration
+	leeway   time.Duration
+	parser   *jwt.Parser
+	now      func() time.Time
+}
+
+func NewJWTTokens(cfg AuthConfig) *JWTTokens {
+	return &JWTTokens{
+		keys:     cfg.Keys,
+		issuer:   cfg.Issuer,
+		audience: cfg.Audience,
+		ttl:      cfg.AccessTokenTTL,
+		leeway:   cfg.Leeway,
+		// claims are validated by Verify with leeway
+		parser: jwt.NewParser(jwt.WithValidMethods(cfg.Keys.algorithms()), jwt.WithoutClaimsValidation()),
+		now:    time.Now,
+	}
+}
+
+// Issue signs token with unique id so that it can be revoked
+func (t *JWTTokens) Issue(userId int) (string, error) {
+	now := t.now()
+	claims := accessClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.New().String(),
+			Issuer:    t.issuer,
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(t.ttl)),
+		},
+		UserId: userId,
+	}
+	if t.audience != "" {
+		claims.Audience = jwt.ClaimStrings{t.audience}
+	}
+
+	key := t.keys.signing
+	token := jwt.NewWithClaims(key.Method, &claims)
+	if key.Id != "" {
+		token.Header["kid"] = key.Id
+	}
+
+	return token.SignedString(key.Private)
+}
+
+// Verify accepts token signed with known key by algorithm of the key, exp is
+// required and clock skew up to leeway is allowed for exp, nbf and iat
+func (t *JWTTokens) Verify(tokenString string) (TokenClaims, error) {
+	var claims accessClaims
+	_, err := t.parser.ParseWithClaims(tokenString, &claims, func(token *jwt.Token) (interface{}, error) {
+		key, err := t.keys.find(token)
+		if err != nil {
+			return nil, err
+		}
+		return key.Public, nil
+	})
+	if err != nil {
+		var validationErr *jwt.ValidationError
+		if errors.As(err, &validationErr) && validationErr.Inner != nil {
+			return TokenClaims{}, validationErr.Inner
+		}
+		return TokenClaims{}, err
+	}
+
+	now := t.now()
+	if claims.ExpiresAt == nil {
+		return TokenClaims{}, errors.New("token has no expiration time")
+	}
+	if now.Add(-t.leeway).After(claims.ExpiresAt.Time) {
+		return TokenClaims{}, storage.TokenExpired
+	}
+	if claims.NotBefore != nil && now.Add(t.leeway).Before(claims.NotBefore.Time) {
+		return TokenClaims{}, storage.TokenNotValidYet
+	}
+	if claims.IssuedAt != nil && now.Add(t.leeway).Before(claims.IssuedAt.Time) {
+		return TokenClaims{}, fmt.Errorf("%w: issued in the future", storage.TokenNotValidYet)
+	}
+
+	if t.issuer != "" && !claims.VerifyIssuer(t.issuer, true) {
+		return TokenClaims{}, errors.New("token has invalid issuer")
+	}
+	if t.audience != "" && !claims.VerifyAudience(t.audience, true) {
+		return TokenClaims{}, errors.New("token has invalid audience")
+	}
+
+	// tokens issued before revocation was added can't be revoked
+	if _, err := uuid.Parse(claims.ID); err != nil {
+		return TokenClaims{}, errors.New("token has no valid id")
+	}
+
+	result := TokenClaims{Id: claims.ID, UserId: claims.UserId, ExpiresAt: claims.ExpiresAt.Time}
+	if claims.IssuedAt != nil {
+		result.IssuedAt = claims.IssuedAt.Time
+	}
+
+	return result, nil
+}
@@ -0,0 +1,171 @@
+package service
+
+import (
+	"encoding/base64"
+	"github.com/golang-jwt/jwt/v4"
+	storage "github.com/mahadeva604/audio-storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestTokens(t *testing.T, now time.Time, signing SigningKey, verification ...SigningKey) *JWTTokens {
+	keys, err := NewKeySet(signing, verification...)
+	require.NoError(t, err)
+
+	tokens := NewJWTTokens(AuthConfig{AccessTokenTTL: time.Minute, Leeway: 5 * time.Second, Issuer: "audio-storage", Keys: keys})
+	tokens.now = func() time.Time { return now }
+	return tokens
+}
+
+func signTestToken(t *testing.T, method jwt.SigningMethod, kid string, key interface{}, claims accessClaims) string {
+	token := jwt.NewWithClaims(method, &claims)
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(key)
+	require.NoError(t, err)
+	return signed
+}
+
+func TestJWTTokens_Verify(t *testing.T) {
+	now := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	k := newTestKeys(t)
+	rsaKey, err := ParseSigningKey("rsa", "RS256", k.rsaPEM)
+	require.NoError(t, err)
+	hsKey := NewHMACKey("hs", []byte("secret"))
+
+	claims := func(iat, nbf, exp time.Time) accessClaims {
+		return accessClaims{
+			RegisteredClaims: jwt.RegisteredClaims{
+				ID:        "2f1f9d8e-3c4b-4a5a-9e6f-7a8b9c0d1e2f",
+				Issuer:    "audio-storage",
+				IssuedAt:  jwt.NewNumericDate(iat),
+				NotBefore: jwt.NewNumericDate(nbf),
+				ExpiresAt: jwt.NewNumericDate(exp),
+			},
+			UserId: 1,
+		}
+	}
+	valid := claims(now, now, now.Add(time.Minute))
+	noExp := valid
+	noExp.ExpiresAt = nil
+
+	tamper := func(token string) string {
+		parts := strings.Split(token, ".")
+		payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+		require.NoError(t, err)
+		parts[1] = base64.RawURLEncoding.EncodeToString([]byte(strings.Replace(string(payload), `"user_id":1`, `"user_id":2`, 1)))
+		return strings.Join(parts, ".")
+	}
+
+	testTable := []struct {
+		name        string
+		token       string
+		expectedErr string
+	}{
+		{
+			name:  "OK",
+			token: signTestToken(t, jwt.SigningMethodRS256, "rsa", k.rsa, valid),
+		},
+		{
+			name:  "OK expired within leeway",
+			token: signTestToken(t, jwt.SigningMethodRS256, "rsa", k.rsa, claims(now.Add(-time.Minute), now.Add(-time.Minute), now.Add(-4*time.Second))),
+		},
+		{
+			name:  "OK not before within leeway",
+			token: signTestToken(t, jwt.SigningMethodRS256, "rsa", k.rsa, claims(now.Add(4*time.Second), now.Add(4*time.Second), now.Add(time.Minute))),
+		},
+		{
+			name:        "Expired",
+			token:       signTestToken(t, jwt.SigningMethodRS256, "rsa", k.rsa, claims(now.Add(-time.Hour), now.Add(-time.Hour), now.Add(-6*time.Second))),
+			expectedErr: storage.TokenExpired.Error(),
+		},
+		{
+			name:        "Not valid yet",
+			token:       signTestToken(t, jwt.SigningMethodRS256, "rsa", k.rsa, claims(now, now.Add(time.Minute), now.Add(time.Hour))),
+			expectedErr: storage.TokenNotValidYet.Error(),
+		},
+		{
+			name:        "Issued in the future",
+			token:       signTestToken(t, jwt.SigningMethodRS256, "rsa", k.rsa, claims(now.Add(time.Minute), now, now.Add(time.Hour))),
+			expectedErr: "token is not valid yet: issued in the future",
+		},
+		{
+			name:        "No expiration time",
+			token:       signTestToken(t, jwt.SigningMethodRS256, "rsa", k.rsa, noExp),
+			expectedErr: "token has no expiration time",
+		},
+		{
+			name:        "Tampered payload",
+			token:       tamper(signTestToken(t, jwt.SigningMethodRS256, "rsa", k.rsa, valid)),
+			expectedErr: "crypto/rsa: verification error",
+		},
+		{
+			name:        "Tampered signature",
+			token:       signTestToken(t, jwt.SigningMethodRS256, "rsa", k.rsa, valid) + "A",
+			expectedErr: "crypto/rsa: verification error",
+		},
+		{
+			name:        "Wrong algorithm for key",
+			token:       signTestToken(t, jwt.SigningMethodHS256, "rsa", k.rsaPub, valid),
+			expectedErr: "invalid signing method",
+		},
+		{
+			name:        "Algorithm of no key",
+			token:       signTestToken(t, jwt.SigningMethodES256, "rsa", k.ec, valid),
+			expectedErr: "signing method ES256 is invalid",
+		},
+		{
+			name:        "None algorithm",
+			token:       signTestToken(t, jwt.SigningMethodNone, "hs", jwt.UnsafeAllowNoneSignatureType, valid),
+			expectedErr: "signing method none is invalid",
+		},
+		{
+			name:        "Unknown key",
+			token:       signTestToken(t, jwt.SigningMethodHS256, "other", []byte("secret"), valid),
+			expectedErr: `unknown signing key "other"`,
+		},
+		{
+			name:        "Wrong issuer",
+			token:       signTestToken(t, jwt.SigningMethodHS256, "hs", []byte("secret"), accessClaims{jwt.RegisteredClaims{ID: valid.ID, ExpiresAt: valid.ExpiresAt}, 1}),
+			expectedErr: "token has invalid issuer",
+		},
+	}
+
+	tokens := newTestTokens(t, now, rsaKey, hsKey)
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			got, err := tokens.Verify(testCase.token)
+			if testCase.expectedErr != "" {
+				assert.EqualError(t, err, testCase.expectedErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, TokenClaims{Id: valid.ID, UserId: 1, IssuedAt: got.IssuedAt, ExpiresAt: got.ExpiresAt}, got)
+		})
+	}
+}
+
+func TestJWTTokens_Issue(t *testing.T) {
+	now := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	tokens := newTestTokens(t, now, NewHMACKey("hs", []byte("secret")))
+
+	token, err := tokens.Issue(1)
+	require.NoError(t, err)
+
+	var claims accessClaims
+	_, err = jwt.NewParser(jwt.WithoutClaimsValidation()).ParseWithClaims(token, &claims, func(*jwt.Token) (interface{}, error) {
+		return []byte("secret"), nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, now, claims.IssuedAt.Time.UTC())
+	assert.Equal(t, now, claims.NotBefore.Time.UTC())
+	assert.Equal(t, now.Add(time.Minute), claims.ExpiresAt.Time.UTC())
+	assert.Equal(t, "audio-storage", claims.Issuer)
+
+	got, err := tokens.Verify(token)
+	assert.NoError(t, err)
+	assert.Equal(t, TokenClaims{Id: claims.ID, UserId: 1, IssuedAt: claims.IssuedAt.Time, ExpiresAt: claims.ExpiresAt.Time}, got)
+}
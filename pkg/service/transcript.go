@@ -0,0 +1,26 @@
+package service
+
+import (
+	storage "github.com/mahadeva604/audio-storage"
+	"github.com/mahadeva604/audio-storage/pkg/repository"
+)
+
+type TranscriptService struct {
+	repo repository.Transcript
+}
+
+func NewTranscriptService(repo repository.Transcript) *TranscriptService {
+	return &TranscriptService{repo: repo}
+}
+
+func (s *TranscriptService) SetTranscript(userID, audioId int, transcript storage.Transcript) error {
+	return s.repo.SetTranscript(userID, audioId, transcript)
+}
+
+func (s *TranscriptService) GetTranscript(userID, audioId int) (storage.Transcript, error) {
+	return s.repo.GetTranscript(userID, audioId)
+}
+
+func (s *TranscriptService) DeleteTranscript(userID, audioId int) error {
+	return s.repo.DeleteTranscript(userID, audioId)
+}
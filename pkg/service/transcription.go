@@ -0,0 +1,40 @@
+package service
+
+import (
+	storage "github.com/mahadeva604/audio-storage"
+	"github.com/mahadeva604/audio-storage/pkg/repository"
+	"time"
+)
+
+type TranscriptionConfig struct {
+	// Provider is nil when speech-to-text is disabled
+	Provider TranscriptionProvider
+	Timeout  time.Duration
+}
+
+// TranscriptionService queues speech-to-text jobs, they are run by workers of
+// the jobs queue
+type TranscriptionService struct {
+	repo repository.Transcription
+	cfg  JobConfig
+}
+
+func NewTranscriptionService(repo repository.Transcription, cfg JobConfig) *TranscriptionService {
+	if cfg.MaxAttempts < 1 {
+		cfg.MaxAttempts = 1
+	}
+	return &TranscriptionService{repo: repo, cfg: cfg}
+}
+
+// StartTranscription queues job for own audio, its result replaces transcript
+func (s *TranscriptionService) StartTranscription(userID, audioId int) error {
+	if s.cfg.Transcription.Provider == nil {
+		return storage.TranscriptionDisabled
+	}
+
+	return s.repo.QueueTranscription(userID, audioId, s.cfg.MaxAttempts)
+}
+
+func (s *TranscriptionService) GetTranscription(userID, audioId int) (storage.TranscriptionJob, error) {
+	return s.repo.GetTranscription(userID, audioId)
+}
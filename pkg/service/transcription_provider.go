@@ -0,0 +1,79 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	storage "github.com/mahadeva604/audio-storage"
+	"os/exec"
+	"strings"
+)
+
+// FilePlaceholder in command arguments is replaced with path of audio file
+const FilePlaceholder = "{file}"
+
+const maxProviderMessage = 512
+
+// TranscriptionProvider is a speech-to-text engine, path is the stored audio file
+type TranscriptionProvider interface {
+	Transcribe(ctx context.Context, path string) (storage.Transcript, error)
+}
+
+// CommandProvider runs executable on the stored file and parses WebVTT, SRT
+// or JSON transcript it writes to stdout
+type CommandProvider struct {
+	command string
+	args    []string
+}
+
+// NewCommandProvider returns provider running command with args, path of
+// the file is appended to args unless one of them has FilePlaceholder
+func NewCommandProvider(command string, args []string) *CommandProvider {
+	return &CommandProvider{command: command, args: args}
+}
+
+func (p *CommandProvider) Transcribe(ctx context.Context, path string) (storage.Transcript, error) {
+	args := make([]string, 0, len(p.args)+1)
+	withFile := false
+	for _, arg := range p.args {
+		if strings.Contains(arg, FilePlaceholder) {
+			arg = strings.ReplaceAll(arg, FilePlaceholder, path)
+			withFile = true
+		}
+		args = append(args, arg)
+	}
+	if !withFile {
+		args = append(args, path)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, p.command, args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		message := strings.TrimSpace(stderr.String())
+		if len(message) > maxProviderMessage {
+			message = message[len(message)-maxProviderMessage:]
+		}
+		return nil, fmt.Errorf("%s: %w: %s", p.command, err, message)
+	}
+
+	output := bytes.TrimSpace(stdout.Bytes())
+	if bytes.HasPrefix(output, []byte("{")) {
+		return storage.ParseTranscriptJson(output)
+	}
+
+	return storage.ParseTranscript(output)
+}
+
+// FakeProvider returns the same transcript or error for every file, it
+// stands in for a speech-to-text engine in tests and development
+type FakeProvider struct {
+	Transcript storage.Transcript
+	Err        error
+}
+
+func (p FakeProvider) Transcribe(ctx context.Context, path string) (storage.Transcript, error) {
+	return p.Transcript, p.Err
+}
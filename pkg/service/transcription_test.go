@@ -0,0 +1,105 @@
+package service
+
+import (
+	"context"
+	storage "github.com/mahadeva604/audio-storage"
+	"github.com/mahadeva604/audio-storage/pkg/repository"
+	"github.com/stretchr/testify/assert"
+	"os/exec"
+	"testing"
+)
+
+func TestCommandProvider_Transcribe(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh is not available")
+	}
+
+	testTable := []struct {
+		name        string
+		args        []string
+		expected    storage.Transcript
+		expectedErr string
+	}{
+		{
+			name: "OK webvtt with file appended",
+			args: []string{"-c", `printf 'WEBVTT\n\n00:01.000 --> 00:02.000\n%s\n' "$0"`},
+			expected: storage.Transcript{
+				{StartMs: 1000, EndMs: 2000, Text: "/data/audio.aac"},
+			},
+		},
+		{
+			name: "OK json segments with placeholder",
+			args: []string{"-c", `printf '{"text":"x","segments":[{"start":1.5,"end":2.25,"text":" %s "}]}' "$1"`, "engine", "--input={file}"},
+			expected: storage.Transcript{
+				{StartMs: 1500, EndMs: 2250, Text: "--input=/data/audio.aac"},
+			},
+		},
+		{
+			name:        "Command failed",
+			args:        []string{"-c", `echo "model not found" >&2; exit 3`},
+			expectedErr: "sh: exit status 3: model not found",
+		},
+		{
+			name:        "Invalid output",
+			args:        []string{"-c", `echo "no transcript"`},
+			expectedErr: "transcript must be WebVTT or SRT file: cue at line 1: timing line is missing",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			p := NewCommandProvider("sh", testCase.args)
+
+			transcript, err := p.Transcribe(context.Background(), "/data/audio.aac")
+			if testCase.expectedErr != "" {
+				assert.EqualError(t, err, testCase.expectedErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, testCase.expected, transcript)
+			}
+		})
+	}
+}
+
+// transcriptionRepo records queued jobs, methods which aren't overridden
+// panic on nil embedded interface
+type transcriptionRepo struct {
+	repository.Transcription
+	maxAttempts int
+}
+
+func (r *transcriptionRepo) QueueTranscription(userID, audioId, maxAttempts int) error {
+	r.maxAttempts = maxAttempts
+	return nil
+}
+
+func TestTranscriptionService_StartTranscription(t *testing.T) {
+	testTable := []struct {
+		name                string
+		provider            TranscriptionProvider
+		expectedErr         error
+		expectedMaxAttempts int
+	}{
+		{
+			name:                "OK",
+			provider:            FakeProvider{},
+			expectedMaxAttempts: 3,
+		},
+		{
+			name:        "Disabled",
+			expectedErr: storage.TranscriptionDisabled,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			repo := &transcriptionRepo{}
+			s := NewTranscriptionService(repo, JobConfig{MaxAttempts: 3, Transcription: TranscriptionConfig{Provider: testCase.provider}})
+
+			err := s.StartTranscription(1, 2)
+
+			assert.Equal(t, testCase.expectedErr, err)
+			assert.Equal(t, testCase.expectedMaxAttempts, repo.maxAttempts)
+		})
+	}
+}
@@ -0,0 +1,5 @@
+DROP TABLE share_auto_accept;
+
+DROP TABLE share_blocks;
+
+ALTER TABLE shares DROP COLUMN status;
@@ -0,0 +1,16 @@
+ALTER TABLE shares ADD COLUMN status TEXT NOT NULL DEFAULT 'accepted'
+    CHECK (status IN ('pending', 'accepted', 'declined'));
+
+ALTER TABLE shares ALTER COLUMN status SET DEFAULT 'pending';
+
+CREATE TABLE share_blocks (
+                        user_id     INTEGER REFERENCES users(user_id) ON DELETE CASCADE NOT NULL,
+                        blocked_id  INTEGER REFERENCES users(user_id) ON DELETE CASCADE NOT NULL,
+                        UNIQUE(user_id, blocked_id)
+);
+
+CREATE TABLE share_auto_accept (
+                        user_id     INTEGER REFERENCES users(user_id) ON DELETE CASCADE NOT NULL,
+                        sender_id   INTEGER REFERENCES users(user_id) ON DELETE CASCADE NOT NULL,
+                        UNIQUE(user_id, sender_id)
+);
@@ -0,0 +1,7 @@
+DROP VIEW audio_access;
+
+DROP TABLE collection_shares;
+
+DROP TABLE collection_items;
+
+DROP TABLE collections;
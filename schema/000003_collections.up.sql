@@ -0,0 +1,33 @@
+CREATE TABLE collections (
+                        collection_id INTEGER PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+                        user_id       INTEGER REFERENCES users(user_id) ON DELETE CASCADE NOT NULL,
+                        title         TEXT NOT NULL
+);
+
+CREATE TABLE collection_items (
+                        collection_id INTEGER REFERENCES collections(collection_id) ON DELETE CASCADE NOT NULL,
+                        audio_id      INTEGER REFERENCES audios(audio_id) ON DELETE CASCADE NOT NULL,
+                        position      INTEGER NOT NULL,
+                        UNIQUE(collection_id, audio_id)
+);
+
+CREATE TABLE collection_shares (
+                        collection_id INTEGER REFERENCES collections(collection_id) ON DELETE CASCADE NOT NULL,
+                        user_id       INTEGER REFERENCES users(user_id) ON DELETE CASCADE NOT NULL,
+                        UNIQUE(collection_id, user_id)
+);
+
+-- Audio is accessible by its owner, by users it is shared with and by users
+-- a collection containing it is shared with, as long as the collection owner
+-- still has access to the audio
+CREATE VIEW audio_access AS
+    SELECT audio_id, user_id FROM audios
+    UNION
+    SELECT audio_id, user_id FROM shares WHERE status = 'accepted'
+    UNION
+    SELECT ci.audio_id, cs.user_id FROM collection_items ci
+    JOIN collections c USING (collection_id)
+    JOIN collection_shares cs USING (collection_id)
+    JOIN audios a ON ci.audio_id = a.audio_id
+    WHERE a.user_id = c.user_id
+    OR EXISTS (SELECT 1 FROM shares s WHERE s.audio_id = ci.audio_id AND s.user_id = c.user_id AND s.status = 'accepted');
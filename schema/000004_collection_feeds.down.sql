@@ -0,0 +1,3 @@
+DROP TABLE collection_feeds;
+
+ALTER TABLE collection_items DROP COLUMN added_at;
@@ -0,0 +1,12 @@
+ALTER TABLE collection_items ADD COLUMN added_at timestamp with time zone NOT NULL DEFAULT now();
+
+CREATE TABLE collection_feeds (
+                        collection_id INTEGER PRIMARY KEY REFERENCES collections(collection_id) ON DELETE CASCADE,
+                        token         uuid UNIQUE NOT NULL,
+                        description   TEXT NOT NULL,
+                        author        TEXT NOT NULL,
+                        image_url     TEXT NOT NULL,
+                        language      TEXT NOT NULL,
+                        category      TEXT NOT NULL,
+                        explicit      BOOLEAN NOT NULL DEFAULT false
+);
@@ -0,0 +1,8 @@
+CREATE TABLE audio_tags (
+                        audio_id INTEGER REFERENCES audios(audio_id) ON DELETE CASCADE NOT NULL,
+                        user_id  INTEGER REFERENCES users(user_id) ON DELETE CASCADE NOT NULL,
+                        tag      VARCHAR(64) NOT NULL,
+                        UNIQUE(audio_id, user_id, tag)
+);
+
+CREATE INDEX audio_tags_tag_idx ON audio_tags (tag varchar_pattern_ops);
@@ -0,0 +1,12 @@
+DROP TRIGGER audio_tags_search_vector ON audio_tags;
+DROP FUNCTION audio_tags_search_vector_update();
+DROP TRIGGER users_search_vector ON users;
+DROP FUNCTION users_search_vector_update();
+DROP TRIGGER audios_search_vector ON audios;
+DROP FUNCTION audios_search_vector_update();
+DROP FUNCTION search_language();
+DROP TABLE search_config;
+
+ALTER TABLE users DROP COLUMN search_vector;
+ALTER TABLE audios DROP COLUMN search_vector;
+ALTER TABLE audios DROP COLUMN description;
@@ -0,0 +1,59 @@
+ALTER TABLE audios ADD COLUMN description TEXT NOT NULL DEFAULT '';
+ALTER TABLE audios ADD COLUMN search_vector tsvector;
+ALTER TABLE users ADD COLUMN search_vector tsvector;
+
+-- Text search configuration used for every search vector and query. It is
+-- synced with the search.language setting on start
+CREATE TABLE search_config (
+                        language regconfig NOT NULL
+);
+
+INSERT INTO search_config VALUES ('english');
+
+CREATE FUNCTION search_language() RETURNS regconfig AS $$
+    SELECT language FROM search_config LIMIT 1
+$$ LANGUAGE sql STABLE;
+
+-- Title, description and owner tags; personal tags are matched at query time
+-- because they are visible only to the user who added them
+CREATE FUNCTION audios_search_vector_update() RETURNS trigger AS $$
+BEGIN
+    NEW.search_vector :=
+        setweight(to_tsvector(search_language(), NEW.title), 'A') ||
+        setweight(to_tsvector(search_language(), NEW.description), 'B') ||
+        setweight(to_tsvector(search_language(), COALESCE((SELECT string_agg(tag, ' ') FROM audio_tags
+            WHERE audio_id = NEW.audio_id AND user_id = NEW.user_id), '')), 'B');
+    RETURN NEW;
+END
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audios_search_vector BEFORE INSERT OR UPDATE ON audios
+    FOR EACH ROW EXECUTE FUNCTION audios_search_vector_update();
+
+CREATE FUNCTION users_search_vector_update() RETURNS trigger AS $$
+BEGIN
+    NEW.search_vector := setweight(to_tsvector(search_language(), NEW.name), 'C');
+    RETURN NEW;
+END
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER users_search_vector BEFORE INSERT OR UPDATE ON users
+    FOR EACH ROW EXECUTE FUNCTION users_search_vector_update();
+
+-- Touching the audio row makes audios_search_vector recompute owner tags
+CREATE FUNCTION audio_tags_search_vector_update() RETURNS trigger AS $$
+BEGIN
+    UPDATE audios SET search_vector = NULL
+    WHERE audio_id = COALESCE(NEW.audio_id, OLD.audio_id) AND user_id = COALESCE(NEW.user_id, OLD.user_id);
+    RETURN NULL;
+END
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audio_tags_search_vector AFTER INSERT OR DELETE ON audio_tags
+    FOR EACH ROW EXECUTE FUNCTION audio_tags_search_vector_update();
+
+UPDATE audios SET search_vector = NULL;
+UPDATE users SET search_vector = NULL;
+
+CREATE INDEX audios_search_vector_idx ON audios USING GIN (search_vector);
+CREATE INDEX users_search_vector_idx ON users USING GIN (search_vector);
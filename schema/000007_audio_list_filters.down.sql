@@ -0,0 +1,4 @@
+ALTER TABLE audios DROP COLUMN format;
+ALTER TABLE audios DROP COLUMN size;
+ALTER TABLE audios DROP COLUMN updated_at;
+ALTER TABLE audios DROP COLUMN created_at;
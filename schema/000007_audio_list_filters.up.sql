@@ -0,0 +1,8 @@
+ALTER TABLE audios ADD COLUMN created_at timestamp with time zone NOT NULL DEFAULT now();
+ALTER TABLE audios ADD COLUMN updated_at timestamp with time zone NOT NULL DEFAULT now();
+-- Size of files uploaded before this migration is unknown
+ALTER TABLE audios ADD COLUMN size BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE audios ADD COLUMN format TEXT NOT NULL DEFAULT 'aac';
+
+CREATE INDEX audios_user_created_idx ON audios (user_id, created_at);
+CREATE INDEX audios_user_updated_idx ON audios (user_id, updated_at);
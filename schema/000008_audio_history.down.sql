@@ -0,0 +1,2 @@
+DROP TABLE audio_history;
+ALTER TABLE audios DROP COLUMN uploaded_by;
@@ -0,0 +1,16 @@
+-- Audio uploaded before this migration was uploaded by its owner
+ALTER TABLE audios ADD COLUMN uploaded_by INTEGER REFERENCES users(user_id);
+UPDATE audios SET uploaded_by = user_id;
+ALTER TABLE audios ALTER COLUMN uploaded_by SET NOT NULL;
+
+CREATE TABLE audio_history (
+                        history_id INTEGER PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+                        audio_id   INTEGER REFERENCES audios(audio_id) ON DELETE CASCADE NOT NULL,
+                        user_id    INTEGER REFERENCES users(user_id) ON DELETE CASCADE NOT NULL,
+                        field      TEXT NOT NULL,
+                        old_value  TEXT NOT NULL,
+                        new_value  TEXT NOT NULL,
+                        changed_at timestamp with time zone NOT NULL DEFAULT now()
+);
+
+CREATE INDEX audio_history_audio_idx ON audio_history (audio_id, changed_at);
@@ -0,0 +1 @@
+ALTER TABLE audios DROP COLUMN version;
@@ -0,0 +1 @@
+ALTER TABLE audios ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
@@ -0,0 +1,8 @@
+DROP TABLE metadata_schemas;
+
+ALTER TABLE audios DROP COLUMN custom;
+ALTER TABLE audios DROP COLUMN location;
+ALTER TABLE audios DROP COLUMN language;
+ALTER TABLE audios DROP COLUMN recorded_at;
+ALTER TABLE audios DROP COLUMN album;
+ALTER TABLE audios DROP COLUMN artist;
@@ -0,0 +1,16 @@
+ALTER TABLE audios ADD COLUMN artist TEXT NOT NULL DEFAULT '';
+ALTER TABLE audios ADD COLUMN album TEXT NOT NULL DEFAULT '';
+ALTER TABLE audios ADD COLUMN recorded_at timestamp with time zone;
+ALTER TABLE audios ADD COLUMN language TEXT NOT NULL DEFAULT '';
+ALTER TABLE audios ADD COLUMN location TEXT NOT NULL DEFAULT '';
+ALTER TABLE audios ADD COLUMN custom JSONB NOT NULL DEFAULT '{}';
+
+CREATE INDEX audios_artist_idx ON audios (lower(artist));
+CREATE INDEX audios_album_idx ON audios (lower(album));
+CREATE INDEX audios_recorded_idx ON audios (recorded_at);
+CREATE INDEX audios_custom_idx ON audios USING GIN (custom);
+
+CREATE TABLE metadata_schemas (
+                        user_id INTEGER REFERENCES users(user_id) ON DELETE CASCADE NOT NULL UNIQUE,
+                        schema  JSONB NOT NULL
+);
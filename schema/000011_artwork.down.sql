@@ -0,0 +1,2 @@
+ALTER TABLE collections DROP COLUMN artwork_id;
+ALTER TABLE audios DROP COLUMN artwork_id;
@@ -0,0 +1,2 @@
+ALTER TABLE audios ADD COLUMN artwork_id UUID;
+ALTER TABLE collections ADD COLUMN artwork_id UUID;
@@ -0,0 +1,18 @@
+-- Top level comments are anchored to a time offset or range of audio in
+-- milliseconds, replies belong to the thread of a top level comment
+CREATE TABLE audio_comments (
+                        comment_id INTEGER PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+                        audio_id   INTEGER REFERENCES audios(audio_id) ON DELETE CASCADE NOT NULL,
+                        user_id    INTEGER REFERENCES users(user_id) ON DELETE CASCADE NOT NULL,
+                        parent_id  INTEGER REFERENCES audio_comments(comment_id) ON DELETE CASCADE,
+                        start_ms   INTEGER CHECK (start_ms >= 0),
+                        end_ms     INTEGER CHECK (end_ms >= start_ms),
+                        text       TEXT NOT NULL,
+                        created_at timestamp with time zone NOT NULL DEFAULT now(),
+                        updated_at timestamp with time zone NOT NULL DEFAULT now(),
+                        CHECK ((parent_id IS NULL) = (start_ms IS NOT NULL)),
+                        CHECK (parent_id IS NULL OR end_ms IS NULL)
+);
+
+CREATE INDEX audio_comments_offset_idx ON audio_comments (audio_id, start_ms, comment_id) WHERE parent_id IS NULL;
+CREATE INDEX audio_comments_parent_idx ON audio_comments (parent_id, created_at);
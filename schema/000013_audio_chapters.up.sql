@@ -0,0 +1,8 @@
+CREATE TABLE audio_chapters (
+                        audio_id    INTEGER REFERENCES audios(audio_id) ON DELETE CASCADE NOT NULL,
+                        start_ms    INTEGER NOT NULL CHECK (start_ms >= 0),
+                        title       TEXT NOT NULL,
+                        url         TEXT NOT NULL DEFAULT '',
+                        image       TEXT NOT NULL DEFAULT '',
+                        PRIMARY KEY (audio_id, start_ms)
+);
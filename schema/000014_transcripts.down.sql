@@ -0,0 +1,2 @@
+DROP TABLE transcript_cues;
+DROP FUNCTION transcript_cues_search_vector_update();
@@ -0,0 +1,21 @@
+CREATE TABLE transcript_cues (
+                        audio_id      INTEGER REFERENCES audios(audio_id) ON DELETE CASCADE NOT NULL,
+                        position      INTEGER NOT NULL,
+                        start_ms      INTEGER NOT NULL CHECK (start_ms >= 0),
+                        end_ms        INTEGER NOT NULL CHECK (end_ms > start_ms),
+                        text          TEXT NOT NULL,
+                        search_vector tsvector,
+                        PRIMARY KEY (audio_id, position)
+);
+
+CREATE FUNCTION transcript_cues_search_vector_update() RETURNS trigger AS $$
+BEGIN
+    NEW.search_vector := setweight(to_tsvector(search_language(), NEW.text), 'D');
+    RETURN NEW;
+END
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER transcript_cues_search_vector BEFORE INSERT OR UPDATE ON transcript_cues
+    FOR EACH ROW EXECUTE FUNCTION transcript_cues_search_vector_update();
+
+CREATE INDEX transcript_cues_search_vector_idx ON transcript_cues USING GIN (search_vector);
@@ -0,0 +1 @@
+DROP TABLE transcription_jobs;
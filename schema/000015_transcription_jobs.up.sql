@@ -0,0 +1,8 @@
+-- State of the last speech-to-text job of audio
+CREATE TABLE transcription_jobs (
+                        audio_id   INTEGER PRIMARY KEY REFERENCES audios(audio_id) ON DELETE CASCADE,
+                        status     TEXT NOT NULL CHECK (status IN ('queued', 'running', 'failed', 'done')),
+                        error      TEXT NOT NULL DEFAULT '',
+                        created_at timestamp with time zone NOT NULL DEFAULT now(),
+                        updated_at timestamp with time zone NOT NULL DEFAULT now()
+);
@@ -0,0 +1,8 @@
+ALTER TABLE users DROP COLUMN is_admin;
+
+ALTER TABLE audios DROP COLUMN checksum;
+ALTER TABLE audios DROP COLUMN bitrate;
+ALTER TABLE audios DROP COLUMN channels;
+ALTER TABLE audios DROP COLUMN sample_rate;
+
+DROP TABLE jobs;
@@ -0,0 +1,26 @@
+CREATE TABLE jobs (
+                        job_id       INTEGER PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+                        type         TEXT NOT NULL CHECK (type IN ('probe', 'remux', 'hls', 'checksum', 'cleanup')),
+                        audio_id     INTEGER REFERENCES audios(audio_id) ON DELETE CASCADE,
+                        status       TEXT NOT NULL DEFAULT 'queued' CHECK (status IN ('queued', 'running', 'done', 'dead')),
+                        attempts     INTEGER NOT NULL DEFAULT 0,
+                        max_attempts INTEGER NOT NULL CHECK (max_attempts > 0),
+                        error        TEXT NOT NULL DEFAULT '',
+                        run_at       timestamp with time zone NOT NULL DEFAULT now(),
+                        locked_until timestamp with time zone,
+                        created_at   timestamp with time zone NOT NULL DEFAULT now(),
+                        updated_at   timestamp with time zone NOT NULL DEFAULT now()
+);
+
+CREATE INDEX jobs_queue_idx ON jobs (run_at, job_id) WHERE status = 'queued';
+CREATE INDEX jobs_audio_idx ON jobs (audio_id, type, job_id);
+CREATE INDEX jobs_status_idx ON jobs (status, job_id);
+-- At most one pending job of every type per audio
+CREATE UNIQUE INDEX jobs_pending_idx ON jobs (type, COALESCE(audio_id, 0)) WHERE status IN ('queued', 'running');
+
+ALTER TABLE audios ADD COLUMN sample_rate INTEGER;
+ALTER TABLE audios ADD COLUMN channels INTEGER;
+ALTER TABLE audios ADD COLUMN bitrate INTEGER;
+ALTER TABLE audios ADD COLUMN checksum TEXT;
+
+ALTER TABLE users ADD COLUMN is_admin BOOLEAN NOT NULL DEFAULT false;
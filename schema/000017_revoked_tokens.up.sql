@@ -0,0 +1,7 @@
+-- Access tokens revoked before they expire, rows are pruned after expiry
+CREATE TABLE revoked_tokens (
+                        jti        uuid PRIMARY KEY,
+                        expires_at timestamp with time zone NOT NULL
+);
+
+CREATE INDEX revoked_tokens_expires_idx ON revoked_tokens (expires_at);
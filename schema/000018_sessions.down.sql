@@ -0,0 +1,8 @@
+-- refresh tokens can't be restored from hashes, users have to sign in again
+CREATE TABLE refresh_tokens (
+                        user_id     INTEGER REFERENCES users(user_id) ON DELETE CASCADE NOT NULL UNIQUE,
+                        refresh_token uuid NOT NULL,
+                        expires_in  timestamp  with time zone NOT NULL
+);
+
+DROP TABLE sessions;
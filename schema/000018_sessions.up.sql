@@ -0,0 +1,20 @@
+-- Every sign-in opens its own session, only sha256 of refresh token is stored
+CREATE TABLE sessions (
+                        session_id   INTEGER PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+                        user_id      INTEGER REFERENCES users(user_id) ON DELETE CASCADE NOT NULL,
+                        token_hash   text NOT NULL UNIQUE,
+                        device_name  text NOT NULL DEFAULT '',
+                        user_agent   text NOT NULL DEFAULT '',
+                        ip           text NOT NULL DEFAULT '',
+                        created_at   timestamp with time zone NOT NULL DEFAULT now(),
+                        last_used_at timestamp with time zone NOT NULL DEFAULT now(),
+                        expires_at   timestamp with time zone NOT NULL
+);
+
+CREATE INDEX sessions_user_idx ON sessions (user_id);
+
+INSERT INTO sessions (user_id, token_hash, expires_at)
+    SELECT user_id, encode(digest(refresh_token::text, 'sha256'), 'hex'), expires_in
+    FROM refresh_tokens WHERE expires_in > now();
+
+DROP TABLE refresh_tokens;
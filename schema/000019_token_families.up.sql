@@ -0,0 +1,23 @@
+-- Refresh tokens replaced by rotation, every one links to the token which
+-- replaced it. Presenting one of them again revokes the whole session
+CREATE TABLE rotated_tokens (
+                        token_hash  text PRIMARY KEY,
+                        session_id  INTEGER REFERENCES sessions(session_id) ON DELETE CASCADE NOT NULL,
+                        replaced_by text NOT NULL,
+                        rotated_at  timestamp with time zone NOT NULL DEFAULT now()
+);
+
+CREATE INDEX rotated_tokens_session_idx ON rotated_tokens (session_id);
+
+CREATE TABLE security_events (
+                        event_id    INTEGER PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+                        user_id     INTEGER REFERENCES users(user_id) ON DELETE CASCADE NOT NULL,
+                        type        text NOT NULL,
+                        session_id  INTEGER,
+                        device_name text NOT NULL DEFAULT '',
+                        user_agent  text NOT NULL DEFAULT '',
+                        ip          text NOT NULL DEFAULT '',
+                        created_at  timestamp with time zone NOT NULL DEFAULT now()
+);
+
+CREATE INDEX security_events_user_idx ON security_events (user_id, event_id);
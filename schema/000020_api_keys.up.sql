@@ -0,0 +1,14 @@
+-- Personal access tokens, only sha256 of key is stored
+CREATE TABLE api_keys (
+                        api_key_id   INTEGER PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+                        user_id      INTEGER REFERENCES users(user_id) ON DELETE CASCADE NOT NULL,
+                        name         text NOT NULL,
+                        prefix       text NOT NULL,
+                        key_hash     text NOT NULL UNIQUE,
+                        scopes       text[] NOT NULL,
+                        created_at   timestamp with time zone NOT NULL DEFAULT now(),
+                        expires_at   timestamp with time zone,
+                        last_used_at timestamp with time zone
+);
+
+CREATE INDEX api_keys_user_idx ON api_keys (user_id);
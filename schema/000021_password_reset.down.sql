@@ -0,0 +1,3 @@
+DROP TABLE password_reset_tokens;
+
+ALTER TABLE users DROP COLUMN email;
@@ -0,0 +1,12 @@
+-- Password reset tokens are sent to email, users without one can't reset password
+ALTER TABLE users ADD COLUMN email text UNIQUE;
+
+CREATE TABLE password_reset_tokens (
+                        token_hash  text PRIMARY KEY,
+                        user_id     INTEGER REFERENCES users(user_id) ON DELETE CASCADE NOT NULL,
+                        created_at  timestamp with time zone NOT NULL DEFAULT now(),
+                        expires_at  timestamp with time zone NOT NULL,
+                        used_at     timestamp with time zone
+);
+
+CREATE INDEX password_reset_tokens_user_idx ON password_reset_tokens (user_id);
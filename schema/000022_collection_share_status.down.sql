@@ -0,0 +1,13 @@
+CREATE OR REPLACE VIEW audio_access AS
+    SELECT audio_id, user_id FROM audios
+    UNION
+    SELECT audio_id, user_id FROM shares WHERE status = 'accepted'
+    UNION
+    SELECT ci.audio_id, cs.user_id FROM collection_items ci
+    JOIN collections c USING (collection_id)
+    JOIN collection_shares cs USING (collection_id)
+    JOIN audios a ON ci.audio_id = a.audio_id
+    WHERE a.user_id = c.user_id
+    OR EXISTS (SELECT 1 FROM shares s WHERE s.audio_id = ci.audio_id AND s.user_id = c.user_id AND s.status = 'accepted');
+
+ALTER TABLE collection_shares DROP COLUMN status;
@@ -0,0 +1,18 @@
+-- Collection shares wait for accept like audio shares, existing ones stay accepted
+ALTER TABLE collection_shares ADD COLUMN status TEXT NOT NULL DEFAULT 'accepted'
+    CHECK (status IN ('pending', 'accepted', 'declined'));
+
+ALTER TABLE collection_shares ALTER COLUMN status SET DEFAULT 'pending';
+
+CREATE OR REPLACE VIEW audio_access AS
+    SELECT audio_id, user_id FROM audios
+    UNION
+    SELECT audio_id, user_id FROM shares WHERE status = 'accepted'
+    UNION
+    SELECT ci.audio_id, cs.user_id FROM collection_items ci
+    JOIN collections c USING (collection_id)
+    JOIN collection_shares cs USING (collection_id)
+    JOIN audios a ON ci.audio_id = a.audio_id
+    WHERE cs.status = 'accepted'
+    AND (a.user_id = c.user_id
+    OR EXISTS (SELECT 1 FROM shares s WHERE s.audio_id = ci.audio_id AND s.user_id = c.user_id AND s.status = 'accepted'));
@@ -0,0 +1,2 @@
+-- Fences writes of a worker whose lease expired and whose job was claimed again
+ALTER TABLE jobs ADD COLUMN claim_token uuid;
@@ -0,0 +1,5 @@
+-- Speech-to-text runs as a job of the jobs queue
+ALTER TABLE jobs DROP CONSTRAINT jobs_type_check;
+ALTER TABLE jobs ADD CONSTRAINT jobs_type_check CHECK (type IN ('probe', 'remux', 'hls', 'checksum', 'cleanup', 'transcribe'));
+
+DROP TABLE transcription_jobs;
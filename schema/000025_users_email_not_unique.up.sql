@@ -0,0 +1,3 @@
+-- Reset is requested by username, unique email would let sign-up tell whether
+-- an email is registered
+ALTER TABLE users DROP CONSTRAINT users_email_key;
@@ -0,0 +1,3 @@
+-- Access tokens of user issued before are revoked, it is set when sessions
+-- are closed or password changes
+ALTER TABLE users ADD COLUMN tokens_valid_after timestamp with time zone;
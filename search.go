@@ -0,0 +1,55 @@
+package storage
+
+import (
+	"html"
+	"strings"
+)
+
+// Snippet highlight markers returned by the database, replaced with <mark>
+// after escaping so that titles can't inject markup
+const (
+	SnippetStartSel = "\x02"
+	SnippetStopSel  = "\x03"
+)
+
+type SearchParam struct {
+	Query  string `json:"q" form:"q" binding:"required,max=256"`
+	Limit  *int   `json:"limit" form:"limit" binding:"required"`
+	Offset *int   `json:"offset" form:"offset" binding:"required"`
+}
+
+type SearchResult struct {
+	Id      int     `json:"id" db:"audio_id"`
+	Title   string  `json:"name" db:"title"`
+	IsOwner bool    `json:"is_owner" db:"is_owner"`
+	Owner   int     `json:"owner_id" db:"user_id"`
+	Name    string  `json:"owner_name" db:"name"`
+	Rank    float64 `json:"rank" db:"rank"`
+	Snippet string  `json:"snippet" db:"snippet"`
+	// Cue is the best matching transcript cue if transcript matches
+	Cue *SearchCue `json:"cue,omitempty" db:"-"`
+}
+
+type SearchCue struct {
+	StartMs int    `json:"start_ms"`
+	EndMs   int    `json:"end_ms"`
+	Snippet string `json:"snippet"`
+}
+
+type SearchResultDb struct {
+	Count      int     `db:"full_count"`
+	CueStartMs *int    `db:"cue_start_ms"`
+	CueEndMs   *int    `db:"cue_end_ms"`
+	CueSnippet *string `db:"cue_snippet"`
+	SearchResult
+}
+
+type SearchResultJson struct {
+	TotalCount int            `json:"total_count"`
+	Records    []SearchResult `json:"records"`
+}
+
+// HighlightSnippet escapes snippet and wraps matched words in <mark> tags
+func HighlightSnippet(snippet string) string {
+	return strings.NewReplacer(SnippetStartSel, "<mark>", SnippetStopSel, "</mark>").Replace(html.EscapeString(snippet))
+}
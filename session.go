@@ -0,0 +1,48 @@
+package storage
+
+import "time"
+
+// SessionClient describes device session is used from
+type SessionClient struct {
+	DeviceName string `json:"device_name" db:"device_name"`
+	UserAgent  string `json:"user_agent" db:"user_agent"`
+	IP         string `json:"ip" db:"ip"`
+}
+
+type Session struct {
+	Id     int `json:"id" db:"session_id"`
+	UserId int `json:"-" db:"user_id"`
+	SessionClient
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at" db:"last_used_at"`
+	ExpiresAt  time.Time `json:"expires_at" db:"expires_at"`
+}
+
+// SecurityRefreshTokenReused is recorded when rotated refresh token is
+// presented again, the token was likely stolen and its session is revoked
+const SecurityRefreshTokenReused = "refresh_token_reused"
+
+// SecurityEvent is suspicious activity on account, client is the one which
+// caused the event and device name is of the affected session
+type SecurityEvent struct {
+	Id        int    `json:"id" db:"event_id"`
+	Type      string `json:"type" db:"type"`
+	SessionId *int   `json:"session_id" db:"session_id"`
+	SessionClient
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+type SecurityEventListParam struct {
+	Limit  *int `json:"limit" form:"limit" binding:"required,min=1"`
+	Offset *int `json:"offset" form:"offset" binding:"required,min=0"`
+}
+
+type SecurityEventDb struct {
+	Count int `db:"full_count"`
+	SecurityEvent
+}
+
+type SecurityEventListJson struct {
+	TotalCount int             `json:"total_count"`
+	Records    []SecurityEvent `json:"records"`
+}
@@ -1,12 +1,24 @@
 package storage
 
+import "errors"
+
 type ShareInput struct {
 	ShareTo int `json:"share_to" binding:"required"`
 }
 
 type ShareListParam struct {
-	Limit  *int `json:"limit" form:"limit" binding:"required"`
-	Offset *int `json:"offset" form:"offset" binding:"required"`
+	Limit     *int   `json:"limit" form:"limit" binding:"required,min=1,max=1000"`
+	Offset    *int   `json:"offset" form:"offset" binding:"omitempty,min=0"`
+	Cursor    string `json:"cursor" form:"cursor"`
+	WithCount bool   `json:"with_count" form:"with_count"`
+}
+
+func (p ShareListParam) Validate() error {
+	if p.Offset != nil && (p.Cursor != "" || p.WithCount) {
+		return errors.New("offset can't be used with cursor or with_count")
+	}
+
+	return nil
 }
 
 type ShareListCount struct {
@@ -24,3 +36,12 @@ type ShareListJson struct {
 	Count int              `json:"total_count"`
 	Users []ShareListCount `json:"users"`
 }
+
+// SharePageJson is a page of share list in cursor mode, total count is
+// returned only on request
+type SharePageJson struct {
+	Count      *int             `json:"total_count,omitempty"`
+	Users      []ShareListCount `json:"users"`
+	NextCursor string           `json:"next_cursor,omitempty"`
+	PrevCursor string           `json:"prev_cursor,omitempty"`
+}
@@ -0,0 +1,62 @@
+package storage
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	MaxTagLength = 64
+	TagModeAnd   = "and"
+	TagModeOr    = "or"
+)
+
+type TagsInput struct {
+	Tags []string `json:"tags" binding:"required,min=1,max=20"`
+}
+
+type AudioTag struct {
+	Name     string `json:"name" db:"tag"`
+	Personal bool   `json:"personal" db:"personal"`
+}
+
+type TagListParam struct {
+	Prefix string `json:"prefix" form:"prefix"`
+	Limit  *int   `json:"limit" form:"limit" binding:"required,min=1"`
+}
+
+type TagCount struct {
+	Name  string `json:"name" db:"tag"`
+	Count int    `json:"count" db:"count"`
+}
+
+// NormalizeTag trims and lowercases tag so that "Jazz " and "jazz" are the same tag
+func NormalizeTag(tag string) (string, error) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+
+	if tag == "" || utf8.RuneCountInString(tag) > MaxTagLength {
+		return "", InvalidTag
+	}
+
+	return tag, nil
+}
+
+// NormalizeTags normalizes every tag and drops duplicates
+func NormalizeTags(tags []string) ([]string, error) {
+	out := make([]string, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+
+	for _, tag := range tags {
+		tag, err := NormalizeTag(tag)
+		if err != nil {
+			return nil, err
+		}
+
+		if !seen[tag] {
+			seen[tag] = true
+			out = append(out, tag)
+		}
+	}
+
+	return out, nil
+}
@@ -0,0 +1,272 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	MaxTranscriptUploadSize = 10 << 20
+	MaxTranscriptCues       = 50000
+	MaxCueLength            = 4096
+	TranscriptFormatJson    = "json"
+	TranscriptFormatVTT     = "webvtt"
+	TranscriptFormatSRT     = "srt"
+	maxCueHours             = 500
+)
+
+var (
+	// cueTimestamp matches [hh:]mm:ss.ttt of WebVTT and hh:mm:ss,ttt of SRT
+	cueTimestamp = regexp.MustCompile(`^(?:(\d+):)?([0-5]\d):([0-5]\d)[.,](\d{3})$`)
+	cueTag       = regexp.MustCompile(`<[^>]*>`)
+	vttEscaper   = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+)
+
+type TranscriptCue struct {
+	StartMs int    `json:"start_ms" db:"start_ms"`
+	EndMs   int    `json:"end_ms" db:"end_ms"`
+	Text    string `json:"text" db:"text"`
+}
+
+// Transcript is a list of cues ordered by start
+type Transcript []TranscriptCue
+
+type TranscriptParam struct {
+	Format string `form:"format" binding:"omitempty,oneof=json webvtt srt"`
+}
+
+type TranscriptJson struct {
+	Cues Transcript `json:"cues"`
+}
+
+// ParseTranscript parses WebVTT or SRT file. Markup is removed, cue text is
+// trimmed, empty cues are dropped and cues are ordered by start
+func ParseTranscript(data []byte) (Transcript, error) {
+	if !utf8.Valid(data) {
+		return nil, fmt.Errorf("%w: file must be UTF-8 encoded", InvalidTranscript)
+	}
+
+	text := strings.TrimPrefix(string(data), "\uFEFF")
+	text = strings.NewReplacer("\r\n", "\n", "\r", "\n").Replace(text)
+	lines := strings.Split(text, "\n")
+
+	vtt := lines[0] == "WEBVTT" || strings.HasPrefix(lines[0], "WEBVTT ") || strings.HasPrefix(lines[0], "WEBVTT\t")
+
+	transcript := make(Transcript, 0)
+	for start := 0; start < len(lines); {
+		if strings.TrimSpace(lines[start]) == "" {
+			start++
+			continue
+		}
+
+		end := start
+		for end < len(lines) && strings.TrimSpace(lines[end]) != "" {
+			end++
+		}
+		block := lines[start:end]
+		line := start + 1
+		start = end
+
+		if vtt && (line == 1 || isVTTMetadata(block[0])) {
+			continue
+		}
+
+		cue, ok, err := parseCue(block, vtt)
+		if err != nil {
+			return nil, fmt.Errorf("%w: cue at line %d: %s", InvalidTranscript, line, err)
+		}
+		if ok {
+			transcript = append(transcript, cue)
+		}
+	}
+
+	return sortCues(transcript)
+}
+
+// transcriptJson is transcript in format of TranscriptJson or segments in
+// seconds as written by whisper like engines
+type transcriptJson struct {
+	Cues     []TranscriptCue `json:"cues"`
+	Segments []struct {
+		Start float64 `json:"start"`
+		End   float64 `json:"end"`
+		Text  string  `json:"text"`
+	} `json:"segments"`
+}
+
+// ParseTranscriptJson parses JSON with cues in milliseconds or segments in
+// seconds and normalizes it the same way as ParseTranscript
+func ParseTranscriptJson(data []byte) (Transcript, error) {
+	var input transcriptJson
+	if err := json.Unmarshal(data, &input); err != nil {
+		return nil, fmt.Errorf("%w: %s", InvalidTranscript, err)
+	}
+
+	cues := input.Cues
+	for _, segment := range input.Segments {
+		cues = append(cues, TranscriptCue{
+			StartMs: int(math.Round(segment.Start * 1000)),
+			EndMs:   int(math.Round(segment.End * 1000)),
+			Text:    segment.Text,
+		})
+	}
+
+	transcript := make(Transcript, 0, len(cues))
+	for i, cue := range cues {
+		if cue.StartMs < 0 || cue.EndMs <= cue.StartMs || cue.EndMs > maxCueHours*3600000 {
+			return nil, fmt.Errorf("%w: cue %d: end time must be after start time", InvalidTranscript, i+1)
+		}
+
+		lines := make([]string, 0)
+		for _, line := range strings.Split(cue.Text, "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				lines = append(lines, line)
+			}
+		}
+		if cue.Text = strings.Join(lines, "\n"); cue.Text == "" {
+			continue
+		}
+		if utf8.RuneCountInString(cue.Text) > MaxCueLength {
+			return nil, fmt.Errorf("%w: cue %d: text must be up to %d characters", InvalidTranscript, i+1, MaxCueLength)
+		}
+
+		transcript = append(transcript, cue)
+	}
+
+	return sortCues(transcript)
+}
+
+// WebVTT returns transcript as WebVTT file
+func (t Transcript) WebVTT() string {
+	var b strings.Builder
+	b.WriteString("WEBVTT\n")
+
+	for _, cue := range t {
+		fmt.Fprintf(&b, "\n%s --> %s\n%s\n", formatTimestamp(cue.StartMs), formatTimestamp(cue.EndMs), vttEscaper.Replace(cue.Text))
+	}
+
+	return b.String()
+}
+
+// SRT returns transcript as SubRip file
+func (t Transcript) SRT() string {
+	var b strings.Builder
+
+	for i, cue := range t {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "%d\n%s --> %s\n%s\n", i+1, formatSRTTimestamp(cue.StartMs), formatSRTTimestamp(cue.EndMs),
+			strings.ReplaceAll(cue.Text, "-->", "->"))
+	}
+
+	return b.String()
+}
+
+// parseCue parses block of optional identifier, timing line and text lines.
+// It returns false for cues without text
+func parseCue(block []string, vtt bool) (TranscriptCue, bool, error) {
+	timing := 0
+	if !strings.Contains(block[0], "-->") {
+		timing = 1
+	}
+	if timing >= len(block) || !strings.Contains(block[timing], "-->") {
+		return TranscriptCue{}, false, fmt.Errorf("timing line is missing")
+	}
+
+	times := strings.SplitN(block[timing], "-->", 2)
+	// WebVTT allows cue settings after end time
+	settings := strings.Fields(times[1])
+	if len(settings) == 0 {
+		return TranscriptCue{}, false, fmt.Errorf("end time is missing")
+	}
+
+	var cue TranscriptCue
+	var err error
+	if cue.StartMs, err = parseCueTimestamp(strings.TrimSpace(times[0])); err != nil {
+		return cue, false, err
+	}
+	if cue.EndMs, err = parseCueTimestamp(settings[0]); err != nil {
+		return cue, false, err
+	}
+	if cue.EndMs <= cue.StartMs {
+		return cue, false, fmt.Errorf("end time must be after start time")
+	}
+
+	text := make([]string, 0, len(block)-timing-1)
+	for _, line := range block[timing+1:] {
+		line = cueTag.ReplaceAllString(line, "")
+		if vtt {
+			line = html.UnescapeString(line)
+		}
+		if line = strings.TrimSpace(line); line != "" {
+			text = append(text, line)
+		}
+	}
+
+	cue.Text = strings.Join(text, "\n")
+	if utf8.RuneCountInString(cue.Text) > MaxCueLength {
+		return cue, false, fmt.Errorf("text must be up to %d characters", MaxCueLength)
+	}
+
+	return cue, cue.Text != "", nil
+}
+
+func parseCueTimestamp(value string) (int, error) {
+	parts := cueTimestamp.FindStringSubmatch(value)
+	if parts == nil {
+		return 0, fmt.Errorf("invalid timestamp %q", value)
+	}
+
+	hours := 0
+	if parts[1] != "" {
+		var err error
+		// cue offsets are stored as milliseconds in integer column
+		if hours, err = strconv.Atoi(parts[1]); err != nil || hours > maxCueHours {
+			return 0, fmt.Errorf("invalid timestamp %q", value)
+		}
+	}
+	minutes, _ := strconv.Atoi(parts[2])
+	seconds, _ := strconv.Atoi(parts[3])
+	ms, _ := strconv.Atoi(parts[4])
+
+	return ((hours*60+minutes)*60+seconds)*1000 + ms, nil
+}
+
+// isVTTMetadata reports if WebVTT block is a comment, style or region definition
+func isVTTMetadata(line string) bool {
+	for _, name := range []string{"NOTE", "STYLE", "REGION"} {
+		if line == name || strings.HasPrefix(line, name+" ") || strings.HasPrefix(line, name+"\t") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// sortCues checks number of cues and orders them by start
+func sortCues(transcript Transcript) (Transcript, error) {
+	if len(transcript) == 0 {
+		return nil, fmt.Errorf("%w: file has no cues", InvalidTranscript)
+	}
+	if len(transcript) > MaxTranscriptCues {
+		return nil, fmt.Errorf("%w: file can't have more than %d cues", InvalidTranscript, MaxTranscriptCues)
+	}
+
+	sort.SliceStable(transcript, func(i, j int) bool {
+		return transcript[i].StartMs < transcript[j].StartMs
+	})
+
+	return transcript, nil
+}
+
+func formatSRTTimestamp(ms int) string {
+	return strings.Replace(formatTimestamp(ms), ".", ",", 1)
+}
@@ -0,0 +1,19 @@
+package storage
+
+import "time"
+
+const (
+	TranscriptionQueued  = "queued"
+	TranscriptionRunning = "running"
+	TranscriptionFailed  = "failed"
+	TranscriptionDone    = "done"
+)
+
+// TranscriptionJob is state of the last speech-to-text job of audio
+type TranscriptionJob struct {
+	AudioId   int       `json:"audio_id" db:"audio_id"`
+	Status    string    `json:"status" db:"status" enums:"queued,running,failed,done"`
+	Error     string    `json:"error,omitempty" db:"error"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
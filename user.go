@@ -5,4 +5,32 @@ type User struct {
 	Name     string `json:"name" binding:"required"`
 	Username string `json:"username" binding:"required"`
 	Password string `json:"password" binding:"required"`
+	// Email is optional, password reset tokens are sent to it
+	Email string `json:"email" binding:"omitempty,email,max=254"`
+}
+
+// ChangePasswordInput changes password of signed in user. Other sessions are
+// closed, the one of RefreshToken is kept if it is given. API keys are kept
+// unless RevokeAPIKeys is set
+type ChangePasswordInput struct {
+	OldPassword   string `json:"old_password" binding:"required"`
+	NewPassword   string `json:"new_password" binding:"required,min=8,max=72"`
+	RefreshToken  string `json:"refresh_token"`
+	RevokeAPIKeys bool   `json:"revoke_api_keys"`
+}
+
+// EmailInput sets email of signed in user, empty email removes it. Password
+// is required so that stolen access token can't redirect reset tokens
+type EmailInput struct {
+	Password string `json:"password" binding:"required"`
+	Email    string `json:"email" binding:"omitempty,email,max=254"`
+}
+
+type PasswordResetRequest struct {
+	Username string `json:"username" binding:"required"`
+}
+
+type ResetPasswordInput struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required,min=8,max=72"`
 }